@@ -0,0 +1,88 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/pborman/uuid"
+)
+
+const (
+	// ActionCreate is the action of POST requests.
+	ActionCreate = "create"
+
+	// ActionUpdate is the action of PUT and PATCH requests.
+	ActionUpdate = "update"
+
+	// ActionDelete is the action of DELETE requests.
+	ActionDelete = "delete"
+
+	// ActionLockout is the action of entries recording that a client was locked out after too many failed
+	// authentication attempts.
+	ActionLockout = "lockout"
+)
+
+// Entry records a single administrative change or client lockout.
+type Entry struct {
+	// ID is a unique id of the entry.
+	ID string `json:"id"`
+
+	// Time is when the request was received.
+	Time time.Time `json:"time"`
+
+	// Subject is the subject of the access token the request was made with, if it is valid.
+	Subject string `json:"subject,omitempty"`
+
+	// ClientID is the id of the client the access token was issued to, if it is valid.
+	ClientID string `json:"client_id,omitempty"`
+
+	// Resource is the path of the request, for example /clients/my-client.
+	Resource string `json:"resource"`
+
+	// Action is one of create, update, delete or lockout.
+	Action string `json:"action"`
+
+	// Method is the HTTP method of the request.
+	Method string `json:"method"`
+
+	// Status is the HTTP status code of the response.
+	Status int `json:"status"`
+
+	// BodyHash is the hex encoded SHA-256 hash of the request body. The body itself is not recorded because it may
+	// contain secrets.
+	BodyHash string `json:"body_sha256,omitempty"`
+
+	// RemoteAddr is the network address of the caller.
+	RemoteAddr string `json:"remote_addr"`
+
+	// RequestID is the value of the X-Request-ID header, if set.
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// NewEntry returns an entry of request r with the given action. The status defaults to 200.
+func NewEntry(r *http.Request, action string) *Entry {
+	return &Entry{
+		ID:         uuid.New(),
+		Time:       time.Now().UTC(),
+		Resource:   r.URL.Path,
+		Action:     action,
+		Method:     r.Method,
+		Status:     http.StatusOK,
+		RemoteAddr: r.RemoteAddr,
+		RequestID:  r.Header.Get("X-Request-ID"),
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/negroni"
+)
+
+var actions = map[string]string{
+	"POST":   ActionCreate,
+	"PUT":    ActionUpdate,
+	"PATCH":  ActionUpdate,
+	"DELETE": ActionDelete,
+}
+
+// Middleware writes an Entry to Sink for every create, update and delete request to a path starting with one of
+// Prefixes. Entries are written after the request was handled and include requests which failed.
+type Middleware struct {
+	Sink     Sink
+	Prefixes []string
+	L        logrus.FieldLogger
+
+	// Identify returns the subject and client id of the access token a request was made with. It returns empty
+	// strings if the token is invalid.
+	Identify func(r *http.Request) (subject, clientID string)
+}
+
+func (m *Middleware) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	action, ok := actions[r.Method]
+	if !ok || !m.audited(r.URL.Path) {
+		next(rw, r)
+		return
+	}
+
+	e := NewEntry(r, action)
+
+	if r.Body != nil {
+		body, err := ioutil.ReadAll(r.Body)
+		r.Body.Close()
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		if err != nil {
+			m.L.WithError(err).WithField("id", e.ID).Warnln("Could not read request body, the audit log entry will not contain its hash")
+		} else if len(body) > 0 {
+			hash := sha256.Sum256(body)
+			e.BodyHash = hex.EncodeToString(hash[:])
+		}
+	}
+
+	if m.Identify != nil {
+		e.Subject, e.ClientID = m.Identify(r)
+	}
+
+	next(rw, r)
+
+	if res, ok := rw.(negroni.ResponseWriter); ok && res.Status() != 0 {
+		e.Status = res.Status()
+	}
+
+	if err := m.Sink.Write(e); err != nil {
+		m.L.WithError(err).WithField("entry", e).Errorln("Could not write audit log entry")
+	}
+}
+
+func (m *Middleware) audited(path string) bool {
+	for _, prefix := range m.Prefixes {
+		if path == prefix || strings.HasPrefix(path, strings.TrimRight(prefix, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/negroni"
+)
+
+type memorySink []*Entry
+
+func (s *memorySink) Write(e *Entry) error {
+	*s = append(*s, e)
+	return nil
+}
+
+func TestMiddleware(t *testing.T) {
+	sink := new(memorySink)
+	m := &Middleware{
+		Sink:     sink,
+		Prefixes: []string{"/clients", "/keys"},
+		L:        logrus.New(),
+		Identify: func(r *http.Request) (string, string) {
+			return "peter", "my-client"
+		},
+	}
+
+	var received string
+	n := negroni.New()
+	n.Use(m)
+	n.UseHandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		received = string(body)
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	for k, tc := range []struct {
+		method  string
+		path    string
+		audited bool
+		action  string
+	}{
+		{method: "GET", path: "/clients", audited: false},
+		{method: "POST", path: "/clients", audited: true, action: ActionCreate},
+		{method: "PUT", path: "/clients/foo", audited: true, action: ActionUpdate},
+		{method: "DELETE", path: "/keys/foo/bar", audited: true, action: ActionDelete},
+		{method: "POST", path: "/keysets", audited: false},
+		{method: "POST", path: "/policies", audited: false},
+	} {
+		*sink = nil
+		body := `{"client_id":"foo"}`
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(body))
+		req.Header.Set("X-Request-ID", "request-1")
+		n.ServeHTTP(httptest.NewRecorder(), req)
+		assert.Equal(t, body, received, "%d", k)
+
+		if !tc.audited {
+			assert.Len(t, *sink, 0, "%d", k)
+			continue
+		}
+
+		require.Len(t, *sink, 1, "%d", k)
+		e := (*sink)[0]
+		hash := sha256.Sum256([]byte(body))
+		assert.NotEmpty(t, e.ID)
+		assert.Equal(t, "peter", e.Subject)
+		assert.Equal(t, "my-client", e.ClientID)
+		assert.Equal(t, tc.path, e.Resource)
+		assert.Equal(t, tc.action, e.Action)
+		assert.Equal(t, tc.method, e.Method)
+		assert.Equal(t, http.StatusCreated, e.Status)
+		assert.Equal(t, hex.EncodeToString(hash[:]), e.BodyHash)
+		assert.Equal(t, "request-1", e.RequestID)
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Sink stores audit log entries.
+type Sink interface {
+	Write(e *Entry) error
+}
+
+// NewSink returns the sink configured by rawurl:
+//
+//  stdout:                                      writes JSON lines to the standard output
+//  file:///var/log/hydra/audit.log              appends JSON lines to a file
+//  syslog:///                                   sends entries to the local syslog daemon
+//  syslog://logs.myapp.com:514?network=tcp      sends entries to a remote syslog daemon, network defaults to udp
+//  https://audit.myapp.com/hydra                sends entries as JSON in a POST request
+//  kafka://broker:9092/topic?broker=other:9092  produces entries to a Kafka topic
+//
+// Webhooks are called using client.
+func NewSink(rawurl string, client *http.Client) (Sink, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	switch u.Scheme {
+	case "stdout":
+		return &WriterSink{W: os.Stdout}, nil
+	case "file":
+		f, err := os.OpenFile(u.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return &WriterSink{W: f}, nil
+	case "syslog":
+		return NewSyslogSink(u)
+	case "http", "https":
+		return &WebhookSink{URL: rawurl, Client: client}, nil
+	case "kafka":
+		return NewKafkaSink(u)
+	}
+	return nil, errors.Errorf("Unknown audit log sink scheme %s", u.Scheme)
+}
+
+// MultiSink writes entries to all of its sinks.
+type MultiSink []Sink
+
+func (s MultiSink) Write(e *Entry) error {
+	var failed []string
+	for _, sink := range s {
+		if err := sink.Write(e); err != nil {
+			failed = append(failed, err.Error())
+		}
+	}
+
+	if len(failed) > 0 {
+		return errors.Errorf("Could not write to %d of %d audit log sinks: %s", len(failed), len(s), strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// WriterSink writes entries as JSON lines to W.
+type WriterSink struct {
+	W io.Writer
+	sync.Mutex
+}
+
+func (s *WriterSink) Write(e *Entry) error {
+	out, err := json.Marshal(e)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	s.Lock()
+	defer s.Unlock()
+	if _, err := s.W.Write(append(out, '\n')); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// WebhookSink sends entries as JSON in a POST request to URL.
+type WebhookSink struct {
+	URL    string
+	Client *http.Client
+}
+
+func (s *WebhookSink) Write(e *Entry) error {
+	out, err := json.Marshal(e)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	c := s.Client
+	if c == nil {
+		c = http.DefaultClient
+	}
+
+	res, err := c.Post(s.URL, "application/json", bytes.NewReader(out))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return errors.Errorf("Expected 2xx status code from audit log webhook but got %d", res.StatusCode)
+	}
+	return nil
+}
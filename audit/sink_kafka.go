@@ -0,0 +1,69 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"github.com/Shopify/sarama"
+	"github.com/pkg/errors"
+)
+
+// KafkaSink produces entries as JSON to a Kafka topic, keyed by the entry id. Writes wait until all in-sync replicas
+// acknowledged the message.
+type KafkaSink struct {
+	Topic    string
+	Producer sarama.SyncProducer
+}
+
+// NewKafkaSink connects to the broker at u.Host and the brokers given as broker query parameters. The topic is the
+// path of u.
+func NewKafkaSink(u *url.URL) (*KafkaSink, error) {
+	topic := strings.Trim(u.Path, "/")
+	if topic == "" {
+		return nil, errors.New("Kafka audit log sink requires a topic, for example kafka://broker:9092/hydra-audit")
+	}
+
+	brokers := append([]string{u.Host}, u.Query()["broker"]...)
+
+	conf := sarama.NewConfig()
+	conf.ClientID = "hydra"
+	conf.Producer.RequiredAcks = sarama.WaitForAll
+	conf.Producer.Return.Successes = true
+
+	producer, err := sarama.NewSyncProducer(brokers, conf)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &KafkaSink{Topic: topic, Producer: producer}, nil
+}
+
+func (s *KafkaSink) Write(e *Entry) error {
+	out, err := json.Marshal(e)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if _, _, err := s.Producer.SendMessage(&sarama.ProducerMessage{
+		Topic: s.Topic,
+		Key:   sarama.StringEncoder(e.ID),
+		Value: sarama.ByteEncoder(out),
+	}); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows,!nacl,!plan9
+
+package audit
+
+import (
+	"encoding/json"
+	"log/syslog"
+	"net/url"
+
+	"github.com/pkg/errors"
+)
+
+// SyslogSink sends entries as JSON to a syslog daemon using the auth facility.
+type SyslogSink struct {
+	w *syslog.Writer
+}
+
+// NewSyslogSink connects to the local syslog daemon if u has no host, or to the daemon at u.Host otherwise. The
+// query parameters network (defaults to udp) and tag (defaults to hydra) are supported.
+func NewSyslogSink(u *url.URL) (*SyslogSink, error) {
+	network, addr := "", ""
+	if u.Host != "" {
+		network, addr = u.Query().Get("network"), u.Host
+		if network == "" {
+			network = "udp"
+		}
+	}
+
+	tag := u.Query().Get("tag")
+	if tag == "" {
+		tag = "hydra"
+	}
+
+	w, err := syslog.Dial(network, addr, syslog.LOG_INFO|syslog.LOG_AUTH, tag)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &SyslogSink{w: w}, nil
+}
+
+func (s *SyslogSink) Write(e *Entry) error {
+	out, err := json.Marshal(e)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(s.w.Info(string(out)))
+}
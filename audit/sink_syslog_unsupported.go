@@ -0,0 +1,34 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build windows nacl plan9
+
+package audit
+
+import (
+	"net/url"
+
+	"github.com/pkg/errors"
+)
+
+// SyslogSink is not supported on this platform.
+type SyslogSink struct{}
+
+func NewSyslogSink(u *url.URL) (*SyslogSink, error) {
+	return nil, errors.New("Syslog audit log sinks are not supported on this platform")
+}
+
+func (s *SyslogSink) Write(e *Entry) error {
+	return errors.New("Syslog audit log sinks are not supported on this platform")
+}
@@ -0,0 +1,102 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type failingSink struct{}
+
+func (s *failingSink) Write(e *Entry) error {
+	return errors.New("failing")
+}
+
+func TestWriterSink(t *testing.T) {
+	var out bytes.Buffer
+	s := &WriterSink{W: &out}
+	require.NoError(t, s.Write(&Entry{ID: "foo"}))
+	require.NoError(t, s.Write(&Entry{ID: "bar"}))
+
+	lines := bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+
+	var e Entry
+	require.NoError(t, json.Unmarshal(lines[1], &e))
+	assert.Equal(t, "bar", e.ID)
+}
+
+func TestWebhookSink(t *testing.T) {
+	var received Entry
+	var status = http.StatusNoContent
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(status)
+	}))
+	defer ts.Close()
+
+	s := &WebhookSink{URL: ts.URL}
+	require.NoError(t, s.Write(&Entry{ID: "foo"}))
+	assert.Equal(t, "foo", received.ID)
+
+	status = http.StatusInternalServerError
+	assert.Error(t, s.Write(&Entry{ID: "foo"}))
+}
+
+func TestMultiSink(t *testing.T) {
+	var out bytes.Buffer
+	s := MultiSink{&failingSink{}, &WriterSink{W: &out}}
+	assert.Error(t, s.Write(&Entry{ID: "foo"}))
+	assert.Contains(t, out.String(), "foo")
+}
+
+func TestNewSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hydra-audit")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "audit.log")
+	s, err := NewSink("file://"+path, nil)
+	require.NoError(t, err)
+	require.NoError(t, s.Write(&Entry{ID: "foo"}))
+
+	out, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(out), `"id":"foo"`)
+
+	client := &http.Client{Timeout: time.Second}
+	s, err = NewSink("https://audit.myapp.com/hydra", client)
+	require.NoError(t, err)
+	require.IsType(t, &WebhookSink{}, s)
+	assert.Equal(t, client, s.(*WebhookSink).Client)
+
+	_, err = NewSink("kafka://localhost:9092", nil)
+	assert.Error(t, err)
+
+	_, err = NewSink("ftp://audit.myapp.com", nil)
+	assert.Error(t, err)
+}
@@ -15,11 +15,23 @@
 package client
 
 import (
+	"net/url"
 	"strings"
+	"time"
+	"unicode"
 
 	"github.com/ory/fosite"
+	"github.com/pkg/errors"
+	"github.com/square/go-jose"
 )
 
+// ClientStatusPending is the status of clients which registered themselves and were not yet approved. Pending clients
+// can not authenticate or request tokens.
+const ClientStatusPending = "pending"
+
+// MaxConsentChallengeLifespan is the longest consent challenge lifespan a client may configure.
+const MaxConsentChallengeLifespan = time.Hour * 24
+
 // Client represents an OAuth 2.0 Client.
 //
 // swagger:model oAuth2Client
@@ -36,12 +48,20 @@ type Client struct {
 	// that they need to write the secret down as it will not be made available again.
 	Secret string `json:"client_secret,omitempty" gorethink:"client_secret"`
 
+	// SecretGeneration describes how the client secret was generated. It is only returned by the request which
+	// generated the secret and never stored.
+	SecretGeneration *SecretGeneration `json:"client_secret_generation,omitempty" gorethink:"-"`
+
+	// hashedSecret is the stored hash of the secret of the client passed to the patch function of PatchClient, see
+	// RehashSecret.
+	hashedSecret string
+
 	// RedirectURIs is an array of allowed redirect urls for the client, for example http://mydomain/oauth/callback .
 	RedirectURIs []string `json:"redirect_uris" gorethink:"redirect_uris"`
 
 	// GrantTypes is an array of grant types the client is allowed to use.
 	//
-	// Pattern: client_credentials|authorize_code|implicit|refresh_token
+	// Pattern: client_credentials|authorize_code|implicit|refresh_token|urn:ietf:params:oauth:grant-type:token-exchange
 	GrantTypes []string `json:"grant_types" gorethink:"grant_types"`
 
 	// ResponseTypes is an array of the OAuth 2.0 response type strings that the client can
@@ -86,6 +106,328 @@ type Client struct {
 	// Public is a boolean that identifies this client as public, meaning that it
 	// does not have a secret. It will disable the client_credentials grant type for this client if set.
 	Public bool `json:"public" gorethink:"public"`
+
+	// TokenEndpointAuthMethod is the requested client authentication method for the token endpoint. Supported
+	// values are client_secret_basic (default), tls_client_auth and self_signed_tls_client_auth.
+	//
+	// Pattern: client_secret_basic|tls_client_auth|self_signed_tls_client_auth
+	TokenEndpointAuthMethod string `json:"token_endpoint_auth_method,omitempty" gorethink:"token_endpoint_auth_method"`
+
+	// TLSClientAuthCertificate is the PEM encoded, self-signed certificate the client presents over mutual TLS
+	// when using self_signed_tls_client_auth.
+	TLSClientAuthCertificate string `json:"tls_client_auth_certificate,omitempty" gorethink:"tls_client_auth_certificate"`
+
+	// TLSClientAuthPublicKeySHA256 is the base64url encoded SHA-256 hash of the subject public key info of the
+	// certificate the client presents over mutual TLS when using self_signed_tls_client_auth. Pinning the public
+	// key instead of the certificate allows the client to renew its certificate without updating the client.
+	TLSClientAuthPublicKeySHA256 string `json:"tls_client_auth_public_key_sha256,omitempty" gorethink:"tls_client_auth_public_key_sha256"`
+
+	// TLSClientAuthSubjectDN is the RFC 4514 string representation of the subject distinguished name of the
+	// certificate the client presents when using tls_client_auth, for example "CN=client,O=Example,C=US". Exactly one
+	// of the tls_client_auth_subject_dn and tls_client_auth_san_* values must be set when using tls_client_auth.
+	TLSClientAuthSubjectDN string `json:"tls_client_auth_subject_dn,omitempty" gorethink:"tls_client_auth_subject_dn"`
+
+	// TLSClientAuthSANDNS is the dNSName subject alternative name of the certificate the client presents when using
+	// tls_client_auth.
+	TLSClientAuthSANDNS string `json:"tls_client_auth_san_dns,omitempty" gorethink:"tls_client_auth_san_dns"`
+
+	// TLSClientAuthSANURI is the uniformResourceIdentifier subject alternative name of the certificate the client
+	// presents when using tls_client_auth.
+	TLSClientAuthSANURI string `json:"tls_client_auth_san_uri,omitempty" gorethink:"tls_client_auth_san_uri"`
+
+	// TLSClientAuthSANIP is the iPAddress subject alternative name of the certificate the client presents when using
+	// tls_client_auth, in dotted decimal notation for IPv4 or colon-delimited hexadecimal for IPv6.
+	TLSClientAuthSANIP string `json:"tls_client_auth_san_ip,omitempty" gorethink:"tls_client_auth_san_ip"`
+
+	// TLSClientAuthSANEmail is the rfc822Name subject alternative name of the certificate the client presents when
+	// using tls_client_auth.
+	TLSClientAuthSANEmail string `json:"tls_client_auth_san_email,omitempty" gorethink:"tls_client_auth_san_email"`
+
+	// Status is "pending" for clients which registered themselves using dynamic client registration and are waiting
+	// for approval, and empty for all other clients. It can not be changed by updating the client, use the approve
+	// endpoint instead.
+	Status string `json:"status,omitempty" gorethink:"status"`
+
+	// ConsentChallengeLifespan is how long the consent challenge of this client remains valid, for example "30m" for
+	// kiosk flows where the user needs more time to sign in. Valid time units are "s", "m" and "h". If empty, the
+	// CHALLENGE_TOKEN_LIFESPAN is used.
+	ConsentChallengeLifespan string `json:"consent_challenge_lifespan,omitempty" gorethink:"consent_challenge_lifespan"`
+
+	// RequirePKCE forces the client to use PKCE with the S256 code challenge method in the authorization code flow.
+	// Authorization requests without a code challenge and code exchanges without a code verifier are rejected.
+	RequirePKCE bool `json:"require_pkce,omitempty" gorethink:"require_pkce"`
+
+	// RequirePushedAuthorizationRequests forces the client to push its authorization requests to the pushed
+	// authorization request endpoint. Authorization requests which do not refer to a pushed request are rejected.
+	RequirePushedAuthorizationRequests bool `json:"require_pushed_authorization_requests,omitempty" gorethink:"require_pushed_authorization_requests"`
+
+	// FirstParty marks clients operated by the same organization as this server, for example its own web and mobile
+	// apps. Policies deciding on consent can refer to it using the "firstParty" context key.
+	FirstParty bool `json:"first_party,omitempty" gorethink:"first_party"`
+
+	// SkipConsent marks trusted clients, for example internal applications used by employees, which are granted all
+	// requested scopes without showing a consent screen. Once the subject has logged in, the authorization endpoint
+	// accepts consent on behalf of the subject instead of redirecting to the consent app.
+	SkipConsent bool `json:"skip_consent,omitempty" gorethink:"skip_consent"`
+
+	// Internal marks clients which are only used by employees or internal services. Policies deciding on consent can
+	// refer to it using the "internal" context key.
+	Internal bool `json:"internal,omitempty" gorethink:"internal"`
+
+	// Environment is the environment the client is deployed to, for example "production" or "staging". Policies
+	// deciding on consent can refer to it using the "environment" context key.
+	Environment string `json:"environment,omitempty" gorethink:"environment"`
+
+	// AccessTokenLifespan shortens the lifespan of access tokens issued to this client, for example "5m" for high-risk
+	// clients. Valid time units are "s", "m" and "h". If empty or longer than ACCESS_TOKEN_LIFESPAN, the
+	// ACCESS_TOKEN_LIFESPAN is used.
+	AccessTokenLifespan string `json:"access_token_lifespan,omitempty" gorethink:"access_token_lifespan"`
+
+	// RefreshTokenLifespan is how long refresh tokens issued to this client remain valid, for example "720h". Valid
+	// time units are "s", "m" and "h". If empty, refresh tokens do not expire.
+	RefreshTokenLifespan string `json:"refresh_token_lifespan,omitempty" gorethink:"refresh_token_lifespan"`
+
+	// IDTokenLifespan shortens the lifespan of ID tokens issued to this client. Valid time units are "s", "m" and "h".
+	// If empty or longer than ID_TOKEN_LIFESPAN, the ID_TOKEN_LIFESPAN is used.
+	IDTokenLifespan string `json:"id_token_lifespan,omitempty" gorethink:"id_token_lifespan"`
+
+	// AuthorizeCodeLifespan shortens the lifespan of authorize codes issued to this client. Valid time units are "s",
+	// "m" and "h". If empty or longer than AUTH_CODE_LIFESPAN, the AUTH_CODE_LIFESPAN is used.
+	AuthorizeCodeLifespan string `json:"authorize_code_lifespan,omitempty" gorethink:"authorize_code_lifespan"`
+
+	// PostLogoutRedirectURIs is an array of URLs the user agent may be redirected to after logging out at the
+	// OpenID Connect end session endpoint.
+	PostLogoutRedirectURIs []string `json:"post_logout_redirect_uris,omitempty" gorethink:"post_logout_redirect_uris"`
+
+	// FrontChannelLogoutURI is loaded in an iframe of the logout page when the user logs out, with the issuer and the
+	// session id in the "iss" and "sid" query parameters, so the client can end its own session.
+	FrontChannelLogoutURI string `json:"frontchannel_logout_uri,omitempty" gorethink:"frontchannel_logout_uri"`
+
+	// BackChannelLogoutURI receives a logout token, a JSON Web Token signed using the OpenID Connect key, in the
+	// "logout_token" form parameter of a POST request when the user logs out.
+	BackChannelLogoutURI string `json:"backchannel_logout_uri,omitempty" gorethink:"backchannel_logout_uri"`
+
+	// JSONWebKeys is the JSON Web Key Set containing the public keys of the client. ID tokens and userinfo responses
+	// are encrypted to one of these keys if the client registered an encryption algorithm. Must not be set together
+	// with jwks_uri.
+	JSONWebKeys *jose.JSONWebKeySet `json:"jwks,omitempty" gorethink:"jwks"`
+
+	// JSONWebKeysURI is the URL of the JSON Web Key Set containing the public keys of the client. Clients which rotate
+	// their keys should use it instead of jwks.
+	JSONWebKeysURI string `json:"jwks_uri,omitempty" gorethink:"jwks_uri"`
+
+	// IDTokenEncryptedResponseAlg is the algorithm used to encrypt the content encryption key of ID tokens issued to
+	// this client. If set, ID tokens are signed and then encrypted to a key from jwks or jwks_uri.
+	//
+	// Pattern: RSA-OAEP|RSA-OAEP-256|ECDH-ES|ECDH-ES+A128KW|ECDH-ES+A256KW
+	IDTokenEncryptedResponseAlg string `json:"id_token_encrypted_response_alg,omitempty" gorethink:"id_token_encrypted_response_alg"`
+
+	// IDTokenEncryptedResponseEnc is the algorithm used to encrypt the content of ID tokens issued to this client.
+	// Requires id_token_encrypted_response_alg and defaults to A128CBC-HS256.
+	//
+	// Pattern: A128CBC-HS256|A256CBC-HS512|A128GCM|A256GCM
+	IDTokenEncryptedResponseEnc string `json:"id_token_encrypted_response_enc,omitempty" gorethink:"id_token_encrypted_response_enc"`
+
+	// UserinfoSignedResponseAlg is the algorithm used to sign userinfo responses. If set, the userinfo endpoint
+	// responds with a JSON Web Token of content type application/jwt, signed using the OpenID Connect key. It must
+	// match the algorithm of the OpenID Connect key.
+	//
+	// Pattern: RS256|EdDSA
+	UserinfoSignedResponseAlg string `json:"userinfo_signed_response_alg,omitempty" gorethink:"userinfo_signed_response_alg"`
+
+	// UserinfoEncryptedResponseAlg is the algorithm used to encrypt the content encryption key of userinfo responses.
+	// If set, the userinfo endpoint responds with an encrypted JSON Web Token of content type application/jwt.
+	//
+	// Pattern: RSA-OAEP|RSA-OAEP-256|ECDH-ES|ECDH-ES+A128KW|ECDH-ES+A256KW
+	UserinfoEncryptedResponseAlg string `json:"userinfo_encrypted_response_alg,omitempty" gorethink:"userinfo_encrypted_response_alg"`
+
+	// UserinfoEncryptedResponseEnc is the algorithm used to encrypt the content of userinfo responses. Requires
+	// userinfo_encrypted_response_alg and defaults to A128CBC-HS256.
+	//
+	// Pattern: A128CBC-HS256|A256CBC-HS512|A128GCM|A256GCM
+	UserinfoEncryptedResponseEnc string `json:"userinfo_encrypted_response_enc,omitempty" gorethink:"userinfo_encrypted_response_enc"`
+
+	// Audience is an array of the audiences, for example the URLs of resource servers, the client may request access
+	// tokens for using the audience parameter at the token endpoint. If empty, the client can not request an audience.
+	Audience []string `json:"audience,omitempty" gorethink:"audience"`
+
+	// AllowedCORSOrigins is an array of origins, for example "https://app.mydomain.com", single-page apps of the client
+	// are served at. Cross-origin requests from these origins to the token, revocation and userinfo endpoints and the
+	// well-known documents are allowed.
+	AllowedCORSOrigins []string `json:"allowed_cors_origins,omitempty" gorethink:"allowed_cors_origins"`
+
+	// ResourceServerAudience marks the client as a resource server. It is an array of the audiences, for example the
+	// URL of the resource server, the client serves. The client may only introspect access tokens issued for one of
+	// these audiences and the introspection response only contains the claims relevant to it.
+	ResourceServerAudience []string `json:"resource_server_audience,omitempty" gorethink:"resource_server_audience"`
+}
+
+// PolicyContext returns the client metadata that policies deciding on consent can refer to in their conditions.
+func (c *Client) PolicyContext() map[string]interface{} {
+	return map[string]interface{}{
+		"firstParty":  c.FirstParty,
+		"internal":    c.Internal,
+		"environment": c.Environment,
+	}
+}
+
+// GetConsentChallengeLifespan returns the consent challenge lifespan of this client, or fallback if none is set.
+func (c *Client) GetConsentChallengeLifespan(fallback time.Duration) time.Duration {
+	if c.ConsentChallengeLifespan == "" {
+		return fallback
+	}
+
+	d, err := time.ParseDuration(c.ConsentChallengeLifespan)
+	if err != nil || d <= 0 {
+		return fallback
+	}
+	return d
+}
+
+// ValidateConsentChallengeLifespan checks that the consent challenge lifespan, if set, is a positive duration of at
+// most MaxConsentChallengeLifespan.
+func (c *Client) ValidateConsentChallengeLifespan() error {
+	if c.ConsentChallengeLifespan == "" {
+		return nil
+	}
+
+	d, err := time.ParseDuration(c.ConsentChallengeLifespan)
+	if err != nil {
+		return errors.Errorf("Could not parse consent challenge lifespan %s: %s", c.ConsentChallengeLifespan, err)
+	} else if d <= 0 {
+		return errors.New("The consent challenge lifespan must be positive")
+	} else if d > MaxConsentChallengeLifespan {
+		return errors.Errorf("The consent challenge lifespan must not exceed %s", MaxConsentChallengeLifespan)
+	}
+	return nil
+}
+
+// GetAccessTokenLifespan returns the access token lifespan of this client, or fallback if none is set.
+func (c *Client) GetAccessTokenLifespan(fallback time.Duration) time.Duration {
+	return parseLifespan(c.AccessTokenLifespan, fallback)
+}
+
+// GetRefreshTokenLifespan returns the refresh token lifespan of this client, or fallback if none is set.
+func (c *Client) GetRefreshTokenLifespan(fallback time.Duration) time.Duration {
+	return parseLifespan(c.RefreshTokenLifespan, fallback)
+}
+
+// GetIDTokenLifespan returns the ID token lifespan of this client, or fallback if none is set.
+func (c *Client) GetIDTokenLifespan(fallback time.Duration) time.Duration {
+	return parseLifespan(c.IDTokenLifespan, fallback)
+}
+
+// GetAuthorizeCodeLifespan returns the authorize code lifespan of this client, or fallback if none is set.
+func (c *Client) GetAuthorizeCodeLifespan(fallback time.Duration) time.Duration {
+	return parseLifespan(c.AuthorizeCodeLifespan, fallback)
+}
+
+// ValidateLogoutURIs checks that the post logout redirect URIs and the logout URIs, if set, are absolute URLs
+// without a fragment.
+func (c *Client) ValidateLogoutURIs() error {
+	for _, uri := range append([]string{c.FrontChannelLogoutURI, c.BackChannelLogoutURI}, c.PostLogoutRedirectURIs...) {
+		if uri == "" {
+			continue
+		}
+
+		u, err := url.Parse(uri)
+		if err != nil {
+			return errors.Errorf("Could not parse logout URI %s: %s", uri, err)
+		} else if !u.IsAbs() || u.Host == "" {
+			return errors.Errorf("The logout URI %s must be an absolute URL", uri)
+		} else if u.Fragment != "" {
+			return errors.Errorf("The logout URI %s must not contain a fragment", uri)
+		}
+	}
+	return nil
+}
+
+// ValidateUserinfoSignedResponseAlg checks that the userinfo signing algorithm, if set, is supported.
+func (c *Client) ValidateUserinfoSignedResponseAlg() error {
+	switch c.UserinfoSignedResponseAlg {
+	case "", "RS256", "EdDSA":
+		return nil
+	}
+	return errors.Errorf("The userinfo_signed_response_alg %s is not supported", c.UserinfoSignedResponseAlg)
+}
+
+// ValidateTokenLifespans checks that the token lifespans, if set, are positive durations.
+func (c *Client) ValidateTokenLifespans() error {
+	for _, l := range []struct {
+		name  string
+		value string
+	}{
+		{name: "access token", value: c.AccessTokenLifespan},
+		{name: "refresh token", value: c.RefreshTokenLifespan},
+		{name: "ID token", value: c.IDTokenLifespan},
+		{name: "authorize code", value: c.AuthorizeCodeLifespan},
+	} {
+		if l.value == "" {
+			continue
+		}
+
+		d, err := time.ParseDuration(l.value)
+		if err != nil {
+			return errors.Errorf("Could not parse %s lifespan %s: %s", l.name, l.value, err)
+		} else if d <= 0 {
+			return errors.Errorf("The %s lifespan must be positive", l.name)
+		}
+	}
+	return nil
+}
+
+func parseLifespan(lifespan string, fallback time.Duration) time.Duration {
+	if lifespan == "" {
+		return fallback
+	}
+
+	d, err := time.ParseDuration(lifespan)
+	if err != nil || d <= 0 {
+		return fallback
+	}
+	return d
+}
+
+// ValidateAudience checks that the audiences and resource server audiences, if set, are not empty and do not contain
+// whitespace.
+func (c *Client) ValidateAudience() error {
+	audience := make([]string, 0, len(c.Audience)+len(c.ResourceServerAudience))
+	audience = append(audience, c.Audience...)
+	audience = append(audience, c.ResourceServerAudience...)
+	for _, aud := range audience {
+		if aud == "" {
+			return errors.New("The audience must not contain empty values")
+		} else if strings.IndexFunc(aud, unicode.IsSpace) >= 0 {
+			return errors.Errorf("The audience %s must not contain whitespace", aud)
+		}
+	}
+	return nil
+}
+
+// ValidateAllowedCORSOrigins checks that the allowed CORS origins, if set, are http or https origins without a path,
+// query or fragment.
+func (c *Client) ValidateAllowedCORSOrigins() error {
+	for _, origin := range c.AllowedCORSOrigins {
+		u, err := url.Parse(origin)
+		if err != nil {
+			return errors.Errorf("Could not parse allowed CORS origin %s: %s", origin, err)
+		} else if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.Errorf("The allowed CORS origin %s must be an http or https URL", origin)
+		} else if (u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+			return errors.Errorf("The allowed CORS origin %s must not contain a path, query, fragment or user info", origin)
+		}
+	}
+	return nil
+}
+
+// IsResourceServer returns true if the client registered the audiences it serves as a resource server.
+func (c *Client) IsResourceServer() bool {
+	return len(c.ResourceServerAudience) > 0
+}
+
+// IsPending returns true if the client registered itself and was not yet approved.
+func (c *Client) IsPending() bool {
+	return c.Status == ClientStatusPending
 }
 
 func (c *Client) GetID() string {
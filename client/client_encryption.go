@@ -0,0 +1,158 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"net/url"
+
+	"github.com/pkg/errors"
+	"github.com/square/go-jose"
+)
+
+// DefaultResponseEncryptionEnc is the content encryption algorithm used when a client registered a key encryption
+// algorithm only.
+const DefaultResponseEncryptionEnc = string(jose.A128CBC_HS256)
+
+// ResponseEncryptionAlgs are the supported values of id_token_encrypted_response_alg and
+// userinfo_encrypted_response_alg. RSA1_5 is not supported because it is vulnerable to padding oracle attacks.
+var ResponseEncryptionAlgs = []string{
+	string(jose.RSA_OAEP),
+	string(jose.RSA_OAEP_256),
+	string(jose.ECDH_ES),
+	string(jose.ECDH_ES_A128KW),
+	string(jose.ECDH_ES_A256KW),
+}
+
+// ResponseEncryptionEncs are the supported values of id_token_encrypted_response_enc and
+// userinfo_encrypted_response_enc.
+var ResponseEncryptionEncs = []string{
+	string(jose.A128CBC_HS256),
+	string(jose.A256CBC_HS512),
+	string(jose.A128GCM),
+	string(jose.A256GCM),
+}
+
+// GetIDTokenEncryption returns the algorithms ID tokens issued to this client are encrypted with, or empty strings if
+// ID tokens are not encrypted.
+func (c *Client) GetIDTokenEncryption() (alg, enc string) {
+	return responseEncryption(c.IDTokenEncryptedResponseAlg, c.IDTokenEncryptedResponseEnc)
+}
+
+// GetUserinfoEncryption returns the algorithms userinfo responses for this client are encrypted with, or empty
+// strings if userinfo responses are not encrypted.
+func (c *Client) GetUserinfoEncryption() (alg, enc string) {
+	return responseEncryption(c.UserinfoEncryptedResponseAlg, c.UserinfoEncryptedResponseEnc)
+}
+
+func responseEncryption(alg, enc string) (string, string) {
+	if alg == "" {
+		return "", ""
+	} else if enc == "" {
+		return alg, DefaultResponseEncryptionEnc
+	}
+	return alg, enc
+}
+
+// ValidateResponseEncryption checks that the encryption algorithms, if set, are supported and that the client
+// registered the public keys responses are encrypted to.
+func (c *Client) ValidateResponseEncryption() error {
+	if c.JSONWebKeys != nil && c.JSONWebKeysURI != "" {
+		return errors.New("The jwks and jwks_uri values must not both be set")
+	}
+
+	if c.JSONWebKeysURI != "" {
+		u, err := url.Parse(c.JSONWebKeysURI)
+		if err != nil {
+			return errors.Errorf("Could not parse jwks_uri %s: %s", c.JSONWebKeysURI, err)
+		} else if !u.IsAbs() || u.Host == "" {
+			return errors.Errorf("The jwks_uri %s must be an absolute URL", c.JSONWebKeysURI)
+		}
+	}
+
+	if c.JSONWebKeys != nil {
+		for _, key := range c.JSONWebKeys.Keys {
+			if !key.IsPublic() {
+				return errors.Errorf("The key %s in jwks must be a public key", key.KeyID)
+			}
+		}
+	}
+
+	for _, e := range []struct {
+		name string
+		alg  string
+		enc  string
+	}{
+		{name: "id_token", alg: c.IDTokenEncryptedResponseAlg, enc: c.IDTokenEncryptedResponseEnc},
+		{name: "userinfo", alg: c.UserinfoEncryptedResponseAlg, enc: c.UserinfoEncryptedResponseEnc},
+	} {
+		if e.alg == "" {
+			if e.enc != "" {
+				return errors.Errorf("The %s_encrypted_response_enc value requires %s_encrypted_response_alg", e.name, e.name)
+			}
+			continue
+		}
+
+		if !stringInSlice(e.alg, ResponseEncryptionAlgs) {
+			return errors.Errorf("The %s_encrypted_response_alg %s is not supported", e.name, e.alg)
+		} else if e.enc != "" && !stringInSlice(e.enc, ResponseEncryptionEncs) {
+			return errors.Errorf("The %s_encrypted_response_enc %s is not supported", e.name, e.enc)
+		}
+
+		if c.JSONWebKeysURI != "" {
+			continue
+		} else if c.JSONWebKeys == nil {
+			return errors.Errorf("The %s_encrypted_response_alg value requires jwks or jwks_uri", e.name)
+		} else if _, err := EncryptionKey(c.JSONWebKeys, e.alg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EncryptionKey returns the first key of keys which can be used to encrypt a content encryption key using alg. Keys
+// intended for signatures and keys restricted to another algorithm are skipped.
+func EncryptionKey(keys *jose.JSONWebKeySet, alg string) (*jose.JSONWebKey, error) {
+	for k := range keys.Keys {
+		key := &keys.Keys[k]
+		if key.Use != "" && key.Use != "enc" {
+			continue
+		} else if key.Algorithm != "" && key.Algorithm != alg {
+			continue
+		}
+
+		switch key.Key.(type) {
+		case *rsa.PublicKey:
+			if alg == string(jose.RSA_OAEP) || alg == string(jose.RSA_OAEP_256) {
+				return key, nil
+			}
+		case *ecdsa.PublicKey:
+			if alg == string(jose.ECDH_ES) || alg == string(jose.ECDH_ES_A128KW) || alg == string(jose.ECDH_ES_A256KW) {
+				return key, nil
+			}
+		}
+	}
+	return nil, errors.Errorf("The JSON Web Key Set contains no public key for encryption with %s", alg)
+}
+
+func stringInSlice(needle string, haystack []string) bool {
+	for _, s := range haystack {
+		if s == needle {
+			return true
+		}
+	}
+	return false
+}
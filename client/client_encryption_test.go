@@ -0,0 +1,82 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"fmt"
+	"testing"
+
+	"github.com/ory/hydra/pkg"
+	"github.com/square/go-jose"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientResponseEncryption(t *testing.T) {
+	rsaKey := pkg.MustINSECURELOWENTROPYRSAKEYFORTEST()
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	keys := &jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+		{Key: &rsaKey.PublicKey, KeyID: "sig", Use: "sig"},
+		{Key: &rsaKey.PublicKey, KeyID: "rsa", Use: "enc"},
+		{Key: &ecKey.PublicKey, KeyID: "ec"},
+	}}
+
+	for k, tc := range []struct {
+		c         *Client
+		expectErr bool
+	}{
+		{c: &Client{}},
+		{c: &Client{JSONWebKeys: keys, IDTokenEncryptedResponseAlg: "RSA-OAEP-256"}},
+		{c: &Client{JSONWebKeys: keys, IDTokenEncryptedResponseAlg: "ECDH-ES", IDTokenEncryptedResponseEnc: "A256GCM"}},
+		{c: &Client{JSONWebKeysURI: "https://app.localhost/jwks.json", UserinfoEncryptedResponseAlg: "RSA-OAEP"}},
+		{c: &Client{IDTokenEncryptedResponseAlg: "RSA-OAEP"}, expectErr: true},
+		{c: &Client{JSONWebKeys: keys, IDTokenEncryptedResponseAlg: "RSA1_5"}, expectErr: true},
+		{c: &Client{JSONWebKeys: keys, IDTokenEncryptedResponseAlg: "RSA-OAEP", IDTokenEncryptedResponseEnc: "A192GCM"}, expectErr: true},
+		{c: &Client{JSONWebKeys: keys, UserinfoEncryptedResponseEnc: "A128GCM"}, expectErr: true},
+		{c: &Client{JSONWebKeys: keys, JSONWebKeysURI: "https://app.localhost/jwks.json"}, expectErr: true},
+		{c: &Client{JSONWebKeysURI: "/jwks.json"}, expectErr: true},
+		{c: &Client{JSONWebKeys: &jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: rsaKey, KeyID: "private"}}}}, expectErr: true},
+		{c: &Client{JSONWebKeys: &jose.JSONWebKeySet{Keys: keys.Keys[:1]}, IDTokenEncryptedResponseAlg: "RSA-OAEP"}, expectErr: true},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			if tc.expectErr {
+				assert.Error(t, tc.c.ValidateResponseEncryption())
+			} else {
+				assert.NoError(t, tc.c.ValidateResponseEncryption())
+			}
+		})
+	}
+
+	key, err := EncryptionKey(keys, "RSA-OAEP")
+	require.NoError(t, err)
+	assert.Equal(t, "rsa", key.KeyID)
+
+	key, err = EncryptionKey(keys, "ECDH-ES+A128KW")
+	require.NoError(t, err)
+	assert.Equal(t, "ec", key.KeyID)
+
+	alg, enc := (&Client{IDTokenEncryptedResponseAlg: "RSA-OAEP"}).GetIDTokenEncryption()
+	assert.Equal(t, "RSA-OAEP", alg)
+	assert.Equal(t, DefaultResponseEncryptionEnc, enc)
+
+	alg, enc = (&Client{UserinfoEncryptedResponseEnc: "A128GCM"}).GetUserinfoEncryption()
+	assert.Empty(t, alg)
+	assert.Empty(t, enc)
+}
@@ -15,7 +15,9 @@
 package client
 
 import (
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/ory/fosite"
 	"github.com/stretchr/testify/assert"
@@ -37,3 +39,111 @@ func TestClient(t *testing.T) {
 	assert.Len(t, c.GetScopes(), 2)
 	assert.EqualValues(t, c.RedirectURIs, c.GetRedirectURIs())
 }
+
+func TestClientConsentChallengeLifespan(t *testing.T) {
+	for k, tc := range []struct {
+		lifespan  string
+		expectErr bool
+		expected  time.Duration
+	}{
+		{lifespan: "", expected: time.Minute * 5},
+		{lifespan: "30m", expected: time.Minute * 30},
+		{lifespan: "24h", expected: time.Hour * 24},
+		{lifespan: "25h", expectErr: true},
+		{lifespan: "-1m", expectErr: true, expected: time.Minute * 5},
+		{lifespan: "0s", expectErr: true, expected: time.Minute * 5},
+		{lifespan: "thirty minutes", expectErr: true, expected: time.Minute * 5},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			c := &Client{ConsentChallengeLifespan: tc.lifespan}
+			if tc.expectErr {
+				assert.Error(t, c.ValidateConsentChallengeLifespan())
+			} else {
+				assert.NoError(t, c.ValidateConsentChallengeLifespan())
+			}
+
+			if tc.expected > 0 {
+				assert.Equal(t, tc.expected, c.GetConsentChallengeLifespan(time.Minute*5))
+			}
+		})
+	}
+}
+
+func TestClientTokenLifespans(t *testing.T) {
+	for k, tc := range []struct {
+		c         *Client
+		expectErr bool
+	}{
+		{c: &Client{}},
+		{c: &Client{AccessTokenLifespan: "5m", RefreshTokenLifespan: "720h", IDTokenLifespan: "1m", AuthorizeCodeLifespan: "30s"}},
+		{c: &Client{AccessTokenLifespan: "0s"}, expectErr: true},
+		{c: &Client{RefreshTokenLifespan: "-1h"}, expectErr: true},
+		{c: &Client{IDTokenLifespan: "one minute"}, expectErr: true},
+		{c: &Client{AuthorizeCodeLifespan: "10"}, expectErr: true},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			if tc.expectErr {
+				assert.Error(t, tc.c.ValidateTokenLifespans())
+			} else {
+				assert.NoError(t, tc.c.ValidateTokenLifespans())
+			}
+		})
+	}
+
+	c := &Client{AccessTokenLifespan: "5m", RefreshTokenLifespan: "invalid"}
+	assert.Equal(t, time.Minute*5, c.GetAccessTokenLifespan(time.Hour))
+	assert.Equal(t, time.Hour, c.GetRefreshTokenLifespan(time.Hour))
+	assert.Equal(t, time.Hour, c.GetIDTokenLifespan(time.Hour))
+	assert.Equal(t, time.Duration(0), c.GetAuthorizeCodeLifespan(0))
+}
+
+func TestClientLogoutURIs(t *testing.T) {
+	for k, tc := range []struct {
+		c         *Client
+		expectErr bool
+	}{
+		{c: &Client{}},
+		{c: &Client{
+			PostLogoutRedirectURIs: []string{"https://app.localhost/logged-out", "http://localhost:3000/"},
+			FrontChannelLogoutURI:  "https://app.localhost/logout/frontchannel",
+			BackChannelLogoutURI:   "https://app.localhost/logout/backchannel?tenant=foo",
+		}},
+		{c: &Client{PostLogoutRedirectURIs: []string{"/logged-out"}}, expectErr: true},
+		{c: &Client{FrontChannelLogoutURI: "https://app.localhost/logout#frontchannel"}, expectErr: true},
+		{c: &Client{BackChannelLogoutURI: "app.localhost/logout"}, expectErr: true},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			if tc.expectErr {
+				assert.Error(t, tc.c.ValidateLogoutURIs())
+			} else {
+				assert.NoError(t, tc.c.ValidateLogoutURIs())
+			}
+		})
+	}
+}
+
+func TestClientUserinfoSignedResponseAlg(t *testing.T) {
+	assert.NoError(t, (&Client{}).ValidateUserinfoSignedResponseAlg())
+	assert.NoError(t, (&Client{UserinfoSignedResponseAlg: "RS256"}).ValidateUserinfoSignedResponseAlg())
+	assert.NoError(t, (&Client{UserinfoSignedResponseAlg: "EdDSA"}).ValidateUserinfoSignedResponseAlg())
+	assert.Error(t, (&Client{UserinfoSignedResponseAlg: "none"}).ValidateUserinfoSignedResponseAlg())
+	assert.Error(t, (&Client{UserinfoSignedResponseAlg: "HS256"}).ValidateUserinfoSignedResponseAlg())
+}
+
+func TestClientAudience(t *testing.T) {
+	assert.NoError(t, (&Client{}).ValidateAudience())
+	assert.NoError(t, (&Client{Audience: []string{"https://api.localhost", "urn:example:billing"}}).ValidateAudience())
+	assert.Error(t, (&Client{Audience: []string{""}}).ValidateAudience())
+	assert.Error(t, (&Client{Audience: []string{"https://api.localhost https://other.localhost"}}).ValidateAudience())
+	assert.NoError(t, (&Client{ResourceServerAudience: []string{"https://api.localhost"}}).ValidateAudience())
+	assert.Error(t, (&Client{ResourceServerAudience: []string{""}}).ValidateAudience())
+	assert.Error(t, (&Client{ResourceServerAudience: []string{"https://api.localhost https://other.localhost"}}).ValidateAudience())
+}
+
+func TestClientAllowedCORSOrigins(t *testing.T) {
+	assert.NoError(t, (&Client{}).ValidateAllowedCORSOrigins())
+	assert.NoError(t, (&Client{AllowedCORSOrigins: []string{"https://app.localhost", "http://localhost:3000/"}}).ValidateAllowedCORSOrigins())
+	assert.Error(t, (&Client{AllowedCORSOrigins: []string{"app.localhost"}}).ValidateAllowedCORSOrigins())
+	assert.Error(t, (&Client{AllowedCORSOrigins: []string{"ftp://app.localhost"}}).ValidateAllowedCORSOrigins())
+	assert.Error(t, (&Client{AllowedCORSOrigins: []string{"https://app.localhost/callback"}}).ValidateAllowedCORSOrigins())
+}
@@ -0,0 +1,200 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// TokenEndpointAuthMethodSecretBasic authenticates the client using the client secret and HTTP basic auth.
+	TokenEndpointAuthMethodSecretBasic = "client_secret_basic"
+
+	// TokenEndpointAuthMethodSelfSignedTLS authenticates the client using a pinned, self-signed certificate
+	// presented over mutual TLS, see https://tools.ietf.org/html/draft-ietf-oauth-mtls
+	TokenEndpointAuthMethodSelfSignedTLS = "self_signed_tls_client_auth"
+
+	// TokenEndpointAuthMethodTLS authenticates the client using a certificate issued by a trusted certificate
+	// authority and presented over mutual TLS, see https://tools.ietf.org/html/rfc8705#section-2.1
+	TokenEndpointAuthMethodTLS = "tls_client_auth"
+)
+
+// UsesSelfSignedTLSClientAuth returns true if the client authenticates at the token endpoint using a self-signed
+// certificate.
+func (c *Client) UsesSelfSignedTLSClientAuth() bool {
+	return c.TokenEndpointAuthMethod == TokenEndpointAuthMethodSelfSignedTLS
+}
+
+// UsesTLSClientAuth returns true if the client authenticates at the token endpoint using a certificate, either a
+// self-signed or one issued by a trusted certificate authority.
+func (c *Client) UsesTLSClientAuth() bool {
+	return c.UsesSelfSignedTLSClientAuth() || c.TokenEndpointAuthMethod == TokenEndpointAuthMethodTLS
+}
+
+// VerifyTLSClientCertificate checks that cert matches the certificate or public key pinned for this client or, if
+// the client uses tls_client_auth, the subject registered for it. With tls_client_auth, the caller must verify the
+// certificate chain against the trusted certificate authorities before.
+func (c *Client) VerifyTLSClientCertificate(cert *x509.Certificate) error {
+	if !c.UsesTLSClientAuth() {
+		return errors.Errorf("Client %s does not use %s or %s", c.ID, TokenEndpointAuthMethodTLS, TokenEndpointAuthMethodSelfSignedTLS)
+	}
+
+	now := time.Now()
+	if now.Before(cert.NotBefore) || now.After(cert.NotAfter) {
+		return errors.New("The client certificate is expired or not yet valid")
+	}
+
+	if c.TokenEndpointAuthMethod == TokenEndpointAuthMethodTLS {
+		return c.verifyTLSClientAuthSubject(cert)
+	}
+
+	if c.TLSClientAuthCertificate != "" {
+		pinned, err := parseCertificate(c.TLSClientAuthCertificate)
+		if err != nil {
+			return err
+		}
+
+		if subtle.ConstantTimeCompare(pinned.Raw, cert.Raw) == 1 {
+			return nil
+		}
+	}
+
+	if c.TLSClientAuthPublicKeySHA256 != "" {
+		hash := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+		if subtle.ConstantTimeCompare([]byte(base64.RawURLEncoding.EncodeToString(hash[:])), []byte(c.TLSClientAuthPublicKeySHA256)) == 1 {
+			return nil
+		}
+	}
+
+	return errors.New("The client certificate does not match the certificate pinned for this client")
+}
+
+func (c *Client) verifyTLSClientAuthSubject(cert *x509.Certificate) error {
+	switch {
+	case c.TLSClientAuthSubjectDN != "":
+		dn, err := certificateSubjectDN(cert)
+		if err != nil {
+			return err
+		}
+		if normalizeDN(dn) == normalizeDN(c.TLSClientAuthSubjectDN) {
+			return nil
+		}
+	case c.TLSClientAuthSANDNS != "":
+		for _, name := range cert.DNSNames {
+			if strings.EqualFold(name, c.TLSClientAuthSANDNS) {
+				return nil
+			}
+		}
+	case c.TLSClientAuthSANURI != "":
+		uris, err := certificateSANURIs(cert)
+		if err != nil {
+			return err
+		}
+		for _, uri := range uris {
+			if uri == c.TLSClientAuthSANURI {
+				return nil
+			}
+		}
+	case c.TLSClientAuthSANIP != "":
+		expected := net.ParseIP(c.TLSClientAuthSANIP)
+		for _, ip := range cert.IPAddresses {
+			if ip.Equal(expected) {
+				return nil
+			}
+		}
+	case c.TLSClientAuthSANEmail != "":
+		for _, email := range cert.EmailAddresses {
+			if email == c.TLSClientAuthSANEmail {
+				return nil
+			}
+		}
+	}
+
+	return errors.New("The subject of the client certificate does not match the subject registered for this client")
+}
+
+// ValidateTLSClientAuth checks that the token endpoint authentication method is supported, that a certificate
+// or public key is pinned if self_signed_tls_client_auth is used and that exactly one subject is registered if
+// tls_client_auth is used.
+func (c *Client) ValidateTLSClientAuth() error {
+	switch c.TokenEndpointAuthMethod {
+	case "", TokenEndpointAuthMethodSecretBasic:
+		return nil
+	case TokenEndpointAuthMethodSelfSignedTLS:
+	case TokenEndpointAuthMethodTLS:
+		return c.validateTLSClientAuthSubject()
+	default:
+		return errors.Errorf("Token endpoint authentication method %s is not supported", c.TokenEndpointAuthMethod)
+	}
+
+	if c.TLSClientAuthCertificate == "" && c.TLSClientAuthPublicKeySHA256 == "" {
+		return errors.Errorf("Either tls_client_auth_certificate or tls_client_auth_public_key_sha256 must be set when using %s", TokenEndpointAuthMethodSelfSignedTLS)
+	}
+
+	if c.TLSClientAuthCertificate != "" {
+		if _, err := parseCertificate(c.TLSClientAuthCertificate); err != nil {
+			return err
+		}
+	}
+
+	if c.TLSClientAuthPublicKeySHA256 != "" {
+		if hash, err := base64.RawURLEncoding.DecodeString(c.TLSClientAuthPublicKeySHA256); err != nil || len(hash) != sha256.Size {
+			return errors.New("tls_client_auth_public_key_sha256 must be an unpadded, base64url encoded SHA-256 hash")
+		}
+	}
+
+	return nil
+}
+
+func (c *Client) validateTLSClientAuthSubject() error {
+	var set int
+	for _, v := range []string{c.TLSClientAuthSubjectDN, c.TLSClientAuthSANDNS, c.TLSClientAuthSANURI, c.TLSClientAuthSANIP, c.TLSClientAuthSANEmail} {
+		if v != "" {
+			set++
+		}
+	}
+
+	if set != 1 {
+		return errors.Errorf("Exactly one of tls_client_auth_subject_dn, tls_client_auth_san_dns, tls_client_auth_san_uri, tls_client_auth_san_ip and tls_client_auth_san_email must be set when using %s", TokenEndpointAuthMethodTLS)
+	}
+
+	if c.TLSClientAuthSANIP != "" && net.ParseIP(c.TLSClientAuthSANIP) == nil {
+		return errors.New("tls_client_auth_san_ip must be an IPv4 or IPv6 address")
+	}
+
+	return nil
+}
+
+func parseCertificate(encoded string) (*x509.Certificate, error) {
+	block, _ := pem.Decode([]byte(encoded))
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.New("tls_client_auth_certificate must be a PEM encoded certificate")
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return cert, nil
+}
@@ -0,0 +1,152 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// crypto/x509 of Go 1.9 neither formats distinguished names nor parses URI subject alternative names, both are
+// needed to match the certificates of clients using tls_client_auth.
+
+var oidExtensionSubjectAltName = asn1.ObjectIdentifier{2, 5, 29, 17}
+
+var attributeTypeNames = map[string]string{
+	"2.5.4.3":                    "CN",
+	"2.5.4.6":                    "C",
+	"2.5.4.7":                    "L",
+	"2.5.4.8":                    "ST",
+	"2.5.4.9":                    "STREET",
+	"2.5.4.10":                   "O",
+	"2.5.4.11":                   "OU",
+	"0.9.2342.19200300.100.1.1":  "UID",
+	"0.9.2342.19200300.100.1.25": "DC",
+}
+
+// certificateSubjectDN returns the subject of the certificate as a RFC 4514 string, for example
+// "CN=client,O=Example,C=US".
+func certificateSubjectDN(cert *x509.Certificate) (string, error) {
+	var rdns pkix.RDNSequence
+	if rest, err := asn1.Unmarshal(cert.RawSubject, &rdns); err != nil {
+		return "", errors.WithStack(err)
+	} else if len(rest) > 0 {
+		return "", errors.New("The subject of the client certificate contains trailing data")
+	}
+
+	parts := make([]string, 0, len(rdns))
+	for i := len(rdns) - 1; i >= 0; i-- {
+		attributes := make([]string, len(rdns[i]))
+		for k, attribute := range rdns[i] {
+			name, ok := attributeTypeNames[attribute.Type.String()]
+			if !ok {
+				name = attribute.Type.String()
+			}
+
+			value, ok := attribute.Value.(string)
+			if !ok {
+				der, err := asn1.Marshal(attribute.Value)
+				if err != nil {
+					return "", errors.WithStack(err)
+				}
+				attributes[k] = name + "=#" + hex.EncodeToString(der)
+				continue
+			}
+			attributes[k] = name + "=" + escapeDNValue(value)
+		}
+		parts = append(parts, strings.Join(attributes, "+"))
+	}
+
+	return strings.Join(parts, ","), nil
+}
+
+func escapeDNValue(value string) string {
+	var b bytes.Buffer
+	for i, r := range value {
+		switch {
+		case strings.ContainsRune(`,+"\<>;`, r),
+			i == 0 && (r == ' ' || r == '#'),
+			i == len(value)-1 && r == ' ':
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		case r == 0:
+			b.WriteString(`\00`)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// normalizeDN removes insignificant whitespace around the relative distinguished names of dn and lower cases it,
+// so that distinguished names can be compared case insensitively.
+func normalizeDN(dn string) string {
+	var rdns []string
+	var current []rune
+	var escaped bool
+	for _, r := range dn {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == ',':
+			rdns = append(rdns, strings.TrimSpace(string(current)))
+			current = current[:0]
+			continue
+		}
+		current = append(current, r)
+	}
+	rdns = append(rdns, strings.TrimSpace(string(current)))
+
+	return strings.ToLower(strings.Join(rdns, ","))
+}
+
+// certificateSANURIs returns the uniformResourceIdentifier subject alternative names of the certificate.
+func certificateSANURIs(cert *x509.Certificate) ([]string, error) {
+	var uris []string
+	for _, extension := range cert.Extensions {
+		if !extension.Id.Equal(oidExtensionSubjectAltName) {
+			continue
+		}
+
+		var names asn1.RawValue
+		if _, err := asn1.Unmarshal(extension.Value, &names); err != nil {
+			return nil, errors.WithStack(err)
+		} else if !names.IsCompound || names.Tag != asn1.TagSequence || names.Class != asn1.ClassUniversal {
+			return nil, errors.New("The subject alternative name extension of the client certificate is malformed")
+		}
+
+		for rest := names.Bytes; len(rest) > 0; {
+			var name asn1.RawValue
+			var err error
+			if rest, err = asn1.Unmarshal(rest, &name); err != nil {
+				return nil, errors.WithStack(err)
+			}
+
+			// uniformResourceIdentifier is the [6] IA5String choice of GeneralName, see RFC 5280 Section 4.2.1.6
+			if name.Class == asn1.ClassContextSpecific && name.Tag == 6 {
+				uris = append(uris, string(name.Bytes))
+			}
+		}
+	}
+	return uris, nil
+}
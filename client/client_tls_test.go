@@ -0,0 +1,162 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createTestCertificate(t *testing.T) (*x509.Certificate, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return cert, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func TestVerifyTLSClientCertificate(t *testing.T) {
+	cert, encoded := createTestCertificate(t)
+	other, _ := createTestCertificate(t)
+	hash := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+
+	c := &Client{ID: "foo", TokenEndpointAuthMethod: TokenEndpointAuthMethodSelfSignedTLS, TLSClientAuthCertificate: encoded}
+	require.NoError(t, c.ValidateTLSClientAuth())
+	assert.NoError(t, c.VerifyTLSClientCertificate(cert))
+	assert.Error(t, c.VerifyTLSClientCertificate(other))
+
+	c = &Client{ID: "foo", TokenEndpointAuthMethod: TokenEndpointAuthMethodSelfSignedTLS, TLSClientAuthPublicKeySHA256: base64.RawURLEncoding.EncodeToString(hash[:])}
+	require.NoError(t, c.ValidateTLSClientAuth())
+	assert.NoError(t, c.VerifyTLSClientCertificate(cert))
+	assert.Error(t, c.VerifyTLSClientCertificate(other))
+
+	c = &Client{ID: "foo", TLSClientAuthCertificate: encoded}
+	assert.Error(t, c.VerifyTLSClientCertificate(cert))
+}
+
+func createTestCertificateFromTemplate(t *testing.T, template *x509.Certificate) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template.SerialNumber = big.NewInt(1)
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert
+}
+
+func TestVerifyTLSClientCertificateSubject(t *testing.T) {
+	cert := createTestCertificateFromTemplate(t, &x509.Certificate{
+		Subject:        pkix.Name{CommonName: "client", Organization: []string{"Example, Inc."}, Country: []string{"US"}},
+		DNSNames:       []string{"client.example.com"},
+		IPAddresses:    []net.IP{net.ParseIP("10.0.0.1")},
+		EmailAddresses: []string{"client@example.com"},
+	})
+
+	dn, err := certificateSubjectDN(cert)
+	require.NoError(t, err)
+	assert.Equal(t, `CN=client,O=Example\, Inc.,C=US`, dn)
+
+	sanURI, err := asn1.Marshal([]asn1.RawValue{{Class: asn1.ClassContextSpecific, Tag: 6, Bytes: []byte("spiffe://example.com/client")}})
+	require.NoError(t, err)
+	uriCert := createTestCertificateFromTemplate(t, &x509.Certificate{
+		Subject:         pkix.Name{CommonName: "client"},
+		ExtraExtensions: []pkix.Extension{{Id: oidExtensionSubjectAltName, Value: sanURI}},
+	})
+
+	for k, tc := range []struct {
+		c     *Client
+		cert  *x509.Certificate
+		valid bool
+	}{
+		{c: &Client{TLSClientAuthSubjectDN: `CN=client,O=Example\, Inc.,C=US`}, cert: cert, valid: true},
+		{c: &Client{TLSClientAuthSubjectDN: `cn=Client, o=Example\, Inc., c=US`}, cert: cert, valid: true},
+		{c: &Client{TLSClientAuthSubjectDN: `CN=client,O=Example\, Inc.`}, cert: cert},
+		{c: &Client{TLSClientAuthSANDNS: "client.example.com"}, cert: cert, valid: true},
+		{c: &Client{TLSClientAuthSANDNS: "other.example.com"}, cert: cert},
+		{c: &Client{TLSClientAuthSANIP: "10.0.0.1"}, cert: cert, valid: true},
+		{c: &Client{TLSClientAuthSANIP: "10.0.0.2"}, cert: cert},
+		{c: &Client{TLSClientAuthSANEmail: "client@example.com"}, cert: cert, valid: true},
+		{c: &Client{TLSClientAuthSANURI: "spiffe://example.com/client"}, cert: uriCert, valid: true},
+		{c: &Client{TLSClientAuthSANURI: "spiffe://example.com/client"}, cert: cert},
+	} {
+		tc.c.ID = "foo"
+		tc.c.TokenEndpointAuthMethod = TokenEndpointAuthMethodTLS
+		require.NoError(t, tc.c.ValidateTLSClientAuth(), "%d", k)
+
+		err := tc.c.VerifyTLSClientCertificate(tc.cert)
+		if tc.valid {
+			assert.NoError(t, err, "%d", k)
+		} else {
+			assert.Error(t, err, "%d", k)
+		}
+	}
+}
+
+func TestValidateTLSClientAuth(t *testing.T) {
+	for k, tc := range []struct {
+		c     *Client
+		valid bool
+	}{
+		{c: &Client{}, valid: true},
+		{c: &Client{TokenEndpointAuthMethod: TokenEndpointAuthMethodSecretBasic}, valid: true},
+		{c: &Client{TokenEndpointAuthMethod: "private_key_jwt"}},
+		{c: &Client{TokenEndpointAuthMethod: TokenEndpointAuthMethodSelfSignedTLS}},
+		{c: &Client{TokenEndpointAuthMethod: TokenEndpointAuthMethodSelfSignedTLS, TLSClientAuthCertificate: "foo"}},
+		{c: &Client{TokenEndpointAuthMethod: TokenEndpointAuthMethodSelfSignedTLS, TLSClientAuthPublicKeySHA256: "foo"}},
+		{c: &Client{TokenEndpointAuthMethod: TokenEndpointAuthMethodTLS}},
+		{c: &Client{TokenEndpointAuthMethod: TokenEndpointAuthMethodTLS, TLSClientAuthSubjectDN: "CN=client"}, valid: true},
+		{c: &Client{TokenEndpointAuthMethod: TokenEndpointAuthMethodTLS, TLSClientAuthSubjectDN: "CN=client", TLSClientAuthSANDNS: "client.example.com"}},
+		{c: &Client{TokenEndpointAuthMethod: TokenEndpointAuthMethodTLS, TLSClientAuthSANIP: "foo"}},
+	} {
+		err := tc.c.ValidateTLSClientAuth()
+		if tc.valid {
+			assert.NoError(t, err, "%d", k)
+		} else {
+			assert.Error(t, err, "%d", k)
+		}
+	}
+}
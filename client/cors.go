@@ -0,0 +1,89 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// CORSOrigins caches the origins all approved clients registered in allowed_cors_origins for a given time to live.
+// Changes to clients become visible once the cache expires.
+type CORSOrigins struct {
+	Manager Storage
+	TTL     time.Duration
+	L       logrus.FieldLogger
+
+	origins   map[string]bool
+	expiresAt time.Time
+	sync.RWMutex
+}
+
+// IsAllowed returns true if a client registered the origin. If the origins can not be loaded, the previously loaded
+// origins are used.
+func (o *CORSOrigins) IsAllowed(origin string) bool {
+	origin = normalizeOrigin(origin)
+
+	o.RLock()
+	origins, expiresAt := o.origins, o.expiresAt
+	o.RUnlock()
+
+	if origins == nil || time.Now().After(expiresAt) {
+		loaded, err := o.load()
+		if err != nil {
+			o.L.WithError(err).Errorln("Could not load the allowed CORS origins of clients")
+		} else {
+			origins = loaded
+		}
+
+		// Failed loads are not retried before the cache expires again, to not hit the storage on every request.
+		o.Lock()
+		o.origins, o.expiresAt = origins, time.Now().Add(o.TTL)
+		o.Unlock()
+	}
+
+	return origins[origin]
+}
+
+func (o *CORSOrigins) load() (map[string]bool, error) {
+	origins := map[string]bool{}
+	for page := 0; ; page += 500 {
+		clients, err := o.Manager.GetClients(500, page)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, c := range clients {
+			if c.IsPending() {
+				continue
+			}
+			for _, origin := range c.AllowedCORSOrigins {
+				origins[normalizeOrigin(origin)] = true
+			}
+		}
+
+		if len(clients) < 500 {
+			break
+		}
+	}
+	return origins, nil
+}
+
+func normalizeOrigin(origin string) string {
+	return strings.ToLower(strings.TrimSuffix(origin, "/"))
+}
@@ -0,0 +1,42 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCORSOrigins(t *testing.T) {
+	m := NewMemoryManager(nil)
+	require.NoError(t, m.CreateClient(&Client{ID: "spa", AllowedCORSOrigins: []string{"https://App.localhost/"}}))
+	require.NoError(t, m.CreateClient(&Client{ID: "pending", Status: ClientStatusPending, AllowedCORSOrigins: []string{"https://pending.localhost"}}))
+
+	origins := &CORSOrigins{Manager: m, TTL: time.Hour, L: logrus.New()}
+	assert.True(t, origins.IsAllowed("https://app.localhost"))
+	assert.False(t, origins.IsAllowed("https://pending.localhost"))
+	assert.False(t, origins.IsAllowed("https://other.localhost"))
+
+	// Changes become visible once the cache expires.
+	require.NoError(t, m.CreateClient(&Client{ID: "other", AllowedCORSOrigins: []string{"https://other.localhost"}}))
+	assert.False(t, origins.IsAllowed("https://other.localhost"))
+
+	origins.expiresAt = time.Now().Add(-time.Second)
+	assert.True(t, origins.IsAllowed("https://other.localhost"))
+}
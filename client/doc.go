@@ -40,6 +40,24 @@ type swaggerUpdateClientPayload struct {
 	Body Client
 }
 
+// swagger:parameters patchOAuth2Client
+type swaggerPatchClientPayload struct {
+	// in: path
+	// required: true
+	ID string `json:"id"`
+
+	// A JSON Merge Patch or a JSON Patch, depending on the Content-Type.
+	//
+	// in: body
+	// required: true
+	Body interface{}
+
+	// The ETag of the client. If the client was changed since, the patch is rejected.
+	//
+	// in: header
+	IfMatch string `json:"If-Match"`
+}
+
 // swagger:parameters listOAuth2Clients
 type swaggerListClientsParameter struct {
 
@@ -50,6 +68,10 @@ type swaggerListClientsParameter struct {
 	// The offset from where to start looking.
 	// in: query
 	Offset int `json:"offset"`
+
+	// Only return clients with this status. Supports "pending".
+	// in: query
+	Status string `json:"status"`
 }
 
 // A list of clients.
@@ -60,7 +82,14 @@ type swaggerListClientsResult struct {
 	Body []Client
 }
 
-// swagger:parameters getOAuth2Client deleteOAuth2Client
+// swagger:parameters registerOAuth2Client
+type swaggerRegisterClientPayload struct {
+	// in: body
+	// required: true
+	Body Client
+}
+
+// swagger:parameters getOAuth2Client deleteOAuth2Client approveOAuth2Client rejectOAuth2Client
 type swaggerQueryClientPayload struct {
 	// The id of the OAuth 2.0 Client.
 	//
@@ -68,3 +97,32 @@ type swaggerQueryClientPayload struct {
 	// in: path
 	ID string `json:"id"`
 }
+
+// swagger:parameters dynamicallyRegisterOAuth2Client
+type swaggerDynamicallyRegisterClientPayload struct {
+	// in: body
+	// required: true
+	Body Client
+}
+
+// swagger:parameters getDynamicallyRegisteredOAuth2Client deleteDynamicallyRegisteredOAuth2Client
+type swaggerQueryDynamicallyRegisteredClientPayload struct {
+	// The id of the OAuth 2.0 Client.
+	//
+	// unique: true
+	// in: path
+	ID string `json:"id"`
+}
+
+// swagger:parameters updateDynamicallyRegisteredOAuth2Client
+type swaggerUpdateDynamicallyRegisteredClientPayload struct {
+	// The id of the OAuth 2.0 Client.
+	//
+	// in: path
+	// required: true
+	ID string `json:"id"`
+
+	// in: body
+	// required: true
+	Body DynamicRegistrationResponse
+}
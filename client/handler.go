@@ -17,12 +17,13 @@ package client
 import (
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/ory/herodot"
 	"github.com/ory/hydra/firewall"
-	"github.com/ory/hydra/rand/sequence"
+	"github.com/ory/hydra/pkg"
 	"github.com/ory/ladon"
 	"github.com/ory/pagination"
 	"github.com/pkg/errors"
@@ -33,6 +34,25 @@ type Handler struct {
 	H              herodot.Writer
 	W              firewall.Firewall
 	ResourcePrefix string
+
+	// RegistrationEnabled allows anyone to register clients at RegistrationPath, see Register.
+	RegistrationEnabled bool
+
+	// SecretPrefix is prepended to generated client secrets so leaked secrets can be found by secret scanners.
+	SecretPrefix string
+
+	// Secrets generates the secrets of clients which are created without one. NewDefaultSecretPolicy is used if it
+	// is nil.
+	Secrets SecretGenerator
+
+	// DynamicRegistration is DynamicRegistrationOpen or DynamicRegistrationProtected to enable dynamic client
+	// registration at DynamicRegistrationPath, see DynamicRegister. RegistrationTokens must be set if it is enabled.
+	DynamicRegistration string
+	RegistrationTokens  RegistrationTokenManager
+
+	// Issuer is the public URL of this server, used to build the registration_client_uri of dynamically registered
+	// clients.
+	Issuer string
 }
 
 const (
@@ -57,12 +77,34 @@ func (h *Handler) PrefixResource(resource string) string {
 	return h.ResourcePrefix + ":" + resource
 }
 
+// GenerateSecret generates a client secret using Secrets and prepends SecretPrefix to it.
+func (h *Handler) GenerateSecret() (string, *SecretGeneration, error) {
+	generator := h.Secrets
+	if generator == nil {
+		generator = NewDefaultSecretPolicy()
+	}
+
+	secret, generation, err := generator.GenerateSecret()
+	if err != nil {
+		return "", nil, err
+	}
+	return h.SecretPrefix + secret, generation, nil
+}
+
 func (h *Handler) SetRoutes(r *httprouter.Router) {
 	r.GET(ClientsHandlerPath, h.List)
 	r.POST(ClientsHandlerPath, h.Create)
 	r.GET(ClientsHandlerPath+"/:id", h.Get)
 	r.PUT(ClientsHandlerPath+"/:id", h.Update)
+	r.PATCH(ClientsHandlerPath+"/:id", h.Patch)
 	r.DELETE(ClientsHandlerPath+"/:id", h.Delete)
+	r.POST(ClientsHandlerPath+"/:id/approve", h.Approve)
+	r.POST(ClientsHandlerPath+"/:id/reject", h.Reject)
+	r.POST(RegistrationPath, h.Register)
+	r.POST(DynamicRegistrationPath, h.DynamicRegister)
+	r.GET(DynamicRegistrationPath+"/:id", h.GetDynamicRegistration)
+	r.PUT(DynamicRegistrationPath+"/:id", h.UpdateDynamicRegistration)
+	r.DELETE(DynamicRegistrationPath+"/:id", h.DeleteDynamicRegistration)
 }
 
 // swagger:route POST /clients oAuth2 createOAuth2Client
@@ -130,18 +172,59 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request, _ httprouter.Pa
 		return
 	}
 
+	var generation *SecretGeneration
 	if len(c.Secret) == 0 {
-		secret, err := sequence.RuneSequence(12, []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ1234567890_-.~"))
+		secret, g, err := h.GenerateSecret()
 		if err != nil {
-			h.H.WriteError(w, r, errors.WithStack(err))
+			h.H.WriteError(w, r, err)
 			return
 		}
-		c.Secret = string(secret)
+		c.Secret, generation = secret, g
 	} else if len(c.Secret) < 6 {
 		h.H.WriteError(w, r, errors.New("The client secret must be at least 6 characters long"))
 		return
 	}
 
+	if err := c.ValidateTLSClientAuth(); err != nil {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := c.ValidateConsentChallengeLifespan(); err != nil {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := c.ValidateTokenLifespans(); err != nil {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := c.ValidateLogoutURIs(); err != nil {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := c.ValidateResponseEncryption(); err != nil {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := c.ValidateUserinfoSignedResponseAlg(); err != nil {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := c.ValidateAudience(); err != nil {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := c.ValidateAllowedCORSOrigins(); err != nil {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, err)
+		return
+	}
+
 	secret := c.Secret
 	if err := h.Manager.CreateClient(&c); err != nil {
 		h.H.WriteError(w, r, err)
@@ -149,9 +232,11 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request, _ httprouter.Pa
 	}
 
 	c.Secret = ""
+	c.SecretGeneration = nil
 
 	if !c.Public {
 		c.Secret = secret
+		c.SecretGeneration = generation
 	}
 
 	h.H.WriteCreated(w, r, ClientsHandlerPath+"/"+c.GetID(), &c)
@@ -236,7 +321,48 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request, ps httprouter.P
 		secret = c.Secret
 	}
 
+	if err := c.ValidateTLSClientAuth(); err != nil {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := c.ValidateConsentChallengeLifespan(); err != nil {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := c.ValidateTokenLifespans(); err != nil {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := c.ValidateLogoutURIs(); err != nil {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := c.ValidateResponseEncryption(); err != nil {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := c.ValidateUserinfoSignedResponseAlg(); err != nil {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := c.ValidateAudience(); err != nil {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := c.ValidateAllowedCORSOrigins(); err != nil {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, err)
+		return
+	}
+
 	c.ID = ps.ByName("id")
+	c.Status = o.Status
 	if err := h.Manager.UpdateClient(&c); err != nil {
 		h.H.WriteError(w, r, err)
 		return
@@ -246,11 +372,178 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request, ps httprouter.P
 	h.H.WriteCreated(w, r, ClientsHandlerPath+"/"+c.GetID(), &c)
 }
 
+// swagger:route PATCH /clients/{id} oAuth2 patchOAuth2Client
+//
+// Patch an OAuth 2.0 Client
+//
+// Change single fields of an existing OAuth 2.0 Client, for example to add a redirect URI, without replacing the
+// whole client. Send either a JSON Merge Patch (RFC 7386) with `Content-Type: application/merge-patch+json` or a JSON
+// Patch (RFC 6902) with `Content-Type: application/json-patch+json`. The patch is applied to the stored client
+// atomically, so concurrent patches of different fields do not overwrite each other.
+//
+// To make sure the client did not change since it was read, send its `ETag` in the `If-Match` header or add a JSON
+// Patch `test` operation. If the client changed, 412 or 409 is returned respectively. The secret can be changed by
+// setting `client_secret`, it is returned only in this response. The id and status of a client can not be changed.
+//
+// The subject making the request needs to be assigned to a policy containing:
+//
+//  ```
+//  {
+//    "resources": ["rn:hydra:clients"],
+//    "actions": ["update"],
+//    "effect": "allow"
+//  }
+//  ```
+//
+//  Additionally, the context key "owner" is set to the owner of the client, allowing policies such as:
+//
+//  ```
+//  {
+//    "resources": ["rn:hydra:clients"],
+//    "actions": ["update"],
+//    "effect": "allow",
+//    "conditions": { "owner": { "type": "EqualsSubjectCondition" } }
+//  }
+//  ```
+//
+//     Consumes:
+//     - application/merge-patch+json
+//     - application/json-patch+json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Security:
+//       oauth2: hydra.clients
+//
+//     Responses:
+//       200: oAuth2Client
+//       400: genericError
+//       401: genericError
+//       403: genericError
+//       409: genericError
+//       412: genericError
+//       415: genericError
+//       500: genericError
+func (h *Handler) Patch(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var ctx = r.Context()
+	var id = ps.ByName("id")
+
+	patch, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		h.H.WriteError(w, r, errors.WithStack(err))
+		return
+	}
+
+	o, err := h.Manager.GetConcreteClient(id)
+	if err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	if _, err := h.W.TokenAllowed(ctx, h.W.TokenFromRequest(r), &firewall.TokenAccessRequest{
+		Resource: h.PrefixResource(ClientsResource),
+		Action:   "update",
+		Context: ladon.Context{
+			"owner": o.Owner,
+		},
+	}, Scope); err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	// invalid is set if the patched client is invalid, so that it is reported with 400.
+	var invalid error
+	var secret string
+	c, err := h.Manager.PatchClient(id, func(c *Client) (*Client, error) {
+		invalid, secret = nil, ""
+
+		if match := r.Header.Get("If-Match"); match != "" {
+			etag, err := pkg.ETag(c)
+			if err != nil {
+				return nil, err
+			} else if !pkg.ETagMatches(match, etag) {
+				return nil, errors.WithStack(pkg.ErrPreconditionFailed)
+			}
+		}
+
+		doc, err := json.Marshal(c)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		out, err := pkg.ApplyPatch(r.Header.Get("Content-Type"), doc, patch)
+		if err != nil {
+			return nil, err
+		}
+
+		var p Client
+		if err := json.Unmarshal(out, &p); err != nil {
+			invalid = errors.WithStack(err)
+			return nil, invalid
+		}
+
+		if len(p.Secret) > 0 && len(p.Secret) < 6 {
+			invalid = errors.New("The client secret must be at least 6 characters long")
+			return nil, invalid
+		} else if err := p.ValidateTLSClientAuth(); err != nil {
+			invalid = err
+			return nil, invalid
+		} else if err := p.ValidateConsentChallengeLifespan(); err != nil {
+			invalid = err
+			return nil, invalid
+		} else if err := p.ValidateTokenLifespans(); err != nil {
+			invalid = err
+			return nil, invalid
+		} else if err := p.ValidateLogoutURIs(); err != nil {
+			invalid = err
+			return nil, invalid
+		} else if err := p.ValidateResponseEncryption(); err != nil {
+			invalid = err
+			return nil, invalid
+		} else if err := p.ValidateUserinfoSignedResponseAlg(); err != nil {
+			invalid = err
+			return nil, invalid
+		} else if err := p.ValidateAudience(); err != nil {
+			invalid = err
+			return nil, invalid
+		} else if err := p.ValidateAllowedCORSOrigins(); err != nil {
+			invalid = err
+			return nil, invalid
+		}
+
+		p.Status = c.Status
+		secret = p.Secret
+		return &p, nil
+	})
+	if invalid != nil {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, invalid)
+		return
+	} else if err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	c.Secret = ""
+	etag, err := pkg.ETag(c)
+	if err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	c.Secret = secret
+	w.Header().Set("ETag", etag)
+	h.H.Write(w, r, c)
+}
+
 // swagger:route GET /clients oAuth2 listOAuth2Clients
 //
 // List OAuth 2.0 Clients
 //
-// This endpoint lists all clients in the database, and never returns client secrets.
+// This endpoint lists all clients in the database, and never returns client secrets. Set `status=pending` to list only
+// clients which registered themselves and are waiting for approval.
 //
 // OAuth 2.0 clients are used to perform OAuth 2.0 and OpenID Connect flows. Usually, OAuth 2.0 clients are generated for applications which want to consume your OAuth 2.0 or OpenID Connect capabilities. To manage ORY Hydra, you will need an OAuth 2.0 Client as well. Make sure that this endpoint is well protected and only callable by first-party components.
 //
@@ -292,6 +585,20 @@ func (h *Handler) List(w http.ResponseWriter, r *http.Request, ps httprouter.Par
 	}
 
 	limit, offset := pagination.Parse(r, 100, 0, 500)
+	if status := r.URL.Query().Get("status"); status != "" {
+		clients, err := filterClientsByStatus(h.Manager, status, limit, offset)
+		if err != nil {
+			h.H.WriteError(w, r, err)
+			return
+		}
+
+		for k := range clients {
+			clients[k].Secret = ""
+		}
+		h.H.Write(w, r, clients)
+		return
+	}
+
 	c, err := h.Manager.GetClients(limit, offset)
 	if err != nil {
 		h.H.WriteError(w, r, err)
@@ -384,6 +691,13 @@ func (h *Handler) Get(w http.ResponseWriter, r *http.Request, ps httprouter.Para
 	}
 
 	c.Secret = ""
+	etag, err := pkg.ETag(c)
+	if err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag)
 	h.H.Write(w, r, c)
 }
 
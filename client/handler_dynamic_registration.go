@@ -0,0 +1,541 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/fosite"
+	"github.com/ory/hydra/firewall"
+	"github.com/ory/hydra/pkg"
+	"github.com/ory/hydra/rand/sequence"
+	"github.com/ory/ladon"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DynamicRegistrationPath points to the OAuth 2.0 Dynamic Client Registration endpoint (RFC 7591). Followed by
+	// the client id, it points to the client configuration endpoint (RFC 7592).
+	DynamicRegistrationPath = "/connect/register"
+
+	// DynamicRegistrationOpen allows anyone to register clients at DynamicRegistrationPath.
+	DynamicRegistrationOpen = "open"
+
+	// DynamicRegistrationProtected requires an initial access token, an access token granted RegistrationScope whose
+	// subject is allowed the action "register" on RegistrationResource, to register clients at
+	// DynamicRegistrationPath.
+	DynamicRegistrationProtected = "protected"
+
+	// RegistrationScope is the scope initial access tokens must be granted.
+	RegistrationScope = "hydra.clients.register"
+
+	// RegistrationGrantTypeResource, RegistrationScopeResource and RegistrationRedirectURIResource are the resources
+	// on which the registrant must be allowed the action "register" for each grant type, scope and redirect URI of a
+	// dynamically registered client.
+	RegistrationGrantTypeResource   = "clients:registration:grant-types:%s"
+	RegistrationScopeResource       = "clients:registration:scopes:%s"
+	RegistrationRedirectURIResource = "clients:registration:redirect-uris:%s"
+
+	// RegistrationBackChannelLogoutURIResource and RegistrationJSONWebKeysURIResource are the resources on which the
+	// registrant must be allowed the action "register" for the back-channel logout URI and the JSON Web Key Set URL of
+	// a dynamically registered client. ORY Hydra sends requests to both, so they must not point to internal services.
+	RegistrationBackChannelLogoutURIResource = "clients:registration:backchannel-logout-uris:%s"
+	RegistrationJSONWebKeysURIResource       = "clients:registration:jwks-uris:%s"
+)
+
+// DynamicRegistrationResponse is the response of the dynamic client registration and client configuration endpoints.
+//
+// swagger:model dynamicClientRegistration
+type DynamicRegistrationResponse struct {
+	*Client
+
+	// ClientID is the id of the client, as named by RFC 7591.
+	ClientID string `json:"client_id"`
+
+	// ClientSecretExpiresAt is the time the client secret expires at, 0 if it does not expire.
+	ClientSecretExpiresAt int64 `json:"client_secret_expires_at"`
+
+	// RegistrationAccessToken authorizes requests to the client configuration endpoint. It is only returned when the
+	// client is registered.
+	RegistrationAccessToken string `json:"registration_access_token,omitempty"`
+
+	// RegistrationClientURI is the location of the client configuration endpoint of the client.
+	RegistrationClientURI string `json:"registration_client_uri"`
+}
+
+type registrationError struct {
+	Name        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+// swagger:route POST /connect/register oAuth2 dynamicallyRegisterOAuth2Client
+//
+// Register an OAuth 2.0 Client using OpenID Connect Dynamic Client Registration
+//
+// This endpoint implements OAuth 2.0 Dynamic Client Registration (RFC 7591) if OAUTH2_DYNAMIC_CLIENT_REGISTRATION is
+// set. If it is "protected", the request must carry an initial access token granted the `hydra.clients.register`
+// scope, whose subject is allowed the action "register" on "rn:hydra:clients:registration". The subject becomes the
+// owner of the client. If it is "open", anyone may register clients as the anonymous subject.
+//
+// Registered clients are pending until an administrator approves them using `/clients/{id}/approve`, unless the
+// registrant is allowed the action "approve" on "rn:hydra:clients:registration" with the context keys "remoteIP" and
+// "scope". Only the client metadata defined by OpenID Connect Dynamic Client Registration is accepted, the registrant
+// must be allowed the action "register" on "rn:hydra:clients:registration:grant-types:<grant-type>",
+// "rn:hydra:clients:registration:scopes:<scope>" and "rn:hydra:clients:registration:redirect-uris:<redirect-uri>"
+// for each grant type, scope and redirect URI of the client, and on
+// "rn:hydra:clients:registration:backchannel-logout-uris:<uri>" and "rn:hydra:clients:registration:jwks-uris:<uri>"
+// for the back-channel logout URI and the JSON Web Key Set URL, which must use https. The context key "remoteIP" is
+// set to the IP address of the registrant, allowing policies such as:
+//
+//  ```
+//  {
+//    "subjects": ["<.*>"],
+//    "resources": ["rn:hydra:clients:registration:redirect-uris:https://<[a-z0-9-]+>.apps.example.com/<.*>"],
+//    "actions": ["register"],
+//    "effect": "allow",
+//    "conditions": { "remoteIP": { "type": "CIDRCondition", "options": { "cidr": "10.0.0.0/8" } } }
+//  }
+//  ```
+//
+// The response contains the client secret and a registration access token, which authorizes the client to read,
+// update and delete its registration at the `registration_client_uri`. Neither can be retrieved later on.
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       201: dynamicClientRegistration
+//       400: genericError
+//       401: genericError
+//       404: genericError
+//       500: genericError
+func (h *Handler) DynamicRegister(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var ctx = r.Context()
+
+	if h.DynamicRegistration == "" {
+		h.H.WriteErrorCode(w, r, http.StatusNotFound, errors.New("Dynamic client registration is disabled"))
+		return
+	}
+
+	var subject string
+	if h.DynamicRegistration == DynamicRegistrationProtected {
+		token, err := h.W.TokenAllowed(ctx, h.W.TokenFromRequest(r), &firewall.TokenAccessRequest{
+			Resource: h.PrefixResource(RegistrationResource),
+			Action:   "register",
+		}, RegistrationScope)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			writeRegistrationError(w, http.StatusUnauthorized, "invalid_token", "The initial access token is missing, invalid or not allowed to register clients")
+			return
+		}
+		subject = token.Subject
+	}
+
+	var m Client
+	if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+		writeRegistrationError(w, http.StatusBadRequest, "invalid_client_metadata", "The client metadata could not be decoded")
+		return
+	}
+
+	c := &Client{}
+	applyRegistrationMetadata(c, &m)
+	if name, err := h.validateRegistration(r, subject, c); err != nil {
+		writeRegistrationError(w, http.StatusBadRequest, name, err.Error())
+		return
+	}
+
+	secret, generation, err := h.GenerateSecret()
+	if err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	c.Secret = secret
+	c.Owner = subject
+	c.Status = ClientStatusPending
+	if h.approvesRegistration(r, subject, c) {
+		c.Status = ""
+	}
+
+	if err := h.Manager.CreateClient(c); err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	token, err := h.newRegistrationToken(c.ID)
+	if err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	c.Secret = ""
+	if !c.Public {
+		c.Secret = secret
+		c.SecretGeneration = generation
+	}
+
+	response := h.dynamicRegistration(c)
+	response.RegistrationAccessToken = token
+	h.H.WriteCreated(w, r, response.RegistrationClientURI, response)
+}
+
+// swagger:route GET /connect/register/{id} oAuth2 getDynamicallyRegisteredOAuth2Client
+//
+// Get an OAuth 2.0 Client using its registration access token
+//
+// This endpoint implements the client configuration endpoint of OAuth 2.0 Dynamic Client Registration Management
+// (RFC 7592). The request must carry the registration access token returned when the client was registered.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: dynamicClientRegistration
+//       401: genericError
+//       404: genericError
+//       500: genericError
+func (h *Handler) GetDynamicRegistration(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	c, ok := h.getDynamicRegistration(w, r, ps.ByName("id"))
+	if !ok {
+		return
+	}
+
+	c.Secret = ""
+	h.H.Write(w, r, h.dynamicRegistration(c))
+}
+
+// swagger:route PUT /connect/register/{id} oAuth2 updateDynamicallyRegisteredOAuth2Client
+//
+// Update an OAuth 2.0 Client using its registration access token
+//
+// This endpoint implements the client configuration endpoint of OAuth 2.0 Dynamic Client Registration Management
+// (RFC 7592). The request must carry the registration access token returned when the client was registered and
+// replaces the client metadata, which is checked against the policies like it is when the client is registered. The
+// client id must be included, the client secret can not be changed.
+//
+// The client is pending again until an administrator approves it if the registrant is not allowed to approve the
+// updated client, or if the update changes its scope, grant types or redirect URIs.
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: dynamicClientRegistration
+//       400: genericError
+//       401: genericError
+//       404: genericError
+//       500: genericError
+func (h *Handler) UpdateDynamicRegistration(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var id = ps.ByName("id")
+
+	o, ok := h.getDynamicRegistration(w, r, id)
+	if !ok {
+		return
+	}
+
+	var m DynamicRegistrationResponse
+	if err := json.NewDecoder(r.Body).Decode(&m); err != nil || m.Client == nil {
+		writeRegistrationError(w, http.StatusBadRequest, "invalid_client_metadata", "The client metadata could not be decoded")
+		return
+	} else if m.ClientID != id {
+		writeRegistrationError(w, http.StatusBadRequest, "invalid_client_metadata", "The client_id does not match the client")
+		return
+	}
+
+	c := *o
+	c.Secret = ""
+	applyRegistrationMetadata(&c, m.Client)
+	if name, err := h.validateRegistration(r, o.Owner, &c); err != nil {
+		writeRegistrationError(w, http.StatusBadRequest, name, err.Error())
+		return
+	}
+
+	approved := h.approvesRegistration(r, o.Owner, &c)
+	updated, err := h.Manager.PatchClient(id, func(p *Client) (*Client, error) {
+		if !approved || registrationPrivilegesChanged(p, &c) {
+			p.Status = ClientStatusPending
+		}
+		applyRegistrationMetadata(p, &c)
+		return p, nil
+	})
+	if err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	updated.Secret = ""
+	h.H.Write(w, r, h.dynamicRegistration(updated))
+}
+
+// swagger:route DELETE /connect/register/{id} oAuth2 deleteDynamicallyRegisteredOAuth2Client
+//
+// Delete an OAuth 2.0 Client using its registration access token
+//
+// This endpoint implements the client configuration endpoint of OAuth 2.0 Dynamic Client Registration Management
+// (RFC 7592). The request must carry the registration access token returned when the client was registered.
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       204: emptyResponse
+//       401: genericError
+//       404: genericError
+//       500: genericError
+func (h *Handler) DeleteDynamicRegistration(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var id = ps.ByName("id")
+
+	if _, ok := h.getDynamicRegistration(w, r, id); !ok {
+		return
+	}
+
+	if err := h.Manager.DeleteClient(id); err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	if err := h.RegistrationTokens.DeleteRegistrationToken(id); err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getDynamicRegistration returns the client if the request carries its registration access token. As required by
+// RFC 7592, unknown clients are reported like invalid tokens.
+func (h *Handler) getDynamicRegistration(w http.ResponseWriter, r *http.Request, id string) (*Client, bool) {
+	if h.DynamicRegistration == "" {
+		h.H.WriteErrorCode(w, r, http.StatusNotFound, errors.New("Dynamic client registration is disabled"))
+		return nil, false
+	}
+
+	err := VerifyRegistrationToken(h.RegistrationTokens, id, fosite.AccessTokenFromRequest(r))
+	if err != nil && errors.Cause(err) != pkg.ErrNotFound {
+		h.H.WriteError(w, r, err)
+		return nil, false
+	}
+
+	var c *Client
+	if err == nil {
+		if c, err = h.Manager.GetConcreteClient(id); err != nil && errors.Cause(err) != pkg.ErrNotFound {
+			h.H.WriteError(w, r, err)
+			return nil, false
+		}
+	}
+
+	if err != nil {
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		writeRegistrationError(w, http.StatusUnauthorized, "invalid_token", "The registration access token is invalid")
+		return nil, false
+	}
+	return c, true
+}
+
+// validateRegistration sets the defaults of RFC 7591, validates the client metadata and checks that the subject is
+// allowed to register each grant type, scope and redirect URI. It returns the RFC 7591 error name along with the
+// error.
+func (h *Handler) validateRegistration(r *http.Request, subject string, c *Client) (string, error) {
+	if c.TokenEndpointAuthMethod == "none" {
+		c.Public = true
+		c.TokenEndpointAuthMethod = ""
+	}
+	if len(c.GrantTypes) == 0 {
+		c.GrantTypes = []string{"authorization_code"}
+	}
+	if len(c.ResponseTypes) == 0 {
+		c.ResponseTypes = []string{"code"}
+	}
+
+	for _, uri := range c.RedirectURIs {
+		if u, err := url.Parse(uri); err != nil || !u.IsAbs() || u.Fragment != "" {
+			return "invalid_redirect_uri", errors.Errorf("The redirect URI %s must be an absolute URL without a fragment", uri)
+		}
+	}
+
+	if err := c.ValidateTLSClientAuth(); err != nil {
+		return "invalid_client_metadata", err
+	} else if err := c.ValidateLogoutURIs(); err != nil {
+		return "invalid_client_metadata", err
+	} else if err := c.ValidateResponseEncryption(); err != nil {
+		return "invalid_client_metadata", err
+	} else if err := c.ValidateUserinfoSignedResponseAlg(); err != nil {
+		return "invalid_client_metadata", err
+	}
+
+	ip, _, _ := net.SplitHostPort(r.RemoteAddr)
+	allowed := func(resource, value string) bool {
+		return h.W.IsAllowed(r.Context(), &firewall.AccessRequest{
+			Subject:  subject,
+			Resource: fmt.Sprintf(h.PrefixResource(resource), value),
+			Action:   "register",
+			Context:  ladon.Context{"remoteIP": ip},
+		}) == nil
+	}
+
+	for _, grantType := range c.GrantTypes {
+		if !allowed(RegistrationGrantTypeResource, grantType) {
+			return "invalid_client_metadata", errors.Errorf("The grant type %s is not allowed", grantType)
+		}
+	}
+	for _, scope := range strings.Fields(c.Scope) {
+		if !allowed(RegistrationScopeResource, scope) {
+			return "invalid_client_metadata", errors.Errorf("The scope %s is not allowed", scope)
+		}
+	}
+	for _, uri := range c.RedirectURIs {
+		if !allowed(RegistrationRedirectURIResource, uri) {
+			return "invalid_redirect_uri", errors.Errorf("The redirect URI %s is not allowed", uri)
+		}
+	}
+	for _, uri := range []struct {
+		name     string
+		value    string
+		resource string
+	}{
+		{name: "backchannel_logout_uri", value: c.BackChannelLogoutURI, resource: RegistrationBackChannelLogoutURIResource},
+		{name: "jwks_uri", value: c.JSONWebKeysURI, resource: RegistrationJSONWebKeysURIResource},
+	} {
+		if uri.value == "" {
+			continue
+		} else if u, err := url.Parse(uri.value); err != nil || u.Scheme != "https" || u.Host == "" {
+			return "invalid_client_metadata", errors.Errorf("The %s %s must be an https URL", uri.name, uri.value)
+		} else if !allowed(uri.resource, uri.value) {
+			return "invalid_client_metadata", errors.Errorf("The %s %s is not allowed", uri.name, uri.value)
+		}
+	}
+	return "", nil
+}
+
+// approvesRegistration returns true if the subject is allowed to approve the client, which is pending until an
+// administrator approves it otherwise.
+func (h *Handler) approvesRegistration(r *http.Request, subject string, c *Client) bool {
+	ip, _, _ := net.SplitHostPort(r.RemoteAddr)
+	return h.W.IsAllowed(r.Context(), &firewall.AccessRequest{
+		Subject:  subject,
+		Resource: h.PrefixResource(RegistrationResource),
+		Action:   "approve",
+		Context: ladon.Context{
+			"remoteIP": ip,
+			"scope":    c.Scope,
+		},
+	}) == nil
+}
+
+// registrationPrivilegesChanged returns true if the scope, grant types or redirect URIs of the updated client differ
+// from the ones of the registered client.
+func registrationPrivilegesChanged(registered, updated *Client) bool {
+	return !sameElements(strings.Fields(registered.Scope), strings.Fields(updated.Scope)) ||
+		!sameElements(registered.GrantTypes, updated.GrantTypes) ||
+		!sameElements(registered.RedirectURIs, updated.RedirectURIs)
+}
+
+// sameElements returns true if a and b contain the same strings, regardless of their order.
+func sameElements(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	counts := map[string]int{}
+	for _, v := range a {
+		counts[v]++
+	}
+	for _, v := range b {
+		if counts[v] == 0 {
+			return false
+		}
+		counts[v]--
+	}
+	return true
+}
+
+func (h *Handler) newRegistrationToken(id string) (string, error) {
+	token, err := sequence.RuneSequence(32, sequence.AlphaNum)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	if err := h.RegistrationTokens.SetRegistrationToken(id, RegistrationTokenSignature(h.SecretPrefix+string(token))); err != nil {
+		return "", err
+	}
+	return h.SecretPrefix + string(token), nil
+}
+
+func (h *Handler) dynamicRegistration(c *Client) *DynamicRegistrationResponse {
+	return &DynamicRegistrationResponse{
+		Client:                c,
+		ClientID:              c.ID,
+		RegistrationClientURI: pkg.JoinURLStrings(h.Issuer, DynamicRegistrationPath, c.ID),
+	}
+}
+
+// applyRegistrationMetadata copies the client metadata a client may register by itself from src to dst. Everything
+// else, such as the owner or the first party flag, is left untouched.
+func applyRegistrationMetadata(dst, src *Client) {
+	dst.Name = src.Name
+	dst.RedirectURIs = src.RedirectURIs
+	dst.GrantTypes = src.GrantTypes
+	dst.ResponseTypes = src.ResponseTypes
+	dst.Scope = src.Scope
+	dst.PolicyURI = src.PolicyURI
+	dst.TermsOfServiceURI = src.TermsOfServiceURI
+	dst.ClientURI = src.ClientURI
+	dst.LogoURI = src.LogoURI
+	dst.Contacts = src.Contacts
+	dst.Public = src.Public
+	dst.TokenEndpointAuthMethod = src.TokenEndpointAuthMethod
+	dst.TLSClientAuthCertificate = src.TLSClientAuthCertificate
+	dst.TLSClientAuthPublicKeySHA256 = src.TLSClientAuthPublicKeySHA256
+	dst.TLSClientAuthSubjectDN = src.TLSClientAuthSubjectDN
+	dst.TLSClientAuthSANDNS = src.TLSClientAuthSANDNS
+	dst.TLSClientAuthSANURI = src.TLSClientAuthSANURI
+	dst.TLSClientAuthSANIP = src.TLSClientAuthSANIP
+	dst.TLSClientAuthSANEmail = src.TLSClientAuthSANEmail
+	dst.RequirePKCE = src.RequirePKCE
+	dst.RequirePushedAuthorizationRequests = src.RequirePushedAuthorizationRequests
+	dst.PostLogoutRedirectURIs = src.PostLogoutRedirectURIs
+	dst.FrontChannelLogoutURI = src.FrontChannelLogoutURI
+	dst.BackChannelLogoutURI = src.BackChannelLogoutURI
+	dst.JSONWebKeys = src.JSONWebKeys
+	dst.JSONWebKeysURI = src.JSONWebKeysURI
+	dst.IDTokenEncryptedResponseAlg = src.IDTokenEncryptedResponseAlg
+	dst.IDTokenEncryptedResponseEnc = src.IDTokenEncryptedResponseEnc
+	dst.UserinfoSignedResponseAlg = src.UserinfoSignedResponseAlg
+	dst.UserinfoEncryptedResponseAlg = src.UserinfoEncryptedResponseAlg
+	dst.UserinfoEncryptedResponseEnc = src.UserinfoEncryptedResponseEnc
+}
+
+func writeRegistrationError(w http.ResponseWriter, code int, name, description string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(&registrationError{Name: name, Description: description})
+}
@@ -0,0 +1,201 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/fosite"
+	"github.com/ory/herodot"
+	"github.com/ory/hydra/client"
+	"github.com/ory/hydra/compose"
+	"github.com/ory/ladon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlerDynamicRegistration(t *testing.T) {
+	manager := client.NewMemoryManager(nil)
+	localWarden, httpClient := compose.NewMockFirewall("foo", "alice", fosite.Arguments{client.RegistrationScope}, &ladon.DefaultPolicy{
+		ID:       "1",
+		Subjects: []string{"alice"},
+		Resources: []string{
+			"rn:hydra:clients:registration",
+			"rn:hydra:clients:registration:grant-types:<authorization_code|refresh_token>",
+			"rn:hydra:clients:registration:scopes:<openid|offline>",
+			"rn:hydra:clients:registration:redirect-uris:https://app.localhost/<.*>",
+			"rn:hydra:clients:registration:jwks-uris:https://app.localhost/<.*>",
+		},
+		Actions: []string{"register"},
+		Effect:  ladon.AllowAccess,
+	}, &ladon.DefaultPolicy{
+		ID:         "2",
+		Subjects:   []string{"alice"},
+		Resources:  []string{"rn:hydra:clients:registration"},
+		Actions:    []string{"approve"},
+		Effect:     ladon.AllowAccess,
+		Conditions: ladon.Conditions{"scope": &ladon.StringEqualCondition{Equals: "openid"}},
+	})
+
+	router := httprouter.New()
+	(&client.Handler{
+		Manager:             manager,
+		H:                   herodot.NewJSONWriter(nil),
+		W:                   localWarden,
+		DynamicRegistration: client.DynamicRegistrationProtected,
+		RegistrationTokens:  client.NewRegistrationTokenMemoryManager(),
+		Issuer:              "https://hydra.localhost",
+	}).SetRoutes(router)
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	do := func(c *http.Client, method, url, token string, body interface{}) (*http.Response, map[string]interface{}) {
+		var b bytes.Buffer
+		if body != nil {
+			require.NoError(t, json.NewEncoder(&b).Encode(body))
+		}
+
+		req, err := http.NewRequest(method, url, &b)
+		require.NoError(t, err)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		res, err := c.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		var result map[string]interface{}
+		json.NewDecoder(res.Body).Decode(&result)
+		return res, result
+	}
+
+	metadata := map[string]interface{}{
+		"client_name":   "app",
+		"redirect_uris": []string{"https://app.localhost/callback"},
+		"grant_types":   []string{"authorization_code", "refresh_token"},
+		"scope":         "openid offline",
+		"jwks_uri":      "https://app.localhost/jwks.json",
+		"owner":         "mallory",
+		"first_party":   true,
+	}
+
+	res, _ := do(http.DefaultClient, "POST", ts.URL+client.DynamicRegistrationPath, "", metadata)
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+	for k, c := range []struct {
+		key      string
+		value    interface{}
+		expected string
+	}{
+		{key: "grant_types", value: []string{"client_credentials"}, expected: "invalid_client_metadata"},
+		{key: "scope", value: "openid hydra.clients", expected: "invalid_client_metadata"},
+		{key: "redirect_uris", value: []string{"https://evil.localhost/callback"}, expected: "invalid_redirect_uri"},
+		{key: "redirect_uris", value: []string{"/callback"}, expected: "invalid_redirect_uri"},
+		{key: "jwks_uri", value: "https://metadata.internal/jwks.json", expected: "invalid_client_metadata"},
+		{key: "jwks_uri", value: "http://app.localhost/jwks.json", expected: "invalid_client_metadata"},
+		{key: "backchannel_logout_uri", value: "https://169.254.169.254/latest/meta-data", expected: "invalid_client_metadata"},
+	} {
+		m := map[string]interface{}{}
+		for key, value := range metadata {
+			m[key] = value
+		}
+		m[c.key] = c.value
+
+		res, result := do(httpClient, "POST", ts.URL+client.DynamicRegistrationPath, "", m)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode, "case %d", k)
+		assert.Equal(t, c.expected, result["error"], "case %d", k)
+	}
+
+	res, result := do(httpClient, "POST", ts.URL+client.DynamicRegistrationPath, "", metadata)
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	id, _ := result["client_id"].(string)
+	token, _ := result["registration_access_token"].(string)
+	require.NotEmpty(t, id)
+	require.NotEmpty(t, token)
+	assert.NotEmpty(t, result["client_secret"])
+	assert.Equal(t, "https://hydra.localhost"+client.DynamicRegistrationPath+"/"+id, result["registration_client_uri"])
+
+	c, err := manager.GetConcreteClient(id)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", c.Owner)
+	assert.False(t, c.FirstParty)
+	assert.Equal(t, []string{"code"}, c.ResponseTypes)
+	assert.True(t, c.IsPending(), "Clients must be pending unless the registrant may approve them")
+
+	res, _ = do(http.DefaultClient, "GET", ts.URL+client.DynamicRegistrationPath+"/"+id, "invalid", nil)
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+	res, result = do(http.DefaultClient, "GET", ts.URL+client.DynamicRegistrationPath+"/"+id, token, nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "app", result["client_name"])
+	assert.Empty(t, result["client_secret"])
+	assert.Empty(t, result["registration_access_token"])
+
+	metadata["client_name"] = "renamed"
+	res, _ = do(http.DefaultClient, "PUT", ts.URL+client.DynamicRegistrationPath+"/"+id, token, metadata)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	metadata["client_id"] = id
+	res, result = do(http.DefaultClient, "PUT", ts.URL+client.DynamicRegistrationPath+"/"+id, token, metadata)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "renamed", result["client_name"])
+
+	c, err = manager.GetConcreteClient(id)
+	require.NoError(t, err)
+	assert.Equal(t, "renamed", c.Name)
+	assert.Equal(t, "alice", c.Owner)
+
+	approve := func() {
+		_, err := manager.PatchClient(id, func(p *client.Client) (*client.Client, error) {
+			p.Status = ""
+			return p, nil
+		})
+		require.NoError(t, err)
+	}
+
+	for k, c := range []struct {
+		key     string
+		value   interface{}
+		pending bool
+	}{
+		{key: "client_name", value: "not allowed to approve", pending: true},
+		{key: "scope", value: "openid", pending: true},
+		{key: "client_name", value: "allowed to approve", pending: false},
+		{key: "redirect_uris", value: []string{"https://app.localhost/other-callback"}, pending: true},
+		{key: "grant_types", value: []string{"authorization_code"}, pending: true},
+	} {
+		approve()
+		metadata[c.key] = c.value
+
+		res, _ = do(http.DefaultClient, "PUT", ts.URL+client.DynamicRegistrationPath+"/"+id, token, metadata)
+		require.Equal(t, http.StatusOK, res.StatusCode, "case %d", k)
+
+		updated, err := manager.GetConcreteClient(id)
+		require.NoError(t, err)
+		assert.Equal(t, c.pending, updated.IsPending(), "case %d", k)
+	}
+
+	res, _ = do(http.DefaultClient, "DELETE", ts.URL+client.DynamicRegistrationPath+"/"+id, token, nil)
+	assert.Equal(t, http.StatusNoContent, res.StatusCode)
+
+	res, _ = do(http.DefaultClient, "GET", ts.URL+client.DynamicRegistrationPath+"/"+id, token, nil)
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+}
@@ -0,0 +1,320 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/hydra/firewall"
+	"github.com/ory/ladon"
+	"github.com/pkg/errors"
+)
+
+const (
+	RegistrationPath = "/oauth2/register"
+
+	// RegistrationResource is the resource on which the anonymous subject must be allowed the action "approve" for
+	// registered clients to be approved automatically.
+	RegistrationResource = "clients:registration"
+)
+
+// swagger:route POST /oauth2/register oAuth2 registerOAuth2Client
+//
+// Register an OAuth 2.0 Client
+//
+// This endpoint allows anyone to register an OAuth 2.0 Client if OAUTH2_CLIENT_REGISTRATION is enabled. The client id
+// and secret are always generated, the owner, first_party, skip_consent, internal and environment are left empty.
+// The secret will be returned in the response and you will not be able to retrieve it later on.
+//
+// Registered clients are pending and can not authenticate or request tokens until they are approved by an
+// administrator. A client is approved right away if a policy allows the anonymous subject to perform the action
+// "approve" on "rn:hydra:clients:registration". The context keys "remoteIP" and "scope" are set to the IP address of
+// the registrant and the requested scope, allowing policies such as:
+//
+//  ```
+//  {
+//    "subjects": ["<.*>"],
+//    "resources": ["rn:hydra:clients:registration"],
+//    "actions": ["approve"],
+//    "effect": "allow",
+//    "conditions": { "remoteIP": { "type": "CIDRCondition", "options": { "cidr": "10.0.0.0/8" } } }
+//  }
+//  ```
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       201: oAuth2Client
+//       400: genericError
+//       404: genericError
+//       500: genericError
+func (h *Handler) Register(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var c Client
+	var ctx = r.Context()
+
+	if !h.RegistrationEnabled {
+		h.H.WriteErrorCode(w, r, http.StatusNotFound, errors.New("Dynamic client registration is disabled"))
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, errors.WithStack(err))
+		return
+	}
+
+	if err := c.ValidateTLSClientAuth(); err != nil {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := c.ValidateConsentChallengeLifespan(); err != nil {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := c.ValidateTokenLifespans(); err != nil {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := c.ValidateLogoutURIs(); err != nil {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := c.ValidateResponseEncryption(); err != nil {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := c.ValidateUserinfoSignedResponseAlg(); err != nil {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	secret, generation, err := h.GenerateSecret()
+	if err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	c.ID = ""
+	c.Owner = ""
+	c.Secret = secret
+	c.SecretGeneration = nil
+	c.Status = ClientStatusPending
+
+	// Clients can not vouch for themselves, their metadata is set by an administrator.
+	c.FirstParty = false
+	c.SkipConsent = false
+	c.Internal = false
+	c.Environment = ""
+	c.Audience = nil
+	c.AllowedCORSOrigins = nil
+	c.ResourceServerAudience = nil
+
+	ip, _, _ := net.SplitHostPort(r.RemoteAddr)
+	if err := h.W.IsAllowed(ctx, &firewall.AccessRequest{
+		Subject:  "",
+		Resource: h.PrefixResource(RegistrationResource),
+		Action:   "approve",
+		Context: ladon.Context{
+			"remoteIP": ip,
+			"scope":    c.Scope,
+		},
+	}); err == nil {
+		c.Status = ""
+	}
+
+	if err := h.Manager.CreateClient(&c); err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	c.Secret = ""
+	if !c.Public {
+		c.Secret = secret
+		c.SecretGeneration = generation
+	}
+
+	h.H.WriteCreated(w, r, ClientsHandlerPath+"/"+c.GetID(), &c)
+}
+
+// swagger:route POST /clients/{id}/approve oAuth2 approveOAuth2Client
+//
+// Approve a registered OAuth 2.0 Client
+//
+// Approves an OAuth 2.0 Client which registered itself and is pending, allowing it to authenticate and request tokens.
+// Pending clients can be listed using `GET /clients?status=pending`.
+//
+// The subject making the request needs to be assigned to a policy containing:
+//
+//  ```
+//  {
+//    "resources": ["rn:hydra:clients:<some-id>"],
+//    "actions": ["approve"],
+//    "effect": "allow"
+//  }
+//  ```
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Security:
+//       oauth2: hydra.clients
+//
+//     Responses:
+//       200: oAuth2Client
+//       401: genericError
+//       403: genericError
+//       404: genericError
+//       409: genericError
+//       500: genericError
+func (h *Handler) Approve(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var id = ps.ByName("id")
+
+	c, ok := h.getPendingClient(w, r, id, "approve")
+	if !ok {
+		return
+	}
+
+	if err := h.Manager.ApproveClient(id); err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	c.Secret = ""
+	c.Status = ""
+	h.H.Write(w, r, c)
+}
+
+// swagger:route POST /clients/{id}/reject oAuth2 rejectOAuth2Client
+//
+// Reject a registered OAuth 2.0 Client
+//
+// Rejects an OAuth 2.0 Client which registered itself and is pending by deleting it. Clients which were already
+// approved can not be rejected, delete them instead.
+//
+// The subject making the request needs to be assigned to a policy containing:
+//
+//  ```
+//  {
+//    "resources": ["rn:hydra:clients:<some-id>"],
+//    "actions": ["reject"],
+//    "effect": "allow"
+//  }
+//  ```
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Security:
+//       oauth2: hydra.clients
+//
+//     Responses:
+//       204: emptyResponse
+//       401: genericError
+//       403: genericError
+//       404: genericError
+//       409: genericError
+//       500: genericError
+func (h *Handler) Reject(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var id = ps.ByName("id")
+
+	if _, ok := h.getPendingClient(w, r, id, "reject"); !ok {
+		return
+	}
+
+	if err := h.Manager.DeleteClient(id); err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) getPendingClient(w http.ResponseWriter, r *http.Request, id, action string) (*Client, bool) {
+	if _, err := h.W.TokenAllowed(r.Context(), h.W.TokenFromRequest(r), &firewall.TokenAccessRequest{
+		Resource: fmt.Sprintf(h.PrefixResource(ClientResource), id),
+		Action:   action,
+	}, Scope); err != nil {
+		h.H.WriteError(w, r, err)
+		return nil, false
+	}
+
+	c, err := h.Manager.GetConcreteClient(id)
+	if err != nil {
+		h.H.WriteError(w, r, err)
+		return nil, false
+	}
+
+	if !c.IsPending() {
+		h.H.WriteErrorCode(w, r, http.StatusConflict, errors.Errorf("Client %s is not pending", id))
+		return nil, false
+	}
+
+	return c, true
+}
+
+// filterClientsByStatus returns the clients with the given status ordered by their id. Clients are read from the
+// manager page by page, so that limit and offset apply to the filtered list.
+func filterClientsByStatus(m Storage, status string, limit, offset int) ([]Client, error) {
+	var matches []Client
+	for page := 0; ; page += 500 {
+		clients, err := m.GetClients(500, page)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, c := range clients {
+			if c.Status == status {
+				matches = append(matches, c)
+			}
+		}
+
+		if len(clients) < 500 {
+			break
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool { return matches[i].ID < matches[j].ID })
+	if offset >= len(matches) {
+		return []Client{}, nil
+	} else if end := offset + limit; end < len(matches) {
+		return matches[offset:end], nil
+	}
+	return matches[offset:], nil
+}
@@ -0,0 +1,108 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/fosite"
+	"github.com/ory/herodot"
+	"github.com/ory/hydra/client"
+	"github.com/ory/hydra/compose"
+	"github.com/ory/ladon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlerPatch(t *testing.T) {
+	manager := client.NewMemoryManager(nil)
+	require.NoError(t, manager.CreateClient(&client.Client{
+		ID:           "patch",
+		Secret:       "secret",
+		RedirectURIs: []string{"https://a/cb"},
+		Scope:        "foo",
+	}))
+
+	localWarden, httpClient := compose.NewMockFirewall("foo", "alice", fosite.Arguments{client.Scope}, &ladon.DefaultPolicy{
+		ID:        "1",
+		Subjects:  []string{"alice"},
+		Resources: []string{"rn:hydra:clients<.*>"},
+		Actions:   []string{"get", "update"},
+		Effect:    ladon.AllowAccess,
+	})
+
+	router := httprouter.New()
+	(&client.Handler{Manager: manager, H: herodot.NewJSONWriter(nil), W: localWarden}).SetRoutes(router)
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	res, err := httpClient.Get(ts.URL + "/clients/patch")
+	require.NoError(t, err)
+	res.Body.Close()
+	etag := res.Header.Get("ETag")
+	require.NotEmpty(t, etag)
+
+	patch := func(contentType, match, body string) *http.Response {
+		req, err := http.NewRequest("PATCH", ts.URL+"/clients/patch", bytes.NewBufferString(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", contentType)
+		if match != "" {
+			req.Header.Set("If-Match", match)
+		}
+
+		res, err := httpClient.Do(req)
+		require.NoError(t, err)
+		return res
+	}
+
+	res = patch("application/json-patch+json", etag, `[{"op":"add","path":"/redirect_uris/-","value":"https://b/cb"}]`)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.NotEqual(t, etag, res.Header.Get("ETag"))
+
+	var patched client.Client
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&patched))
+	assert.Equal(t, []string{"https://a/cb", "https://b/cb"}, patched.RedirectURIs)
+	assert.Equal(t, "foo", patched.Scope)
+	assert.Empty(t, patched.Secret)
+
+	for k, tc := range []struct {
+		contentType string
+		match       string
+		body        string
+		code        int
+	}{
+		{contentType: "application/merge-patch+json", match: etag, body: `{"scope":"bar"}`, code: http.StatusPreconditionFailed},
+		{contentType: "application/json", body: `{"scope":"bar"}`, code: http.StatusUnsupportedMediaType},
+		{contentType: "application/json-patch+json", body: `[{"op":"test","path":"/scope","value":"bar"}]`, code: http.StatusConflict},
+		{contentType: "application/merge-patch+json", body: `{"client_secret":"short"}`, code: http.StatusBadRequest},
+		{contentType: "application/merge-patch+json", body: `{"id":"other","scope":"bar","status":"pending"}`, code: http.StatusOK},
+	} {
+		res := patch(tc.contentType, tc.match, tc.body)
+		res.Body.Close()
+		assert.Equal(t, tc.code, res.StatusCode, "case %d", k)
+	}
+
+	c, err := manager.GetConcreteClient("patch")
+	require.NoError(t, err)
+	assert.Equal(t, "bar", c.Scope)
+	assert.Empty(t, c.Status)
+	assert.Len(t, c.RedirectURIs, 2)
+}
@@ -16,6 +16,7 @@ package client
 
 import (
 	"github.com/ory/fosite"
+	"github.com/pkg/errors"
 )
 
 type Manager interface {
@@ -36,4 +37,38 @@ type Storage interface {
 	GetClients(limit, offset int) (map[string]Client, error)
 
 	GetConcreteClient(id string) (*Client, error)
+
+	// ApproveClient clears the pending status of a client, allowing it to authenticate.
+	ApproveClient(id string) error
+
+	// PatchClient atomically replaces a client with the client returned by patch, which is called with the current
+	// client and may be called more than once. The client passed to patch has no secret: if the returned client has
+	// one, it is hashed and stored, otherwise the current secret is kept. The client id can not be changed.
+	PatchClient(id string, patch func(c *Client) (*Client, error)) (*Client, error)
+}
+
+// patchClient calls patch with a copy of o without secret and returns the patched client with the id of o and, unless
+// patch set a new secret, the hashed secret of o.
+func patchClient(o *Client, patch func(c *Client) (*Client, error), hasher fosite.Hasher) (*Client, error) {
+	c := *o
+	c.Secret = ""
+	c.hashedSecret = o.Secret
+
+	p, err := patch(&c)
+	if err != nil {
+		return nil, err
+	}
+
+	p.ID = o.ID
+	p.hashedSecret = ""
+	if p.Secret == "" {
+		p.Secret = o.Secret
+	} else {
+		h, err := hasher.Hash([]byte(p.Secret))
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		p.Secret = string(h)
+	}
+	return p, nil
 }
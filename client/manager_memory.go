@@ -57,11 +57,17 @@ func (m *MemoryManager) GetConcreteClient(id string) (*Client, error) {
 }
 
 func (m *MemoryManager) GetClient(_ context.Context, id string) (fosite.Client, error) {
-	return m.GetConcreteClient(id)
+	c, err := m.GetConcreteClient(id)
+	if err != nil {
+		return nil, err
+	} else if c.IsPending() {
+		return nil, errors.Wrap(pkg.ErrNotFound, "")
+	}
+	return c, nil
 }
 
 func (m *MemoryManager) UpdateClient(c *Client) error {
-	o, err := m.GetClient(context.Background(), c.ID)
+	o, err := m.GetConcreteClient(c.ID)
 	if err != nil {
 		return err
 	}
@@ -97,6 +103,8 @@ func (m *MemoryManager) Authenticate(id string, secret []byte) (*Client, error)
 	c, err := m.GetConcreteClient(id)
 	if err != nil {
 		return nil, err
+	} else if c.IsPending() {
+		return nil, errors.Wrap(pkg.ErrNotFound, "")
 	}
 
 	if err := m.Hasher.Compare(c.GetHashedSecret(), secret); err != nil {
@@ -128,6 +136,41 @@ func (m *MemoryManager) CreateClient(c *Client) error {
 	return nil
 }
 
+func (m *MemoryManager) ApproveClient(id string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	for k, c := range m.Clients {
+		if c.GetID() == id {
+			m.Clients[k].Status = ""
+			return nil
+		}
+	}
+
+	return errors.Wrap(pkg.ErrNotFound, "")
+}
+
+func (m *MemoryManager) PatchClient(id string, patch func(c *Client) (*Client, error)) (*Client, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	for k, o := range m.Clients {
+		if o.GetID() != id {
+			continue
+		}
+
+		c, err := patchClient(&o, patch, m.Hasher)
+		if err != nil {
+			return nil, err
+		}
+
+		m.Clients[k] = *c
+		return c, nil
+	}
+
+	return nil, errors.Wrap(pkg.ErrNotFound, "")
+}
+
 func (m *MemoryManager) DeleteClient(id string) error {
 	m.Lock()
 	defer m.Unlock()
@@ -0,0 +1,243 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/go-redis/redis"
+	"github.com/ory/fosite"
+	"github.com/ory/hydra/pkg"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+)
+
+const (
+	// redisClients is a hash containing the JSON encoded clients, indexed by client id.
+	redisClients = "hydra:client:clients"
+
+	// redisClientIDs is a sorted set containing all client ids. All members have the same score, so they are
+	// ordered by id.
+	redisClientIDs = "hydra:client:ids"
+)
+
+// redisPatchMaxAttempts is how often a patch is retried if another client was changed while it was applied.
+const redisPatchMaxAttempts = 10
+
+type RedisManager struct {
+	DB     *redis.Client
+	Hasher fosite.Hasher
+}
+
+func (m *RedisManager) GetConcreteClient(id string) (*Client, error) {
+	out, err := m.DB.HGet(redisClients, id).Bytes()
+	if err == redis.Nil {
+		return nil, errors.Wrap(pkg.ErrNotFound, "")
+	} else if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var c Client
+	if err := json.Unmarshal(out, &c); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &c, nil
+}
+
+func (m *RedisManager) GetClient(_ context.Context, id string) (fosite.Client, error) {
+	c, err := m.GetConcreteClient(id)
+	if err != nil {
+		return nil, err
+	} else if c.IsPending() {
+		return nil, errors.Wrap(pkg.ErrNotFound, "")
+	}
+	return c, nil
+}
+
+func (m *RedisManager) UpdateClient(c *Client) error {
+	o, err := m.GetConcreteClient(c.ID)
+	if err != nil {
+		return err
+	}
+
+	if c.Secret == "" {
+		c.Secret = string(o.GetHashedSecret())
+	} else {
+		h, err := m.Hasher.Hash([]byte(c.Secret))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		c.Secret = string(h)
+	}
+
+	return m.store(c)
+}
+
+func (m *RedisManager) Authenticate(id string, secret []byte) (*Client, error) {
+	c, err := m.GetConcreteClient(id)
+	if err != nil {
+		return nil, err
+	} else if c.IsPending() {
+		return nil, errors.Wrap(pkg.ErrNotFound, "")
+	}
+
+	if err := m.Hasher.Compare(c.GetHashedSecret(), secret); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return c, nil
+}
+
+func (m *RedisManager) CreateClient(c *Client) error {
+	if c.ID == "" {
+		c.ID = uuid.New()
+	}
+
+	if exists, err := m.DB.HExists(redisClients, c.ID).Result(); err != nil {
+		return errors.WithStack(err)
+	} else if exists {
+		return errors.Errorf("Client %s already exists", c.ID)
+	}
+
+	h, err := m.Hasher.Hash([]byte(c.Secret))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	c.Secret = string(h)
+
+	return m.store(c)
+}
+
+func (m *RedisManager) ApproveClient(id string) error {
+	c, err := m.GetConcreteClient(id)
+	if err != nil {
+		return err
+	}
+
+	c.Status = ""
+	return m.store(c)
+}
+
+// PatchClient watches the clients while the patch is applied. If any client changes in the meantime, the patch is
+// applied again to the changed client.
+func (m *RedisManager) PatchClient(id string, patch func(c *Client) (*Client, error)) (*Client, error) {
+	var result *Client
+	var err error
+	for attempt := 1; attempt <= redisPatchMaxAttempts; attempt++ {
+		err = m.DB.Watch(func(tx *redis.Tx) error {
+			out, err := tx.HGet(redisClients, id).Bytes()
+			if err == redis.Nil {
+				return errors.Wrap(pkg.ErrNotFound, "")
+			} else if err != nil {
+				return errors.WithStack(err)
+			}
+
+			var o Client
+			if err := json.Unmarshal(out, &o); err != nil {
+				return errors.WithStack(err)
+			}
+
+			c, err := patchClient(&o, patch, m.Hasher)
+			if err != nil {
+				return err
+			}
+
+			if out, err = json.Marshal(c); err != nil {
+				return errors.WithStack(err)
+			}
+
+			if _, err := tx.Pipelined(func(pipe redis.Pipeliner) error {
+				pipe.HSet(redisClients, c.ID, string(out))
+				return nil
+			}); err != nil {
+				return err
+			}
+			result = c
+			return nil
+		}, redisClients)
+		if err != redis.TxFailedErr {
+			break
+		}
+	}
+
+	if err == redis.TxFailedErr {
+		return nil, errors.Wrap(pkg.ErrConflict, "The client was changed concurrently")
+	} else if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return result, nil
+}
+
+func (m *RedisManager) DeleteClient(id string) error {
+	if _, err := m.DB.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.HDel(redisClients, id)
+		pipe.ZRem(redisClientIDs, id)
+		return nil
+	}); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func (m *RedisManager) GetClients(limit, offset int) (map[string]Client, error) {
+	clients := make(map[string]Client)
+	if limit <= 0 {
+		return clients, nil
+	}
+
+	ids, err := m.DB.ZRange(redisClientIDs, int64(offset), int64(offset+limit-1)).Result()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	} else if len(ids) == 0 {
+		return clients, nil
+	}
+
+	values, err := m.DB.HMGet(redisClients, ids...).Result()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	for _, value := range values {
+		out, ok := value.(string)
+		if !ok {
+			// The client was deleted in the meantime.
+			continue
+		}
+
+		var c Client
+		if err := json.Unmarshal([]byte(out), &c); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		clients[c.ID] = c
+	}
+	return clients, nil
+}
+
+func (m *RedisManager) store(c *Client) error {
+	out, err := json.Marshal(c)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if _, err := m.DB.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.HSet(redisClients, c.ID, string(out))
+		pipe.ZAdd(redisClientIDs, redis.Z{Member: c.ID})
+		return nil
+	}); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
@@ -17,15 +17,18 @@ package client
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"strings"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/ory/fosite"
+	"github.com/ory/hydra/events"
 	"github.com/ory/hydra/pkg"
 	"github.com/pborman/uuid"
 	"github.com/pkg/errors"
 	"github.com/rubenv/sql-migrate"
+	"github.com/square/go-jose"
 )
 
 var migrations = &migrate.MemoryMigrationSource{
@@ -52,12 +55,187 @@ var migrations = &migrate.MemoryMigrationSource{
 				"DROP TABLE hydra_client",
 			},
 		},
+		{
+			Id: "2",
+			Up: []string{
+				`ALTER TABLE hydra_client ADD token_endpoint_auth_method varchar(64) NOT NULL DEFAULT ''`,
+				`ALTER TABLE hydra_client ADD tls_client_auth_certificate varchar(8192) NOT NULL DEFAULT ''`,
+				`ALTER TABLE hydra_client ADD tls_client_auth_public_key_sha256 varchar(64) NOT NULL DEFAULT ''`,
+			},
+			Down: []string{
+				`ALTER TABLE hydra_client DROP COLUMN token_endpoint_auth_method`,
+				`ALTER TABLE hydra_client DROP COLUMN tls_client_auth_certificate`,
+				`ALTER TABLE hydra_client DROP COLUMN tls_client_auth_public_key_sha256`,
+			},
+		},
+		{
+			Id: "3",
+			Up: []string{
+				`ALTER TABLE hydra_client ADD status varchar(16) NOT NULL DEFAULT ''`,
+			},
+			Down: []string{
+				`ALTER TABLE hydra_client DROP COLUMN status`,
+			},
+		},
+		{
+			Id: "4",
+			Up: []string{
+				`ALTER TABLE hydra_client ADD consent_challenge_lifespan varchar(32) NOT NULL DEFAULT ''`,
+			},
+			Down: []string{
+				`ALTER TABLE hydra_client DROP COLUMN consent_challenge_lifespan`,
+			},
+		},
+		{
+			Id: "5",
+			Up: []string{
+				`ALTER TABLE hydra_client ADD require_pkce boolean NOT NULL DEFAULT false`,
+			},
+			Down: []string{
+				`ALTER TABLE hydra_client DROP COLUMN require_pkce`,
+			},
+		},
+		{
+			Id: "6",
+			Up: []string{
+				`ALTER TABLE hydra_client ADD first_party boolean NOT NULL DEFAULT false`,
+				`ALTER TABLE hydra_client ADD internal boolean NOT NULL DEFAULT false`,
+				`ALTER TABLE hydra_client ADD environment varchar(64) NOT NULL DEFAULT ''`,
+			},
+			Down: []string{
+				`ALTER TABLE hydra_client DROP COLUMN first_party`,
+				`ALTER TABLE hydra_client DROP COLUMN internal`,
+				`ALTER TABLE hydra_client DROP COLUMN environment`,
+			},
+		},
+		{
+			Id: "7",
+			Up: []string{
+				`ALTER TABLE hydra_client ADD access_token_lifespan varchar(32) NOT NULL DEFAULT ''`,
+				`ALTER TABLE hydra_client ADD refresh_token_lifespan varchar(32) NOT NULL DEFAULT ''`,
+				`ALTER TABLE hydra_client ADD id_token_lifespan varchar(32) NOT NULL DEFAULT ''`,
+				`ALTER TABLE hydra_client ADD authorize_code_lifespan varchar(32) NOT NULL DEFAULT ''`,
+			},
+			Down: []string{
+				`ALTER TABLE hydra_client DROP COLUMN access_token_lifespan`,
+				`ALTER TABLE hydra_client DROP COLUMN refresh_token_lifespan`,
+				`ALTER TABLE hydra_client DROP COLUMN id_token_lifespan`,
+				`ALTER TABLE hydra_client DROP COLUMN authorize_code_lifespan`,
+			},
+		},
+		{
+			Id: "8",
+			Up: []string{
+				`ALTER TABLE hydra_client ADD post_logout_redirect_uris varchar(2048) NOT NULL DEFAULT ''`,
+				`ALTER TABLE hydra_client ADD frontchannel_logout_uri varchar(512) NOT NULL DEFAULT ''`,
+				`ALTER TABLE hydra_client ADD backchannel_logout_uri varchar(512) NOT NULL DEFAULT ''`,
+			},
+			Down: []string{
+				`ALTER TABLE hydra_client DROP COLUMN post_logout_redirect_uris`,
+				`ALTER TABLE hydra_client DROP COLUMN frontchannel_logout_uri`,
+				`ALTER TABLE hydra_client DROP COLUMN backchannel_logout_uri`,
+			},
+		},
+		{
+			Id: "9",
+			Up: []string{
+				`ALTER TABLE hydra_client ADD tls_client_auth_subject_dn varchar(1024) NOT NULL DEFAULT ''`,
+				`ALTER TABLE hydra_client ADD tls_client_auth_san_dns varchar(255) NOT NULL DEFAULT ''`,
+				`ALTER TABLE hydra_client ADD tls_client_auth_san_uri varchar(512) NOT NULL DEFAULT ''`,
+				`ALTER TABLE hydra_client ADD tls_client_auth_san_ip varchar(64) NOT NULL DEFAULT ''`,
+				`ALTER TABLE hydra_client ADD tls_client_auth_san_email varchar(255) NOT NULL DEFAULT ''`,
+			},
+			Down: []string{
+				`ALTER TABLE hydra_client DROP COLUMN tls_client_auth_subject_dn`,
+				`ALTER TABLE hydra_client DROP COLUMN tls_client_auth_san_dns`,
+				`ALTER TABLE hydra_client DROP COLUMN tls_client_auth_san_uri`,
+				`ALTER TABLE hydra_client DROP COLUMN tls_client_auth_san_ip`,
+				`ALTER TABLE hydra_client DROP COLUMN tls_client_auth_san_email`,
+			},
+		},
+		{
+			Id: "10",
+			Up: []string{
+				`ALTER TABLE hydra_client ADD require_pushed_authorization_requests boolean NOT NULL DEFAULT false`,
+			},
+			Down: []string{
+				`ALTER TABLE hydra_client DROP COLUMN require_pushed_authorization_requests`,
+			},
+		},
+		{
+			Id: "11",
+			Up: []string{
+				`ALTER TABLE hydra_client ADD jwks text`,
+				`UPDATE hydra_client SET jwks=''`,
+				`ALTER TABLE hydra_client ADD jwks_uri varchar(512) NOT NULL DEFAULT ''`,
+				`ALTER TABLE hydra_client ADD id_token_encrypted_response_alg varchar(32) NOT NULL DEFAULT ''`,
+				`ALTER TABLE hydra_client ADD id_token_encrypted_response_enc varchar(32) NOT NULL DEFAULT ''`,
+				`ALTER TABLE hydra_client ADD userinfo_encrypted_response_alg varchar(32) NOT NULL DEFAULT ''`,
+				`ALTER TABLE hydra_client ADD userinfo_encrypted_response_enc varchar(32) NOT NULL DEFAULT ''`,
+			},
+			Down: []string{
+				`ALTER TABLE hydra_client DROP COLUMN jwks`,
+				`ALTER TABLE hydra_client DROP COLUMN jwks_uri`,
+				`ALTER TABLE hydra_client DROP COLUMN id_token_encrypted_response_alg`,
+				`ALTER TABLE hydra_client DROP COLUMN id_token_encrypted_response_enc`,
+				`ALTER TABLE hydra_client DROP COLUMN userinfo_encrypted_response_alg`,
+				`ALTER TABLE hydra_client DROP COLUMN userinfo_encrypted_response_enc`,
+			},
+		},
+		{
+			Id: "12",
+			Up: []string{
+				`ALTER TABLE hydra_client ADD userinfo_signed_response_alg varchar(32) NOT NULL DEFAULT ''`,
+			},
+			Down: []string{
+				`ALTER TABLE hydra_client DROP COLUMN userinfo_signed_response_alg`,
+			},
+		},
+		{
+			Id: "13",
+			Up: []string{
+				`ALTER TABLE hydra_client ADD audience varchar(2048) NOT NULL DEFAULT ''`,
+			},
+			Down: []string{
+				`ALTER TABLE hydra_client DROP COLUMN audience`,
+			},
+		},
+		{
+			Id: "14",
+			Up: []string{
+				`ALTER TABLE hydra_client ADD allowed_cors_origins varchar(2048) NOT NULL DEFAULT ''`,
+			},
+			Down: []string{
+				`ALTER TABLE hydra_client DROP COLUMN allowed_cors_origins`,
+			},
+		},
+		{
+			Id: "15",
+			Up: []string{
+				`ALTER TABLE hydra_client ADD skip_consent boolean NOT NULL DEFAULT false`,
+			},
+			Down: []string{
+				`ALTER TABLE hydra_client DROP COLUMN skip_consent`,
+			},
+		},
+		{
+			Id: "16",
+			Up: []string{
+				`ALTER TABLE hydra_client ADD resource_server_audience varchar(2048) NOT NULL DEFAULT ''`,
+			},
+			Down: []string{
+				`ALTER TABLE hydra_client DROP COLUMN resource_server_audience`,
+			},
+		},
 	},
 }
 
 type SQLManager struct {
 	Hasher fosite.Hasher
 	DB     *sqlx.DB
+
+	// Outbox, if set, stores an event for every change to a client in the same transaction as the change.
+	Outbox events.Outbox
 }
 
 type sqlData struct {
@@ -75,6 +253,47 @@ type sqlData struct {
 	LogoURI           string `db:"logo_uri"`
 	Contacts          string `db:"contacts"`
 	Public            bool   `db:"public"`
+
+	TokenEndpointAuthMethod      string `db:"token_endpoint_auth_method"`
+	TLSClientAuthCertificate     string `db:"tls_client_auth_certificate"`
+	TLSClientAuthPublicKeySHA256 string `db:"tls_client_auth_public_key_sha256"`
+	TLSClientAuthSubjectDN       string `db:"tls_client_auth_subject_dn"`
+	TLSClientAuthSANDNS          string `db:"tls_client_auth_san_dns"`
+	TLSClientAuthSANURI          string `db:"tls_client_auth_san_uri"`
+	TLSClientAuthSANIP           string `db:"tls_client_auth_san_ip"`
+	TLSClientAuthSANEmail        string `db:"tls_client_auth_san_email"`
+
+	Status                   string `db:"status"`
+	ConsentChallengeLifespan string `db:"consent_challenge_lifespan"`
+	RequirePKCE              bool   `db:"require_pkce"`
+	FirstParty               bool   `db:"first_party"`
+	SkipConsent              bool   `db:"skip_consent"`
+	Internal                 bool   `db:"internal"`
+	Environment              string `db:"environment"`
+
+	RequirePushedAuthorizationRequests bool `db:"require_pushed_authorization_requests"`
+
+	AccessTokenLifespan   string `db:"access_token_lifespan"`
+	RefreshTokenLifespan  string `db:"refresh_token_lifespan"`
+	IDTokenLifespan       string `db:"id_token_lifespan"`
+	AuthorizeCodeLifespan string `db:"authorize_code_lifespan"`
+
+	PostLogoutRedirectURIs string `db:"post_logout_redirect_uris"`
+	FrontChannelLogoutURI  string `db:"frontchannel_logout_uri"`
+	BackChannelLogoutURI   string `db:"backchannel_logout_uri"`
+
+	JSONWebKeys                  string `db:"jwks"`
+	JSONWebKeysURI               string `db:"jwks_uri"`
+	IDTokenEncryptedResponseAlg  string `db:"id_token_encrypted_response_alg"`
+	IDTokenEncryptedResponseEnc  string `db:"id_token_encrypted_response_enc"`
+	UserinfoEncryptedResponseAlg string `db:"userinfo_encrypted_response_alg"`
+	UserinfoEncryptedResponseEnc string `db:"userinfo_encrypted_response_enc"`
+
+	UserinfoSignedResponseAlg string `db:"userinfo_signed_response_alg"`
+
+	Audience               string `db:"audience"`
+	AllowedCORSOrigins     string `db:"allowed_cors_origins"`
+	ResourceServerAudience string `db:"resource_server_audience"`
 }
 
 var sqlParams = []string{
@@ -92,9 +311,50 @@ var sqlParams = []string{
 	"logo_uri",
 	"contacts",
 	"public",
+	"token_endpoint_auth_method",
+	"tls_client_auth_certificate",
+	"tls_client_auth_public_key_sha256",
+	"tls_client_auth_subject_dn",
+	"tls_client_auth_san_dns",
+	"tls_client_auth_san_uri",
+	"tls_client_auth_san_ip",
+	"tls_client_auth_san_email",
+	"status",
+	"consent_challenge_lifespan",
+	"require_pkce",
+	"first_party",
+	"skip_consent",
+	"internal",
+	"environment",
+	"access_token_lifespan",
+	"refresh_token_lifespan",
+	"id_token_lifespan",
+	"authorize_code_lifespan",
+	"post_logout_redirect_uris",
+	"frontchannel_logout_uri",
+	"backchannel_logout_uri",
+	"require_pushed_authorization_requests",
+	"jwks",
+	"jwks_uri",
+	"id_token_encrypted_response_alg",
+	"id_token_encrypted_response_enc",
+	"userinfo_encrypted_response_alg",
+	"userinfo_encrypted_response_enc",
+	"userinfo_signed_response_alg",
+	"audience",
+	"allowed_cors_origins",
+	"resource_server_audience",
 }
 
-func sqlDataFromClient(d *Client) *sqlData {
+func sqlDataFromClient(d *Client) (*sqlData, error) {
+	var jwks []byte
+	if d.JSONWebKeys != nil {
+		var err error
+		if jwks, err = json.Marshal(d.JSONWebKeys); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
 	return &sqlData{
 		ID:                d.ID,
 		Name:              d.Name,
@@ -110,10 +370,59 @@ func sqlDataFromClient(d *Client) *sqlData {
 		LogoURI:           d.LogoURI,
 		Contacts:          strings.Join(d.Contacts, "|"),
 		Public:            d.Public,
-	}
+
+		TokenEndpointAuthMethod:      d.TokenEndpointAuthMethod,
+		TLSClientAuthCertificate:     d.TLSClientAuthCertificate,
+		TLSClientAuthPublicKeySHA256: d.TLSClientAuthPublicKeySHA256,
+		TLSClientAuthSubjectDN:       d.TLSClientAuthSubjectDN,
+		TLSClientAuthSANDNS:          d.TLSClientAuthSANDNS,
+		TLSClientAuthSANURI:          d.TLSClientAuthSANURI,
+		TLSClientAuthSANIP:           d.TLSClientAuthSANIP,
+		TLSClientAuthSANEmail:        d.TLSClientAuthSANEmail,
+
+		Status:                   d.Status,
+		ConsentChallengeLifespan: d.ConsentChallengeLifespan,
+		RequirePKCE:              d.RequirePKCE,
+		FirstParty:               d.FirstParty,
+		SkipConsent:              d.SkipConsent,
+		Internal:                 d.Internal,
+		Environment:              d.Environment,
+
+		RequirePushedAuthorizationRequests: d.RequirePushedAuthorizationRequests,
+
+		AccessTokenLifespan:   d.AccessTokenLifespan,
+		RefreshTokenLifespan:  d.RefreshTokenLifespan,
+		IDTokenLifespan:       d.IDTokenLifespan,
+		AuthorizeCodeLifespan: d.AuthorizeCodeLifespan,
+
+		PostLogoutRedirectURIs: strings.Join(d.PostLogoutRedirectURIs, "|"),
+		FrontChannelLogoutURI:  d.FrontChannelLogoutURI,
+		BackChannelLogoutURI:   d.BackChannelLogoutURI,
+
+		JSONWebKeys:                  string(jwks),
+		JSONWebKeysURI:               d.JSONWebKeysURI,
+		IDTokenEncryptedResponseAlg:  d.IDTokenEncryptedResponseAlg,
+		IDTokenEncryptedResponseEnc:  d.IDTokenEncryptedResponseEnc,
+		UserinfoEncryptedResponseAlg: d.UserinfoEncryptedResponseAlg,
+		UserinfoEncryptedResponseEnc: d.UserinfoEncryptedResponseEnc,
+
+		UserinfoSignedResponseAlg: d.UserinfoSignedResponseAlg,
+
+		Audience:               strings.Join(d.Audience, "|"),
+		AllowedCORSOrigins:     strings.Join(d.AllowedCORSOrigins, "|"),
+		ResourceServerAudience: strings.Join(d.ResourceServerAudience, "|"),
+	}, nil
 }
 
-func (d *sqlData) ToClient() *Client {
+func (d *sqlData) ToClient() (*Client, error) {
+	var jwks *jose.JSONWebKeySet
+	if d.JSONWebKeys != "" {
+		jwks = new(jose.JSONWebKeySet)
+		if err := json.Unmarshal([]byte(d.JSONWebKeys), jwks); err != nil {
+			return nil, errors.Wrapf(err, "Could not decode the JSON Web Key Set of client %s", d.ID)
+		}
+	}
+
 	return &Client{
 		ID:                d.ID,
 		Name:              d.Name,
@@ -129,7 +438,53 @@ func (d *sqlData) ToClient() *Client {
 		LogoURI:           d.LogoURI,
 		Contacts:          pkg.SplitNonEmpty(d.Contacts, "|"),
 		Public:            d.Public,
-	}
+
+		TokenEndpointAuthMethod:      d.TokenEndpointAuthMethod,
+		TLSClientAuthCertificate:     d.TLSClientAuthCertificate,
+		TLSClientAuthPublicKeySHA256: d.TLSClientAuthPublicKeySHA256,
+		TLSClientAuthSubjectDN:       d.TLSClientAuthSubjectDN,
+		TLSClientAuthSANDNS:          d.TLSClientAuthSANDNS,
+		TLSClientAuthSANURI:          d.TLSClientAuthSANURI,
+		TLSClientAuthSANIP:           d.TLSClientAuthSANIP,
+		TLSClientAuthSANEmail:        d.TLSClientAuthSANEmail,
+
+		Status:                   d.Status,
+		ConsentChallengeLifespan: d.ConsentChallengeLifespan,
+		RequirePKCE:              d.RequirePKCE,
+		FirstParty:               d.FirstParty,
+		SkipConsent:              d.SkipConsent,
+		Internal:                 d.Internal,
+		Environment:              d.Environment,
+
+		RequirePushedAuthorizationRequests: d.RequirePushedAuthorizationRequests,
+
+		AccessTokenLifespan:   d.AccessTokenLifespan,
+		RefreshTokenLifespan:  d.RefreshTokenLifespan,
+		IDTokenLifespan:       d.IDTokenLifespan,
+		AuthorizeCodeLifespan: d.AuthorizeCodeLifespan,
+
+		PostLogoutRedirectURIs: pkg.SplitNonEmpty(d.PostLogoutRedirectURIs, "|"),
+		FrontChannelLogoutURI:  d.FrontChannelLogoutURI,
+		BackChannelLogoutURI:   d.BackChannelLogoutURI,
+
+		JSONWebKeys:                  jwks,
+		JSONWebKeysURI:               d.JSONWebKeysURI,
+		IDTokenEncryptedResponseAlg:  d.IDTokenEncryptedResponseAlg,
+		IDTokenEncryptedResponseEnc:  d.IDTokenEncryptedResponseEnc,
+		UserinfoEncryptedResponseAlg: d.UserinfoEncryptedResponseAlg,
+		UserinfoEncryptedResponseEnc: d.UserinfoEncryptedResponseEnc,
+
+		UserinfoSignedResponseAlg: d.UserinfoSignedResponseAlg,
+
+		Audience:               pkg.SplitNonEmpty(d.Audience, "|"),
+		AllowedCORSOrigins:     pkg.SplitNonEmpty(d.AllowedCORSOrigins, "|"),
+		ResourceServerAudience: pkg.SplitNonEmpty(d.ResourceServerAudience, "|"),
+	}, nil
+}
+
+// Migrations returns the SQL migrations embedded in the binary.
+func (s *SQLManager) Migrations() *migrate.MemoryMigrationSource {
+	return migrations
 }
 
 func (s *SQLManager) CreateSchemas() (int, error) {
@@ -149,15 +504,21 @@ func (m *SQLManager) GetConcreteClient(id string) (*Client, error) {
 		return nil, errors.WithStack(err)
 	}
 
-	return d.ToClient(), nil
+	return d.ToClient()
 }
 
 func (m *SQLManager) GetClient(_ context.Context, id string) (fosite.Client, error) {
-	return m.GetConcreteClient(id)
+	c, err := m.GetConcreteClient(id)
+	if err != nil {
+		return nil, err
+	} else if c.IsPending() {
+		return nil, errors.Wrap(pkg.ErrNotFound, "")
+	}
+	return c, nil
 }
 
 func (m *SQLManager) UpdateClient(c *Client) error {
-	o, err := m.GetClient(context.Background(), c.ID)
+	o, err := m.GetConcreteClient(c.ID)
 	if err != nil {
 		return errors.WithStack(err)
 	}
@@ -172,13 +533,76 @@ func (m *SQLManager) UpdateClient(c *Client) error {
 		c.Secret = string(h)
 	}
 
-	s := sqlDataFromClient(c)
+	e, err := events.NewEvent(events.ClientUpdated, map[string]string{"client_id": c.ID})
+	if err != nil {
+		return err
+	}
+
+	return events.Transaction(m.DB, m.Outbox, e, func(tx *sqlx.Tx) error {
+		return updateClient(tx, c)
+	})
+}
+
+func (m *SQLManager) PatchClient(id string, patch func(c *Client) (*Client, error)) (*Client, error) {
+	dialect, err := pkg.SQLDialect(m.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	lock := "SELECT * FROM hydra_client WHERE id=? FOR UPDATE"
+	if dialect == pkg.SQLDialectCockroach {
+		// CockroachDB does not support row locks. Its transactions are serializable instead, so one of two
+		// concurrent patches fails with a retryable error and sees the other patch when it is retried.
+		lock = "SELECT * FROM hydra_client WHERE id=?"
+	}
+
+	e, err := events.NewEvent(events.ClientUpdated, map[string]string{"client_id": id})
+	if err != nil {
+		return nil, err
+	}
+
+	var result *Client
+	if err := events.Transaction(m.DB, m.Outbox, e, func(tx *sqlx.Tx) error {
+		var d sqlData
+		if err := tx.Get(&d, tx.Rebind(lock), id); err == sql.ErrNoRows {
+			return errors.Wrap(pkg.ErrNotFound, "")
+		} else if err != nil {
+			return errors.WithStack(err)
+		}
+
+		c, err := d.ToClient()
+		if err != nil {
+			return err
+		}
+
+		c, err = patchClient(c, patch, m.Hasher)
+		if err != nil {
+			return err
+		}
+
+		if err := updateClient(tx, c); err != nil {
+			return err
+		}
+		result = c
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func updateClient(tx *sqlx.Tx, c *Client) error {
 	var query []string
 	for _, param := range sqlParams {
 		query = append(query, fmt.Sprintf("%s=:%s", param, param))
 	}
 
-	if _, err := m.DB.NamedExec(fmt.Sprintf(`UPDATE hydra_client SET %s WHERE id=:id`, strings.Join(query, ", ")), s); err != nil {
+	data, err := sqlDataFromClient(c)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.NamedExec(fmt.Sprintf(`UPDATE hydra_client SET %s WHERE id=:id`, strings.Join(query, ", ")), data); err != nil {
 		return errors.WithStack(err)
 	}
 	return nil
@@ -188,6 +612,8 @@ func (m *SQLManager) Authenticate(id string, secret []byte) (*Client, error) {
 	c, err := m.GetConcreteClient(id)
 	if err != nil {
 		return nil, errors.WithStack(err)
+	} else if c.IsPending() {
+		return nil, errors.Wrap(pkg.ErrNotFound, "")
 	}
 
 	if err := m.Hasher.Compare(c.GetHashedSecret(), secret); err != nil {
@@ -208,22 +634,58 @@ func (m *SQLManager) CreateClient(c *Client) error {
 	}
 	c.Secret = string(h)
 
-	data := sqlDataFromClient(c)
-	if _, err := m.DB.NamedExec(fmt.Sprintf(
-		"INSERT INTO hydra_client (%s) VALUES (%s)",
-		strings.Join(sqlParams, ", "),
-		":"+strings.Join(sqlParams, ", :"),
-	), data); err != nil {
-		return errors.WithStack(err)
+	e, err := events.NewEvent(events.ClientCreated, map[string]string{"client_id": c.ID})
+	if err != nil {
+		return err
 	}
-	return nil
+
+	data, err := sqlDataFromClient(c)
+	if err != nil {
+		return err
+	}
+
+	return events.Transaction(m.DB, m.Outbox, e, func(tx *sqlx.Tx) error {
+		if _, err := tx.NamedExec(fmt.Sprintf(
+			"INSERT INTO hydra_client (%s) VALUES (%s)",
+			strings.Join(sqlParams, ", "),
+			":"+strings.Join(sqlParams, ", :"),
+		), data); err != nil {
+			return errors.WithStack(err)
+		}
+		return nil
+	})
+}
+
+func (m *SQLManager) ApproveClient(id string) error {
+	if _, err := m.GetConcreteClient(id); err != nil {
+		return err
+	}
+
+	e, err := events.NewEvent(events.ClientUpdated, map[string]string{"client_id": id})
+	if err != nil {
+		return err
+	}
+
+	return events.Transaction(m.DB, m.Outbox, e, func(tx *sqlx.Tx) error {
+		if _, err := tx.Exec(m.DB.Rebind(`UPDATE hydra_client SET status='' WHERE id=?`), id); err != nil {
+			return errors.WithStack(err)
+		}
+		return nil
+	})
 }
 
 func (m *SQLManager) DeleteClient(id string) error {
-	if _, err := m.DB.Exec(m.DB.Rebind(`DELETE FROM hydra_client WHERE id=?`), id); err != nil {
-		return errors.WithStack(err)
+	e, err := events.NewEvent(events.ClientDeleted, map[string]string{"client_id": id})
+	if err != nil {
+		return err
 	}
-	return nil
+
+	return events.Transaction(m.DB, m.Outbox, e, func(tx *sqlx.Tx) error {
+		if _, err := tx.Exec(m.DB.Rebind(`DELETE FROM hydra_client WHERE id=?`), id); err != nil {
+			return errors.WithStack(err)
+		}
+		return nil
+	})
 }
 
 func (m *SQLManager) GetClients(limit, offset int) (clients map[string]Client, err error) {
@@ -235,7 +697,11 @@ func (m *SQLManager) GetClients(limit, offset int) (clients map[string]Client, e
 	}
 
 	for _, k := range d {
-		clients[k.ID] = *k.ToClient()
+		c, err := k.ToClient()
+		if err != nil {
+			return nil, err
+		}
+		clients[k.ID] = *c
 	}
 	return clients, nil
 }
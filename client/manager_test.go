@@ -27,9 +27,11 @@ import (
 )
 
 var clientManagers = map[string]Manager{}
+var registrationTokenManagers = map[string]RegistrationTokenManager{}
 
 func init() {
 	clientManagers["memory"] = NewMemoryManager(&fosite.BCrypt{})
+	registrationTokenManagers["memory"] = NewRegistrationTokenMemoryManager()
 }
 
 func TestMain(m *testing.M) {
@@ -39,6 +41,7 @@ func TestMain(m *testing.M) {
 			integration.BootParallel([]func(){
 				connectToPG,
 				connectToMySQL,
+				connectToRedis,
 			})
 		}
 	}
@@ -55,7 +58,13 @@ func connectToMySQL() {
 		log.Fatalf("Could not create postgres schema: %v", err)
 	}
 
+	r := &RegistrationTokenSQLManager{DB: db}
+	if _, err := r.CreateSchemas(); err != nil {
+		log.Fatalf("Could not create mysql schema: %v", err)
+	}
+
 	clientManagers["mysql"] = s
+	registrationTokenManagers["mysql"] = r
 }
 
 func connectToPG() {
@@ -66,7 +75,19 @@ func connectToPG() {
 		log.Fatalf("Could not create postgres schema: %v", err)
 	}
 
+	r := &RegistrationTokenSQLManager{DB: db}
+	if _, err := r.CreateSchemas(); err != nil {
+		log.Fatalf("Could not create postgres schema: %v", err)
+	}
+
 	clientManagers["postgres"] = s
+	registrationTokenManagers["postgres"] = r
+}
+
+func connectToRedis() {
+	db := integration.ConnectToRedis()
+	clientManagers["redis"] = &RedisManager{DB: db, Hasher: &fosite.BCrypt{WorkFactor: 4}}
+	registrationTokenManagers["redis"] = &RegistrationTokenRedisManager{DB: db}
 }
 
 func TestCreateGetDeleteClient(t *testing.T) {
@@ -86,3 +107,9 @@ func TestAuthenticateClient(t *testing.T) {
 		t.Run(fmt.Sprintf("case=%s", k), TestHelperClientAuthenticate(k, m))
 	}
 }
+
+func TestPatchClient(t *testing.T) {
+	for k, m := range clientManagers {
+		t.Run(fmt.Sprintf("case=%s", k), TestHelperPatchClient(k, m))
+	}
+}
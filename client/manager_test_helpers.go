@@ -15,9 +15,13 @@
 package client
 
 import (
+	"fmt"
+	"sync"
 	"testing"
 
 	"github.com/ory/fosite"
+	"github.com/ory/hydra/pkg"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -137,3 +141,62 @@ func compare(t *testing.T, c fosite.Client, k string) {
 	}
 	assert.Equal(t, c.GetRedirectURIs(), []string{"http://redirect"})
 }
+
+func TestHelperPatchClient(k string, m Manager) func(t *testing.T) {
+	return func(t *testing.T) {
+		t.Parallel()
+		require.NoError(t, m.CreateClient(&Client{
+			ID:           "patch-client",
+			Secret:       "secret",
+			RedirectURIs: []string{"http://redirect"},
+			Status:       ClientStatusPending,
+		}))
+
+		_, err := m.PatchClient("does-not-exist", func(c *Client) (*Client, error) {
+			return c, nil
+		})
+		assert.Equal(t, pkg.ErrNotFound, errors.Cause(err))
+
+		var wg sync.WaitGroup
+		errs := make([]error, 5)
+		for i := range errs {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				_, errs[i] = m.PatchClient("patch-client", func(c *Client) (*Client, error) {
+					assert.Empty(t, c.Secret)
+					c.ID = "changed"
+					c.RedirectURIs = append(append([]string{}, c.RedirectURIs...), fmt.Sprintf("http://redirect/%d", i))
+					return c, nil
+				})
+			}(i)
+		}
+		wg.Wait()
+
+		for _, err := range errs {
+			require.NoError(t, err)
+		}
+
+		c, err := m.GetConcreteClient("patch-client")
+		require.NoError(t, err)
+		assert.Len(t, c.RedirectURIs, 6)
+		assert.Equal(t, ClientStatusPending, c.Status)
+		require.NoError(t, m.ApproveClient("patch-client"))
+
+		_, err = m.Authenticate("patch-client", []byte("secret"))
+		require.NoError(t, err)
+
+		_, err = m.PatchClient("patch-client", func(c *Client) (*Client, error) {
+			c.Secret = "new-secret"
+			return c, nil
+		})
+		require.NoError(t, err)
+
+		_, err = m.Authenticate("patch-client", []byte("secret"))
+		assert.Error(t, err)
+		_, err = m.Authenticate("patch-client", []byte("new-secret"))
+		require.NoError(t, err)
+
+		require.NoError(t, m.DeleteClient("patch-client"))
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+
+	"github.com/ory/hydra/pkg"
+	"github.com/pkg/errors"
+)
+
+// RegistrationTokenManager stores the signatures of registration access tokens, which allow clients registered at
+// DynamicRegistrationPath to read, update and delete their own registration.
+type RegistrationTokenManager interface {
+	// SetRegistrationToken stores the signature of the registration access token of the client, replacing its
+	// previous token.
+	SetRegistrationToken(clientID, signature string) error
+
+	// GetRegistrationToken returns the signature of the registration access token of the client or pkg.ErrNotFound
+	// if it has none.
+	GetRegistrationToken(clientID string) (string, error)
+
+	// DeleteRegistrationToken removes the registration access token of the client, if it exists.
+	DeleteRegistrationToken(clientID string) error
+}
+
+// RegistrationTokenSignature returns the signature under which a registration access token is stored. Tokens are
+// random, so a hash is sufficient.
+func RegistrationTokenSignature(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifyRegistrationToken checks that token is the registration access token of the client.
+func VerifyRegistrationToken(m RegistrationTokenManager, clientID, token string) error {
+	signature, err := m.GetRegistrationToken(clientID)
+	if err != nil {
+		return err
+	}
+
+	if token == "" || subtle.ConstantTimeCompare([]byte(signature), []byte(RegistrationTokenSignature(token))) != 1 {
+		return errors.WithStack(pkg.ErrNotFound)
+	}
+	return nil
+}
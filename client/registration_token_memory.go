@@ -0,0 +1,58 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"sync"
+
+	"github.com/ory/hydra/pkg"
+	"github.com/pkg/errors"
+)
+
+type RegistrationTokenMemoryManager struct {
+	Signatures map[string]string
+	sync.RWMutex
+}
+
+func NewRegistrationTokenMemoryManager() *RegistrationTokenMemoryManager {
+	return &RegistrationTokenMemoryManager{Signatures: map[string]string{}}
+}
+
+func (m *RegistrationTokenMemoryManager) SetRegistrationToken(clientID, signature string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	m.Signatures[clientID] = signature
+	return nil
+}
+
+func (m *RegistrationTokenMemoryManager) GetRegistrationToken(clientID string) (string, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	signature, ok := m.Signatures[clientID]
+	if !ok {
+		return "", errors.WithStack(pkg.ErrNotFound)
+	}
+	return signature, nil
+}
+
+func (m *RegistrationTokenMemoryManager) DeleteRegistrationToken(clientID string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	delete(m.Signatures, clientID)
+	return nil
+}
@@ -0,0 +1,52 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"github.com/go-redis/redis"
+	"github.com/ory/hydra/pkg"
+	"github.com/pkg/errors"
+)
+
+// redisRegistrationTokens is a hash containing the signatures of the registration access tokens, indexed by client id.
+const redisRegistrationTokens = "hydra:client:registration-tokens"
+
+type RegistrationTokenRedisManager struct {
+	DB *redis.Client
+}
+
+func (m *RegistrationTokenRedisManager) SetRegistrationToken(clientID, signature string) error {
+	if err := m.DB.HSet(redisRegistrationTokens, clientID, signature).Err(); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func (m *RegistrationTokenRedisManager) GetRegistrationToken(clientID string) (string, error) {
+	signature, err := m.DB.HGet(redisRegistrationTokens, clientID).Result()
+	if err == redis.Nil {
+		return "", errors.WithStack(pkg.ErrNotFound)
+	} else if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return signature, nil
+}
+
+func (m *RegistrationTokenRedisManager) DeleteRegistrationToken(clientID string) error {
+	if err := m.DB.HDel(redisRegistrationTokens, clientID).Err(); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/ory/hydra/pkg"
+	"github.com/pkg/errors"
+	"github.com/rubenv/sql-migrate"
+)
+
+var registrationTokenMigrations = &migrate.MemoryMigrationSource{
+	Migrations: []*migrate.Migration{
+		{
+			Id: "1",
+			Up: []string{
+				`CREATE TABLE IF NOT EXISTS hydra_client_registration_token (
+	client_id	varchar(255) NOT NULL PRIMARY KEY,
+	signature	varchar(64) NOT NULL,
+	created_at	timestamp NOT NULL
+)`,
+			},
+			Down: []string{
+				"DROP TABLE hydra_client_registration_token",
+			},
+		},
+	},
+}
+
+type RegistrationTokenSQLManager struct {
+	DB *sqlx.DB
+}
+
+// Migrations returns the SQL migrations embedded in the binary.
+func (m *RegistrationTokenSQLManager) Migrations() *migrate.MemoryMigrationSource {
+	return registrationTokenMigrations
+}
+
+func (m *RegistrationTokenSQLManager) CreateSchemas() (int, error) {
+	migrate.SetTable("hydra_client_registration_token_migration")
+	n, err := migrate.Exec(m.DB.DB, m.DB.DriverName(), registrationTokenMigrations, migrate.Up)
+	if err != nil {
+		return 0, errors.Wrapf(err, "Could not migrate sql schema, applied %d migrations", n)
+	}
+	return n, nil
+}
+
+func (m *RegistrationTokenSQLManager) SetRegistrationToken(clientID, signature string) error {
+	return pkg.SQLTransaction(m.DB, func(tx *sqlx.Tx) error {
+		if _, err := tx.Exec(tx.Rebind("DELETE FROM hydra_client_registration_token WHERE client_id=?"), clientID); err != nil {
+			return errors.WithStack(err)
+		}
+
+		if _, err := tx.Exec(
+			tx.Rebind("INSERT INTO hydra_client_registration_token (client_id, signature, created_at) VALUES (?, ?, ?)"),
+			clientID, signature, time.Now().UTC(),
+		); err != nil {
+			return errors.WithStack(err)
+		}
+		return nil
+	})
+}
+
+func (m *RegistrationTokenSQLManager) GetRegistrationToken(clientID string) (string, error) {
+	var signature string
+	if err := m.DB.Get(&signature, m.DB.Rebind("SELECT signature FROM hydra_client_registration_token WHERE client_id=?"), clientID); err == sql.ErrNoRows {
+		return "", errors.WithStack(pkg.ErrNotFound)
+	} else if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return signature, nil
+}
+
+func (m *RegistrationTokenSQLManager) DeleteRegistrationToken(clientID string) error {
+	if _, err := m.DB.Exec(m.DB.Rebind("DELETE FROM hydra_client_registration_token WHERE client_id=?"), clientID); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client_test
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/ory/hydra/client"
+	"github.com/ory/hydra/pkg"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistrationTokenManagers(t *testing.T) {
+	for k, m := range registrationTokenManagers {
+		t.Run(fmt.Sprintf("case=%s", k), func(t *testing.T) {
+			_, err := m.GetRegistrationToken("registration-token-client")
+			assert.Equal(t, pkg.ErrNotFound, errors.Cause(err))
+
+			require.NoError(t, m.SetRegistrationToken("registration-token-client", RegistrationTokenSignature("first")))
+			require.NoError(t, m.SetRegistrationToken("registration-token-client", RegistrationTokenSignature("second")))
+
+			assert.Error(t, VerifyRegistrationToken(m, "registration-token-client", "first"))
+			assert.Error(t, VerifyRegistrationToken(m, "registration-token-client", ""))
+			assert.NoError(t, VerifyRegistrationToken(m, "registration-token-client", "second"))
+
+			require.NoError(t, m.DeleteRegistrationToken("registration-token-client"))
+			_, err = m.GetRegistrationToken("registration-token-client")
+			assert.Equal(t, pkg.ErrNotFound, errors.Cause(err))
+		})
+	}
+}
@@ -15,6 +15,7 @@
 package client_test
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -55,7 +56,7 @@ func TestClientSDK(t *testing.T) {
 		ID:        "1",
 		Subjects:  []string{"alice"},
 		Resources: []string{"rn:hydra:clients<.*>"},
-		Actions:   []string{"create", "get", "delete", "update"},
+		Actions:   []string{"create", "get", "delete", "update", "approve", "reject"},
 		Effect:    ladon.AllowAccess,
 	})
 
@@ -63,6 +64,8 @@ func TestClientSDK(t *testing.T) {
 		Manager: manager,
 		H:       herodot.NewJSONWriter(nil),
 		W:       localWarden,
+
+		RegistrationEnabled: true,
 	}
 
 	router := httprouter.New()
@@ -83,7 +86,7 @@ func TestClientSDK(t *testing.T) {
 		result, _, err = c.GetOAuth2Client(createClient.Id)
 		assert.EqualValues(t, compareClient, *result)
 
-		results, _, err := c.ListOAuth2Clients(100, 0)
+		results, _, err := c.ListOAuth2Clients(100, 0, "")
 		require.NoError(t, err)
 		assert.Len(t, results, 1)
 		assert.EqualValues(t, compareClient, results[0])
@@ -120,4 +123,94 @@ func TestClientSDK(t *testing.T) {
 		require.NoError(t, err)
 		assert.NotEqual(t, "", result.ClientSecret)
 	})
+
+	t.Run("case=registered client is pending until it is approved", func(t *testing.T) {
+		registered, response, err := c.RegisterOAuth2Client(createTestClient("registered"))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusCreated, response.StatusCode)
+		assert.NotEqual(t, "1234", registered.Id)
+		assert.Empty(t, registered.Owner)
+		assert.NotEqual(t, "registeredsecret", registered.ClientSecret)
+		assert.Equal(t, client.ClientStatusPending, registered.Status)
+
+		_, err = manager.GetClient(context.Background(), registered.Id)
+		assert.Error(t, err)
+		_, err = manager.Authenticate(registered.Id, []byte(registered.ClientSecret))
+		assert.Error(t, err)
+
+		results, _, err := c.ListOAuth2Clients(100, 0, client.ClientStatusPending)
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, registered.Id, results[0].Id)
+
+		approved, response, err := c.ApproveOAuth2Client(registered.Id)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		assert.Empty(t, approved.Status)
+
+		_, err = manager.Authenticate(registered.Id, []byte(registered.ClientSecret))
+		assert.NoError(t, err)
+
+		_, response, err = c.ApproveOAuth2Client(registered.Id)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusConflict, response.StatusCode)
+
+		response, err = c.RejectOAuth2Client(registered.Id)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusConflict, response.StatusCode)
+	})
+
+	t.Run("case=registered client is rejected", func(t *testing.T) {
+		registered, _, err := c.RegisterOAuth2Client(createTestClient("rejected"))
+		require.NoError(t, err)
+
+		response, err := c.RejectOAuth2Client(registered.Id)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNoContent, response.StatusCode)
+
+		_, response, err = c.GetOAuth2Client(registered.Id)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, response.StatusCode)
+	})
+
+	t.Run("case=registration is disabled", func(t *testing.T) {
+		handler.RegistrationEnabled = false
+		defer func() { handler.RegistrationEnabled = true }()
+
+		_, response, err := c.RegisterOAuth2Client(createTestClient("disabled"))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, response.StatusCode)
+	})
+}
+
+func TestClientRegistrationAutoApprove(t *testing.T) {
+	manager := client.NewMemoryManager(nil)
+
+	localWarden, _ := compose.NewMockFirewall("foo", "alice", fosite.Arguments{client.Scope}, &ladon.DefaultPolicy{
+		ID:         "1",
+		Subjects:   []string{"<.*>"},
+		Resources:  []string{"rn:hydra:clients:registration"},
+		Actions:    []string{"approve"},
+		Effect:     ladon.AllowAccess,
+		Conditions: ladon.Conditions{"remoteIP": &ladon.CIDRCondition{CIDR: "127.0.0.0/8"}},
+	})
+
+	handler := &client.Handler{
+		Manager:             manager,
+		H:                   herodot.NewJSONWriter(nil),
+		W:                   localWarden,
+		RegistrationEnabled: true,
+	}
+
+	router := httprouter.New()
+	handler.SetRoutes(router)
+	server := httptest.NewServer(router)
+	c := hydra.NewOAuth2ApiWithBasePath(server.URL)
+
+	registered, _, err := c.RegisterOAuth2Client(createTestClient(""))
+	require.NoError(t, err)
+	assert.Empty(t, registered.Status)
+
+	_, err = manager.Authenticate(registered.Id, []byte(registered.ClientSecret))
+	assert.NoError(t, err)
 }
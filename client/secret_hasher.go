@@ -0,0 +1,223 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	SecretHashBCrypt   = "bcrypt"
+	SecretHashArgon2id = "argon2id"
+)
+
+var (
+	errSecretMismatch = errors.New("The client secret does not match the hash")
+	errRehashSkipped  = errors.New("The client secret was changed or rehashed concurrently")
+)
+
+// Argon2idParams are the parameters of argon2id hashes.
+type Argon2idParams struct {
+	// Memory is the memory used to compute a hash in KiB.
+	Memory uint32
+
+	// Iterations is the number of passes over the memory.
+	Iterations uint32
+
+	// Parallelism is the number of threads used to compute a hash.
+	Parallelism uint8
+}
+
+// DefaultArgon2idParams are the argon2id parameters recommended by RFC 9106 for memory constrained environments.
+var DefaultArgon2idParams = Argon2idParams{Memory: 64 * 1024, Iterations: 3, Parallelism: 4}
+
+// SecretHasher hashes client secrets using bcrypt or argon2id. It compares secrets with hashes of either algorithm,
+// so the algorithm and its parameters can be changed without invalidating stored secrets, see NeedsRehash.
+type SecretHasher struct {
+	// Algorithm is SecretHashBCrypt or SecretHashArgon2id, bcrypt is used if it is empty.
+	Algorithm string
+
+	// BCryptCost is the cost of bcrypt hashes, bcrypt.DefaultCost is used if it is lower than bcrypt.MinCost.
+	BCryptCost int
+
+	// Argon2id are the parameters of argon2id hashes.
+	Argon2id Argon2idParams
+}
+
+// Validate returns an error if the algorithm is unknown or the argon2id parameters are invalid.
+func (h *SecretHasher) Validate() error {
+	switch h.Algorithm {
+	case "", SecretHashBCrypt:
+		if h.BCryptCost > bcrypt.MaxCost {
+			return errors.Errorf("The bcrypt cost may not exceed %d", bcrypt.MaxCost)
+		}
+	case SecretHashArgon2id:
+		if h.Argon2id.Memory < 8*uint32(h.Argon2id.Parallelism) || h.Argon2id.Iterations < 1 || h.Argon2id.Parallelism < 1 {
+			return errors.New("The argon2id iterations and parallelism must be positive and the memory at least 8 KiB per thread")
+		}
+	default:
+		return errors.Errorf("Unknown client secret hash algorithm %s", h.Algorithm)
+	}
+	return nil
+}
+
+func (h *SecretHasher) Hash(data []byte) ([]byte, error) {
+	if h.Algorithm != SecretHashArgon2id {
+		hash, err := bcrypt.GenerateFromPassword(data, h.bcryptCost())
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return hash, nil
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	p := h.Argon2id
+	key := argon2.IDKey(data, salt, p.Iterations, p.Memory, p.Parallelism, 32)
+	return []byte(fmt.Sprintf(
+		"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, p.Memory, p.Iterations, p.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key),
+	)), nil
+}
+
+func (h *SecretHasher) Compare(hash, data []byte) error {
+	if !isArgon2idHash(hash) {
+		if err := bcrypt.CompareHashAndPassword(hash, data); err != nil {
+			return errors.WithStack(err)
+		}
+		return nil
+	}
+
+	p, salt, key, err := parseArgon2idHash(hash)
+	if err != nil {
+		return err
+	}
+
+	if subtle.ConstantTimeCompare(argon2.IDKey(data, salt, p.Iterations, p.Memory, p.Parallelism, uint32(len(key))), key) != 1 {
+		return errors.WithStack(errSecretMismatch)
+	}
+	return nil
+}
+
+// NeedsRehash returns true if hash was created using another algorithm or weaker parameters than h uses. Hashes
+// which are neither bcrypt nor argon2id hashes are never rehashed.
+func (h *SecretHasher) NeedsRehash(hash []byte) bool {
+	if isArgon2idHash(hash) {
+		p, _, _, err := parseArgon2idHash(hash)
+		if err != nil {
+			return false
+		}
+		return h.Algorithm != SecretHashArgon2id ||
+			p.Memory < h.Argon2id.Memory || p.Iterations < h.Argon2id.Iterations || p.Parallelism < h.Argon2id.Parallelism
+	}
+
+	cost, err := bcrypt.Cost(hash)
+	if err != nil {
+		return false
+	}
+	return h.Algorithm == SecretHashArgon2id || cost < h.bcryptCost()
+}
+
+func (h *SecretHasher) bcryptCost() int {
+	if h.BCryptCost < bcrypt.MinCost {
+		return bcrypt.DefaultCost
+	}
+	return h.BCryptCost
+}
+
+// DescribeSecretHash returns the algorithm and parameters of a client secret hash, for example "bcrypt (cost 10)".
+func DescribeSecretHash(hash []byte) string {
+	if isArgon2idHash(hash) {
+		if p, _, _, err := parseArgon2idHash(hash); err == nil {
+			return fmt.Sprintf("argon2id (memory %d KiB, %d iterations, parallelism %d)", p.Memory, p.Iterations, p.Parallelism)
+		}
+	} else if cost, err := bcrypt.Cost(hash); err == nil {
+		return fmt.Sprintf("bcrypt (cost %d)", cost)
+	}
+	return "unknown"
+}
+
+func isArgon2idHash(hash []byte) bool {
+	return strings.HasPrefix(string(hash), "$argon2id$")
+}
+
+func parseArgon2idHash(hash []byte) (p Argon2idParams, salt, key []byte, err error) {
+	parts := strings.Split(string(hash), "$")
+	if len(parts) != 6 {
+		return p, nil, nil, errors.New("Malformed argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, errors.Errorf("Unsupported argon2id version %s", parts[2])
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism); err != nil {
+		return p, nil, nil, errors.Wrap(err, "Malformed argon2id parameters")
+	}
+
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return p, nil, nil, errors.WithStack(err)
+	}
+
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return p, nil, nil, errors.WithStack(err)
+	} else if len(key) == 0 {
+		return p, nil, nil, errors.New("Malformed argon2id hash")
+	}
+
+	return p, salt, key, nil
+}
+
+// RehashSecret hashes secret again and stores it if the stored hash of the client was created using another
+// algorithm or weaker parameters than hasher uses, see SecretHasher.NeedsRehash. The client must have just been
+// authenticated using secret. It returns true if the secret was rehashed. The secret is not stored if it was changed
+// in the meantime.
+func RehashSecret(m Manager, hasher *SecretHasher, id string, secret []byte) (bool, error) {
+	c, err := m.GetConcreteClient(id)
+	if err != nil {
+		return false, err
+	} else if !hasher.NeedsRehash(c.GetHashedSecret()) {
+		return false, nil
+	}
+
+	if _, err := m.PatchClient(id, func(c *Client) (*Client, error) {
+		if !hasher.NeedsRehash([]byte(c.hashedSecret)) {
+			return nil, errors.WithStack(errRehashSkipped)
+		} else if err := hasher.Compare([]byte(c.hashedSecret), secret); err != nil {
+			return nil, errors.WithStack(errRehashSkipped)
+		}
+
+		c.Secret = string(secret)
+		return c, nil
+	}); errors.Cause(err) == errRehashSkipped {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
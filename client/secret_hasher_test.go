@@ -0,0 +1,81 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecretHasher(t *testing.T) {
+	weak := &SecretHasher{BCryptCost: 4}
+	strong := &SecretHasher{BCryptCost: 5}
+	argon := &SecretHasher{Algorithm: SecretHashArgon2id, Argon2id: Argon2idParams{Memory: 64, Iterations: 1, Parallelism: 1}}
+
+	for k, h := range []*SecretHasher{weak, strong, argon} {
+		hash, err := h.Hash([]byte("secret"))
+		require.NoError(t, err, "case %d", k)
+
+		for _, other := range []*SecretHasher{weak, strong, argon} {
+			assert.NoError(t, other.Compare(hash, []byte("secret")), "case %d", k)
+			assert.Error(t, other.Compare(hash, []byte("other")), "case %d", k)
+		}
+		assert.False(t, h.NeedsRehash(hash), "case %d", k)
+	}
+
+	hash, err := weak.Hash([]byte("secret"))
+	require.NoError(t, err)
+	assert.True(t, strong.NeedsRehash(hash))
+	assert.True(t, argon.NeedsRehash(hash))
+	assert.Equal(t, "bcrypt (cost 4)", DescribeSecretHash(hash))
+
+	hash, err = argon.Hash([]byte("secret"))
+	require.NoError(t, err)
+	assert.True(t, strong.NeedsRehash(hash))
+	assert.True(t, (&SecretHasher{Algorithm: SecretHashArgon2id, Argon2id: Argon2idParams{Memory: 128, Iterations: 1, Parallelism: 1}}).NeedsRehash(hash))
+	assert.Equal(t, "argon2id (memory 64 KiB, 1 iterations, parallelism 1)", DescribeSecretHash(hash))
+
+	assert.False(t, strong.NeedsRehash([]byte("not a hash")))
+	assert.Error(t, (&SecretHasher{Algorithm: "md5"}).Validate())
+}
+
+func TestRehashSecret(t *testing.T) {
+	weak := &SecretHasher{BCryptCost: 4}
+	strong := &SecretHasher{BCryptCost: 5}
+
+	m := NewMemoryManager(weak)
+	require.NoError(t, m.CreateClient(&Client{ID: "foo", Secret: "secret"}))
+
+	m.Hasher = strong
+	rehashed, err := RehashSecret(m, strong, "foo", []byte("other"))
+	require.NoError(t, err)
+	assert.False(t, rehashed)
+
+	rehashed, err = RehashSecret(m, strong, "foo", []byte("secret"))
+	require.NoError(t, err)
+	assert.True(t, rehashed)
+
+	c, err := m.GetConcreteClient("foo")
+	require.NoError(t, err)
+	assert.Equal(t, "bcrypt (cost 5)", DescribeSecretHash(c.GetHashedSecret()))
+	assert.NoError(t, strong.Compare(c.GetHashedSecret(), []byte("secret")))
+	assert.Empty(t, c.hashedSecret)
+
+	rehashed, err = RehashSecret(m, strong, "foo", []byte("secret"))
+	require.NoError(t, err)
+	assert.False(t, rehashed)
+}
@@ -0,0 +1,179 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"crypto/rand"
+	"io"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/ory/hydra/rand/sequence"
+	"github.com/pkg/errors"
+)
+
+// SecretCharacterClasses are the character classes generated client secrets can be composed of.
+var SecretCharacterClasses = map[string]string{
+	"lower":   "abcdefghijklmnopqrstuvwxyz",
+	"upper":   "ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+	"digits":  "1234567890",
+	"symbols": "_-.~",
+}
+
+// SecretGenerator generates the secrets of clients which are created without one.
+type SecretGenerator interface {
+	// GenerateSecret returns a new client secret and metadata on how it was generated.
+	GenerateSecret() (string, *SecretGeneration, error)
+}
+
+// SecretGeneration describes how a client secret was generated.
+//
+// swagger:model oAuth2ClientSecretGeneration
+type SecretGeneration struct {
+	// Length is the number of characters of the secret, without the secret prefix.
+	Length int `json:"length"`
+
+	// CharacterClasses are the character classes the secret was drawn from.
+	CharacterClasses []string `json:"character_classes"`
+
+	// EntropyBits is the entropy of the secret in bits.
+	EntropyBits float64 `json:"entropy_bits"`
+
+	// EntropySource is the source of randomness the secret was generated with.
+	EntropySource string `json:"entropy_source"`
+}
+
+// SecretPolicy generates client secrets of a minimum length and entropy which contain at least one character of each
+// of the configured character classes.
+type SecretPolicy struct {
+	// Length is the minimum number of characters of generated secrets.
+	Length int
+
+	// MinEntropy is the minimum entropy of generated secrets in bits. The length is increased if Length characters
+	// do not provide enough entropy.
+	MinEntropy int
+
+	// CharacterClasses are keys of SecretCharacterClasses.
+	CharacterClasses []string
+
+	// Source is the source of randomness, crypto/rand is used if it is nil. SourceName describes it in the generation
+	// metadata.
+	Source     io.Reader
+	SourceName string
+}
+
+// NewDefaultSecretPolicy returns a policy generating 26 character secrets from all character classes using crypto/rand.
+func NewDefaultSecretPolicy() *SecretPolicy {
+	return &SecretPolicy{
+		Length:           26,
+		CharacterClasses: []string{"lower", "upper", "digits", "symbols"},
+	}
+}
+
+// Validate returns an error if the policy can not generate secrets.
+func (p *SecretPolicy) Validate() error {
+	if len(p.CharacterClasses) == 0 {
+		return errors.New("At least one character class is required to generate client secrets")
+	}
+
+	for _, class := range p.CharacterClasses {
+		if _, ok := SecretCharacterClasses[class]; !ok {
+			return errors.Errorf("Unknown client secret character class %s", class)
+		}
+	}
+
+	if p.Length < 1 && p.MinEntropy < 1 {
+		return errors.New("Either the length or the minimum entropy of client secrets must be positive")
+	}
+
+	if p.length() < len(p.CharacterClasses) {
+		return errors.Errorf("Client secrets of %d characters can not contain all of %d character classes", p.length(), len(p.CharacterClasses))
+	}
+
+	return nil
+}
+
+// GenerateSecret generates a new client secret according to the policy.
+func (p *SecretPolicy) GenerateSecret() (string, *SecretGeneration, error) {
+	if err := p.Validate(); err != nil {
+		return "", nil, err
+	}
+
+	source, sourceName := p.Source, p.SourceName
+	if source == nil {
+		source, sourceName = rand.Reader, "crypto/rand"
+	}
+
+	runes := p.runes()
+	length := p.length()
+
+	// Drawing every character from all classes keeps the entropy at length*log2(len(runes)); secrets which miss a
+	// class are discarded instead of patched, which would make the position of that class predictable.
+	for i := 0; i < 1000; i++ {
+		secret, err := sequence.RuneSequenceFrom(source, length, runes)
+		if err != nil {
+			return "", nil, errors.WithStack(err)
+		}
+
+		if !p.containsAllClasses(string(secret)) {
+			continue
+		}
+
+		classes := append([]string{}, p.CharacterClasses...)
+		sort.Strings(classes)
+		return string(secret), &SecretGeneration{
+			Length:           length,
+			CharacterClasses: classes,
+			EntropyBits:      math.Floor(float64(length)*math.Log2(float64(len(runes)))*100) / 100,
+			EntropySource:    sourceName,
+		}, nil
+	}
+
+	return "", nil, errors.New("Unable to generate a client secret containing all character classes")
+}
+
+func (p *SecretPolicy) runes() []rune {
+	var runes []rune
+	seen := map[string]bool{}
+	for _, class := range p.CharacterClasses {
+		if !seen[class] {
+			seen[class] = true
+			runes = append(runes, []rune(SecretCharacterClasses[class])...)
+		}
+	}
+	return runes
+}
+
+func (p *SecretPolicy) length() int {
+	length := p.Length
+	if p.MinEntropy > 0 {
+		if n := len(p.runes()); n > 1 {
+			if l := int(math.Ceil(float64(p.MinEntropy) / math.Log2(float64(n)))); l > length {
+				length = l
+			}
+		}
+	}
+	return length
+}
+
+func (p *SecretPolicy) containsAllClasses(secret string) bool {
+	for _, class := range p.CharacterClasses {
+		if !strings.ContainsAny(secret, SecretCharacterClasses[class]) {
+			return false
+		}
+	}
+	return true
+}
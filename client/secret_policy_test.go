@@ -0,0 +1,69 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecretPolicy(t *testing.T) {
+	t.Run("case=default policy", func(t *testing.T) {
+		secret, generation, err := NewDefaultSecretPolicy().GenerateSecret()
+		require.NoError(t, err)
+		assert.Len(t, secret, 26)
+		assert.Equal(t, 26, generation.Length)
+		assert.Equal(t, []string{"digits", "lower", "symbols", "upper"}, generation.CharacterClasses)
+		assert.Equal(t, "crypto/rand", generation.EntropySource)
+		for _, class := range SecretCharacterClasses {
+			assert.True(t, strings.ContainsAny(secret, class), "%s does not contain any of %s", secret, class)
+		}
+	})
+
+	t.Run("case=minimum entropy increases the length", func(t *testing.T) {
+		p := &SecretPolicy{Length: 12, MinEntropy: 256, CharacterClasses: []string{"lower", "upper", "digits"}}
+		secret, generation, err := p.GenerateSecret()
+		require.NoError(t, err)
+		assert.Len(t, secret, 43)
+		assert.True(t, generation.EntropyBits >= 256, "%f", generation.EntropyBits)
+		assert.False(t, strings.ContainsAny(secret, SecretCharacterClasses["symbols"]))
+	})
+
+	t.Run("case=uses the entropy source", func(t *testing.T) {
+		p := &SecretPolicy{Length: 8, CharacterClasses: []string{"digits"}, Source: bytes.NewReader(make([]byte, 8)), SourceName: "zeros"}
+		secret, generation, err := p.GenerateSecret()
+		require.NoError(t, err)
+		assert.Equal(t, "11111111", secret)
+		assert.Equal(t, "zeros", generation.EntropySource)
+
+		_, _, err = p.GenerateSecret()
+		assert.Error(t, err)
+	})
+
+	t.Run("case=rejects invalid policies", func(t *testing.T) {
+		for k, p := range []*SecretPolicy{
+			{Length: 26},
+			{Length: 26, CharacterClasses: []string{"emoji"}},
+			{CharacterClasses: []string{"lower"}},
+			{Length: 2, CharacterClasses: []string{"lower", "upper", "digits"}},
+		} {
+			assert.Error(t, p.Validate(), "case %d", k)
+		}
+	})
+}
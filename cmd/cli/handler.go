@@ -26,6 +26,7 @@ type Handler struct {
 	Token     *TokenHandler
 	Groups    *GroupHandler
 	Migration *MigrateHandler
+	Janitor   *JanitorHandler
 }
 
 func NewHandler(c *config.Config) *Handler {
@@ -37,5 +38,6 @@ func NewHandler(c *config.Config) *Handler {
 		Token:     newTokenHandler(c),
 		Groups:    newGroupHandler(c),
 		Migration: newMigrateHandler(c),
+		Janitor:   newJanitorHandler(c),
 	}
 }
@@ -15,9 +15,14 @@
 package cli
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
 
 	"net/http"
@@ -25,6 +30,7 @@ import (
 	"github.com/ory/hydra/config"
 	"github.com/ory/hydra/pkg"
 	hydra "github.com/ory/hydra/sdk/go/hydra/swagger"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
 
@@ -76,30 +82,87 @@ func (h *ClientHandler) CreateClient(cmd *cobra.Command, args []string) {
 	responseTypes, _ := cmd.Flags().GetStringSlice("response-types")
 	grantTypes, _ := cmd.Flags().GetStringSlice("grant-types")
 	allowedScopes, _ := cmd.Flags().GetStringSlice("allowed-scopes")
+	audience, _ := cmd.Flags().GetStringSlice("audience")
+	allowedCORSOrigins, _ := cmd.Flags().GetStringSlice("allowed-cors-origins")
+	resourceServerAudience, _ := cmd.Flags().GetStringSlice("resource-server-audience")
 	callbacks, _ := cmd.Flags().GetStringSlice("callbacks")
 	name, _ := cmd.Flags().GetString("name")
 	secret, _ := cmd.Flags().GetString("secret")
 	id, _ := cmd.Flags().GetString("id")
 	public, _ := cmd.Flags().GetBool("is-public")
+	skipConsent, _ := cmd.Flags().GetBool("skip-consent")
+	requirePKCE, _ := cmd.Flags().GetBool("require-pkce")
+	requirePAR, _ := cmd.Flags().GetBool("require-pushed-authorization-requests")
+	authMethod, _ := cmd.Flags().GetString("token-endpoint-auth-method")
+	certificateFile, _ := cmd.Flags().GetString("tls-client-auth-certificate")
+	publicKeySHA256, _ := cmd.Flags().GetString("tls-client-auth-public-key-sha256")
+	subjectDN, _ := cmd.Flags().GetString("tls-client-auth-subject-dn")
+	sanDNS, _ := cmd.Flags().GetString("tls-client-auth-san-dns")
+	sanURI, _ := cmd.Flags().GetString("tls-client-auth-san-uri")
+	sanIP, _ := cmd.Flags().GetString("tls-client-auth-san-ip")
+	sanEmail, _ := cmd.Flags().GetString("tls-client-auth-san-email")
+	jwksFile, _ := cmd.Flags().GetString("jwks")
+	jwksURI, _ := cmd.Flags().GetString("jwks-uri")
+	idTokenAlg, _ := cmd.Flags().GetString("id-token-encrypted-response-alg")
+	idTokenEnc, _ := cmd.Flags().GetString("id-token-encrypted-response-enc")
+	userinfoSigningAlg, _ := cmd.Flags().GetString("userinfo-signed-response-alg")
+	userinfoAlg, _ := cmd.Flags().GetString("userinfo-encrypted-response-alg")
+	userinfoEnc, _ := cmd.Flags().GetString("userinfo-encrypted-response-enc")
 
-	if secret == "" {
-		var secretb []byte
-		secretb, err = pkg.GenerateSecret(26)
-		pkg.Must(err, "Could not generate secret: %s", err)
-		secret = string(secretb)
-	} else {
+	var certificate string
+	if certificateFile != "" {
+		pem, err := ioutil.ReadFile(certificateFile)
+		pkg.Must(err, "Could not read client certificate from %s: %s", certificateFile, err)
+		certificate = string(pem)
+	}
+
+	var jwks *hydra.JsonWebKeySet
+	if jwksFile != "" {
+		raw, err := ioutil.ReadFile(jwksFile)
+		pkg.Must(err, "Could not read JSON Web Key Set from %s: %s", jwksFile, err)
+
+		jwks = new(hydra.JsonWebKeySet)
+		err = json.Unmarshal(raw, jwks)
+		pkg.Must(err, "Could not decode JSON Web Key Set from %s: %s", jwksFile, err)
+	}
+
+	if secret != "" {
 		fmt.Println("You should not provide secrets using command line flags. The secret might leak to bash history and similar systems.")
 	}
 
 	cc := hydra.OAuth2Client{
-		Id:            id,
-		ClientSecret:  secret,
-		ResponseTypes: responseTypes,
-		Scope:         strings.Join(allowedScopes, " "),
-		GrantTypes:    grantTypes,
-		RedirectUris:  callbacks,
-		ClientName:    name,
-		Public:        public,
+		Id:                     id,
+		ClientSecret:           secret,
+		ResponseTypes:          responseTypes,
+		Scope:                  strings.Join(allowedScopes, " "),
+		Audience:               audience,
+		AllowedCorsOrigins:     allowedCORSOrigins,
+		ResourceServerAudience: resourceServerAudience,
+		GrantTypes:             grantTypes,
+		RedirectUris:           callbacks,
+		ClientName:             name,
+		Public:                 public,
+		SkipConsent:            skipConsent,
+		RequirePkce:            requirePKCE,
+
+		RequirePushedAuthorizationRequests: requirePAR,
+
+		TokenEndpointAuthMethod:      authMethod,
+		TlsClientAuthCertificate:     certificate,
+		TlsClientAuthPublicKeySha256: publicKeySHA256,
+		TlsClientAuthSubjectDn:       subjectDN,
+		TlsClientAuthSanDns:          sanDNS,
+		TlsClientAuthSanUri:          sanURI,
+		TlsClientAuthSanIp:           sanIP,
+		TlsClientAuthSanEmail:        sanEmail,
+
+		Jwks:                         jwks,
+		JwksUri:                      jwksURI,
+		IdTokenEncryptedResponseAlg:  idTokenAlg,
+		IdTokenEncryptedResponseEnc:  idTokenEnc,
+		UserinfoEncryptedResponseAlg: userinfoAlg,
+		UserinfoEncryptedResponseEnc: userinfoEnc,
+		UserinfoSignedResponseAlg:    userinfoSigningAlg,
 	}
 
 	result, response, err := m.CreateOAuth2Client(cc)
@@ -142,3 +205,196 @@ func (h *ClientHandler) GetClient(cmd *cobra.Command, args []string) {
 	checkResponse(response, err, http.StatusOK)
 	fmt.Printf("%s\n", formatResponse(cl))
 }
+
+func (h *ClientHandler) DiffClients(cmd *cobra.Command, args []string) {
+	m := h.newClientManager(cmd)
+
+	if len(args) != 1 {
+		fmt.Print(cmd.UsageString())
+		return
+	}
+
+	manifest, err := readClientManifest(args[0])
+	pkg.Must(err, "Could not read manifest: %s", err)
+
+	var live []hydra.OAuth2Client
+	for offset := int64(0); ; offset += clientDiffPageSize {
+		clients, response, err := m.ListOAuth2Clients(clientDiffPageSize, offset, "")
+		checkResponse(response, err, http.StatusOK)
+		live = append(live, clients...)
+		if len(clients) < clientDiffPageSize {
+			break
+		}
+	}
+
+	changes := diffClients(manifest, live)
+	fmt.Print(formatClientChanges(changes))
+
+	if exit, _ := cmd.Flags().GetBool("detailed-exitcode"); exit && len(changes) > 0 {
+		os.Exit(2)
+	}
+}
+
+// clientDiffPageSize is the number of clients fetched per request when listing the live clients.
+const clientDiffPageSize = 500
+
+// clientDiffIgnoredFields are not compared because the server never returns client secrets and the status is
+// managed by approving registered clients.
+var clientDiffIgnoredFields = map[string]bool{
+	"client_secret": true,
+	"status":        true,
+}
+
+const (
+	clientChangeCreate = "create"
+	clientChangeUpdate = "update"
+	clientChangeDelete = "delete"
+)
+
+type clientChange struct {
+	Action string
+	ID     string
+	// Fields lists the differing fields of an update, sorted by name.
+	Fields []clientFieldChange
+}
+
+type clientFieldChange struct {
+	Name string
+	From interface{}
+	To   interface{}
+}
+
+// readClientManifest reads every *.json file in dir, each containing one client as accepted by
+// "hydra clients import".
+func readClientManifest(dir string) ([]hydra.OAuth2Client, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	seen := map[string]string{}
+	clients := make([]hydra.OAuth2Client, 0, len(paths))
+	for _, path := range paths {
+		body, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		var c hydra.OAuth2Client
+		if err := json.Unmarshal(body, &c); err != nil {
+			return nil, errors.Wrapf(err, "could not parse %s", path)
+		} else if c.Id == "" {
+			return nil, errors.Errorf("client in %s has no id", path)
+		} else if other, ok := seen[c.Id]; ok {
+			return nil, errors.Errorf("client %s is defined in both %s and %s", c.Id, other, path)
+		}
+
+		seen[c.Id] = path
+		clients = append(clients, c)
+	}
+	return clients, nil
+}
+
+// diffClients returns the changes which turn live into manifest, ordered by client id.
+func diffClients(manifest, live []hydra.OAuth2Client) []clientChange {
+	current := map[string]hydra.OAuth2Client{}
+	for _, c := range live {
+		current[c.Id] = c
+	}
+
+	var changes []clientChange
+	for _, c := range manifest {
+		l, ok := current[c.Id]
+		if !ok {
+			changes = append(changes, clientChange{Action: clientChangeCreate, ID: c.Id})
+			continue
+		}
+
+		delete(current, c.Id)
+		if fields := diffClientFields(l, c); len(fields) > 0 {
+			changes = append(changes, clientChange{Action: clientChangeUpdate, ID: c.Id, Fields: fields})
+		}
+	}
+
+	for id := range current {
+		changes = append(changes, clientChange{Action: clientChangeDelete, ID: id})
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].ID < changes[j].ID
+	})
+	return changes
+}
+
+func diffClientFields(from, to hydra.OAuth2Client) []clientFieldChange {
+	a, b := clientFields(from), clientFields(to)
+
+	names := map[string]bool{}
+	for name := range a {
+		names[name] = true
+	}
+	for name := range b {
+		names[name] = true
+	}
+
+	var fields []clientFieldChange
+	for name := range names {
+		if clientDiffIgnoredFields[name] || reflect.DeepEqual(a[name], b[name]) {
+			continue
+		}
+		fields = append(fields, clientFieldChange{Name: name, From: a[name], To: b[name]})
+	}
+
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].Name < fields[j].Name
+	})
+	return fields
+}
+
+// clientFields returns the JSON fields of c. Empty fields are omitted, so a field missing from the manifest equals
+// an empty field of the live client.
+func clientFields(c hydra.OAuth2Client) map[string]interface{} {
+	out, err := json.Marshal(c)
+	pkg.Must(err, "Could not encode client: %s", err)
+
+	var fields map[string]interface{}
+	err = json.Unmarshal(out, &fields)
+	pkg.Must(err, "Could not decode client: %s", err)
+	return fields
+}
+
+func formatClientChanges(changes []clientChange) string {
+	if len(changes) == 0 {
+		return "No changes. The live clients match the manifest.\n"
+	}
+
+	var b bytes.Buffer
+	counts := map[string]int{}
+	for _, c := range changes {
+		counts[c.Action]++
+		switch c.Action {
+		case clientChangeCreate:
+			fmt.Fprintf(&b, "+ create client %s\n", c.ID)
+		case clientChangeUpdate:
+			fmt.Fprintf(&b, "~ update client %s\n", c.ID)
+			for _, f := range c.Fields {
+				fmt.Fprintf(&b, "    %s: %s => %s\n", f.Name, formatClientField(f.From), formatClientField(f.To))
+			}
+		case clientChangeDelete:
+			fmt.Fprintf(&b, "- delete client %s\n", c.ID)
+		}
+	}
+
+	fmt.Fprintf(&b, "\nPlan: %d to create, %d to update, %d to delete.\n", counts[clientChangeCreate], counts[clientChangeUpdate], counts[clientChangeDelete])
+	return b.String()
+}
+
+func formatClientField(v interface{}) string {
+	if v == nil {
+		return "(empty)"
+	}
+
+	out, err := json.Marshal(v)
+	pkg.Must(err, "Could not encode field: %s", err)
+	return string(out)
+}
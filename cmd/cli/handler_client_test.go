@@ -0,0 +1,83 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	hydra "github.com/ory/hydra/sdk/go/hydra/swagger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadClientManifest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hydra-clients")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "a.json"), []byte(`{"id":"a","scope":"foo"}`), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "README.md"), []byte(`not a client`), 0600))
+
+	clients, err := readClientManifest(dir)
+	require.NoError(t, err)
+	require.Len(t, clients, 1)
+	assert.Equal(t, "foo", clients[0].Scope)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "b.json"), []byte(`{"id":"a"}`), 0600))
+	_, err = readClientManifest(dir)
+	assert.Error(t, err)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "b.json"), []byte(`{"scope":"foo"}`), 0600))
+	_, err = readClientManifest(dir)
+	assert.Error(t, err)
+}
+
+func TestDiffClients(t *testing.T) {
+	manifest := []hydra.OAuth2Client{
+		{Id: "create", ClientName: "new"},
+		{Id: "same", Scope: "foo", ClientSecret: "secret"},
+		{Id: "update", Scope: "foo bar", RedirectUris: []string{"https://a/cb"}},
+	}
+	live := []hydra.OAuth2Client{
+		{Id: "delete"},
+		{Id: "same", Scope: "foo", Status: "approved"},
+		{Id: "update", Scope: "foo", RedirectUris: []string{"https://a/cb"}, ClientName: "old"},
+	}
+
+	changes := diffClients(manifest, live)
+	assert.Equal(t, []clientChange{
+		{Action: clientChangeCreate, ID: "create"},
+		{Action: clientChangeDelete, ID: "delete"},
+		{Action: clientChangeUpdate, ID: "update", Fields: []clientFieldChange{
+			{Name: "client_name", From: "old", To: nil},
+			{Name: "scope", From: "foo", To: "foo bar"},
+		}},
+	}, changes)
+
+	assert.Equal(t, `+ create client create
+- delete client delete
+~ update client update
+    client_name: "old" => (empty)
+    scope: "foo" => "foo bar"
+
+Plan: 1 to create, 1 to update, 1 to delete.
+`, formatClientChanges(changes))
+
+	assert.Empty(t, diffClients(manifest[1:2], live[1:2]))
+	assert.Equal(t, "No changes. The live clients match the manifest.\n", formatClientChanges(nil))
+}
@@ -0,0 +1,78 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ory/hydra/config"
+	"github.com/ory/hydra/oauth2"
+	"github.com/spf13/cobra"
+)
+
+type JanitorHandler struct {
+	c *config.Config
+	m *MigrateHandler
+}
+
+func newJanitorHandler(c *config.Config) *JanitorHandler {
+	return &JanitorHandler{c: c, m: newMigrateHandler(c)}
+}
+
+func (h *JanitorHandler) PurgeExpired(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		fmt.Println(cmd.UsageString())
+		return
+	}
+
+	batchSize, _ := cmd.Flags().GetInt("batch-size")
+	limit, _ := cmd.Flags().GetInt("limit")
+	if batchSize <= 0 || limit < 0 {
+		fmt.Println(cmd.UsageString())
+		return
+	}
+
+	db, err := h.m.connectToSql(args[0])
+	if err != nil {
+		fmt.Printf("An error occurred while connecting to SQL: %s", err)
+		os.Exit(1)
+		return
+	}
+
+	janitor := &oauth2.SQLJanitor{
+		DB:                  db,
+		AccessTokenLifespan: h.c.GetAccessTokenLifespan(),
+		AuthCodeLifespan:    h.c.GetAuthCodeLifespan(),
+		BatchSize:           batchSize,
+		Limit:               limit,
+		DecisionRetention:   h.c.GetWardenDecisionLogRetention(),
+	}
+
+	results, err := janitor.PurgeExpired(time.Now().UTC())
+	for _, r := range results {
+		if r.Abandoned > 0 {
+			fmt.Printf("Removed %d expired records from %s, %d of which were abandoned.\n", r.Removed, r.Table, r.Abandoned)
+		} else {
+			fmt.Printf("Removed %d expired records from %s.\n", r.Removed, r.Table)
+		}
+	}
+	if err != nil {
+		fmt.Printf("An error occurred while removing expired records: %s", err)
+		os.Exit(1)
+		return
+	}
+}
@@ -15,11 +15,13 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
-
+	"io/ioutil"
 	"net/http"
 
 	"github.com/ory/hydra/config"
+	"github.com/ory/hydra/pkg"
 	hydra "github.com/ory/hydra/sdk/go/hydra/swagger"
 	"github.com/spf13/cobra"
 )
@@ -55,11 +57,83 @@ func (h *JWKHandler) CreateKeys(cmd *cobra.Command, args []string) {
 	}
 
 	alg, _ := cmd.Flags().GetString("alg")
-	keys, response, err := m.CreateJsonWebKeySet(args[0], hydra.JsonWebKeySetGeneratorRequest{Alg: alg, Kid: kid})
+	bits, _ := cmd.Flags().GetInt64("bits")
+	keys, response, err := m.CreateJsonWebKeySet(args[0], hydra.JsonWebKeySetGeneratorRequest{Alg: alg, Kid: kid, Bits: bits})
+	checkResponse(response, err, http.StatusCreated)
+	fmt.Printf("%s\n", formatResponse(keys))
+}
+
+func (h *JWKHandler) AppendKey(cmd *cobra.Command, args []string) {
+	m := h.newJwkManager(cmd)
+	if len(args) != 2 {
+		fmt.Println(cmd.UsageString())
+		return
+	}
+
+	alg, _ := cmd.Flags().GetString("alg")
+	bits, _ := cmd.Flags().GetInt64("bits")
+	keys, response, err := m.AppendJsonWebKey(args[1], args[0], hydra.JsonWebKeySetGeneratorRequest{Alg: alg, Bits: bits})
 	checkResponse(response, err, http.StatusCreated)
 	fmt.Printf("%s\n", formatResponse(keys))
 }
 
+func (h *JWKHandler) ImportKeys(cmd *cobra.Command, args []string) {
+	m := h.newJwkManager(cmd)
+	if file, _ := cmd.Flags().GetString("file"); file != "" {
+		h.importBackup(cmd, m, file)
+		return
+	}
+
+	if len(args) < 2 {
+		fmt.Println(cmd.UsageString())
+		return
+	}
+
+	for _, path := range args[1:] {
+		raw, err := ioutil.ReadFile(path)
+		pkg.Must(err, "Could not read file %s: %s", path, err)
+
+		keys, response, err := m.ImportJsonWebKeys(args[0], string(raw))
+		checkResponse(response, err, http.StatusCreated)
+		fmt.Printf("%s\n", formatResponse(keys))
+	}
+}
+
+func (h *JWKHandler) importBackup(cmd *cobra.Command, m *hydra.JsonWebKeyApi, file string) {
+	raw, err := ioutil.ReadFile(file)
+	pkg.Must(err, "Could not read file %s: %s", file, err)
+
+	var backup hydra.JsonWebKeyBackup
+	err = json.Unmarshal(raw, &backup)
+	pkg.Must(err, "Could not decode backup %s: %s", file, err)
+
+	passphrase, _ := cmd.Flags().GetString("passphrase")
+	summaries, response, err := m.ImportJsonWebKeySets(hydra.JsonWebKeyBackupImportRequest{Backup: backup, Passphrase: passphrase})
+	checkResponse(response, err, http.StatusCreated)
+	fmt.Printf("%s\n", formatResponse(summaries))
+}
+
+func (h *JWKHandler) ExportKeys(cmd *cobra.Command, args []string) {
+	m := h.newJwkManager(cmd)
+	if all, _ := cmd.Flags().GetBool("all"); !all || len(args) != 0 {
+		fmt.Println(cmd.UsageString())
+		return
+	}
+
+	passphrase, _ := cmd.Flags().GetString("passphrase")
+	backup, response, err := m.ExportJsonWebKeySets(hydra.JsonWebKeyBackupExportRequest{Passphrase: passphrase})
+	checkResponse(response, err, http.StatusOK)
+
+	if file, _ := cmd.Flags().GetString("file"); file != "" {
+		err = ioutil.WriteFile(file, []byte(formatResponse(backup)), 0600)
+		pkg.Must(err, "Could not write file %s: %s", file, err)
+		fmt.Printf("Exported all JSON Web Key Sets to %s.\n", file)
+		return
+	}
+
+	fmt.Printf("%s\n", formatResponse(backup))
+}
+
 func (h *JWKHandler) GetKeys(cmd *cobra.Command, args []string) {
 	m := h.newJwkManager(cmd)
 	if len(args) != 1 {
@@ -15,21 +15,30 @@
 package cli
 
 import (
+	"crypto/sha256"
 	"fmt"
 	"net/url"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/ory/hydra/client"
 	"github.com/ory/hydra/config"
+	"github.com/ory/hydra/events"
 	"github.com/ory/hydra/jwk"
 	"github.com/ory/hydra/oauth2"
 	"github.com/ory/hydra/pkg"
+	"github.com/ory/hydra/warden/decision"
+	"github.com/ory/hydra/warden/elevation"
 	"github.com/ory/hydra/warden/group"
-	ladon "github.com/ory/ladon/manager/sql"
+	"github.com/ory/hydra/warden/usage"
+	"github.com/ory/ladon"
+	lsql "github.com/ory/ladon/manager/sql"
 	"github.com/pkg/errors"
+	"github.com/rubenv/sql-migrate"
 	"github.com/spf13/cobra"
 )
 
@@ -43,6 +52,29 @@ func newMigrateHandler(c *config.Config) *MigrateHandler {
 
 type schemaCreator interface {
 	CreateSchemas() (int, error)
+	Migrations() *migrate.MemoryMigrationSource
+}
+
+func newSchemaCreators(db *sqlx.DB) map[string]schemaCreator {
+	return map[string]schemaCreator{
+		"client":              &client.SQLManager{DB: db},
+		"client_registration": &client.RegistrationTokenSQLManager{DB: db},
+		"oauth2":              &oauth2.FositeSQLStore{DB: db},
+		"token_history":       &oauth2.TokenHistorySQLManager{DB: db},
+		"jwk":                 &jwk.SQLManager{DB: db},
+		"group":               &group.SQLManager{DB: db},
+		"consent":             oauth2.NewConsentRequestSQLManager(db),
+		"consent_stats":       &oauth2.ConsentStatisticsSQLManager{DB: db},
+		"consent_grant":       &oauth2.ConsentGrantSQLManager{DB: db},
+		"consent_remembered":  &oauth2.RememberedConsentSQLManager{DB: db},
+		"login_session":       &oauth2.LoginSessionSQLManager{DB: db},
+		"login_request":       &oauth2.LoginRequestSQLManager{DB: db},
+		"pushed_request":      &oauth2.PushedAuthorizationRequestSQLManager{DB: db},
+		"events":              &events.SQLOutbox{DB: db},
+		"decision":            &decision.SQLManager{DB: db},
+		"elevation":           &elevation.SQLManager{DB: db},
+		"policy_usage":        &usage.SQLManager{DB: db},
+	}
 }
 
 func (h *MigrateHandler) connectToSql(dsn string) (*sqlx.DB, error) {
@@ -54,11 +86,15 @@ func (h *MigrateHandler) connectToSql(dsn string) (*sqlx.DB, error) {
 	}
 
 	if err := pkg.Retry(h.c.GetLogger(), time.Second*15, time.Minute*2, func() error {
+		driver := u.Scheme
 		if u.Scheme == "mysql" {
 			dsn = strings.Replace(dsn, "mysql://", "", -1)
+		} else if u.Scheme == "cockroach" {
+			driver = "postgres"
+			dsn = strings.Replace(dsn, "cockroach://", "postgres://", 1)
 		}
 
-		if db, err = sqlx.Open(u.Scheme, dsn); err != nil {
+		if db, err = sqlx.Open(driver, dsn); err != nil {
 			return errors.Errorf("Could not connect to SQL: %s", err)
 		} else if err := db.Ping(); err != nil {
 			return errors.Errorf("Could not connect to SQL: %s", err)
@@ -96,7 +132,7 @@ func (h *MigrateHandler) MigrateLadon050To060(cmd *cobra.Command, args []string)
 }
 
 func (h *MigrateHandler) runMigrateLadon050To060(db *sqlx.DB) error {
-	m := ladon.NewSQLManager(db, nil)
+	m := lsql.NewSQLManager(db, nil)
 	fmt.Printf("Applying `%s` SQL migrations.\n", "ladon")
 	if num, err := m.CreateSchemas("", "hydra_policy_migration"); err != nil {
 		return errors.Wrap(err, "Could not apply `ladon` SQL migrations")
@@ -105,7 +141,7 @@ func (h *MigrateHandler) runMigrateLadon050To060(db *sqlx.DB) error {
 	}
 
 	fmt.Println("Moving policies to new schema")
-	mm := ladon.SQLManagerMigrateFromMajor0Minor6ToMajor0Minor7{
+	mm := lsql.SQLManagerMigrateFromMajor0Minor6ToMajor0Minor7{
 		DB:         db,
 		SQLManager: m,
 	}
@@ -117,6 +153,11 @@ func (h *MigrateHandler) runMigrateLadon050To060(db *sqlx.DB) error {
 }
 
 func (h *MigrateHandler) MigrateSQL(cmd *cobra.Command, args []string) {
+	if plan, _ := cmd.Flags().GetBool("plan"); plan {
+		h.printMigrationPlan()
+		return
+	}
+
 	if len(args) == 0 {
 		fmt.Println(cmd.UsageString())
 		return
@@ -140,20 +181,14 @@ func (h *MigrateHandler) MigrateSQL(cmd *cobra.Command, args []string) {
 func (h *MigrateHandler) runMigrateSQL(db *sqlx.DB) error {
 	var total int
 	fmt.Printf("Applying `%s` SQL migrations...\n", "ladon")
-	if num, err := ladon.NewSQLManager(db, nil).CreateSchemas("", "hydra_policy_migration"); err != nil {
+	if num, err := lsql.NewSQLManager(db, nil).CreateSchemas("", "hydra_policy_migration"); err != nil {
 		return errors.Wrap(err, "Could not apply `ladon` SQL migrations")
 	} else {
 		fmt.Printf("Applied %d `%s` SQL migrations.\n", num, "ladon")
 		total += num
 	}
 
-	for k, m := range map[string]schemaCreator{
-		"client":  &client.SQLManager{DB: db},
-		"oauth2":  &oauth2.FositeSQLStore{DB: db},
-		"jwk":     &jwk.SQLManager{DB: db},
-		"group":   &group.SQLManager{DB: db},
-		"consent": oauth2.NewConsentRequestSQLManager(db),
-	} {
+	for k, m := range newSchemaCreators(db) {
 		fmt.Printf("Applying `%s` SQL migrations...\n", k)
 		if num, err := m.CreateSchemas(); err != nil {
 			return errors.Wrapf(err, "Could not apply `%s` SQL migrations", k)
@@ -166,3 +201,312 @@ func (h *MigrateHandler) runMigrateSQL(db *sqlx.DB) error {
 	fmt.Printf("Migration successful! Applied a total of %d SQL migrations.\n", total)
 	return nil
 }
+
+func (h *MigrateHandler) MigrateSecret(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		fmt.Println(cmd.UsageString())
+		return
+	}
+
+	current, next := h.c.SystemSecret, os.Getenv("NEW_SYSTEM_SECRET")
+	currentURL, nextURL := h.c.JWKCipherURL, os.Getenv("NEW_JWK_CIPHER_URL")
+	if next == "" {
+		next = current
+	}
+	if nextURL == "" {
+		nextURL = currentURL
+	}
+
+	if cipherUsesSecret(currentURL) && len(current) < 16 {
+		fmt.Println("SYSTEM_SECRET must be set to the current system secret and be at least 16 characters long.")
+		os.Exit(1)
+		return
+	} else if cipherUsesSecret(nextURL) && len(next) < 16 {
+		fmt.Println("NEW_SYSTEM_SECRET must be set to the new system secret and be at least 16 characters long.")
+		os.Exit(1)
+		return
+	}
+
+	currentDerivation, nextDerivation := h.c.SystemSecretDerivation, h.c.SystemSecretDerivation
+	if v := os.Getenv("NEW_SYSTEM_SECRET_DERIVATION"); v != "" {
+		var err error
+		if nextDerivation, err = strconv.Atoi(v); err != nil {
+			fmt.Printf("NEW_SYSTEM_SECRET_DERIVATION must be a number: %s", err)
+			os.Exit(1)
+			return
+		}
+	}
+
+	currentSystemKey, nextSystemKey := sha256.Sum256([]byte(current)), sha256.Sum256([]byte(next))
+	currentKey, err := pkg.DeriveSecret(currentSystemKey[:], currentDerivation, pkg.SecretPurposeJWK)
+	if err != nil {
+		fmt.Printf("An error occurred while deriving the current key: %s", err)
+		os.Exit(1)
+		return
+	}
+
+	nextKey, err := pkg.DeriveSecret(nextSystemKey[:], nextDerivation, pkg.SecretPurposeJWK)
+	if err != nil {
+		fmt.Printf("An error occurred while deriving the new key: %s", err)
+		os.Exit(1)
+		return
+	}
+
+	currentCipher, err := jwk.NewCipher(currentURL, currentKey)
+	if err != nil {
+		fmt.Printf("An error occurred while creating the current cipher: %s", err)
+		os.Exit(1)
+		return
+	}
+
+	nextCipher, err := jwk.NewCipher(nextURL, nextKey)
+	if err != nil {
+		fmt.Printf("An error occurred while creating the new cipher: %s", err)
+		os.Exit(1)
+		return
+	}
+
+	db, err := h.connectToSql(args[0])
+	if err != nil {
+		fmt.Printf("An error occurred while connecting to SQL: %s", err)
+		os.Exit(1)
+		return
+	}
+
+	m := &jwk.SQLManager{DB: db, Cipher: currentCipher}
+	n, err := m.RotateCipher(nextCipher)
+	if err != nil {
+		fmt.Printf("An error occurred while re-encrypting the JSON Web Keys: %s", err)
+		os.Exit(1)
+		return
+	}
+
+	fmt.Printf("Re-encrypted %d JSON Web Keys, restart Hydra with SYSTEM_SECRET, SYSTEM_SECRET_DERIVATION and JWK_CIPHER_URL set to the new values.\n", n)
+}
+
+func (h *MigrateHandler) MigrateResourcePrefix(cmd *cobra.Command, args []string) {
+	from, _ := cmd.Flags().GetString("from")
+	to, _ := cmd.Flags().GetString("to")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	if to == "" {
+		to = h.c.AccessControlResourcePrefix
+	}
+
+	if len(args) != 1 || from == "" || to == "" {
+		fmt.Println(cmd.UsageString())
+		return
+	}
+
+	db, err := h.connectToSql(args[0])
+	if err != nil {
+		fmt.Printf("An error occurred while connecting to SQL: %s", err)
+		os.Exit(1)
+		return
+	}
+
+	n, err := h.runMigrateResourcePrefix(lsql.NewSQLManager(db, nil), from, to, dryRun)
+	if err != nil {
+		fmt.Printf("An error occurred while rewriting policy resources: %s", err)
+		os.Exit(1)
+		return
+	}
+
+	if dryRun {
+		fmt.Printf("Dry run, %d policies would be updated. Run the command without --dry-run to apply the changes.\n", n)
+		return
+	}
+	fmt.Printf("Updated %d policies, restart Hydra with RESOURCE_NAME_PREFIX=%s.\n", n, to)
+}
+
+func (h *MigrateHandler) VerifyIndexes(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		fmt.Println(cmd.UsageString())
+		return
+	}
+
+	db, err := h.connectToSql(args[0])
+	if err != nil {
+		fmt.Printf("An error occurred while connecting to SQL: %s", err)
+		os.Exit(1)
+		return
+	}
+
+	missing, err := h.runVerifyIndexes(db)
+	if err != nil {
+		fmt.Printf("An error occurred while checking the indexes: %s", err)
+		os.Exit(1)
+		return
+	} else if missing > 0 {
+		os.Exit(1)
+		return
+	}
+}
+
+func (h *MigrateHandler) VerifyClientSecrets(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		fmt.Println(cmd.UsageString())
+		return
+	}
+
+	db, err := h.connectToSql(args[0])
+	if err != nil {
+		fmt.Printf("An error occurred while connecting to SQL: %s", err)
+		os.Exit(1)
+		return
+	}
+
+	weak, err := h.runVerifyClientSecrets(&client.SQLManager{DB: db}, h.c.GetClientSecretHasher())
+	if err != nil {
+		fmt.Printf("An error occurred while checking the client secrets: %s", err)
+		os.Exit(1)
+		return
+	} else if weak > 0 {
+		os.Exit(1)
+		return
+	}
+}
+
+// runVerifyClientSecrets prints the clients whose secrets need to be rehashed and returns their number.
+func (h *MigrateHandler) runVerifyClientSecrets(m client.Storage, hasher *client.SecretHasher) (int, error) {
+	var weak []string
+	for offset := 0; ; offset += 500 {
+		clients, err := m.GetClients(500, offset)
+		if err != nil {
+			return 0, err
+		}
+
+		for id, c := range clients {
+			if hasher.NeedsRehash(c.GetHashedSecret()) {
+				weak = append(weak, fmt.Sprintf("%s\t%s", id, client.DescribeSecretHash(c.GetHashedSecret())))
+			}
+		}
+
+		if len(clients) < 500 {
+			break
+		}
+	}
+
+	if len(weak) == 0 {
+		fmt.Println("All client secrets are hashed using the configured algorithm and parameters.")
+		return 0, nil
+	}
+
+	sort.Strings(weak)
+	fmt.Printf("The secrets of %d clients are hashed using another algorithm or weaker parameters:\n\n", len(weak))
+	for _, line := range weak {
+		fmt.Println(line)
+	}
+	return len(weak), nil
+}
+
+// runVerifyIndexes prints a CREATE INDEX statement for every missing index and returns the number of missing indexes.
+func (h *MigrateHandler) runVerifyIndexes(db *sqlx.DB) (int, error) {
+	dialect, err := pkg.SQLDialect(db)
+	if err != nil {
+		return 0, err
+	}
+
+	missing, err := pkg.MissingSQLIndexes(db, config.SQLIndexes())
+	if err != nil {
+		return 0, err
+	}
+
+	if len(missing) == 0 {
+		fmt.Println("All indexes exist.")
+		return 0, nil
+	}
+
+	fmt.Printf("%d indexes are missing, create them by running:\n\n", len(missing))
+	for _, index := range missing {
+		fmt.Printf("-- %s\n%s;\n", index.Query, index.CreateStatement(dialect))
+	}
+	return len(missing), nil
+}
+
+// runMigrateResourcePrefix replaces the resource name prefix from with to in all policies and prints the changes.
+// Policies are not updated if dryRun is true. It returns the number of policies which were, or would be, updated.
+func (h *MigrateHandler) runMigrateResourcePrefix(m ladon.Manager, from, to string, dryRun bool) (int, error) {
+	from, to = strings.TrimRight(from, ":"), strings.TrimRight(to, ":")
+
+	var policies ladon.Policies
+	for offset, limit := int64(0), int64(500); ; offset += limit {
+		page, err := m.GetAll(limit, offset)
+		if err != nil {
+			return 0, errors.WithStack(err)
+		}
+
+		policies = append(policies, page...)
+		if int64(len(page)) < limit {
+			break
+		}
+	}
+
+	var updated int
+	for _, p := range policies {
+		resources, changed := rewriteResourcePrefix(p.GetResources(), from, to)
+		if !changed {
+			continue
+		}
+
+		fmt.Printf("Policy %s:\n", p.GetID())
+		for k, resource := range p.GetResources() {
+			if resource != resources[k] {
+				fmt.Printf("  - %s\n  + %s\n", resource, resources[k])
+			}
+		}
+
+		if !dryRun {
+			policy, ok := p.(*ladon.DefaultPolicy)
+			if !ok {
+				return updated, errors.Errorf("Policy %s has unexpected type %T", p.GetID(), p)
+			}
+
+			policy.Resources = resources
+			if err := m.Update(policy); err != nil {
+				return updated, errors.Wrapf(err, "Could not update policy %s", p.GetID())
+			}
+		}
+		updated++
+	}
+
+	return updated, nil
+}
+
+// rewriteResourcePrefix replaces prefix from with to in all resources which equal from or start with from followed
+// by a colon. It returns true if any resource was changed.
+func rewriteResourcePrefix(resources []string, from, to string) ([]string, bool) {
+	var changed bool
+	rewritten := make([]string, len(resources))
+	for k, resource := range resources {
+		rewritten[k] = resource
+		if resource == from || strings.HasPrefix(resource, from+":") {
+			rewritten[k] = to + strings.TrimPrefix(resource, from)
+			changed = true
+		}
+	}
+	return rewritten, changed
+}
+
+func cipherUsesSecret(rawurl string) bool {
+	return rawurl == "" || strings.HasPrefix(rawurl, "aes:")
+}
+
+func (h *MigrateHandler) printMigrationPlan() {
+	creators := newSchemaCreators(nil)
+
+	var names []string
+	for k := range creators {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	fmt.Println("-- The `ladon` SQL migrations are embedded in github.com/ory/ladon and are not listed here.")
+	for _, name := range names {
+		for _, m := range creators[name].Migrations().Migrations {
+			fmt.Printf("\n-- Migration `%s` of `%s`\n", m.Id, name)
+			for _, statement := range m.Up {
+				fmt.Printf("%s\n", strings.TrimSpace(statement))
+			}
+		}
+	}
+}
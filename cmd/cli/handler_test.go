@@ -23,6 +23,7 @@ import (
 	"github.com/ory/hydra/config"
 	"github.com/ory/hydra/integration"
 	"github.com/ory/ladon"
+	"github.com/ory/ladon/manager/memory"
 	lsql "github.com/ory/ladon/manager/sql"
 	"github.com/pborman/uuid"
 	"github.com/stretchr/testify/assert"
@@ -86,3 +87,33 @@ func TestMigrateHandlerSQL(t *testing.T) {
 
 	assert.NoError(t, handler.runMigrateLadon050To060(db))
 }
+
+func TestRewriteResourcePrefix(t *testing.T) {
+	resources, changed := rewriteResourcePrefix([]string{"rn:hydra", "rn:hydra:clients", "rn:hydra:<.*>", "rn:hydrax:clients", "<rn:hydra|rn:other>"}, "rn:hydra", "rn:myapp")
+	assert.True(t, changed)
+	assert.Equal(t, []string{"rn:myapp", "rn:myapp:clients", "rn:myapp:<.*>", "rn:hydrax:clients", "<rn:hydra|rn:other>"}, resources)
+
+	_, changed = rewriteResourcePrefix([]string{"article"}, "rn:hydra", "rn:myapp")
+	assert.False(t, changed)
+}
+
+func TestMigrateHandlerResourcePrefix(t *testing.T) {
+	handler := newMigrateHandler(&config.Config{})
+	m := memory.NewMemoryManager()
+	require.NoError(t, m.Create(&ladon.DefaultPolicy{ID: "1", Resources: []string{"rn:hydra:clients", "article"}, Effect: ladon.AllowAccess}))
+	require.NoError(t, m.Create(&ladon.DefaultPolicy{ID: "2", Resources: []string{"article"}, Effect: ladon.AllowAccess}))
+
+	n, err := handler.runMigrateResourcePrefix(m, "rn:hydra:", "rn:myapp", true)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	p, err := m.Get("1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"rn:hydra:clients", "article"}, p.GetResources())
+
+	n, err = handler.runMigrateResourcePrefix(m, "rn:hydra", "rn:myapp", false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	p, err = m.Get("1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"rn:myapp:clients", "article"}, p.GetResources())
+}
@@ -69,6 +69,30 @@ func (h *TokenHandler) RevokeToken(cmd *cobra.Command, args []string) {
 	fmt.Printf("Revoked token %s", token)
 }
 
+func (h *TokenHandler) RevokeClientTokens(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		fmt.Print(cmd.UsageString())
+		return
+	}
+
+	handler := h.newTokenManager(cmd)
+	response, err := handler.RevokeOAuth2Tokens(args[0], "")
+	checkResponse(response, err, http.StatusNoContent)
+	fmt.Printf("Revoked all tokens issued to client %s\n", args[0])
+}
+
+func (h *TokenHandler) RevokeSubjectTokens(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		fmt.Print(cmd.UsageString())
+		return
+	}
+
+	handler := h.newTokenManager(cmd)
+	response, err := handler.RevokeOAuth2Tokens("", args[0])
+	checkResponse(response, err, http.StatusNoContent)
+	fmt.Printf("Revoked all tokens issued on behalf of subject %s\n", args[0])
+}
+
 func (h *TokenHandler) FlushTokens(cmd *cobra.Command, args []string) {
 	handler := h.newTokenManager(cmd)
 
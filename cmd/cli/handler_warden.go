@@ -0,0 +1,224 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	hydra "github.com/ory/hydra/sdk/go/hydra/swagger"
+	"github.com/ory/ladon"
+	"github.com/spf13/cobra"
+)
+
+// policyCollector implements ladon.AuditLogger in order to find out which policies led to a decision.
+type policyCollector struct {
+	deciders ladon.Policies
+}
+
+func (c *policyCollector) LogRejectedAccessRequest(request *ladon.Request, pool ladon.Policies, deciders ladon.Policies) {
+	c.deciders = deciders
+}
+
+func (c *policyCollector) LogGrantedAccessRequest(request *ladon.Request, pool ladon.Policies, deciders ladon.Policies) {
+	c.deciders = deciders
+}
+
+func (h *IntrospectionHandler) newWardenApi(cmd *cobra.Command) *hydra.WardenApi {
+	c := hydra.NewWardenApiWithBasePath(h.Config.GetClusterURLWithoutTailingSlash())
+	c.Configuration.Transport = h.Config.OAuth2Client(cmd).Transport
+	if term, _ := cmd.Flags().GetBool("fake-tls-termination"); term {
+		c.Configuration.DefaultHeader["X-Forwarded-Proto"] = "https"
+	}
+	return c
+}
+
+func (h *IntrospectionHandler) newPolicyApi(cmd *cobra.Command) *hydra.PolicyApi {
+	c := hydra.NewPolicyApiWithBasePath(h.Config.GetClusterURLWithoutTailingSlash())
+	c.Configuration.Transport = h.Config.OAuth2Client(cmd).Transport
+	if term, _ := cmd.Flags().GetBool("fake-tls-termination"); term {
+		c.Configuration.DefaultHeader["X-Forwarded-Proto"] = "https"
+	}
+	return c
+}
+
+func (h *IntrospectionHandler) CheckAccess(cmd *cobra.Command, args []string) {
+	subject, _ := cmd.Flags().GetString("subject")
+	token, _ := cmd.Flags().GetString("token")
+	resource, _ := cmd.Flags().GetString("resource")
+	action, _ := cmd.Flags().GetString("action")
+	scopes, _ := cmd.Flags().GetStringSlice("scopes")
+	pairs, _ := cmd.Flags().GetStringSlice("context")
+	explain, _ := cmd.Flags().GetBool("explain")
+
+	if (subject == "") == (token == "") || resource == "" || action == "" {
+		fmt.Print(cmd.UsageString())
+		return
+	}
+
+	context, err := parseAccessContext(pairs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+		return
+	}
+
+	m := h.newWardenApi(cmd)
+	var allowed bool
+	if token != "" {
+		o := hydra.NewOAuth2ApiWithBasePath(h.Config.GetClusterURLWithoutTailingSlash())
+		o.Configuration.Transport = m.Configuration.Transport
+		o.Configuration.DefaultHeader = m.Configuration.DefaultHeader
+
+		introspection, response, err := o.IntrospectOAuth2Token(token, "")
+		checkResponse(response, err, http.StatusOK)
+		if !introspection.Active {
+			fmt.Println("Allowed: false")
+			fmt.Println("The token is not active.")
+			os.Exit(1)
+			return
+		}
+
+		result, response, err := m.DoesWardenAllowTokenAccessRequest(hydra.WardenTokenAccessRequest{
+			Token:    token,
+			Resource: resource,
+			Action:   action,
+			Scopes:   scopes,
+			Context:  context,
+		})
+		checkResponse(response, err, http.StatusOK)
+
+		allowed, subject = result.Allowed, introspection.Sub
+		fmt.Printf("Allowed: %t\n", allowed)
+		fmt.Printf("Subject: %s\n", subject)
+		fmt.Printf("Client: %s\n", introspection.ClientId)
+		fmt.Printf("Granted scopes: %s\n", introspection.Scope)
+	} else {
+		result, response, err := m.DoesWardenAllowAccessRequest(hydra.WardenAccessRequest{
+			Subject:  subject,
+			Resource: resource,
+			Action:   action,
+			Context:  context,
+		})
+		checkResponse(response, err, http.StatusOK)
+
+		allowed = result.Allowed
+		fmt.Printf("Allowed: %t\n", allowed)
+		fmt.Printf("Subject: %s\n", subject)
+	}
+
+	if explain && subject != "" {
+		h.explainAccess(cmd, m, &ladon.Request{Subject: subject, Resource: resource, Action: action, Context: context})
+	}
+
+	if !allowed {
+		os.Exit(1)
+	}
+}
+
+// explainAccess prints the policies which allow or deny the request for the subject and each group it belongs to.
+// The policies are evaluated locally, so decisions made by elevations, peers or a remote authorizer are not explained.
+func (h *IntrospectionHandler) explainAccess(cmd *cobra.Command, m *hydra.WardenApi, r *ladon.Request) {
+	policies, err := h.listPolicies(cmd)
+	if err != nil {
+		fmt.Printf("Could not list policies to explain the decision: %s\n", err)
+		return
+	}
+
+	subjects := []string{r.Subject}
+	groups, response, err := m.ListGroups(r.Subject, 500, 0)
+	if err != nil || response.StatusCode != http.StatusOK {
+		fmt.Println("Could not list the groups of the subject, only policies of the subject itself are shown.")
+	} else {
+		for _, g := range groups {
+			subjects = append(subjects, g.Id)
+		}
+	}
+
+	var lines []string
+	for _, subject := range subjects {
+		collector := new(policyCollector)
+		l := &ladon.Ladon{AuditLogger: collector}
+		_ = l.DoPoliciesAllow(&ladon.Request{Subject: subject, Resource: r.Resource, Action: r.Action, Context: r.Context}, policies)
+
+		for _, p := range collector.deciders {
+			lines = append(lines, fmt.Sprintf("\t%s\t%s\t(subject %s)", p.GetEffect(), p.GetID(), subject))
+		}
+	}
+
+	if len(lines) == 0 {
+		fmt.Println("No policy matches the request, access is denied by default.")
+		return
+	}
+
+	fmt.Println("Matching policies:")
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+}
+
+func (h *IntrospectionHandler) listPolicies(cmd *cobra.Command) (ladon.Policies, error) {
+	m := h.newPolicyApi(cmd)
+
+	var policies ladon.Policies
+	for offset := int64(0); ; offset += 500 {
+		page, response, err := m.ListPolicies(offset, 500)
+		if err != nil {
+			return nil, err
+		} else if response.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("status code %d was received", response.StatusCode)
+		}
+
+		for _, p := range page {
+			out, err := json.Marshal(p)
+			if err != nil {
+				return nil, err
+			}
+
+			var policy ladon.DefaultPolicy
+			if err := json.Unmarshal(out, &policy); err != nil {
+				return nil, err
+			}
+			policies = append(policies, &policy)
+		}
+
+		if len(page) < 500 {
+			break
+		}
+	}
+	return policies, nil
+}
+
+// parseAccessContext parses key=value pairs into an access request context. Values which are valid JSON are decoded,
+// other values are used as strings.
+func parseAccessContext(pairs []string) (ladon.Context, error) {
+	context := ladon.Context{}
+	for _, pair := range pairs {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf(`Context "%s" must be formatted as key=value`, pair)
+		}
+
+		var value interface{}
+		if err := json.Unmarshal([]byte(kv[1]), &value); err != nil {
+			value = kv[1]
+		}
+		context[kv[0]] = value
+	}
+	return context, nil
+}
@@ -37,7 +37,28 @@ func init() {
 	clientsCreateCmd.Flags().StringSliceP("grant-types", "g", []string{"authorization_code"}, "A list of allowed grant types")
 	clientsCreateCmd.Flags().StringSliceP("response-types", "r", []string{"code"}, "A list of allowed response types")
 	clientsCreateCmd.Flags().StringSliceP("allowed-scopes", "a", []string{""}, "A list of allowed scopes")
+	clientsCreateCmd.Flags().StringSlice("audience", []string{}, "A list of audiences the client may request access tokens for")
+	clientsCreateCmd.Flags().StringSlice("resource-server-audience", []string{}, "A list of audiences the client serves as a resource server. Resource servers may only introspect access tokens issued for these audiences")
+	clientsCreateCmd.Flags().StringSlice("allowed-cors-origins", []string{}, "A list of origins the client's single-page app may call the token, revocation and userinfo endpoints from")
 	clientsCreateCmd.Flags().Bool("is-public", false, "Use this flag to create a public client")
+	clientsCreateCmd.Flags().Bool("skip-consent", false, "Use this flag to grant all requested scopes to a trusted client without showing a consent screen")
+	clientsCreateCmd.Flags().Bool("require-pkce", false, "Use this flag to force the client to use PKCE with the S256 code challenge method")
+	clientsCreateCmd.Flags().Bool("require-pushed-authorization-requests", false, "Use this flag to force the client to push its authorization requests to /oauth2/par")
 	clientsCreateCmd.Flags().String("secret", "", "Provide the client's secret")
 	clientsCreateCmd.Flags().StringP("name", "n", "", "The client's name")
+	clientsCreateCmd.Flags().String("token-endpoint-auth-method", "", "Set to tls_client_auth or self_signed_tls_client_auth to authenticate the client using a TLS client certificate")
+	clientsCreateCmd.Flags().String("tls-client-auth-certificate", "", "Path to the PEM encoded, self-signed certificate used for self_signed_tls_client_auth")
+	clientsCreateCmd.Flags().String("tls-client-auth-public-key-sha256", "", "The base64url encoded SHA-256 hash of the certificate's public key, can be used instead of --tls-client-auth-certificate")
+	clientsCreateCmd.Flags().String("tls-client-auth-subject-dn", "", "The subject distinguished name of the certificate used for tls_client_auth, for example \"CN=client,O=Example\"")
+	clientsCreateCmd.Flags().String("tls-client-auth-san-dns", "", "The DNS name subject alternative name of the certificate used for tls_client_auth")
+	clientsCreateCmd.Flags().String("tls-client-auth-san-uri", "", "The URI subject alternative name of the certificate used for tls_client_auth")
+	clientsCreateCmd.Flags().String("tls-client-auth-san-ip", "", "The IP address subject alternative name of the certificate used for tls_client_auth")
+	clientsCreateCmd.Flags().String("tls-client-auth-san-email", "", "The email subject alternative name of the certificate used for tls_client_auth")
+	clientsCreateCmd.Flags().String("jwks", "", "Path to the JSON Web Key Set containing the public keys ID tokens and userinfo responses are encrypted to")
+	clientsCreateCmd.Flags().String("jwks-uri", "", "The URL of the JSON Web Key Set containing the public keys ID tokens and userinfo responses are encrypted to")
+	clientsCreateCmd.Flags().String("id-token-encrypted-response-alg", "", "Encrypt ID tokens using this key encryption algorithm, for example RSA-OAEP-256")
+	clientsCreateCmd.Flags().String("id-token-encrypted-response-enc", "", "Encrypt ID tokens using this content encryption algorithm, defaults to A128CBC-HS256")
+	clientsCreateCmd.Flags().String("userinfo-signed-response-alg", "", "Sign userinfo responses using the OpenID Connect key, must match its algorithm, for example RS256")
+	clientsCreateCmd.Flags().String("userinfo-encrypted-response-alg", "", "Encrypt userinfo responses using this key encryption algorithm, for example RSA-OAEP-256")
+	clientsCreateCmd.Flags().String("userinfo-encrypted-response-enc", "", "Encrypt userinfo responses using this content encryption algorithm, defaults to A128CBC-HS256")
 }
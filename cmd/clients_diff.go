@@ -0,0 +1,39 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var clientsDiffCmd = &cobra.Command{
+	Use:   "diff <path/to/manifest>",
+	Short: "Show how the OAuth2 clients differ from a manifest",
+	Long: `This command compares the OAuth2 clients registered at the cluster with a manifest directory and prints the
+clients that would be created, updated or deleted to make the cluster match it. Nothing is changed.
+
+Every *.json file in the manifest directory contains one client in the format accepted by "hydra clients import"
+and must set the client's id. Client secrets and the client status are not compared.
+
+Example:
+  hydra clients diff ./clients --detailed-exitcode
+`,
+	Run: cmdHandler.Clients.DiffClients,
+}
+
+func init() {
+	clientsCmd.AddCommand(clientsDiffCmd)
+	clientsDiffCmd.Flags().Bool("detailed-exitcode", false, "Exit with code 2 if the clients differ from the manifest")
+}
@@ -0,0 +1,47 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/ory/hydra/oauth2"
+	"github.com/spf13/cobra"
+)
+
+// janitorCmd represents the janitor command
+var janitorCmd = &cobra.Command{
+	Use:   "janitor <database-url>",
+	Short: "Removes expired tokens, authorize codes and consent requests from the SQL database",
+	Long: `Removes expired access tokens, authorize codes, OpenID Connect sessions, consent requests, login requests and
+pushed authorization requests from the SQL database. Records are removed in batches of --batch-size, so that the tables
+are not locked for long, and at most --limit records are removed from each table per run. Run this command periodically,
+for example as a cron job, to keep the size of the database under control.
+
+Access tokens and authorize codes expire after ACCESS_TOKEN_LIFESPAN and AUTH_CODE_LIFESPAN, which must be set to the
+values Hydra runs with. Refresh tokens do not expire and are kept until they are used or revoked. Authorize codes are
+deleted when they are exchanged, so only codes which were never used are removed. Expired consent requests the user
+never answered are reported as abandoned. Warden decisions are removed once WARDEN_DECISION_LOG_RETENTION passed,
+unless it is set to "0".
+
+Example:
+	hydra janitor --limit 100000 postgres://...
+`,
+	Run: cmdHandler.Janitor.PurgeExpired,
+}
+
+func init() {
+	RootCmd.AddCommand(janitorCmd)
+	janitorCmd.Flags().Int("batch-size", oauth2.DefaultJanitorBatchSize, "The number of records removed per statement")
+	janitorCmd.Flags().Int("limit", 0, "The maximum number of records removed from each table, unlimited if 0")
+}
@@ -0,0 +1,37 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// keysAppendCmd represents the append command
+var keysAppendCmd = &cobra.Command{
+	Use:   "append <set> <key>",
+	Short: "Generate a new JSON Web Key and add it to an existing set",
+	Long: `Generates a new JSON Web Key and adds it to an existing JSON Web Key Set without changing the other keys of
+that set. Only the generated keys are printed. This is useful for rotating keys.
+
+Example:
+  hydra keys append my-set 2018-01-rotation --alg ES256`,
+	Run: cmdHandler.Keys.AppendKey,
+}
+
+func init() {
+	keysCmd.AddCommand(keysAppendCmd)
+	keysAppendCmd.Flags().StringP("alg", "a", "", "REQUIRED name that identifies the algorithm intended for use with the key. Supports: RS256, ES256, ES384, ES512, EdDSA, HS256, HS512")
+	keysAppendCmd.Flags().Int64P("bits", "b", 0, "The size of the key in bits, only supported by RS256. Must be at least 2048, defaults to 4096")
+}
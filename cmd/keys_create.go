@@ -41,6 +41,7 @@ var keysCreateCmd = &cobra.Command{
 
 func init() {
 	keysCmd.AddCommand(keysCreateCmd)
-	keysCreateCmd.Flags().StringP("alg", "a", "", "REQUIRED name that identifies the algorithm intended for use with the key. Supports: RS256, ES512, HS256")
+	keysCreateCmd.Flags().StringP("alg", "a", "", "REQUIRED name that identifies the algorithm intended for use with the key. Supports: RS256, ES256, ES384, ES512, EdDSA, HS256, HS512")
+	keysCreateCmd.Flags().Int64P("bits", "b", 0, "The size of the key in bits, only supported by RS256. Must be at least 2048, defaults to 4096")
 
 }
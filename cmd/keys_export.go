@@ -0,0 +1,39 @@
+// Copyright © 2016 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// keysExportCmd represents the export command
+var keysExportCmd = &cobra.Command{
+	Use:   "export --all",
+	Short: "Export all JSON Web Key Sets encrypted with a passphrase",
+	Long: `Exports all JSON Web Key Sets, including private keys and the lifetime of every key, as a backup which is
+encrypted with the given passphrase. The backup can be restored using "hydra keys import --file". It does not depend
+on the system secret and can therefore be restored on a different installation.
+
+Example:
+  hydra keys export --all --passphrase "$BACKUP_PASSPHRASE" --file backup.json`,
+	Run: cmdHandler.Keys.ExportKeys,
+}
+
+func init() {
+	keysCmd.AddCommand(keysExportCmd)
+	keysExportCmd.Flags().Bool("all", false, "REQUIRED export all JSON Web Key Sets")
+	keysExportCmd.Flags().String("passphrase", "", "REQUIRED the passphrase used to encrypt the backup, must be at least 8 characters long")
+	keysExportCmd.Flags().StringP("file", "f", "", "Write the backup to this file instead of stdout")
+}
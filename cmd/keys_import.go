@@ -0,0 +1,42 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// keysImportCmd represents the import command
+var keysImportCmd = &cobra.Command{
+	Use:   "import (<set> <file> [<file>...] | --file <backup>)",
+	Short: "Import PEM or DER encoded keys into a JSON Web Key Set",
+	Long: `Imports existing RSA and ECDSA keys into a JSON Web Key Set. Each file is either a PEM document, which may
+contain several blocks, or a single DER encoded key. Supported are PKCS#1, PKCS#8 and SEC 1 private keys, PKIX and
+PKCS#1 public keys, and X.509 certificates. The key id is computed from the SHA-256 thumbprint of the public key.
+
+If --file is given, all JSON Web Key Sets of a backup created by "hydra keys export --all" are restored instead.
+Nothing is imported if one of the keys of the backup already exists.
+
+Examples:
+  hydra keys import my-set private.pem cert.pem
+  hydra keys import --file backup.json --passphrase "$BACKUP_PASSPHRASE"`,
+	Run: cmdHandler.Keys.ImportKeys,
+}
+
+func init() {
+	keysCmd.AddCommand(keysImportCmd)
+	keysImportCmd.Flags().StringP("file", "f", "", "Restore the backup in this file instead of importing PEM or DER encoded keys")
+	keysImportCmd.Flags().String("passphrase", "", "The passphrase the backup was encrypted with")
+}
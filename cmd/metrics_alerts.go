@@ -0,0 +1,47 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ory/hydra/metrics"
+	"github.com/spf13/cobra"
+)
+
+// metricsAlertsCmd represents the alerts command
+var metricsAlertsCmd = &cobra.Command{
+	Use:   "alerts",
+	Short: "Print Prometheus alerting rules for request errors and duration",
+	Long: `Prints Prometheus alerting rules as YAML which fire if the share of requests to an endpoint failing with a 5xx
+status code, or the 99th percentile of the request duration of an endpoint, exceeds a threshold for ten minutes.
+
+Example:
+  hydra metrics alerts --error-ratio 0.01 --latency 500ms > hydra-alerts.yml
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		errorRatio, _ := cmd.Flags().GetFloat64("error-ratio")
+		latency, _ := cmd.Flags().GetDuration("latency")
+		fmt.Print(string(metrics.AlertingRules(errorRatio, latency.Seconds())))
+	},
+}
+
+func init() {
+	metricsCmd.AddCommand(metricsAlertsCmd)
+
+	metricsAlertsCmd.Flags().Float64("error-ratio", 0.05, "Share of failed requests, between 0 and 1, above which an alert fires")
+	metricsAlertsCmd.Flags().Duration("latency", time.Second, "99th percentile of the request duration above which an alert fires")
+}
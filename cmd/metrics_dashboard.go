@@ -0,0 +1,44 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/ory/hydra/metrics"
+	"github.com/ory/hydra/pkg"
+	"github.com/spf13/cobra"
+)
+
+// metricsDashboardCmd represents the dashboard command
+var metricsDashboardCmd = &cobra.Command{
+	Use:   "dashboard",
+	Short: "Print a Grafana dashboard showing request rate, errors and duration per endpoint",
+	Long: `Prints a Grafana dashboard as JSON which shows the request rate, error ratio and request duration per endpoint,
+based on the metrics exposed at /health/metrics. The dashboard expects a Prometheus data source.
+
+Example:
+  hydra metrics dashboard > hydra-dashboard.json
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		out, err := metrics.GrafanaDashboard()
+		pkg.Must(err, "Could not create dashboard: %s", err)
+		fmt.Println(string(out))
+	},
+}
+
+func init() {
+	metricsCmd.AddCommand(metricsDashboardCmd)
+}
@@ -0,0 +1,47 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import "github.com/spf13/cobra"
+
+// migrateResourcePrefixCmd represents the resource-prefix command
+var migrateResourcePrefixCmd = &cobra.Command{
+	Use:   "resource-prefix <database-url>",
+	Short: "Rewrites the resource names of all policies stored in SQL to a new resource name prefix",
+	Long: `Hydra prefixes the resource names it checks access to with RESOURCE_NAME_PREFIX, which defaults to rn:hydra.
+When the prefix is changed, policies referring to resource names with the previous prefix stop matching. This command
+replaces the prefix given by --from with the one given by --to in the resources of all policies. Resources are
+rewritten if they equal the previous prefix or start with it followed by a colon, so "rn:hydra:clients" and
+"rn:hydra:<.*>" are rewritten but "<rn:hydra|rn:other>:clients" is not and must be updated by hand.
+
+If --to is not set, RESOURCE_NAME_PREFIX is used. Use --dry-run to print the changes without applying them. Once the
+command succeeded, restart Hydra with RESOURCE_NAME_PREFIX set to the new prefix.
+
+Example:
+	hydra migrate resource-prefix --from rn:hydra --to rn:myapp --dry-run postgres://...
+
+### WARNING ###
+
+Before running this command on an existing database, create a back up!
+`,
+	Run: cmdHandler.Migration.MigrateResourcePrefix,
+}
+
+func init() {
+	migrateCmd.AddCommand(migrateResourcePrefixCmd)
+	migrateResourcePrefixCmd.Flags().String("from", "rn:hydra", "The resource name prefix the policies currently use")
+	migrateResourcePrefixCmd.Flags().String("to", "", "The new resource name prefix, defaults to RESOURCE_NAME_PREFIX")
+	migrateResourcePrefixCmd.Flags().Bool("dry-run", false, "Print the changes without applying them")
+}
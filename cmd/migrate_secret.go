@@ -0,0 +1,47 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import "github.com/spf13/cobra"
+
+// migrateSecretCmd represents the secret command
+var migrateSecretCmd = &cobra.Command{
+	Use:   "secret <database-url>",
+	Short: "Re-encrypts all JSON Web Keys stored in SQL with a new system secret or cipher",
+	Long: `Hydra encrypts JSON Web Keys, such as the OpenID Connect ID Token signing key, with the system secret or
+the key management service configured in JWK_CIPHER_URL. This command re-encrypts all stored JSON Web Keys so that the
+system secret or cipher can be rotated without losing them.
+
+The current secret is read from SYSTEM_SECRET and the new secret from NEW_SYSTEM_SECRET. The current cipher is read
+from JWK_CIPHER_URL and the new cipher from NEW_JWK_CIPHER_URL, see "hydra help host" for supported values. Use
+NEW_JWK_CIPHER_URL=aes:// to move keys from a key management service back to the system secret. The current key
+derivation is read from SYSTEM_SECRET_DERIVATION and the new one from NEW_SYSTEM_SECRET_DERIVATION. Once the
+command succeeded, restart Hydra with SYSTEM_SECRET, SYSTEM_SECRET_DERIVATION and JWK_CIPHER_URL set to the new values.
+
+Example:
+	SYSTEM_SECRET=<current-secret> NEW_SYSTEM_SECRET=<new-secret> hydra migrate secret postgres://...
+	SYSTEM_SECRET=<current-secret> NEW_JWK_CIPHER_URL=awskms:///alias/hydra hydra migrate secret postgres://...
+	SYSTEM_SECRET=<current-secret> NEW_SYSTEM_SECRET_DERIVATION=1 hydra migrate secret postgres://...
+
+### WARNING ###
+
+Before running this command on an existing database, create a back up!
+`,
+	Run: cmdHandler.Migration.MigrateSecret,
+}
+
+func init() {
+	migrateCmd.AddCommand(migrateSecretCmd)
+}
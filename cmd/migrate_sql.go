@@ -29,10 +29,13 @@ This decreases risk of failure and decreases time required.
 ### WARNING ###
 
 Before running this command on an existing database, create a back up!
+
+The SQL migrations are embedded in the binary. Use --plan to print them without connecting to a database.
 `,
 	Run: cmdHandler.Migration.MigrateSQL,
 }
 
 func init() {
 	migrateCmd.AddCommand(migrateSqlCmd)
+	migrateSqlCmd.Flags().Bool("plan", false, "Print the SQL migrations embedded in this binary instead of applying them")
 }
@@ -0,0 +1,37 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import "github.com/spf13/cobra"
+
+// migrateVerifyClientSecretsCmd represents the verify-client-secrets command
+var migrateVerifyClientSecretsCmd = &cobra.Command{
+	Use:   "verify-client-secrets <database-url>",
+	Short: "Reports clients whose secrets are hashed using a weaker algorithm or parameters than configured",
+	Long: `Lists the OAuth 2.0 Clients stored in SQL whose secrets were hashed using another algorithm or weaker parameters
+than configured by CLIENT_SECRET_HASHER, BCRYPT_COST and the ARGON2ID_* settings. Secrets are hashed again when the
+client authenticates at the token endpoint, so clients which are listed have not authenticated since the settings were
+changed. Secrets can not be rehashed without knowing them, rotate the secrets of such clients if they must not keep
+their weak hashes. The command exits with status 1 if weak hashes were found.
+
+Example:
+	CLIENT_SECRET_HASHER=argon2id hydra migrate verify-client-secrets postgres://...
+`,
+	Run: cmdHandler.Migration.VerifyClientSecrets,
+}
+
+func init() {
+	migrateCmd.AddCommand(migrateVerifyClientSecretsCmd)
+}
@@ -0,0 +1,38 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import "github.com/spf13/cobra"
+
+// migrateVerifyIndexesCmd represents the verify-indexes command
+var migrateVerifyIndexesCmd = &cobra.Command{
+	Use:   "verify-indexes <database-url>",
+	Short: "Checks that the indexes frequently executed queries rely on exist",
+	Long: `Checks that the SQL database contains the indexes which frequently executed queries rely on, such as looking up
+tokens by signature, revoking the tokens of a client and searching the policies of a subject or resource. The SQL
+migrations only create primary keys, so large installations should create the other indexes. For every missing index,
+a CREATE INDEX statement is printed. The command exits with status 1 if indexes are missing.
+
+Hydra runs the same check at startup, logs a warning for every missing index and reports them at /health/ready.
+
+Example:
+	hydra migrate verify-indexes postgres://...
+`,
+	Run: cmdHandler.Migration.VerifyIndexes,
+}
+
+func init() {
+	migrateCmd.AddCommand(migrateVerifyIndexesCmd)
+}
@@ -106,15 +106,72 @@ func initConfig() {
 	viper.BindEnv("CONSENT_URL")
 	viper.SetDefault("CONSENT_URL", oauth2.DefaultConsentPath)
 
+	viper.BindEnv("LOGIN_URL")
+	viper.SetDefault("LOGIN_URL", "")
+
+	viper.BindEnv("ERROR_URL")
+	viper.SetDefault("ERROR_URL", "")
+
+	viper.BindEnv("ERROR_CATALOG_DIR")
+	viper.SetDefault("ERROR_CATALOG_DIR", "")
+
 	viper.BindEnv("DATABASE_PLUGIN")
 	viper.SetDefault("DATABASE_PLUGIN", "")
 
 	viper.BindEnv("DATABASE_URL")
 	viper.SetDefault("DATABASE_URL", "")
 
+	viper.BindEnv("SQL_SLOW_QUERY_THRESHOLD")
+	viper.SetDefault("SQL_SLOW_QUERY_THRESHOLD", "1s")
+
+	viper.BindEnv("RATE_LIMIT_REDIS_URL")
+	viper.SetDefault("RATE_LIMIT_REDIS_URL", "")
+
+	viper.BindEnv("RATE_LIMIT_TOKEN_PER_CLIENT")
+	viper.SetDefault("RATE_LIMIT_TOKEN_PER_CLIENT", "")
+
+	viper.BindEnv("RATE_LIMIT_TOKEN_PER_IP")
+	viper.SetDefault("RATE_LIMIT_TOKEN_PER_IP", "")
+
+	viper.BindEnv("RATE_LIMIT_INTROSPECT_PER_CLIENT")
+	viper.SetDefault("RATE_LIMIT_INTROSPECT_PER_CLIENT", "")
+
+	viper.BindEnv("RATE_LIMIT_INTROSPECT_PER_IP")
+	viper.SetDefault("RATE_LIMIT_INTROSPECT_PER_IP", "")
+
+	viper.BindEnv("RATE_LIMIT_ADMIN_PER_CLIENT")
+	viper.SetDefault("RATE_LIMIT_ADMIN_PER_CLIENT", "")
+
+	viper.BindEnv("RATE_LIMIT_ADMIN_PER_IP")
+	viper.SetDefault("RATE_LIMIT_ADMIN_PER_IP", "")
+
+	viper.BindEnv("CLIENT_AUTH_MAX_FAILURES")
+	viper.SetDefault("CLIENT_AUTH_MAX_FAILURES", 10)
+
+	viper.BindEnv("CLIENT_AUTH_FAILURE_WINDOW")
+	viper.SetDefault("CLIENT_AUTH_FAILURE_WINDOW", "5m")
+
+	viper.BindEnv("CLIENT_AUTH_LOCKOUT")
+	viper.SetDefault("CLIENT_AUTH_LOCKOUT", "15m")
+
+	viper.BindEnv("TRACING_PROVIDER")
+	viper.SetDefault("TRACING_PROVIDER", "")
+
+	viper.BindEnv("TRACING_SERVICE_NAME")
+	viper.SetDefault("TRACING_SERVICE_NAME", "ORY Hydra")
+
+	viper.BindEnv("TRACING_JAEGER_AGENT_ADDRESS")
+	viper.SetDefault("TRACING_JAEGER_AGENT_ADDRESS", "127.0.0.1:6831")
+
+	viper.BindEnv("TRACING_SAMPLE_RATE")
+	viper.SetDefault("TRACING_SAMPLE_RATE", 1)
+
 	viper.BindEnv("SYSTEM_SECRET")
 	viper.SetDefault("SYSTEM_SECRET", "")
 
+	viper.BindEnv("SYSTEM_SECRET_DERIVATION")
+	viper.SetDefault("SYSTEM_SECRET_DERIVATION", 0)
+
 	viper.BindEnv("CLIENT_SECRET")
 	viper.SetDefault("CLIENT_SECRET", "")
 
@@ -130,15 +187,165 @@ func initConfig() {
 	viper.BindEnv("ISSUER")
 	viper.SetDefault("ISSUER", "http://localhost:4444")
 
+	viper.BindEnv("ISSUER_BY_HOST")
+	viper.SetDefault("ISSUER_BY_HOST", "")
+
+	viper.BindEnv("HTTPS_TLS_SANS")
+	viper.SetDefault("HTTPS_TLS_SANS", "localhost,127.0.0.1,::1,host.docker.internal")
+
 	viper.BindEnv("BCRYPT_COST")
 	viper.SetDefault("BCRYPT_COST", 10)
 
+	viper.BindEnv("CLIENT_SECRET_HASHER")
+	viper.SetDefault("CLIENT_SECRET_HASHER", "bcrypt")
+
+	viper.BindEnv("ARGON2ID_MEMORY")
+	viper.SetDefault("ARGON2ID_MEMORY", 65536)
+
+	viper.BindEnv("ARGON2ID_ITERATIONS")
+	viper.SetDefault("ARGON2ID_ITERATIONS", 3)
+
+	viper.BindEnv("ARGON2ID_PARALLELISM")
+	viper.SetDefault("ARGON2ID_PARALLELISM", 4)
+
+	viper.BindEnv("RSA_KEY_LENGTH")
+	viper.SetDefault("RSA_KEY_LENGTH", 4096)
+
+	viper.BindEnv("JWK_CACHE_TTL")
+	viper.SetDefault("JWK_CACHE_TTL", "")
+
+	viper.BindEnv("JWK_CIPHER_URL")
+	viper.SetDefault("JWK_CIPHER_URL", "")
+
+	viper.BindEnv("JWK_CLEANUP_INTERVAL")
+	viper.SetDefault("JWK_CLEANUP_INTERVAL", "1h")
+
+	viper.BindEnv("JWK_MIN_RSA_KEY_SIZE")
+	viper.SetDefault("JWK_MIN_RSA_KEY_SIZE", 2048)
+
+	viper.BindEnv("JWK_MIN_EC_KEY_SIZE")
+	viper.SetDefault("JWK_MIN_EC_KEY_SIZE", 256)
+
+	viper.BindEnv("JWK_MIN_SYMMETRIC_KEY_SIZE")
+	viper.SetDefault("JWK_MIN_SYMMETRIC_KEY_SIZE", 256)
+
+	viper.BindEnv("JWK_DENIED_ALGORITHMS")
+	viper.SetDefault("JWK_DENIED_ALGORITHMS", "none,RS1")
+
 	viper.BindEnv("OAUTH2_SHARE_ERROR_DEBUG")
 	viper.SetDefault("OAUTH2_SHARE_ERROR_DEBUG", false)
 
+	viper.BindEnv("OAUTH2_REQUIRE_PKCE_FOR_PUBLIC_CLIENTS")
+	viper.SetDefault("OAUTH2_REQUIRE_PKCE_FOR_PUBLIC_CLIENTS", false)
+
+	viper.BindEnv("OAUTH2_EMBED_ALLOWED_ORIGINS")
+	viper.SetDefault("OAUTH2_EMBED_ALLOWED_ORIGINS", "")
+
+	viper.BindEnv("OAUTH2_PUSHED_AUTHORIZATION_REQUEST_LIFESPAN")
+	viper.SetDefault("OAUTH2_PUSHED_AUTHORIZATION_REQUEST_LIFESPAN", "1m")
+
+	viper.BindEnv("OAUTH2_RESOURCE_SCOPES")
+	viper.SetDefault("OAUTH2_RESOURCE_SCOPES", "")
+
+	viper.BindEnv("OAUTH2_INTROSPECTION_CACHE_TTL")
+	viper.SetDefault("OAUTH2_INTROSPECTION_CACHE_TTL", "")
+
+	viper.BindEnv("OAUTH2_INTROSPECTION_CACHE_MAX_ENTRIES")
+	viper.SetDefault("OAUTH2_INTROSPECTION_CACHE_MAX_ENTRIES", 10000)
+
+	viper.BindEnv("OAUTH2_INTROSPECTION_CACHE_CONTROL_MAX_AGE")
+	viper.SetDefault("OAUTH2_INTROSPECTION_CACHE_CONTROL_MAX_AGE", "")
+
+	viper.BindEnv("OAUTH2_INTROSPECTION_CACHE_CONTROL_SUBJECTS")
+	viper.SetDefault("OAUTH2_INTROSPECTION_CACHE_CONTROL_SUBJECTS", "")
+
+	viper.BindEnv("OAUTH2_TOKEN_HISTORY")
+	viper.SetDefault("OAUTH2_TOKEN_HISTORY", false)
+
+	viper.BindEnv("CONSENT_STATISTICS")
+	viper.SetDefault("CONSENT_STATISTICS", false)
+
+	viper.BindEnv("REVOCATION_FILTER_INTERVAL")
+	viper.SetDefault("REVOCATION_FILTER_INTERVAL", "10s")
+
+	viper.BindEnv("REVOCATION_FILTER_FALSE_POSITIVE_RATE")
+	viper.SetDefault("REVOCATION_FILTER_FALSE_POSITIVE_RATE", 0.001)
+
+	viper.BindEnv("OAUTH2_CLIENT_REGISTRATION")
+	viper.SetDefault("OAUTH2_CLIENT_REGISTRATION", false)
+
+	viper.BindEnv("OAUTH2_DYNAMIC_CLIENT_REGISTRATION")
+	viper.SetDefault("OAUTH2_DYNAMIC_CLIENT_REGISTRATION", "")
+
+	viper.BindEnv("OAUTH2_CLIENT_SECRET_LENGTH")
+	viper.SetDefault("OAUTH2_CLIENT_SECRET_LENGTH", 26)
+
+	viper.BindEnv("OAUTH2_CLIENT_SECRET_MIN_ENTROPY")
+	viper.SetDefault("OAUTH2_CLIENT_SECRET_MIN_ENTROPY", 0)
+
+	viper.BindEnv("OAUTH2_CLIENT_SECRET_CHARACTER_CLASSES")
+	viper.SetDefault("OAUTH2_CLIENT_SECRET_CHARACTER_CLASSES", "lower,upper,digits,symbols")
+
+	viper.BindEnv("OAUTH2_CLIENT_SECRET_ENTROPY_SOURCE")
+	viper.SetDefault("OAUTH2_CLIENT_SECRET_ENTROPY_SOURCE", "")
+
+	viper.BindEnv("WARDEN_DECISION_LOG")
+	viper.SetDefault("WARDEN_DECISION_LOG", false)
+
+	viper.BindEnv("WARDEN_DECISION_LOG_ALLOW_SAMPLE_RATE")
+	viper.SetDefault("WARDEN_DECISION_LOG_ALLOW_SAMPLE_RATE", 0.01)
+
+	viper.BindEnv("WARDEN_DECISION_LOG_RETENTION")
+	viper.SetDefault("WARDEN_DECISION_LOG_RETENTION", "720h")
+
+	viper.BindEnv("WARDEN_POLICY_USAGE")
+	viper.SetDefault("WARDEN_POLICY_USAGE", false)
+
+	viper.BindEnv("WARDEN_CACHE_TTL")
+	viper.SetDefault("WARDEN_CACHE_TTL", "")
+
+	viper.BindEnv("WARDEN_CACHE_MAX_ENTRIES")
+	viper.SetDefault("WARDEN_CACHE_MAX_ENTRIES", 10000)
+
+	viper.BindEnv("WARDEN_AUTHORIZER_URL")
+	viper.SetDefault("WARDEN_AUTHORIZER_URL", "")
+
+	viper.BindEnv("WARDEN_AUTHORIZER_TIMEOUT")
+	viper.SetDefault("WARDEN_AUTHORIZER_TIMEOUT", "5s")
+
+	viper.BindEnv("WARDEN_API_KEYS")
+	viper.SetDefault("WARDEN_API_KEYS", "")
+
+	viper.BindEnv("WARDEN_API_KEYS_FILE")
+	viper.SetDefault("WARDEN_API_KEYS_FILE", "")
+
+	viper.BindEnv("WARDEN_ACTION_GROUPS")
+	viper.SetDefault("WARDEN_ACTION_GROUPS", "")
+
+	viper.BindEnv("WARDEN_ELEVATIONS")
+	viper.SetDefault("WARDEN_ELEVATIONS", false)
+
+	viper.BindEnv("WARDEN_ELEVATION_MAX_DURATION")
+	viper.SetDefault("WARDEN_ELEVATION_MAX_DURATION", "8h")
+
+	viper.BindEnv("AUDIT_LOG_SINKS")
+	viper.SetDefault("AUDIT_LOG_SINKS", "")
+
+	viper.BindEnv("AUDIT_LOG_WEBHOOK_TIMEOUT")
+	viper.SetDefault("AUDIT_LOG_WEBHOOK_TIMEOUT", "5s")
+
+	viper.BindEnv("SUBJECT_PSEUDONYMIZATION_KEY")
+	viper.SetDefault("SUBJECT_PSEUDONYMIZATION_KEY", "")
+
 	viper.BindEnv("ACCESS_TOKEN_LIFESPAN")
 	viper.SetDefault("ACCESS_TOKEN_LIFESPAN", "1h")
 
+	viper.BindEnv("ACCESS_TOKEN_STRATEGY")
+	viper.SetDefault("ACCESS_TOKEN_STRATEGY", "opaque")
+
+	viper.BindEnv("SCOPE_STRATEGY")
+	viper.SetDefault("SCOPE_STRATEGY", "wildcard")
+
 	viper.BindEnv("ID_TOKEN_LIFESPAN")
 	viper.SetDefault("ID_TOKEN_LIFESPAN", "1h")
 
@@ -148,6 +355,33 @@ func initConfig() {
 	viper.BindEnv("CHALLENGE_TOKEN_LIFESPAN")
 	viper.SetDefault("CHALLENGE_TOKEN_LIFESPAN", "10m")
 
+	viper.BindEnv("CONSENT_REQUEST_CLEANUP_INTERVAL")
+	viper.SetDefault("CONSENT_REQUEST_CLEANUP_INTERVAL", "1h")
+
+	viper.BindEnv("CONSENT_REQUEST_PARK_LIFESPAN")
+	viper.SetDefault("CONSENT_REQUEST_PARK_LIFESPAN", "1h")
+
+	viper.BindEnv("EVENTS_WEBHOOK_URL")
+	viper.SetDefault("EVENTS_WEBHOOK_URL", "")
+
+	viper.BindEnv("EVENTS_WEBHOOK_SECRET")
+	viper.SetDefault("EVENTS_WEBHOOK_SECRET", "")
+
+	viper.BindEnv("EVENTS_DISPATCH_INTERVAL")
+	viper.SetDefault("EVENTS_DISPATCH_INTERVAL", "5s")
+
+	viper.BindEnv("EVENTS_WEBHOOK_TIMEOUT")
+	viper.SetDefault("EVENTS_WEBHOOK_TIMEOUT", "10s")
+
+	viper.BindEnv("CLAIMS_HOOK_URL")
+	viper.SetDefault("CLAIMS_HOOK_URL", "")
+
+	viper.BindEnv("CLAIMS_HOOK_SECRET")
+	viper.SetDefault("CLAIMS_HOOK_SECRET", "")
+
+	viper.BindEnv("CLAIMS_HOOK_TIMEOUT")
+	viper.SetDefault("CLAIMS_HOOK_TIMEOUT", "5s")
+
 	viper.BindEnv("LOG_LEVEL")
 	viper.SetDefault("LOG_LEVEL", "info")
 
@@ -166,6 +400,12 @@ func initConfig() {
 	viper.BindEnv("OIDC_DISCOVERY_USERINFO_ENDPOINT")
 	viper.SetDefault("OIDC_DISCOVERY_USERINFO_ENDPOINT", "")
 
+	viper.BindEnv("OIDC_ID_TOKEN_SIGNING_ALG")
+	viper.SetDefault("OIDC_ID_TOKEN_SIGNING_ALG", "RS256")
+
+	viper.BindEnv("OIDC_JWKS_CACHE_MAX_AGE")
+	viper.SetDefault("OIDC_JWKS_CACHE_MAX_AGE", "")
+
 	// If a config file is found, read it in.
 	if err := viper.ReadInConfig(); err != nil {
 		fmt.Printf(`Config file not found because "%s"`, err)
@@ -61,7 +61,9 @@ func TestExecute(t *testing.T) {
 		{args: []string{"clients", "delete", "foobarbaz"}},
 		{args: []string{"keys", "create", "foo", "-a", "HS256"}},
 		{args: []string{"keys", "create", "foo", "-a", "HS256"}},
+		{args: []string{"keys", "append", "foo", "bar", "-a", "HS256"}},
 		{args: []string{"keys", "get", "foo"}},
+		{args: []string{"keys", "export", "--all", "--passphrase", "correct horse battery"}},
 		{args: []string{"keys", "delete", "foo"}},
 		{args: []string{"token", "revoke", "foo"}},
 		{args: []string{"token", "client"}},
@@ -79,9 +81,12 @@ func TestExecute(t *testing.T) {
 		{args: []string{"groups", "find", "peter"}},
 		{args: []string{"groups", "members", "remove", "my-group", "peter"}},
 		{args: []string{"groups", "delete", "my-group"}},
+		{args: []string{"warden", "check", "--subject", "admin", "--resource", "rn:hydra:clients", "--action", "create"}},
 		{args: []string{"help", "migrate", "sql"}},
 		{args: []string{"help", "migrate", "ladon", "0.6.0"}},
 		{args: []string{"version"}},
+		{args: []string{"metrics", "dashboard"}},
+		{args: []string{"metrics", "alerts", "--latency", "500ms"}},
 		{args: []string{"token", "flush"}},
 		{args: []string{"token", "user", "--no-open"}, wait: func() bool {
 			time.Sleep(time.Millisecond * 10)
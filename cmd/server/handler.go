@@ -26,25 +26,28 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/gorilla/context"
 	"github.com/julienschmidt/httprouter"
-	"github.com/meatballhat/negroni-logrus"
 	"github.com/ory/graceful"
 	"github.com/ory/herodot"
+	"github.com/ory/hydra/audit"
 	"github.com/ory/hydra/client"
 	"github.com/ory/hydra/config"
+	"github.com/ory/hydra/erasure"
 	"github.com/ory/hydra/jwk"
 	"github.com/ory/hydra/oauth2"
 	"github.com/ory/hydra/pkg"
 	"github.com/ory/hydra/policy"
+	"github.com/ory/hydra/ratelimit"
+	"github.com/ory/hydra/tracing"
 	"github.com/ory/hydra/warden"
+	"github.com/ory/hydra/warden/decision"
+	"github.com/ory/hydra/warden/elevation"
 	"github.com/ory/hydra/warden/group"
-	"github.com/ory/ladon"
+	"github.com/ory/hydra/warden/usage"
 	"github.com/pkg/errors"
 	"github.com/rs/cors"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
-	"github.com/urfave/negroni"
 )
 
 func parseCorsOptions() cors.Options {
@@ -81,13 +84,7 @@ func RunHost(c *config.Config) func(cmd *cobra.Command, args []string) {
 	return func(cmd *cobra.Command, args []string) {
 		fmt.Println(banner)
 
-		router := httprouter.New()
 		logger := c.GetLogger()
-		serverHandler := &Handler{
-			Config: c,
-			H:      herodot.NewJSONWriter(logger),
-		}
-		serverHandler.registerRoutes(router)
 		c.ForceHTTP, _ = cmd.Flags().GetBool("dangerous-force-http")
 
 		if !c.ForceHTTP {
@@ -99,34 +96,23 @@ func RunHost(c *config.Config) func(cmd *cobra.Command, args []string) {
 			if issuer.Scheme != "https" {
 				logger.Fatalln("Issuer must use HTTPS unless --dangerous-force-http is passed. To find out more, use `hydra help host`.")
 			}
-		}
-
-		if c.ClusterURL == "" {
-			proto := "https"
-			if c.ForceHTTP {
-				proto = "http"
+			for host, issuer := range c.GetIssuersByHost() {
+				u, err := url.Parse(issuer)
+				pkg.Must(err, "Could not parse issuer URL of host %s: %s", host, err)
+				if u.Scheme != "https" {
+					logger.Fatalf("Issuer of host %s must use HTTPS unless --dangerous-force-http is passed. To find out more, use `hydra help host`.", host)
+				}
 			}
-			host := "localhost"
-			if c.BindHost != "" {
-				host = c.BindHost
-			}
-			c.ClusterURL = fmt.Sprintf("%s://%s:%d", proto, host, c.BindPort)
 		}
 
-		n := negroni.New()
-
+		telemetry := false
 		if ok, _ := cmd.Flags().GetBool("disable-telemetry"); !ok && os.Getenv("DISABLE_TELEMETRY") != "1" {
+			telemetry = true
 			metrics := c.GetMetrics()
 			go metrics.RegisterSegment(c.BuildVersion, c.BuildHash, c.BuildTime)
 			go metrics.CommitMemoryStatistics()
-			n.Use(metrics)
 		}
-
-		n.Use(negronilogrus.NewMiddlewareFromLogger(logger, c.Issuer))
-		n.UseFunc(serverHandler.rejectInsecureRequests)
-		n.UseHandler(router)
-		corsHandler := cors.New(parseCorsOptions()).Handler(n)
-
+		s := newServer(c, telemetry)
 
 		// load certs
 		certs := x509.NewCertPool()
@@ -148,20 +134,19 @@ func RunHost(c *config.Config) func(cmd *cobra.Command, args []string) {
 
                 fmt.Printf("Pool Subjects : %x\n", certs.Subjects())
 
-		var srv = graceful.WithDefaults(&http.Server{
-			Addr:    c.GetAddress(),
-			Handler: context.ClearHandler(corsHandler),
-			TLSConfig: &tls.Config{
-				Certificates: []tls.Certificate{getOrCreateTLSCertificate(cmd, c)},
-				ClientAuth: tls.VerifyClientCertIfGiven,
-				ClientCAs: certs,
-				NextProtos: []string{"h2", "http/1.1"},
-				CipherSuites: StandardCipherSuites(),
-				PreferServerCipherSuites: true,
-				MinVersion: tls.VersionTLS12,
-				SessionTicketsDisabled: true,
-			},
-		})
+		s.HTTP = graceful.WithDefaults(s.HTTP)
+		s.HTTP.TLSConfig = &tls.Config{
+			Certificates:             []tls.Certificate{getOrCreateTLSCertificate(cmd, c)},
+			ClientAuth:               tls.RequestClientCert,
+			ClientCAs:                certs,
+			VerifyPeerCertificate:    verifyClientCertificate(certs),
+			NextProtos:               []string{"h2", "http/1.1"},
+			CipherSuites:             StandardCipherSuites(),
+			PreferServerCipherSuites: true,
+			MinVersion:               tls.VersionTLS12,
+			SessionTicketsDisabled:   true,
+		}
+		s.Handler.OAuth2.TLSClientAuthCAs = certs
 
 		if ok, _ := cmd.Flags().GetBool("dangerous-auto-logon"); ok {
 			logger.Warnln("Do not use flag --dangerous-auto-logon in production.")
@@ -169,35 +154,31 @@ func RunHost(c *config.Config) func(cmd *cobra.Command, args []string) {
 			pkg.Must(err, "Could not write configuration file: %s", err)
 		}
 
-		err := graceful.Graceful(func() error {
-			var err error
-			logger.Infof("Setting up http server on %s", c.GetAddress())
-			if c.ForceHTTP {
-				logger.Warnln("HTTPS disabled. Never do this in production.")
-				err = srv.ListenAndServe()
-			} else if c.AllowTLSTermination != "" {
-				logger.Infoln("TLS termination enabled, disabling https.")
-				err = srv.ListenAndServe()
-			} else {
-				err = srv.ListenAndServeTLS("", "")
-			}
-
-			return err
-		}, srv.Shutdown)
+		err := graceful.Graceful(s.Start, s.Stop)
 		logger.WithError(err).Fatal("Could not gracefully run server")
 	}
 }
 
 type Handler struct {
-	Clients *client.Handler
-	Keys    *jwk.Handler
-	OAuth2  *oauth2.Handler
-	Consent *oauth2.ConsentSessionHandler
-	Policy  *policy.Handler
-	Groups  *group.Handler
-	Warden  *warden.WardenHandler
-	Config  *config.Config
-	H       herodot.Writer
+	Clients     *client.Handler
+	Keys        *jwk.Handler
+	OAuth2      *oauth2.Handler
+	Consent     *oauth2.ConsentSessionHandler
+	Policy      *policy.Handler
+	Groups      *group.Handler
+	Warden      *warden.WardenHandler
+	Decisions   *decision.Handler
+	Elevations  *elevation.Handler
+	PolicyUsage *usage.Handler
+	Erasure     *erasure.Handler
+	Audit       *audit.Middleware
+	RateLimit   *ratelimit.Middleware
+	Tracing     *tracing.Middleware
+	Config      *config.Config
+	H           herodot.Writer
+
+	// PolicyUsageTracker counts policy matches if WARDEN_POLICY_USAGE is set. It is run by Server.Start.
+	PolicyUsageTracker *usage.Tracker
 }
 
 func (h *Handler) registerRoutes(router *httprouter.Router) {
@@ -205,31 +186,102 @@ func (h *Handler) registerRoutes(router *httprouter.Router) {
 	ctx := c.Context()
 
 	// Set up dependencies
+	h.Tracing = newTracingMiddleware(c)
 	injectJWKManager(c)
 	injectConsentManager(c)
+	var consentStatistics oauth2.ConsentStatisticsManager
+	if c.ConsentStatistics {
+		consentStatistics = newConsentStatisticsManager(c)
+		ctx.ConsentManager = &oauth2.ConsentStatisticsRecorder{ConsentRequestManager: ctx.ConsentManager, Statistics: consentStatistics}
+	}
+	consentGrants := newConsentGrantManager(c)
+	ctx.ConsentManager = &oauth2.ConsentGrantRecorder{ConsentRequestManager: ctx.ConsentManager, Grants: consentGrants}
+	rememberedConsents := newRememberedConsentManager(c)
+	ctx.ConsentManager = &oauth2.ConsentRememberer{ConsentRequestManager: ctx.ConsentManager, Remembered: rememberedConsents}
+	loginSessions := newLoginSessionManager(c)
+	var logins oauth2.LoginRequestManager
+	if c.LoginURL != "" {
+		logins = newLoginRequestManager(c)
+	}
 	clientsManager := newClientManager(c)
-	injectFositeStore(c, clientsManager)
+	if h.Tracing != nil {
+		ctx.KeyManager = &tracing.KeyManager{Manager: ctx.KeyManager}
+		clientsManager = &tracing.ClientManager{Manager: clientsManager}
+	}
+	var history oauth2.TokenHistoryManager
+	if c.OAuth2TokenHistory {
+		history = newTokenHistoryManager(c)
+	}
+	injectFositeStore(c, clientsManager, history)
+	if h.Tracing != nil {
+		ctx.FositeStore = &tracing.FositeStore{FositeStorer: ctx.FositeStore}
+	}
+
+	var wardenCache *warden.DecisionCache
+	if ttl := c.GetWardenCacheTTL(); ttl > 0 {
+		wardenCache = warden.NewDecisionCache(ttl, c.WardenCacheMaxEntries)
+		ctx.FositeStore = &warden.CacheInvalidatingStorage{FositeStorer: ctx.FositeStore, Cache: wardenCache}
+	}
+
+	var introspectionCache *oauth2.IntrospectionCache
+	if ttl := c.GetOAuth2IntrospectionCacheTTL(); ttl > 0 {
+		introspectionCache = oauth2.NewIntrospectionCache(ttl, c.OAuth2IntrospectionCacheEntries)
+		ctx.FositeStore = &warden.CacheInvalidatingStorage{FositeStorer: ctx.FositeStore, Cache: introspectionCache}
+	}
+
 	oauth2Provider, idTokenKeyID := newOAuth2Provider(c)
+	auditSink := newAuditSink(c)
 
 	// set up warden
+	var decisions *decision.Recorder
+	if c.WardenDecisionLog {
+		decisions = &decision.Recorder{
+			Manager:         newDecisionManager(c),
+			AllowSampleRate: c.WardenDecisionLogAllowSampleRate,
+			L:               c.GetLogger(),
+		}
+	}
+
+	var policyUsage *usage.Tracker
+	if c.WardenPolicyUsage {
+		policyUsage = &usage.Tracker{
+			Manager: newPolicyUsageManager(c),
+			L:       c.GetLogger(),
+		}
+	}
+
+	var elevations elevation.Manager
+	if c.WardenElevations {
+		elevations = newElevationManager(c)
+	}
+
 	ctx.Warden = &warden.LocalWarden{
-		Warden: &ladon.Ladon{
-			Manager: ctx.LadonManager,
-		},
+		Warden:              newPolicyDecisionPoint(c, decisions, policyUsage),
 		OAuth2:              oauth2Provider,
 		Issuer:              c.Issuer,
 		AccessTokenLifespan: c.GetAccessTokenLifespan(),
 		Groups:              ctx.GroupManager,
 		L:                   c.GetLogger(),
+		Decisions:           decisions,
+		Cache:               wardenCache,
+		Subjects:            c.GetSubjectPseudonymizer(),
+		DecisionObserved:    c.GetMetrics().OperationStatistics.RecordFirewallDecision,
+		ActionGroups:        newActionGroups(c),
+		Peers:               newPeerIssuers(c),
+		Elevations:          elevations,
+		ScopeStrategy:       c.GetScopeStrategy(),
 	}
 
 	// Set up handlers
 	h.Clients = newClientHandler(c, router, clientsManager)
-	h.Keys = newJWKHandler(c, router)
+	h.Keys = newJWKHandler(c, router, clientsManager)
 	h.Policy = newPolicyHandler(c, router)
-	h.Consent = newConsentHanlder(c, router)
-	h.OAuth2 = newOAuth2Handler(c, router, ctx.ConsentManager, oauth2Provider, idTokenKeyID)
+	h.Consent = newConsentHanlder(c, router, clientsManager, consentStatistics, logins)
+	h.OAuth2 = newOAuth2Handler(c, router, ctx.ConsentManager, oauth2Provider, idTokenKeyID, history, consentGrants, rememberedConsents, loginSessions, logins, auditSink)
+	h.OAuth2.ClientSecretVerified = newClientSecretRehasher(c, clientsManager)
+	h.OAuth2.IntrospectionCache = introspectionCache
 	h.Warden = warden.NewHandler(c, router)
+	h.Warden.APIKeys = newWardenAPIKeys(c)
 	h.Groups = &group.Handler{
 		H:              herodot.NewJSONWriter(c.GetLogger()),
 		W:              ctx.Warden,
@@ -237,7 +289,37 @@ func (h *Handler) registerRoutes(router *httprouter.Router) {
 		ResourcePrefix: c.AccessControlResourcePrefix,
 	}
 	h.Groups.SetRoutes(router)
+	if decisions != nil {
+		h.Decisions = newDecisionHandler(c, router, decisions.Manager)
+	}
+	if elevations != nil {
+		h.Elevations = newElevationHandler(c, router, elevations)
+	}
+	if policyUsage != nil {
+		h.PolicyUsageTracker = policyUsage
+		h.PolicyUsage = newPolicyUsageHandler(c, router, policyUsage.Manager)
+	}
+	eraser := &erasure.Eraser{
+		Tokens:             ctx.FositeStore,
+		TokenHistory:       history,
+		ConsentGrants:      consentGrants,
+		RememberedConsents: rememberedConsents,
+		LoginSessions:      loginSessions,
+		LoginRequests:      logins,
+		Groups:             ctx.GroupManager,
+		Subjects:           c.GetSubjectPseudonymizer(),
+	}
+	if decisions != nil {
+		eraser.Decisions = decisions.Manager
+	}
+	if elevations != nil {
+		eraser.Elevations = elevations
+	}
+	h.Erasure = newErasureHandler(c, router, eraser)
 	_ = newHealthHandler(c, router)
+	_ = newConfigHandler(c, router)
+	h.Audit = newAuditMiddleware(c, auditSink, oauth2Provider)
+	h.RateLimit = newRateLimitMiddleware(c, oauth2Provider, h.H)
 
 	h.createRootIfNewInstall(c)
 }
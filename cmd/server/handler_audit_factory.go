@@ -0,0 +1,87 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/ory/fosite"
+	"github.com/ory/hydra/audit"
+	"github.com/ory/hydra/client"
+	"github.com/ory/hydra/config"
+	"github.com/ory/hydra/erasure"
+	"github.com/ory/hydra/jwk"
+	"github.com/ory/hydra/oauth2"
+	"github.com/ory/hydra/pkg"
+	"github.com/ory/hydra/policy"
+	"github.com/ory/hydra/warden/elevation"
+	"github.com/ory/hydra/warden/group"
+)
+
+// newAuditSink returns the sinks of AUDIT_LOG_SINKS, or nil if it is not set.
+func newAuditSink(c *config.Config) audit.Sink {
+	urls := pkg.SplitNonEmpty(c.AuditLogSinks, ",")
+	if len(urls) == 0 {
+		return nil
+	}
+
+	var sinks audit.MultiSink
+	client := &http.Client{Timeout: c.GetAuditLogWebhookTimeout()}
+	for _, u := range urls {
+		sink, err := audit.NewSink(u, client)
+		if err != nil {
+			c.GetLogger().Fatalf("Could not set up audit log sink: %s", err)
+		}
+		sinks = append(sinks, sink)
+	}
+	return sinks
+}
+
+// newAuditMiddleware returns the audit log middleware if sink is set, nil otherwise.
+func newAuditMiddleware(c *config.Config, sink audit.Sink, provider fosite.OAuth2Provider) *audit.Middleware {
+	if sink == nil {
+		return nil
+	}
+
+	subjects := c.GetSubjectPseudonymizer()
+	return &audit.Middleware{
+		Sink: sink,
+		Prefixes: []string{
+			client.ClientsHandlerPath,
+			client.RegistrationPath,
+			client.DynamicRegistrationPath,
+			jwk.KeyHandlerPath,
+			jwk.BackupHandlerPath,
+			policy.PolicyHandlerPath,
+			group.GroupsHandlerPath,
+			elevation.ElevationsHandlerPath,
+			erasure.ErasureHandlerPath,
+		},
+		L: c.GetLogger(),
+		Identify: func(r *http.Request) (string, string) {
+			token := fosite.AccessTokenFromRequest(r)
+			if token == "" {
+				return "", ""
+			}
+
+			auth, err := provider.IntrospectToken(context.Background(), token, fosite.AccessToken, oauth2.NewSession(""))
+			if err != nil {
+				return "", ""
+			}
+			return subjects.Pseudonymize(auth.GetSession().GetSubject()), auth.GetClient().GetID()
+		},
+	}
+}
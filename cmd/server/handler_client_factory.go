@@ -15,6 +15,9 @@
 package server
 
 import (
+	"os"
+	"strings"
+
 	"github.com/julienschmidt/httprouter"
 	"github.com/ory/herodot"
 	"github.com/ory/hydra/client"
@@ -28,10 +31,19 @@ func newClientManager(c *config.Config) client.Manager {
 	case *config.MemoryConnection:
 		return client.NewMemoryManager(ctx.Hasher)
 	case *config.SQLConnection:
-		return &client.SQLManager{
+		m := &client.SQLManager{
 			DB:     con.GetDatabase(),
 			Hasher: ctx.Hasher,
 		}
+		if outbox := newEventOutbox(c); outbox != nil {
+			m.Outbox = outbox
+		}
+		return m
+	case *config.RedisConnection:
+		return &client.RedisManager{
+			DB:     con.GetClient(),
+			Hasher: ctx.Hasher,
+		}
 	case *config.PluginConnection:
 		if m, err := con.NewClientManager(); err != nil {
 			c.GetLogger().Fatalf("Could not load client manager plugin %s", err)
@@ -51,8 +63,78 @@ func newClientHandler(c *config.Config, router *httprouter.Router, manager clien
 		H: herodot.NewJSONWriter(c.GetLogger()),
 		W: ctx.Warden, Manager: manager,
 		ResourcePrefix: c.AccessControlResourcePrefix,
+
+		RegistrationEnabled: c.OAuth2ClientRegistration,
+		SecretPrefix:        c.GetClientSecretPrefix(),
+		Secrets:             newClientSecretPolicy(c),
+	}
+
+	switch c.OAuth2DynamicClientRegistration {
+	case "":
+	case client.DynamicRegistrationOpen, client.DynamicRegistrationProtected:
+		h.DynamicRegistration = c.OAuth2DynamicClientRegistration
+		h.RegistrationTokens = newRegistrationTokenManager(c)
+		h.Issuer = c.Issuer
+	default:
+		c.GetLogger().Fatalf(`OAUTH2_DYNAMIC_CLIENT_REGISTRATION must be "open" or "protected", got %s`, c.OAuth2DynamicClientRegistration)
 	}
 
 	h.SetRoutes(router)
 	return h
 }
+
+// newClientSecretRehasher returns a function which rehashes the secrets of clients authenticating at the token
+// endpoint if they were hashed using another algorithm or weaker parameters than configured.
+func newClientSecretRehasher(c *config.Config, manager client.Manager) func(id string, secret []byte) {
+	hasher := c.GetClientSecretHasher()
+	return func(id string, secret []byte) {
+		if rehashed, err := client.RehashSecret(manager, hasher, id, secret); err != nil {
+			c.GetLogger().WithError(err).WithField("client_id", id).Errorln("Could not rehash client secret")
+		} else if rehashed {
+			c.GetLogger().WithField("client_id", id).Infoln("Rehashed client secret")
+		}
+	}
+}
+
+func newClientSecretPolicy(c *config.Config) *client.SecretPolicy {
+	p := &client.SecretPolicy{
+		Length:     c.OAuth2ClientSecretLength,
+		MinEntropy: c.OAuth2ClientSecretMinEntropy,
+	}
+
+	for _, class := range strings.Split(c.OAuth2ClientSecretClasses, ",") {
+		if class = strings.TrimSpace(class); class != "" {
+			p.CharacterClasses = append(p.CharacterClasses, class)
+		}
+	}
+
+	if path := c.OAuth2ClientSecretEntropySource; path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			c.GetLogger().Fatalf("Could not open OAUTH2_CLIENT_SECRET_ENTROPY_SOURCE %s: %s", path, err)
+		}
+		p.Source, p.SourceName = f, path
+	}
+
+	if err := p.Validate(); err != nil {
+		c.GetLogger().Fatalf("Client secret policy is invalid: %s", err)
+	}
+	return p
+}
+
+func newRegistrationTokenManager(c *config.Config) client.RegistrationTokenManager {
+	switch con := c.Context().Connection.(type) {
+	case *config.MemoryConnection:
+		return client.NewRegistrationTokenMemoryManager()
+	case *config.SQLConnection:
+		return &client.RegistrationTokenSQLManager{DB: con.GetDatabase()}
+	case *config.RedisConnection:
+		return &client.RegistrationTokenRedisManager{DB: con.GetClient()}
+	case *config.PluginConnection:
+		// Registration access tokens kept in memory would be lost on restart, locking registered clients out.
+		c.GetLogger().Fatalln("Dynamic client registration is not supported by database plugins, use a SQL or Redis database instead")
+		return nil
+	default:
+		panic("Unknown connection type.")
+	}
+}
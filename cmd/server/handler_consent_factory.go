@@ -15,8 +15,11 @@
 package server
 
 import (
+	"time"
+
 	"github.com/julienschmidt/httprouter"
 	"github.com/ory/herodot"
+	"github.com/ory/hydra/client"
 	"github.com/ory/hydra/config"
 	"github.com/ory/hydra/oauth2"
 )
@@ -30,7 +33,14 @@ func injectConsentManager(c *config.Config) {
 		manager = oauth2.NewConsentRequestMemoryManager()
 		break
 	case *config.SQLConnection:
-		manager = oauth2.NewConsentRequestSQLManager(con.GetDatabase())
+		m := oauth2.NewConsentRequestSQLManager(con.GetDatabase())
+		if outbox := newEventOutbox(c); outbox != nil {
+			m.Outbox = outbox
+		}
+		manager = m
+		break
+	case *config.RedisConnection:
+		manager = oauth2.NewConsentRequestRedisManager(con.GetClient())
 		break
 	case *config.PluginConnection:
 		var err error
@@ -46,14 +56,146 @@ func injectConsentManager(c *config.Config) {
 
 }
 
-func newConsentHanlder(c *config.Config, router *httprouter.Router) *oauth2.ConsentSessionHandler {
+func newConsentStatisticsManager(c *config.Config) oauth2.ConsentStatisticsManager {
+	switch con := c.Context().Connection.(type) {
+	case *config.MemoryConnection:
+		return oauth2.NewConsentStatisticsMemoryManager()
+	case *config.SQLConnection:
+		return &oauth2.ConsentStatisticsSQLManager{DB: con.GetDatabase()}
+	case *config.RedisConnection:
+		c.GetLogger().Warnln("Consent statistics are not supported by Redis, storing the statistics in memory")
+		return oauth2.NewConsentStatisticsMemoryManager()
+	case *config.PluginConnection:
+		c.GetLogger().Warnln("Consent statistics are not supported by database plugins, storing the statistics in memory")
+		return oauth2.NewConsentStatisticsMemoryManager()
+	default:
+		panic("Unknown connection type.")
+	}
+}
+
+func newConsentGrantManager(c *config.Config) oauth2.ConsentGrantManager {
+	switch con := c.Context().Connection.(type) {
+	case *config.MemoryConnection:
+		return oauth2.NewConsentGrantMemoryManager()
+	case *config.SQLConnection:
+		return &oauth2.ConsentGrantSQLManager{DB: con.GetDatabase()}
+	case *config.RedisConnection:
+		return &oauth2.ConsentGrantRedisManager{DB: con.GetClient()}
+	case *config.PluginConnection:
+		// Grants kept in memory would be lost on restart, so subjects could not review or revoke their consent.
+		c.GetLogger().Fatalln("Consent grants are not supported by database plugins, use a SQL or Redis database instead")
+		return nil
+	default:
+		panic("Unknown connection type.")
+	}
+}
+
+func newRememberedConsentManager(c *config.Config) oauth2.RememberedConsentManager {
+	switch con := c.Context().Connection.(type) {
+	case *config.MemoryConnection:
+		return oauth2.NewRememberedConsentMemoryManager()
+	case *config.SQLConnection:
+		return &oauth2.RememberedConsentSQLManager{DB: con.GetDatabase()}
+	case *config.RedisConnection:
+		return &oauth2.RememberedConsentRedisManager{DB: con.GetClient()}
+	case *config.PluginConnection:
+		// Consent remembered in memory could not be revoked on the other instances.
+		c.GetLogger().Fatalln("Remembered consent is not supported by database plugins, use a SQL or Redis database instead")
+		return nil
+	default:
+		panic("Unknown connection type.")
+	}
+}
+
+func newLoginSessionManager(c *config.Config) oauth2.LoginSessionManager {
+	switch con := c.Context().Connection.(type) {
+	case *config.MemoryConnection:
+		return oauth2.NewLoginSessionMemoryManager()
+	case *config.SQLConnection:
+		return &oauth2.LoginSessionSQLManager{DB: con.GetDatabase()}
+	case *config.RedisConnection:
+		return &oauth2.LoginSessionRedisManager{DB: con.GetClient()}
+	case *config.PluginConnection:
+		// Login sessions kept in memory would be lost on restart, so clients would not be logged out.
+		c.GetLogger().Fatalln("Login sessions are not supported by database plugins, use a SQL or Redis database instead")
+		return nil
+	default:
+		panic("Unknown connection type.")
+	}
+}
+
+func newLoginRequestManager(c *config.Config) oauth2.LoginRequestManager {
+	switch con := c.Context().Connection.(type) {
+	case *config.MemoryConnection:
+		return oauth2.NewLoginRequestMemoryManager()
+	case *config.SQLConnection:
+		return &oauth2.LoginRequestSQLManager{DB: con.GetDatabase()}
+	case *config.RedisConnection:
+		return &oauth2.LoginRequestRedisManager{DB: con.GetClient()}
+	case *config.PluginConnection:
+		// Login requests kept in memory could not be verified by the other instances.
+		c.GetLogger().Fatalln("Login requests are not supported by database plugins, use a SQL or Redis database instead")
+		return nil
+	default:
+		panic("Unknown connection type.")
+	}
+}
+
+func newPushedAuthorizationRequestManager(c *config.Config) oauth2.PushedAuthorizationRequestManager {
+	switch con := c.Context().Connection.(type) {
+	case *config.MemoryConnection:
+		return oauth2.NewPushedAuthorizationRequestMemoryManager()
+	case *config.SQLConnection:
+		return &oauth2.PushedAuthorizationRequestSQLManager{DB: con.GetDatabase()}
+	case *config.RedisConnection:
+		return &oauth2.PushedAuthorizationRequestRedisManager{DB: con.GetClient()}
+	case *config.PluginConnection:
+		// Requests pushed to one instance kept in memory could not be used at the other instances.
+		c.GetLogger().Fatalln("Pushed authorization requests are not supported by database plugins, use a SQL or Redis database instead")
+		return nil
+	default:
+		panic("Unknown connection type.")
+	}
+}
+
+func newConsentHanlder(c *config.Config, router *httprouter.Router, clients client.Manager, statistics oauth2.ConsentStatisticsManager, logins oauth2.LoginRequestManager) *oauth2.ConsentSessionHandler {
 	ctx := c.Context()
 	h := &oauth2.ConsentSessionHandler{
 		H: herodot.NewJSONWriter(c.GetLogger()),
 		W: ctx.Warden, M: ctx.ConsentManager,
 		ResourcePrefix: c.AccessControlResourcePrefix,
+		ParkLifespan:   c.GetConsentRequestParkLifespan(),
+		Issuer:         c.Issuer,
+		Clients:        clients,
+		Statistics:     statistics,
+		Logins:         logins,
 	}
 
 	h.SetRoutes(router)
 	return h
 }
+
+func runConsentRequestCleanup(c *config.Config, stop <-chan struct{}) {
+	interval := c.GetConsentRequestCleanupInterval()
+	if interval <= 0 {
+		c.GetLogger().Infoln("Consent request cleanup is disabled")
+		return
+	}
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-time.After(interval):
+		}
+
+		expired, abandoned, err := c.Context().ConsentManager.FlushExpiredConsentRequests(time.Now().UTC())
+		if err != nil {
+			c.GetLogger().WithError(err).Errorln("Could not remove expired consent requests")
+			continue
+		}
+
+		c.GetMetrics().ConsentStatistics.Record(expired, abandoned)
+		c.GetLogger().WithField("expired", expired).WithField("abandoned", abandoned).Debugln("Removed expired consent requests")
+	}
+}
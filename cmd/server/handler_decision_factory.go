@@ -0,0 +1,77 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/herodot"
+	"github.com/ory/hydra/config"
+	"github.com/ory/hydra/warden/decision"
+)
+
+func newDecisionManager(c *config.Config) decision.Manager {
+	switch con := c.Context().Connection.(type) {
+	case *config.MemoryConnection:
+		return decision.NewMemoryManager()
+	case *config.SQLConnection:
+		return &decision.SQLManager{DB: con.GetDatabase()}
+	case *config.RedisConnection:
+		// Decisions kept in memory would be lost on restart, which defeats access reviews.
+		c.GetLogger().Fatalln("WARDEN_DECISION_LOG is not supported by Redis, use a SQL database instead")
+		return nil
+	case *config.PluginConnection:
+		c.GetLogger().Fatalln("WARDEN_DECISION_LOG is not supported by database plugins, use a SQL database instead")
+		return nil
+	default:
+		panic("Unknown connection type.")
+	}
+}
+
+func runDecisionLogCleanup(c *config.Config, manager decision.Manager, stop <-chan struct{}) {
+	retention := c.GetWardenDecisionLogRetention()
+	if retention <= 0 {
+		c.GetLogger().Infoln("Warden decision log cleanup is disabled")
+		return
+	}
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-time.After(time.Hour):
+		}
+
+		deleted, err := manager.DeleteDecisionsBefore(time.Now().UTC().Add(-retention))
+		if err != nil {
+			c.GetLogger().WithError(err).Errorln("Could not remove old warden decisions")
+			continue
+		}
+
+		c.GetLogger().WithField("deleted", deleted).Debugln("Removed old warden decisions")
+	}
+}
+
+func newDecisionHandler(c *config.Config, router *httprouter.Router, manager decision.Manager) *decision.Handler {
+	h := &decision.Handler{
+		H:              herodot.NewJSONWriter(c.GetLogger()),
+		W:              c.Context().Warden,
+		Manager:        manager,
+		ResourcePrefix: c.AccessControlResourcePrefix,
+	}
+	h.SetRoutes(router)
+	return h
+}
@@ -0,0 +1,53 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/herodot"
+	"github.com/ory/hydra/config"
+	"github.com/ory/hydra/warden/elevation"
+)
+
+func newElevationManager(c *config.Config) elevation.Manager {
+	switch con := c.Context().Connection.(type) {
+	case *config.MemoryConnection:
+		return elevation.NewMemoryManager()
+	case *config.SQLConnection:
+		return &elevation.SQLManager{DB: con.GetDatabase()}
+	case *config.RedisConnection:
+		// Elevations kept in memory would only be known to the instance they were requested at and could not be
+		// revoked at the other instances.
+		c.GetLogger().Fatalln("WARDEN_ELEVATIONS is not supported by Redis, use a SQL database instead")
+		return nil
+	case *config.PluginConnection:
+		c.GetLogger().Fatalln("WARDEN_ELEVATIONS is not supported by database plugins, use a SQL database instead")
+		return nil
+	default:
+		panic("Unknown connection type.")
+	}
+}
+
+func newElevationHandler(c *config.Config, router *httprouter.Router, manager elevation.Manager) *elevation.Handler {
+	h := &elevation.Handler{
+		H:              herodot.NewJSONWriter(c.GetLogger()),
+		W:              c.Context().Warden,
+		Manager:        manager,
+		ResourcePrefix: c.AccessControlResourcePrefix,
+		MaxDuration:    c.GetWardenElevationMaxDuration(),
+	}
+	h.SetRoutes(router)
+	return h
+}
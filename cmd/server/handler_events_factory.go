@@ -0,0 +1,69 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+
+	"github.com/ory/hydra/config"
+	"github.com/ory/hydra/events"
+)
+
+// eventsEnabled returns true if a webhook or in-process subscribers receive events.
+func eventsEnabled(c *config.Config) bool {
+	return c.EventsWebhookURL != "" || c.Context().EventBus.HasSubscribers()
+}
+
+// newEventOutbox returns the SQL event outbox if event delivery is enabled, nil otherwise.
+func newEventOutbox(c *config.Config) *events.SQLOutbox {
+	if !eventsEnabled(c) {
+		return nil
+	}
+
+	con, ok := c.Context().Connection.(*config.SQLConnection)
+	if !ok {
+		return nil
+	}
+
+	return &events.SQLOutbox{DB: con.GetDatabase()}
+}
+
+func runEventDispatcher(c *config.Config, stop <-chan struct{}) {
+	if !eventsEnabled(c) {
+		return
+	}
+
+	outbox := newEventOutbox(c)
+	if outbox == nil {
+		c.GetLogger().Warnln("Events are enabled but only supported with SQL databases, no events will be delivered")
+		return
+	}
+
+	publishers := events.MultiPublisher{c.Context().EventBus}
+	if c.EventsWebhookURL != "" {
+		publishers = append(publishers, &events.WebhookPublisher{
+			URL:    c.EventsWebhookURL,
+			Secret: []byte(c.EventsWebhookSecret),
+			Client: &http.Client{Timeout: c.GetEventsWebhookTimeout()},
+		})
+	}
+
+	(&events.Dispatcher{
+		Outbox:    outbox,
+		Publisher: publishers,
+		Interval:  c.GetEventsDispatchInterval(),
+		L:         c.GetLogger(),
+	}).Run(stop)
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/ory/herodot"
 	"github.com/ory/hydra/config"
 	"github.com/ory/hydra/health"
+	"github.com/ory/hydra/pkg"
 )
 
 func newHealthHandler(c *config.Config, router *httprouter.Router) *health.Handler {
@@ -28,6 +29,34 @@ func newHealthHandler(c *config.Config, router *httprouter.Router) *health.Handl
 		W:              c.Context().Warden,
 		ResourcePrefix: c.AccessControlResourcePrefix,
 	}
+	if con, ok := c.Context().Connection.(*config.SQLConnection); ok {
+		h.SQLIndexes = new(health.SQLIndexStatus)
+		go checkSQLIndexes(c, con, h.SQLIndexes)
+	}
 	h.SetRoutes(router)
 	return h
 }
+
+// checkSQLIndexes logs a warning with a suggestion for every index which frequently executed queries rely on but
+// which is missing from the database.
+func checkSQLIndexes(c *config.Config, con *config.SQLConnection, status *health.SQLIndexStatus) {
+	db := con.GetDatabase()
+	missing, err := pkg.MissingSQLIndexes(db, config.SQLIndexes())
+	status.Update(missing, err)
+	if err != nil {
+		c.GetLogger().WithError(err).Warnln("Could not check the SQL indexes")
+		return
+	}
+
+	dialect, err := pkg.SQLDialect(db)
+	if err != nil {
+		dialect = pkg.SQLDialectPostgres
+	}
+
+	for _, index := range missing {
+		c.GetLogger().
+			WithField("table", index.Table).
+			WithField("query", index.Query).
+			Warnf("An index frequently executed queries rely on is missing, consider running: %s", index.CreateStatement(dialect))
+	}
+}
@@ -15,10 +15,14 @@
 package server
 
 import (
+	"time"
+
 	"github.com/julienschmidt/httprouter"
 	"github.com/ory/herodot"
+	"github.com/ory/hydra/client"
 	"github.com/ory/hydra/config"
 	"github.com/ory/hydra/jwk"
+	"github.com/ory/hydra/pkg"
 )
 
 func injectJWKManager(c *config.Config) {
@@ -29,11 +33,29 @@ func injectJWKManager(c *config.Config) {
 		ctx.KeyManager = &jwk.MemoryManager{}
 		break
 	case *config.SQLConnection:
-		ctx.KeyManager = &jwk.SQLManager{
-			DB: con.GetDatabase(),
-			Cipher: &jwk.AEAD{
-				Key: c.GetSystemSecret(),
-			},
+		cipher, err := jwk.NewCipher(c.JWKCipherURL, c.GetDerivedSecret(pkg.SecretPurposeJWK))
+		if err != nil {
+			c.GetLogger().Fatalf("Could not create JSON Web Key cipher: %s", err)
+		}
+
+		m := &jwk.SQLManager{
+			DB:     con.GetDatabase(),
+			Cipher: cipher,
+		}
+		if outbox := newEventOutbox(c); outbox != nil {
+			m.Outbox = outbox
+		}
+		ctx.KeyManager = m
+		break
+	case *config.RedisConnection:
+		cipher, err := jwk.NewCipher(c.JWKCipherURL, c.GetDerivedSecret(pkg.SecretPurposeJWK))
+		if err != nil {
+			c.GetLogger().Fatalf("Could not create JSON Web Key cipher: %s", err)
+		}
+
+		ctx.KeyManager = &jwk.RedisManager{
+			DB:     con.GetClient(),
+			Cipher: cipher,
 		}
 		break
 	case *config.PluginConnection:
@@ -46,16 +68,51 @@ func injectJWKManager(c *config.Config) {
 	default:
 		c.GetLogger().Fatalf("Unknown connection type.")
 	}
+
+	if ttl := c.GetJWKCacheTTL(); ttl > 0 {
+		cached := jwk.NewCachedManager(ctx.KeyManager, ttl)
+		cached.LookupObserved = c.GetMetrics().OperationStatistics.RecordJWKCacheLookup
+		ctx.KeyManager = cached
+	}
 }
 
-func newJWKHandler(c *config.Config, router *httprouter.Router) *jwk.Handler {
+func newJWKHandler(c *config.Config, router *httprouter.Router, clients client.Manager) *jwk.Handler {
 	ctx := c.Context()
 	h := &jwk.Handler{
-		H:              herodot.NewJSONWriter(c.GetLogger()),
-		W:              ctx.Warden,
-		Manager:        ctx.KeyManager,
-		ResourcePrefix: c.AccessControlResourcePrefix,
+		H:               herodot.NewJSONWriter(c.GetLogger()),
+		W:               ctx.Warden,
+		Manager:         ctx.KeyManager,
+		ResourcePrefix:  c.AccessControlResourcePrefix,
+		RSAKeyLength:    c.GetRSAKeyLength(),
+		WellKnownMaxAge: c.GetJWKSCacheMaxAge(),
+		Clients:         clients,
+		Policies:        ctx.LadonManager,
+		KeyPolicy:       c.GetJWKKeyPolicy(),
 	}
 	h.SetRoutes(router)
 	return h
 }
+
+func runJWKCleanup(c *config.Config, stop <-chan struct{}) {
+	interval := c.GetJWKCleanupInterval()
+	if interval <= 0 {
+		c.GetLogger().Infoln("JSON Web Key cleanup is disabled")
+		return
+	}
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-time.After(interval):
+		}
+
+		deleted, err := c.Context().KeyManager.DeleteExpiredKeys(time.Now().UTC())
+		if err != nil {
+			c.GetLogger().WithError(err).Errorln("Could not remove expired JSON Web Keys")
+			continue
+		}
+
+		c.GetLogger().WithField("deleted", deleted).Debugln("Removed expired JSON Web Keys")
+	}
+}
@@ -15,23 +15,34 @@
 package server
 
 import (
+	"context"
+	"crypto"
 	"fmt"
+	"net/http"
 	"net/url"
+	"time"
 
 	"github.com/gorilla/sessions"
 	"github.com/julienschmidt/httprouter"
 	"github.com/ory/fosite"
 	"github.com/ory/fosite/compose"
+	foauth2 "github.com/ory/fosite/handler/oauth2"
+	"github.com/ory/fosite/handler/openid"
 	"github.com/ory/herodot"
+	"github.com/ory/hydra/audit"
 	"github.com/ory/hydra/client"
 	"github.com/ory/hydra/config"
+	"github.com/ory/hydra/firewall"
+	"github.com/ory/hydra/i18n"
 	"github.com/ory/hydra/jwk"
 	"github.com/ory/hydra/oauth2"
 	"github.com/ory/hydra/pkg"
+	"github.com/ory/hydra/tracing"
 	"github.com/ory/hydra/warden"
+	"github.com/square/go-jose"
 )
 
-func injectFositeStore(c *config.Config, clients client.Manager) {
+func injectFositeStore(c *config.Config, clients client.Manager, history oauth2.TokenHistoryManager) {
 	var ctx = c.Context()
 	var store pkg.FositeStorer
 
@@ -40,7 +51,14 @@ func injectFositeStore(c *config.Config, clients client.Manager) {
 		store = oauth2.NewFositeMemoryStore(clients, c.GetAccessTokenLifespan())
 		break
 	case *config.SQLConnection:
-		store = oauth2.NewFositeSQLStore(clients, con.GetDatabase(), c.GetLogger(), c.GetAccessTokenLifespan())
+		sqlStore := oauth2.NewFositeSQLStore(clients, con.GetDatabase(), c.GetLogger(), c.GetAccessTokenLifespan())
+		if outbox := newEventOutbox(c); outbox != nil {
+			sqlStore.Outbox = outbox
+		}
+		store = sqlStore
+		break
+	case *config.RedisConnection:
+		store = oauth2.NewFositeRedisStore(clients, con.GetClient(), c.GetLogger(), c.GetAccessTokenLifespan())
 		break
 	case *config.PluginConnection:
 		var err error
@@ -52,6 +70,14 @@ func injectFositeStore(c *config.Config, clients client.Manager) {
 		panic("Unknown connection type.")
 	}
 
+	if history != nil {
+		store = &oauth2.TokenHistoryStorage{
+			FositeStorer:        store,
+			History:             history,
+			AccessTokenLifespan: c.GetAccessTokenLifespan(),
+		}
+	}
+
 	ctx.FositeStore = store
 }
 
@@ -59,12 +85,13 @@ func newOAuth2Provider(c *config.Config) (fosite.OAuth2Provider, string) {
 	var ctx = c.Context()
 	var store = ctx.FositeStore
 
-	privateKey, err := createOrGetJWK(c, oauth2.OpenIDConnectKeyName, "private")
+	alg := c.GetIDTokenSigningAlgorithm()
+	privateKey, err := createOrGetJWKForAlgorithm(c, oauth2.OpenIDConnectKeyName, "private", alg)
 	if err != nil {
 		c.GetLogger().WithError(err).Fatalf(`Could not fetch private signing key for OpenID Connect - did you forget to run "hydra migrate sql" or forget to set the SYSTEM_SECRET?`)
 	}
 
-	publicKey, err := createOrGetJWK(c, oauth2.OpenIDConnectKeyName, "public")
+	publicKey, err := createOrGetJWKForAlgorithm(c, oauth2.OpenIDConnectKeyName, "public", alg)
 	if err != nil {
 		c.GetLogger().WithError(err).Fatalf(`Could not fetch public signing key for OpenID Connect - did you forget to run "hydra migrate sql" or forget to set the SYSTEM_SECRET?`)
 	}
@@ -80,14 +107,67 @@ func newOAuth2Provider(c *config.Config) (fosite.OAuth2Provider, string) {
 		EnablePKCEPlainChallengeMethod: false,
 	}
 
+	var signer crypto.Signer
+	var idTokenStrategy openid.OpenIDConnectTokenStrategy
+	if alg == "EdDSA" {
+		signer = jwk.MustEd25519Private(privateKey)
+		idTokenStrategy = &oauth2.EdDSAOpenIDConnectStrategy{
+			PrivateKey: jwk.MustEd25519Private(privateKey),
+			Expiry:     c.GetIDTokenLifespan(),
+			Issuer:     c.Issuer,
+		}
+	} else {
+		signer = jwk.MustRSAPrivate(privateKey)
+		idTokenStrategy = compose.NewOpenIDConnectStrategy(jwk.MustRSAPrivate(privateKey))
+	}
+
+	var coreStrategy foauth2.CoreStrategy = compose.NewOAuth2HMACStrategy(fc, c.GetDerivedSecret(pkg.SecretPurposeTokenHMAC))
+	if c.GetAccessTokenPrefix() != "" || c.GetRefreshTokenPrefix() != "" {
+		coreStrategy = &oauth2.PrefixedTokenStrategy{
+			CoreStrategy:       coreStrategy,
+			AccessTokenPrefix:  c.GetAccessTokenPrefix(),
+			RefreshTokenPrefix: c.GetRefreshTokenPrefix(),
+		}
+
+		// The token history endpoint computes token signatures using the context's strategy.
+		ctx.FositeStrategy = coreStrategy
+	}
+
+	if c.GetAccessTokenStrategy() == oauth2.AccessTokenStrategyJWT {
+		coreStrategy = &oauth2.JWTAccessTokenStrategy{
+			CoreStrategy:        coreStrategy,
+			PrivateKey:          signer,
+			Algorithm:           jose.SignatureAlgorithm(alg),
+			KeyID:               publicKey.KeyID,
+			Issuer:              c.Issuer,
+			AccessTokenLifespan: c.GetAccessTokenLifespan(),
+		}
+
+		// The token history endpoint computes token signatures using the context's strategy.
+		ctx.FositeStrategy = coreStrategy
+	}
+
+	if c.TracingProvider != "" {
+		coreStrategy = &tracing.CoreStrategy{CoreStrategy: coreStrategy}
+		idTokenStrategy = &tracing.OpenIDConnectTokenStrategy{OpenIDConnectTokenStrategy: idTokenStrategy}
+	}
+
 	return compose.Compose(
 		fc,
-		store,
+		&oauth2.TLSClientAuthStorage{FositeStorer: store},
 		&compose.CommonStrategy{
-			CoreStrategy:               compose.NewOAuth2HMACStrategy(fc, c.GetSystemSecret()),
-			OpenIDConnectTokenStrategy: compose.NewOpenIDConnectStrategy(jwk.MustRSAPrivate(privateKey)),
+			CoreStrategy:               coreStrategy,
+			OpenIDConnectTokenStrategy: idTokenStrategy,
+		},
+		&oauth2.TLSClientAuthHasher{Hasher: ctx.Hasher},
+		// Rejects flows of clients which must use PKCE before an authorize code is issued.
+		func(config *compose.Config, storage interface{}, strategy interface{}) interface{} {
+			return &oauth2.PKCEEnforcementHandler{RequireForPublicClients: c.OAuth2RequirePKCEForPublic}
+		},
+		// Applies the token lifespans configured by the client before any tokens are issued.
+		func(config *compose.Config, storage interface{}, strategy interface{}) interface{} {
+			return &oauth2.ClientLifespanHandler{}
 		},
-		nil,
 		compose.OAuth2AuthorizeExplicitFactory,
 		compose.OAuth2AuthorizeImplicitFactory,
 		compose.OAuth2ClientCredentialsGrantFactory,
@@ -98,10 +178,23 @@ func newOAuth2Provider(c *config.Config) (fosite.OAuth2Provider, string) {
 		compose.OpenIDConnectImplicitFactory,
 		compose.OAuth2TokenRevocationFactory,
 		warden.OAuth2TokenIntrospectionFactory,
+		func(config *compose.Config, storage interface{}, strategy interface{}) interface{} {
+			return &oauth2.TokenExchangeHandler{
+				CoreStrategy:        strategy.(foauth2.CoreStrategy),
+				CoreStorage:         storage.(foauth2.CoreStorage),
+				ScopeStrategy:       config.GetScopeStrategy(),
+				AccessTokenLifespan: config.GetAccessTokenLifespan(),
+				ResourcePrefix:      c.AccessControlResourcePrefix,
+				// The warden is created after the provider, it is looked up when a token is exchanged.
+				Allowed: func(ctx context.Context, r *firewall.AccessRequest) error {
+					return c.Context().Warden.IsAllowed(ctx, r)
+				},
+			}
+		},
 	), publicKey.KeyID
 }
 
-func newOAuth2Handler(c *config.Config, router *httprouter.Router, cm oauth2.ConsentRequestManager, o fosite.OAuth2Provider, idTokenKeyID string) *oauth2.Handler {
+func newOAuth2Handler(c *config.Config, router *httprouter.Router, cm oauth2.ConsentRequestManager, o fosite.OAuth2Provider, idTokenKeyID string, history oauth2.TokenHistoryManager, grants oauth2.ConsentGrantManager, remembered oauth2.RememberedConsentManager, loginSessions oauth2.LoginSessionManager, logins oauth2.LoginRequestManager, auditSink audit.Sink) *oauth2.Handler {
 	if c.ConsentURL == "" {
 		proto := "https"
 		if c.ForceHTTP {
@@ -117,6 +210,9 @@ func newOAuth2Handler(c *config.Config, router *httprouter.Router, cm oauth2.Con
 	consentURL, err := url.Parse(c.ConsentURL)
 	pkg.Must(err, "Could not parse consent url %s.", c.ConsentURL)
 
+	errorURL, err := url.Parse(c.ErrorURL)
+	pkg.Must(err, "Could not parse error url %s.", c.ErrorURL)
+
 	handler := &oauth2.Handler{
 		ScopesSupported:  c.OpenIDDiscoveryScopesSupported,
 		UserinfoEndpoint: c.OpenIDDiscoveryUserinfoEndpoint,
@@ -124,24 +220,154 @@ func newOAuth2Handler(c *config.Config, router *httprouter.Router, cm oauth2.Con
 		ForcedHTTP:       c.ForceHTTP,
 		OAuth2:           o,
 		ScopeStrategy:    c.GetScopeStrategy(),
+		ResourceScopes:   newResourceScopes(c),
 		Consent: &oauth2.DefaultConsentStrategy{
 			Issuer:                   c.Issuer,
 			ConsentManager:           c.Context().ConsentManager,
 			DefaultChallengeLifespan: c.GetChallengeTokenLifespan(),
 			DefaultIDTokenLifespan:   c.GetIDTokenLifespan(),
-			KeyID: idTokenKeyID,
+			Remembered:               remembered,
+			LoginSessions:            loginSessions,
+			KeyID:                    idTokenKeyID,
+		},
+		Storage:                   c.Context().FositeStore,
+		ConsentManager:            cm,
+		TokenHistory:              history,
+		ConsentGrants:             grants,
+		RememberedConsents:        remembered,
+		TokenStrategy:             c.Context().FositeStrategy,
+		ConsentURL:                *consentURL,
+		ErrorURL:                  *errorURL,
+		H:                         herodot.NewJSONWriter(c.GetLogger()),
+		AccessTokenLifespan:       c.GetAccessTokenLifespan(),
+		CookieStore:               sessions.NewCookieStore(c.GetCookieSecret()),
+		Issuer:                    c.Issuer,
+		IssuersByHost:             c.GetIssuersByHost(),
+		EmbedOrigins:              c.GetOAuth2EmbedAllowedOrigins(),
+		ClientSecretHasher:        c.Context().Hasher,
+		L:                         c.GetLogger(),
+		W:                         c.Context().Warden,
+		ResourcePrefix:            c.AccessControlResourcePrefix,
+		IDTokenSigningAlgorithm:   c.GetIDTokenSigningAlgorithm(),
+		TokenIssued:               c.GetMetrics().OperationStatistics.RecordTokenIssued,
+		IntrospectionObserved:     c.GetMetrics().OperationStatistics.RecordIntrospection,
+		ClientAuthFailed:          c.GetMetrics().OperationStatistics.RecordClientAuthFailure,
+		Logout:                    newLogout(c, loginSessions),
+		DynamicClientRegistration: c.OAuth2DynamicClientRegistration != "",
+
+		PushedAuthorizationRequests:        newPushedAuthorizationRequestManager(c),
+		PushedAuthorizationRequestLifespan: c.GetPushedAuthorizationRequestLifespan(),
+
+		IntrospectionCacheControlMaxAge:   c.GetOAuth2IntrospectionCacheControlMaxAge(),
+		IntrospectionCacheControlSubjects: c.GetOAuth2IntrospectionCacheControlSubjects(),
+
+		UserinfoSigner: newUserinfoSigner(c),
+		ResponseEncrypter: &oauth2.ResponseEncrypter{
+			HTTPClient:     &http.Client{Timeout: time.Second * 10},
+			KeySetLifespan: time.Minute * 5,
 		},
-		Storage:             c.Context().FositeStore,
-		ConsentURL:          *consentURL,
-		H:                   herodot.NewJSONWriter(c.GetLogger()),
-		AccessTokenLifespan: c.GetAccessTokenLifespan(),
-		CookieStore:         sessions.NewCookieStore(c.GetCookieSecret()),
-		Issuer:              c.Issuer,
-		L:                   c.GetLogger(),
-		W:                   c.Context().Warden,
-		ResourcePrefix:      c.AccessControlResourcePrefix,
 	}
 
+	if c.LoginURL != "" {
+		loginURL, err := url.Parse(c.LoginURL)
+		pkg.Must(err, "Could not parse login url %s.", c.LoginURL)
+		handler.LoginURL = *loginURL
+		handler.Logins = logins
+		handler.LoginSessions = loginSessions
+		handler.LoginChallengeLifespan = c.GetChallengeTokenLifespan()
+	}
+
+	if c.ClaimsHookURL != "" {
+		handler.ClaimsHook = &oauth2.WebhookClaimsHook{
+			URL:    c.ClaimsHookURL,
+			Secret: []byte(c.ClaimsHookSecret),
+			Client: &http.Client{Timeout: c.GetClaimsHookTimeout()},
+		}
+	}
+
+	if c.ErrorCatalogDir != "" {
+		catalog, err := i18n.LoadCatalog(c.ErrorCatalogDir)
+		if err != nil {
+			c.GetLogger().WithError(err).Fatalf("Could not load error catalog from %s", c.ErrorCatalogDir)
+		}
+		handler.ErrorCatalog = catalog
+	}
+
+	if c.ClientAuthMaxFailures > 0 {
+		handler.ClientAuthGuard = &oauth2.ClientAuthGuard{
+			MaxFailures:    c.ClientAuthMaxFailures,
+			Window:         c.GetClientAuthFailureWindow(),
+			Lockout:        c.GetClientAuthLockout(),
+			TrustedProxies: trustedProxies(c),
+		}
+	}
+
+	if auditSink != nil {
+		handler.ClientLockedOut = func(r *http.Request, clientID string) {
+			e := audit.NewEntry(r, audit.ActionLockout)
+			e.ClientID = clientID
+			e.Status = http.StatusUnauthorized
+			if err := auditSink.Write(e); err != nil {
+				c.GetLogger().WithError(err).WithField("entry", e).Errorln("Could not write audit log entry")
+			}
+		}
+	}
+
+	handler.RevocationFilter = newRevocationFilter(c, history)
 	handler.SetRoutes(router)
 	return handler
 }
+
+// newResourceScopes returns the scopes of the resources of OAUTH2_RESOURCE_SCOPES.
+func newResourceScopes(c *config.Config) oauth2.ResourceScopes {
+	scopes, err := oauth2.ParseResourceScopes(c.OAuth2ResourceScopes)
+	if err != nil {
+		c.GetLogger().Fatalf("Could not parse OAUTH2_RESOURCE_SCOPES: %s", err)
+	}
+	return scopes
+}
+
+func newLogout(c *config.Config, sessions oauth2.LoginSessionManager) *oauth2.Logout {
+	signer, alg, keyID := openIDConnectSigningKey(c)
+	return &oauth2.Logout{
+		Sessions:   sessions,
+		Clients:    c.Context().FositeStore,
+		PrivateKey: signer,
+		Algorithm:  alg,
+		KeyID:      keyID,
+		HTTPClient: &http.Client{Timeout: time.Second * 10},
+		L:          c.GetLogger(),
+	}
+}
+
+func newUserinfoSigner(c *config.Config) *oauth2.UserinfoSigner {
+	signer, alg, keyID := openIDConnectSigningKey(c)
+	return &oauth2.UserinfoSigner{
+		PrivateKey: signer,
+		Algorithm:  alg,
+		KeyID:      keyID,
+	}
+}
+
+// openIDConnectSigningKey returns the private OpenID Connect key used to sign ID tokens, its algorithm and the key id
+// of its public key.
+func openIDConnectSigningKey(c *config.Config) (crypto.Signer, jose.SignatureAlgorithm, string) {
+	alg := c.GetIDTokenSigningAlgorithm()
+	privateKey, err := createOrGetJWKForAlgorithm(c, oauth2.OpenIDConnectKeyName, "private", alg)
+	if err != nil {
+		c.GetLogger().WithError(err).Fatalf("Could not fetch private OpenID Connect signing key")
+	}
+
+	publicKey, err := createOrGetJWKForAlgorithm(c, oauth2.OpenIDConnectKeyName, "public", alg)
+	if err != nil {
+		c.GetLogger().WithError(err).Fatalf("Could not fetch public OpenID Connect signing key")
+	}
+
+	var signer crypto.Signer
+	if alg == "EdDSA" {
+		signer = jwk.MustEd25519Private(privateKey)
+	} else {
+		signer = jwk.MustRSAPrivate(privateKey)
+	}
+	return signer, jose.SignatureAlgorithm(alg), publicKey.KeyID
+}
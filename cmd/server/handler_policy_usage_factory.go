@@ -0,0 +1,51 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/herodot"
+	"github.com/ory/hydra/config"
+	"github.com/ory/hydra/warden/usage"
+)
+
+func newPolicyUsageManager(c *config.Config) usage.Manager {
+	switch con := c.Context().Connection.(type) {
+	case *config.MemoryConnection:
+		return usage.NewMemoryManager()
+	case *config.SQLConnection:
+		return &usage.SQLManager{DB: con.GetDatabase()}
+	case *config.RedisConnection:
+		c.GetLogger().Warnln("Policy usage tracking is not supported by Redis, storing policy usage in memory")
+		return usage.NewMemoryManager()
+	case *config.PluginConnection:
+		c.GetLogger().Warnln("Policy usage tracking is not supported by database plugins, storing policy usage in memory")
+		return usage.NewMemoryManager()
+	default:
+		panic("Unknown connection type.")
+	}
+}
+
+func newPolicyUsageHandler(c *config.Config, router *httprouter.Router, manager usage.Manager) *usage.Handler {
+	h := &usage.Handler{
+		H:              herodot.NewJSONWriter(c.GetLogger()),
+		W:              c.Context().Warden,
+		Manager:        manager,
+		Policies:       c.Context().LadonManager,
+		ResourcePrefix: c.AccessControlResourcePrefix,
+	}
+	h.SetRoutes(router)
+	return h
+}
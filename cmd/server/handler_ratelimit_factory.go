@@ -0,0 +1,128 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/ory/fosite"
+	"github.com/ory/herodot"
+	"github.com/ory/hydra/client"
+	"github.com/ory/hydra/config"
+	"github.com/ory/hydra/jwk"
+	"github.com/ory/hydra/oauth2"
+	"github.com/ory/hydra/pkg"
+	"github.com/ory/hydra/policy"
+	"github.com/ory/hydra/ratelimit"
+	"github.com/ory/hydra/warden/group"
+)
+
+// newRateLimitMiddleware returns the rate limiting middleware if any RATE_LIMIT_* limit is set, nil otherwise.
+func newRateLimitMiddleware(c *config.Config, provider fosite.OAuth2Provider, h herodot.Writer) *ratelimit.Middleware {
+	rules := []ratelimit.Rule{
+		{
+			Name:      "token",
+			Prefixes:  []string{oauth2.TokenPath},
+			PerClient: c.GetRateLimit("RATE_LIMIT_TOKEN_PER_CLIENT", c.RateLimitTokenPerClient),
+			PerIP:     c.GetRateLimit("RATE_LIMIT_TOKEN_PER_IP", c.RateLimitTokenPerIP),
+		},
+		{
+			Name:      "introspect",
+			Prefixes:  []string{oauth2.IntrospectPath},
+			PerClient: c.GetRateLimit("RATE_LIMIT_INTROSPECT_PER_CLIENT", c.RateLimitIntrospectPerClient),
+			PerIP:     c.GetRateLimit("RATE_LIMIT_INTROSPECT_PER_IP", c.RateLimitIntrospectPerIP),
+		},
+		{
+			Name: "admin",
+			Prefixes: []string{
+				client.ClientsHandlerPath,
+				jwk.KeyHandlerPath,
+				jwk.BackupHandlerPath,
+				policy.PolicyHandlerPath,
+				group.GroupsHandlerPath,
+			},
+			PerClient: c.GetRateLimit("RATE_LIMIT_ADMIN_PER_CLIENT", c.RateLimitAdminPerClient),
+			PerIP:     c.GetRateLimit("RATE_LIMIT_ADMIN_PER_IP", c.RateLimitAdminPerIP),
+		},
+	}
+
+	var enabled []ratelimit.Rule
+	for _, rule := range rules {
+		if rule.PerClient.Enabled() || rule.PerIP.Enabled() {
+			enabled = append(enabled, rule)
+		}
+	}
+	if len(enabled) == 0 {
+		return nil
+	}
+
+	var limiter ratelimit.Limiter = new(ratelimit.MemoryLimiter)
+	if c.RateLimitRedisURL != "" {
+		u, err := url.Parse(c.RateLimitRedisURL)
+		if err != nil {
+			c.GetLogger().Fatalf("Could not parse RATE_LIMIT_REDIS_URL: %s", err)
+		}
+		limiter = &ratelimit.RedisLimiter{DB: (&config.RedisConnection{URL: u, L: c.GetLogger()}).GetClient()}
+	}
+
+	return &ratelimit.Middleware{
+		Limiter:        limiter,
+		Rules:          enabled,
+		H:              h,
+		L:              c.GetLogger(),
+		TrustedProxies: trustedProxies(c),
+		ClientID: func(r *http.Request) string {
+			if id, _, ok := r.BasicAuth(); ok {
+				if id, err := url.QueryUnescape(id); err == nil {
+					return id
+				}
+			}
+
+			if r.Method == "POST" && strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+				if err := r.ParseForm(); err == nil && r.PostForm.Get("client_id") != "" {
+					return r.PostForm.Get("client_id")
+				}
+			}
+
+			token := fosite.AccessTokenFromRequest(r)
+			if token == "" {
+				return ""
+			}
+
+			auth, err := provider.IntrospectToken(context.Background(), token, fosite.AccessToken, oauth2.NewSession(""))
+			if err != nil {
+				return ""
+			}
+			return auth.GetClient().GetID()
+		},
+	}
+}
+
+// trustedProxies returns the networks of HTTPS_ALLOW_TERMINATION_FROM, whose X-Forwarded-For header is trusted.
+func trustedProxies(c *config.Config) []*net.IPNet {
+	var proxies []*net.IPNet
+	for _, cidr := range pkg.SplitNonEmpty(c.AllowTLSTermination, ",") {
+		_, network, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			c.GetLogger().Fatalf("Could not parse HTTPS_ALLOW_TERMINATION_FROM: %s", err)
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies
+}
@@ -15,10 +15,13 @@
 package server
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/ory/hydra/config"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestStart(t *testing.T) {
@@ -30,3 +33,17 @@ func TestStart(t *testing.T) {
 	}
 	h.registerRoutes(router)
 }
+
+func TestNewServer(t *testing.T) {
+	s := NewServer(&config.Config{
+		DatabaseURL: "memory",
+		ForceHTTP:   true,
+	})
+	assert.NotNil(t, s.Clients())
+	assert.NotNil(t, s.Keys())
+	assert.NotNil(t, s.Policies())
+
+	res := httptest.NewRecorder()
+	s.ServeHTTP(res, httptest.NewRequest("GET", "/health/status", nil))
+	assert.Equal(t, http.StatusOK, res.Code)
+}
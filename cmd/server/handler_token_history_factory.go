@@ -0,0 +1,88 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"crypto"
+
+	"github.com/ory/hydra/config"
+	"github.com/ory/hydra/jwk"
+	"github.com/ory/hydra/oauth2"
+	"github.com/square/go-jose"
+)
+
+func newTokenHistoryManager(c *config.Config) oauth2.TokenHistoryManager {
+	switch con := c.Context().Connection.(type) {
+	case *config.MemoryConnection:
+		return oauth2.NewTokenHistoryMemoryManager()
+	case *config.SQLConnection:
+		return &oauth2.TokenHistorySQLManager{DB: con.GetDatabase()}
+	case *config.RedisConnection:
+		// A history kept in memory would be lost on restart and differ between instances.
+		c.GetLogger().Fatalln("OAUTH2_TOKEN_HISTORY is not supported by Redis, use a SQL database instead")
+		return nil
+	case *config.PluginConnection:
+		c.GetLogger().Fatalln("OAUTH2_TOKEN_HISTORY is not supported by database plugins, use a SQL database instead")
+		return nil
+	default:
+		panic("Unknown connection type.")
+	}
+}
+
+// newRevocationFilter returns the filter of revoked access tokens, or nil if the token history or the filter are
+// disabled. The filter is regenerated by Server.Start.
+func newRevocationFilter(c *config.Config, history oauth2.TokenHistoryManager) *oauth2.RevocationFilter {
+	interval := c.GetRevocationFilterInterval()
+	if history == nil || interval <= 0 {
+		return nil
+	}
+
+	// Edge validators trust the filter, so it must be generated from a history that all instances share and that
+	// survives restarts. Otherwise tokens revoked by another instance or before a restart would pass.
+	if _, ok := c.Context().Connection.(*config.SQLConnection); !ok {
+		c.GetLogger().Fatalln("The revocation filter requires a SQL database, set REVOCATION_FILTER_INTERVAL=0 to disable it")
+		return nil
+	}
+
+	alg := c.GetIDTokenSigningAlgorithm()
+	privateKey, err := createOrGetJWKForAlgorithm(c, oauth2.OpenIDConnectKeyName, "private", alg)
+	if err != nil {
+		c.GetLogger().WithError(err).Fatalf("Could not fetch private signing key for the revocation filter")
+	}
+
+	publicKey, err := createOrGetJWKForAlgorithm(c, oauth2.OpenIDConnectKeyName, "public", alg)
+	if err != nil {
+		c.GetLogger().WithError(err).Fatalf("Could not fetch public signing key for the revocation filter")
+	}
+
+	var signer crypto.Signer
+	if alg == "EdDSA" {
+		signer = jwk.MustEd25519Private(privateKey)
+	} else {
+		signer = jwk.MustRSAPrivate(privateKey)
+	}
+
+	f := &oauth2.RevocationFilter{
+		History:           history,
+		PrivateKey:        signer,
+		Algorithm:         jose.SignatureAlgorithm(alg),
+		KeyID:             publicKey.KeyID,
+		Issuer:            c.Issuer,
+		Interval:          interval,
+		FalsePositiveRate: c.GetRevocationFilterFalsePositiveRate(),
+		L:                 c.GetLogger(),
+	}
+	return f
+}
@@ -0,0 +1,43 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"github.com/opentracing/opentracing-go"
+	"github.com/ory/hydra/config"
+	"github.com/ory/hydra/tracing"
+)
+
+// newTracingMiddleware sets up the global tracer if TRACING_PROVIDER is set and returns the middleware which starts a
+// span for every request. It returns nil if tracing is disabled.
+func newTracingMiddleware(c *config.Config) *tracing.Middleware {
+	if c.TracingProvider == "" {
+		return nil
+	}
+
+	tracer, _, err := tracing.New(&tracing.Config{
+		Provider:           c.TracingProvider,
+		ServiceName:        c.TracingServiceName,
+		JaegerAgentAddress: c.TracingJaegerAgentAddress,
+		SampleRate:         c.GetTracingSampleRate(),
+	})
+	if err != nil {
+		c.GetLogger().Fatalf("Could not set up tracing: %s", err)
+	}
+
+	opentracing.SetGlobalTracer(tracer)
+	c.GetLogger().Infof("Reporting traces to %s agent at %s", c.TracingProvider, c.TracingJaegerAgentAddress)
+	return &tracing.Middleware{Tracer: tracer}
+}
@@ -0,0 +1,183 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/ory/hydra/config"
+	"github.com/ory/hydra/pkg"
+	"github.com/ory/hydra/warden"
+	"github.com/ory/hydra/warden/decision"
+	"github.com/ory/hydra/warden/usage"
+	"github.com/ory/ladon"
+)
+
+// newPolicyDecisionPoint returns the external authorizer if WARDEN_AUTHORIZER_URL is set, ladon otherwise. Decisions
+// and policy usage are only recorded by ladon.
+func newPolicyDecisionPoint(c *config.Config, decisions *decision.Recorder, policyUsage *usage.Tracker) ladon.Warden {
+	if c.WardenAuthorizerURL != "" {
+		u, err := url.Parse(c.WardenAuthorizerURL)
+		if err != nil {
+			c.GetLogger().Fatalf("Could not parse WARDEN_AUTHORIZER_URL: %s", err)
+		} else if u.Scheme != "http" && u.Scheme != "https" {
+			c.GetLogger().Fatalf("WARDEN_AUTHORIZER_URL must be a http or https URL but got scheme %s", u.Scheme)
+		}
+
+		c.GetLogger().Infof("Access requests are decided by the external authorizer at %s", u.Host)
+		return &warden.HTTPAuthorizer{
+			URL:    c.WardenAuthorizerURL,
+			Client: &http.Client{Timeout: c.GetWardenAuthorizerTimeout()},
+		}
+	}
+
+	lw := &ladon.Ladon{
+		Manager: c.Context().LadonManager,
+	}
+	var loggers auditLoggers
+	if decisions != nil {
+		loggers = append(loggers, decisions)
+	}
+	if policyUsage != nil {
+		loggers = append(loggers, policyUsage)
+	}
+
+	switch len(loggers) {
+	case 0:
+	case 1:
+		lw.AuditLogger = loggers[0]
+	default:
+		lw.AuditLogger = loggers
+	}
+	return lw
+}
+
+// auditLoggers passes the decisions of ladon to several audit loggers.
+type auditLoggers []ladon.AuditLogger
+
+func (l auditLoggers) LogRejectedAccessRequest(request *ladon.Request, pool ladon.Policies, deciders ladon.Policies) {
+	for _, logger := range l {
+		logger.LogRejectedAccessRequest(request, pool, deciders)
+	}
+}
+
+func (l auditLoggers) LogGrantedAccessRequest(request *ladon.Request, pool ladon.Policies, deciders ladon.Policies) {
+	for _, logger := range l {
+		logger.LogGrantedAccessRequest(request, pool, deciders)
+	}
+}
+
+// newActionGroups returns the action groups of WARDEN_ACTION_GROUPS.
+func newActionGroups(c *config.Config) warden.ActionGroups {
+	groups, err := warden.ParseActionGroups(c.WardenActionGroups)
+	if err != nil {
+		c.GetLogger().Fatalf("Could not parse WARDEN_ACTION_GROUPS: %s", err)
+	}
+	return groups
+}
+
+// newPeerIssuers returns the peers of WARDEN_TRUSTED_PEERS, or nil if none are configured.
+func newPeerIssuers(c *config.Config) *warden.PeerIssuers {
+	issuers := pkg.SplitNonEmpty(c.WardenTrustedPeers, ",")
+	if len(issuers) == 0 {
+		return nil
+	}
+
+	for _, issuer := range issuers {
+		if u, err := url.Parse(issuer); err != nil {
+			c.GetLogger().Fatalf("Could not parse WARDEN_TRUSTED_PEERS: %s", err)
+		} else if u.Scheme != "http" && u.Scheme != "https" {
+			c.GetLogger().Fatalf("WARDEN_TRUSTED_PEERS must contain http or https URLs but got scheme %s", u.Scheme)
+		} else if issuer == c.Issuer {
+			c.GetLogger().Fatalf("WARDEN_TRUSTED_PEERS must not contain the issuer of this instance %s", issuer)
+		}
+	}
+
+	if c.WardenTrustedPeerAudience == "" {
+		c.GetLogger().Fatalln("WARDEN_TRUSTED_PEERS is set but WARDEN_TRUSTED_PEER_AUDIENCE is empty")
+	}
+
+	scopes, err := warden.ParsePeerScopes(c.WardenTrustedPeerScopes)
+	if err != nil {
+		c.GetLogger().Fatalf("Could not parse WARDEN_TRUSTED_PEER_SCOPES: %s", err)
+	} else if len(scopes) == 0 {
+		c.GetLogger().Warnln("WARDEN_TRUSTED_PEERS is set but WARDEN_TRUSTED_PEER_SCOPES is empty, tokens of peers are granted no scopes")
+	}
+
+	c.GetLogger().Infof("Accepting access tokens issued by %d trusted peers", len(issuers))
+	return warden.NewPeerIssuers(issuers, c.WardenTrustedPeerAudience, scopes, c.GetScopeStrategy(), &http.Client{Timeout: time.Second * 10}, c.GetWardenTrustedPeerKeysTTL())
+}
+
+// newWardenAPIKeys returns the pre-shared keys of the warden endpoints, or nil if none are configured.
+func newWardenAPIKeys(c *config.Config) *warden.APIKeys {
+	if c.WardenAPIKeys == "" && c.WardenAPIKeysFile == "" {
+		return nil
+	}
+
+	keys, err := loadWardenAPIKeys(c)
+	if err != nil {
+		c.GetLogger().Fatalf("Could not read WARDEN_API_KEYS_FILE: %s", err)
+	}
+
+	apiKeys := warden.NewAPIKeys(keys)
+	c.GetLogger().Infof("Loaded %d pre-shared keys for the warden endpoints", apiKeys.Len())
+	return apiKeys
+}
+
+func loadWardenAPIKeys(c *config.Config) ([]string, error) {
+	keys := pkg.SplitNonEmpty(c.WardenAPIKeys, ",")
+	if c.WardenAPIKeysFile == "" {
+		return keys, nil
+	}
+
+	fromFile, err := warden.ReadAPIKeysFile(c.WardenAPIKeysFile)
+	if err != nil {
+		return nil, err
+	}
+	return append(keys, fromFile...), nil
+}
+
+// runWardenAPIKeysReload reads WARDEN_API_KEYS_FILE again whenever the process receives SIGHUP, until stop is closed.
+func runWardenAPIKeysReload(c *config.Config, apiKeys *warden.APIKeys, stop <-chan struct{}) {
+	if apiKeys == nil || c.WardenAPIKeysFile == "" {
+		return
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-hup:
+		}
+
+		keys, err := loadWardenAPIKeys(c)
+		if err != nil {
+			c.GetLogger().WithError(err).Errorln("Could not reload WARDEN_API_KEYS_FILE, keeping the current keys")
+			continue
+		}
+
+		apiKeys.Set(keys)
+		c.GetLogger().Infof("Reloaded %d pre-shared keys for the warden endpoints", apiKeys.Len())
+	}
+}
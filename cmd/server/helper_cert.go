@@ -16,11 +16,14 @@ package server
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/pem"
+	"fmt"
 	"math/big"
+	"net"
 	"strings"
 	"time"
 
@@ -34,12 +37,18 @@ import (
 
 const (
 	tlsKeyName = "hydra.https-tls"
+
+	selfSignedCertificateLifespan = time.Hour * 24 * 365
 )
 
 func loadCertificateFromFile(cmd *cobra.Command, c *config.Config) *tls.Certificate {
 	keyPath := viper.GetString("HTTPS_TLS_KEY_PATH")
 	certPath := viper.GetString("HTTPS_TLS_CERT_PATH")
-	if kp, _ := cmd.Flags().GetString("https-tls-key-path"); kp != "" {
+	if cmd == nil {
+		if keyPath == "" || certPath == "" {
+			return nil
+		}
+	} else if kp, _ := cmd.Flags().GetString("https-tls-key-path"); kp != "" {
 		keyPath = kp
 	} else if cp, _ := cmd.Flags().GetString("https-tls-cert-path"); cp != "" {
 		certPath = cp
@@ -90,8 +99,9 @@ func getOrCreateTLSCertificate(cmd *cobra.Command, c *config.Config) tls.Certifi
 		c.GetLogger().WithError(err).Fatalf(`Could not fetch TLS keys - did you forget to run "hydra migrate sql" or forget to set the SYSTEM_SECRET?`)
 	}
 
-	if len(privateKey.Certificates) == 0 {
-		cert, err := createSelfSignedCertificate(privateKey.Key)
+	names := c.GetTLSSubjectAlternativeNames()
+	if len(privateKey.Certificates) == 0 || !isSelfSignedCertificateValid(privateKey.Certificates[0], names, time.Now().UTC()) {
+		cert, err := createSelfSignedCertificate(privateKey.Key, names)
 		if err != nil {
 			c.GetLogger().WithError(err).Fatalf(`Could not generate a self signed TLS certificate.`)
 		}
@@ -114,6 +124,8 @@ func getOrCreateTLSCertificate(cmd *cobra.Command, c *config.Config) tls.Certifi
 		c.GetLogger().Fatal("TLS certificate chain can not be empty")
 	}
 
+	c.GetLogger().Infof("Using self signed TLS certificate for %s with SHA-256 fingerprint %s", strings.Join(names, ", "), certificateFingerprint(privateKey.Certificates[0]))
+
 	pemCert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: privateKey.Certificates[0].Raw})
 	pemKey := pem.EncodeToMemory(block)
 	cert, err := tls.X509KeyPair(pemCert, pemKey)
@@ -122,7 +134,9 @@ func getOrCreateTLSCertificate(cmd *cobra.Command, c *config.Config) tls.Certifi
 	return cert
 }
 
-func createSelfSignedCertificate(key interface{}) (cert *x509.Certificate, err error) {
+// createSelfSignedCertificate creates a certificate for the given host names and IP addresses which is signed by key
+// itself. The certificate is not a CA certificate, because browsers refuse CA certificates used by servers.
+func createSelfSignedCertificate(key interface{}, names []string) (cert *x509.Certificate, err error) {
 	serialNumberLimit := new(big.Int).Lsh(big.NewInt(1), 128)
 	serialNumber, err := rand.Int(rand.Reader, serialNumberLimit)
 	if err != nil {
@@ -140,15 +154,20 @@ func createSelfSignedCertificate(key interface{}) (cert *x509.Certificate, err e
 			CommonName:   "Hydra",
 		},
 		NotBefore:             time.Now().UTC(),
-		NotAfter:              time.Now().UTC().Add(time.Hour * 24 * 7),
+		NotAfter:              time.Now().UTC().Add(selfSignedCertificateLifespan),
 		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
 		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
 		BasicConstraintsValid: true,
 	}
 
-	certificate.IsCA = true
-	certificate.KeyUsage |= x509.KeyUsageCertSign
-	certificate.DNSNames = append(certificate.DNSNames, "localhost")
+	for _, name := range names {
+		if ip := net.ParseIP(name); ip != nil {
+			certificate.IPAddresses = append(certificate.IPAddresses, ip)
+		} else {
+			certificate.DNSNames = append(certificate.DNSNames, name)
+		}
+	}
+
 	der, err := x509.CreateCertificate(rand.Reader, certificate, certificate, publicKey(key), key)
 	if err != nil {
 		return cert, errors.Errorf("Failed to create certificate: %s", err)
@@ -160,3 +179,70 @@ func createSelfSignedCertificate(key interface{}) (cert *x509.Certificate, err e
 	}
 	return cert, nil
 }
+
+// isSelfSignedCertificateValid returns true if the certificate has not expired and was issued for exactly the given
+// host names and IP addresses.
+func isSelfSignedCertificateValid(cert *x509.Certificate, names []string, now time.Time) bool {
+	if now.Before(cert.NotBefore) || now.After(cert.NotAfter) {
+		return false
+	}
+
+	if len(names) != len(cert.DNSNames)+len(cert.IPAddresses) {
+		return false
+	}
+
+	for _, name := range names {
+		if err := cert.VerifyHostname(name); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// certificateFingerprint returns the SHA-256 fingerprint of the certificate as colon separated hex, the format used by
+// browsers and openssl.
+func certificateFingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	parts := make([]string, len(sum))
+	for i, b := range sum {
+		parts[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(parts, ":")
+}
+
+// verifyClientCertificate returns a tls.Config.VerifyPeerCertificate function that accepts client certificates
+// issued by one of the given CAs as well as self-signed client certificates. Self-signed certificates are matched
+// against the certificate pinned for the client when the client authenticates at the token endpoint.
+func verifyClientCertificate(roots *x509.CertPool) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return nil
+		}
+
+		certs := make([]*x509.Certificate, len(rawCerts))
+		for i, raw := range rawCerts {
+			cert, err := x509.ParseCertificate(raw)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			certs[i] = cert
+		}
+
+		leaf := certs[0]
+		if len(certs) == 1 && leaf.CheckSignatureFrom(leaf) == nil {
+			return nil
+		}
+
+		intermediates := x509.NewCertPool()
+		for _, cert := range certs[1:] {
+			intermediates.AddCert(cert)
+		}
+
+		_, err := leaf.Verify(x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: intermediates,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		})
+		return errors.WithStack(err)
+	}
+}
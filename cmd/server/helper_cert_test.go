@@ -0,0 +1,53 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateSelfSignedCertificate(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	names := []string{"localhost", "127.0.0.1", "::1", "host.docker.internal"}
+	cert, err := createSelfSignedCertificate(key, names)
+	require.NoError(t, err)
+
+	assert.False(t, cert.IsCA)
+	assert.Equal(t, []string{"localhost", "host.docker.internal"}, cert.DNSNames)
+	require.Len(t, cert.IPAddresses, 2)
+	assert.True(t, cert.IPAddresses[0].Equal(net.ParseIP("127.0.0.1")))
+	assert.True(t, cert.IPAddresses[1].Equal(net.ParseIP("::1")))
+	assert.NoError(t, cert.CheckSignatureFrom(cert))
+
+	now := time.Now().UTC()
+	assert.True(t, isSelfSignedCertificateValid(cert, names, now))
+	assert.False(t, isSelfSignedCertificateValid(cert, []string{"localhost"}, now))
+	assert.False(t, isSelfSignedCertificateValid(cert, append(names, "hydra.local"), now))
+	assert.False(t, isSelfSignedCertificateValid(cert, names, now.Add(selfSignedCertificateLifespan+time.Hour)))
+
+	fingerprint := certificateFingerprint(cert)
+	assert.Len(t, fingerprint, 32*3-1)
+	assert.Regexp(t, "^([0-9A-F]{2}:){31}[0-9A-F]{2}$", fingerprint)
+}
@@ -35,9 +35,8 @@ func (h *Handler) createRootIfNewInstall(c *config.Config) {
 		return
 	}
 
-	rs, err := pkg.GenerateSecret(16)
+	secret, _, err := h.Clients.GenerateSecret()
 	pkg.Must(err, "Could notgenerate secret because %s", err)
-	secret := string(rs)
 
 	id := ""
 	forceRoot := os.Getenv("FORCE_ROOT_CLIENT_CREDENTIALS")
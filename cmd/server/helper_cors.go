@@ -0,0 +1,61 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ory/hydra/client"
+	"github.com/ory/hydra/config"
+	"github.com/ory/hydra/oauth2"
+	"github.com/rs/cors"
+)
+
+// clientCORSPaths are the endpoints single-page apps talk to. Cross-origin requests to them are allowed from the
+// origins clients registered in allowed_cors_origins, in addition to CORS_ALLOWED_ORIGINS.
+var clientCORSPaths = []string{oauth2.TokenPath, oauth2.RevocationPath, oauth2.UserinfoPath, "/.well-known/"}
+
+func isClientCORSPath(path string) bool {
+	for _, p := range clientCORSPaths {
+		if path == p || (strings.HasSuffix(p, "/") && strings.HasPrefix(path, p)) {
+			return true
+		}
+	}
+	return false
+}
+
+// newCORSHandler applies CORS_ALLOWED_ORIGINS to all requests, except for requests to clientCORSPaths from an origin
+// registered by a client, which are allowed without credentials.
+func newCORSHandler(c *config.Config, clients client.Storage, options cors.Options, next http.Handler) http.Handler {
+	origins := &client.CORSOrigins{Manager: clients, TTL: time.Minute, L: c.GetLogger()}
+	static := cors.New(options).Handler(next)
+	dynamic := cors.New(cors.Options{
+		AllowOriginFunc: origins.IsAllowed,
+		AllowedMethods:  []string{"GET", "POST"},
+		AllowedHeaders:  []string{"Authorization", "Content-Type"},
+		MaxAge:          options.MaxAge,
+		Debug:           options.Debug,
+	}).Handler(next)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if origin := r.Header.Get("Origin"); origin != "" && isClientCORSPath(r.URL.Path) && origins.IsAllowed(origin) {
+			dynamic.ServeHTTP(w, r)
+			return
+		}
+		static.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,59 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ory/hydra/client"
+	"github.com/ory/hydra/config"
+	"github.com/rs/cors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCORSHandler(t *testing.T) {
+	clients := client.NewMemoryManager(nil)
+	require.NoError(t, clients.CreateClient(&client.Client{ID: "spa", AllowedCORSOrigins: []string{"https://app.localhost"}}))
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	h := newCORSHandler(&config.Config{}, clients, cors.Options{AllowedOrigins: []string{"https://admin.localhost"}}, next)
+
+	for k, tc := range []struct {
+		path    string
+		origin  string
+		allowed bool
+	}{
+		{path: "/oauth2/token", origin: "https://app.localhost", allowed: true},
+		{path: "/.well-known/openid-configuration", origin: "https://app.localhost", allowed: true},
+		{path: "/clients", origin: "https://app.localhost", allowed: false},
+		{path: "/oauth2/token", origin: "https://other.localhost", allowed: false},
+		{path: "/clients", origin: "https://admin.localhost", allowed: true},
+		{path: "/oauth2/token", origin: "https://admin.localhost", allowed: true},
+	} {
+		r := httptest.NewRequest("GET", tc.path, nil)
+		r.Header.Set("Origin", tc.origin)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		if tc.allowed {
+			assert.Equal(t, tc.origin, w.Header().Get("Access-Control-Allow-Origin"), "case %d", k)
+		} else {
+			assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"), "case %d", k)
+		}
+	}
+}
@@ -20,55 +20,70 @@ import (
 
 	"github.com/ory/hydra/config"
 	"github.com/ory/hydra/jwk"
-	"github.com/ory/hydra/pkg"
 	"github.com/pkg/errors"
 	"github.com/square/go-jose"
+	"golang.org/x/crypto/ed25519"
 )
 
 func createOrGetJWK(c *config.Config, set string, prefix string) (key *jose.JSONWebKey, err error) {
-	ctx := c.Context()
+	return createOrGetJWKForAlgorithm(c, set, prefix, "RS256")
+}
 
-	keys, err := ctx.KeyManager.GetKeySet(set)
-	if errors.Cause(err) == pkg.ErrNotFound || len(keys.Keys) == 0 {
-		c.GetLogger().Infof("JSON Web Key Set %s does not exist yet, generating new key pair...", set)
-		keys, err = createJWKS(ctx, set)
-		if err != nil {
-			return nil, err
-		}
-	} else if err != nil {
-		return nil, err
+// createOrGetJWKForAlgorithm returns the first key with the given prefix in the set that is suitable for the
+// given algorithm. If no such key exists, a new key pair is generated and added to the set. Existing keys are kept
+// so that tokens signed with them can still be verified using the JSON Web Key Set. Instances starting at the same
+// time generate the key pair only once, see jwk.Manager.EnsureKeySet.
+func createOrGetJWKForAlgorithm(c *config.Config, set string, prefix string, alg string) (key *jose.JSONWebKey, err error) {
+	keys, _, err := c.Context().KeyManager.EnsureKeySet(set, func(keys *jose.JSONWebKeySet) bool {
+		_, err := findKeyForAlgorithm(keys, prefix, alg)
+		return err == nil
+	}, func() (*jose.JSONWebKeySet, error) {
+		c.GetLogger().Infof("JSON Web Key with prefix %s and algorithm %s not found in JSON Web Key Set %s, generating new key pair...", prefix, alg, set)
+		return generateJWKS(c, set, alg)
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not get or create JSON Web Key Set %s", set)
 	}
 
-	key, err = jwk.FindKeyByPrefix(keys, prefix)
-	if err != nil {
-		c.GetLogger().Infof("JSON Web Key with prefix %s not found in JSON Web Key Set %s, generating new key pair...", prefix, set)
+	return findKeyForAlgorithm(keys, prefix, alg)
+}
 
-		keys, err = createJWKS(ctx, set)
-		if err != nil {
-			return nil, err
-		}
+func findKeyForAlgorithm(set *jose.JSONWebKeySet, prefix string, alg string) (*jose.JSONWebKey, error) {
+	keys, err := jwk.FindKeysByPrefix(set, prefix)
+	if err != nil {
+		return nil, err
+	}
 
-		key, err = jwk.FindKeyByPrefix(keys, prefix)
-		if err != nil {
-			return nil, err
+	for k, key := range keys.Keys {
+		if keySupportsAlgorithm(key.Key, alg) {
+			return &keys.Keys[k], nil
 		}
 	}
 
-	return key, nil
+	return nil, errors.Errorf("Unable to find key with prefix %s for algorithm %s in JSON Web Key Set", prefix, alg)
 }
 
-func createJWKS(ctx *config.Context, set string) (*jose.JSONWebKeySet, error) {
-	generator := jwk.RS256Generator{}
-	keys, err := generator.Generate("")
-	if err != nil {
-		return nil, errors.Wrapf(err, "Could not generate %s key", set)
+func keySupportsAlgorithm(key interface{}, alg string) bool {
+	switch key.(type) {
+	case *rsa.PrivateKey, *rsa.PublicKey:
+		return alg == "RS256"
+	case ed25519.PrivateKey, ed25519.PublicKey:
+		return alg == "EdDSA"
+	default:
+		return false
+	}
+}
+
+func generateJWKS(c *config.Config, set string, alg string) (*jose.JSONWebKeySet, error) {
+	var generator jwk.KeyGenerator = &jwk.RS256Generator{KeyLength: c.GetRSAKeyLength()}
+	if alg == "EdDSA" {
+		generator = &jwk.EdDSAGenerator{}
 	}
 
-	err = ctx.KeyManager.AddKeySet(set, keys)
+	keys, err := generator.Generate("")
 	if err != nil {
-		return nil, errors.Wrapf(err, "Could not persist %s key", set)
+		return nil, errors.Wrapf(err, "Could not generate %s key", set)
 	}
-
 	return keys, nil
 }
 
@@ -78,6 +93,8 @@ func publicKey(key interface{}) interface{} {
 		return &k.PublicKey
 	case *ecdsa.PrivateKey:
 		return &k.PublicKey
+	case ed25519.PrivateKey:
+		return k.Public()
 	default:
 		return nil
 	}
@@ -0,0 +1,181 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"sync"
+
+	gcontext "github.com/gorilla/context"
+	"github.com/julienschmidt/httprouter"
+	"github.com/meatballhat/negroni-logrus"
+	"github.com/ory/herodot"
+	"github.com/ory/hydra/client"
+	"github.com/ory/hydra/config"
+	"github.com/ory/hydra/jwk"
+	"github.com/ory/hydra/oauth2"
+	"github.com/ory/hydra/warden/group"
+	"github.com/ory/ladon"
+	"github.com/urfave/negroni"
+)
+
+// Server runs Hydra in-process. It is what `hydra host` runs, minus the command line: use NewServer to embed Hydra
+// in another Go program, Start and Stop to control its lifecycle, and the manager accessors to work with its
+// storage directly.
+type Server struct {
+	Config  *config.Config
+	Handler *Handler
+
+	// HTTP is the server Start listens with. Its address, TLS configuration and timeouts may be changed before
+	// calling Start.
+	HTTP *http.Server
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewServer sets up the managers and routes described by c. Telemetry is not collected by embedded servers.
+func NewServer(c *config.Config) *Server {
+	return newServer(c, false)
+}
+
+func newServer(c *config.Config, telemetry bool) *Server {
+	router := httprouter.New()
+	logger := c.GetLogger()
+	h := &Handler{
+		Config: c,
+		H:      herodot.NewJSONWriter(logger),
+	}
+	h.registerRoutes(router)
+
+	if c.ClusterURL == "" {
+		proto := "https"
+		if c.ForceHTTP {
+			proto = "http"
+		}
+		host := "localhost"
+		if c.BindHost != "" {
+			host = c.BindHost
+		}
+		c.ClusterURL = fmt.Sprintf("%s://%s:%d", proto, host, c.BindPort)
+	}
+
+	n := negroni.New()
+	if h.Tracing != nil {
+		n.Use(h.Tracing)
+	}
+	n.Use(c.GetMetrics().RequestStatistics)
+	if telemetry {
+		n.Use(c.GetMetrics())
+	}
+	n.Use(negronilogrus.NewMiddlewareFromLogger(logger, c.Issuer))
+	n.UseFunc(h.rejectInsecureRequests)
+	if h.Audit != nil {
+		n.Use(h.Audit)
+	}
+	if h.RateLimit != nil {
+		n.Use(h.RateLimit)
+	}
+	n.UseHandler(router)
+
+	return &Server{
+		Config:  c,
+		Handler: h,
+		HTTP: &http.Server{
+			Addr:    c.GetAddress(),
+			Handler: gcontext.ClearHandler(newCORSHandler(c, h.Clients.Manager, parseCorsOptions(), n)),
+		},
+		stop: make(chan struct{}),
+	}
+}
+
+// ServeHTTP serves a single request without listening on a socket, which is useful in tests.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.HTTP.Handler.ServeHTTP(w, r)
+}
+
+// Start runs the background jobs and listens on the configured address. It blocks until the server is stopped
+// and returns http.ErrServerClosed after a call to Stop.
+func (s *Server) Start() error {
+	c := s.Config
+	logger := c.GetLogger()
+
+	go runConsentRequestCleanup(c, s.stop)
+	go runJWKCleanup(c, s.stop)
+	go runEventDispatcher(c, s.stop)
+	if s.Handler.Decisions != nil {
+		go runDecisionLogCleanup(c, s.Handler.Decisions.Manager, s.stop)
+	}
+	if s.Handler.PolicyUsageTracker != nil {
+		go s.Handler.PolicyUsageTracker.Run(s.stop)
+	}
+	if s.Handler.OAuth2.RevocationFilter != nil {
+		go s.Handler.OAuth2.RevocationFilter.Run(s.stop)
+	}
+	go runWardenAPIKeysReload(c, s.Handler.Warden.APIKeys, s.stop)
+
+	logger.Infof("Setting up http server on %s", s.HTTP.Addr)
+	if c.ForceHTTP {
+		logger.Warnln("HTTPS disabled. Never do this in production.")
+		return s.HTTP.ListenAndServe()
+	} else if c.AllowTLSTermination != "" {
+		logger.Infoln("TLS termination enabled, disabling https.")
+		return s.HTTP.ListenAndServe()
+	}
+
+	if s.HTTP.TLSConfig == nil {
+		s.HTTP.TLSConfig = &tls.Config{
+			Certificates: []tls.Certificate{getOrCreateTLSCertificate(nil, c)},
+			NextProtos:   []string{"h2", "http/1.1"},
+			CipherSuites: StandardCipherSuites(),
+			MinVersion:   tls.VersionTLS12,
+		}
+	}
+	return s.HTTP.ListenAndServeTLS("", "")
+}
+
+// Stop halts the background jobs and gracefully shuts the HTTP server down.
+func (s *Server) Stop(ctx context.Context) error {
+	s.stopOnce.Do(func() { close(s.stop) })
+	return s.HTTP.Shutdown(ctx)
+}
+
+// Clients returns the client manager used by the server.
+func (s *Server) Clients() client.Manager {
+	return s.Handler.Clients.Manager
+}
+
+// Keys returns the JSON Web Key manager used by the server.
+func (s *Server) Keys() jwk.Manager {
+	return s.Config.Context().KeyManager
+}
+
+// Policies returns the access control policy manager used by the server.
+func (s *Server) Policies() ladon.Manager {
+	return s.Config.Context().LadonManager
+}
+
+// Groups returns the warden group manager used by the server.
+func (s *Server) Groups() group.Manager {
+	return s.Config.Context().GroupManager
+}
+
+// ConsentRequests returns the consent request manager used by the server.
+func (s *Server) ConsentRequests() oauth2.ConsentRequestManager {
+	return s.Config.Context().ConsentManager
+}
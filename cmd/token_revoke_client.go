@@ -0,0 +1,32 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// tokenRevokeClientCmd represents the revoke-client command
+var tokenRevokeClientCmd = &cobra.Command{
+	Use:   "revoke-client <client-id>",
+	Short: "Revoke all access and refresh tokens issued to a client",
+	Long: `Revokes all access and refresh tokens issued to a client, for example after its secret was leaked. Rotate the
+client's credentials afterwards, or the client can be issued new tokens.`,
+	Run: cmdHandler.Token.RevokeClientTokens,
+}
+
+func init() {
+	tokenCmd.AddCommand(tokenRevokeClientCmd)
+}
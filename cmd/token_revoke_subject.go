@@ -0,0 +1,32 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// tokenRevokeSubjectCmd represents the revoke-subject command
+var tokenRevokeSubjectCmd = &cobra.Command{
+	Use:   "revoke-subject <subject>",
+	Short: "Revoke all access and refresh tokens issued on behalf of a subject",
+	Long: `Revokes all access and refresh tokens issued on behalf of a subject, for example when the user signs out
+everywhere. Tokens of all clients are revoked.`,
+	Run: cmdHandler.Token.RevokeSubjectTokens,
+}
+
+func init() {
+	tokenCmd.AddCommand(tokenRevokeSubjectCmd)
+}
@@ -17,6 +17,7 @@ package cmd
 import (
 	"fmt"
 
+	"github.com/ory/hydra/config"
 	"github.com/spf13/cobra"
 )
 
@@ -28,9 +29,17 @@ var versionCmd = &cobra.Command{
 		fmt.Printf("Version:    %s\n", Version)
 		fmt.Printf("Git Hash:   %s\n", GitHash)
 		fmt.Printf("Build Time: %s\n", BuildTime)
+		fmt.Printf("Plugins:    %s\n", pluginSupport())
 	},
 }
 
 func init() {
 	RootCmd.AddCommand(versionCmd)
 }
+
+func pluginSupport() string {
+	if config.PluginsSupported {
+		return "enabled"
+	}
+	return "disabled"
+}
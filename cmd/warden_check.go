@@ -0,0 +1,49 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// wardenCheckCmd represents the check command
+var wardenCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Check if a subject or the subject of an access token is allowed to perform an action on a resource",
+	Long: `Asks the warden whether a subject, given by --subject, or the subject of an access token, given by --token, is
+allowed to perform an action on a resource and prints the decision. For access tokens, the client and the granted
+scopes of the token are printed as well, and --scopes checks that the token was granted the scopes.
+
+Unless --explain=false is set, the policies which allow or deny the request are printed for the subject and every
+group it belongs to. They are evaluated locally, which requires access to the policies and groups, and may therefore
+not explain decisions made by elevations or a remote authorizer. The command exits with status 1 if access is denied.
+
+Example:
+	hydra warden check --subject peter --resource rn:hydra:clients:foo --action get --context remoteIP=10.0.0.1
+	hydra warden check --token $TOKEN --scopes hydra.clients --resource rn:hydra:clients --action create
+`,
+	Run: cmdHandler.Warden.CheckAccess,
+}
+
+func init() {
+	wardenCmd.AddCommand(wardenCheckCmd)
+	wardenCheckCmd.Flags().String("subject", "", "The subject requesting access")
+	wardenCheckCmd.Flags().String("token", "", "An access token whose subject requests access")
+	wardenCheckCmd.Flags().String("resource", "", "The resource access is requested to")
+	wardenCheckCmd.Flags().String("action", "", "The action requested on the resource")
+	wardenCheckCmd.Flags().StringSlice("scopes", []string{}, "Scopes the access token must have been granted")
+	wardenCheckCmd.Flags().StringSlice("context", []string{}, "The context of the request as key=value pairs, values which are valid JSON are decoded")
+	wardenCheckCmd.Flags().Bool("explain", true, "Print the policies which allow or deny the request")
+}
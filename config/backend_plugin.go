@@ -43,6 +43,10 @@ func (c *PluginConnection) load() error {
 	}
 
 	cf := c.Config
+	if !PluginsSupported {
+		return errors.Errorf("Unable to load database plugin %s because this binary was built without plugin support, plugins require a cgo enabled build on linux or darwin", cf.DatabasePlugin)
+	}
+
 	p, err := plugin.Open(cf.DatabasePlugin)
 	if err != nil {
 		return errors.WithStack(err)
@@ -121,7 +125,7 @@ func (c *PluginConnection) NewJWKManager() (jwk.Manager, error) {
 		return nil, errors.New("Unable to type assert `NewJWKManager`")
 	} else {
 		return m(c.db, &jwk.AEAD{
-			Key: c.Config.GetSystemSecret(),
+			Key: c.Config.GetDerivedSecret(pkg.SecretPurposeJWK),
 		}), nil
 	}
 }
@@ -0,0 +1,59 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/ory/hydra/pkg"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+type RedisConnection struct {
+	client *redis.Client
+	URL    *url.URL
+	L      logrus.FieldLogger
+}
+
+func (c *RedisConnection) GetClient() *redis.Client {
+	if c.client != nil {
+		return c.client
+	}
+
+	options, err := redis.ParseURL(c.URL.String())
+	if err != nil {
+		c.L.Fatalf("Could not parse redis url: %s", err)
+	}
+
+	if err := pkg.Retry(c.L, time.Second*15, time.Minute*2, func() error {
+		c.L.Infof("Connecting with %s", c.URL.Scheme+"://*:*@"+c.URL.Host+c.URL.Path)
+		client := redis.NewClient(options)
+		if err := client.Ping().Err(); err != nil {
+			client.Close()
+			return errors.Errorf("Could not Connect to Redis: %s", err)
+		}
+
+		c.L.Infof("Connected to Redis!")
+		c.client = client
+		return nil
+	}); err != nil {
+		c.L.Fatalf("Could not Connect to Redis: %s", err)
+	}
+
+	return c.client
+}
@@ -15,6 +15,7 @@
 package config
 
 import (
+	"database/sql"
 	"net/url"
 	"runtime"
 	"strconv"
@@ -24,7 +25,10 @@ import (
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
+	"github.com/ory/hydra/metrics"
+	"github.com/ory/hydra/oauth2"
 	"github.com/ory/hydra/pkg"
+	"github.com/ory/hydra/policy"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
@@ -33,6 +37,18 @@ type SQLConnection struct {
 	db  *sqlx.DB
 	URL *url.URL
 	L   logrus.FieldLogger
+
+	// SlowQueryThreshold enables logging of queries which take at least this long if greater than zero.
+	SlowQueryThreshold time.Duration
+
+	// SlowQueries, if set, records the duration of all queries and the queries which exceeded SlowQueryThreshold.
+	SlowQueries *metrics.QueryStatistics
+}
+
+// SQLIndexes returns the indexes which frequently executed queries of the SQL backend rely on.
+func SQLIndexes() []pkg.SQLIndex {
+	indexes := append([]pkg.SQLIndex{}, oauth2.SQLIndexes...)
+	return append(indexes, policy.SQLIndexes...)
 }
 
 func cleanURLQuery(c *url.URL) *url.URL {
@@ -55,17 +71,45 @@ func (c *SQLConnection) GetDatabase() *sqlx.DB {
 
 	var err error
 	clean := cleanURLQuery(c.URL)
+	instrumented := ""
 
 	if err = pkg.Retry(c.L, time.Second*15, time.Minute*2, func() error {
 		c.L.Infof("Connecting with %s", c.URL.Scheme+"://*:*@"+c.URL.Host+c.URL.Path+"?"+clean.RawQuery)
 		u := clean.String()
+		driver := clean.Scheme
 		if clean.Scheme == "mysql" {
 			u = strings.Replace(u, "mysql://", "", -1)
+		} else if clean.Scheme == "cockroach" {
+			// CockroachDB speaks the PostgreSQL wire protocol.
+			driver = "postgres"
+			u = strings.Replace(u, "cockroach://", "postgres://", 1)
 		}
 
-		if c.db, err = sqlx.Open(clean.Scheme, u); err != nil {
+		if (c.SlowQueryThreshold > 0 || c.SlowQueries != nil) && instrumented == "" {
+			l := &slowQueryLogger{threshold: c.SlowQueryThreshold, logger: c.L}
+			if c.SlowQueries != nil {
+				l.record = c.SlowQueries.RecordSlow
+				l.observeAll = c.SlowQueries.Record
+			}
+
+			if instrumented, err = registerInstrumentedDriver(driver, l); err != nil {
+				return errors.Errorf("Could not instrument SQL driver: %s", err)
+			}
+		}
+
+		if instrumented != "" {
+			// The instrumented driver is registered under a different name, so the name of the original driver is
+			// passed on to sqlx, which uses it to pick the placeholder syntax.
+			db, err := sql.Open(instrumented, u)
+			if err != nil {
+				return errors.Errorf("Could not Connect to SQL: %s", err)
+			}
+			c.db = sqlx.NewDb(db, driver)
+		} else if c.db, err = sqlx.Open(driver, u); err != nil {
 			return errors.Errorf("Could not Connect to SQL: %s", err)
-		} else if err := c.db.Ping(); err != nil {
+		}
+
+		if err := c.db.Ping(); err != nil {
 			return errors.Errorf("Could not Connect to SQL: %s", err)
 		}
 
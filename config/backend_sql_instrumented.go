@@ -0,0 +1,184 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+var instrumentedDrivers uint64
+
+var queryTable = regexp.MustCompile(`(?i)\b(?:from|into|update|table(?:\s+if\s+not\s+exists)?)\s+["` + "`" + `]?([a-z0-9_]+)`)
+
+// slowQueryLogger logs queries which take at least threshold and passes them on to record. If threshold is zero, no
+// queries are logged. The duration of every query is passed on to observeAll.
+type slowQueryLogger struct {
+	threshold  time.Duration
+	logger     logrus.FieldLogger
+	record     func(operation string, duration time.Duration)
+	observeAll func(operation string, duration time.Duration)
+}
+
+func (l *slowQueryLogger) observe(query string, args []driver.Value, duration time.Duration) {
+	operation := queryOperation(query)
+	if l.observeAll != nil {
+		l.observeAll(operation, duration)
+	}
+
+	if l.threshold <= 0 || duration < l.threshold {
+		return
+	}
+
+	l.logger.
+		WithField("operation", operation).
+		WithField("query", strings.Join(strings.Fields(query), " ")).
+		WithField("args", sanitizeQueryArgs(args)).
+		WithField("duration", duration.String()).
+		Warn("Slow SQL query")
+
+	if l.record != nil {
+		l.record(operation, duration)
+	}
+}
+
+// queryOperation returns the statement type and the first table of query, for example "SELECT hydra_client".
+func queryOperation(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return "UNKNOWN"
+	}
+
+	operation := strings.ToUpper(fields[0])
+	if m := queryTable.FindStringSubmatch(query); len(m) == 2 {
+		operation += " " + strings.ToLower(m[1])
+	}
+	return operation
+}
+
+// sanitizeQueryArgs describes query arguments by their type and length only, because they may contain secrets such
+// as tokens, client secrets or keys.
+func sanitizeQueryArgs(args []driver.Value) []string {
+	sanitized := make([]string, len(args))
+	for k, arg := range args {
+		switch v := arg.(type) {
+		case nil:
+			sanitized[k] = "nil"
+		case string:
+			sanitized[k] = fmt.Sprintf("string(%d)", len(v))
+		case []byte:
+			sanitized[k] = fmt.Sprintf("[]byte(%d)", len(v))
+		default:
+			sanitized[k] = fmt.Sprintf("%T", v)
+		}
+	}
+	return sanitized
+}
+
+// registerInstrumentedDriver registers a driver which wraps the driver registered as name and reports the duration of
+// every statement to l. It returns the name of the new driver.
+func registerInstrumentedDriver(name string, l *slowQueryLogger) (string, error) {
+	db, err := sql.Open(name, "")
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	defer db.Close()
+
+	instrumented := fmt.Sprintf("hydra-instrumented-%s-%d", name, atomic.AddUint64(&instrumentedDrivers, 1))
+	sql.Register(instrumented, &instrumentedDriver{Driver: db.Driver(), l: l})
+	return instrumented, nil
+}
+
+type instrumentedDriver struct {
+	driver.Driver
+	l *slowQueryLogger
+}
+
+func (d *instrumentedDriver) Open(name string) (driver.Conn, error) {
+	c, err := d.Driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedConn{Conn: c, l: d.l}, nil
+}
+
+type instrumentedConn struct {
+	driver.Conn
+	l *slowQueryLogger
+}
+
+func (c *instrumentedConn) Prepare(query string) (driver.Stmt, error) {
+	s, err := c.Conn.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedStmt{Stmt: s, query: query, l: c.l}, nil
+}
+
+func (c *instrumentedConn) Exec(query string, args []driver.Value) (driver.Result, error) {
+	e, ok := c.Conn.(driver.Execer)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	start := time.Now()
+	res, err := e.Exec(query, args)
+	if err != driver.ErrSkip {
+		c.l.observe(query, args, time.Since(start))
+	}
+	return res, err
+}
+
+func (c *instrumentedConn) Query(query string, args []driver.Value) (driver.Rows, error) {
+	q, ok := c.Conn.(driver.Queryer)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	start := time.Now()
+	rows, err := q.Query(query, args)
+	if err != driver.ErrSkip {
+		c.l.observe(query, args, time.Since(start))
+	}
+	return rows, err
+}
+
+type instrumentedStmt struct {
+	driver.Stmt
+	query string
+	l     *slowQueryLogger
+}
+
+func (s *instrumentedStmt) Exec(args []driver.Value) (driver.Result, error) {
+	start := time.Now()
+	res, err := s.Stmt.Exec(args)
+	s.l.observe(s.query, args, time.Since(start))
+	return res, err
+}
+
+func (s *instrumentedStmt) Query(args []driver.Value) (driver.Rows, error) {
+	start := time.Now()
+	rows, err := s.Stmt.Query(args)
+	s.l.observe(s.query, args, time.Since(start))
+	return rows, err
+}
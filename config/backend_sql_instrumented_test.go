@@ -0,0 +1,104 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/ory/hydra/metrics"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type slowDriver struct{}
+
+func (d *slowDriver) Open(name string) (driver.Conn, error) {
+	return &slowConn{}, nil
+}
+
+type slowConn struct{}
+
+func (c *slowConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *slowConn) Close() error {
+	return nil
+}
+
+func (c *slowConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *slowConn) Exec(query string, args []driver.Value) (driver.Result, error) {
+	time.Sleep(time.Millisecond * 10)
+	return driver.RowsAffected(1), nil
+}
+
+func init() {
+	sql.Register("hydra-test-slow", &slowDriver{})
+}
+
+func TestQueryOperation(t *testing.T) {
+	assert.Equal(t, "SELECT hydra_client", queryOperation("SELECT * FROM hydra_client WHERE id=$1"))
+	assert.Equal(t, "INSERT hydra_jwk", queryOperation("insert into hydra_jwk (sid, kid) VALUES (?, ?)"))
+	assert.Equal(t, "UPDATE hydra_client", queryOperation("UPDATE hydra_client SET status='' WHERE id=?"))
+	assert.Equal(t, "DELETE hydra_oauth2_access", queryOperation("DELETE FROM `hydra_oauth2_access` WHERE signature=?"))
+	assert.Equal(t, "CREATE hydra_warden_group", queryOperation("CREATE TABLE IF NOT EXISTS hydra_warden_group (id varchar(255))"))
+	assert.Equal(t, "SELECT", queryOperation("SELECT version()"))
+	assert.Equal(t, "UNKNOWN", queryOperation(""))
+}
+
+func TestSanitizeQueryArgs(t *testing.T) {
+	assert.Equal(t, []string{"string(6)", "[]byte(3)", "int64", "nil", "time.Time"}, sanitizeQueryArgs([]driver.Value{"secret", []byte("foo"), int64(1), nil, time.Now()}))
+}
+
+func TestInstrumentedDriver(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logrus.New()
+	logger.Out = &buf
+	stats := metrics.NewQueryStatistics()
+
+	name, err := registerInstrumentedDriver("hydra-test-slow", &slowQueryLogger{
+		threshold:  time.Millisecond * 5,
+		logger:     logger,
+		record:     stats.RecordSlow,
+		observeAll: stats.Record,
+	})
+	require.NoError(t, err)
+
+	db, err := sql.Open(name, "")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("UPDATE hydra_client SET client_secret=? WHERE id=?", "super-secret-value", "foo")
+	require.NoError(t, err)
+
+	assert.Contains(t, buf.String(), "Slow SQL query")
+	assert.Contains(t, buf.String(), "UPDATE hydra_client")
+	assert.Contains(t, buf.String(), "string(18)")
+	assert.NotContains(t, buf.String(), "super-secret-value")
+
+	var out bytes.Buffer
+	require.NoError(t, stats.WritePrometheus(&out, false))
+	assert.Contains(t, out.String(), `hydra_sql_slow_queries_total{operation="UPDATE hydra_client"} 1`)
+	assert.Contains(t, out.String(), `hydra_sql_query_duration_seconds_count{operation="UPDATE hydra_client"} 1`)
+}
@@ -30,9 +30,15 @@ import (
 	"github.com/ory/fosite"
 	foauth2 "github.com/ory/fosite/handler/oauth2"
 	"github.com/ory/fosite/token/hmac"
+	"github.com/ory/hydra/client"
+	"github.com/ory/hydra/events"
 	"github.com/ory/hydra/health"
+	"github.com/ory/hydra/jwk"
 	"github.com/ory/hydra/metrics"
+	hoa2 "github.com/ory/hydra/oauth2"
 	"github.com/ory/hydra/pkg"
+	"github.com/ory/hydra/policy"
+	"github.com/ory/hydra/ratelimit"
 	"github.com/ory/hydra/warden/group"
 	"github.com/ory/ladon"
 	lmem "github.com/ory/ladon/manager/memory"
@@ -50,33 +56,119 @@ type Config struct {
 	// These are used by client commands
 	ClusterURL            string `mapstructure:"CLUSTER_URL" yaml:"cluster_url"`
 	ClientID              string `mapstructure:"CLIENT_ID" yaml:"client_id,omitempty"`
-	ClientSecret          string `mapstructure:"CLIENT_SECRET" yaml:"client_secret,omitempty"`
+	ClientSecret          string `mapstructure:"CLIENT_SECRET" yaml:"client_secret,omitempty" secret:"true"`
 	SignedUpForNewsletter bool   `yaml:"signed_up_for_newsletter,omitempty"`
 
 	// These are used by the host command
-	BindPort                         int    `mapstructure:"PORT" yaml:"-"`
-	BindHost                         string `mapstructure:"HOST" yaml:"-"`
-	Issuer                           string `mapstructure:"ISSUER" yaml:"-"`
-	SystemSecret                     string `mapstructure:"SYSTEM_SECRET" yaml:"-"`
-	DatabaseURL                      string `mapstructure:"DATABASE_URL" yaml:"-"`
-	DatabasePlugin                   string `mapstructure:"DATABASE_PLUGIN" yaml:"-"`
-	ConsentURL                       string `mapstructure:"CONSENT_URL" yaml:"-"`
-	AllowTLSTermination              string `mapstructure:"HTTPS_ALLOW_TERMINATION_FROM" yaml:"-"`
-	BCryptWorkFactor                 int    `mapstructure:"BCRYPT_COST" yaml:"-"`
-	AccessTokenLifespan              string `mapstructure:"ACCESS_TOKEN_LIFESPAN" yaml:"-"`
-	ScopeStrategy                    string `mapstructure:"SCOPE_STRATEGY" yaml:"-"`
-	AuthCodeLifespan                 string `mapstructure:"AUTH_CODE_LIFESPAN" yaml:"-"`
-	IDTokenLifespan                  string `mapstructure:"ID_TOKEN_LIFESPAN" yaml:"-"`
-	ChallengeTokenLifespan           string `mapstructure:"CHALLENGE_TOKEN_LIFESPAN" yaml:"-"`
-	CookieSecret                     string `mapstructure:"COOKIE_SECRET" yaml:"-"`
-	LogLevel                         string `mapstructure:"LOG_LEVEL" yaml:"-"`
-	LogFormat                        string `mapstructure:"LOG_FORMAT" yaml:"-"`
-	AccessControlResourcePrefix      string `mapstructure:"RESOURCE_NAME_PREFIX" yaml:"-"`
-	OpenIDDiscoveryClaimsSupported   string `mapstructure:"OIDC_DISCOVERY_CLAIMS_SUPPORTED" yaml:"-"`
-	OpenIDDiscoveryScopesSupported   string `mapstructure:"OIDC_DISCOVERY_SCOPES_SUPPORTED" yaml:"-"`
-	OpenIDDiscoveryUserinfoEndpoint  string `mapstructure:"OIDC_DISCOVERY_USERINFO_ENDPOINT" yaml:"-"`
-	SendOAuth2DebugMessagesToClients bool   `mapstructure:"OAUTH2_SHARE_ERROR_DEBUG" yaml:"-"`
-	ForceHTTP                        bool   `yaml:"-"`
+	BindPort                         int     `mapstructure:"PORT" yaml:"-"`
+	BindHost                         string  `mapstructure:"HOST" yaml:"-"`
+	Issuer                           string  `mapstructure:"ISSUER" yaml:"-"`
+	IssuerByHost                     string  `mapstructure:"ISSUER_BY_HOST" yaml:"-"`
+	SystemSecret                     string  `mapstructure:"SYSTEM_SECRET" yaml:"-" secret:"true"`
+	SystemSecretDerivation           int     `mapstructure:"SYSTEM_SECRET_DERIVATION" yaml:"-"`
+	DatabaseURL                      string  `mapstructure:"DATABASE_URL" yaml:"-"`
+	DatabasePlugin                   string  `mapstructure:"DATABASE_PLUGIN" yaml:"-"`
+	SQLSlowQueryThreshold            string  `mapstructure:"SQL_SLOW_QUERY_THRESHOLD" yaml:"-"`
+	ConsentURL                       string  `mapstructure:"CONSENT_URL" yaml:"-"`
+	LoginURL                         string  `mapstructure:"LOGIN_URL" yaml:"-"`
+	ErrorURL                         string  `mapstructure:"ERROR_URL" yaml:"-"`
+	ErrorCatalogDir                  string  `mapstructure:"ERROR_CATALOG_DIR" yaml:"-"`
+	AllowTLSTermination              string  `mapstructure:"HTTPS_ALLOW_TERMINATION_FROM" yaml:"-"`
+	TLSSubjectAlternativeNames       string  `mapstructure:"HTTPS_TLS_SANS" yaml:"-"`
+	BCryptWorkFactor                 int     `mapstructure:"BCRYPT_COST" yaml:"-"`
+	ClientSecretHasher               string  `mapstructure:"CLIENT_SECRET_HASHER" yaml:"-"`
+	Argon2idMemory                   int     `mapstructure:"ARGON2ID_MEMORY" yaml:"-"`
+	Argon2idIterations               int     `mapstructure:"ARGON2ID_ITERATIONS" yaml:"-"`
+	Argon2idParallelism              int     `mapstructure:"ARGON2ID_PARALLELISM" yaml:"-"`
+	RSAKeyLength                     int     `mapstructure:"RSA_KEY_LENGTH" yaml:"-"`
+	JWKCacheTTL                      string  `mapstructure:"JWK_CACHE_TTL" yaml:"-"`
+	JWKCipherURL                     string  `mapstructure:"JWK_CIPHER_URL" yaml:"-"`
+	JWKCleanupInterval               string  `mapstructure:"JWK_CLEANUP_INTERVAL" yaml:"-"`
+	JWKMinRSAKeySize                 int     `mapstructure:"JWK_MIN_RSA_KEY_SIZE" yaml:"-"`
+	JWKMinECKeySize                  int     `mapstructure:"JWK_MIN_EC_KEY_SIZE" yaml:"-"`
+	JWKMinSymmetricKeySize           int     `mapstructure:"JWK_MIN_SYMMETRIC_KEY_SIZE" yaml:"-"`
+	JWKDeniedAlgorithms              string  `mapstructure:"JWK_DENIED_ALGORITHMS" yaml:"-"`
+	AccessTokenLifespan              string  `mapstructure:"ACCESS_TOKEN_LIFESPAN" yaml:"-"`
+	AccessTokenStrategy              string  `mapstructure:"ACCESS_TOKEN_STRATEGY" yaml:"-"`
+	ScopeStrategy                    string  `mapstructure:"SCOPE_STRATEGY" yaml:"-"`
+	AuthCodeLifespan                 string  `mapstructure:"AUTH_CODE_LIFESPAN" yaml:"-"`
+	IDTokenLifespan                  string  `mapstructure:"ID_TOKEN_LIFESPAN" yaml:"-"`
+	ChallengeTokenLifespan           string  `mapstructure:"CHALLENGE_TOKEN_LIFESPAN" yaml:"-"`
+	ConsentRequestCleanupInterval    string  `mapstructure:"CONSENT_REQUEST_CLEANUP_INTERVAL" yaml:"-"`
+	ConsentRequestParkLifespan       string  `mapstructure:"CONSENT_REQUEST_PARK_LIFESPAN" yaml:"-"`
+	EventsWebhookURL                 string  `mapstructure:"EVENTS_WEBHOOK_URL" yaml:"-"`
+	EventsWebhookSecret              string  `mapstructure:"EVENTS_WEBHOOK_SECRET" yaml:"-" secret:"true"`
+	EventsDispatchInterval           string  `mapstructure:"EVENTS_DISPATCH_INTERVAL" yaml:"-"`
+	EventsWebhookTimeout             string  `mapstructure:"EVENTS_WEBHOOK_TIMEOUT" yaml:"-"`
+	ClaimsHookURL                    string  `mapstructure:"CLAIMS_HOOK_URL" yaml:"-"`
+	ClaimsHookSecret                 string  `mapstructure:"CLAIMS_HOOK_SECRET" yaml:"-" secret:"true"`
+	ClaimsHookTimeout                string  `mapstructure:"CLAIMS_HOOK_TIMEOUT" yaml:"-"`
+	CookieSecret                     string  `mapstructure:"COOKIE_SECRET" yaml:"-" secret:"true"`
+	LogLevel                         string  `mapstructure:"LOG_LEVEL" yaml:"-"`
+	LogFormat                        string  `mapstructure:"LOG_FORMAT" yaml:"-"`
+	AccessControlResourcePrefix      string  `mapstructure:"RESOURCE_NAME_PREFIX" yaml:"-"`
+	OpenIDDiscoveryClaimsSupported   string  `mapstructure:"OIDC_DISCOVERY_CLAIMS_SUPPORTED" yaml:"-"`
+	OpenIDDiscoveryScopesSupported   string  `mapstructure:"OIDC_DISCOVERY_SCOPES_SUPPORTED" yaml:"-"`
+	OpenIDDiscoveryUserinfoEndpoint  string  `mapstructure:"OIDC_DISCOVERY_USERINFO_ENDPOINT" yaml:"-"`
+	IDTokenSigningAlgorithm          string  `mapstructure:"OIDC_ID_TOKEN_SIGNING_ALG" yaml:"-"`
+	JWKSCacheMaxAge                  string  `mapstructure:"OIDC_JWKS_CACHE_MAX_AGE" yaml:"-"`
+	SendOAuth2DebugMessagesToClients bool    `mapstructure:"OAUTH2_SHARE_ERROR_DEBUG" yaml:"-"`
+	OAuth2TokenHistory               bool    `mapstructure:"OAUTH2_TOKEN_HISTORY" yaml:"-"`
+	ConsentStatistics                bool    `mapstructure:"CONSENT_STATISTICS" yaml:"-"`
+	RevocationFilterInterval         string  `mapstructure:"REVOCATION_FILTER_INTERVAL" yaml:"-"`
+	RevocationFilterFalsePositive    float64 `mapstructure:"REVOCATION_FILTER_FALSE_POSITIVE_RATE" yaml:"-"`
+	OAuth2ClientRegistration         bool    `mapstructure:"OAUTH2_CLIENT_REGISTRATION" yaml:"-"`
+	OAuth2DynamicClientRegistration  string  `mapstructure:"OAUTH2_DYNAMIC_CLIENT_REGISTRATION" yaml:"-"`
+	OAuth2RequirePKCEForPublic       bool    `mapstructure:"OAUTH2_REQUIRE_PKCE_FOR_PUBLIC_CLIENTS" yaml:"-"`
+	OAuth2EmbedAllowedOrigins        string  `mapstructure:"OAUTH2_EMBED_ALLOWED_ORIGINS" yaml:"-"`
+	OAuth2PushedRequestLifespan      string  `mapstructure:"OAUTH2_PUSHED_AUTHORIZATION_REQUEST_LIFESPAN" yaml:"-"`
+	OAuth2ResourceScopes             string  `mapstructure:"OAUTH2_RESOURCE_SCOPES" yaml:"-"`
+	OAuth2IntrospectionCacheTTL      string  `mapstructure:"OAUTH2_INTROSPECTION_CACHE_TTL" yaml:"-"`
+	OAuth2IntrospectionCacheEntries  int     `mapstructure:"OAUTH2_INTROSPECTION_CACHE_MAX_ENTRIES" yaml:"-"`
+	OAuth2IntrospectionMaxAge        string  `mapstructure:"OAUTH2_INTROSPECTION_CACHE_CONTROL_MAX_AGE" yaml:"-"`
+	OAuth2IntrospectionMaxAgeSubs    string  `mapstructure:"OAUTH2_INTROSPECTION_CACHE_CONTROL_SUBJECTS" yaml:"-"`
+	OAuth2AccessTokenPrefix          string  `mapstructure:"OAUTH2_ACCESS_TOKEN_PREFIX" yaml:"-"`
+	OAuth2RefreshTokenPrefix         string  `mapstructure:"OAUTH2_REFRESH_TOKEN_PREFIX" yaml:"-"`
+	OAuth2ClientSecretPrefix         string  `mapstructure:"OAUTH2_CLIENT_SECRET_PREFIX" yaml:"-"`
+	OAuth2ClientSecretLength         int     `mapstructure:"OAUTH2_CLIENT_SECRET_LENGTH" yaml:"-"`
+	OAuth2ClientSecretMinEntropy     int     `mapstructure:"OAUTH2_CLIENT_SECRET_MIN_ENTROPY" yaml:"-"`
+	OAuth2ClientSecretClasses        string  `mapstructure:"OAUTH2_CLIENT_SECRET_CHARACTER_CLASSES" yaml:"-"`
+	OAuth2ClientSecretEntropySource  string  `mapstructure:"OAUTH2_CLIENT_SECRET_ENTROPY_SOURCE" yaml:"-"`
+	WardenDecisionLog                bool    `mapstructure:"WARDEN_DECISION_LOG" yaml:"-"`
+	WardenDecisionLogAllowSampleRate float64 `mapstructure:"WARDEN_DECISION_LOG_ALLOW_SAMPLE_RATE" yaml:"-"`
+	WardenDecisionLogRetention       string  `mapstructure:"WARDEN_DECISION_LOG_RETENTION" yaml:"-"`
+	WardenPolicyUsage                bool    `mapstructure:"WARDEN_POLICY_USAGE" yaml:"-"`
+	WardenCacheTTL                   string  `mapstructure:"WARDEN_CACHE_TTL" yaml:"-"`
+	WardenCacheMaxEntries            int     `mapstructure:"WARDEN_CACHE_MAX_ENTRIES" yaml:"-"`
+	WardenAuthorizerURL              string  `mapstructure:"WARDEN_AUTHORIZER_URL" yaml:"-"`
+	WardenAuthorizerTimeout          string  `mapstructure:"WARDEN_AUTHORIZER_TIMEOUT" yaml:"-"`
+	WardenAPIKeys                    string  `mapstructure:"WARDEN_API_KEYS" yaml:"-" secret:"true"`
+	WardenAPIKeysFile                string  `mapstructure:"WARDEN_API_KEYS_FILE" yaml:"-"`
+	WardenActionGroups               string  `mapstructure:"WARDEN_ACTION_GROUPS" yaml:"-"`
+	WardenTrustedPeers               string  `mapstructure:"WARDEN_TRUSTED_PEERS" yaml:"-"`
+	WardenTrustedPeerAudience        string  `mapstructure:"WARDEN_TRUSTED_PEER_AUDIENCE" yaml:"-"`
+	WardenTrustedPeerScopes          string  `mapstructure:"WARDEN_TRUSTED_PEER_SCOPES" yaml:"-"`
+	WardenTrustedPeerKeysTTL         string  `mapstructure:"WARDEN_TRUSTED_PEER_KEYS_TTL" yaml:"-"`
+	WardenElevations                 bool    `mapstructure:"WARDEN_ELEVATIONS" yaml:"-"`
+	WardenElevationMaxDuration       string  `mapstructure:"WARDEN_ELEVATION_MAX_DURATION" yaml:"-"`
+	AuditLogSinks                    string  `mapstructure:"AUDIT_LOG_SINKS" yaml:"-"`
+	AuditLogWebhookTimeout           string  `mapstructure:"AUDIT_LOG_WEBHOOK_TIMEOUT" yaml:"-"`
+	SubjectPseudonymizationKey       string  `mapstructure:"SUBJECT_PSEUDONYMIZATION_KEY" yaml:"-" secret:"true"`
+	RateLimitRedisURL                string  `mapstructure:"RATE_LIMIT_REDIS_URL" yaml:"-"`
+	RateLimitTokenPerClient          string  `mapstructure:"RATE_LIMIT_TOKEN_PER_CLIENT" yaml:"-"`
+	RateLimitTokenPerIP              string  `mapstructure:"RATE_LIMIT_TOKEN_PER_IP" yaml:"-"`
+	RateLimitIntrospectPerClient     string  `mapstructure:"RATE_LIMIT_INTROSPECT_PER_CLIENT" yaml:"-"`
+	RateLimitIntrospectPerIP         string  `mapstructure:"RATE_LIMIT_INTROSPECT_PER_IP" yaml:"-"`
+	RateLimitAdminPerClient          string  `mapstructure:"RATE_LIMIT_ADMIN_PER_CLIENT" yaml:"-"`
+	RateLimitAdminPerIP              string  `mapstructure:"RATE_LIMIT_ADMIN_PER_IP" yaml:"-"`
+	ClientAuthMaxFailures            int     `mapstructure:"CLIENT_AUTH_MAX_FAILURES" yaml:"-"`
+	ClientAuthFailureWindow          string  `mapstructure:"CLIENT_AUTH_FAILURE_WINDOW" yaml:"-"`
+	ClientAuthLockout                string  `mapstructure:"CLIENT_AUTH_LOCKOUT" yaml:"-"`
+	TracingProvider                  string  `mapstructure:"TRACING_PROVIDER" yaml:"-"`
+	TracingServiceName               string  `mapstructure:"TRACING_SERVICE_NAME" yaml:"-"`
+	TracingJaegerAgentAddress        string  `mapstructure:"TRACING_JAEGER_AGENT_ADDRESS" yaml:"-"`
+	TracingSampleRate                float64 `mapstructure:"TRACING_SAMPLE_RATE" yaml:"-"`
+	ForceHTTP                        bool    `yaml:"-"`
 
 	BuildVersion string                  `yaml:"-"`
 	BuildHash    string                  `yaml:"-"`
@@ -93,13 +185,24 @@ func (c *Config) GetClusterURLWithoutTailingSlash() string {
 	return strings.TrimRight(c.ClusterURL, "/")
 }
 
+// GetScopeStrategy returns the strategy SCOPE_STRATEGY selects for matching requested scopes against granted scopes,
+// used by the token endpoint, token introspection and the warden alike.
 func (c *Config) GetScopeStrategy() fosite.ScopeStrategy {
-	if c.ScopeStrategy == "DEPRECATED_HIERARCHICAL_SCOPE_STRATEGY" {
-		c.GetLogger().Warn("Using deprecated hierarchical scope strategy, consider upgrading to wildcards.")
+	switch c.ScopeStrategy {
+	case "", "wildcard":
+		return fosite.WildcardScopeStrategy
+	case "exact":
+		return pkg.ExactScopeStrategy
+	case "hierarchic":
+		return fosite.HierarchicScopeStrategy
+	case "DEPRECATED_HIERARCHICAL_SCOPE_STRATEGY":
+		c.GetLogger().Warn("DEPRECATED_HIERARCHICAL_SCOPE_STRATEGY is deprecated, set SCOPE_STRATEGY=hierarchic instead.")
 		return fosite.HierarchicScopeStrategy
 	}
 
-	return fosite.WildcardScopeStrategy
+	// Falling back to another strategy could grant scopes the operator did not intend to grant.
+	c.GetLogger().Fatalf(`SCOPE_STRATEGY must be one of "exact", "wildcard" or "hierarchic", got "%s"`, c.ScopeStrategy)
+	return nil
 }
 
 func matchesRange(r *http.Request, ranges []string) error {
@@ -161,7 +264,7 @@ func (c *Config) DoesRequestSatisfyTermination(r *http.Request) error {
 		return errors.New("TLS termination is not enabled")
 	}
 
-	if r.URL.Path == health.HealthStatusPath {
+	if r.URL.Path == health.HealthStatusPath || r.URL.Path == health.HealthReadyPath {
 		return nil
 	}
 
@@ -189,6 +292,223 @@ func (c *Config) GetChallengeTokenLifespan() time.Duration {
 	return d
 }
 
+func (c *Config) GetSQLSlowQueryThreshold() time.Duration {
+	if c.SQLSlowQueryThreshold == "" {
+		return time.Second
+	}
+
+	d, err := time.ParseDuration(c.SQLSlowQueryThreshold)
+	if err != nil {
+		c.GetLogger().Warnf("Could not parse SQL slow query threshold value (%s). Defaulting to 1s", c.SQLSlowQueryThreshold)
+		return time.Second
+	}
+	return d
+}
+
+// GetRateLimit parses the rate limit value of the setting key. Invalid limits are fatal, so that a typo does not
+// silently disable rate limiting.
+func (c *Config) GetRateLimit(key, value string) ratelimit.Limit {
+	limit, err := ratelimit.ParseLimit(value)
+	if err != nil {
+		c.GetLogger().Fatalf("Could not parse %s: %s", key, err)
+	}
+	return limit
+}
+
+func (c *Config) GetClientAuthFailureWindow() time.Duration {
+	d, err := time.ParseDuration(c.ClientAuthFailureWindow)
+	if err != nil {
+		c.GetLogger().Warnf("Could not parse client authentication failure window value (%s). Defaulting to 5m", c.ClientAuthFailureWindow)
+		return time.Minute * 5
+	}
+	return d
+}
+
+func (c *Config) GetClientAuthLockout() time.Duration {
+	d, err := time.ParseDuration(c.ClientAuthLockout)
+	if err != nil {
+		c.GetLogger().Warnf("Could not parse client authentication lockout value (%s). Defaulting to 15m", c.ClientAuthLockout)
+		return time.Minute * 15
+	}
+	return d
+}
+
+func (c *Config) GetRevocationFilterInterval() time.Duration {
+	d, err := time.ParseDuration(c.RevocationFilterInterval)
+	if err != nil {
+		c.GetLogger().Warnf("Could not parse revocation filter interval value (%s). Defaulting to 10s", c.RevocationFilterInterval)
+		return time.Second * 10
+	}
+	return d
+}
+
+func (c *Config) GetRevocationFilterFalsePositiveRate() float64 {
+	if c.RevocationFilterFalsePositive <= 0 || c.RevocationFilterFalsePositive >= 1 {
+		c.GetLogger().Warnf("Revocation filter false positive rate value (%f) is not between 0 and 1. Defaulting to 0.001", c.RevocationFilterFalsePositive)
+		return 0.001
+	}
+	return c.RevocationFilterFalsePositive
+}
+
+func (c *Config) GetTracingSampleRate() float64 {
+	if c.TracingSampleRate < 0 || c.TracingSampleRate > 1 {
+		c.GetLogger().Warnf("Tracing sample rate value (%f) is not between 0 and 1. Defaulting to 1", c.TracingSampleRate)
+		return 1
+	}
+	return c.TracingSampleRate
+}
+
+func (c *Config) GetEventsDispatchInterval() time.Duration {
+	d, err := time.ParseDuration(c.EventsDispatchInterval)
+	if err != nil {
+		c.GetLogger().Warnf("Could not parse events dispatch interval value (%s). Defaulting to 5s", c.EventsDispatchInterval)
+		return time.Second * 5
+	}
+	return d
+}
+
+func (c *Config) GetEventsWebhookTimeout() time.Duration {
+	d, err := time.ParseDuration(c.EventsWebhookTimeout)
+	if err != nil || d <= 0 {
+		c.GetLogger().Warnf("Could not parse events webhook timeout value (%s). Defaulting to 10s", c.EventsWebhookTimeout)
+		return time.Second * 10
+	}
+	return d
+}
+
+func (c *Config) GetClaimsHookTimeout() time.Duration {
+	d, err := time.ParseDuration(c.ClaimsHookTimeout)
+	if err != nil || d <= 0 {
+		c.GetLogger().Warnf("Could not parse claims hook timeout value (%s). Defaulting to 5s", c.ClaimsHookTimeout)
+		return time.Second * 5
+	}
+	return d
+}
+
+func (c *Config) GetAuditLogWebhookTimeout() time.Duration {
+	d, err := time.ParseDuration(c.AuditLogWebhookTimeout)
+	if err != nil || d <= 0 {
+		c.GetLogger().Warnf("Could not parse audit log webhook timeout value (%s). Defaulting to 5s", c.AuditLogWebhookTimeout)
+		return time.Second * 5
+	}
+	return d
+}
+
+func (c *Config) GetConsentRequestCleanupInterval() time.Duration {
+	if c.ConsentRequestCleanupInterval == "" {
+		return time.Hour
+	}
+
+	d, err := time.ParseDuration(c.ConsentRequestCleanupInterval)
+	if err != nil {
+		c.GetLogger().Warnf("Could not parse consent request cleanup interval value (%s). Defaulting to 1h", c.ConsentRequestCleanupInterval)
+		return time.Hour
+	}
+	return d
+}
+
+// GetIssuersByHost parses ISSUER_BY_HOST, a comma separated list of host=issuer pairs, into a map of lower-case hosts
+// to issuers.
+func (c *Config) GetIssuersByHost() map[string]string {
+	issuers := map[string]string{}
+	for _, pair := range pkg.SplitNonEmpty(c.IssuerByHost, ",") {
+		parts := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			c.GetLogger().Warnf("Could not parse issuer by host value (%s), expected host=issuer. Ignoring it", pair)
+			continue
+		}
+		issuers[strings.ToLower(parts[0])] = parts[1]
+	}
+	return issuers
+}
+
+// GetOAuth2EmbedAllowedOrigins parses OAUTH2_EMBED_ALLOWED_ORIGINS, a comma separated list of origins which may embed
+// the authorization flow of first-party clients.
+func (c *Config) GetOAuth2EmbedAllowedOrigins() []string {
+	var origins []string
+	for _, origin := range pkg.SplitNonEmpty(c.OAuth2EmbedAllowedOrigins, ",") {
+		if origin = strings.TrimSuffix(strings.TrimSpace(origin), "/"); origin != "" {
+			origins = append(origins, strings.ToLower(origin))
+		}
+	}
+	return origins
+}
+
+// GetOAuth2IntrospectionCacheTTL returns how long the results of introspecting active tokens are cached. The cache
+// is disabled if zero.
+func (c *Config) GetOAuth2IntrospectionCacheTTL() time.Duration {
+	if c.OAuth2IntrospectionCacheTTL == "" {
+		return 0
+	}
+
+	d, err := time.ParseDuration(c.OAuth2IntrospectionCacheTTL)
+	if err != nil {
+		c.GetLogger().Warnf("Could not parse introspection cache ttl value (%s). Disabling the cache", c.OAuth2IntrospectionCacheTTL)
+		return 0
+	}
+	return d
+}
+
+// GetOAuth2IntrospectionCacheControlMaxAge returns how long trusted resource servers may cache introspection results.
+// Cache-Control hints are disabled if zero.
+func (c *Config) GetOAuth2IntrospectionCacheControlMaxAge() time.Duration {
+	if c.OAuth2IntrospectionMaxAge == "" {
+		return 0
+	}
+
+	d, err := time.ParseDuration(c.OAuth2IntrospectionMaxAge)
+	if err != nil {
+		c.GetLogger().Warnf("Could not parse introspection cache control max age value (%s). Disabling cache control hints", c.OAuth2IntrospectionMaxAge)
+		return 0
+	}
+	return d
+}
+
+// GetOAuth2IntrospectionCacheControlSubjects parses OAUTH2_INTROSPECTION_CACHE_CONTROL_SUBJECTS, a comma separated
+// list of the resource servers which may cache introspection results.
+func (c *Config) GetOAuth2IntrospectionCacheControlSubjects() []string {
+	var subjects []string
+	for _, subject := range pkg.SplitNonEmpty(c.OAuth2IntrospectionMaxAgeSubs, ",") {
+		if subject = strings.TrimSpace(subject); subject != "" {
+			subjects = append(subjects, subject)
+		}
+	}
+	return subjects
+}
+
+// GetTLSSubjectAlternativeNames returns the host names and IP addresses the self signed TLS certificate is issued for.
+func (c *Config) GetTLSSubjectAlternativeNames() []string {
+	var names []string
+	for _, name := range pkg.SplitNonEmpty(c.TLSSubjectAlternativeNames, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	if len(names) == 0 {
+		return []string{"localhost", "127.0.0.1", "::1", "host.docker.internal"}
+	}
+	return names
+}
+
+func (c *Config) GetConsentRequestParkLifespan() time.Duration {
+	d, err := time.ParseDuration(c.ConsentRequestParkLifespan)
+	if err != nil {
+		c.GetLogger().Warnf("Could not parse consent request park lifespan value (%s). Defaulting to 1h", c.ConsentRequestParkLifespan)
+		return time.Hour
+	}
+	return d
+}
+
+func (c *Config) GetPushedAuthorizationRequestLifespan() time.Duration {
+	d, err := time.ParseDuration(c.OAuth2PushedRequestLifespan)
+	if err != nil {
+		c.GetLogger().Warnf("Could not parse pushed authorization request lifespan value (%s). Defaulting to 1m", c.OAuth2PushedRequestLifespan)
+		return time.Minute
+	}
+	return d
+}
+
 func (c *Config) GetAccessTokenLifespan() time.Duration {
 	d, err := time.ParseDuration(c.AccessTokenLifespan)
 	if err != nil {
@@ -216,6 +536,210 @@ func (c *Config) GetIDTokenLifespan() time.Duration {
 	return d
 }
 
+func (c *Config) GetRSAKeyLength() int {
+	if c.RSAKeyLength == 0 {
+		return jwk.DefaultRSAKeyLength
+	} else if c.RSAKeyLength < jwk.MinRSAKeyLength {
+		c.GetLogger().Warnf("RSA key length %d is below the minimum of %d bits. Defaulting to %d", c.RSAKeyLength, jwk.MinRSAKeyLength, jwk.DefaultRSAKeyLength)
+		return jwk.DefaultRSAKeyLength
+	} else if c.RSAKeyLength > jwk.MaxRSAKeyLength {
+		c.GetLogger().Warnf("RSA key length %d is above the maximum of %d bits. Defaulting to %d", c.RSAKeyLength, jwk.MaxRSAKeyLength, jwk.DefaultRSAKeyLength)
+		return jwk.DefaultRSAKeyLength
+	}
+	return c.RSAKeyLength
+}
+
+// GetJWKKeyPolicy returns the policy uploaded JSON Web Keys must satisfy.
+func (c *Config) GetJWKKeyPolicy() *jwk.KeyPolicy {
+	return &jwk.KeyPolicy{
+		MinRSAKeySize:       c.JWKMinRSAKeySize,
+		MinECKeySize:        c.JWKMinECKeySize,
+		MinSymmetricKeySize: c.JWKMinSymmetricKeySize,
+		DeniedAlgorithms:    pkg.SplitNonEmpty(c.JWKDeniedAlgorithms, ","),
+	}
+}
+
+func (c *Config) GetJWKCacheTTL() time.Duration {
+	if c.JWKCacheTTL == "" {
+		return 0
+	}
+
+	d, err := time.ParseDuration(c.JWKCacheTTL)
+	if err != nil {
+		c.GetLogger().Warnf("Could not parse JSON Web Key cache ttl value (%s). Disabling the cache", c.JWKCacheTTL)
+		return 0
+	}
+	return d
+}
+
+func (c *Config) GetWardenCacheTTL() time.Duration {
+	if c.WardenCacheTTL == "" {
+		return 0
+	}
+
+	d, err := time.ParseDuration(c.WardenCacheTTL)
+	if err != nil {
+		c.GetLogger().Warnf("Could not parse warden cache ttl value (%s). Disabling the cache", c.WardenCacheTTL)
+		return 0
+	}
+	return d
+}
+
+func (c *Config) GetWardenElevationMaxDuration() time.Duration {
+	if c.WardenElevationMaxDuration == "" {
+		return time.Hour * 8
+	}
+
+	d, err := time.ParseDuration(c.WardenElevationMaxDuration)
+	if err != nil {
+		c.GetLogger().Warnf("Could not parse warden elevation max duration value (%s). Defaulting to 8h", c.WardenElevationMaxDuration)
+		return time.Hour * 8
+	}
+	return d
+}
+
+func (c *Config) GetWardenTrustedPeerKeysTTL() time.Duration {
+	if c.WardenTrustedPeerKeysTTL == "" {
+		return time.Minute * 10
+	}
+
+	d, err := time.ParseDuration(c.WardenTrustedPeerKeysTTL)
+	if err != nil {
+		c.GetLogger().Warnf("Could not parse trusted peer keys ttl value (%s). Defaulting to 10m", c.WardenTrustedPeerKeysTTL)
+		return time.Minute * 10
+	}
+	return d
+}
+
+func (c *Config) GetWardenAuthorizerTimeout() time.Duration {
+	if c.WardenAuthorizerTimeout == "" {
+		return time.Second * 5
+	}
+
+	d, err := time.ParseDuration(c.WardenAuthorizerTimeout)
+	if err != nil {
+		c.GetLogger().Warnf("Could not parse warden authorizer timeout value (%s). Defaulting to 5s", c.WardenAuthorizerTimeout)
+		return time.Second * 5
+	}
+	return d
+}
+
+// GetSubjectPseudonymizer returns the pseudonymizer for subjects written to logs and audit sinks, or nil if subjects
+// are not pseudonymized.
+func (c *Config) GetSubjectPseudonymizer() *pkg.Pseudonymizer {
+	if c.SubjectPseudonymizationKey == "" {
+		return nil
+	}
+	return &pkg.Pseudonymizer{Key: []byte(c.SubjectPseudonymizationKey)}
+}
+
+func (c *Config) GetJWKCleanupInterval() time.Duration {
+	if c.JWKCleanupInterval == "" {
+		return time.Hour
+	}
+
+	d, err := time.ParseDuration(c.JWKCleanupInterval)
+	if err != nil {
+		c.GetLogger().Warnf("Could not parse JSON Web Key cleanup interval value (%s). Defaulting to 1h", c.JWKCleanupInterval)
+		return time.Hour
+	}
+	return d
+}
+
+func (c *Config) GetWardenDecisionLogRetention() time.Duration {
+	if c.WardenDecisionLogRetention == "" {
+		return time.Hour * 24 * 30
+	}
+
+	d, err := time.ParseDuration(c.WardenDecisionLogRetention)
+	if err != nil {
+		c.GetLogger().Warnf("Could not parse warden decision log retention value (%s). Defaulting to 720h", c.WardenDecisionLogRetention)
+		return time.Hour * 24 * 30
+	}
+	return d
+}
+
+func (c *Config) GetJWKSCacheMaxAge() time.Duration {
+	if c.JWKSCacheMaxAge == "" {
+		return 0
+	}
+
+	d, err := time.ParseDuration(c.JWKSCacheMaxAge)
+	if err != nil {
+		c.GetLogger().Warnf("Could not parse JSON Web Key Set cache max age value (%s). Disabling Cache-Control", c.JWKSCacheMaxAge)
+		return 0
+	}
+	return d
+}
+
+func (c *Config) GetAccessTokenStrategy() string {
+	switch c.AccessTokenStrategy {
+	case "", hoa2.AccessTokenStrategyOpaque:
+		return hoa2.AccessTokenStrategyOpaque
+	case hoa2.AccessTokenStrategyJWT:
+		return hoa2.AccessTokenStrategyJWT
+	}
+	c.GetLogger().Warnf("Access token strategy %s is not supported. Defaulting to %s", c.AccessTokenStrategy, hoa2.AccessTokenStrategyOpaque)
+	return hoa2.AccessTokenStrategyOpaque
+}
+
+func (c *Config) GetAccessTokenPrefix() string {
+	return c.mustTokenPrefix("OAUTH2_ACCESS_TOKEN_PREFIX", c.OAuth2AccessTokenPrefix)
+}
+
+func (c *Config) GetRefreshTokenPrefix() string {
+	return c.mustTokenPrefix("OAUTH2_REFRESH_TOKEN_PREFIX", c.OAuth2RefreshTokenPrefix)
+}
+
+func (c *Config) GetClientSecretPrefix() string {
+	return c.mustTokenPrefix("OAUTH2_CLIENT_SECRET_PREFIX", c.OAuth2ClientSecretPrefix)
+}
+
+// mustTokenPrefix rejects prefixes containing dots, because they would make opaque tokens look like JSON Web Tokens
+// and break the separation of the token and its signature.
+func (c *Config) mustTokenPrefix(key, prefix string) string {
+	if strings.Contains(prefix, ".") {
+		c.GetLogger().Fatalf(`%s may not contain ".", got "%s"`, key, prefix)
+	}
+	return prefix
+}
+
+// GetClientSecretHasher returns the hasher of client secrets configured by CLIENT_SECRET_HASHER, BCRYPT_COST and the
+// ARGON2ID_* settings.
+func (c *Config) GetClientSecretHasher() *client.SecretHasher {
+	h := &client.SecretHasher{
+		Algorithm:  c.ClientSecretHasher,
+		BCryptCost: c.BCryptWorkFactor,
+		Argon2id:   client.DefaultArgon2idParams,
+	}
+
+	if c.Argon2idMemory > 0 {
+		h.Argon2id.Memory = uint32(c.Argon2idMemory)
+	}
+	if c.Argon2idIterations > 0 {
+		h.Argon2id.Iterations = uint32(c.Argon2idIterations)
+	}
+	if c.Argon2idParallelism > 0 && c.Argon2idParallelism < 256 {
+		h.Argon2id.Parallelism = uint8(c.Argon2idParallelism)
+	}
+
+	if err := h.Validate(); err != nil {
+		c.GetLogger().Fatalf("Client secret hasher is invalid: %s", err)
+	}
+	return h
+}
+
+func (c *Config) GetIDTokenSigningAlgorithm() string {
+	switch c.IDTokenSigningAlgorithm {
+	case "", "RS256":
+		return "RS256"
+	case "EdDSA":
+		return "EdDSA"
+	}
+	c.GetLogger().Warnf("ID token signing algorithm %s is not supported. Defaulting to RS256", c.IDTokenSigningAlgorithm)
+	return "RS256"
+}
+
 func (c *Config) Context() *Context {
 	if c.context != nil {
 		return c.context
@@ -238,10 +762,18 @@ func (c *Config) Context() *Context {
 		}
 
 		switch u.Scheme {
-		case "postgres":
+		case "postgres", "cockroach":
 			fallthrough
 		case "mysql":
 			connection = &SQLConnection{
+				URL:                u,
+				L:                  c.GetLogger(),
+				SlowQueryThreshold: c.GetSQLSlowQueryThreshold(),
+				SlowQueries:        c.GetMetrics().QueryStatistics,
+			}
+			break
+		case "redis", "rediss":
+			connection = &RedisConnection{
 				URL: u,
 				L:   c.GetLogger(),
 			}
@@ -265,6 +797,10 @@ func (c *Config) Context() *Context {
 			DB: con.GetDatabase(),
 		}
 		break
+	case *RedisConnection:
+		manager = &policy.RedisManager{DB: con.GetClient()}
+		groupManager = &group.RedisManager{DB: con.GetClient()}
+		break
 	case *PluginConnection:
 		var err error
 		manager, err = con.NewPolicyManager()
@@ -282,19 +818,18 @@ func (c *Config) Context() *Context {
 	}
 
 	c.context = &Context{
-		Connection: connection,
-		Hasher: &fosite.BCrypt{
-			WorkFactor: c.BCryptWorkFactor,
-		},
+		Connection:   connection,
+		Hasher:       c.GetClientSecretHasher(),
 		LadonManager: manager,
 		FositeStrategy: &foauth2.HMACSHAStrategy{
 			Enigma: &hmac.HMACStrategy{
-				GlobalSecret: c.GetSystemSecret(),
+				GlobalSecret: c.GetDerivedSecret(pkg.SecretPurposeTokenHMAC),
 			},
 			AccessTokenLifespan:   c.GetAccessTokenLifespan(),
 			AuthorizeCodeLifespan: c.GetAuthCodeLifespan(),
 		},
 		GroupManager: groupManager,
+		EventBus:     new(events.Bus),
 	}
 
 	return c.context
@@ -374,7 +909,16 @@ func (c *Config) GetCookieSecret() []byte {
 	if c.CookieSecret != "" {
 		return []byte(c.CookieSecret)
 	}
-	return c.GetSystemSecret()
+	return c.GetDerivedSecret(pkg.SecretPurposeCookie)
+}
+
+// GetDerivedSecret returns the key for purpose derived from the system secret using SYSTEM_SECRET_DERIVATION.
+func (c *Config) GetDerivedSecret(purpose string) []byte {
+	key, err := pkg.DeriveSecret(c.GetSystemSecret(), c.SystemSecretDerivation, purpose)
+	if err != nil {
+		c.GetLogger().Fatalf("Could not derive the %s key from SYSTEM_SECRET: %s", purpose, err)
+	}
+	return key
 }
 
 func (c *Config) GetSystemSecret() []byte {
@@ -17,6 +17,7 @@ package config
 import (
 	"github.com/ory/fosite"
 	"github.com/ory/fosite/handler/oauth2"
+	"github.com/ory/hydra/events"
 	"github.com/ory/hydra/firewall"
 	"github.com/ory/hydra/jwk"
 	hoa2 "github.com/ory/hydra/oauth2"
@@ -36,4 +37,7 @@ type Context struct {
 	KeyManager     jwk.Manager
 	ConsentManager hoa2.ConsentRequestManager
 	GroupManager   group.Manager
+
+	// EventBus delivers events to in-process subscribers. Subscribers must be registered before the server starts.
+	EventBus *events.Bus
 }
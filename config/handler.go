@@ -0,0 +1,95 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/herodot"
+	"github.com/ory/hydra/firewall"
+)
+
+const ConfigHandlerPath = "/admin/config"
+
+// Handler exposes the configuration of this instance.
+type Handler struct {
+	Config         *Config
+	H              herodot.Writer
+	W              firewall.Firewall
+	ResourcePrefix string
+}
+
+func (h *Handler) PrefixResource(resource string) string {
+	if h.ResourcePrefix == "" {
+		h.ResourcePrefix = "rn:hydra"
+	}
+
+	if h.ResourcePrefix[len(h.ResourcePrefix)-1] == ':' {
+		h.ResourcePrefix = h.ResourcePrefix[:len(h.ResourcePrefix)-1]
+	}
+
+	return h.ResourcePrefix + ":" + resource
+}
+
+func (h *Handler) SetRoutes(r *httprouter.Router) {
+	r.GET(ConfigHandlerPath, h.Get)
+}
+
+// swagger:route GET /admin/config admin getConfiguration
+//
+// Show the configuration of this instance
+//
+// This endpoint returns the configuration this instance loaded, sorted by key. Every setting states whether it was
+// set as environment variable, in the configuration file, or whether the default value applies. Secrets are
+// redacted, as are passwords in URLs such as DATABASE_URL. Be aware that the configuration refers to a single
+// instance only.
+//
+// The subject making the request needs to be assigned to a policy containing:
+//
+//  ```
+//  {
+//    "resources": ["rn:hydra:admin:config"],
+//    "actions": ["get"],
+//    "effect": "allow"
+//  }
+//  ```
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Security:
+//       oauth2: hydra.admin
+//
+//     Responses:
+//       200: configurationSettings
+//       401: genericError
+//       403: genericError
+//       500: genericError
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var ctx = context.Background()
+	if _, err := h.W.TokenAllowed(ctx, h.W.TokenFromRequest(r), &firewall.TokenAccessRequest{
+		Resource: h.PrefixResource("admin:config"),
+		Action:   "get",
+	}, "hydra.admin"); err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	h.H.Write(w, r, h.Config.Settings())
+}
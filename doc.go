@@ -31,11 +31,19 @@
 //           hydra.keys.create: "A scope required to create JSON Web Keys"
 //           hydra.keys.delete: "A scope required to delete JSON Web Keys"
 //           hydra.keys.update: "A scope required to get JSON Web Keys"
+//           hydra.keys.export: "A scope required to export private JSON Web Keys as PEM"
+//           hydra.keys.delegate: "A scope required to create credentials which may only manage a single JSON Web Key Set"
 //           hydra.consent: "A scope required to fetch and modify consent requests"
+//           hydra.admin: "A scope required to show the configuration of an instance"
 //           offline: "A scope required when requesting refresh tokens"
 //           openid: "Request an OpenID Connect ID Token"
 //     basic:
 //         type: basic
+//     wardenApiKey:
+//         type: apiKey
+//         name: Authorization
+//         in: header
+//         description: "A pre-shared key sent as bearer token, for example \"Authorization: Bearer <key>\""
 //
 //     Extensions:
 //     ---
@@ -0,0 +1,215 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package erasure erases the data Hydra stores about a subject to fulfill right-to-erasure requests.
+package erasure
+
+import (
+	"context"
+	"time"
+
+	"github.com/ory/hydra/oauth2"
+	"github.com/ory/hydra/pkg"
+	"github.com/ory/hydra/warden/decision"
+	"github.com/ory/hydra/warden/elevation"
+	"github.com/ory/hydra/warden/group"
+	"github.com/pkg/errors"
+)
+
+// Report lists the data that was erased about a subject.
+//
+// swagger:model erasureReport
+type Report struct {
+	// Subject is the subject whose data was erased.
+	Subject string `json:"subject"`
+
+	// Pseudonym is the pseudonym the subject appears as in logs, audit sinks and the token history. It is empty if
+	// subjects are not pseudonymized. Logs and audit sinks are not managed by Hydra and must be purged using this
+	// pseudonym.
+	Pseudonym string `json:"pseudonym,omitempty"`
+
+	// ErasedAt is the time the data was erased.
+	ErasedAt time.Time `json:"erased_at"`
+
+	// RevokedTokenRequests are the ids of the requests whose access and refresh tokens were revoked and deleted.
+	RevokedTokenRequests []string `json:"revoked_token_requests"`
+
+	// AnonymizedTokenRecords is the number of token history records whose subject was replaced by the pseudonym.
+	AnonymizedTokenRecords int `json:"anonymized_token_records"`
+
+	// ConsentGrants are the ids of the clients whose consent grants were deleted.
+	ConsentGrants []string `json:"consent_grants"`
+
+	// RememberedConsents are the ids of the clients whose remembered consent decisions were deleted.
+	RememberedConsents []string `json:"remembered_consents"`
+
+	// LoginSessions is the number of login sessions that were ended.
+	LoginSessions int `json:"login_sessions"`
+
+	// LoginRequests is the number of login requests of the subject that were deleted.
+	LoginRequests int `json:"login_requests"`
+
+	// Groups are the ids of the warden groups the subject was removed from.
+	Groups []string `json:"groups"`
+
+	// Decisions is the number of recorded warden decisions that were deleted.
+	Decisions int `json:"decisions"`
+
+	// Elevations is the number of warden elevations requested by the subject that were deleted.
+	Elevations int `json:"elevations"`
+}
+
+// Eraser erases the data stored about a subject. Stores which are nil, for example because the feature storing the
+// data is disabled, are skipped.
+//
+// Consent requests are not erased, they are removed when expired consent requests are flushed. Erasing a subject does
+// not prevent it from signing in again, which creates new data.
+type Eraser struct {
+	Tokens             pkg.FositeStorer
+	TokenHistory       oauth2.TokenHistoryManager
+	ConsentGrants      oauth2.ConsentGrantManager
+	RememberedConsents oauth2.RememberedConsentManager
+	LoginSessions      oauth2.LoginSessionManager
+	LoginRequests      oauth2.LoginRequestManager
+	Groups             group.Manager
+	Decisions          decision.Manager
+	Elevations         elevation.Manager
+
+	// Subjects, if set, is used to compute the pseudonym of the subject.
+	Subjects *pkg.Pseudonymizer
+}
+
+// Erase erases all data stored about subject and reports what was erased. Erase is idempotent, if it fails it can be
+// called again to erase the remaining data.
+func (e *Eraser) Erase(ctx context.Context, subject string) (*Report, error) {
+	if subject == "" {
+		return nil, errors.New("Subject must not be empty")
+	}
+
+	report := &Report{
+		Subject:              subject,
+		ErasedAt:             time.Now().UTC().Round(time.Second),
+		RevokedTokenRequests: []string{},
+		ConsentGrants:        []string{},
+		RememberedConsents:   []string{},
+		Groups:               []string{},
+	}
+	if pseudonym := e.Subjects.Pseudonymize(subject); pseudonym != subject {
+		report.Pseudonym = pseudonym
+	}
+
+	if e.Tokens != nil {
+		requestIDs, err := e.Tokens.RevokeSubjectTokens(ctx, subject)
+		if err != nil {
+			return nil, err
+		}
+		report.RevokedTokenRequests = append(report.RevokedTokenRequests, requestIDs...)
+	}
+
+	if e.TokenHistory != nil {
+		n, err := e.TokenHistory.AnonymizeSubjectTokenRecords(subject, report.Pseudonym)
+		if err != nil {
+			return nil, err
+		}
+		report.AnonymizedTokenRecords = n
+	}
+
+	if e.ConsentGrants != nil {
+		grants, err := e.ConsentGrants.GetConsentGrants(subject)
+		if err != nil {
+			return nil, err
+		}
+		for _, grant := range grants {
+			if err := e.ConsentGrants.DeleteConsentGrant(subject, grant.ClientID); err != nil && errors.Cause(err) != pkg.ErrNotFound {
+				return nil, err
+			}
+			report.ConsentGrants = append(report.ConsentGrants, grant.ClientID)
+		}
+	}
+
+	if e.RememberedConsents != nil {
+		clientIDs, err := e.RememberedConsents.ForgetSubjectConsents(subject)
+		if err != nil {
+			return nil, err
+		}
+		report.RememberedConsents = append(report.RememberedConsents, clientIDs...)
+	}
+
+	if e.LoginSessions != nil {
+		n, err := e.LoginSessions.DeleteSubjectLoginSessions(subject)
+		if err != nil {
+			return nil, err
+		}
+		report.LoginSessions = n
+	}
+
+	if e.LoginRequests != nil {
+		n, err := e.LoginRequests.DeleteSubjectLoginRequests(subject)
+		if err != nil {
+			return nil, err
+		}
+		report.LoginRequests = n
+	}
+
+	if e.Groups != nil {
+		groups, err := e.findGroups(subject)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range groups {
+			if err := e.Groups.RemoveGroupMembers(id, []string{subject}); err != nil {
+				return nil, err
+			}
+			report.Groups = append(report.Groups, id)
+		}
+	}
+
+	if e.Decisions != nil {
+		n, err := e.Decisions.DeleteSubjectDecisions(subject)
+		if err != nil {
+			return nil, err
+		}
+		report.Decisions = n
+	}
+
+	if e.Elevations != nil {
+		n, err := e.Elevations.DeleteSubjectElevations(subject)
+		if err != nil {
+			return nil, err
+		}
+		report.Elevations = n
+	}
+
+	return report, nil
+}
+
+// findGroups returns the ids of all groups subject is a member of. All pages are collected before the subject is
+// removed from any group, so that removing memberships does not shift the pages.
+func (e *Eraser) findGroups(subject string) ([]string, error) {
+	const limit = 100
+
+	ids := []string{}
+	for offset := 0; ; offset += limit {
+		groups, err := e.Groups.FindGroupsByMember(subject, limit, offset)
+		if err != nil {
+			return nil, err
+		}
+		for _, g := range groups {
+			ids = append(ids, g.ID)
+		}
+		if len(groups) < limit {
+			return ids, nil
+		}
+	}
+}
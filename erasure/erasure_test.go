@@ -0,0 +1,138 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package erasure_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ory/fosite"
+	"github.com/ory/hydra/client"
+	. "github.com/ory/hydra/erasure"
+	"github.com/ory/hydra/oauth2"
+	"github.com/ory/hydra/pkg"
+	"github.com/ory/hydra/warden/decision"
+	"github.com/ory/hydra/warden/elevation"
+	"github.com/ory/hydra/warden/group"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEraser(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC().Round(time.Second)
+
+	tokens := oauth2.NewFositeMemoryStore(client.NewMemoryManager(&fosite.BCrypt{WorkFactor: 4}), time.Hour)
+	for id, subject := range map[string]string{"alice-request": "alice", "bob-request": "bob"} {
+		require.NoError(t, tokens.CreateAccessTokenSession(ctx, id+"-signature", &fosite.Request{
+			ID:          id,
+			Client:      &client.Client{ID: "photos"},
+			Session:     oauth2.NewSession(subject),
+			RequestedAt: now,
+		}))
+	}
+
+	history := oauth2.NewTokenHistoryMemoryManager()
+	require.NoError(t, history.CreateTokenRecord(&oauth2.TokenRecord{Signature: "alice-request-signature", TokenType: fosite.AccessToken, RequestID: "alice-request", ClientID: "photos", Subject: "alice", IssuedAt: now}))
+
+	grants := oauth2.NewConsentGrantMemoryManager()
+	require.NoError(t, grants.RecordConsentGrant("alice", "photos", []string{"openid"}, now))
+	require.NoError(t, grants.RecordConsentGrant("bob", "photos", []string{"openid"}, now))
+
+	remembered := oauth2.NewRememberedConsentMemoryManager()
+	require.NoError(t, remembered.RememberConsent(&oauth2.RememberedConsent{Subject: "alice", ClientID: "contacts", RememberedAt: now, ExpiresAt: now.Add(time.Hour)}))
+
+	sessions := oauth2.NewLoginSessionMemoryManager()
+	require.NoError(t, sessions.AddLoginSessionClient("alice-session", "alice", "photos"))
+	require.NoError(t, sessions.AddLoginSessionClient("bob-session", "bob", "photos"))
+
+	logins := oauth2.NewLoginRequestMemoryManager()
+	require.NoError(t, logins.CreateLoginRequest(&oauth2.LoginRequest{Challenge: "alice-challenge", Verifier: "alice-verifier", Subject: "alice", ExpiresAt: now.Add(time.Hour), RequestedAt: now}))
+	require.NoError(t, logins.CreateLoginRequest(&oauth2.LoginRequest{Challenge: "bob-challenge", Verifier: "bob-verifier", Subject: "bob", ExpiresAt: now.Add(time.Hour), RequestedAt: now}))
+
+	groups := group.NewMemoryManager()
+	require.NoError(t, groups.CreateGroup(&group.Group{ID: "admins", Members: []string{"alice", "bob"}}))
+	require.NoError(t, groups.CreateGroup(&group.Group{ID: "editors", Members: []string{"bob"}}))
+
+	decisions := decision.NewMemoryManager()
+	require.NoError(t, decisions.AddDecision(&decision.Decision{Subject: "alice", CreatedAt: now}))
+	require.NoError(t, decisions.AddDecision(&decision.Decision{Subject: "bob", CreatedAt: now}))
+
+	elevations := elevation.NewMemoryManager()
+	require.NoError(t, elevations.CreateElevation(&elevation.Elevation{Subject: "alice", Status: elevation.StatusPending, RequestedAt: now}))
+	require.NoError(t, elevations.CreateElevation(&elevation.Elevation{Subject: "bob", Status: elevation.StatusPending, RequestedAt: now}))
+
+	e := &Eraser{
+		Tokens:             tokens,
+		TokenHistory:       history,
+		ConsentGrants:      grants,
+		RememberedConsents: remembered,
+		LoginSessions:      sessions,
+		LoginRequests:      logins,
+		Groups:             groups,
+		Decisions:          decisions,
+		Elevations:         elevations,
+		Subjects:           &pkg.Pseudonymizer{Key: []byte("secret")},
+	}
+
+	_, err := e.Erase(ctx, "")
+	assert.Error(t, err)
+
+	report, err := e.Erase(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", report.Subject)
+	assert.Equal(t, e.Subjects.Pseudonymize("alice"), report.Pseudonym)
+	assert.Equal(t, []string{"alice-request"}, report.RevokedTokenRequests)
+	assert.Equal(t, 1, report.AnonymizedTokenRecords)
+	assert.Equal(t, []string{"photos"}, report.ConsentGrants)
+	assert.Equal(t, []string{"contacts"}, report.RememberedConsents)
+	assert.Equal(t, 1, report.LoginSessions)
+	assert.Equal(t, 1, report.LoginRequests)
+	assert.Equal(t, []string{"admins"}, report.Groups)
+	assert.Equal(t, 1, report.Decisions)
+	assert.Equal(t, 1, report.Elevations)
+
+	_, err = tokens.GetAccessTokenSession(ctx, "bob-request-signature", oauth2.NewSession(""))
+	require.NoError(t, err)
+
+	record, err := history.GetTokenRecord("alice-request-signature")
+	require.NoError(t, err)
+	assert.Equal(t, report.Pseudonym, record.Subject)
+
+	_, err = sessions.GetLoginSession("alice-session")
+	assert.Equal(t, pkg.ErrNotFound, errors.Cause(err))
+
+	admins, err := groups.GetGroup("admins")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bob"}, admins.Members)
+
+	report, err = e.Erase(ctx, "alice")
+	require.NoError(t, err)
+	assert.Empty(t, report.RevokedTokenRequests)
+	assert.Empty(t, report.ConsentGrants)
+	assert.Empty(t, report.RememberedConsents)
+	assert.Empty(t, report.Groups)
+	assert.Equal(t, 0, report.LoginSessions)
+	assert.Equal(t, 0, report.LoginRequests)
+	assert.Equal(t, 0, report.Decisions)
+	assert.Equal(t, 0, report.Elevations)
+
+	report, err = (&Eraser{}).Erase(ctx, "bob")
+	require.NoError(t, err)
+	assert.Empty(t, report.Pseudonym)
+	assert.Empty(t, report.RevokedTokenRequests)
+}
@@ -0,0 +1,135 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package erasure
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/herodot"
+	"github.com/ory/hydra/firewall"
+	"github.com/pkg/errors"
+)
+
+const (
+	// ErasureHandlerPath points to the endpoint erasing the data of a subject. The subject is sent in the request body
+	// rather than the path, so that it does not end up in access logs.
+	ErasureHandlerPath = "/subjects/erasure"
+
+	// SubjectResource is the resource on which the action "erase" must be allowed to erase the data of a subject.
+	SubjectResource = "subjects:%s"
+	Scope           = "hydra.subjects.erase"
+)
+
+// ErasureRequest identifies the subject whose data is erased.
+//
+// swagger:model erasureRequest
+type ErasureRequest struct {
+	// Subject is the subject whose data is erased.
+	Subject string `json:"subject"`
+}
+
+type Handler struct {
+	Eraser *Eraser
+	H      herodot.Writer
+	W      firewall.Firewall
+
+	ResourcePrefix string
+}
+
+func (h *Handler) PrefixResource(resource string) string {
+	if h.ResourcePrefix == "" {
+		h.ResourcePrefix = "rn:hydra"
+	}
+
+	if h.ResourcePrefix[len(h.ResourcePrefix)-1] == ':' {
+		h.ResourcePrefix = h.ResourcePrefix[:len(h.ResourcePrefix)-1]
+	}
+
+	return h.ResourcePrefix + ":" + resource
+}
+
+func (h *Handler) SetRoutes(r *httprouter.Router) {
+	r.POST(ErasureHandlerPath, h.EraseSubject)
+}
+
+// swagger:route POST /subjects/erasure erasure eraseSubject
+//
+// Erase all data stored about a subject
+//
+// This endpoint fulfills right-to-erasure requests. It revokes and deletes the access and refresh tokens issued on
+// behalf of the subject, replaces the subject in the token history with its pseudonym, deletes the subject's consent
+// grants, remembered consent decisions, login sessions, login requests, recorded warden decisions and requested warden
+// elevations, and removes the subject from all warden groups.
+//
+// The response reports what was erased, including the pseudonym the subject appears as in logs and audit sinks if
+// SUBJECT_PSEUDONYMIZATION_KEY is set. Logs and audit sinks are not managed by this server and must be purged using
+// that pseudonym. Erasing a subject twice is safe, the second report lists only data created in between.
+//
+// The subject making the request needs to be assigned to a policy containing:
+//
+//  ```
+//  {
+//    "resources": ["rn:hydra:subjects:<subject>"],
+//    "actions": ["erase"],
+//    "effect": "allow"
+//  }
+//  ```
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Security:
+//       oauth2: hydra.subjects.erase
+//
+//     Responses:
+//       200: erasureReport
+//       400: genericError
+//       401: genericError
+//       403: genericError
+//       500: genericError
+func (h *Handler) EraseSubject(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var request ErasureRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, errors.WithStack(err))
+		return
+	} else if request.Subject == "" {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, errors.New("Field subject must not be empty"))
+		return
+	}
+
+	if _, err := h.W.TokenAllowed(r.Context(), h.W.TokenFromRequest(r), &firewall.TokenAccessRequest{
+		Resource: fmt.Sprintf(h.PrefixResource(SubjectResource), request.Subject),
+		Action:   "erase",
+	}, Scope); err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	report, err := h.Eraser.Erase(r.Context(), request.Subject)
+	if err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	h.H.Write(w, r, report)
+}
@@ -0,0 +1,102 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Subscriber receives events from a Bus. If it returns an error, the event is delivered again later.
+type Subscriber func(e *Event) error
+
+type subscription struct {
+	types map[string]bool
+	fn    Subscriber
+}
+
+// Bus delivers events to in-process subscribers. Subscribers receive events from the outbox, so an event is only
+// delivered once the state change it describes was committed, and it may be delivered more than once. Subscribe
+// must be called before the server is started, otherwise events are not stored in the outbox unless a webhook is
+// configured.
+type Bus struct {
+	sync.RWMutex
+	subscriptions []subscription
+}
+
+// Subscribe registers fn for events of the given types, or for all events if no type is given.
+func (b *Bus) Subscribe(fn Subscriber, types ...string) {
+	s := subscription{fn: fn}
+	if len(types) > 0 {
+		s.types = map[string]bool{}
+		for _, t := range types {
+			s.types[t] = true
+		}
+	}
+
+	b.Lock()
+	defer b.Unlock()
+	b.subscriptions = append(b.subscriptions, s)
+}
+
+// HasSubscribers returns true if at least one subscriber was registered.
+func (b *Bus) HasSubscribers() bool {
+	b.RLock()
+	defer b.RUnlock()
+	return len(b.subscriptions) > 0
+}
+
+// Publish delivers e to all subscribers of its type. All subscribers are called even if one of them fails.
+func (b *Bus) Publish(e *Event) error {
+	b.RLock()
+	subscriptions := b.subscriptions
+	b.RUnlock()
+
+	var failed []string
+	for _, s := range subscriptions {
+		if s.types != nil && !s.types[e.Type] {
+			continue
+		}
+
+		if err := s.fn(e); err != nil {
+			failed = append(failed, err.Error())
+		}
+	}
+
+	if len(failed) > 0 {
+		return errors.Errorf("%d subscribers could not process event %s: %s", len(failed), e.ID, strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// MultiPublisher delivers events to all of its publishers. If any of them fails, the event is delivered to all of
+// them again later.
+type MultiPublisher []Publisher
+
+func (p MultiPublisher) Publish(e *Event) error {
+	var failed []string
+	for _, publisher := range p {
+		if err := publisher.Publish(e); err != nil {
+			failed = append(failed, err.Error())
+		}
+	}
+
+	if len(failed) > 0 {
+		return errors.Errorf("Could not deliver event %s to %d of %d publishers: %s", e.ID, len(failed), len(p), strings.Join(failed, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBus(t *testing.T) {
+	b := new(Bus)
+	assert.False(t, b.HasSubscribers())
+
+	var all, clients []string
+	b.Subscribe(func(e *Event) error {
+		all = append(all, e.Type)
+		return nil
+	})
+	b.Subscribe(func(e *Event) error {
+		clients = append(clients, e.Type)
+		return nil
+	}, ClientCreated, ClientDeleted)
+	assert.True(t, b.HasSubscribers())
+
+	for _, typ := range []string{ClientCreated, KeyRotated, ClientDeleted} {
+		e, err := NewEvent(typ, nil)
+		require.NoError(t, err)
+		require.NoError(t, b.Publish(e))
+	}
+	assert.Equal(t, []string{ClientCreated, KeyRotated, ClientDeleted}, all)
+	assert.Equal(t, []string{ClientCreated, ClientDeleted}, clients)
+
+	b.Subscribe(func(e *Event) error {
+		return errors.New("failing")
+	})
+	e, err := NewEvent(TokenRevoked, nil)
+	require.NoError(t, err)
+	assert.Error(t, b.Publish(e))
+	assert.Equal(t, TokenRevoked, all[len(all)-1])
+}
+
+func TestMultiPublisher(t *testing.T) {
+	var received int
+	b := new(Bus)
+	b.Subscribe(func(e *Event) error {
+		received++
+		return nil
+	})
+
+	failing := new(Bus)
+	failing.Subscribe(func(e *Event) error {
+		return errors.New("failing")
+	})
+
+	e, err := NewEvent(ClientCreated, nil)
+	require.NoError(t, err)
+	assert.NoError(t, MultiPublisher{b}.Publish(e))
+	assert.Error(t, MultiPublisher{failing, b}.Publish(e))
+	assert.Equal(t, 2, received)
+}
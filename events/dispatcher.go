@@ -0,0 +1,184 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Publisher delivers events to consumers.
+type Publisher interface {
+	Publish(e *Event) error
+}
+
+const (
+	// SignatureHeader carries the HMAC-SHA256 signature of the request body as "sha256=<hex>".
+	SignatureHeader = "X-Hydra-Signature"
+
+	// EventTypeHeader carries the type of the delivered event.
+	EventTypeHeader = "X-Hydra-Event-Type"
+
+	// EventIDHeader carries the id of the delivered event.
+	EventIDHeader = "X-Hydra-Event-ID"
+)
+
+// Sign returns the value of SignatureHeader for body.
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature returns true if signature, the value of SignatureHeader, is the signature of body. Consumers of
+// webhooks can use it to verify that an event was sent by Hydra.
+func VerifySignature(secret, body []byte, signature string) bool {
+	if !strings.HasPrefix(signature, "sha256=") {
+		return false
+	}
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
+
+// WebhookPublisher delivers events by sending them as JSON in a POST request to URL. If Secret is set, the request
+// body is signed with it, see SignatureHeader.
+type WebhookPublisher struct {
+	URL    string
+	Secret []byte
+	Client *http.Client
+}
+
+func (p *WebhookPublisher) Publish(e *Event) error {
+	out, err := json.Marshal(e)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	c := p.Client
+	if c == nil {
+		c = http.DefaultClient
+	}
+
+	req, err := http.NewRequest("POST", p.URL, bytes.NewReader(out))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventTypeHeader, e.Type)
+	req.Header.Set(EventIDHeader, e.ID)
+	if len(p.Secret) > 0 {
+		req.Header.Set(SignatureHeader, Sign(p.Secret, out))
+	}
+
+	res, err := c.Do(req)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return errors.Errorf("Expected 2xx status code from webhook %s but got %d", p.URL, res.StatusCode)
+	}
+	return nil
+}
+
+// Dispatcher periodically delivers pending events from the outbox. Failed deliveries are retried with an
+// exponential backoff, capped at MaxBackoff.
+type Dispatcher struct {
+	Outbox     *SQLOutbox
+	Publisher  Publisher
+	Interval   time.Duration
+	BatchSize  int
+	MaxBackoff time.Duration
+	L          logrus.FieldLogger
+}
+
+// Run dispatches pending events until stop is closed. A nil stop channel runs the dispatcher until the process exits.
+func (d *Dispatcher) Run(stop <-chan struct{}) {
+	interval := d.Interval
+	if interval == 0 {
+		interval = time.Second * 5
+	}
+
+	for {
+		if _, err := d.DispatchPending(); err != nil {
+			d.L.WithError(err).Errorln("Could not dispatch events from the outbox")
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// DispatchPending delivers one batch of pending events and returns the number of events delivered.
+func (d *Dispatcher) DispatchPending() (int, error) {
+	limit := d.BatchSize
+	if limit == 0 {
+		limit = 100
+	}
+
+	pending, err := d.Outbox.Pending(limit)
+	if err != nil {
+		return 0, err
+	}
+
+	var delivered int
+	for _, e := range pending {
+		if err := d.Publisher.Publish(e.Event); err != nil {
+			attempts := e.Attempts + 1
+			next := time.Now().UTC().Add(d.backoff(attempts))
+			d.L.WithError(err).Warnf("Could not deliver event %s, retrying at %s", e.ID, next)
+			if err := d.Outbox.MarkFailed(e.ID, attempts, next); err != nil {
+				return delivered, err
+			}
+			continue
+		}
+
+		if err := d.Outbox.MarkDelivered(e.ID); err != nil {
+			return delivered, err
+		}
+		delivered++
+	}
+
+	return delivered, nil
+}
+
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	max := d.MaxBackoff
+	if max == 0 {
+		max = time.Hour
+	}
+
+	if attempts > 20 {
+		return max
+	}
+
+	b := time.Second * time.Duration(1<<uint(attempts))
+	if b > max {
+		return max
+	}
+	return b
+}
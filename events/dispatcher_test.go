@@ -0,0 +1,72 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookPublisher(t *testing.T) {
+	var received Event
+	var header http.Header
+	var body []byte
+	var status = http.StatusNoContent
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var err error
+		body, err = ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(body, &received))
+		header = r.Header
+		w.WriteHeader(status)
+	}))
+	defer ts.Close()
+
+	e, err := NewEvent(ClientCreated, map[string]string{"client_id": "foo"})
+	require.NoError(t, err)
+
+	p := &WebhookPublisher{URL: ts.URL}
+	require.NoError(t, p.Publish(e))
+	assert.Equal(t, e.ID, received.ID)
+	assert.Equal(t, ClientCreated, received.Type)
+	assert.JSONEq(t, `{"client_id":"foo"}`, string(received.Payload))
+	assert.Equal(t, ClientCreated, header.Get(EventTypeHeader))
+	assert.Equal(t, e.ID, header.Get(EventIDHeader))
+	assert.Empty(t, header.Get(SignatureHeader))
+
+	p.Secret = []byte("secret")
+	require.NoError(t, p.Publish(e))
+	assert.True(t, VerifySignature([]byte("secret"), body, header.Get(SignatureHeader)))
+	assert.False(t, VerifySignature([]byte("other-secret"), body, header.Get(SignatureHeader)))
+	assert.False(t, VerifySignature([]byte("secret"), append(body, ' '), header.Get(SignatureHeader)))
+
+	status = http.StatusInternalServerError
+	assert.Error(t, p.Publish(e))
+}
+
+func TestDispatcherBackoff(t *testing.T) {
+	d := &Dispatcher{MaxBackoff: time.Minute}
+	assert.Equal(t, time.Second*2, d.backoff(1))
+	assert.Equal(t, time.Second*8, d.backoff(3))
+	assert.Equal(t, time.Minute, d.backoff(10))
+	assert.Equal(t, time.Minute, d.backoff(100))
+}
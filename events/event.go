@@ -0,0 +1,70 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package events implements a transactional outbox. Events are persisted in the same SQL transaction as the state
+// change they describe and are delivered asynchronously by a Dispatcher, which guarantees at-least-once delivery
+// even if the process crashes after the state change was committed.
+package events
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+)
+
+const (
+	ClientCreated = "client.created"
+	ClientUpdated = "client.updated"
+	ClientDeleted = "client.deleted"
+
+	KeyCreated    = "jwk.key.created"
+	KeyUpdated    = "jwk.key.updated"
+	KeyDeleted    = "jwk.key.deleted"
+	KeySetDeleted = "jwk.set.deleted"
+
+	// KeyRotated is emitted when keys are added to a set which already contains keys, for example when a new
+	// signing key is appended. KeyCreated is emitted for each of the added keys as well.
+	KeyRotated = "jwk.key.rotated"
+
+	// ConsentAccepted is emitted when the user granted consent.
+	ConsentAccepted = "consent.accepted"
+	ConsentRejected = "consent.rejected"
+
+	TokenRevoked = "token.revoked"
+)
+
+// Event is a state change that is delivered to consumers. Consumers may receive an event more than once and
+// should use the ID to discard duplicates.
+type Event struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+func NewEvent(typ string, payload interface{}) (*Event, error) {
+	out, err := json.Marshal(payload)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &Event{
+		ID:        uuid.New(),
+		Type:      typ,
+		Payload:   out,
+		CreatedAt: time.Now().UTC().Round(time.Second),
+	}, nil
+}
@@ -0,0 +1,149 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/ory/hydra/pkg"
+	"github.com/pkg/errors"
+	"github.com/rubenv/sql-migrate"
+)
+
+var migrations = &migrate.MemoryMigrationSource{
+	Migrations: []*migrate.Migration{
+		{
+			Id: "1",
+			Up: []string{`CREATE TABLE IF NOT EXISTS hydra_event_outbox (
+	id      		varchar(36) NOT NULL PRIMARY KEY,
+	event_type		varchar(64) NOT NULL,
+	payload			text NOT NULL,
+	created_at		timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	attempts		int NOT NULL DEFAULT 0,
+	next_attempt_at	timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP
+)`},
+			Down: []string{
+				"DROP TABLE hydra_event_outbox",
+			},
+		},
+	},
+}
+
+// Outbox stores events as part of a SQL transaction.
+type Outbox interface {
+	Enqueue(tx *sqlx.Tx, e *Event) error
+}
+
+type sqlData struct {
+	ID            string    `db:"id"`
+	Type          string    `db:"event_type"`
+	Payload       string    `db:"payload"`
+	CreatedAt     time.Time `db:"created_at"`
+	Attempts      int       `db:"attempts"`
+	NextAttemptAt time.Time `db:"next_attempt_at"`
+}
+
+// PendingEvent is an event that has not been delivered yet.
+type PendingEvent struct {
+	*Event
+	Attempts int
+}
+
+type SQLOutbox struct {
+	DB *sqlx.DB
+}
+
+// Migrations returns the SQL migrations embedded in the binary.
+func (o *SQLOutbox) Migrations() *migrate.MemoryMigrationSource {
+	return migrations
+}
+
+func (o *SQLOutbox) CreateSchemas() (int, error) {
+	migrate.SetTable("hydra_event_outbox_migration")
+	n, err := migrate.Exec(o.DB.DB, o.DB.DriverName(), migrations, migrate.Up)
+	if err != nil {
+		return 0, errors.Wrapf(err, "Could not migrate sql schema, applied %d migrations", n)
+	}
+	return n, nil
+}
+
+func (o *SQLOutbox) Enqueue(tx *sqlx.Tx, e *Event) error {
+	if _, err := tx.NamedExec(`INSERT INTO hydra_event_outbox (id, event_type, payload, created_at, attempts, next_attempt_at) VALUES (:id, :event_type, :payload, :created_at, :attempts, :next_attempt_at)`, &sqlData{
+		ID:            e.ID,
+		Type:          e.Type,
+		Payload:       string(e.Payload),
+		CreatedAt:     e.CreatedAt,
+		NextAttemptAt: e.CreatedAt,
+	}); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// Pending returns up to limit events that are due for delivery, oldest first.
+func (o *SQLOutbox) Pending(limit int) ([]PendingEvent, error) {
+	var ds []sqlData
+	if err := o.DB.Select(&ds, o.DB.Rebind("SELECT * FROM hydra_event_outbox WHERE next_attempt_at <= ? ORDER BY created_at LIMIT ?"), time.Now().UTC(), limit); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	events := make([]PendingEvent, len(ds))
+	for k, d := range ds {
+		events[k] = PendingEvent{
+			Event: &Event{
+				ID:        d.ID,
+				Type:      d.Type,
+				Payload:   json.RawMessage(d.Payload),
+				CreatedAt: d.CreatedAt,
+			},
+			Attempts: d.Attempts,
+		}
+	}
+	return events, nil
+}
+
+// MarkDelivered removes a delivered event from the outbox.
+func (o *SQLOutbox) MarkDelivered(id string) error {
+	if _, err := o.DB.Exec(o.DB.Rebind("DELETE FROM hydra_event_outbox WHERE id=?"), id); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// MarkFailed records a failed delivery attempt and schedules the next one.
+func (o *SQLOutbox) MarkFailed(id string, attempts int, next time.Time) error {
+	if _, err := o.DB.Exec(o.DB.Rebind("UPDATE hydra_event_outbox SET attempts=?, next_attempt_at=? WHERE id=?"), attempts, next.UTC(), id); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// Transaction runs fn in a SQL transaction. If outbox and e are not nil, the event is stored in the outbox as part of
+// the same transaction, so that it is persisted if, and only if, the state change is committed. Transactions that fail
+// with a serialization failure, as reported by CockroachDB under contention, are retried.
+func Transaction(db *sqlx.DB, outbox Outbox, e *Event, fn func(tx *sqlx.Tx) error) error {
+	return pkg.SQLTransaction(db, func(tx *sqlx.Tx) error {
+		if err := fn(tx); err != nil {
+			return err
+		}
+
+		if outbox != nil && e != nil {
+			return outbox.Enqueue(tx, e)
+		}
+		return nil
+	})
+}
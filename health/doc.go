@@ -14,6 +14,13 @@
 
 package health
 
+import (
+	"time"
+
+	"github.com/ory/hydra/metrics"
+	"github.com/ory/hydra/pkg"
+)
+
 // A list of clients.
 // swagger:response healthStatus
 type swaggerListClientsResult struct {
@@ -23,3 +30,45 @@ type swaggerListClientsResult struct {
 		Status string `json:"status"`
 	}
 }
+
+// The readiness of this instance.
+// swagger:response healthReady
+type swaggerHealthReady struct {
+	// in: body
+	Body swaggerHealthReadyBody
+}
+
+// swagger:model healthReady
+type swaggerHealthReadyBody struct {
+	// Status is "ok", or "degraded" if indexes are missing or could not be checked.
+	Status string `json:"status"`
+
+	// SQLIndexes is the result of the index check, if a SQL database is used.
+	SQLIndexes *swaggerSQLIndexStatus `json:"sql_indexes,omitempty"`
+}
+
+// swagger:model sqlIndexStatus
+type swaggerSQLIndexStatus struct {
+	// CheckedAt is when the indexes were checked, empty while the check is running.
+	CheckedAt *time.Time `json:"checked_at,omitempty"`
+
+	// Missing are the indexes frequently executed queries rely on but which are missing from the database.
+	Missing []pkg.SQLIndex `json:"missing"`
+
+	// Error is set if the indexes could not be checked.
+	Error string `json:"error,omitempty"`
+}
+
+// Statistics of this instance.
+// swagger:response healthStats
+type swaggerHealthStats struct {
+	// in: body
+	Body metrics.MetricsManager
+}
+
+// Request metrics of this instance in the Prometheus text exposition format.
+// swagger:response prometheusMetrics
+type swaggerPrometheusMetrics struct {
+	// in: body
+	Body string
+}
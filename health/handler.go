@@ -15,16 +15,27 @@
 package health
 
 import (
+	"context"
 	"net/http"
+	"strings"
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/ory/herodot"
 	"github.com/ory/hydra/firewall"
 	"github.com/ory/hydra/metrics"
+	"github.com/ory/hydra/pkg"
 )
 
 const (
 	HealthStatusPath = "/health/status"
+	HealthStatsPath  = "/health/stats"
+	HealthReadyPath  = "/health/ready"
+
+	// HealthMetricsPath exposes request metrics in the Prometheus text format.
+	HealthMetricsPath = "/health/metrics"
+
+	// MetricsPath exposes the same metrics as HealthMetricsPath at the path Prometheus scrapes by default.
+	MetricsPath = "/metrics"
 )
 
 type Handler struct {
@@ -32,6 +43,9 @@ type Handler struct {
 	H              *herodot.JSONWriter
 	W              firewall.Firewall
 	ResourcePrefix string
+
+	// SQLIndexes, if set, is reported by the ready probe.
+	SQLIndexes *SQLIndexStatus
 }
 
 func (h *Handler) PrefixResource(resource string) string {
@@ -48,6 +62,10 @@ func (h *Handler) PrefixResource(resource string) string {
 
 func (h *Handler) SetRoutes(r *httprouter.Router) {
 	r.GET(HealthStatusPath, h.Health)
+	r.GET(HealthStatsPath, h.Statistics)
+	r.GET(HealthReadyPath, h.Ready)
+	r.GET(HealthMetricsPath, h.Prometheus)
+	r.GET(MetricsPath, h.Prometheus)
 }
 
 // swagger:route GET /health/status health getInstanceStatus
@@ -64,3 +82,162 @@ func (h *Handler) SetRoutes(r *httprouter.Router) {
 func (h *Handler) Health(rw http.ResponseWriter, r *http.Request, _ httprouter.Params) {
 	rw.Write([]byte(`{"status": "ok"}`))
 }
+
+// swagger:route GET /health/ready health getInstanceReadiness
+//
+// Check the Readiness Status
+//
+// This endpoint returns a 200 status code when the instance is ready to serve requests. If a SQL database is used, the
+// response lists the indexes which frequently executed queries rely on but which are missing from the database, as
+// found by the check run at startup. Missing indexes slow down queries but do not make the instance unready, so the
+// status is "degraded" instead of "ok" but the status code stays 200. This endpoint does not require the
+// `X-Forwarded-Proto` header when TLS termination is set.
+//
+//     Responses:
+//       200: healthReady
+//       500: genericError
+func (h *Handler) Ready(rw http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var ready swaggerHealthReadyBody
+	ready.Status = "ok"
+
+	if h.SQLIndexes != nil {
+		checkedAt, missing, err := h.SQLIndexes.Get()
+		if missing == nil {
+			missing = []pkg.SQLIndex{}
+		}
+
+		ready.SQLIndexes = &swaggerSQLIndexStatus{Missing: missing}
+		if !checkedAt.IsZero() {
+			ready.SQLIndexes.CheckedAt = &checkedAt
+		}
+		if err != nil {
+			ready.SQLIndexes.Error = err.Error()
+		}
+		if len(missing) > 0 || err != nil {
+			ready.Status = "degraded"
+		}
+	}
+
+	h.H.Write(rw, r, &ready)
+}
+
+// swagger:route GET /health/stats health getStatistics
+//
+// Show instance statistics
+//
+// This endpoint returns information on the instance's health, such as memory usage and how many consent flows
+// were abandoned. Be aware that if you are running multiple nodes of ORY Hydra, the statistics refer to a single
+// instance only.
+//
+// The subject making the request needs to be assigned to a policy containing:
+//
+//  ```
+//  {
+//    "resources": ["rn:hydra:health:stats"],
+//    "actions": ["get"],
+//    "effect": "allow"
+//  }
+//  ```
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Security:
+//       oauth2: hydra.health
+//
+//     Responses:
+//       200: healthStats
+//       401: genericError
+//       403: genericError
+//       500: genericError
+func (h *Handler) Statistics(rw http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var ctx = context.Background()
+	if _, err := h.W.TokenAllowed(ctx, h.W.TokenFromRequest(r), &firewall.TokenAccessRequest{
+		Resource: h.PrefixResource("health:stats"),
+		Action:   "get",
+	}, "hydra.health"); err != nil {
+		h.H.WriteError(rw, r, err)
+		return
+	}
+
+	h.Metrics.MemoryStatistics.Update()
+	h.H.Write(rw, r, h.Metrics)
+}
+
+// swagger:route GET /health/metrics health getPrometheusMetrics
+//
+// Show request metrics in the Prometheus format
+//
+// This endpoint returns the rate, errors and duration of HTTP requests per endpoint, the duration of SQL queries and
+// the number and duration of slow SQL queries per operation, the number of issued access tokens per grant type, the
+// duration of token introspections and firewall decisions, and the hits and misses of the JSON Web Key cache in the
+// Prometheus text exposition format. The same metrics are served at `/metrics`. If the request accepts
+// `application/openmetrics-text`, the OpenMetrics format is returned instead, which includes the `X-Request-ID` of a
+// recent request per duration bucket as exemplar. Use `hydra metrics dashboard` and `hydra metrics alerts` to export a
+// Grafana dashboard and Prometheus alerting rules for these metrics. Be aware that the metrics refer to a single
+// instance only.
+//
+// The subject making the request needs to be assigned to a policy containing the following. If the policy also
+// allows the empty subject, metrics can be scraped without an access token.
+//
+//  ```
+//  {
+//    "resources": ["rn:hydra:health:metrics"],
+//    "actions": ["get"],
+//    "effect": "allow"
+//  }
+//  ```
+//
+//     Produces:
+//     - text/plain
+//     - application/openmetrics-text
+//
+//     Schemes: http, https
+//
+//     Security:
+//       oauth2: hydra.health
+//
+//     Responses:
+//       200: prometheusMetrics
+//       401: genericError
+//       403: genericError
+//       500: genericError
+func (h *Handler) Prometheus(rw http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var ctx = context.Background()
+	if _, err := h.W.TokenAllowed(ctx, h.W.TokenFromRequest(r), &firewall.TokenAccessRequest{
+		Resource: h.PrefixResource("health:metrics"),
+		Action:   "get",
+	}, "hydra.health"); err != nil {
+		if err := h.W.IsAllowed(ctx, &firewall.AccessRequest{
+			Subject:  "",
+			Resource: h.PrefixResource("health:metrics"),
+			Action:   "get",
+		}); err != nil {
+			h.H.WriteError(rw, r, err)
+			return
+		}
+	}
+
+	openMetrics := strings.Contains(r.Header.Get("Accept"), "application/openmetrics-text")
+	if openMetrics {
+		rw.Header().Set("Content-Type", "application/openmetrics-text; version=1.0.0; charset=utf-8")
+	} else {
+		rw.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	}
+
+	if err := h.Metrics.QueryStatistics.WritePrometheus(rw, openMetrics); err != nil {
+		h.H.WriteError(rw, r, err)
+		return
+	}
+
+	if err := h.Metrics.OperationStatistics.WritePrometheus(rw, openMetrics); err != nil {
+		h.H.WriteError(rw, r, err)
+		return
+	}
+
+	if err := h.Metrics.RequestStatistics.WritePrometheus(rw, openMetrics); err != nil {
+		h.H.WriteError(rw, r, err)
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package health
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ory/hydra/pkg"
+)
+
+// SQLIndexStatus holds the result of the last check for indexes missing from the SQL database. It is safe for
+// concurrent use.
+type SQLIndexStatus struct {
+	sync.RWMutex
+	checkedAt time.Time
+	missing   []pkg.SQLIndex
+	err       error
+}
+
+// Update stores the result of a check.
+func (s *SQLIndexStatus) Update(missing []pkg.SQLIndex, err error) {
+	s.Lock()
+	defer s.Unlock()
+	s.checkedAt = time.Now().UTC()
+	s.missing = missing
+	s.err = err
+}
+
+// Get returns the result of the last check. checkedAt is zero if no check finished yet.
+func (s *SQLIndexStatus) Get() (checkedAt time.Time, missing []pkg.SQLIndex, err error) {
+	s.RLock()
+	defer s.RUnlock()
+	return s.checkedAt, s.missing, s.err
+}
@@ -0,0 +1,124 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package i18n localizes the descriptions of errors which are shown to end users, for example by the consent app.
+package i18n
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/text/language"
+)
+
+// Catalog holds the descriptions of errors, keyed by the error name such as "invalid_request", in a set of languages.
+type Catalog struct {
+	matcher  language.Matcher
+	messages []map[string]string
+}
+
+// NewCatalog returns a catalog of messages, which map language tags such as "de" or "pt-BR" to the descriptions of
+// errors keyed by their name. English is the default language and keeps the original descriptions unless the messages
+// contain it.
+func NewCatalog(messages map[string]map[string]string) (*Catalog, error) {
+	keys := make([]string, 0, len(messages))
+	for key := range messages {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	tags := []language.Tag{language.English}
+	c := &Catalog{messages: []map[string]string{{}}}
+	for _, key := range keys {
+		tag, err := language.Parse(key)
+		if err != nil {
+			return nil, errors.Errorf("Could not parse language tag %s: %s", key, err)
+		}
+
+		if tag == language.English {
+			c.messages[0] = messages[key]
+			continue
+		}
+
+		tags = append(tags, tag)
+		c.messages = append(c.messages, messages[key])
+	}
+
+	c.matcher = language.NewMatcher(tags)
+	return c, nil
+}
+
+// LoadCatalog reads a catalog from the JSON files in dir. Each file is named after a language tag, for example
+// "de.json", and contains an object mapping error names to their descriptions.
+func LoadCatalog(dir string) (*Catalog, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	messages := map[string]map[string]string{}
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		var m map[string]string
+		if err := json.Unmarshal(content, &m); err != nil {
+			return nil, errors.Errorf("Could not decode error catalog %s: %s", file, err)
+		}
+		messages[strings.TrimSuffix(filepath.Base(file), ".json")] = m
+	}
+
+	return NewCatalog(messages)
+}
+
+// Preferred returns the languages preferred by the user agent in order. The space separated ui_locales of OpenID
+// Connect take precedence over the Accept-Language header.
+func Preferred(r *http.Request, uiLocales string) []language.Tag {
+	var tags []language.Tag
+	for _, locale := range strings.Fields(uiLocales) {
+		if tag, err := language.Parse(locale); err == nil {
+			tags = append(tags, tag)
+		}
+	}
+
+	if accepted, _, err := language.ParseAcceptLanguage(r.Header.Get("Accept-Language")); err == nil {
+		tags = append(tags, accepted...)
+	}
+	return tags
+}
+
+// Describe returns the description of the error name in the best match of the preferred languages, or fallback if
+// the catalog has no such description.
+func (c *Catalog) Describe(preferred []language.Tag, name, fallback string) string {
+	if len(preferred) == 0 {
+		return fallback
+	}
+
+	_, index, confidence := c.matcher.Match(preferred...)
+	if confidence == language.No {
+		return fallback
+	}
+
+	if description := c.messages[index][name]; description != "" {
+		return description
+	}
+	return fallback
+}
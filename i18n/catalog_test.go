@@ -0,0 +1,59 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCatalog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hydra-i18n")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "de.json"), []byte(`{"invalid_request":"Die Anfrage ist ungültig"}`), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "fr.json"), []byte(`{"invalid_request":"La requête est invalide"}`), 0600))
+
+	c, err := LoadCatalog(dir)
+	require.NoError(t, err)
+
+	for k, tc := range []struct {
+		acceptLanguage string
+		uiLocales      string
+		name           string
+		expected       string
+	}{
+		{acceptLanguage: "", name: "invalid_request", expected: "The request is invalid"},
+		{acceptLanguage: "de-CH, en;q=0.5", name: "invalid_request", expected: "Die Anfrage ist ungültig"},
+		{acceptLanguage: "en, de;q=0.5", name: "invalid_request", expected: "The request is invalid"},
+		{acceptLanguage: "es", name: "invalid_request", expected: "The request is invalid"},
+		{acceptLanguage: "de", uiLocales: "fr-CA de", name: "invalid_request", expected: "La requête est invalide"},
+		{acceptLanguage: "de", name: "invalid_scope", expected: "The request is invalid"},
+	} {
+		r := &http.Request{Header: http.Header{}}
+		r.Header.Set("Accept-Language", tc.acceptLanguage)
+		assert.Equal(t, tc.expected, c.Describe(Preferred(r, tc.uiLocales), tc.name, "The request is invalid"), "case %d", k)
+	}
+
+	_, err = NewCatalog(map[string]map[string]string{"not a tag": {}})
+	assert.Error(t, err)
+}
@@ -21,6 +21,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/go-redis/redis"
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
@@ -31,7 +32,7 @@ var resources []*dockertest.Resource
 var pool *dockertest.Pool
 
 func BootParallel(fs []func()) {
-	if os.Getenv("TEST_DATABASE_POSTGRESQL") != "" || os.Getenv("TEST_DATABASE_MYSQL") != "" {
+	if os.Getenv("TEST_DATABASE_POSTGRESQL") != "" || os.Getenv("TEST_DATABASE_MYSQL") != "" || os.Getenv("TEST_DATABASE_REDIS") != "" || os.Getenv("TEST_DATABASE_COCKROACHDB") != "" {
 		for _, f := range fs {
 			f()
 		}
@@ -136,3 +137,79 @@ func ConnectToPostgres() *sqlx.DB {
 	resources = append(resources, resource)
 	return db
 }
+
+func ConnectToCockroach() *sqlx.DB {
+	if url := os.Getenv("TEST_DATABASE_COCKROACHDB"); url != "" {
+		log.Println("Found cockroachdb test database config, skipping dockertest...")
+		db, err := sqlx.Open("postgres", url)
+		if err != nil {
+			log.Fatalf("Could not connect to bootstrapped database: %s", err)
+		}
+		return db
+	}
+
+	var db *sqlx.DB
+	var err error
+	pool, err = dockertest.NewPool("")
+	if err != nil {
+		log.Fatalf("Could not connect to docker: %s", err)
+	}
+
+	resource, err := pool.RunWithOptions(&dockertest.RunOptions{
+		Repository: "cockroachdb/cockroach",
+		Tag:        "v2.0.0",
+		Cmd:        []string{"start", "--insecure"},
+	})
+	if err != nil {
+		log.Fatalf("Could not start resource: %s", err)
+	}
+
+	if err = pool.Retry(func() error {
+		var err error
+		db, err = sqlx.Open("postgres", fmt.Sprintf("postgres://root@localhost:%s/defaultdb?sslmode=disable", resource.GetPort("26257/tcp")))
+		if err != nil {
+			return err
+		}
+		return db.Ping()
+	}); err != nil {
+		pool.Purge(resource)
+		log.Fatalf("Could not connect to docker: %s", err)
+	}
+
+	resources = append(resources, resource)
+	return db
+}
+
+func ConnectToRedis() *redis.Client {
+	if url := os.Getenv("TEST_DATABASE_REDIS"); url != "" {
+		log.Println("Found redis test database config, skipping dockertest...")
+		options, err := redis.ParseURL(url)
+		if err != nil {
+			log.Fatalf("Could not parse redis url: %s", err)
+		}
+		return redis.NewClient(options)
+	}
+
+	var db *redis.Client
+	var err error
+	pool, err = dockertest.NewPool("")
+	if err != nil {
+		log.Fatalf("Could not connect to docker: %s", err)
+	}
+
+	resource, err := pool.Run("redis", "4.0", nil)
+	if err != nil {
+		log.Fatalf("Could not start resource: %s", err)
+	}
+
+	if err = pool.Retry(func() error {
+		db = redis.NewClient(&redis.Options{Addr: fmt.Sprintf("localhost:%s", resource.GetPort("6379/tcp"))})
+		return db.Ping().Err()
+	}); err != nil {
+		pool.Purge(resource)
+		log.Fatalf("Could not connect to docker: %s", err)
+	}
+
+	resources = append(resources, resource)
+	return db
+}
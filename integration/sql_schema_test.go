@@ -15,16 +15,19 @@
 package integration
 
 import (
+	"sort"
 	"testing"
 
 	"github.com/ory/fosite"
 	"github.com/ory/hydra/client"
+	"github.com/ory/hydra/events"
 	"github.com/ory/hydra/jwk"
 	"github.com/ory/hydra/oauth2"
 	"github.com/ory/hydra/warden/group"
 	"github.com/ory/ladon"
 	lsql "github.com/ory/ladon/manager/sql"
 	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -47,6 +50,7 @@ func TestSQLSchema(t *testing.T) {
 	om := oauth2.FositeSQLStore{Manager: cm, DB: db, L: logrus.New()}
 	crm := oauth2.NewConsentRequestSQLManager(db)
 	pm := lsql.NewSQLManager(db, nil)
+	em := &events.SQLOutbox{DB: db}
 
 	_, err := pm.CreateSchemas("", "hydra_policy_migration")
 	require.NoError(t, err)
@@ -60,6 +64,8 @@ func TestSQLSchema(t *testing.T) {
 	require.NoError(t, err)
 	_, err = crm.CreateSchemas()
 	require.NoError(t, err)
+	_, err = em.CreateSchemas()
+	require.NoError(t, err)
 
 	require.NoError(t, jm.AddKey("integration-test-foo", jwk.First(p1)))
 	require.NoError(t, pm.Create(&ladon.DefaultPolicy{ID: "integration-test-foo", Resources: []string{"foo"}, Actions: []string{"bar"}, Subjects: []string{"baz"}, Effect: "allow"}))
@@ -71,4 +77,30 @@ func TestSQLSchema(t *testing.T) {
 		Members: []string{"asdf"},
 	}))
 	require.NoError(t, gm.DeleteGroup("integration-test-asdfas"))
+
+	cm.Outbox = em
+	require.NoError(t, cm.CreateClient(&client.Client{ID: "integration-test-events"}))
+	pending, err := em.Pending(10)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, events.ClientCreated, pending[0].Type)
+
+	require.NoError(t, em.MarkDelivered(pending[0].ID))
+	pending, err = em.Pending(10)
+	require.NoError(t, err)
+	assert.Len(t, pending, 0)
+
+	om.Outbox = em
+	jm.Outbox = em
+	require.NoError(t, om.RevokeAccessToken(nil, "foo"))
+	require.NoError(t, jm.AddKey("integration-test-foo", jwk.First(ks.Key("public:foo"))))
+	pending, err = em.Pending(10)
+	require.NoError(t, err)
+
+	var types []string
+	for _, e := range pending {
+		types = append(types, e.Type)
+	}
+	sort.Strings(types)
+	assert.Equal(t, []string{events.KeyCreated, events.KeyRotated, events.TokenRevoked}, types)
 }
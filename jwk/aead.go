@@ -55,5 +55,9 @@ func (c *AEAD) Decrypt(ciphertext string) ([]byte, error) {
 	}
 
 	plaintext, err := cryptopasta.Decrypt(raw, &key)
+	if err != nil {
+		return []byte{}, errors.WithStack(err)
+	}
+
 	return plaintext, nil
 }
@@ -52,3 +52,16 @@ func TestAEAD(t *testing.T) {
 		assert.Equal(t, plain, res)
 	}
 }
+
+func TestAEADWrongKey(t *testing.T) {
+	key, err := randomBytes(32)
+	require.NoError(t, err)
+	other, err := randomBytes(32)
+	require.NoError(t, err)
+
+	ct, err := (&AEAD{Key: key}).Encrypt([]byte("foo"))
+	require.NoError(t, err)
+
+	_, err = (&AEAD{Key: other}).Decrypt(ct)
+	assert.Error(t, err)
+}
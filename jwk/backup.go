@@ -0,0 +1,208 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwk
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/ory/hydra/pkg"
+	"github.com/pkg/errors"
+	"github.com/square/go-jose"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// BackupVersion is the version of the backup format written by ExportKeySets.
+	BackupVersion = 1
+
+	// MinBackupPassphraseLength is the minimum length of the passphrase used to encrypt backups.
+	MinBackupPassphraseLength = 8
+
+	backupPageSize = 500
+)
+
+// ErrBackupConflict is returned by ImportKeySets if a key of the backup already exists.
+var ErrBackupConflict = errors.New("A key of the backup already exists")
+
+// ErrInvalidBackup is returned by ImportKeySets if the backup can not be decrypted or decoded.
+var ErrInvalidBackup = errors.New("The backup is invalid")
+
+// Backup is a passphrase encrypted copy of all JSON Web Key Sets.
+//
+// swagger:model jsonWebKeyBackup
+type Backup struct {
+	// Version is the version of the backup format.
+	Version int `json:"version"`
+
+	// Salt is the base64url encoded salt used to derive the encryption key from the passphrase with scrypt.
+	Salt string `json:"salt"`
+
+	// Sets contains the encrypted key sets.
+	Sets string `json:"sets"`
+}
+
+type backupKeySet struct {
+	Set       string                       `json:"set"`
+	Keys      *jose.JSONWebKeySet          `json:"keys"`
+	Lifetimes map[string]backupKeyLifetime `json:"lifetimes,omitempty"`
+}
+
+type backupKeyLifetime struct {
+	NotBefore *time.Time `json:"nbf,omitempty"`
+	ExpiresAt *time.Time `json:"exp,omitempty"`
+}
+
+// ExportKeySets encrypts all key sets of the manager, including the lifetimes of their keys, with the passphrase.
+func ExportKeySets(m Manager, passphrase string) (*Backup, error) {
+	var sets []backupKeySet
+	for offset := 0; ; offset += backupPageSize {
+		summaries, err := m.ListKeySets(backupPageSize, offset)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, summary := range summaries {
+			keys, err := m.GetKeySet(summary.Set)
+			if errors.Cause(err) == pkg.ErrNotFound {
+				// The set was deleted in the meantime.
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+
+			lifetimes, err := m.GetKeyLifetimes(summary.Set)
+			if err != nil {
+				return nil, err
+			}
+
+			set := backupKeySet{Set: summary.Set, Keys: keys, Lifetimes: map[string]backupKeyLifetime{}}
+			for kid, lifetime := range lifetimes {
+				set.Lifetimes[kid] = backupKeyLifetime{NotBefore: nullTime(lifetime.NotBefore), ExpiresAt: nullTime(lifetime.ExpiresAt)}
+			}
+			sets = append(sets, set)
+		}
+
+		if len(summaries) < backupPageSize {
+			break
+		}
+	}
+
+	plaintext, err := json.Marshal(sets)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	salt, err := RandomBytes(16)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := backupKey(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext, err := (&AEAD{Key: key}).Encrypt(plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Backup{
+		Version: BackupVersion,
+		Salt:    base64.RawURLEncoding.EncodeToString(salt),
+		Sets:    ciphertext,
+	}, nil
+}
+
+// ImportKeySets decrypts a backup with the passphrase and adds its key sets to the manager. No key is added if one of
+// the keys of the backup already exists, in which case ErrBackupConflict is returned. Returns a summary of the
+// imported sets.
+func ImportKeySets(m Manager, backup *Backup, passphrase string) ([]KeySetSummary, error) {
+	if backup.Version != BackupVersion {
+		return nil, errors.Wrapf(ErrInvalidBackup, "Backup version %d is not supported", backup.Version)
+	}
+
+	salt, err := base64.RawURLEncoding.DecodeString(backup.Salt)
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidBackup, err.Error())
+	}
+
+	key, err := backupKey(passphrase, salt)
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidBackup, err.Error())
+	}
+
+	plaintext, err := (&AEAD{Key: key}).Decrypt(backup.Sets)
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidBackup, "Could not decrypt backup, the passphrase is probably wrong")
+	}
+
+	var sets []backupKeySet
+	if err := json.Unmarshal(plaintext, &sets); err != nil {
+		return nil, errors.Wrap(ErrInvalidBackup, err.Error())
+	}
+
+	for _, set := range sets {
+		existing, err := m.GetKeySet(set.Set)
+		if errors.Cause(err) == pkg.ErrNotFound {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		for _, key := range set.Keys.Keys {
+			if len(existing.Key(key.KeyID)) > 0 {
+				return nil, errors.Wrapf(ErrBackupConflict, "Key %s already exists in set %s", key.KeyID, set.Set)
+			}
+		}
+	}
+
+	summaries := []KeySetSummary{}
+	for _, set := range sets {
+		if err := m.AddKeySet(set.Set, set.Keys); err != nil {
+			return nil, err
+		}
+
+		for kid, lifetime := range set.Lifetimes {
+			var l KeyLifetime
+			if lifetime.NotBefore != nil {
+				l.NotBefore = *lifetime.NotBefore
+			}
+			if lifetime.ExpiresAt != nil {
+				l.ExpiresAt = *lifetime.ExpiresAt
+			}
+			if err := m.SetKeyLifetime(set.Set, kid, l); err != nil {
+				return nil, err
+			}
+		}
+
+		summaries = append(summaries, summarizeKeySet(set.Set, set.Keys))
+	}
+	return summaries, nil
+}
+
+func backupKey(passphrase string, salt []byte) ([]byte, error) {
+	if len(passphrase) < MinBackupPassphraseLength {
+		return nil, errors.Errorf("The passphrase must be at least %d characters long", MinBackupPassphraseLength)
+	}
+
+	key, err := scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return key, nil
+}
@@ -0,0 +1,81 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwk_test
+
+import (
+	"crypto"
+	"testing"
+	"time"
+
+	. "github.com/ory/hydra/jwk"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupKeySets(t *testing.T) {
+	source := new(MemoryManager)
+	for _, set := range []string{"foo", "bar"} {
+		ks, err := (&ECDSA256Generator{}).Generate(set)
+		require.NoError(t, err)
+		require.NoError(t, source.AddKeySet(set, ks))
+	}
+
+	lifetime := KeyLifetime{ExpiresAt: time.Now().Add(time.Hour).UTC().Round(time.Second)}
+	require.NoError(t, source.SetKeyLifetime("foo", "private:foo", lifetime))
+
+	_, err := ExportKeySets(source, "short")
+	assert.Error(t, err)
+
+	backup, err := ExportKeySets(source, "correct horse battery")
+	require.NoError(t, err)
+	assert.Equal(t, BackupVersion, backup.Version)
+	assert.NotContains(t, backup.Sets, "private:foo")
+
+	target := new(MemoryManager)
+	_, err = ImportKeySets(target, backup, "wrong horse battery")
+	assert.Equal(t, ErrInvalidBackup, errors.Cause(err))
+
+	summaries, err := ImportKeySets(target, backup, "correct horse battery")
+	require.NoError(t, err)
+	assert.Len(t, summaries, 2)
+
+	for _, set := range []string{"foo", "bar"} {
+		expected, err := source.GetKeySet(set)
+		require.NoError(t, err)
+		got, err := target.GetKeySet(set)
+		require.NoError(t, err)
+		require.Len(t, got.Keys, len(expected.Keys))
+		for k, key := range expected.Keys {
+			assert.Equal(t, key.KeyID, got.Keys[k].KeyID)
+			expectedThumbprint, err := key.Thumbprint(crypto.SHA256)
+			require.NoError(t, err)
+			gotThumbprint, err := got.Keys[k].Thumbprint(crypto.SHA256)
+			require.NoError(t, err)
+			assert.Equal(t, expectedThumbprint, gotThumbprint)
+		}
+	}
+
+	lifetimes, err := target.GetKeyLifetimes("foo")
+	require.NoError(t, err)
+	assert.True(t, lifetime.ExpiresAt.Equal(lifetimes["private:foo"].ExpiresAt))
+
+	// Nothing is imported if a key already exists.
+	require.NoError(t, target.DeleteKeySet("bar"))
+	_, err = ImportKeySets(target, backup, "correct horse battery")
+	assert.Equal(t, ErrBackupConflict, errors.Cause(err))
+	_, err = target.GetKeySet("bar")
+	assert.Error(t, err)
+}
@@ -19,6 +19,7 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/square/go-jose"
+	"golang.org/x/crypto/ed25519"
 )
 
 func MustRSAPublic(key *jose.JSONWebKey) *rsa.PublicKey {
@@ -53,3 +54,27 @@ func ToRSAPrivate(key *jose.JSONWebKey) (*rsa.PrivateKey, error) {
 	}
 	return res, nil
 }
+
+func MustEd25519Private(key *jose.JSONWebKey) ed25519.PrivateKey {
+	res, err := ToEd25519Private(key)
+	if err != nil {
+		panic(err.Error())
+	}
+	return res
+}
+
+func ToEd25519Private(key *jose.JSONWebKey) (ed25519.PrivateKey, error) {
+	res, ok := key.Key.(ed25519.PrivateKey)
+	if !ok {
+		return res, errors.New("Could not convert key to Ed25519 Private Key.")
+	}
+	return res, nil
+}
+
+func ToEd25519Public(key *jose.JSONWebKey) (ed25519.PublicKey, error) {
+	res, ok := key.Key.(ed25519.PublicKey)
+	if !ok {
+		return res, errors.New("Could not convert key to Ed25519 Public Key.")
+	}
+	return res, nil
+}
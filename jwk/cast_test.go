@@ -37,3 +37,20 @@ func TestMustRSAPrivate(t *testing.T) {
 	assert.Nil(t, err)
 	MustRSAPublic(&keys.Key("public:foo")[0])
 }
+
+func TestMustEd25519Private(t *testing.T) {
+	keys, err := new(EdDSAGenerator).Generate("foo")
+	assert.Nil(t, err)
+
+	priv := keys.Key("private:foo")
+	assert.NotPanics(t, func() {
+		MustEd25519Private(&priv[0])
+	})
+
+	pub := keys.Key("public:foo")
+	_, err = ToEd25519Public(&pub[0])
+	assert.NoError(t, err)
+
+	_, err = ToEd25519Private(&pub[0])
+	assert.Error(t, err)
+}
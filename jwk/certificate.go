@@ -0,0 +1,124 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwk
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"github.com/square/go-jose"
+)
+
+// CertificateThumbprintSHA256 returns the "x5t#S256" value of a certificate, the base64url encoded SHA-256 digest of
+// its DER encoding.
+func CertificateThumbprintSHA256(cert *x509.Certificate) string {
+	digest := sha256.Sum256(cert.Raw)
+	return base64.RawURLEncoding.EncodeToString(digest[:])
+}
+
+// ValidateCertificateChain checks the x5c chain of a key. The first certificate must contain the public key of the
+// JSON Web Key and every certificate must be signed by the one following it. Keys without certificates are valid.
+func ValidateCertificateChain(key *jose.JSONWebKey) error {
+	if len(key.Certificates) == 0 {
+		return nil
+	}
+
+	public := key.Public()
+	if public.Key == nil {
+		return errors.Errorf("Key %s can not carry a certificate chain because it has no public key", key.KeyID)
+	}
+
+	expected, err := x509.MarshalPKIXPublicKey(public.Key)
+	if err != nil {
+		return errors.Wrapf(err, "Key %s can not carry a certificate chain", key.KeyID)
+	}
+
+	leaf, err := x509.MarshalPKIXPublicKey(key.Certificates[0].PublicKey)
+	if err != nil {
+		return errors.Wrapf(err, "Could not encode the public key of the leaf certificate of key %s", key.KeyID)
+	} else if !bytes.Equal(expected, leaf) {
+		return errors.Errorf("The leaf certificate of key %s does not match its public key", key.KeyID)
+	}
+
+	for k := 0; k < len(key.Certificates)-1; k++ {
+		if err := key.Certificates[k].CheckSignatureFrom(key.Certificates[k+1]); err != nil {
+			return errors.Wrapf(err, "Certificate %d of the chain of key %s is not signed by certificate %d", k, key.KeyID, k+1)
+		}
+	}
+
+	return nil
+}
+
+// certifiedKey carries the "x5t#S256" thumbprint next to the JSON Web Key, which go-jose does not know about.
+type certifiedKey struct {
+	ThumbprintSHA256 string `json:"x5t#S256,omitempty"`
+}
+
+// DecodeCertifiedKey decodes a JSON Web Key and validates its x5c certificate chain. If the key carries a "x5t#S256"
+// thumbprint, it must match the leaf certificate.
+func DecodeCertifiedKey(raw []byte) (*jose.JSONWebKey, error) {
+	var key jose.JSONWebKey
+	if err := key.UnmarshalJSON(raw); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var certified certifiedKey
+	if err := json.Unmarshal(raw, &certified); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if err := ValidateCertificateChain(&key); err != nil {
+		return nil, err
+	}
+
+	if certified.ThumbprintSHA256 != "" {
+		if len(key.Certificates) == 0 {
+			return nil, errors.Errorf("Key %s has a x5t#S256 thumbprint but no x5c certificate chain", key.KeyID)
+		} else if certified.ThumbprintSHA256 != CertificateThumbprintSHA256(key.Certificates[0]) {
+			return nil, errors.Errorf("The x5t#S256 thumbprint of key %s does not match its leaf certificate", key.KeyID)
+		}
+	}
+
+	return &key, nil
+}
+
+// EncodeCertifiedKey encodes a JSON Web Key and adds the "x5t#S256" thumbprint of the leaf certificate, if the key
+// carries a x5c certificate chain.
+func EncodeCertifiedKey(key *jose.JSONWebKey) (json.RawMessage, error) {
+	out, err := key.MarshalJSON()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if len(key.Certificates) == 0 {
+		return out, nil
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(out, &fields); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	fields["x5t#S256"] = CertificateThumbprintSHA256(key.Certificates[0])
+	out, err = json.Marshal(fields)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return out, nil
+}
@@ -0,0 +1,138 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwk_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	. "github.com/ory/hydra/jwk"
+	"github.com/square/go-jose"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createCertificate(t *testing.T, serial int64, public crypto.PublicKey, parent *x509.Certificate, signer crypto.Signer) *x509.Certificate {
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: "hydra"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  parent == nil,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+	if parent == nil {
+		parent = template
+	}
+
+	raw, err := x509.CreateCertificate(rand.Reader, template, parent, public, signer)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(raw)
+	require.NoError(t, err)
+	return cert
+}
+
+func TestValidateCertificateChain(t *testing.T) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	otherCAKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	otherRSAKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	ca := createCertificate(t, 1, &caKey.PublicKey, nil, caKey)
+	otherCA := createCertificate(t, 2, &otherCAKey.PublicKey, nil, otherCAKey)
+	leaf := createCertificate(t, 3, &rsaKey.PublicKey, ca, caKey)
+
+	for k, tc := range []struct {
+		key   jose.JSONWebKey
+		valid bool
+	}{
+		{key: jose.JSONWebKey{Key: &rsaKey.PublicKey, KeyID: "public:foo"}, valid: true},
+		{key: jose.JSONWebKey{Key: &rsaKey.PublicKey, KeyID: "public:foo", Certificates: []*x509.Certificate{leaf}}, valid: true},
+		{key: jose.JSONWebKey{Key: rsaKey, KeyID: "private:foo", Certificates: []*x509.Certificate{leaf, ca}}, valid: true},
+		{key: jose.JSONWebKey{Key: &caKey.PublicKey, KeyID: "public:ca", Certificates: []*x509.Certificate{ca}}, valid: true},
+		{key: jose.JSONWebKey{Key: &otherRSAKey.PublicKey, KeyID: "public:foo", Certificates: []*x509.Certificate{leaf}}, valid: false},
+		{key: jose.JSONWebKey{Key: &rsaKey.PublicKey, KeyID: "public:foo", Certificates: []*x509.Certificate{leaf, otherCA}}, valid: false},
+		{key: jose.JSONWebKey{Key: []byte("secret"), KeyID: "foo", Certificates: []*x509.Certificate{leaf}}, valid: false},
+	} {
+		err := ValidateCertificateChain(&tc.key)
+		if tc.valid {
+			assert.NoError(t, err, "case %d", k)
+		} else {
+			assert.Error(t, err, "case %d", k)
+		}
+	}
+}
+
+func TestCertifiedKeyEncoding(t *testing.T) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	ca := createCertificate(t, 1, &caKey.PublicKey, nil, caKey)
+	leaf := createCertificate(t, 2, &rsaKey.PublicKey, ca, caKey)
+
+	digest := sha256.Sum256(leaf.Raw)
+	thumbprint := base64.RawURLEncoding.EncodeToString(digest[:])
+	assert.Equal(t, thumbprint, CertificateThumbprintSHA256(leaf))
+
+	key := &jose.JSONWebKey{Key: &rsaKey.PublicKey, KeyID: "public:foo", Certificates: []*x509.Certificate{leaf, ca}}
+	out, err := EncodeCertifiedKey(key)
+	require.NoError(t, err)
+
+	var fields map[string]interface{}
+	require.NoError(t, json.Unmarshal(out, &fields))
+	assert.Equal(t, thumbprint, fields["x5t#S256"])
+	assert.Len(t, fields["x5c"], 2)
+
+	decoded, err := DecodeCertifiedKey(out)
+	require.NoError(t, err)
+	assert.Equal(t, "public:foo", decoded.KeyID)
+	require.Len(t, decoded.Certificates, 2)
+	assert.Equal(t, leaf.Raw, decoded.Certificates[0].Raw)
+
+	fields["x5t#S256"] = CertificateThumbprintSHA256(ca)
+	tampered, err := json.Marshal(fields)
+	require.NoError(t, err)
+	_, err = DecodeCertifiedKey(tampered)
+	assert.Error(t, err)
+
+	out, err = EncodeCertifiedKey(&jose.JSONWebKey{Key: &rsaKey.PublicKey, KeyID: "public:bar"})
+	require.NoError(t, err)
+	assert.NotContains(t, string(out), "x5t#S256")
+
+	delete(fields, "x5c")
+	fields["x5t#S256"] = thumbprint
+	tampered, err = json.Marshal(fields)
+	require.NoError(t, err)
+	_, err = DecodeCertifiedKey(tampered)
+	assert.Error(t, err)
+}
@@ -0,0 +1,96 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwk
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Cipher encrypts and decrypts the data keys that protect JSON Web Keys stored by the SQLManager.
+type Cipher interface {
+	Encrypt(plaintext []byte) (string, error)
+	Decrypt(ciphertext string) ([]byte, error)
+}
+
+// NewCipher returns the Cipher identified by rawurl. Supported URLs are:
+//
+//   - an empty string or aes:// uses AES-GCM with the given system secret
+//   - awskms:///<key-id>?region=<region> uses AWS KMS
+//   - gcpkms://projects/<project>/locations/<location>/keyRings/<ring>/cryptoKeys/<key> uses Google Cloud KMS
+//   - vault://<key>?address=<vault-address>&timeout=<duration> uses the Vault transit secrets engine. The token is read
+//     from VAULT_TOKEN, the timeout of requests to Vault defaults to 10s.
+func NewCipher(rawurl string, secret []byte) (Cipher, error) {
+	if rawurl == "" {
+		return &AEAD{Key: secret}, nil
+	}
+
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	switch u.Scheme {
+	case "aes":
+		return &AEAD{Key: secret}, nil
+	case "awskms":
+		key := strings.TrimPrefix(u.Path, "/")
+		if key == "" {
+			return nil, errors.Errorf("Key id is missing in cipher url %s", rawurl)
+		}
+		return NewAWSKMSCipher(key, u.Query().Get("region"))
+	case "gcpkms":
+		key := u.Host + u.Path
+		if key == "" {
+			return nil, errors.Errorf("Key name is missing in cipher url %s", rawurl)
+		}
+		return NewGCPKMSCipher(key)
+	case "vault":
+		if u.Host == "" {
+			return nil, errors.Errorf("Key name is missing in cipher url %s", rawurl)
+		}
+
+		address := u.Query().Get("address")
+		if address == "" {
+			address = os.Getenv("VAULT_ADDR")
+		}
+		if address == "" {
+			return nil, errors.Errorf("Vault address is missing in cipher url %s and VAULT_ADDR is not set", rawurl)
+		}
+
+		timeout := defaultVaultTimeout
+		if raw := u.Query().Get("timeout"); raw != "" {
+			if timeout, err = time.ParseDuration(raw); err != nil {
+				return nil, errors.Wrapf(err, "Could not parse timeout in cipher url %s", rawurl)
+			} else if timeout <= 0 {
+				return nil, errors.Errorf("Timeout in cipher url %s must be positive", rawurl)
+			}
+		}
+
+		return &VaultTransitCipher{
+			Address: address,
+			Token:   os.Getenv("VAULT_TOKEN"),
+			Key:     u.Host,
+			Client:  &http.Client{Timeout: timeout},
+		}, nil
+	default:
+		return nil, errors.Errorf("Unknown cipher scheme %s", u.Scheme)
+	}
+}
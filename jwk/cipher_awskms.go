@@ -0,0 +1,73 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwk
+
+import (
+	"encoding/base64"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+	"github.com/pkg/errors"
+)
+
+// AWSKMSCipher encrypts data keys using a customer master key stored in AWS KMS.
+type AWSKMSCipher struct {
+	KMS   kmsiface.KMSAPI
+	KeyID string
+}
+
+// NewAWSKMSCipher creates an AWSKMSCipher using the default AWS credential chain. If region is empty, the region is
+// read from the environment.
+func NewAWSKMSCipher(keyID, region string) (*AWSKMSCipher, error) {
+	config := aws.NewConfig()
+	if region != "" {
+		config = config.WithRegion(region)
+	}
+
+	sess, err := session.NewSession(config)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &AWSKMSCipher{KMS: kms.New(sess), KeyID: keyID}, nil
+}
+
+func (c *AWSKMSCipher) Encrypt(plaintext []byte) (string, error) {
+	out, err := c.KMS.Encrypt(&kms.EncryptInput{
+		KeyId:     aws.String(c.KeyID),
+		Plaintext: plaintext,
+	})
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return base64.URLEncoding.EncodeToString(out.CiphertextBlob), nil
+}
+
+func (c *AWSKMSCipher) Decrypt(ciphertext string) ([]byte, error) {
+	raw, err := base64.URLEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return []byte{}, errors.WithStack(err)
+	}
+
+	out, err := c.KMS.Decrypt(&kms.DecryptInput{CiphertextBlob: raw})
+	if err != nil {
+		return []byte{}, errors.WithStack(err)
+	}
+
+	return out.Plaintext, nil
+}
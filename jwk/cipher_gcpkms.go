@@ -0,0 +1,83 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwk
+
+import (
+	"context"
+	"encoding/base64"
+
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/cloudkms/v1"
+)
+
+// GCPKMSCipher encrypts data keys using a crypto key stored in Google Cloud KMS. KeyName is the resource name of the
+// key, for example projects/my-project/locations/global/keyRings/hydra/cryptoKeys/jwk.
+type GCPKMSCipher struct {
+	Service *cloudkms.Service
+	KeyName string
+}
+
+// NewGCPKMSCipher creates a GCPKMSCipher using the Google application default credentials.
+func NewGCPKMSCipher(keyName string) (*GCPKMSCipher, error) {
+	client, err := google.DefaultClient(context.Background(), cloudkms.CloudPlatformScope)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	service, err := cloudkms.New(client)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &GCPKMSCipher{Service: service, KeyName: keyName}, nil
+}
+
+func (c *GCPKMSCipher) Encrypt(plaintext []byte) (string, error) {
+	resp, err := c.Service.Projects.Locations.KeyRings.CryptoKeys.Encrypt(c.KeyName, &cloudkms.EncryptRequest{
+		Plaintext: base64.StdEncoding.EncodeToString(plaintext),
+	}).Do()
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(resp.Ciphertext)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return base64.URLEncoding.EncodeToString(raw), nil
+}
+
+func (c *GCPKMSCipher) Decrypt(ciphertext string) ([]byte, error) {
+	raw, err := base64.URLEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return []byte{}, errors.WithStack(err)
+	}
+
+	resp, err := c.Service.Projects.Locations.KeyRings.CryptoKeys.Decrypt(c.KeyName, &cloudkms.DecryptRequest{
+		Ciphertext: base64.StdEncoding.EncodeToString(raw),
+	}).Do()
+	if err != nil {
+		return []byte{}, errors.WithStack(err)
+	}
+
+	plaintext, err := base64.StdEncoding.DecodeString(resp.Plaintext)
+	if err != nil {
+		return []byte{}, errors.WithStack(err)
+	}
+
+	return plaintext, nil
+}
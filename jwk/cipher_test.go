@@ -0,0 +1,92 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwk
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCipher(t *testing.T) {
+	secret := []byte("00000000000000000000000000000000")
+
+	for k, tc := range []struct {
+		url       string
+		expectErr bool
+	}{
+		{url: ""},
+		{url: "aes://"},
+		{url: "vault://hydra?address=http://localhost:8200"},
+		{url: "vault://hydra?address=http://localhost:8200&timeout=2s"},
+		{url: "vault://hydra?address=http://localhost:8200&timeout=forever", expectErr: true},
+		{url: "vault://hydra?address=http://localhost:8200&timeout=-1s", expectErr: true},
+		{url: "vault://?address=http://localhost:8200", expectErr: true},
+		{url: "awskms:///", expectErr: true},
+		{url: "foo://bar", expectErr: true},
+	} {
+		c, err := NewCipher(tc.url, secret)
+		if tc.expectErr {
+			assert.Error(t, err, "%d: %s", k, tc.url)
+			continue
+		}
+		require.NoError(t, err, "%d: %s", k, tc.url)
+		assert.NotNil(t, c, "%d: %s", k, tc.url)
+	}
+}
+
+func TestVaultTransitCipher(t *testing.T) {
+	// Fakes the transit engine by prefixing the plaintext, which is enough to verify the wire format.
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "token", r.Header.Get("X-Vault-Token"))
+
+		var in vaultTransitData
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&in))
+
+		var out vaultTransitData
+		switch r.URL.Path {
+		case "/v1/transit/encrypt/hydra":
+			out.Ciphertext = "vault:v1:" + in.Plaintext
+		case "/v1/transit/decrypt/hydra":
+			out.Plaintext = in.Ciphertext[len("vault:v1:"):]
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": out})
+	}))
+	defer ts.Close()
+
+	c := &VaultTransitCipher{Address: ts.URL, Token: "token", Key: "hydra"}
+	ct, err := c.Encrypt([]byte("foo"))
+	require.NoError(t, err)
+
+	raw, err := base64.URLEncoding.DecodeString(ct)
+	require.NoError(t, err)
+	assert.Equal(t, "vault:v1:"+base64.StdEncoding.EncodeToString([]byte("foo")), string(raw))
+
+	pt, err := c.Decrypt(ct)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("foo"), pt)
+
+	_, err = (&VaultTransitCipher{Address: ts.URL, Token: "token", Key: "unknown"}).Encrypt([]byte("foo"))
+	assert.Error(t, err)
+}
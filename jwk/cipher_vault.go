@@ -0,0 +1,123 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwk
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// defaultVaultTimeout bounds requests to Vault so that a hanging Vault server does not block key operations forever.
+const defaultVaultTimeout = time.Second * 10
+
+// VaultTransitCipher encrypts data keys using the transit secrets engine of HashiCorp Vault.
+type VaultTransitCipher struct {
+	// Address is the address of the Vault server, for example https://vault:8200.
+	Address string
+
+	// Token is the Vault token used to authenticate. It needs to be allowed to encrypt and decrypt using Key.
+	Token string
+
+	// Key is the name of the transit encryption key.
+	Key string
+
+	// Mount is the path the transit secrets engine is mounted at, defaults to transit.
+	Mount string
+
+	// Client sends the requests to Vault. Defaults to a client with a timeout of 10 seconds.
+	Client *http.Client
+}
+
+type vaultTransitData struct {
+	Plaintext  string `json:"plaintext,omitempty"`
+	Ciphertext string `json:"ciphertext,omitempty"`
+}
+
+func (c *VaultTransitCipher) Encrypt(plaintext []byte) (string, error) {
+	out, err := c.do("encrypt", &vaultTransitData{Plaintext: base64.StdEncoding.EncodeToString(plaintext)})
+	if err != nil {
+		return "", err
+	}
+
+	return base64.URLEncoding.EncodeToString([]byte(out.Ciphertext)), nil
+}
+
+func (c *VaultTransitCipher) Decrypt(ciphertext string) ([]byte, error) {
+	raw, err := base64.URLEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return []byte{}, errors.WithStack(err)
+	}
+
+	out, err := c.do("decrypt", &vaultTransitData{Ciphertext: string(raw)})
+	if err != nil {
+		return []byte{}, err
+	}
+
+	plaintext, err := base64.StdEncoding.DecodeString(out.Plaintext)
+	if err != nil {
+		return []byte{}, errors.WithStack(err)
+	}
+
+	return plaintext, nil
+}
+
+func (c *VaultTransitCipher) do(operation string, in *vaultTransitData) (*vaultTransitData, error) {
+	mount := c.Mount
+	if mount == "" {
+		mount = "transit"
+	}
+
+	client := c.Client
+	if client == nil {
+		client = &http.Client{Timeout: defaultVaultTimeout}
+	}
+
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(in); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	req, err := http.NewRequest("POST", strings.TrimRight(c.Address, "/")+"/v1/"+mount+"/"+operation+"/"+c.Key, &body)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	req.Header.Set("X-Vault-Token", c.Token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("Vault transit %s returned status code %d", operation, resp.StatusCode)
+	}
+
+	var out struct {
+		Data vaultTransitData `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &out.Data, nil
+}
@@ -33,6 +33,14 @@ type swaggerJsonWebKeyQuery struct {
 	Set string `json:"set"`
 }
 
+// swagger:parameters getJsonWebKey
+type swaggerJsonWebKeyExport struct {
+	// If set to true and the key is requested as PEM, private keys are returned instead of their public key. Requires
+	// the hydra.keys.export scope.
+	// in: query
+	Private bool `json:"private"`
+}
+
 // swagger:parameters updateJsonWebKeySet
 type swaggerJwkUpdateSet struct {
 	// The set
@@ -44,6 +52,30 @@ type swaggerJwkUpdateSet struct {
 	Body swaggerJSONWebKeySet
 }
 
+// swagger:parameters patchJsonWebKey
+type swaggerJwkPatchSetKey struct {
+	// The kid of the desired key
+	// in: path
+	// required: true
+	KID string `json:"kid"`
+
+	// The set
+	// in: path
+	// required: true
+	Set string `json:"set"`
+
+	// A JSON Merge Patch or a JSON Patch, depending on the Content-Type.
+	//
+	// in: body
+	// required: true
+	Body interface{}
+
+	// The ETag of the key. If the key was changed since, the patch is rejected.
+	//
+	// in: header
+	IfMatch string `json:"If-Match"`
+}
+
 // swagger:parameters updateJsonWebKey
 type swaggerJwkUpdateSetKey struct {
 	// The kid of the desired key
@@ -71,6 +103,72 @@ type swaggerJwkCreateSet struct {
 	Body createRequest
 }
 
+// swagger:parameters appendJsonWebKey
+type swaggerJwkAppendKey struct {
+	// The kid of the key to be created
+	// in: path
+	// required: true
+	KID string `json:"kid"`
+
+	// The set
+	// in: path
+	// required: true
+	Set string `json:"set"`
+
+	// in: body
+	Body createRequest
+}
+
+// swagger:parameters rotateJsonWebKeySet
+type swaggerJwkRotateSet struct {
+	// The set
+	// in: path
+	// required: true
+	Set string `json:"set"`
+
+	// in: body
+	Body createRequest
+}
+
+// swagger:parameters createJsonWebKeySetCredentials
+type swaggerJwkCreateCredentials struct {
+	// The set
+	// in: path
+	// required: true
+	Set string `json:"set"`
+
+	// in: body
+	Body credentialsRequest
+}
+
+// swagger:parameters importJsonWebKeys
+type swaggerJwkImport struct {
+	// The set
+	// in: path
+	// required: true
+	Set string `json:"set"`
+
+	// A PEM document or a DER encoded key.
+	//
+	// in: body
+	// required: true
+	Body string
+}
+
+// swagger:parameters exportJsonWebKeySets
+type swaggerJwkExportBackup struct {
+	// in: body
+	// required: true
+	Body backupExportRequest
+}
+
+// swagger:parameters importJsonWebKeySets
+type swaggerJwkImportBackup struct {
+	// in: body
+	// required: true
+	Body backupImportRequest
+}
+
 // swagger:parameters getJsonWebKeySet deleteJsonWebKeySet
 type swaggerJwkSetQuery struct {
 	// The set
@@ -79,6 +177,32 @@ type swaggerJwkSetQuery struct {
 	Set string `json:"set"`
 }
 
+// swagger:parameters listJsonWebKeySets
+type swaggerListJsonWebKeySetsParameters struct {
+	// The maximum amount of sets returned.
+	// in: query
+	Limit int `json:"limit"`
+
+	// The offset from where to start looking.
+	// in: query
+	Offset int `json:"offset"`
+}
+
+// A list of JSON Web Key Set summaries
+// swagger:response jsonWebKeySetSummaries
+type swaggerJSONWebKeySetSummaries struct {
+	// in: body
+	// type: array
+	Body []KeySetSummary
+}
+
+// A passphrase encrypted backup of all JSON Web Key Sets
+// swagger:response jsonWebKeyBackup
+type swaggerJSONWebKeyBackup struct {
+	// in: body
+	Body Backup
+}
+
 // swagger:model jsonWebKeySet
 type swaggerJSONWebKeySet struct {
 	// The value of the "keys" parameter is an array of JWK values.  By
@@ -133,12 +257,25 @@ type swaggerJSONWebKey struct {
 	// certificate.
 	X5c []string `json:"x5c,omitempty"`
 
+	// The "x5t#S256" (X.509 certificate SHA-256 thumbprint) parameter is a
+	// base64url-encoded SHA-256 thumbprint (a.k.a. digest) of the DER
+	// encoding of the first certificate of the "x5c" chain.
+	X5tS256 string `json:"x5t#S256,omitempty"`
+
 	K string `json:"k,omitempty"`
 	X string `json:"x,omitempty"`
 	Y string `json:"y,omitempty"`
 	N string `json:"n,omitempty"`
 	E string `json:"e,omitempty"`
 
+	// The time, in seconds since the epoch, before which the key must not be used. Only set for
+	// keys created with a not before time.
+	Nbf int64 `json:"nbf,omitempty"`
+
+	// The time, in seconds since the epoch, at which the key expires. Only set for keys created
+	// with an expiry.
+	Exp int64 `json:"exp,omitempty"`
+
 	D  string `json:"d,omitempty"`
 	P  string `json:"p,omitempty"`
 	Q  string `json:"q,omitempty"`
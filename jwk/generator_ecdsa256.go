@@ -35,11 +35,13 @@ func (g *ECDSA256Generator) Generate(id string) (*jose.JSONWebKeySet, error) {
 	return &jose.JSONWebKeySet{
 		Keys: []jose.JSONWebKey{
 			{
+				Algorithm:    "ES256",
 				Key:          key,
 				KeyID:        ider("private", id),
 				Certificates: []*x509.Certificate{},
 			},
 			{
+				Algorithm:    "ES256",
 				Key:          &key.PublicKey,
 				KeyID:        ider("public", id),
 				Certificates: []*x509.Certificate{},
@@ -0,0 +1,51 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwk
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+
+	"github.com/pkg/errors"
+	"github.com/square/go-jose"
+)
+
+type ECDSA384Generator struct{}
+
+func (g *ECDSA384Generator) Generate(id string) (*jose.JSONWebKeySet, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		return nil, errors.Errorf("Could not generate key because %s", err)
+	}
+
+	return &jose.JSONWebKeySet{
+		Keys: []jose.JSONWebKey{
+			{
+				Algorithm:    "ES384",
+				Key:          key,
+				KeyID:        ider("private", id),
+				Certificates: []*x509.Certificate{},
+			},
+			{
+				Algorithm:    "ES384",
+				Key:          &key.PublicKey,
+				KeyID:        ider("public", id),
+				Certificates: []*x509.Certificate{},
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,50 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwk
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+
+	"github.com/pkg/errors"
+	"github.com/square/go-jose"
+	"golang.org/x/crypto/ed25519"
+)
+
+type EdDSAGenerator struct{}
+
+func (g *EdDSAGenerator) Generate(id string) (*jose.JSONWebKeySet, error) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, errors.Errorf("Could not generate key because %s", err)
+	}
+
+	return &jose.JSONWebKeySet{
+		Keys: []jose.JSONWebKey{
+			{
+				Algorithm:    "EdDSA",
+				Key:          private,
+				KeyID:        ider("private", id),
+				Certificates: []*x509.Certificate{},
+			},
+			{
+				Algorithm:    "EdDSA",
+				Key:          public,
+				KeyID:        ider("public", id),
+				Certificates: []*x509.Certificate{},
+			},
+		},
+	}, nil
+}
@@ -23,16 +23,33 @@ import (
 	"github.com/square/go-jose"
 )
 
+const (
+	// DefaultRSAKeyLength is the key length used by RS256Generator if none is set.
+	DefaultRSAKeyLength = 4096
+
+	// MinRSAKeyLength is the smallest key length RS256Generator accepts.
+	MinRSAKeyLength = 2048
+
+	// MaxRSAKeyLength is the largest key length RS256Generator accepts. Generating larger keys takes minutes.
+	MaxRSAKeyLength = 8192
+)
+
 type RS256Generator struct {
+	// KeyLength is the size of the generated key in bits. Defaults to DefaultRSAKeyLength.
 	KeyLength int
 }
 
 func (g *RS256Generator) Generate(id string) (*jose.JSONWebKeySet, error) {
-	if g.KeyLength < 4096 {
-		g.KeyLength = 4096
+	keyLength := g.KeyLength
+	if keyLength == 0 {
+		keyLength = DefaultRSAKeyLength
+	} else if keyLength < MinRSAKeyLength {
+		return nil, errors.Errorf("RSA key length must be at least %d bits but got %d", MinRSAKeyLength, keyLength)
+	} else if keyLength > MaxRSAKeyLength {
+		return nil, errors.Errorf("RSA key length must be at most %d bits but got %d", MaxRSAKeyLength, keyLength)
 	}
 
-	key, err := rsa.GenerateKey(rand.Reader, g.KeyLength)
+	key, err := rsa.GenerateKey(rand.Reader, keyLength)
 	if err != nil {
 		return nil, errors.Errorf("Could not generate key because %s", err)
 	} else if err = key.Validate(); err != nil {
@@ -15,6 +15,8 @@
 package jwk
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"testing"
 
 	"fmt"
@@ -22,6 +24,7 @@ import (
 	"github.com/square/go-jose"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ed25519"
 )
 
 func TestGenerator(t *testing.T) {
@@ -36,15 +39,22 @@ func TestGenerator(t *testing.T) {
 		{
 			g: &RS256Generator{},
 			check: func(ks *jose.JSONWebKeySet) {
-				assert.Len(t, ks, 2)
+				assert.Len(t, ks.Keys, 2)
 				assert.NotEmpty(t, ks.Keys[0].Key)
 				assert.NotEmpty(t, ks.Keys[1].Key)
 			},
 		},
+		{
+			g: &RS256Generator{KeyLength: 3072},
+			check: func(ks *jose.JSONWebKeySet) {
+				assert.Len(t, ks.Keys, 2)
+				assert.Equal(t, 3072, MustRSAPrivate(&ks.Keys[0]).N.BitLen())
+			},
+		},
 		{
 			g: &ECDSA512Generator{},
 			check: func(ks *jose.JSONWebKeySet) {
-				assert.Len(t, ks, 2)
+				assert.Len(t, ks.Keys, 2)
 				assert.NotEmpty(t, ks.Keys[0].Key)
 				assert.NotEmpty(t, ks.Keys[1].Key)
 			},
@@ -52,22 +62,38 @@ func TestGenerator(t *testing.T) {
 		{
 			g: &ECDSA256Generator{},
 			check: func(ks *jose.JSONWebKeySet) {
-				assert.Len(t, ks, 2)
+				assert.Len(t, ks.Keys, 2)
 				assert.NotEmpty(t, ks.Keys[0].Key)
 				assert.NotEmpty(t, ks.Keys[1].Key)
 			},
 		},
+		{
+			g: &ECDSA384Generator{},
+			check: func(ks *jose.JSONWebKeySet) {
+				assert.Len(t, ks.Keys, 2)
+				assert.Equal(t, elliptic.P384(), ks.Keys[0].Key.(*ecdsa.PrivateKey).Curve)
+				assert.Equal(t, elliptic.P384(), ks.Keys[1].Key.(*ecdsa.PublicKey).Curve)
+			},
+		},
+		{
+			g: &EdDSAGenerator{},
+			check: func(ks *jose.JSONWebKeySet) {
+				assert.Len(t, ks.Keys, 2)
+				assert.IsType(t, ed25519.PrivateKey{}, ks.Keys[0].Key)
+				assert.IsType(t, ed25519.PublicKey{}, ks.Keys[1].Key)
+			},
+		},
 		{
 			g: &HS256Generator{},
 			check: func(ks *jose.JSONWebKeySet) {
-				assert.Len(t, ks, 1)
+				assert.Len(t, ks.Keys, 1)
 				assert.NotEmpty(t, ks.Keys[0].Key)
 			},
 		},
 		{
 			g: &HS512Generator{},
 			check: func(ks *jose.JSONWebKeySet) {
-				assert.Len(t, ks, 1)
+				assert.Len(t, ks.Keys, 1)
 				assert.NotEmpty(t, ks.Keys[0].Key)
 			},
 		},
@@ -75,9 +101,17 @@ func TestGenerator(t *testing.T) {
 		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
 			keys, err := c.g.Generate("foo")
 			require.NoError(t, err)
-			if err != nil {
-				c.check(keys)
-			}
+			c.check(keys)
 		})
 	}
 }
+
+func TestRS256GeneratorRejectsShortKeys(t *testing.T) {
+	_, err := (&RS256Generator{KeyLength: 1024}).Generate("foo")
+	assert.Error(t, err)
+}
+
+func TestRS256GeneratorRejectsLongKeys(t *testing.T) {
+	_, err := (&RS256Generator{KeyLength: 16384}).Generate("foo")
+	assert.Error(t, err)
+}
@@ -18,11 +18,19 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/ory/herodot"
+	"github.com/ory/hydra/client"
 	"github.com/ory/hydra/firewall"
+	"github.com/ory/hydra/pkg"
+	"github.com/ory/ladon"
+	"github.com/ory/pagination"
 	"github.com/pkg/errors"
 	"github.com/square/go-jose"
 )
@@ -31,6 +39,10 @@ const (
 	IDTokenKeyName    = "hydra.openid.id-token"
 	KeyHandlerPath    = "/keys"
 	WellKnownKeysPath = "/.well-known/jwks.json"
+	BackupHandlerPath = "/backup/keys"
+
+	// maxImportSize limits the size of PEM or DER documents sent to the import endpoint.
+	maxImportSize = 1 << 20
 )
 
 type Handler struct {
@@ -39,6 +51,20 @@ type Handler struct {
 	H              herodot.Writer
 	W              firewall.Firewall
 	ResourcePrefix string
+
+	// RSAKeyLength is the default size of RSA keys generated by the RS256 generator. Defaults to DefaultRSAKeyLength.
+	RSAKeyLength int
+
+	// WellKnownMaxAge, if set, is sent as the max-age of the Cache-Control header of the well-known JSON Web Key Set.
+	WellKnownMaxAge time.Duration
+
+	// Clients and Policies, if set, are used to create credentials which may only manage the keys of a single set,
+	// see CreateCredentials.
+	Clients  client.Manager
+	Policies ladon.Manager
+
+	// KeyPolicy, if set, rejects uploaded and patched keys which are too weak or use a denied algorithm.
+	KeyPolicy *KeyPolicy
 }
 
 func (h *Handler) PrefixResource(resource string) string {
@@ -56,10 +82,13 @@ func (h *Handler) PrefixResource(resource string) string {
 func (h *Handler) GetGenerators() map[string]KeyGenerator {
 	if h.Generators == nil || len(h.Generators) == 0 {
 		h.Generators = map[string]KeyGenerator{
-			"RS256": &RS256Generator{},
+			"RS256": &RS256Generator{KeyLength: h.RSAKeyLength},
+			"ES256": &ECDSA256Generator{},
+			"ES384": &ECDSA384Generator{},
 			"ES512": &ECDSA512Generator{},
 			"HS256": &HS256Generator{},
 			"HS512": &HS512Generator{},
+			"EdDSA": &EdDSAGenerator{},
 		}
 	}
 	return h.Generators
@@ -67,21 +96,27 @@ func (h *Handler) GetGenerators() map[string]KeyGenerator {
 
 func (h *Handler) SetRoutes(r *httprouter.Router) {
 	r.GET(WellKnownKeysPath, h.WellKnown)
+	r.GET(KeyHandlerPath, h.ListKeySets)
 	r.GET(KeyHandlerPath+"/:set/:key", h.GetKey)
 	r.GET(KeyHandlerPath+"/:set", h.GetKeySet)
 
 	r.POST(KeyHandlerPath+"/:set", h.Create)
+	r.POST(KeyHandlerPath+"/:set/:key", h.postKey)
 
 	r.PUT(KeyHandlerPath+"/:set/:key", h.UpdateKey)
+	r.PATCH(KeyHandlerPath+"/:set/:key", h.PatchKey)
 	r.PUT(KeyHandlerPath+"/:set", h.UpdateKeySet)
 
 	r.DELETE(KeyHandlerPath+"/:set/:key", h.DeleteKey)
 	r.DELETE(KeyHandlerPath+"/:set", h.DeleteKeySet)
+
+	r.POST(BackupHandlerPath+"/export", h.ExportBackup)
+	r.POST(BackupHandlerPath+"/import", h.ImportBackup)
 }
 
 // swagger:model jsonWebKeySetGeneratorRequest
 type createRequest struct {
-	// The algorithm to be used for creating the key. Supports "RS256", "ES512", "HS512", and "HS256"
+	// The algorithm to be used for creating the key. Supports "RS256", "ES256", "ES384", "ES512", "EdDSA", "HS512", and "HS256"
 	// required: true
 	// in: body
 	Algorithm string `json:"alg"`
@@ -90,6 +125,47 @@ type createRequest struct {
 	// required: true
 	// in: body
 	KeyID string `json:"kid"`
+
+	// The size of the key in bits. Only supported by "RS256", must be between 2048 and 8192 and defaults to 4096.
+	// in: body
+	Bits int `json:"bits"`
+
+	// The intended use of the key, either "sig" (signature) or "enc" (encryption). Defaults to the use chosen by the
+	// generator.
+	// in: body
+	Use string `json:"use"`
+
+	// The time, in seconds since the epoch, before which the key must not be used.
+	// in: body
+	NotBefore int64 `json:"nbf"`
+
+	// The time, in seconds since the epoch, at which the key expires. Expired keys are no longer published at
+	// /.well-known/jwks.json and are removed from their set periodically.
+	// in: body
+	ExpiresAt int64 `json:"exp"`
+}
+
+// lifetime validates the key metadata of the request and returns the lifetime of the keys to be created.
+func (r *createRequest) lifetime(now time.Time) (KeyLifetime, error) {
+	var lifetime KeyLifetime
+	if r.Use != "" && r.Use != "sig" && r.Use != "enc" {
+		return lifetime, errors.Errorf("Key use must be either sig or enc, got %s", r.Use)
+	}
+
+	if r.NotBefore > 0 {
+		lifetime.NotBefore = time.Unix(r.NotBefore, 0).UTC()
+	}
+
+	if r.ExpiresAt > 0 {
+		lifetime.ExpiresAt = time.Unix(r.ExpiresAt, 0).UTC()
+		if lifetime.IsExpired(now) {
+			return lifetime, errors.New("Key expiry must be in the future")
+		} else if !lifetime.NotBefore.IsZero() && !lifetime.NotBefore.Before(lifetime.ExpiresAt) {
+			return lifetime, errors.New("Key expiry must be after the time the key becomes valid")
+		}
+	}
+
+	return lifetime, nil
 }
 
 type joseWebKeySetRequest struct {
@@ -127,6 +203,7 @@ type joseWebKeySetRequest struct {
 //
 //     Responses:
 //       200: jsonWebKeySet
+//       304: emptyResponse
 //       401: genericError
 //       403: genericError
 //       500: genericError
@@ -169,13 +246,45 @@ func (h *Handler) WellKnown(w http.ResponseWriter, r *http.Request, ps httproute
 		return
 	}
 
+	lifetimes, err := h.Manager.GetKeyLifetimes(IDTokenKeyName)
+	if err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	// Expired keys are not published, even if they were not pruned yet.
+	var now = time.Now().UTC()
+	var published = &jose.JSONWebKeySet{Keys: []jose.JSONWebKey{}}
+	for _, key := range keys.Keys {
+		if !lifetimes[key.KeyID].IsExpired(now) {
+			published.Keys = append(published.Keys, key)
+		}
+	}
+	keys = published
+
 	for _, key := range keys.Keys {
 		if err := fw(key.KeyID); err != nil {
 			return
 		}
 	}
 
-	h.H.Write(w, r, keys)
+	etag, err := pkg.ETag(keys)
+	if err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag)
+	if h.WellKnownMaxAge > 0 {
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(h.WellKnownMaxAge.Seconds())))
+	}
+
+	if pkg.ETagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	h.writeKeySet(w, r, IDTokenKeyName, keys)
 }
 
 // swagger:route GET /keys/{set}/{kid} jsonWebKey getJsonWebKey
@@ -186,6 +295,10 @@ func (h *Handler) WellKnown(w http.ResponseWriter, r *http.Request, ps httproute
 //
 // A JSON Web Key (JWK) is a JavaScript Object Notation (JSON) data structure that represents a cryptographic key. A JWK Set is a JSON data structure that represents a set of JWKs. A JSON Web Key is identified by its set and key id. ORY Hydra uses this functionality to store cryptographic keys used for TLS and JSON Web Tokens (such as OpenID Connect ID tokens), and allows storing user-defined keys as well.
 //
+// If the request is sent with `Accept: application/x-pem-file`, the key and its certificate chain are returned as PEM
+// instead. Private keys are returned as their public key unless `private=true` is set and the access token was also
+// granted the `hydra.keys.export` scope. Symmetric keys can not be encoded as PEM.
+//
 // The subject making the request needs to be assigned to a policy containing:
 //
 //  ```
@@ -201,6 +314,7 @@ func (h *Handler) WellKnown(w http.ResponseWriter, r *http.Request, ps httproute
 //
 //     Produces:
 //     - application/json
+//     - application/x-pem-file
 //
 //     Schemes: http, https
 //
@@ -211,6 +325,7 @@ func (h *Handler) WellKnown(w http.ResponseWriter, r *http.Request, ps httproute
 //       200: jsonWebKeySet
 //       401: genericError
 //       403: genericError
+//       406: genericError
 //       500: genericError
 func (h *Handler) GetKey(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	var ctx = context.Background()
@@ -239,7 +354,50 @@ func (h *Handler) GetKey(w http.ResponseWriter, r *http.Request, ps httprouter.P
 		return
 	}
 
-	h.H.Write(w, r, keys)
+	if strings.Contains(r.Header.Get("Accept"), "application/x-pem-file") {
+		h.writePEM(w, r, h.PrefixResource("keys:"+setName+":"+keyName), keys)
+		return
+	}
+
+	if len(keys.Keys) == 1 {
+		// The ETag can be sent in If-Match when patching the key.
+		out, err := EncodeCertifiedKey(&keys.Keys[0])
+		if err != nil {
+			h.H.WriteError(w, r, err)
+			return
+		}
+
+		etag, err := pkg.ETag(out)
+		if err != nil {
+			h.H.WriteError(w, r, err)
+			return
+		}
+		w.Header().Set("ETag", etag)
+	}
+
+	h.writeKeySet(w, r, setName, keys)
+}
+
+func (h *Handler) writePEM(w http.ResponseWriter, r *http.Request, resource string, keys *jose.JSONWebKeySet) {
+	private := r.URL.Query().Get("private") == "true"
+	if private {
+		if _, err := h.W.TokenAllowed(context.Background(), h.W.TokenFromRequest(r), &firewall.TokenAccessRequest{
+			Resource: resource,
+			Action:   "get",
+		}, "hydra.keys.get", "hydra.keys.export"); err != nil {
+			h.H.WriteError(w, r, err)
+			return
+		}
+	}
+
+	out, err := EncodePEM(keys.Keys, private)
+	if err != nil {
+		h.H.WriteErrorCode(w, r, http.StatusNotAcceptable, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-pem-file")
+	w.Write(out)
 }
 
 // swagger:route GET /keys/{set} jsonWebKey getJsonWebKeySet
@@ -296,7 +454,99 @@ func (h *Handler) GetKeySet(w http.ResponseWriter, r *http.Request, ps httproute
 		}
 	}
 
-	h.H.Write(w, r, keys)
+	h.writeKeySet(w, r, setName, keys)
+}
+
+// writeKeySet writes the keys of a JSON Web Key Set, see encodeKeySet.
+func (h *Handler) writeKeySet(w http.ResponseWriter, r *http.Request, set string, keys *jose.JSONWebKeySet) {
+	out, err := h.encodeKeySet(set, keys)
+	if err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	h.H.Write(w, r, out)
+}
+
+// encodeKeySet encodes the keys of a JSON Web Key Set. Keys carrying a x5c certificate chain include the "x5t#S256"
+// thumbprint of their leaf certificate, keys with a lifetime include "nbf" and "exp".
+func (h *Handler) encodeKeySet(set string, keys *jose.JSONWebKeySet) (*joseWebKeySetRequest, error) {
+	lifetimes, err := h.Manager.GetKeyLifetimes(set)
+	if err != nil {
+		return nil, err
+	}
+
+	var out = joseWebKeySetRequest{Keys: []json.RawMessage{}}
+	for k := range keys.Keys {
+		key, err := EncodeCertifiedKey(&keys.Keys[k])
+		if err != nil {
+			return nil, err
+		}
+
+		key, err = encodeLifetime(key, lifetimes[keys.Keys[k].KeyID])
+		if err != nil {
+			return nil, err
+		}
+		out.Keys = append(out.Keys, key)
+	}
+
+	return &out, nil
+}
+
+// swagger:route GET /keys jsonWebKey listJsonWebKeySets
+//
+// List JSON Web Key Sets
+//
+// This endpoint returns the names of the JSON Web Key Sets stored in ORY Hydra, together with the number of keys
+// and the algorithms used in each set. The keys themselves are not returned.
+//
+// A JSON Web Key (JWK) is a JavaScript Object Notation (JSON) data structure that represents a cryptographic key. A JWK Set is a JSON data structure that represents a set of JWKs. A JSON Web Key is identified by its set and key id. ORY Hydra uses this functionality to store cryptographic keys used for TLS and JSON Web Tokens (such as OpenID Connect ID tokens), and allows storing user-defined keys as well.
+//
+// The subject making the request needs to be assigned to a policy containing:
+//
+//  ```
+//  {
+//    "resources": ["rn:hydra:keys"],
+//    "actions": ["list"],
+//    "effect": "allow"
+//  }
+//  ```
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Security:
+//       oauth2: hydra.keys.list
+//
+//     Responses:
+//       200: jsonWebKeySetSummaries
+//       401: genericError
+//       403: genericError
+//       500: genericError
+func (h *Handler) ListKeySets(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var ctx = context.Background()
+
+	if _, err := h.W.TokenAllowed(ctx, h.W.TokenFromRequest(r), &firewall.TokenAccessRequest{
+		Resource: h.PrefixResource("keys"),
+		Action:   "list",
+	}, "hydra.keys.list"); err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	limit, offset := pagination.Parse(r, 100, 0, 500)
+	summaries, err := h.Manager.ListKeySets(limit, offset)
+	if err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	h.H.Write(w, r, summaries)
 }
 
 // swagger:route POST /keys/{set} jsonWebKey createJsonWebKeySet
@@ -305,6 +555,9 @@ func (h *Handler) GetKeySet(w http.ResponseWriter, r *http.Request, ps httproute
 //
 // This endpoint is capable of generating JSON Web Key Sets for you. There a different strategies available, such as symmetric cryptographic keys (HS256, HS512) and asymetric cryptographic keys (RS256, ECDSA). If the specified JSON Web Key Set does not exist, it will be created.
 //
+// Keys can be created with a use ("sig" or "enc") and a lifetime, given by "nbf" and "exp" in seconds since the epoch.
+// Expired keys are no longer published at /.well-known/jwks.json and are removed from their set periodically.
+//
 // A JSON Web Key (JWK) is a JavaScript Object Notation (JSON) data structure that represents a cryptographic key. A JWK Set is a JSON data structure that represents a set of JWKs. A JSON Web Key is identified by its set and key id. ORY Hydra uses this functionality to store cryptographic keys used for TLS and JSON Web Tokens (such as OpenID Connect ID tokens), and allows storing user-defined keys as well.
 //
 // The subject making the request needs to be assigned to a policy containing:
@@ -330,6 +583,7 @@ func (h *Handler) GetKeySet(w http.ResponseWriter, r *http.Request, ps httproute
 //
 //     Responses:
 //       200: jsonWebKeySet
+//       400: genericError
 //       401: genericError
 //       403: genericError
 //       500: genericError
@@ -348,11 +602,18 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request, ps httprouter.P
 
 	if err := json.NewDecoder(r.Body).Decode(&keyRequest); err != nil {
 		h.H.WriteError(w, r, errors.WithStack(err))
+		return
 	}
 
-	generator, found := h.GetGenerators()[keyRequest.Algorithm]
-	if !found {
-		h.H.WriteErrorCode(w, r, http.StatusBadRequest, errors.Errorf("Generator %s unknown", keyRequest.Algorithm))
+	generator, err := h.generatorFor(&keyRequest)
+	if err != nil {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	lifetime, err := keyRequest.lifetime(time.Now().UTC())
+	if err != nil {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, err)
 		return
 	}
 
@@ -362,6 +623,213 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request, ps httprouter.P
 		return
 	}
 
+	if err := h.addGeneratedKeys(set, keys, keyRequest.Use, lifetime); err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	out, err := h.encodeKeySet(set, keys)
+	if err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	h.H.WriteCreated(w, r, fmt.Sprintf("%s://%s/keys/%s", r.URL.Scheme, r.URL.Host, set), out)
+}
+
+// swagger:route POST /keys/{set}/{kid} jsonWebKey appendJsonWebKey
+//
+// Generate a new JSON Web Key and add it to an existing set
+//
+// This endpoint generates a new key and appends it to an existing JSON Web Key Set without touching the other keys
+// of that set, which is useful for key rotation. Only the generated keys are returned. The key id is taken from the
+// URL. The request fails with 404 if the set does not exist and with 409 if the set already contains a key with the
+// generated key id. The key ids "import", "credentials" and "rotate" are reserved.
+//
+// A JSON Web Key (JWK) is a JavaScript Object Notation (JSON) data structure that represents a cryptographic key. A JWK Set is a JSON data structure that represents a set of JWKs. A JSON Web Key is identified by its set and key id. ORY Hydra uses this functionality to store cryptographic keys used for TLS and JSON Web Tokens (such as OpenID Connect ID tokens), and allows storing user-defined keys as well.
+//
+// The subject making the request needs to be assigned to a policy containing:
+//
+//  ```
+//  {
+//    "resources": ["rn:hydra:keys:<set>:<kid>"],
+//    "actions": ["create"],
+//    "effect": "allow"
+//  }
+//  ```
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Security:
+//       oauth2: hydra.keys.create
+//
+//     Responses:
+//       201: jsonWebKeySet
+//       400: genericError
+//       401: genericError
+//       403: genericError
+//       404: genericError
+//       409: genericError
+//       500: genericError
+func (h *Handler) AppendKey(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var ctx = context.Background()
+	var keyRequest createRequest
+	var set = ps.ByName("set")
+	var kid = ps.ByName("key")
+
+	if _, err := h.W.TokenAllowed(ctx, h.W.TokenFromRequest(r), &firewall.TokenAccessRequest{
+		Resource: h.PrefixResource("keys:" + set + ":" + kid),
+		Action:   "create",
+	}, "hydra.keys.create"); err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&keyRequest); err != nil {
+		h.H.WriteError(w, r, errors.WithStack(err))
+		return
+	}
+
+	if keyRequest.KeyID != "" && keyRequest.KeyID != kid {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, errors.Errorf("Key id %s from the request body does not match key id %s from the URL", keyRequest.KeyID, kid))
+		return
+	}
+
+	generator, err := h.generatorFor(&keyRequest)
+	if err != nil {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	lifetime, err := keyRequest.lifetime(time.Now().UTC())
+	if err != nil {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	existing, err := h.Manager.GetKeySet(set)
+	if err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	keys, err := generator.Generate(kid)
+	if err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	for _, key := range keys.Keys {
+		if len(existing.Key(key.KeyID)) > 0 {
+			h.H.WriteErrorCode(w, r, http.StatusConflict, errors.Errorf("Key %s already exists in set %s", key.KeyID, set))
+			return
+		}
+	}
+
+	if err := h.addGeneratedKeys(set, keys, keyRequest.Use, lifetime); err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	out, err := h.encodeKeySet(set, keys)
+	if err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	h.H.WriteCreated(w, r, fmt.Sprintf("%s://%s/keys/%s/%s", r.URL.Scheme, r.URL.Host, set, kid), out)
+}
+
+// swagger:route POST /keys/{set}/import jsonWebKey importJsonWebKeys
+//
+// Import PEM or DER encoded keys into a JSON Web Key Set
+//
+// Use this endpoint to add existing RSA and ECDSA keys to a JSON Web Key Set. The request body is either a PEM document,
+// which may contain several blocks, or a single DER encoded key. Supported are PKCS#1, PKCS#8 and SEC 1 private keys,
+// PKIX and PKCS#1 public keys, and X.509 certificates. The set is created if it does not exist.
+//
+// The key id is the base64url encoded SHA-256 thumbprint (RFC 7638) of the public key. Private keys are stored as
+// "private:<thumbprint>" and "public:<thumbprint>", public keys and certificates as "public:<thumbprint>". The request
+// fails with 409 if the set already contains one of the imported keys.
+//
+// A JSON Web Key (JWK) is a JavaScript Object Notation (JSON) data structure that represents a cryptographic key. A JWK Set is a JSON data structure that represents a set of JWKs. A JSON Web Key is identified by its set and key id. ORY Hydra uses this functionality to store cryptographic keys used for TLS and JSON Web Tokens (such as OpenID Connect ID tokens), and allows storing user-defined keys as well.
+//
+// The subject making the request needs to be assigned to a policy containing:
+//
+//  ```
+//  {
+//    "resources": ["rn:hydra:keys:<set>"],
+//    "actions": ["create"],
+//    "effect": "allow"
+//  }
+//  ```
+//
+//     Consumes:
+//     - application/x-pem-file
+//     - application/octet-stream
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Security:
+//       oauth2: hydra.keys.create
+//
+//     Responses:
+//       201: jsonWebKeySet
+//       400: genericError
+//       401: genericError
+//       403: genericError
+//       409: genericError
+//       500: genericError
+func (h *Handler) Import(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var ctx = context.Background()
+	var set = ps.ByName("set")
+
+	if _, err := h.W.TokenAllowed(ctx, h.W.TokenFromRequest(r), &firewall.TokenAccessRequest{
+		Resource: h.PrefixResource("keys:" + set),
+		Action:   "create",
+	}, "hydra.keys.create"); err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	raw, err := ioutil.ReadAll(io.LimitReader(r.Body, maxImportSize))
+	if err != nil {
+		h.H.WriteError(w, r, errors.WithStack(err))
+		return
+	}
+
+	keys, err := ImportKeys(raw)
+	if err != nil {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.KeyPolicy.CheckAll(keys); err != nil {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	if existing, err := h.Manager.GetKeySet(set); err == nil {
+		for _, key := range keys.Keys {
+			if len(existing.Key(key.KeyID)) > 0 {
+				h.H.WriteErrorCode(w, r, http.StatusConflict, errors.Errorf("Key %s already exists in set %s", key.KeyID, set))
+				return
+			}
+		}
+	} else if errors.Cause(err) != pkg.ErrNotFound {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
 	if err := h.Manager.AddKeySet(set, keys); err != nil {
 		h.H.WriteError(w, r, err)
 		return
@@ -370,12 +838,205 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request, ps httprouter.P
 	h.H.WriteCreated(w, r, fmt.Sprintf("%s://%s/keys/%s", r.URL.Scheme, r.URL.Host, set), keys)
 }
 
+// swagger:route POST /keys/{set}/rotate jsonWebKey rotateJsonWebKeySet
+//
+// Rotate a JSON Web Key Set
+//
+// This endpoint generates a new key and appends it to a JSON Web Key Set, like appendJsonWebKey, but is safe to call
+// concurrently: rotations of the same set are executed one after another, even across Hydra instances. If the set
+// was rotated by another request while this request was waiting, no further key is generated. Instead, the keys of
+// that rotation are returned with status 200, so all callers receive the same new key. The key id is optional and
+// generated if omitted. The set is created if it does not exist.
+//
+// A JSON Web Key (JWK) is a JavaScript Object Notation (JSON) data structure that represents a cryptographic key. A JWK Set is a JSON data structure that represents a set of JWKs. A JSON Web Key is identified by its set and key id. ORY Hydra uses this functionality to store cryptographic keys used for TLS and JSON Web Tokens (such as OpenID Connect ID tokens), and allows storing user-defined keys as well.
+//
+// The subject making the request needs to be assigned to a policy containing:
+//
+//  ```
+//  {
+//    "resources": ["rn:hydra:keys:<set>"],
+//    "actions": ["create"],
+//    "effect": "allow"
+//  }
+//  ```
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Security:
+//       oauth2: hydra.keys.create
+//
+//     Responses:
+//       200: jsonWebKeySet
+//       201: jsonWebKeySet
+//       400: genericError
+//       401: genericError
+//       403: genericError
+//       409: genericError
+//       500: genericError
+func (h *Handler) Rotate(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var ctx = context.Background()
+	var keyRequest createRequest
+	var set = ps.ByName("set")
+	var since = time.Now().UTC()
+
+	if _, err := h.W.TokenAllowed(ctx, h.W.TokenFromRequest(r), &firewall.TokenAccessRequest{
+		Resource: h.PrefixResource("keys:" + set),
+		Action:   "create",
+	}, "hydra.keys.create"); err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&keyRequest); err != nil {
+		h.H.WriteError(w, r, errors.WithStack(err))
+		return
+	}
+
+	generator, err := h.generatorFor(&keyRequest)
+	if err != nil {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	lifetime, err := keyRequest.lifetime(since)
+	if err != nil {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	var conflict error
+	keys, rotated, err := h.Manager.RotateKeySet(set, since, func() (*jose.JSONWebKeySet, error) {
+		keys, err := generator.Generate(keyRequest.KeyID)
+		if err != nil {
+			return nil, err
+		}
+
+		if existing, err := h.Manager.GetKeySet(set); err == nil {
+			for _, key := range keys.Keys {
+				if len(existing.Key(key.KeyID)) > 0 {
+					conflict = errors.Errorf("Key %s already exists in set %s", key.KeyID, set)
+					return nil, conflict
+				}
+			}
+		} else if errors.Cause(err) != pkg.ErrNotFound {
+			return nil, err
+		}
+
+		if keyRequest.Use != "" {
+			for k := range keys.Keys {
+				keys.Keys[k].Use = keyRequest.Use
+			}
+		}
+		return keys, nil
+	})
+	if conflict != nil && errors.Cause(err) == conflict {
+		h.H.WriteErrorCode(w, r, http.StatusConflict, err)
+		return
+	} else if err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	if rotated && !lifetime.IsZero() {
+		for _, key := range keys.Keys {
+			if err := h.Manager.SetKeyLifetime(set, key.KeyID, lifetime); err != nil {
+				h.H.WriteError(w, r, err)
+				return
+			}
+		}
+	}
+
+	out, err := h.encodeKeySet(set, keys)
+	if err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	if !rotated {
+		h.H.Write(w, r, out)
+		return
+	}
+	h.H.WriteCreated(w, r, fmt.Sprintf("%s://%s/keys/%s", r.URL.Scheme, r.URL.Host, set), out)
+}
+
+// postKey dispatches POST /keys/:set/:key, because httprouter does not allow the static import, credentials and rotate
+// paths next to the key id wildcard.
+func (h *Handler) postKey(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	switch ps.ByName("key") {
+	case "import":
+		h.Import(w, r, ps)
+	case "credentials":
+		h.CreateCredentials(w, r, ps)
+	case "rotate":
+		h.Rotate(w, r, ps)
+	default:
+		h.AppendKey(w, r, ps)
+	}
+}
+
+// addGeneratedKeys stores generated keys together with the use and lifetime requested for them.
+func (h *Handler) addGeneratedKeys(set string, keys *jose.JSONWebKeySet, use string, lifetime KeyLifetime) error {
+	if use != "" {
+		for k := range keys.Keys {
+			keys.Keys[k].Use = use
+		}
+	}
+
+	if err := h.Manager.AddKeySet(set, keys); err != nil {
+		return err
+	}
+
+	if lifetime.IsZero() {
+		return nil
+	}
+
+	for _, key := range keys.Keys {
+		if err := h.Manager.SetKeyLifetime(set, key.KeyID, lifetime); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// generatorFor returns the key generator for the algorithm and key size of the request.
+func (h *Handler) generatorFor(keyRequest *createRequest) (KeyGenerator, error) {
+	generator, found := h.GetGenerators()[keyRequest.Algorithm]
+	if !found {
+		return nil, errors.Errorf("Generator %s unknown", keyRequest.Algorithm)
+	}
+
+	if keyRequest.Bits != 0 {
+		if _, ok := generator.(*RS256Generator); !ok {
+			return nil, errors.Errorf("Generator %s does not support setting the key size", keyRequest.Algorithm)
+		} else if keyRequest.Bits < MinRSAKeyLength {
+			return nil, errors.Errorf("Key size must be at least %d bits", MinRSAKeyLength)
+		} else if keyRequest.Bits > MaxRSAKeyLength {
+			return nil, errors.Errorf("Key size must be at most %d bits", MaxRSAKeyLength)
+		}
+		generator = &RS256Generator{KeyLength: keyRequest.Bits}
+	}
+	return generator, nil
+}
+
 // swagger:route PUT /keys/{set} jsonWebKey updateJsonWebKeySet
 //
 // Update a JSON Web Key Set
 //
 // Use this method if you do not want to let Hydra generate the JWKs for you, but instead save your own.
 //
+// A key may carry a x5c certificate chain. The first certificate must contain the public key of the JSON Web Key and
+// every certificate must be signed by the one following it. If the key carries a x5t#S256 thumbprint, it must match the
+// first certificate. Keys that fail these checks are rejected with 400.
+//
+// Keys which are weaker than the configured key policy, for example RSA keys with less than 2048 bits, or which use a
+// denied algorithm are rejected with 400 as well.
+//
 // A JSON Web Key (JWK) is a JavaScript Object Notation (JSON) data structure that represents a cryptographic key. A JWK Set is a JSON data structure that represents a set of JWKs. A JSON Web Key is identified by its set and key id. ORY Hydra uses this functionality to store cryptographic keys used for TLS and JSON Web Tokens (such as OpenID Connect ID tokens), and allows storing user-defined keys as well.
 //
 // The subject making the request needs to be assigned to a policy containing:
@@ -401,6 +1062,7 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request, ps httprouter.P
 //
 //     Responses:
 //       200: jsonWebKeySet
+//       400: genericError
 //       401: genericError
 //       403: genericError
 //       500: genericError
@@ -424,19 +1086,25 @@ func (h *Handler) UpdateKeySet(w http.ResponseWriter, r *http.Request, ps httpro
 	}
 
 	for _, request := range requests.Keys {
-		key := &jose.JSONWebKey{}
-		if err := key.UnmarshalJSON(request); err != nil {
-			h.H.WriteError(w, r, errors.WithStack(err))
+		key, err := DecodeCertifiedKey(request)
+		if err != nil {
+			h.H.WriteErrorCode(w, r, http.StatusBadRequest, err)
+			return
 		}
 		keySet.Keys = append(keySet.Keys, *key)
 	}
 
+	if err := h.KeyPolicy.CheckAll(keySet); err != nil {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, err)
+		return
+	}
+
 	if err := h.Manager.AddKeySet(set, keySet); err != nil {
 		h.H.WriteError(w, r, err)
 		return
 	}
 
-	h.H.Write(w, r, keySet)
+	h.writeKeySet(w, r, set, keySet)
 }
 
 // swagger:route PUT /keys/{set}/{kid} jsonWebKey updateJsonWebKey
@@ -445,6 +1113,13 @@ func (h *Handler) UpdateKeySet(w http.ResponseWriter, r *http.Request, ps httpro
 //
 // Use this method if you do not want to let Hydra generate the JWKs for you, but instead save your own.
 //
+// A key may carry a x5c certificate chain. The first certificate must contain the public key of the JSON Web Key and
+// every certificate must be signed by the one following it. If the key carries a x5t#S256 thumbprint, it must match the
+// first certificate. Keys that fail these checks are rejected with 400.
+//
+// Keys which are weaker than the configured key policy, for example RSA keys with less than 2048 bits, or which use a
+// denied algorithm are rejected with 400 as well.
+//
 // A JSON Web Key (JWK) is a JavaScript Object Notation (JSON) data structure that represents a cryptographic key. A JWK Set is a JSON data structure that represents a set of JWKs. A JSON Web Key is identified by its set and key id. ORY Hydra uses this functionality to store cryptographic keys used for TLS and JSON Web Tokens (such as OpenID Connect ID tokens), and allows storing user-defined keys as well.
 //
 // The subject making the request needs to be assigned to a policy containing:
@@ -470,19 +1145,26 @@ func (h *Handler) UpdateKeySet(w http.ResponseWriter, r *http.Request, ps httpro
 //
 //     Responses:
 //       200: jsonWebKey
+//       400: genericError
 //       401: genericError
 //       403: genericError
 //       500: genericError
 func (h *Handler) UpdateKey(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	var ctx = context.Background()
-	var key jose.JSONWebKey
+	var raw json.RawMessage
 	var set = ps.ByName("set")
 
-	if err := json.NewDecoder(r.Body).Decode(&key); err != nil {
+	if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
 		h.H.WriteError(w, r, errors.WithStack(err))
 		return
 	}
 
+	key, err := DecodeCertifiedKey(raw)
+	if err != nil {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, err)
+		return
+	}
+
 	if _, err := h.W.TokenAllowed(ctx, h.W.TokenFromRequest(r), &firewall.TokenAccessRequest{
 		Resource: h.PrefixResource("keys:" + set + ":" + key.KeyID),
 		Action:   "update",
@@ -491,12 +1173,148 @@ func (h *Handler) UpdateKey(w http.ResponseWriter, r *http.Request, ps httproute
 		return
 	}
 
-	if err := h.Manager.AddKey(set, &key); err != nil {
+	if err := h.KeyPolicy.Check(key); err != nil {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.Manager.AddKey(set, key); err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	out, err := EncodeCertifiedKey(key)
+	if err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	h.H.Write(w, r, &out)
+}
+
+// swagger:route PATCH /keys/{set}/{kid} jsonWebKey patchJsonWebKey
+//
+// Patch a JSON Web Key
+//
+// Change single fields of a JSON Web Key, for example its "use" or "x5c" certificate chain, without replacing the whole
+// key. Send either a JSON Merge Patch (RFC 7386) with `Content-Type: application/merge-patch+json` or a JSON Patch
+// (RFC 6902) with `Content-Type: application/json-patch+json`. The patch is applied to the stored key atomically.
+//
+// To make sure the key did not change since it was read, send the `ETag` returned when retrieving the key in the
+// `If-Match` header or add a JSON Patch `test` operation. If the key changed, 412 or 409 is returned respectively. The
+// patched key is validated like keys sent to the update endpoint, its key id can not be changed.
+//
+// A JSON Web Key (JWK) is a JavaScript Object Notation (JSON) data structure that represents a cryptographic key. A JWK Set is a JSON data structure that represents a set of JWKs. A JSON Web Key is identified by its set and key id. ORY Hydra uses this functionality to store cryptographic keys used for TLS and JSON Web Tokens (such as OpenID Connect ID tokens), and allows storing user-defined keys as well.
+//
+// The subject making the request needs to be assigned to a policy containing:
+//
+//  ```
+//  {
+//    "resources": ["rn:hydra:keys:<set>:<kid>"],
+//    "actions": ["update"],
+//    "effect": "allow"
+//  }
+//  ```
+//
+//     Consumes:
+//     - application/merge-patch+json
+//     - application/json-patch+json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Security:
+//       oauth2: hydra.keys.update
+//
+//     Responses:
+//       200: jsonWebKey
+//       400: genericError
+//       401: genericError
+//       403: genericError
+//       409: genericError
+//       412: genericError
+//       415: genericError
+//       500: genericError
+func (h *Handler) PatchKey(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var ctx = context.Background()
+	var set = ps.ByName("set")
+	var kid = ps.ByName("key")
+
+	patch, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		h.H.WriteError(w, r, errors.WithStack(err))
+		return
+	}
+
+	if _, err := h.W.TokenAllowed(ctx, h.W.TokenFromRequest(r), &firewall.TokenAccessRequest{
+		Resource: h.PrefixResource("keys:" + set + ":" + kid),
+		Action:   "update",
+	}, "hydra.keys.update"); err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	// invalid is set if the patched key is invalid, so that it is reported with 400.
+	var invalid error
+	key, err := h.Manager.PatchKey(set, kid, func(key *jose.JSONWebKey) (*jose.JSONWebKey, error) {
+		invalid = nil
+
+		doc, err := EncodeCertifiedKey(key)
+		if err != nil {
+			return nil, err
+		}
+
+		if match := r.Header.Get("If-Match"); match != "" {
+			etag, err := pkg.ETag(doc)
+			if err != nil {
+				return nil, err
+			} else if !pkg.ETagMatches(match, etag) {
+				return nil, errors.WithStack(pkg.ErrPreconditionFailed)
+			}
+		}
+
+		out, err := pkg.ApplyPatch(r.Header.Get("Content-Type"), doc, patch)
+		if err != nil {
+			return nil, err
+		}
+
+		p, err := DecodeCertifiedKey(out)
+		if err != nil {
+			invalid = err
+			return nil, invalid
+		} else if p.KeyID != kid {
+			invalid = errors.Errorf("The key id can not be changed from %s to %s", kid, p.KeyID)
+			return nil, invalid
+		} else if err := h.KeyPolicy.Check(p); err != nil {
+			invalid = err
+			return nil, invalid
+		}
+		return p, nil
+	})
+	if invalid != nil {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, invalid)
+		return
+	} else if err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	out, err := EncodeCertifiedKey(key)
+	if err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	etag, err := pkg.ETag(out)
+	if err != nil {
 		h.H.WriteError(w, r, err)
 		return
 	}
 
-	h.H.Write(w, r, key)
+	w.Header().Set("ETag", etag)
+	h.H.Write(w, r, &out)
 }
 
 // swagger:route DELETE /keys/{set} jsonWebKey deleteJsonWebKeySet
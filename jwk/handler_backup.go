@@ -0,0 +1,188 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/hydra/firewall"
+	"github.com/pkg/errors"
+)
+
+// swagger:model jsonWebKeyBackupExportRequest
+type backupExportRequest struct {
+	// The passphrase used to encrypt the backup, must be at least 8 characters long.
+	// required: true
+	// in: body
+	Passphrase string `json:"passphrase"`
+}
+
+// swagger:model jsonWebKeyBackupImportRequest
+type backupImportRequest struct {
+	// The passphrase the backup was encrypted with.
+	// required: true
+	// in: body
+	Passphrase string `json:"passphrase"`
+
+	// The backup to restore.
+	// required: true
+	// in: body
+	Backup *Backup `json:"backup"`
+}
+
+// swagger:route POST /backup/keys/export jsonWebKey exportJsonWebKeySets
+//
+// Export all JSON Web Key Sets
+//
+// This endpoint exports all JSON Web Key Sets, including private keys and the lifetime of every key, encrypted with
+// the given passphrase. The backup can be restored using the import endpoint, for example after losing the database
+// or when moving to a different database. The encryption key is derived from the passphrase using scrypt, the backup
+// does not depend on the system secret.
+//
+// A JSON Web Key (JWK) is a JavaScript Object Notation (JSON) data structure that represents a cryptographic key. A JWK Set is a JSON data structure that represents a set of JWKs. A JSON Web Key is identified by its set and key id. ORY Hydra uses this functionality to store cryptographic keys used for TLS and JSON Web Tokens (such as OpenID Connect ID tokens), and allows storing user-defined keys as well.
+//
+// The subject making the request needs to be assigned to a policy containing:
+//
+//  ```
+//  {
+//    "resources": ["rn:hydra:keys"],
+//    "actions": ["export"],
+//    "effect": "allow"
+//  }
+//  ```
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Security:
+//       oauth2: hydra.keys.export
+//
+//     Responses:
+//       200: jsonWebKeyBackup
+//       400: genericError
+//       401: genericError
+//       403: genericError
+//       500: genericError
+func (h *Handler) ExportBackup(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var ctx = context.Background()
+	var request backupExportRequest
+
+	if _, err := h.W.TokenAllowed(ctx, h.W.TokenFromRequest(r), &firewall.TokenAccessRequest{
+		Resource: h.PrefixResource("keys"),
+		Action:   "export",
+	}, "hydra.keys.export"); err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, errors.WithStack(err))
+		return
+	}
+
+	if len(request.Passphrase) < MinBackupPassphraseLength {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, errors.Errorf("The passphrase must be at least %d characters long", MinBackupPassphraseLength))
+		return
+	}
+
+	backup, err := ExportKeySets(h.Manager, request.Passphrase)
+	if err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	h.H.Write(w, r, backup)
+}
+
+// swagger:route POST /backup/keys/import jsonWebKey importJsonWebKeySets
+//
+// Import JSON Web Key Sets from a backup
+//
+// This endpoint restores all JSON Web Key Sets of a backup created by the export endpoint. Keys are added to
+// existing sets. If any key of the backup already exists, nothing is imported and a 409 error is returned.
+//
+// A JSON Web Key (JWK) is a JavaScript Object Notation (JSON) data structure that represents a cryptographic key. A JWK Set is a JSON data structure that represents a set of JWKs. A JSON Web Key is identified by its set and key id. ORY Hydra uses this functionality to store cryptographic keys used for TLS and JSON Web Tokens (such as OpenID Connect ID tokens), and allows storing user-defined keys as well.
+//
+// The subject making the request needs to be assigned to a policy containing:
+//
+//  ```
+//  {
+//    "resources": ["rn:hydra:keys"],
+//    "actions": ["import"],
+//    "effect": "allow"
+//  }
+//  ```
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Security:
+//       oauth2: hydra.keys.create
+//
+//     Responses:
+//       201: jsonWebKeySetSummaries
+//       400: genericError
+//       401: genericError
+//       403: genericError
+//       409: genericError
+//       500: genericError
+func (h *Handler) ImportBackup(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var ctx = context.Background()
+	var request backupImportRequest
+
+	if _, err := h.W.TokenAllowed(ctx, h.W.TokenFromRequest(r), &firewall.TokenAccessRequest{
+		Resource: h.PrefixResource("keys"),
+		Action:   "import",
+	}, "hydra.keys.create"); err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, errors.WithStack(err))
+		return
+	} else if request.Backup == nil {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, errors.New("The backup is missing"))
+		return
+	}
+
+	summaries, err := ImportKeySets(h.Manager, request.Backup, request.Passphrase)
+	if errors.Cause(err) == ErrBackupConflict {
+		h.H.WriteErrorCode(w, r, http.StatusConflict, err)
+		return
+	} else if errors.Cause(err) == ErrInvalidBackup {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, err)
+		return
+	} else if err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	h.H.WriteCreated(w, r, fmt.Sprintf("%s://%s%s", r.URL.Scheme, r.URL.Host, KeyHandlerPath), summaries)
+}
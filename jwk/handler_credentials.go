@@ -0,0 +1,222 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/hydra/client"
+	"github.com/ory/hydra/firewall"
+	"github.com/ory/hydra/rand/sequence"
+	"github.com/ory/ladon"
+	"github.com/pkg/errors"
+)
+
+// credentialsActions are the actions which may be delegated to key management credentials.
+var credentialsActions = []string{"get", "create", "update", "delete"}
+
+// swagger:model jsonWebKeySetCredentialsRequest
+type credentialsRequest struct {
+	// The actions the credentials may perform on the keys of the set. Supports "get", "create", "update" and "delete",
+	// defaults to "get", "create" and "update".
+	// in: body
+	Actions []string `json:"actions"`
+
+	// The name of the OAuth 2.0 Client, defaults to "Key management for <set>".
+	// in: body
+	Name string `json:"client_name"`
+}
+
+// Credentials are OAuth 2.0 Client credentials which may only manage the keys of a single JSON Web Key Set.
+//
+// swagger:model jsonWebKeySetCredentials
+type Credentials struct {
+	// Set is the name of the JSON Web Key Set the credentials may manage.
+	Set string `json:"set"`
+
+	// ClientID is the id of the OAuth 2.0 Client.
+	ClientID string `json:"client_id"`
+
+	// ClientSecret is the secret of the OAuth 2.0 Client. It is only returned once.
+	ClientSecret string `json:"client_secret"`
+
+	// PolicyID is the id of the policy which grants the actions on the set to the client.
+	PolicyID string `json:"policy_id"`
+
+	// Actions are the actions the credentials may perform on the keys of the set.
+	Actions []string `json:"actions"`
+
+	// Scope is the scope the OAuth 2.0 Client may request, one hydra.keys.<action> scope per action.
+	Scope string `json:"scope"`
+}
+
+// swagger:route POST /keys/{set}/credentials jsonWebKey createJsonWebKeySetCredentials
+//
+// Create credentials which may only manage the keys of a JSON Web Key Set
+//
+// This endpoint creates an OAuth 2.0 Client using the client_credentials grant together with a policy which allows
+// that client to perform the requested actions on the keys of the given set, and nothing else. This allows every
+// service to rotate its own keys without holding administrative privileges. The client and the policy are created
+// together: if the policy can not be created, the client is removed again. The client secret is only returned once.
+//
+// A JSON Web Key (JWK) is a JavaScript Object Notation (JSON) data structure that represents a cryptographic key. A JWK Set is a JSON data structure that represents a set of JWKs. A JSON Web Key is identified by its set and key id. ORY Hydra uses this functionality to store cryptographic keys used for TLS and JSON Web Tokens (such as OpenID Connect ID tokens), and allows storing user-defined keys as well.
+//
+// The subject making the request needs to be assigned to a policy containing:
+//
+//  ```
+//  {
+//    "resources": ["rn:hydra:keys:<set>"],
+//    "actions": ["delegate"],
+//    "effect": "allow"
+//  }
+//  ```
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Security:
+//       oauth2: hydra.keys.delegate
+//
+//     Responses:
+//       201: jsonWebKeySetCredentials
+//       400: genericError
+//       401: genericError
+//       403: genericError
+//       404: genericError
+//       500: genericError
+func (h *Handler) CreateCredentials(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var ctx = context.Background()
+	var request credentialsRequest
+	var set = ps.ByName("set")
+
+	if h.Clients == nil || h.Policies == nil {
+		h.H.WriteErrorCode(w, r, http.StatusNotFound, errors.New("Key management credentials are not available"))
+		return
+	}
+
+	if _, err := h.W.TokenAllowed(ctx, h.W.TokenFromRequest(r), &firewall.TokenAccessRequest{
+		Resource: h.PrefixResource("keys:" + set),
+		Action:   "delegate",
+	}, "hydra.keys.delegate"); err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		h.H.WriteError(w, r, errors.WithStack(err))
+		return
+	}
+
+	if strings.ContainsAny(set, "<>") {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, errors.Errorf("Set %s must not contain < or >", set))
+		return
+	}
+
+	actions, err := validateCredentialsActions(request.Actions)
+	if err != nil {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	var scopes []string
+	for _, action := range actions {
+		scopes = append(scopes, "hydra.keys."+action)
+	}
+
+	if request.Name == "" {
+		request.Name = "Key management for " + set
+	}
+
+	secret, err := sequence.RuneSequence(32, []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ1234567890_-.~"))
+	if err != nil {
+		h.H.WriteError(w, r, errors.WithStack(err))
+		return
+	}
+
+	c := &client.Client{
+		Name:          request.Name,
+		Secret:        string(secret),
+		GrantTypes:    []string{"client_credentials"},
+		ResponseTypes: []string{"token"},
+		Scope:         strings.Join(scopes, " "),
+	}
+	if err := h.Clients.CreateClient(c); err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	policy := &ladon.DefaultPolicy{
+		ID:          "keys-credentials-" + c.GetID(),
+		Description: fmt.Sprintf("This policy was created together with client %s and allows it to manage the keys of set %s.", c.GetID(), set),
+		Subjects:    []string{c.GetID()},
+		Resources:   []string{h.PrefixResource("keys:" + set), h.PrefixResource("keys:" + set + ":<.*>")},
+		Actions:     actions,
+		Effect:      ladon.AllowAccess,
+	}
+	if err := h.Policies.Create(policy); err != nil {
+		if de := h.Clients.DeleteClient(c.GetID()); de != nil {
+			h.H.WriteError(w, r, errors.Wrap(err, de.Error()))
+			return
+		}
+		h.H.WriteError(w, r, errors.WithStack(err))
+		return
+	}
+
+	h.H.WriteCreated(w, r, fmt.Sprintf("%s://%s/clients/%s", r.URL.Scheme, r.URL.Host, c.GetID()), &Credentials{
+		Set:          set,
+		ClientID:     c.GetID(),
+		ClientSecret: string(secret),
+		PolicyID:     policy.ID,
+		Actions:      actions,
+		Scope:        c.Scope,
+	})
+}
+
+// validateCredentialsActions checks that all actions may be delegated and removes duplicates.
+func validateCredentialsActions(requested []string) ([]string, error) {
+	if len(requested) == 0 {
+		return []string{"get", "create", "update"}, nil
+	}
+
+	seen := map[string]bool{}
+	var actions []string
+	for _, action := range requested {
+		var valid bool
+		for _, allowed := range credentialsActions {
+			if action == allowed {
+				valid = true
+				break
+			}
+		}
+
+		if !valid {
+			return nil, errors.Errorf("Action %s can not be delegated, expected one of %s", action, strings.Join(credentialsActions, ", "))
+		} else if !seen[action] {
+			seen[action] = true
+			actions = append(actions, action)
+		}
+	}
+	return actions, nil
+}
@@ -15,17 +15,30 @@
 package jwk_test
 
 import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
 	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/ory/fosite"
 	"github.com/ory/herodot"
+	hclient "github.com/ory/hydra/client"
 	"github.com/ory/hydra/compose"
+	"github.com/ory/hydra/firewall"
 	. "github.com/ory/hydra/jwk"
 	"github.com/ory/ladon"
+	"github.com/ory/ladon/manager/memory"
+	"github.com/pkg/errors"
 	"github.com/square/go-jose"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -33,9 +46,11 @@ import (
 
 var testServer *httptest.Server
 var IDKS *jose.JSONWebKeySet
+var testClient *http.Client
 
 func init() {
-	localWarden, _ := compose.NewMockFirewall(
+	var localWarden firewall.Firewall
+	localWarden, testClient = compose.NewMockFirewall(
 		"tests",
 		"alice",
 		fosite.Arguments{
@@ -43,12 +58,19 @@ func init() {
 			"hydra.keys.get",
 			"hydra.keys.delete",
 			"hydra.keys.update",
+			"hydra.keys.list",
 		}, &ladon.DefaultPolicy{
 			ID:        "1",
 			Subjects:  []string{"<.*>"},
 			Resources: []string{"rn:hydra:keys:<[^:]+>:public:test-id"},
 			Actions:   []string{"get"},
 			Effect:    ladon.AllowAccess,
+		}, &ladon.DefaultPolicy{
+			ID:        "2",
+			Subjects:  []string{"alice"},
+			Resources: []string{"rn:hydra:keys"},
+			Actions:   []string{"list"},
+			Effect:    ladon.AllowAccess,
 		},
 	)
 	router := httprouter.New()
@@ -79,3 +101,571 @@ func TestHandlerWellKnown(t *testing.T) {
 	require.NotNil(t, resp, "Could not find key public")
 	assert.Equal(t, resp, IDKS.Key("public:test-id"))
 }
+
+func TestHandlerWellKnownETag(t *testing.T) {
+	JWKPath := "/.well-known/jwks.json"
+	res, err := http.Get(testServer.URL + JWKPath)
+	require.NoError(t, err)
+	res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	etag := res.Header.Get("ETag")
+	require.NotEmpty(t, etag)
+
+	for _, tc := range []struct {
+		d      string
+		match  string
+		status int
+	}{
+		{d: "matching etag", match: etag, status: http.StatusNotModified},
+		{d: "weak matching etag", match: `"foo", W/` + etag, status: http.StatusNotModified},
+		{d: "stale etag", match: `"foo"`, status: http.StatusOK},
+	} {
+		t.Run("case="+tc.d, func(t *testing.T) {
+			req, err := http.NewRequest("GET", testServer.URL+JWKPath, nil)
+			require.NoError(t, err)
+			req.Header.Set("If-None-Match", tc.match)
+
+			res, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer res.Body.Close()
+
+			assert.Equal(t, tc.status, res.StatusCode)
+			assert.Equal(t, etag, res.Header.Get("ETag"))
+		})
+	}
+}
+
+func TestHandlerListKeySets(t *testing.T) {
+	res, err := testClient.Get(testServer.URL + KeyHandlerPath)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	var summaries []KeySetSummary
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&summaries))
+	require.Len(t, summaries, 1)
+	assert.Equal(t, KeySetSummary{Set: IDTokenKeyName, Keys: 2, Algorithms: []string{"RS256"}}, summaries[0])
+}
+
+func TestHandlerAppendKey(t *testing.T) {
+	localWarden, client := compose.NewMockFirewall(
+		"tests",
+		"alice",
+		fosite.Arguments{"hydra.keys.create"},
+		&ladon.DefaultPolicy{
+			ID:        "1",
+			Subjects:  []string{"alice"},
+			Resources: []string{"rn:hydra:keys:<[^:]+>:<.*>"},
+			Actions:   []string{"create"},
+			Effect:    ladon.AllowAccess,
+		},
+	)
+
+	manager := &MemoryManager{}
+	existing, err := (&ECDSA256Generator{}).Generate("old")
+	require.NoError(t, err)
+	require.NoError(t, manager.AddKeySet("rotate", existing))
+
+	router := httprouter.New()
+	h := Handler{Manager: manager, W: localWarden, H: herodot.NewJSONWriter(nil)}
+	h.SetRoutes(router)
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	appendKey := func(set, kid, body string) *http.Response {
+		res, err := client.Post(ts.URL+KeyHandlerPath+"/"+set+"/"+kid, "application/json", bytes.NewBufferString(body))
+		require.NoError(t, err)
+		return res
+	}
+
+	res := appendKey("rotate", "new", `{"alg":"ES256"}`)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+
+	var created jose.JSONWebKeySet
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&created))
+	require.Len(t, created.Keys, 2)
+	assert.Len(t, created.Key("public:new"), 1)
+	assert.Len(t, created.Key("private:new"), 1)
+
+	keys, err := manager.GetKeySet("rotate")
+	require.NoError(t, err)
+	assert.Len(t, keys.Keys, 4)
+	assert.Len(t, keys.Key("public:old"), 1)
+
+	for k, tc := range []struct {
+		set, kid, body string
+		code           int
+	}{
+		{set: "rotate", kid: "new", body: `{"alg":"ES256"}`, code: http.StatusConflict},
+		{set: "does-not-exist", kid: "new", body: `{"alg":"ES256"}`, code: http.StatusNotFound},
+		{set: "rotate", kid: "other", body: `{"alg":"ES256","kid":"new"}`, code: http.StatusBadRequest},
+		{set: "rotate", kid: "other", body: `{"alg":"foo"}`, code: http.StatusBadRequest},
+	} {
+		res := appendKey(tc.set, tc.kid, tc.body)
+		res.Body.Close()
+		assert.Equal(t, tc.code, res.StatusCode, "case %d", k)
+	}
+}
+
+func TestHandlerRotateKeySet(t *testing.T) {
+	localWarden, client := compose.NewMockFirewall(
+		"tests",
+		"alice",
+		fosite.Arguments{"hydra.keys.create"},
+		&ladon.DefaultPolicy{
+			ID:        "1",
+			Subjects:  []string{"alice"},
+			Resources: []string{"rn:hydra:keys:<[^:]+>"},
+			Actions:   []string{"create"},
+			Effect:    ladon.AllowAccess,
+		},
+	)
+
+	manager := &MemoryManager{}
+	router := httprouter.New()
+	h := Handler{Manager: manager, W: localWarden, H: herodot.NewJSONWriter(nil)}
+	h.SetRoutes(router)
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	rotate := func(body string) *http.Response {
+		res, err := client.Post(ts.URL+KeyHandlerPath+"/signing/rotate", "application/json", bytes.NewBufferString(body))
+		require.NoError(t, err)
+		return res
+	}
+
+	res := rotate(`{"alg":"ES256","kid":"first","use":"sig"}`)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+
+	var created jose.JSONWebKeySet
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&created))
+	require.Len(t, created.Keys, 2)
+	assert.Equal(t, "sig", created.Key("public:first")[0].Use)
+
+	for k, tc := range []struct {
+		body string
+		code int
+	}{
+		{body: `{"alg":"ES256","kid":"first"}`, code: http.StatusConflict},
+		{body: `{"alg":"foo"}`, code: http.StatusBadRequest},
+		{body: `{"alg":"ES256"}`, code: http.StatusCreated},
+	} {
+		res := rotate(tc.body)
+		res.Body.Close()
+		assert.Equal(t, tc.code, res.StatusCode, "case %d", k)
+	}
+
+	keys, err := manager.GetKeySet("signing")
+	require.NoError(t, err)
+	assert.Len(t, keys.Keys, 4)
+}
+
+func TestHandlerPatchKey(t *testing.T) {
+	localWarden, client := compose.NewMockFirewall(
+		"tests",
+		"alice",
+		fosite.Arguments{"hydra.keys.get", "hydra.keys.update"},
+		&ladon.DefaultPolicy{
+			ID:        "1",
+			Subjects:  []string{"alice"},
+			Resources: []string{"rn:hydra:keys:<.*>"},
+			Actions:   []string{"get", "update"},
+			Effect:    ladon.AllowAccess,
+		},
+	)
+
+	manager := &MemoryManager{}
+	keys, err := testGenerator.Generate("patch")
+	require.NoError(t, err)
+	require.NoError(t, manager.AddKeySet("patch", keys))
+
+	router := httprouter.New()
+	h := Handler{Manager: manager, W: localWarden, H: herodot.NewJSONWriter(nil)}
+	h.SetRoutes(router)
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	url := ts.URL + KeyHandlerPath + "/patch/public:patch"
+	res, err := client.Get(url)
+	require.NoError(t, err)
+	res.Body.Close()
+	etag := res.Header.Get("ETag")
+	require.NotEmpty(t, etag)
+
+	patch := func(contentType, match, body string) *http.Response {
+		req, err := http.NewRequest("PATCH", url, bytes.NewBufferString(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", contentType)
+		if match != "" {
+			req.Header.Set("If-Match", match)
+		}
+
+		res, err := client.Do(req)
+		require.NoError(t, err)
+		return res
+	}
+
+	res = patch("application/merge-patch+json", etag, `{"use":"enc"}`)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.NotEqual(t, etag, res.Header.Get("ETag"))
+
+	var patched jose.JSONWebKey
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&patched))
+	assert.Equal(t, "enc", patched.Use)
+	assert.Equal(t, keys.Key("public:patch")[0].Key, patched.Key)
+
+	for k, tc := range []struct {
+		contentType string
+		match       string
+		body        string
+		code        int
+	}{
+		{contentType: "application/merge-patch+json", match: etag, body: `{"use":"sig"}`, code: http.StatusPreconditionFailed},
+		{contentType: "application/json", body: `{"use":"sig"}`, code: http.StatusUnsupportedMediaType},
+		{contentType: "application/json-patch+json", body: `[{"op":"test","path":"/use","value":"sig"}]`, code: http.StatusConflict},
+		{contentType: "application/json-patch+json", body: `[{"op":"replace","path":"/kid","value":"other"}]`, code: http.StatusBadRequest},
+		{contentType: "application/json-patch+json", body: `[{"op":"test","path":"/use","value":"enc"},{"op":"replace","path":"/use","value":"sig"}]`, code: http.StatusOK},
+	} {
+		res := patch(tc.contentType, tc.match, tc.body)
+		res.Body.Close()
+		assert.Equal(t, tc.code, res.StatusCode, "case %d", k)
+	}
+
+	stored, err := manager.GetKey("patch", "public:patch")
+	require.NoError(t, err)
+	assert.Equal(t, "sig", stored.Keys[0].Use)
+}
+
+func TestHandlerCreateKeyLifetime(t *testing.T) {
+	localWarden, client := compose.NewMockFirewall(
+		"tests",
+		"alice",
+		fosite.Arguments{"hydra.keys.create"},
+		&ladon.DefaultPolicy{
+			ID:        "1",
+			Subjects:  []string{"alice"},
+			Resources: []string{"rn:hydra:keys:<.*>"},
+			Actions:   []string{"create"},
+			Effect:    ladon.AllowAccess,
+		},
+	)
+
+	manager := &MemoryManager{}
+	router := httprouter.New()
+	h := Handler{Manager: manager, W: localWarden, H: herodot.NewJSONWriter(nil)}
+	h.SetRoutes(router)
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	now := time.Now().Unix()
+	for k, tc := range []struct {
+		body string
+		code int
+	}{
+		{body: fmt.Sprintf(`{"alg":"ES256","kid":"foo","use":"foo","exp":%d}`, now+3600), code: http.StatusBadRequest},
+		{body: fmt.Sprintf(`{"alg":"ES256","kid":"foo","exp":%d}`, now-3600), code: http.StatusBadRequest},
+		{body: fmt.Sprintf(`{"alg":"ES256","kid":"foo","nbf":%d,"exp":%d}`, now+7200, now+3600), code: http.StatusBadRequest},
+		{body: fmt.Sprintf(`{"alg":"ES256","kid":"foo","use":"enc","nbf":%d,"exp":%d}`, now, now+3600), code: http.StatusCreated},
+	} {
+		res, err := client.Post(ts.URL+KeyHandlerPath+"/lifetimes", "application/json", bytes.NewBufferString(tc.body))
+		require.NoError(t, err)
+		defer res.Body.Close()
+		require.Equal(t, tc.code, res.StatusCode, "case %d", k)
+		if tc.code != http.StatusCreated {
+			continue
+		}
+
+		var created struct {
+			Keys []struct {
+				KeyID     string `json:"kid"`
+				Use       string `json:"use"`
+				NotBefore int64  `json:"nbf"`
+				ExpiresAt int64  `json:"exp"`
+			} `json:"keys"`
+		}
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&created))
+		require.Len(t, created.Keys, 2)
+		for _, key := range created.Keys {
+			assert.Equal(t, "enc", key.Use)
+			assert.Equal(t, now, key.NotBefore)
+			assert.Equal(t, now+3600, key.ExpiresAt)
+		}
+	}
+
+	lifetimes, err := manager.GetKeyLifetimes("lifetimes")
+	require.NoError(t, err)
+	assert.Len(t, lifetimes, 2)
+}
+
+func TestHandlerWellKnownExpiredKeys(t *testing.T) {
+	manager := &MemoryManager{}
+	keys, err := testGenerator.Generate("test-id")
+	require.NoError(t, err)
+	require.NoError(t, manager.AddKeySet(IDTokenKeyName, keys))
+
+	localWarden, _ := compose.NewMockFirewall("tests", "alice", fosite.Arguments{}, &ladon.DefaultPolicy{
+		ID:        "1",
+		Subjects:  []string{"<.*>"},
+		Resources: []string{"rn:hydra:keys:<[^:]+>:public:test-id"},
+		Actions:   []string{"get"},
+		Effect:    ladon.AllowAccess,
+	})
+
+	router := httprouter.New()
+	h := Handler{Manager: manager, W: localWarden, H: herodot.NewJSONWriter(nil)}
+	h.SetRoutes(router)
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	getKeys := func() *jose.JSONWebKeySet {
+		res, err := http.Get(ts.URL + WellKnownKeysPath)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var known jose.JSONWebKeySet
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&known))
+		return &known
+	}
+
+	assert.Len(t, getKeys().Key("public:test-id"), 1)
+
+	require.NoError(t, manager.SetKeyLifetime(IDTokenKeyName, "public:test-id", KeyLifetime{ExpiresAt: time.Now().Add(-time.Minute)}))
+	assert.Empty(t, getKeys().Keys)
+}
+
+type failingPolicyManager struct {
+	ladon.Manager
+}
+
+func (m *failingPolicyManager) Create(policy ladon.Policy) error {
+	return errors.New("policy storage is unavailable")
+}
+
+func TestHandlerCreateCredentials(t *testing.T) {
+	localWarden, client := compose.NewMockFirewall(
+		"tests",
+		"alice",
+		fosite.Arguments{"hydra.keys.delegate"},
+		&ladon.DefaultPolicy{
+			ID:        "1",
+			Subjects:  []string{"alice"},
+			Resources: []string{"rn:hydra:keys:<[^:]+>"},
+			Actions:   []string{"delegate"},
+			Effect:    ladon.AllowAccess,
+		},
+	)
+
+	clients := hclient.NewMemoryManager(nil)
+	policies := memory.NewMemoryManager()
+	h := &Handler{Manager: &MemoryManager{}, W: localWarden, H: herodot.NewJSONWriter(nil), Clients: clients, Policies: policies}
+	router := httprouter.New()
+	h.SetRoutes(router)
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	createCredentials := func(set, body string) *http.Response {
+		res, err := client.Post(ts.URL+KeyHandlerPath+"/"+set+"/credentials", "application/json", bytes.NewBufferString(body))
+		require.NoError(t, err)
+		return res
+	}
+
+	res := createCredentials("my-service", `{"actions":["create","update","create"]}`)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+
+	var credentials Credentials
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&credentials))
+	assert.Equal(t, "my-service", credentials.Set)
+	assert.NotEmpty(t, credentials.ClientID)
+	assert.NotEmpty(t, credentials.ClientSecret)
+	assert.Equal(t, []string{"create", "update"}, credentials.Actions)
+	assert.Equal(t, "hydra.keys.create hydra.keys.update", credentials.Scope)
+
+	c, err := clients.Authenticate(credentials.ClientID, []byte(credentials.ClientSecret))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"client_credentials"}, c.GrantTypes)
+	assert.Equal(t, credentials.Scope, c.Scope)
+
+	_, err = policies.Get(credentials.PolicyID)
+	require.NoError(t, err)
+
+	w := &ladon.Ladon{Manager: policies}
+	for k, tc := range []struct {
+		resource, action string
+		allowed          bool
+	}{
+		{resource: "rn:hydra:keys:my-service", action: "create", allowed: true},
+		{resource: "rn:hydra:keys:my-service:public:foo", action: "update", allowed: true},
+		{resource: "rn:hydra:keys:my-service:public:foo", action: "delete", allowed: false},
+		{resource: "rn:hydra:keys:other-service", action: "create", allowed: false},
+		{resource: "rn:hydra:keys:my-service-2", action: "create", allowed: false},
+		{resource: "rn:hydra:clients", action: "create", allowed: false},
+	} {
+		err := w.IsAllowed(&ladon.Request{Subject: credentials.ClientID, Resource: tc.resource, Action: tc.action})
+		if tc.allowed {
+			assert.NoError(t, err, "case %d", k)
+		} else {
+			assert.Error(t, err, "case %d", k)
+		}
+	}
+
+	for k, tc := range []struct {
+		set, body string
+		code      int
+	}{
+		{set: "my-service", body: `{"actions":["list"]}`, code: http.StatusBadRequest},
+		{set: "my-service", body: `{"actions":["delegate"]}`, code: http.StatusBadRequest},
+		{set: "my-service:public", body: `{}`, code: http.StatusForbidden},
+	} {
+		res := createCredentials(tc.set, tc.body)
+		res.Body.Close()
+		assert.Equal(t, tc.code, res.StatusCode, "case %d", k)
+	}
+
+	all, err := clients.GetClients(100, 0)
+	require.NoError(t, err)
+	assert.Len(t, all, 1)
+
+	h.Policies = &failingPolicyManager{Manager: policies}
+	res = createCredentials("my-service", `{}`)
+	res.Body.Close()
+	assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+
+	all, err = clients.GetClients(100, 0)
+	require.NoError(t, err)
+	assert.Len(t, all, 1)
+
+	h.Clients = nil
+	res = createCredentials("my-service", `{}`)
+	res.Body.Close()
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+}
+
+func TestHandlerImportKeys(t *testing.T) {
+	localWarden, client := compose.NewMockFirewall(
+		"tests",
+		"alice",
+		fosite.Arguments{"hydra.keys.create"},
+		&ladon.DefaultPolicy{
+			ID:        "1",
+			Subjects:  []string{"alice"},
+			Resources: []string{"rn:hydra:keys:<[^:]+>"},
+			Actions:   []string{"create"},
+			Effect:    ladon.AllowAccess,
+		},
+	)
+
+	manager := &MemoryManager{}
+	router := httprouter.New()
+	h := Handler{Manager: manager, W: localWarden, H: herodot.NewJSONWriter(nil)}
+	h.SetRoutes(router)
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	encoded := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+
+	importKeys := func(body []byte) *http.Response {
+		res, err := client.Post(ts.URL+KeyHandlerPath+"/imported/import", "application/x-pem-file", bytes.NewBuffer(body))
+		require.NoError(t, err)
+		return res
+	}
+
+	res := importKeys(encoded)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+
+	var imported jose.JSONWebKeySet
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&imported))
+	require.Len(t, imported.Keys, 2)
+	assert.Equal(t, "ES256", imported.Keys[0].Algorithm)
+
+	keys, err := manager.GetKeySet("imported")
+	require.NoError(t, err)
+	assert.Len(t, keys.Keys, 2)
+
+	res = importKeys(encoded)
+	res.Body.Close()
+	assert.Equal(t, http.StatusConflict, res.StatusCode)
+
+	res = importKeys(der)
+	res.Body.Close()
+	assert.Equal(t, http.StatusConflict, res.StatusCode)
+
+	res = importKeys([]byte("not a key"))
+	res.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+}
+
+func TestHandlerGetKeyPEM(t *testing.T) {
+	policy := &ladon.DefaultPolicy{
+		ID:        "1",
+		Subjects:  []string{"alice"},
+		Resources: []string{"rn:hydra:keys:<.*>"},
+		Actions:   []string{"get"},
+		Effect:    ladon.AllowAccess,
+	}
+
+	ks, err := (&ECDSA256Generator{}).Generate("pem")
+	require.NoError(t, err)
+	manager := &MemoryManager{}
+	require.NoError(t, manager.AddKeySet("exported", ks))
+
+	newClient := func(scopes ...string) (*http.Client, string) {
+		localWarden, client := compose.NewMockFirewall("tests", "alice", fosite.Arguments(scopes), policy)
+		router := httprouter.New()
+		h := Handler{Manager: manager, W: localWarden, H: herodot.NewJSONWriter(nil)}
+		h.SetRoutes(router)
+		ts := httptest.NewServer(router)
+		return client, ts.URL
+	}
+
+	getPEM := func(client *http.Client, url string) (*http.Response, []string) {
+		req, err := http.NewRequest("GET", url, nil)
+		require.NoError(t, err)
+		req.Header.Set("Accept", "application/x-pem-file")
+		res, err := client.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		body, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+
+		var types []string
+		for block, rest := pem.Decode(body); block != nil; block, rest = pem.Decode(rest) {
+			types = append(types, block.Type)
+		}
+		return res, types
+	}
+
+	client, url := newClient("hydra.keys.get")
+
+	res, types := getPEM(client, url+KeyHandlerPath+"/exported/private:pem")
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "application/x-pem-file", res.Header.Get("Content-Type"))
+	assert.Equal(t, []string{"PUBLIC KEY"}, types)
+
+	res, types = getPEM(client, url+KeyHandlerPath+"/exported/private:pem?private=true")
+	assert.NotEqual(t, http.StatusOK, res.StatusCode)
+	assert.Empty(t, types)
+
+	client, url = newClient("hydra.keys.get", "hydra.keys.export")
+	res, types = getPEM(client, url+KeyHandlerPath+"/exported/private:pem?private=true")
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, []string{"EC PRIVATE KEY"}, types)
+
+	res, err = client.Get(url + KeyHandlerPath + "/exported/private:pem")
+	require.NoError(t, err)
+	defer res.Body.Close()
+	var set jose.JSONWebKeySet
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&set))
+	assert.Len(t, set.Keys, 1)
+}
@@ -68,11 +68,39 @@ func PEMBlockForKey(key interface{}) (*pem.Block, error) {
 			return nil, errors.WithStack(err)
 		}
 		return &pem.Block{Type: "EC PRIVATE KEY", Bytes: b}, nil
+	case *rsa.PublicKey, *ecdsa.PublicKey:
+		b, err := x509.MarshalPKIXPublicKey(k)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return &pem.Block{Type: "PUBLIC KEY", Bytes: b}, nil
 	default:
 		return nil, errors.New("Invalid key type")
 	}
 }
 
+// EncodePEM encodes the keys, each followed by its certificate chain, as PEM. If includePrivate is false, private keys
+// are encoded as their public key.
+func EncodePEM(keys []jose.JSONWebKey, includePrivate bool) ([]byte, error) {
+	var out []byte
+	for _, key := range keys {
+		if !includePrivate && !key.IsPublic() {
+			key = key.Public()
+		}
+
+		block, err := PEMBlockForKey(key.Key)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Key %s can not be encoded as PEM", key.KeyID)
+		}
+		out = append(out, pem.EncodeToMemory(block)...)
+
+		for _, cert := range key.Certificates {
+			out = append(out, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})...)
+		}
+	}
+	return out, nil
+}
+
 func ider(typ, id string) string {
 	if id == "" {
 		id = uuid.New()
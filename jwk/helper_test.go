@@ -1,6 +1,7 @@
 package jwk
 
 import (
+	"encoding/pem"
 	"testing"
 
 	"github.com/square/go-jose"
@@ -51,3 +52,31 @@ func TestIder(t *testing.T) {
 	assert.True(t, len(ider("public", "")) > len("public:"))
 	assert.Equal(t, "public:foo", ider("public", "foo"))
 }
+
+func TestEncodePEM(t *testing.T) {
+	ks, err := (&ECDSA256Generator{}).Generate("foo")
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		keys    []jose.JSONWebKey
+		private bool
+		types   []string
+	}{
+		{keys: ks.Keys, private: false, types: []string{"PUBLIC KEY", "PUBLIC KEY"}},
+		{keys: ks.Keys, private: true, types: []string{"EC PRIVATE KEY", "PUBLIC KEY"}},
+	} {
+		out, err := EncodePEM(tc.keys, tc.private)
+		require.NoError(t, err)
+
+		var types []string
+		for block, rest := pem.Decode(out); block != nil; block, rest = pem.Decode(rest) {
+			types = append(types, block.Type)
+		}
+		assert.Equal(t, tc.types, types)
+	}
+
+	hs, err := (&HS256Generator{}).Generate("foo")
+	require.NoError(t, err)
+	_, err = EncodePEM(hs.Keys, true)
+	assert.Error(t, err)
+}
@@ -0,0 +1,193 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwk
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+
+	"github.com/pkg/errors"
+	"github.com/square/go-jose"
+)
+
+// ImportKeys converts PEM or DER encoded RSA and ECDSA keys to a JSON Web Key Set. Supported are PKCS#1, PKCS#8 and
+// SEC 1 private keys, PKIX and PKCS#1 public keys, and X.509 certificates. A PEM document may contain several blocks.
+//
+// The key id is the base64url encoded SHA-256 thumbprint (RFC 7638) of the public key. Like the keys created by the
+// generators, private keys result in a "private:<thumbprint>" and a "public:<thumbprint>" key, while public keys and
+// certificates only result in a "public:<thumbprint>" key. Certificates are added to the x5c chain of the public key.
+func ImportKeys(raw []byte) (*jose.JSONWebKeySet, error) {
+	var ders [][]byte
+	var types []string
+	if rest := bytes.TrimSpace(raw); bytes.HasPrefix(rest, []byte("-----BEGIN")) {
+		for {
+			var block *pem.Block
+			block, rest = pem.Decode(rest)
+			if block == nil {
+				break
+			}
+			ders = append(ders, block.Bytes)
+			types = append(types, block.Type)
+		}
+		if len(ders) == 0 {
+			return nil, errors.New("Unable to decode PEM document")
+		}
+	} else {
+		ders = append(ders, raw)
+		types = append(types, "")
+	}
+
+	set := &jose.JSONWebKeySet{}
+	for k, der := range ders {
+		key, err := parseDERKey(types[k], der)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := appendImportedKey(set, key); err != nil {
+			return nil, err
+		}
+	}
+
+	return set, nil
+}
+
+// parseDERKey parses a DER encoded key or certificate. If the type of the PEM block is unknown, all supported
+// encodings are tried.
+func parseDERKey(typ string, der []byte) (interface{}, error) {
+	switch typ {
+	case "RSA PRIVATE KEY":
+		key, err := x509.ParsePKCS1PrivateKey(der)
+		return key, errors.WithStack(err)
+	case "EC PRIVATE KEY":
+		key, err := x509.ParseECPrivateKey(der)
+		return key, errors.WithStack(err)
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(der)
+		return key, errors.WithStack(err)
+	case "PUBLIC KEY":
+		key, err := x509.ParsePKIXPublicKey(der)
+		return key, errors.WithStack(err)
+	case "RSA PUBLIC KEY":
+		return parsePKCS1PublicKey(der)
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(der)
+		return cert, errors.WithStack(err)
+	case "":
+		if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+			return key, nil
+		} else if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+			return key, nil
+		} else if key, err := x509.ParseECPrivateKey(der); err == nil {
+			return key, nil
+		} else if key, err := x509.ParsePKIXPublicKey(der); err == nil {
+			return key, nil
+		} else if cert, err := x509.ParseCertificate(der); err == nil {
+			return cert, nil
+		} else if key, err := parsePKCS1PublicKey(der); err == nil {
+			return key, nil
+		}
+		return nil, errors.New("Unable to parse DER encoded key")
+	default:
+		return nil, errors.Errorf("Unsupported PEM block type %s", typ)
+	}
+}
+
+func parsePKCS1PublicKey(der []byte) (*rsa.PublicKey, error) {
+	var key rsa.PublicKey
+	if rest, err := asn1.Unmarshal(der, &key); err != nil {
+		return nil, errors.WithStack(err)
+	} else if len(rest) > 0 {
+		return nil, errors.New("Trailing data after PKCS#1 public key")
+	}
+	return &key, nil
+}
+
+func appendImportedKey(set *jose.JSONWebKeySet, key interface{}) error {
+	var private crypto.Signer
+	var certificates []*x509.Certificate
+	var public interface{}
+
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		private, public = k, k.Public()
+	case *ecdsa.PrivateKey:
+		private, public = k, k.Public()
+	case *x509.Certificate:
+		certificates, public = []*x509.Certificate{k}, k.PublicKey
+	default:
+		public = k
+	}
+
+	alg, err := importedKeyAlgorithm(public)
+	if err != nil {
+		return err
+	}
+
+	thumbprint, err := (&jose.JSONWebKey{Key: public}).Thumbprint(crypto.SHA256)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	id := base64.RawURLEncoding.EncodeToString(thumbprint)
+
+	if private != nil && len(set.Key(ider("private", id))) == 0 {
+		set.Keys = append(set.Keys, jose.JSONWebKey{
+			Algorithm: alg,
+			Key:       private,
+			KeyID:     ider("private", id),
+			Use:       "sig",
+		})
+	}
+
+	for k, existing := range set.Keys {
+		if existing.KeyID == ider("public", id) {
+			set.Keys[k].Certificates = append(set.Keys[k].Certificates, certificates...)
+			return nil
+		}
+	}
+
+	set.Keys = append(set.Keys, jose.JSONWebKey{
+		Algorithm:    alg,
+		Key:          public,
+		KeyID:        ider("public", id),
+		Use:          "sig",
+		Certificates: certificates,
+	})
+	return nil
+}
+
+func importedKeyAlgorithm(public interface{}) (string, error) {
+	switch k := public.(type) {
+	case *rsa.PublicKey:
+		return "RS256", nil
+	case *ecdsa.PublicKey:
+		switch k.Curve.Params().Name {
+		case "P-256":
+			return "ES256", nil
+		case "P-384":
+			return "ES384", nil
+		case "P-521":
+			return "ES512", nil
+		}
+		return "", errors.Errorf("Unsupported elliptic curve %s", k.Curve.Params().Name)
+	}
+	return "", errors.Errorf("Unsupported key type %T", public)
+}
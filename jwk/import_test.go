@@ -0,0 +1,105 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwk_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	. "github.com/ory/hydra/jwk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportKeys(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(rsaKey)
+	require.NoError(t, err)
+	sec1, err := x509.MarshalECPrivateKey(ecKey)
+	require.NoError(t, err)
+	spki, err := x509.MarshalPKIXPublicKey(&ecKey.PublicKey)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "hydra"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, &rsaKey.PublicKey, rsaKey)
+	require.NoError(t, err)
+
+	encode := func(blocks ...*pem.Block) []byte {
+		var out []byte
+		for _, block := range blocks {
+			out = append(out, pem.EncodeToMemory(block)...)
+		}
+		return out
+	}
+
+	for k, tc := range []struct {
+		raw  []byte
+		kids []string
+		alg  string
+		err  bool
+	}{
+		{raw: encode(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}), kids: []string{"private", "public"}, alg: "RS256"},
+		{raw: encode(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8}), kids: []string{"private", "public"}, alg: "RS256"},
+		{raw: encode(&pem.Block{Type: "EC PRIVATE KEY", Bytes: sec1}), kids: []string{"private", "public"}, alg: "ES384"},
+		{raw: encode(&pem.Block{Type: "PUBLIC KEY", Bytes: spki}), kids: []string{"public"}, alg: "ES384"},
+		{raw: encode(&pem.Block{Type: "CERTIFICATE", Bytes: cert}), kids: []string{"public"}, alg: "RS256"},
+		{raw: encode(&pem.Block{Type: "CERTIFICATE", Bytes: cert}, &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}), kids: []string{"public", "private"}, alg: "RS256"},
+		{raw: sec1, kids: []string{"private", "public"}, alg: "ES384"},
+		{raw: pkcs8, kids: []string{"private", "public"}, alg: "RS256"},
+		{raw: spki, kids: []string{"public"}, alg: "ES384"},
+		{raw: []byte("-----BEGIN FOO-----\nYmFy\n-----END FOO-----\n"), err: true},
+		{raw: []byte("-----BEGIN"), err: true},
+		{raw: []byte("foo"), err: true},
+	} {
+		keys, err := ImportKeys(tc.raw)
+		if tc.err {
+			assert.Error(t, err, "case %d", k)
+			continue
+		}
+		require.NoError(t, err, "case %d", k)
+		require.Len(t, keys.Keys, len(tc.kids), "case %d", k)
+
+		for i, prefix := range tc.kids {
+			assert.Regexp(t, "^"+prefix+":[A-Za-z0-9_-]{43}$", keys.Keys[i].KeyID, "case %d", k)
+			assert.Equal(t, tc.alg, keys.Keys[i].Algorithm, "case %d", k)
+			assert.True(t, keys.Keys[i].Valid(), "case %d", k)
+		}
+	}
+
+	// The key id only depends on the public key.
+	private, err := ImportKeys(encode(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}))
+	require.NoError(t, err)
+	public, err := ImportKeys(encode(&pem.Block{Type: "CERTIFICATE", Bytes: cert}))
+	require.NoError(t, err)
+	assert.Equal(t, private.Keys[1].KeyID, public.Keys[0].KeyID)
+	assert.Len(t, public.Keys[0].Certificates, 1)
+}
@@ -0,0 +1,63 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwk
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// KeyLifetime describes when a key becomes valid and when it expires. Zero values mean that the key is not restricted.
+type KeyLifetime struct {
+	NotBefore time.Time
+	ExpiresAt time.Time
+}
+
+// IsZero returns true if the key is not restricted at all.
+func (l KeyLifetime) IsZero() bool {
+	return l.NotBefore.IsZero() && l.ExpiresAt.IsZero()
+}
+
+// IsExpired returns true if the key expired at or before now.
+func (l KeyLifetime) IsExpired(now time.Time) bool {
+	return !l.ExpiresAt.IsZero() && !now.Before(l.ExpiresAt)
+}
+
+// encodeLifetime adds the lifetime of a key as "nbf" and "exp" parameters, in seconds since the epoch, to its encoding.
+func encodeLifetime(key json.RawMessage, lifetime KeyLifetime) (json.RawMessage, error) {
+	if lifetime.IsZero() {
+		return key, nil
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(key, &fields); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if !lifetime.NotBefore.IsZero() {
+		fields["nbf"] = lifetime.NotBefore.Unix()
+	}
+	if !lifetime.ExpiresAt.IsZero() {
+		fields["exp"] = lifetime.ExpiresAt.Unix()
+	}
+
+	out, err := json.Marshal(fields)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return out, nil
+}
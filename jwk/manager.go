@@ -14,7 +14,13 @@
 
 package jwk
 
-import "github.com/square/go-jose"
+import (
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/square/go-jose"
+)
 
 type Manager interface {
 	AddKey(set string, key *jose.JSONWebKey) error
@@ -28,4 +34,73 @@ type Manager interface {
 	DeleteKey(set, kid string) error
 
 	DeleteKeySet(set string) error
+
+	// ListKeySets returns a summary of the stored key sets, ordered by set name.
+	ListKeySets(limit, offset int) ([]KeySetSummary, error)
+
+	// SetKeyLifetime stores when a key of a set becomes valid and when it expires.
+	SetKeyLifetime(set, kid string, lifetime KeyLifetime) error
+
+	// GetKeyLifetimes returns the lifetimes of the keys of a set, indexed by key id. Keys without a lifetime are
+	// omitted.
+	GetKeyLifetimes(set string) (map[string]KeyLifetime, error)
+
+	// DeleteExpiredKeys removes all keys which expired before now and returns the number of removed keys.
+	DeleteExpiredKeys(now time.Time) (int, error)
+
+	// RotateKeySet adds the keys returned by generate to a set. Concurrent rotations of the same set are executed one
+	// after another: if the set was rotated at or after since, for example by a concurrent request, no keys are
+	// generated and the keys of that rotation are returned instead. The returned bool is true if generate was called.
+	RotateKeySet(set string, since time.Time, generate func() (*jose.JSONWebKeySet, error)) (*jose.JSONWebKeySet, bool, error)
+
+	// EnsureKeySet returns all keys of a set if find accepts them. Otherwise the keys returned by generate are added to
+	// the set and all keys of the set, including the generated ones, are returned. Concurrent calls for the same set,
+	// even from different Hydra instances, are executed one after another and after concurrent rotations, so that only
+	// one of several instances starting at the same time generates keys. The returned bool is true if generate was
+	// called.
+	EnsureKeySet(set string, find func(keys *jose.JSONWebKeySet) bool, generate func() (*jose.JSONWebKeySet, error)) (*jose.JSONWebKeySet, bool, error)
+
+	// PatchKey atomically replaces a key with the key returned by patch, which is called with the current key and may
+	// be called more than once. The key id can not be changed.
+	PatchKey(set, kid string, patch func(key *jose.JSONWebKey) (*jose.JSONWebKey, error)) (*jose.JSONWebKey, error)
+}
+
+// patchKey calls patch with a copy of key and checks that the key id was not changed.
+func patchKey(key jose.JSONWebKey, patch func(key *jose.JSONWebKey) (*jose.JSONWebKey, error)) (*jose.JSONWebKey, error) {
+	kid := key.KeyID
+	p, err := patch(&key)
+	if err != nil {
+		return nil, err
+	} else if p.KeyID != kid {
+		return nil, errors.Errorf("The key id of key %s can not be changed", kid)
+	}
+	return p, nil
+}
+
+// KeySetSummary describes a JSON Web Key Set without exposing its keys.
+//
+// swagger:model jsonWebKeySetSummary
+type KeySetSummary struct {
+	// Set is the name of the JSON Web Key Set.
+	Set string `json:"set"`
+
+	// Keys is the number of keys in the set.
+	Keys int `json:"keys"`
+
+	// Algorithms is a list of the algorithms of the keys in the set.
+	Algorithms []string `json:"algorithms"`
+}
+
+func summarizeKeySet(set string, keys *jose.JSONWebKeySet) KeySetSummary {
+	seen := map[string]bool{}
+	algorithms := []string{}
+	for _, key := range keys.Keys {
+		if key.Algorithm != "" && !seen[key.Algorithm] {
+			seen[key.Algorithm] = true
+			algorithms = append(algorithms, key.Algorithm)
+		}
+	}
+	sort.Strings(algorithms)
+
+	return KeySetSummary{Set: set, Keys: len(keys.Keys), Algorithms: algorithms}
 }
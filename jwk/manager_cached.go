@@ -0,0 +1,216 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwk
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ory/hydra/pkg"
+	"github.com/pkg/errors"
+	"github.com/square/go-jose"
+)
+
+// CachedManager is a Manager that caches JSON Web Key Sets of an underlying Manager for a given time to live.
+// Cached entries are invalidated whenever the set is modified through this manager. Changes made by other
+// instances sharing the same storage become visible once the cached entry expires.
+type CachedManager struct {
+	Manager Manager
+	TTL     time.Duration
+
+	// LookupObserved, if set, is called for every lookup of a key set with whether it was served from the cache.
+	LookupObserved func(hit bool)
+
+	sets      map[string]*cachedKeySet
+	lifetimes map[string]*cachedLifetimes
+
+	// generation is incremented on every invalidation, so that results read from Manager before an invalidation
+	// are not cached after it.
+	generation uint64
+	sync.RWMutex
+}
+
+type cachedKeySet struct {
+	keys      *jose.JSONWebKeySet
+	expiresAt time.Time
+}
+
+type cachedLifetimes struct {
+	lifetimes map[string]KeyLifetime
+	expiresAt time.Time
+}
+
+func NewCachedManager(m Manager, ttl time.Duration) *CachedManager {
+	return &CachedManager{
+		Manager:   m,
+		TTL:       ttl,
+		sets:      map[string]*cachedKeySet{},
+		lifetimes: map[string]*cachedLifetimes{},
+	}
+}
+
+func (m *CachedManager) AddKey(set string, key *jose.JSONWebKey) error {
+	defer m.invalidate(set)
+	return m.Manager.AddKey(set, key)
+}
+
+func (m *CachedManager) AddKeySet(set string, keys *jose.JSONWebKeySet) error {
+	defer m.invalidate(set)
+	return m.Manager.AddKeySet(set, keys)
+}
+
+func (m *CachedManager) GetKey(set, kid string) (*jose.JSONWebKeySet, error) {
+	keys, err := m.GetKeySet(set)
+	if err != nil {
+		return nil, err
+	}
+
+	result := keys.Key(kid)
+	if len(result) == 0 {
+		return nil, errors.Wrap(pkg.ErrNotFound, "")
+	}
+
+	return &jose.JSONWebKeySet{
+		Keys: result,
+	}, nil
+}
+
+func (m *CachedManager) GetKeySet(set string) (*jose.JSONWebKeySet, error) {
+	m.RLock()
+	cached, found := m.sets[set]
+	generation := m.generation
+	m.RUnlock()
+
+	hit := found && time.Now().Before(cached.expiresAt)
+	if m.LookupObserved != nil {
+		m.LookupObserved(hit)
+	}
+	if hit {
+		return copyKeySet(cached.keys), nil
+	}
+
+	keys, err := m.Manager.GetKeySet(set)
+	if err != nil {
+		return nil, err
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	if m.generation != generation {
+		// The set was modified while it was read, the result may be stale.
+		return copyKeySet(keys), nil
+	} else if m.sets == nil {
+		m.sets = map[string]*cachedKeySet{}
+	}
+	m.sets[set] = &cachedKeySet{keys: copyKeySet(keys), expiresAt: time.Now().Add(m.TTL)}
+
+	return copyKeySet(keys), nil
+}
+
+func (m *CachedManager) DeleteKey(set, kid string) error {
+	defer m.invalidate(set)
+	return m.Manager.DeleteKey(set, kid)
+}
+
+func (m *CachedManager) DeleteKeySet(set string) error {
+	defer m.invalidate(set)
+	return m.Manager.DeleteKeySet(set)
+}
+
+func (m *CachedManager) ListKeySets(limit, offset int) ([]KeySetSummary, error) {
+	return m.Manager.ListKeySets(limit, offset)
+}
+
+func (m *CachedManager) SetKeyLifetime(set, kid string, lifetime KeyLifetime) error {
+	defer m.invalidate(set)
+	return m.Manager.SetKeyLifetime(set, kid, lifetime)
+}
+
+func (m *CachedManager) GetKeyLifetimes(set string) (map[string]KeyLifetime, error) {
+	m.RLock()
+	cached, found := m.lifetimes[set]
+	generation := m.generation
+	m.RUnlock()
+
+	if found && time.Now().Before(cached.expiresAt) {
+		return copyLifetimes(cached.lifetimes), nil
+	}
+
+	lifetimes, err := m.Manager.GetKeyLifetimes(set)
+	if err != nil {
+		return nil, err
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	if m.generation != generation {
+		return copyLifetimes(lifetimes), nil
+	} else if m.lifetimes == nil {
+		m.lifetimes = map[string]*cachedLifetimes{}
+	}
+	m.lifetimes[set] = &cachedLifetimes{lifetimes: copyLifetimes(lifetimes), expiresAt: time.Now().Add(m.TTL)}
+
+	return copyLifetimes(lifetimes), nil
+}
+
+func (m *CachedManager) DeleteExpiredKeys(now time.Time) (int, error) {
+	deleted, err := m.Manager.DeleteExpiredKeys(now)
+	if deleted > 0 {
+		m.Lock()
+		m.sets = map[string]*cachedKeySet{}
+		m.lifetimes = map[string]*cachedLifetimes{}
+		m.generation++
+		m.Unlock()
+	}
+	return deleted, err
+}
+
+func (m *CachedManager) RotateKeySet(set string, since time.Time, generate func() (*jose.JSONWebKeySet, error)) (*jose.JSONWebKeySet, bool, error) {
+	defer m.invalidate(set)
+	return m.Manager.RotateKeySet(set, since, generate)
+}
+
+func (m *CachedManager) EnsureKeySet(set string, find func(keys *jose.JSONWebKeySet) bool, generate func() (*jose.JSONWebKeySet, error)) (*jose.JSONWebKeySet, bool, error) {
+	defer m.invalidate(set)
+	return m.Manager.EnsureKeySet(set, find, generate)
+}
+
+func (m *CachedManager) PatchKey(set, kid string, patch func(key *jose.JSONWebKey) (*jose.JSONWebKey, error)) (*jose.JSONWebKey, error) {
+	defer m.invalidate(set)
+	return m.Manager.PatchKey(set, kid, patch)
+}
+
+func (m *CachedManager) invalidate(set string) {
+	m.Lock()
+	defer m.Unlock()
+
+	delete(m.sets, set)
+	delete(m.lifetimes, set)
+	m.generation++
+}
+
+func copyKeySet(keys *jose.JSONWebKeySet) *jose.JSONWebKeySet {
+	return &jose.JSONWebKeySet{Keys: append([]jose.JSONWebKey{}, keys.Keys...)}
+}
+
+func copyLifetimes(lifetimes map[string]KeyLifetime) map[string]KeyLifetime {
+	result := make(map[string]KeyLifetime, len(lifetimes))
+	for kid, lifetime := range lifetimes {
+		result[kid] = lifetime
+	}
+	return result
+}
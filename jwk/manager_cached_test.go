@@ -0,0 +1,156 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwk_test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/ory/hydra/jwk"
+	"github.com/square/go-jose"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCachedManager(t *testing.T) {
+	ks, err := (&HS256Generator{}).Generate("TestCachedManager")
+	require.NoError(t, err)
+
+	backend := new(MemoryManager)
+	m := NewCachedManager(backend, time.Hour)
+
+	require.NoError(t, m.AddKeySet("foo", ks))
+
+	got, err := m.GetKeySet("foo")
+	require.NoError(t, err)
+	assert.Len(t, got.Keys, 1)
+
+	// Writes that bypass the cache are not visible until the entry is invalidated.
+	require.NoError(t, backend.AddKeySet("foo", ks))
+	got, err = m.GetKeySet("foo")
+	require.NoError(t, err)
+	assert.Len(t, got.Keys, 1)
+
+	require.NoError(t, m.AddKey("foo", &ks.Keys[0]))
+	got, err = m.GetKeySet("foo")
+	require.NoError(t, err)
+	assert.Len(t, got.Keys, 3)
+
+	_, err = m.GetKey("foo", ks.Keys[0].KeyID)
+	require.NoError(t, err)
+
+	require.NoError(t, m.DeleteKeySet("foo"))
+	_, err = m.GetKeySet("foo")
+	assert.Error(t, err)
+}
+
+// blockingManager blocks the first GetKeySet after reading from Manager until proceed is closed.
+type blockingManager struct {
+	Manager
+	read    chan struct{}
+	proceed chan struct{}
+}
+
+func (m *blockingManager) GetKeySet(set string) (*jose.JSONWebKeySet, error) {
+	keys, err := m.Manager.GetKeySet(set)
+	if m.read != nil {
+		close(m.read)
+		m.read = nil
+		<-m.proceed
+	}
+	return keys, err
+}
+
+func TestCachedManagerDoesNotCacheStaleReads(t *testing.T) {
+	ks, err := (&HS256Generator{}).Generate("TestCachedManagerDoesNotCacheStaleReads")
+	require.NoError(t, err)
+
+	backend := new(MemoryManager)
+	require.NoError(t, backend.AddKeySet("foo", ks))
+
+	read, proceed, done := make(chan struct{}), make(chan struct{}), make(chan struct{})
+	m := NewCachedManager(&blockingManager{Manager: backend, read: read, proceed: proceed}, time.Hour)
+
+	go func() {
+		defer close(done)
+		got, err := m.GetKeySet("foo")
+		require.NoError(t, err)
+		assert.Len(t, got.Keys, 1)
+	}()
+
+	// The key is added after the set was read but before the read result is cached.
+	<-read
+	require.NoError(t, m.AddKey("foo", &ks.Keys[0]))
+	close(proceed)
+	<-done
+
+	got, err := m.GetKeySet("foo")
+	require.NoError(t, err)
+	assert.Len(t, got.Keys, 2)
+}
+
+func TestCachedManagerExpiry(t *testing.T) {
+	ks, err := (&HS256Generator{}).Generate("TestCachedManagerExpiry")
+	require.NoError(t, err)
+
+	backend := new(MemoryManager)
+	m := NewCachedManager(backend, time.Millisecond*10)
+
+	require.NoError(t, m.AddKeySet("foo", ks))
+	_, err = m.GetKeySet("foo")
+	require.NoError(t, err)
+
+	require.NoError(t, backend.AddKeySet("foo", ks))
+	time.Sleep(time.Millisecond * 20)
+
+	got, err := m.GetKeySet("foo")
+	require.NoError(t, err)
+	assert.Len(t, got.Keys, 2)
+}
+
+func TestCachedManagerKeyLifetimes(t *testing.T) {
+	ks, err := (&HS256Generator{}).Generate("TestCachedManagerKeyLifetimes")
+	require.NoError(t, err)
+
+	backend := new(MemoryManager)
+	m := NewCachedManager(backend, time.Hour)
+	kid := ks.Keys[0].KeyID
+	now := time.Now().UTC()
+
+	require.NoError(t, m.AddKeySet("foo", ks))
+	lifetimes, err := m.GetKeyLifetimes("foo")
+	require.NoError(t, err)
+	assert.Empty(t, lifetimes)
+
+	require.NoError(t, m.SetKeyLifetime("foo", kid, KeyLifetime{ExpiresAt: now.Add(-time.Minute)}))
+	lifetimes, err = m.GetKeyLifetimes("foo")
+	require.NoError(t, err)
+	assert.Len(t, lifetimes, 1)
+
+	_, err = m.GetKeySet("foo")
+	require.NoError(t, err)
+
+	deleted, err := m.DeleteExpiredKeys(now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+
+	got, err := m.GetKeySet("foo")
+	require.NoError(t, err)
+	assert.Empty(t, got.Keys)
+
+	lifetimes, err = m.GetKeyLifetimes("foo")
+	require.NoError(t, err)
+	assert.Empty(t, lifetimes)
+}
@@ -15,7 +15,9 @@
 package jwk
 
 import (
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/ory/hydra/pkg"
 	"github.com/pkg/errors"
@@ -23,8 +25,17 @@ import (
 )
 
 type MemoryManager struct {
-	Keys map[string]*jose.JSONWebKeySet
+	Keys      map[string]*jose.JSONWebKeySet
+	Lifetimes map[string]map[string]KeyLifetime
 	sync.RWMutex
+
+	rotating  sync.Mutex
+	rotations map[string]memoryRotation
+}
+
+type memoryRotation struct {
+	kids      []string
+	rotatedAt time.Time
 }
 
 func (m *MemoryManager) AddKey(set string, key *jose.JSONWebKey) error {
@@ -40,9 +51,14 @@ func (m *MemoryManager) AddKey(set string, key *jose.JSONWebKey) error {
 }
 
 func (m *MemoryManager) AddKeySet(set string, keys *jose.JSONWebKeySet) error {
-	for _, key := range keys.Keys {
-		m.AddKey(set, &key)
+	m.Lock()
+	defer m.Unlock()
+
+	m.alloc()
+	if m.Keys[set] == nil {
+		m.Keys[set] = &jose.JSONWebKeySet{Keys: []jose.JSONWebKey{}}
 	}
+	m.Keys[set].Keys = append(m.Keys[set].Keys, keys.Keys...)
 	return nil
 }
 
@@ -80,20 +96,27 @@ func (m *MemoryManager) GetKeySet(set string) (*jose.JSONWebKeySet, error) {
 }
 
 func (m *MemoryManager) DeleteKey(set, kid string) error {
-	keys, err := m.GetKeySet(set)
-	if err != nil {
-		return err
+	m.Lock()
+	defer m.Unlock()
+
+	m.alloc()
+	return m.deleteKey(set, kid)
+}
+
+func (m *MemoryManager) deleteKey(set, kid string) error {
+	keys, found := m.Keys[set]
+	if !found {
+		return errors.Wrap(pkg.ErrNotFound, "")
 	}
 
-	m.Lock()
 	var results []jose.JSONWebKey
 	for _, key := range keys.Keys {
 		if key.KeyID != kid {
-			results = append(results)
+			results = append(results, key)
 		}
 	}
 	m.Keys[set].Keys = results
-	defer m.Unlock()
+	delete(m.Lifetimes[set], kid)
 
 	return nil
 }
@@ -103,11 +126,192 @@ func (m *MemoryManager) DeleteKeySet(set string) error {
 	defer m.Unlock()
 
 	delete(m.Keys, set)
+	delete(m.Lifetimes, set)
+	delete(m.rotations, set)
 	return nil
 }
 
+func (m *MemoryManager) SetKeyLifetime(set, kid string, lifetime KeyLifetime) error {
+	m.Lock()
+	defer m.Unlock()
+
+	m.alloc()
+	keys, found := m.Keys[set]
+	if !found || len(keys.Key(kid)) == 0 {
+		return errors.Wrap(pkg.ErrNotFound, "")
+	}
+
+	if m.Lifetimes[set] == nil {
+		m.Lifetimes[set] = map[string]KeyLifetime{}
+	}
+	m.Lifetimes[set][kid] = lifetime
+	return nil
+}
+
+func (m *MemoryManager) GetKeyLifetimes(set string) (map[string]KeyLifetime, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	lifetimes := map[string]KeyLifetime{}
+	for kid, lifetime := range m.Lifetimes[set] {
+		if !lifetime.IsZero() {
+			lifetimes[kid] = lifetime
+		}
+	}
+	return lifetimes, nil
+}
+
+func (m *MemoryManager) DeleteExpiredKeys(now time.Time) (int, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	m.alloc()
+	var deleted int
+	for set, lifetimes := range m.Lifetimes {
+		for kid, lifetime := range lifetimes {
+			if !lifetime.IsExpired(now) {
+				continue
+			}
+			if err := m.deleteKey(set, kid); err != nil {
+				return deleted, err
+			}
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (m *MemoryManager) ListKeySets(limit, offset int) ([]KeySetSummary, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	var sets []string
+	for set := range m.Keys {
+		sets = append(sets, set)
+	}
+	sort.Strings(sets)
+
+	summaries := []KeySetSummary{}
+	if offset >= len(sets) {
+		return summaries, nil
+	}
+
+	sets = sets[offset:]
+	if limit < len(sets) {
+		sets = sets[:limit]
+	}
+
+	for _, set := range sets {
+		summaries = append(summaries, summarizeKeySet(set, m.Keys[set]))
+	}
+	return summaries, nil
+}
+
+func (m *MemoryManager) PatchKey(set, kid string, patch func(key *jose.JSONWebKey) (*jose.JSONWebKey, error)) (*jose.JSONWebKey, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	m.alloc()
+	if m.Keys[set] == nil {
+		return nil, errors.Wrap(pkg.ErrNotFound, "")
+	}
+
+	for k, key := range m.Keys[set].Keys {
+		if key.KeyID != kid {
+			continue
+		}
+
+		p, err := patchKey(key, patch)
+		if err != nil {
+			return nil, err
+		}
+
+		m.Keys[set].Keys[k] = *p
+		return p, nil
+	}
+
+	return nil, errors.Wrap(pkg.ErrNotFound, "")
+}
+
+func (m *MemoryManager) RotateKeySet(set string, since time.Time, generate func() (*jose.JSONWebKeySet, error)) (*jose.JSONWebKeySet, bool, error) {
+	m.rotating.Lock()
+	defer m.rotating.Unlock()
+
+	m.RLock()
+	m.alloc()
+	last, found := m.rotations[set]
+	var keys []jose.JSONWebKey
+	if found && !last.rotatedAt.Before(since) && m.Keys[set] != nil {
+		for _, kid := range last.kids {
+			keys = append(keys, m.Keys[set].Key(kid)...)
+		}
+	}
+	m.RUnlock()
+
+	if len(keys) > 0 {
+		return &jose.JSONWebKeySet{Keys: keys}, false, nil
+	}
+
+	generated, err := generate()
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err := m.AddKeySet(set, generated); err != nil {
+		return nil, false, err
+	}
+
+	rotation := memoryRotation{rotatedAt: time.Now().UTC()}
+	for _, key := range generated.Keys {
+		rotation.kids = append(rotation.kids, key.KeyID)
+	}
+
+	m.Lock()
+	m.rotations[set] = rotation
+	m.Unlock()
+
+	return generated, true, nil
+}
+
+func (m *MemoryManager) EnsureKeySet(set string, find func(keys *jose.JSONWebKeySet) bool, generate func() (*jose.JSONWebKeySet, error)) (*jose.JSONWebKeySet, bool, error) {
+	m.rotating.Lock()
+	defer m.rotating.Unlock()
+
+	keys, err := m.GetKeySet(set)
+	if errors.Cause(err) == pkg.ErrNotFound {
+		keys = &jose.JSONWebKeySet{Keys: []jose.JSONWebKey{}}
+	} else if err != nil {
+		return nil, false, err
+	}
+
+	if find(keys) {
+		return keys, false, nil
+	}
+
+	generated, err := generate()
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err := m.AddKeySet(set, generated); err != nil {
+		return nil, false, err
+	}
+
+	keys, err = m.GetKeySet(set)
+	if err != nil {
+		return nil, false, err
+	}
+	return keys, true, nil
+}
+
 func (m *MemoryManager) alloc() {
 	if m.Keys == nil {
 		m.Keys = make(map[string]*jose.JSONWebKeySet)
 	}
+	if m.Lifetimes == nil {
+		m.Lifetimes = make(map[string]map[string]KeyLifetime)
+	}
+	if m.rotations == nil {
+		m.rotations = make(map[string]memoryRotation)
+	}
 }
@@ -0,0 +1,508 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwk
+
+import (
+	"encoding/json"
+	"net/url"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/ory/hydra/pkg"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+	"github.com/square/go-jose"
+)
+
+// RedisManager stores JSON Web Keys in Redis using the same envelope encryption as SQLManager. Every key is stored
+// under its own Redis key, so keys with an expiry are removed by Redis when they expire, even if DeleteExpiredKeys
+// is never called.
+type RedisManager struct {
+	DB     *redis.Client
+	Cipher Cipher
+}
+
+// redisJWKSets is a sorted set containing the names of all key sets. All members have the same score, so they are
+// ordered by name.
+const redisJWKSets = "hydra:jwk:sets"
+
+// redisJWKSetKey is a sorted set containing the key ids of a key set.
+func redisJWKSetKey(set string) string {
+	return "hydra:jwk:set:" + url.QueryEscape(set)
+}
+
+// redisJWKLifetimesKey is a hash containing the JSON encoded lifetimes of the keys of a key set, indexed by key id.
+func redisJWKLifetimesKey(set string) string {
+	return "hydra:jwk:lifetimes:" + url.QueryEscape(set)
+}
+
+// redisJWKKey holds the encrypted key.
+func redisJWKKey(set, kid string) string {
+	return "hydra:jwk:key:" + url.QueryEscape(set) + ":" + url.QueryEscape(kid)
+}
+
+// redisJWKRotationKey holds the JSON encoded redisRotation of the last rotation of a key set.
+func redisJWKRotationKey(set string) string {
+	return "hydra:jwk:rotation:" + url.QueryEscape(set)
+}
+
+// redisJWKRotationLockKey is held while a key set is rotated.
+func redisJWKRotationLockKey(set string) string {
+	return "hydra:jwk:rotation-lock:" + url.QueryEscape(set)
+}
+
+// redisRotationLockTTL is the time after which a rotation lock is released, even if the instance holding it crashed.
+// Rotations waiting for the lock give up after the same time.
+const redisRotationLockTTL = time.Second * 30
+
+// redisPatchMaxAttempts is how often a patch is retried if the key was changed while it was applied.
+const redisPatchMaxAttempts = 5
+
+// redisUnlock deletes a lock only if it is still held by the given token, so that a lock that expired and was
+// acquired by another rotation is not released.
+var redisUnlock = redis.NewScript(`if redis.call("get", KEYS[1]) == ARGV[1] then return redis.call("del", KEYS[1]) else return 0 end`)
+
+type redisRotation struct {
+	KIDs      []string  `json:"kids"`
+	RotatedAt time.Time `json:"rotated_at"`
+}
+
+func (m *RedisManager) AddKey(set string, key *jose.JSONWebKey) error {
+	return m.AddKeySet(set, &jose.JSONWebKeySet{Keys: []jose.JSONWebKey{*key}})
+}
+
+// AddKeySet adds all keys in a single transaction. If any key can not be added, for example because its key id is
+// already taken, none of the keys are added.
+func (m *RedisManager) AddKeySet(set string, keys *jose.JSONWebKeySet) error {
+	if len(keys.Keys) == 0 {
+		return nil
+	}
+
+	var names []string
+	encrypted := make([]string, len(keys.Keys))
+	for k, key := range keys.Keys {
+		out, err := json.Marshal(key)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		if encrypted[k], err = sealEnvelope(m.Cipher, out); err != nil {
+			return err
+		}
+		names = append(names, redisJWKKey(set, key.KeyID))
+	}
+
+	return m.DB.Watch(func(tx *redis.Tx) error {
+		if n, err := tx.Exists(names...).Result(); err != nil {
+			return errors.WithStack(err)
+		} else if n > 0 {
+			return errors.Errorf("A key with the same key id already exists in set %s", set)
+		}
+
+		if _, err := tx.Pipelined(func(pipe redis.Pipeliner) error {
+			pipe.ZAdd(redisJWKSets, redis.Z{Member: set})
+			for k, key := range keys.Keys {
+				pipe.Set(names[k], encrypted[k], 0)
+				pipe.ZAdd(redisJWKSetKey(set), redis.Z{Member: key.KeyID})
+				pipe.HDel(redisJWKLifetimesKey(set), key.KeyID)
+			}
+			return nil
+		}); err != nil {
+			return errors.WithStack(err)
+		}
+		return nil
+	}, names...)
+}
+
+func (m *RedisManager) GetKey(set, kid string) (*jose.JSONWebKeySet, error) {
+	encrypted, err := m.DB.Get(redisJWKKey(set, kid)).Result()
+	if err == redis.Nil {
+		return nil, errors.Wrap(pkg.ErrNotFound, "")
+	} else if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	key, err := m.decrypt(encrypted)
+	if err != nil {
+		return nil, err
+	}
+
+	return &jose.JSONWebKeySet{
+		Keys: []jose.JSONWebKey{*key},
+	}, nil
+}
+
+func (m *RedisManager) GetKeySet(set string) (*jose.JSONWebKeySet, error) {
+	kids, err := m.DB.ZRange(redisJWKSetKey(set), 0, -1).Result()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	} else if len(kids) == 0 {
+		return nil, errors.Wrap(pkg.ErrNotFound, "")
+	}
+
+	names := make([]string, len(kids))
+	for k, kid := range kids {
+		names[k] = redisJWKKey(set, kid)
+	}
+
+	values, err := m.DB.MGet(names...).Result()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	keys := &jose.JSONWebKeySet{Keys: []jose.JSONWebKey{}}
+	for _, value := range values {
+		encrypted, ok := value.(string)
+		if !ok {
+			// The key expired but was not yet removed from the set by DeleteExpiredKeys.
+			continue
+		}
+
+		key, err := m.decrypt(encrypted)
+		if err != nil {
+			return nil, err
+		}
+		keys.Keys = append(keys.Keys, *key)
+	}
+
+	if len(keys.Keys) == 0 {
+		return nil, errors.Wrap(pkg.ErrNotFound, "")
+	}
+
+	return keys, nil
+}
+
+func (m *RedisManager) ListKeySets(limit, offset int) ([]KeySetSummary, error) {
+	summaries := []KeySetSummary{}
+	if limit <= 0 {
+		return summaries, nil
+	}
+
+	sets, err := m.DB.ZRange(redisJWKSets, int64(offset), int64(offset+limit-1)).Result()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	for _, set := range sets {
+		keys, err := m.GetKeySet(set)
+		if errors.Cause(err) == pkg.ErrNotFound {
+			// All keys of the set expired or the set was deleted in the meantime.
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, summarizeKeySet(set, keys))
+	}
+	return summaries, nil
+}
+
+// PatchKey watches the key while the patch is applied. If the key changes in the meantime, the patch is applied again
+// to the changed key.
+func (m *RedisManager) PatchKey(set, kid string, patch func(key *jose.JSONWebKey) (*jose.JSONWebKey, error)) (*jose.JSONWebKey, error) {
+	name := redisJWKKey(set, kid)
+
+	var result *jose.JSONWebKey
+	var err error
+	for attempt := 1; attempt <= redisPatchMaxAttempts; attempt++ {
+		err = m.DB.Watch(func(tx *redis.Tx) error {
+			encrypted, err := tx.Get(name).Result()
+			if err == redis.Nil {
+				return errors.Wrap(pkg.ErrNotFound, "")
+			} else if err != nil {
+				return errors.WithStack(err)
+			}
+
+			key, err := m.decrypt(encrypted)
+			if err != nil {
+				return err
+			}
+
+			p, err := patchKey(*key, patch)
+			if err != nil {
+				return err
+			}
+
+			out, err := json.Marshal(p)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+
+			if encrypted, err = sealEnvelope(m.Cipher, out); err != nil {
+				return err
+			}
+
+			if _, err := tx.Pipelined(func(pipe redis.Pipeliner) error {
+				pipe.Set(name, encrypted, 0)
+				return nil
+			}); err != nil {
+				return err
+			}
+			result = p
+			return nil
+		}, name)
+		if err != redis.TxFailedErr {
+			break
+		}
+	}
+
+	if err == redis.TxFailedErr {
+		return nil, errors.Wrap(pkg.ErrConflict, "The key was changed concurrently")
+	} else if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return result, nil
+}
+
+func (m *RedisManager) DeleteKey(set, kid string) error {
+	if _, err := m.DB.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.Del(redisJWKKey(set, kid))
+		pipe.ZRem(redisJWKSetKey(set), kid)
+		pipe.HDel(redisJWKLifetimesKey(set), kid)
+		return nil
+	}); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if n, err := m.DB.ZCard(redisJWKSetKey(set)).Result(); err != nil {
+		return errors.WithStack(err)
+	} else if n == 0 {
+		if err := m.DB.ZRem(redisJWKSets, set).Err(); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+func (m *RedisManager) DeleteKeySet(set string) error {
+	kids, err := m.DB.ZRange(redisJWKSetKey(set), 0, -1).Result()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if _, err := m.DB.TxPipelined(func(pipe redis.Pipeliner) error {
+		for _, kid := range kids {
+			pipe.Del(redisJWKKey(set, kid))
+		}
+		pipe.Del(redisJWKSetKey(set), redisJWKLifetimesKey(set), redisJWKRotationKey(set))
+		pipe.ZRem(redisJWKSets, set)
+		return nil
+	}); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// SetKeyLifetime stores the lifetime of a key and sets the TTL of the key to its expiry.
+func (m *RedisManager) SetKeyLifetime(set, kid string, lifetime KeyLifetime) error {
+	if n, err := m.DB.Exists(redisJWKKey(set, kid)).Result(); err != nil {
+		return errors.WithStack(err)
+	} else if n == 0 {
+		return errors.Wrap(pkg.ErrNotFound, "")
+	}
+
+	out, err := json.Marshal(lifetime)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if _, err := m.DB.TxPipelined(func(pipe redis.Pipeliner) error {
+		if lifetime.IsZero() {
+			pipe.HDel(redisJWKLifetimesKey(set), kid)
+		} else {
+			pipe.HSet(redisJWKLifetimesKey(set), kid, string(out))
+		}
+
+		if lifetime.ExpiresAt.IsZero() {
+			pipe.Persist(redisJWKKey(set, kid))
+		} else {
+			pipe.PExpireAt(redisJWKKey(set, kid), lifetime.ExpiresAt)
+		}
+		return nil
+	}); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func (m *RedisManager) GetKeyLifetimes(set string) (map[string]KeyLifetime, error) {
+	values, err := m.DB.HGetAll(redisJWKLifetimesKey(set)).Result()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	lifetimes := map[string]KeyLifetime{}
+	for kid, value := range values {
+		var lifetime KeyLifetime
+		if err := json.Unmarshal([]byte(value), &lifetime); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		lifetimes[kid] = lifetime
+	}
+	return lifetimes, nil
+}
+
+// DeleteExpiredKeys removes keys which expired before now from their sets. Redis already removed the keys themselves
+// when their TTL elapsed.
+func (m *RedisManager) DeleteExpiredKeys(now time.Time) (int, error) {
+	sets, err := m.DB.ZRange(redisJWKSets, 0, -1).Result()
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	var deleted int
+	for _, set := range sets {
+		lifetimes, err := m.GetKeyLifetimes(set)
+		if err != nil {
+			return deleted, err
+		}
+
+		for kid, lifetime := range lifetimes {
+			if !lifetime.IsExpired(now) {
+				continue
+			}
+			if err := m.DeleteKey(set, kid); err != nil {
+				return deleted, err
+			}
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// RotateKeySet serializes concurrent rotations of a set, even from different Hydra instances, with a lock key that
+// expires after redisRotationLockTTL. See Manager.RotateKeySet.
+func (m *RedisManager) RotateKeySet(set string, since time.Time, generate func() (*jose.JSONWebKeySet, error)) (*jose.JSONWebKeySet, bool, error) {
+	unlock, err := m.lockRotation(set)
+	if err != nil {
+		return nil, false, err
+	}
+	defer unlock()
+
+	out, err := m.DB.Get(redisJWKRotationKey(set)).Result()
+	if err != nil && err != redis.Nil {
+		return nil, false, errors.WithStack(err)
+	} else if err == nil {
+		var last redisRotation
+		if err := json.Unmarshal([]byte(out), &last); err != nil {
+			return nil, false, errors.WithStack(err)
+		}
+
+		if !last.RotatedAt.Before(since) {
+			keys := &jose.JSONWebKeySet{Keys: []jose.JSONWebKey{}}
+			for _, kid := range last.KIDs {
+				key, err := m.GetKey(set, kid)
+				if errors.Cause(err) == pkg.ErrNotFound {
+					continue
+				} else if err != nil {
+					return nil, false, err
+				}
+				keys.Keys = append(keys.Keys, key.Keys...)
+			}
+
+			if len(keys.Keys) > 0 {
+				return keys, false, nil
+			}
+			// The keys of the last rotation were deleted in the meantime, so rotate again.
+		}
+	}
+
+	keys, err := generate()
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err := m.AddKeySet(set, keys); err != nil {
+		return nil, false, err
+	}
+
+	rotation := redisRotation{RotatedAt: time.Now().UTC()}
+	for _, key := range keys.Keys {
+		rotation.KIDs = append(rotation.KIDs, key.KeyID)
+	}
+
+	if out, err := json.Marshal(rotation); err != nil {
+		return nil, false, errors.WithStack(err)
+	} else if err := m.DB.Set(redisJWKRotationKey(set), string(out), 0).Err(); err != nil {
+		return nil, false, errors.WithStack(err)
+	}
+
+	return keys, true, nil
+}
+
+// EnsureKeySet holds the same lock as RotateKeySet. See Manager.EnsureKeySet.
+func (m *RedisManager) EnsureKeySet(set string, find func(keys *jose.JSONWebKeySet) bool, generate func() (*jose.JSONWebKeySet, error)) (*jose.JSONWebKeySet, bool, error) {
+	unlock, err := m.lockRotation(set)
+	if err != nil {
+		return nil, false, err
+	}
+	defer unlock()
+
+	keys, err := m.GetKeySet(set)
+	if errors.Cause(err) == pkg.ErrNotFound {
+		keys = &jose.JSONWebKeySet{Keys: []jose.JSONWebKey{}}
+	} else if err != nil {
+		return nil, false, err
+	}
+
+	if find(keys) {
+		return keys, false, nil
+	}
+
+	generated, err := generate()
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err := m.AddKeySet(set, generated); err != nil {
+		return nil, false, err
+	}
+
+	keys.Keys = append(keys.Keys, generated.Keys...)
+	return keys, true, nil
+}
+
+// lockRotation acquires the lock serializing changes to the keys of a set, waiting at most redisRotationLockTTL for
+// a concurrent holder to release it. The returned function releases the lock.
+func (m *RedisManager) lockRotation(set string) (func(), error) {
+	token := uuid.New()
+	deadline := time.Now().Add(redisRotationLockTTL)
+	for {
+		if ok, err := m.DB.SetNX(redisJWKRotationLockKey(set), token, redisRotationLockTTL).Result(); err != nil {
+			return nil, errors.WithStack(err)
+		} else if ok {
+			break
+		} else if time.Now().After(deadline) {
+			return nil, errors.Errorf("Timed out waiting for a concurrent rotation of key set %s", set)
+		}
+		time.Sleep(time.Millisecond * 50)
+	}
+
+	return func() {
+		redisUnlock.Run(m.DB, []string{redisJWKRotationLockKey(set)}, token)
+	}, nil
+}
+
+func (m *RedisManager) decrypt(encrypted string) (*jose.JSONWebKey, error) {
+	out, err := openEnvelope(m.Cipher, encrypted)
+	if err != nil {
+		return nil, err
+	}
+
+	var key jose.JSONWebKey
+	if err := json.Unmarshal(out, &key); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &key, nil
+}
@@ -17,17 +17,27 @@ package jwk
 import (
 	"database/sql"
 	"encoding/json"
+	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/ory/hydra/events"
 	"github.com/ory/hydra/pkg"
 	"github.com/pkg/errors"
 	"github.com/rubenv/sql-migrate"
 	"github.com/square/go-jose"
 )
 
+// SQLManager stores JSON Web Keys using envelope encryption: every key is encrypted with a random data key, which
+// itself is encrypted with Cipher. Rotating Cipher therefore only requires re-encrypting the data keys, see RotateCipher.
 type SQLManager struct {
 	DB     *sqlx.DB
-	Cipher *AEAD
+	Cipher Cipher
+
+	// Outbox, if set, stores an event for every change to a key in the same transaction as the change.
+	Outbox events.Outbox
+
+	statements pkg.SQLStatements
 }
 
 var migrations = &migrate.MemoryMigrationSource{
@@ -47,14 +57,61 @@ var migrations = &migrate.MemoryMigrationSource{
 				"DROP TABLE hydra_jwk",
 			},
 		},
+		{
+			Id: "2",
+			Up: []string{
+				`ALTER TABLE hydra_jwk ADD not_before timestamp NULL`,
+				`ALTER TABLE hydra_jwk ADD expires_at timestamp NULL`,
+			},
+			Down: []string{
+				`ALTER TABLE hydra_jwk DROP COLUMN not_before`,
+				`ALTER TABLE hydra_jwk DROP COLUMN expires_at`,
+			},
+		},
+		{
+			Id: "3",
+			Up: []string{
+				`CREATE TABLE IF NOT EXISTS hydra_jwk_rotation (
+	sid        varchar(255) NOT NULL PRIMARY KEY,
+	kids       text NOT NULL,
+	rotated_at timestamp NOT NULL
+)`,
+			},
+			Down: []string{
+				"DROP TABLE hydra_jwk_rotation",
+			},
+		},
 	},
 }
 
+const (
+	// sqlKeyVersionPlain marks keys that are encrypted directly with the system secret.
+	sqlKeyVersionPlain = 0
+
+	// sqlKeyVersionEnvelope marks keys that are encrypted with a data key, which is encrypted with the system secret.
+	sqlKeyVersionEnvelope = 1
+)
+
 type sqlData struct {
-	Set     string `db:"sid"`
-	KID     string `db:"kid"`
-	Version int    `db:"version"`
-	Key     string `db:"keydata"`
+	Set       string     `db:"sid"`
+	KID       string     `db:"kid"`
+	Version   int        `db:"version"`
+	Key       string     `db:"keydata"`
+	NotBefore *time.Time `db:"not_before"`
+	ExpiresAt *time.Time `db:"expires_at"`
+}
+
+// sqlRotation records the keys created by the last rotation of a key set. Its row is also used as a lock that
+// serializes concurrent rotations of the set and concurrent calls of EnsureKeySet.
+type sqlRotation struct {
+	Set       string    `db:"sid"`
+	KIDs      string    `db:"kids"`
+	RotatedAt time.Time `db:"rotated_at"`
+}
+
+// Migrations returns the SQL migrations embedded in the binary.
+func (s *SQLManager) Migrations() *migrate.MemoryMigrationSource {
+	return migrations
 }
 
 func (s *SQLManager) CreateSchemas() (int, error) {
@@ -67,81 +124,257 @@ func (s *SQLManager) CreateSchemas() (int, error) {
 }
 
 func (m *SQLManager) AddKey(set string, key *jose.JSONWebKey) error {
-	out, err := json.Marshal(key)
-	if err != nil {
-		return errors.WithStack(err)
+	return m.AddKeySet(set, &jose.JSONWebKeySet{Keys: []jose.JSONWebKey{*key}})
+}
+
+// AddKeySet adds all keys in a single transaction. If any key can not be added, for example because its key id is
+// already taken, none of the keys are added. If the set already contained keys, a KeyRotated event is stored in
+// addition to the KeyCreated events.
+func (m *SQLManager) AddKeySet(set string, keys *jose.JSONWebKeySet) error {
+	return pkg.SQLTransaction(m.DB, func(tx *sqlx.Tx) error {
+		return m.addKeySet(tx, set, keys)
+	})
+}
+
+func (m *SQLManager) addKeySet(tx *sqlx.Tx, set string, keys *jose.JSONWebKeySet) error {
+	var existing int
+	if m.Outbox != nil {
+		if err := tx.Get(&existing, tx.Rebind("SELECT COUNT(*) FROM hydra_jwk WHERE sid=?"), set); err != nil {
+			return errors.WithStack(err)
+		}
 	}
 
-	encrypted, err := m.Cipher.Encrypt(out)
-	if err != nil {
-		return errors.WithStack(err)
+	kids := make([]string, len(keys.Keys))
+	rows := make([][]interface{}, len(keys.Keys))
+	for k, key := range keys.Keys {
+		out, err := json.Marshal(key)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		encrypted, err := m.encrypt(m.Cipher, out)
+		if err != nil {
+			return err
+		}
+
+		kids[k] = key.KeyID
+		rows[k] = []interface{}{set, key.KeyID, sqlKeyVersionEnvelope, encrypted}
 	}
 
-	if _, err = m.DB.NamedExec(`INSERT INTO hydra_jwk (sid, kid, version, keydata) VALUES (:sid, :kid, :version, :keydata)`, &sqlData{
-		Set:     set,
-		KID:     key.KeyID,
-		Version: 0,
-		Key:     encrypted,
-	}); err != nil {
-		return errors.WithStack(err)
+	// All keys are inserted with a single statement instead of one statement per key.
+	if err := pkg.SQLBatchInsert(tx, "hydra_jwk", []string{"sid", "kid", "version", "keydata"}, rows); err != nil {
+		return err
 	}
-	return nil
+
+	if m.Outbox == nil {
+		return nil
+	}
+
+	for _, kid := range kids {
+		e, err := events.NewEvent(events.KeyCreated, map[string]string{"set": set, "kid": kid})
+		if err != nil {
+			return err
+		}
+		if err := m.Outbox.Enqueue(tx, e); err != nil {
+			return err
+		}
+	}
+
+	if existing == 0 || len(kids) == 0 {
+		return nil
+	}
+
+	e, err := events.NewEvent(events.KeyRotated, map[string]interface{}{"set": set, "kids": kids})
+	if err != nil {
+		return err
+	}
+	return m.Outbox.Enqueue(tx, e)
 }
 
-func (m *SQLManager) AddKeySet(set string, keys *jose.JSONWebKeySet) error {
-	tx, err := m.DB.Beginx()
+// RotateKeySet locks the set's row in hydra_jwk_rotation for the duration of the transaction, so concurrent
+// rotations of the same set, even from different Hydra instances, are executed one after another. See
+// Manager.RotateKeySet.
+func (m *SQLManager) RotateKeySet(set string, since time.Time, generate func() (*jose.JSONWebKeySet, error)) (*jose.JSONWebKeySet, bool, error) {
+	insert, lock, err := m.rotationLockStatements()
 	if err != nil {
-		return errors.WithStack(err)
+		return nil, false, err
 	}
 
-	for _, key := range keys.Keys {
-		out, err := json.Marshal(key)
+	var result *jose.JSONWebKeySet
+	var rotated bool
+	err = pkg.SQLTransaction(m.DB, func(tx *sqlx.Tx) error {
+		result, rotated = nil, false
+
+		last, err := lockRotation(tx, insert, lock, set)
 		if err != nil {
-			if re := tx.Rollback(); re != nil {
-				return errors.Wrap(err, re.Error())
+			return err
+		}
+
+		// MySQL stores timestamps with a precision of one second.
+		if last.KIDs != "" && !last.RotatedAt.Before(since.Truncate(time.Second)) {
+			keys, err := m.getKeys(tx, set, strings.Split(last.KIDs, ","))
+			if err != nil {
+				return err
+			} else if len(keys.Keys) > 0 {
+				result = keys
+				return nil
 			}
-			return errors.WithStack(err)
+			// The keys of the last rotation were deleted in the meantime, so rotate again.
 		}
 
-		encrypted, err := m.Cipher.Encrypt(out)
+		keys, err := generate()
 		if err != nil {
-			if re := tx.Rollback(); re != nil {
-				return errors.Wrap(err, re.Error())
-			}
-			return errors.WithStack(err)
+			return err
 		}
 
-		if _, err = tx.NamedExec(`INSERT INTO hydra_jwk (sid, kid, version, keydata) VALUES (:sid, :kid, :version, :keydata)`, &sqlData{
-			Set:     set,
-			KID:     key.KeyID,
-			Version: 0,
-			Key:     encrypted,
-		}); err != nil {
-			if re := tx.Rollback(); re != nil {
-				return errors.Wrap(err, re.Error())
-			}
+		if err := m.addKeySet(tx, set, keys); err != nil {
+			return err
+		}
+
+		kids := make([]string, len(keys.Keys))
+		for k, key := range keys.Keys {
+			kids[k] = key.KeyID
+		}
+		if _, err := tx.Exec(
+			tx.Rebind("UPDATE hydra_jwk_rotation SET kids=?, rotated_at=? WHERE sid=?"),
+			strings.Join(kids, ","), time.Now().UTC(), set,
+		); err != nil {
 			return errors.WithStack(err)
 		}
+
+		result, rotated = keys, true
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return result, rotated, nil
+}
+
+// EnsureKeySet locks the set's row in hydra_jwk_rotation like RotateKeySet, so Hydra instances starting at the same
+// time do not all add keys to an empty set. See Manager.EnsureKeySet.
+func (m *SQLManager) EnsureKeySet(set string, find func(keys *jose.JSONWebKeySet) bool, generate func() (*jose.JSONWebKeySet, error)) (*jose.JSONWebKeySet, bool, error) {
+	insert, lock, err := m.rotationLockStatements()
+	if err != nil {
+		return nil, false, err
 	}
 
-	if err := tx.Commit(); err != nil {
-		if re := tx.Rollback(); re != nil {
-			return errors.Wrap(err, re.Error())
+	var result *jose.JSONWebKeySet
+	var generated bool
+	err = pkg.SQLTransaction(m.DB, func(tx *sqlx.Tx) error {
+		result, generated = nil, false
+
+		if _, err := lockRotation(tx, insert, lock, set); err != nil {
+			return err
 		}
-		return errors.WithStack(err)
+
+		keys, err := m.getKeys(tx, set, nil)
+		if err != nil {
+			return err
+		} else if find(keys) {
+			result = keys
+			return nil
+		}
+
+		fresh, err := generate()
+		if err != nil {
+			return err
+		}
+
+		if err := m.addKeySet(tx, set, fresh); err != nil {
+			return err
+		}
+
+		keys.Keys = append(keys.Keys, fresh.Keys...)
+		result, generated = keys, true
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
 	}
-	return nil
+	return result, generated, nil
+}
+
+// rotationLockStatements returns the statements creating and locking the row of a set in hydra_jwk_rotation.
+func (m *SQLManager) rotationLockStatements() (insert string, lock string, err error) {
+	dialect, err := pkg.SQLDialect(m.DB)
+	if err != nil {
+		return "", "", err
+	}
+
+	insert = "INSERT INTO hydra_jwk_rotation (sid, kids, rotated_at) VALUES (?, '', ?) ON CONFLICT DO NOTHING"
+	lock = "SELECT sid, kids, rotated_at FROM hydra_jwk_rotation WHERE sid=? FOR UPDATE"
+	switch dialect {
+	case pkg.SQLDialectMySQL:
+		insert = "INSERT IGNORE INTO hydra_jwk_rotation (sid, kids, rotated_at) VALUES (?, '', ?)"
+	case pkg.SQLDialectCockroach:
+		// CockroachDB does not support row locks. Its transactions are serializable instead, so one of two
+		// concurrent rotations fails with a retryable error and sees the other rotation when it is retried.
+		lock = "SELECT sid, kids, rotated_at FROM hydra_jwk_rotation WHERE sid=?"
+	}
+	return insert, lock, nil
+}
+
+// lockRotation creates the set's row in hydra_jwk_rotation if it does not exist yet and locks it until tx ends.
+func lockRotation(tx *sqlx.Tx, insert, lock, set string) (*sqlRotation, error) {
+	if _, err := tx.Exec(tx.Rebind(insert), set, time.Now().UTC()); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var last sqlRotation
+	if err := tx.Get(&last, tx.Rebind(lock), set); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &last, nil
+}
+
+// getKeys returns the keys of a set with one of the given key ids, skipping key ids that do not exist. All keys of the
+// set are returned if kids is nil.
+func (m *SQLManager) getKeys(tx *sqlx.Tx, set string, kids []string) (*jose.JSONWebKeySet, error) {
+	wanted := map[string]bool{}
+	for _, kid := range kids {
+		wanted[kid] = true
+	}
+
+	var ds []sqlData
+	if err := tx.Select(&ds, tx.Rebind("SELECT * FROM hydra_jwk WHERE sid=?"), set); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	keys := &jose.JSONWebKeySet{Keys: []jose.JSONWebKey{}}
+	for _, d := range ds {
+		if kids != nil && !wanted[d.KID] {
+			continue
+		}
+
+		key, err := m.decrypt(m.Cipher, &d)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		var c jose.JSONWebKey
+		if err := json.Unmarshal(key, &c); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		keys.Keys = append(keys.Keys, c)
+	}
+	return keys, nil
 }
 
 func (m *SQLManager) GetKey(set, kid string) (*jose.JSONWebKeySet, error) {
+	stmt, err := m.statements.Preparex(m.DB, "SELECT * FROM hydra_jwk WHERE sid=? AND kid=?")
+	if err != nil {
+		return nil, err
+	}
+
 	var d sqlData
-	if err := m.DB.Get(&d, m.DB.Rebind("SELECT * FROM hydra_jwk WHERE sid=? AND kid=?"), set, kid); err == sql.ErrNoRows {
+	if err := stmt.Get(&d, set, kid); err == sql.ErrNoRows {
 		return nil, errors.Wrap(pkg.ErrNotFound, "")
 	} else if err != nil {
 		return nil, errors.WithStack(err)
 	}
 
-	key, err := m.Cipher.Decrypt(d.Key)
+	key, err := m.decrypt(m.Cipher, &d)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
@@ -157,8 +390,13 @@ func (m *SQLManager) GetKey(set, kid string) (*jose.JSONWebKeySet, error) {
 }
 
 func (m *SQLManager) GetKeySet(set string) (*jose.JSONWebKeySet, error) {
+	stmt, err := m.statements.Preparex(m.DB, "SELECT * FROM hydra_jwk WHERE sid=?")
+	if err != nil {
+		return nil, err
+	}
+
 	var ds []sqlData
-	if err := m.DB.Select(&ds, m.DB.Rebind("SELECT * FROM hydra_jwk WHERE sid=?"), set); err == sql.ErrNoRows {
+	if err := stmt.Select(&ds, set); err == sql.ErrNoRows {
 		return nil, errors.Wrap(pkg.ErrNotFound, "")
 	} else if err != nil {
 		return nil, errors.WithStack(err)
@@ -170,7 +408,7 @@ func (m *SQLManager) GetKeySet(set string) (*jose.JSONWebKeySet, error) {
 
 	keys := &jose.JSONWebKeySet{Keys: []jose.JSONWebKey{}}
 	for _, d := range ds {
-		key, err := m.Cipher.Decrypt(d.Key)
+		key, err := m.decrypt(m.Cipher, &d)
 		if err != nil {
 			return nil, errors.WithStack(err)
 		}
@@ -185,16 +423,287 @@ func (m *SQLManager) GetKeySet(set string) (*jose.JSONWebKeySet, error) {
 	return keys, nil
 }
 
+func (m *SQLManager) ListKeySets(limit, offset int) ([]KeySetSummary, error) {
+	var sets []string
+	if err := m.DB.Select(&sets, m.DB.Rebind("SELECT DISTINCT sid FROM hydra_jwk ORDER BY sid LIMIT ? OFFSET ?"), limit, offset); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	summaries := []KeySetSummary{}
+	for _, set := range sets {
+		keys, err := m.GetKeySet(set)
+		if errors.Cause(err) == pkg.ErrNotFound {
+			// The set was deleted in the meantime.
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, summarizeKeySet(set, keys))
+	}
+	return summaries, nil
+}
+
+func (m *SQLManager) PatchKey(set, kid string, patch func(key *jose.JSONWebKey) (*jose.JSONWebKey, error)) (*jose.JSONWebKey, error) {
+	dialect, err := pkg.SQLDialect(m.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	lock := "SELECT * FROM hydra_jwk WHERE sid=? AND kid=? FOR UPDATE"
+	if dialect == pkg.SQLDialectCockroach {
+		// See RotateKeySet.
+		lock = "SELECT * FROM hydra_jwk WHERE sid=? AND kid=?"
+	}
+
+	e, err := events.NewEvent(events.KeyUpdated, map[string]string{"set": set, "kid": kid})
+	if err != nil {
+		return nil, err
+	}
+
+	var result *jose.JSONWebKey
+	if err := events.Transaction(m.DB, m.Outbox, e, func(tx *sqlx.Tx) error {
+		var d sqlData
+		if err := tx.Get(&d, tx.Rebind(lock), set, kid); err == sql.ErrNoRows {
+			return errors.Wrap(pkg.ErrNotFound, "")
+		} else if err != nil {
+			return errors.WithStack(err)
+		}
+
+		out, err := m.decrypt(m.Cipher, &d)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		var key jose.JSONWebKey
+		if err := json.Unmarshal(out, &key); err != nil {
+			return errors.WithStack(err)
+		}
+
+		p, err := patchKey(key, patch)
+		if err != nil {
+			return err
+		}
+
+		if out, err = json.Marshal(p); err != nil {
+			return errors.WithStack(err)
+		}
+
+		encrypted, err := m.encrypt(m.Cipher, out)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(
+			tx.Rebind("UPDATE hydra_jwk SET version=?, keydata=? WHERE sid=? AND kid=?"),
+			sqlKeyVersionEnvelope, encrypted, set, kid,
+		); err != nil {
+			return errors.WithStack(err)
+		}
+		result = p
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
 func (m *SQLManager) DeleteKey(set, kid string) error {
-	if _, err := m.DB.Exec(m.DB.Rebind(`DELETE FROM hydra_jwk WHERE sid=? AND kid=?`), set, kid); err != nil {
-		return errors.WithStack(err)
+	e, err := events.NewEvent(events.KeyDeleted, map[string]string{"set": set, "kid": kid})
+	if err != nil {
+		return err
 	}
-	return nil
+
+	return events.Transaction(m.DB, m.Outbox, e, func(tx *sqlx.Tx) error {
+		if _, err := tx.Exec(m.DB.Rebind(`DELETE FROM hydra_jwk WHERE sid=? AND kid=?`), set, kid); err != nil {
+			return errors.WithStack(err)
+		}
+		return nil
+	})
 }
 
 func (m *SQLManager) DeleteKeySet(set string) error {
-	if _, err := m.DB.Exec(m.DB.Rebind(`DELETE FROM hydra_jwk WHERE sid=?`), set); err != nil {
+	e, err := events.NewEvent(events.KeySetDeleted, map[string]string{"set": set})
+	if err != nil {
+		return err
+	}
+
+	return events.Transaction(m.DB, m.Outbox, e, func(tx *sqlx.Tx) error {
+		if _, err := tx.Exec(m.DB.Rebind(`DELETE FROM hydra_jwk WHERE sid=?`), set); err != nil {
+			return errors.WithStack(err)
+		}
+		if _, err := tx.Exec(m.DB.Rebind(`DELETE FROM hydra_jwk_rotation WHERE sid=?`), set); err != nil {
+			return errors.WithStack(err)
+		}
+		return nil
+	})
+}
+
+func (m *SQLManager) SetKeyLifetime(set, kid string, lifetime KeyLifetime) error {
+	var count int
+	if err := m.DB.Get(&count, m.DB.Rebind("SELECT COUNT(*) FROM hydra_jwk WHERE sid=? AND kid=?"), set, kid); err != nil {
+		return errors.WithStack(err)
+	} else if count == 0 {
+		return errors.Wrap(pkg.ErrNotFound, "")
+	}
+
+	if _, err := m.DB.Exec(
+		m.DB.Rebind("UPDATE hydra_jwk SET not_before=?, expires_at=? WHERE sid=? AND kid=?"),
+		nullTime(lifetime.NotBefore), nullTime(lifetime.ExpiresAt), set, kid,
+	); err != nil {
 		return errors.WithStack(err)
 	}
 	return nil
 }
+
+func (m *SQLManager) GetKeyLifetimes(set string) (map[string]KeyLifetime, error) {
+	var ds []sqlData
+	if err := m.DB.Select(&ds, m.DB.Rebind("SELECT sid, kid, not_before, expires_at FROM hydra_jwk WHERE sid=? AND (not_before IS NOT NULL OR expires_at IS NOT NULL)"), set); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	lifetimes := map[string]KeyLifetime{}
+	for _, d := range ds {
+		var lifetime KeyLifetime
+		if d.NotBefore != nil {
+			lifetime.NotBefore = d.NotBefore.UTC()
+		}
+		if d.ExpiresAt != nil {
+			lifetime.ExpiresAt = d.ExpiresAt.UTC()
+		}
+		lifetimes[d.KID] = lifetime
+	}
+	return lifetimes, nil
+}
+
+func (m *SQLManager) DeleteExpiredKeys(now time.Time) (int, error) {
+	var ds []sqlData
+	if err := m.DB.Select(&ds, m.DB.Rebind("SELECT sid, kid FROM hydra_jwk WHERE expires_at IS NOT NULL AND expires_at <= ?"), now.UTC()); err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	for k, d := range ds {
+		if err := m.DeleteKey(d.Set, d.KID); err != nil {
+			return k, err
+		}
+	}
+	return len(ds), nil
+}
+
+func nullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	t = t.UTC()
+	return &t
+}
+
+// RotateCipher re-encrypts all stored keys so that they can be decrypted with next instead of the current Cipher.
+// Keys stored before envelope encryption was introduced are converted to envelope encryption. Returns the number of
+// keys that were re-encrypted.
+func (m *SQLManager) RotateCipher(next Cipher) (int, error) {
+	var ds []sqlData
+	if err := pkg.SQLTransaction(m.DB, func(tx *sqlx.Tx) error {
+		ds = nil
+		if err := tx.Select(&ds, "SELECT * FROM hydra_jwk"); err != nil {
+			return errors.WithStack(err)
+		}
+
+		// The update is prepared once and executed for every key.
+		update, err := tx.Preparex(tx.Rebind("UPDATE hydra_jwk SET version=?, keydata=? WHERE sid=? AND kid=?"))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		defer update.Close()
+
+		for _, d := range ds {
+			keydata, err := m.reencrypt(&d, next)
+			if err != nil {
+				return errors.Wrapf(err, "Could not re-encrypt key %s in set %s", d.KID, d.Set)
+			}
+
+			if _, err := update.Exec(sqlKeyVersionEnvelope, keydata, d.Set, d.KID); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+
+	m.Cipher = next
+	return len(ds), nil
+}
+
+func (m *SQLManager) reencrypt(d *sqlData, next Cipher) (string, error) {
+	if d.Version != sqlKeyVersionEnvelope {
+		plaintext, err := m.decrypt(m.Cipher, d)
+		if err != nil {
+			return "", err
+		}
+		return m.encrypt(next, plaintext)
+	}
+
+	parts := strings.SplitN(d.Key, ".", 2)
+	if len(parts) != 2 {
+		return "", errors.New("Malformed envelope")
+	}
+
+	dataKey, err := m.Cipher.Decrypt(parts[0])
+	if err != nil {
+		return "", err
+	}
+
+	wrapped, err := next.Encrypt(dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	return wrapped + "." + parts[1], nil
+}
+
+func (m *SQLManager) encrypt(master Cipher, plaintext []byte) (string, error) {
+	return sealEnvelope(master, plaintext)
+}
+
+func (m *SQLManager) decrypt(master Cipher, d *sqlData) ([]byte, error) {
+	if d.Version != sqlKeyVersionEnvelope {
+		return master.Decrypt(d.Key)
+	}
+	return openEnvelope(master, d.Key)
+}
+
+// sealEnvelope encrypts plaintext with a random data key and returns the data key, encrypted with master, and the
+// ciphertext joined by a dot.
+func sealEnvelope(master Cipher, plaintext []byte) (string, error) {
+	dataKey, err := RandomBytes(32)
+	if err != nil {
+		return "", err
+	}
+
+	ciphertext, err := (&AEAD{Key: dataKey}).Encrypt(plaintext)
+	if err != nil {
+		return "", err
+	}
+
+	wrapped, err := master.Encrypt(dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	return wrapped + "." + ciphertext, nil
+}
+
+// openEnvelope decrypts an envelope created by sealEnvelope.
+func openEnvelope(master Cipher, envelope string) ([]byte, error) {
+	parts := strings.SplitN(envelope, ".", 2)
+	if len(parts) != 2 {
+		return nil, errors.New("Malformed envelope")
+	}
+
+	dataKey, err := master.Decrypt(parts[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return (&AEAD{Key: dataKey}).Decrypt(parts[1])
+}
@@ -20,13 +20,18 @@ import (
 	"log"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/ory/hydra/integration"
 	. "github.com/ory/hydra/jwk"
+	"github.com/square/go-jose"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var managers = map[string]Manager{
 	"memory": new(MemoryManager),
+	"cached": NewCachedManager(new(MemoryManager), time.Minute),
 }
 
 var testGenerator = &RS256Generator{}
@@ -40,6 +45,7 @@ func TestMain(m *testing.M) {
 			integration.BootParallel([]func(){
 				connectToPG,
 				connectToMySQL,
+				connectToRedis,
 			})
 		}
 	}
@@ -70,6 +76,10 @@ func connectToMySQL() {
 	managers["mysql"] = s
 }
 
+func connectToRedis() {
+	managers["redis"] = &RedisManager{DB: integration.ConnectToRedis(), Cipher: &AEAD{Key: encryptionKey}}
+}
+
 func TestManagerKey(t *testing.T) {
 	ks, _ := testGenerator.Generate("TestManagerKey")
 
@@ -86,3 +96,125 @@ func TestManagerKeySet(t *testing.T) {
 		t.Run(fmt.Sprintf("case=%s", name), TestHelperManagerKeySet(m, ks, "TestManagerKeySet"))
 	}
 }
+
+func TestManagerListKeySets(t *testing.T) {
+	ks, _ := testGenerator.Generate("TestManagerListKeySets")
+
+	for name, m := range managers {
+		t.Run(fmt.Sprintf("case=%s", name), TestHelperManagerListKeySets(m, ks, "TestManagerListKeySets"))
+	}
+}
+
+func TestManagerKeyLifetimes(t *testing.T) {
+	ks, _ := testGenerator.Generate("TestManagerKeyLifetimes")
+
+	for name, m := range managers {
+		t.Run(fmt.Sprintf("case=%s", name), TestHelperManagerKeyLifetimes(m, ks, "TestManagerKeyLifetimes"))
+	}
+}
+
+func TestManagerRotateKeySet(t *testing.T) {
+	for name, m := range managers {
+		t.Run(fmt.Sprintf("case=%s", name), TestHelperManagerRotateKeySet(m, testGenerator, "TestManagerRotateKeySet"))
+	}
+}
+
+func TestManagerEnsureKeySet(t *testing.T) {
+	for name, m := range managers {
+		t.Run(fmt.Sprintf("case=%s", name), TestHelperManagerEnsureKeySet(m, testGenerator, "TestManagerEnsureKeySet"))
+	}
+}
+
+func TestManagerPatchKey(t *testing.T) {
+	ks, _ := testGenerator.Generate("TestManagerPatchKey")
+
+	for name, m := range managers {
+		t.Run(fmt.Sprintf("case=%s", name), TestHelperManagerPatchKey(m, ks, "TestManagerPatchKey"))
+	}
+}
+
+func TestSQLManagerRotateCipher(t *testing.T) {
+	ks, _ := testGenerator.Generate("TestSQLManagerRotateCipher")
+
+	for name, m := range managers {
+		s, ok := m.(*SQLManager)
+		if !ok {
+			continue
+		}
+
+		t.Run(fmt.Sprintf("case=%s", name), func(t *testing.T) {
+			next, err := RandomBytes(32)
+			require.NoError(t, err)
+
+			rm := &SQLManager{DB: s.DB, Cipher: &AEAD{Key: encryptionKey}}
+			require.NoError(t, rm.AddKeySet("TestSQLManagerRotateCipher", ks))
+
+			n, err := rm.RotateCipher(&AEAD{Key: next})
+			require.NoError(t, err)
+			assert.True(t, n >= len(ks.Keys))
+
+			_, err = (&SQLManager{DB: s.DB, Cipher: &AEAD{Key: encryptionKey}}).GetKeySet("TestSQLManagerRotateCipher")
+			assert.Error(t, err)
+
+			got, err := (&SQLManager{DB: s.DB, Cipher: &AEAD{Key: next}}).GetKeySet("TestSQLManagerRotateCipher")
+			require.NoError(t, err)
+			assert.Len(t, got.Keys, len(ks.Keys))
+
+			_, err = rm.RotateCipher(&AEAD{Key: encryptionKey})
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestSQLManagerAddKeySetIsAtomic(t *testing.T) {
+	ks, _ := testGenerator.Generate("TestSQLManagerAddKeySetIsAtomic")
+	extra, _ := (&HS256Generator{}).Generate("TestSQLManagerAddKeySetIsAtomic-extra")
+
+	for name, m := range managers {
+		if _, ok := m.(*SQLManager); !ok {
+			continue
+		}
+
+		t.Run(fmt.Sprintf("case=%s", name), func(t *testing.T) {
+			set := "TestSQLManagerAddKeySetIsAtomic-" + name
+			require.NoError(t, m.AddKeySet(set, &jose.JSONWebKeySet{Keys: ks.Keys[:1]}))
+
+			// The last key conflicts with the existing one, so the extra key must not be added either.
+			err := m.AddKeySet(set, &jose.JSONWebKeySet{Keys: append(extra.Keys, ks.Keys[0])})
+			require.Error(t, err)
+
+			got, err := m.GetKeySet(set)
+			require.NoError(t, err)
+			require.Len(t, got.Keys, 1)
+			assert.Equal(t, ks.Keys[0].KeyID, got.Keys[0].KeyID)
+		})
+	}
+}
+
+func TestSQLManagerAddLargeKeySet(t *testing.T) {
+	ks := &jose.JSONWebKeySet{}
+	for i := 0; i < 100; i++ {
+		k, err := (&HS256Generator{}).Generate(fmt.Sprintf("TestSQLManagerAddLargeKeySet-%d", i))
+		require.NoError(t, err)
+		ks.Keys = append(ks.Keys, k.Keys...)
+	}
+
+	for name, m := range managers {
+		if _, ok := m.(*SQLManager); !ok {
+			continue
+		}
+
+		t.Run(fmt.Sprintf("case=%s", name), func(t *testing.T) {
+			set := "TestSQLManagerAddLargeKeySet-" + name
+			require.NoError(t, m.AddKeySet(set, ks))
+
+			got, err := m.GetKeySet(set)
+			require.NoError(t, err)
+			assert.Len(t, got.Keys, len(ks.Keys))
+
+			got, err = m.GetKey(set, ks.Keys[99].KeyID)
+			require.NoError(t, err)
+			assert.Equal(t, ks.Keys[99].Key, got.Keys[0].Key)
+		})
+	}
+}
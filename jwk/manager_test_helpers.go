@@ -17,8 +17,11 @@ package jwk
 import (
 	"crypto/rand"
 	"io"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/ory/hydra/pkg"
 	"github.com/pkg/errors"
 	"github.com/square/go-jose"
 	"github.com/stretchr/testify/assert"
@@ -89,3 +92,234 @@ func TestHelperManagerKeySet(m Manager, keys *jose.JSONWebKeySet, suffix string)
 		assert.NotNil(t, err)
 	}
 }
+
+func TestHelperManagerListKeySets(m Manager, keys *jose.JSONWebKeySet, suffix string) func(t *testing.T) {
+	return func(t *testing.T) {
+		t.Parallel()
+		first, second := "list-a:"+suffix, "list-b:"+suffix
+		require.NoError(t, m.AddKeySet(first, keys))
+		require.NoError(t, m.AddKeySet(second, keys))
+
+		summaries, err := m.ListKeySets(1000, 0)
+		require.NoError(t, err)
+
+		var found []KeySetSummary
+		for _, s := range summaries {
+			if s.Set == first || s.Set == second {
+				found = append(found, s)
+			}
+		}
+		require.Len(t, found, 2)
+		assert.Equal(t, first, found[0].Set)
+		assert.Equal(t, second, found[1].Set)
+		assert.Equal(t, len(keys.Keys), found[0].Keys)
+		assert.Equal(t, []string{First(keys.Keys).Algorithm}, found[0].Algorithms)
+
+		page, err := m.ListKeySets(1, 0)
+		require.NoError(t, err)
+		assert.Len(t, page, 1)
+
+		page, err = m.ListKeySets(1, len(summaries)+1000)
+		require.NoError(t, err)
+		assert.Empty(t, page)
+
+		require.NoError(t, m.DeleteKeySet(first))
+		require.NoError(t, m.DeleteKeySet(second))
+	}
+}
+
+func TestHelperManagerKeyLifetimes(m Manager, keys *jose.JSONWebKeySet, suffix string) func(t *testing.T) {
+	return func(t *testing.T) {
+		t.Parallel()
+		set := "lifetimes:" + suffix
+		require.NoError(t, m.AddKeySet(set, keys))
+
+		assert.Error(t, m.SetKeyLifetime(set, "does-not-exist", KeyLifetime{}))
+
+		lifetimes, err := m.GetKeyLifetimes(set)
+		require.NoError(t, err)
+		assert.Empty(t, lifetimes)
+
+		now := time.Now().UTC().Round(time.Second)
+		expired := KeyLifetime{NotBefore: now.Add(-time.Hour * 2), ExpiresAt: now.Add(-time.Hour)}
+		valid := KeyLifetime{ExpiresAt: now.Add(time.Hour)}
+		require.NoError(t, m.SetKeyLifetime(set, "private:"+suffix, expired))
+		require.NoError(t, m.SetKeyLifetime(set, "public:"+suffix, valid))
+
+		lifetimes, err = m.GetKeyLifetimes(set)
+		require.NoError(t, err)
+		require.Len(t, lifetimes, 2)
+		assert.True(t, expired.NotBefore.Equal(lifetimes["private:"+suffix].NotBefore))
+		assert.True(t, expired.ExpiresAt.Equal(lifetimes["private:"+suffix].ExpiresAt))
+		assert.True(t, lifetimes["public:"+suffix].NotBefore.IsZero())
+		assert.True(t, valid.ExpiresAt.Equal(lifetimes["public:"+suffix].ExpiresAt))
+
+		deleted, err := m.DeleteExpiredKeys(now)
+		require.NoError(t, err)
+		assert.Equal(t, 1, deleted)
+
+		_, err = m.GetKey(set, "private:"+suffix)
+		assert.Error(t, err)
+		_, err = m.GetKey(set, "public:"+suffix)
+		assert.NoError(t, err)
+
+		lifetimes, err = m.GetKeyLifetimes(set)
+		require.NoError(t, err)
+		assert.Len(t, lifetimes, 1)
+
+		require.NoError(t, m.DeleteKeySet(set))
+	}
+}
+
+func TestHelperManagerRotateKeySet(m Manager, generator KeyGenerator, suffix string) func(t *testing.T) {
+	return func(t *testing.T) {
+		t.Parallel()
+		set := "rotate:" + suffix
+		since := time.Now().UTC()
+
+		var lock sync.Mutex
+		var generated int
+		generate := func() (*jose.JSONWebKeySet, error) {
+			lock.Lock()
+			generated++
+			lock.Unlock()
+			return generator.Generate("")
+		}
+
+		var wg sync.WaitGroup
+		results := make([]*jose.JSONWebKeySet, 5)
+		errs := make([]error, len(results))
+		for k := range results {
+			wg.Add(1)
+			go func(k int) {
+				defer wg.Done()
+				results[k], _, errs[k] = m.RotateKeySet(set, since, generate)
+			}(k)
+		}
+		wg.Wait()
+
+		assert.Equal(t, 1, generated)
+		for k := range results {
+			require.NoError(t, errs[k])
+			require.Len(t, results[k].Keys, len(results[0].Keys))
+			for i := range results[k].Keys {
+				assert.Equal(t, results[0].Keys[i].KeyID, results[k].Keys[i].KeyID)
+			}
+		}
+
+		stored, err := m.GetKeySet(set)
+		require.NoError(t, err)
+		assert.Len(t, stored.Keys, len(results[0].Keys))
+
+		time.Sleep(time.Second)
+		keys, rotated, err := m.RotateKeySet(set, time.Now().UTC(), generate)
+		require.NoError(t, err)
+		assert.True(t, rotated)
+		assert.NotEqual(t, results[0].Keys[0].KeyID, keys.Keys[0].KeyID)
+		assert.Equal(t, 2, generated)
+
+		require.NoError(t, m.DeleteKeySet(set))
+	}
+}
+
+func TestHelperManagerEnsureKeySet(m Manager, generator KeyGenerator, suffix string) func(t *testing.T) {
+	return func(t *testing.T) {
+		t.Parallel()
+		set := "ensure:" + suffix
+
+		var lock sync.Mutex
+		var generated int
+		generate := func() (*jose.JSONWebKeySet, error) {
+			lock.Lock()
+			generated++
+			lock.Unlock()
+			return generator.Generate("")
+		}
+		find := func(prefix string) func(keys *jose.JSONWebKeySet) bool {
+			return func(keys *jose.JSONWebKeySet) bool {
+				_, err := FindKeysByPrefix(keys, prefix)
+				return err == nil
+			}
+		}
+
+		var wg sync.WaitGroup
+		results := make([]*jose.JSONWebKeySet, 5)
+		errs := make([]error, len(results))
+		for k := range results {
+			wg.Add(1)
+			go func(k int) {
+				defer wg.Done()
+				results[k], _, errs[k] = m.EnsureKeySet(set, find("private"), generate)
+			}(k)
+		}
+		wg.Wait()
+
+		assert.Equal(t, 1, generated)
+		for k := range results {
+			require.NoError(t, errs[k])
+			require.Len(t, results[k].Keys, len(results[0].Keys))
+		}
+
+		stored, err := m.GetKeySet(set)
+		require.NoError(t, err)
+		assert.Len(t, stored.Keys, len(results[0].Keys))
+
+		keys, created, err := m.EnsureKeySet(set, find("public"), generate)
+		require.NoError(t, err)
+		assert.False(t, created)
+		assert.Len(t, keys.Keys, len(stored.Keys))
+
+		keys, created, err = m.EnsureKeySet(set, find("does-not-exist"), generate)
+		require.NoError(t, err)
+		assert.True(t, created)
+		assert.Len(t, keys.Keys, len(stored.Keys)*2)
+		assert.Equal(t, 2, generated)
+
+		require.NoError(t, m.DeleteKeySet(set))
+	}
+}
+
+func TestHelperManagerPatchKey(m Manager, keys *jose.JSONWebKeySet, suffix string) func(t *testing.T) {
+	return func(t *testing.T) {
+		t.Parallel()
+		set := "patch:" + suffix
+		kid := keys.Keys[0].KeyID
+		require.NoError(t, m.AddKey(set, First(keys.Keys)))
+
+		_, err := m.PatchKey(set, "does-not-exist", func(key *jose.JSONWebKey) (*jose.JSONWebKey, error) {
+			return key, nil
+		})
+		assert.Equal(t, pkg.ErrNotFound, errors.Cause(err))
+
+		_, err = m.PatchKey(set, kid, func(key *jose.JSONWebKey) (*jose.JSONWebKey, error) {
+			key.KeyID = "changed"
+			return key, nil
+		})
+		assert.Error(t, err)
+
+		var wg sync.WaitGroup
+		errs := make([]error, 5)
+		for k := range errs {
+			wg.Add(1)
+			go func(k int) {
+				defer wg.Done()
+				_, errs[k] = m.PatchKey(set, kid, func(key *jose.JSONWebKey) (*jose.JSONWebKey, error) {
+					key.Use += "x"
+					return key, nil
+				})
+			}(k)
+		}
+		wg.Wait()
+
+		for _, err := range errs {
+			require.NoError(t, err)
+		}
+
+		stored, err := m.GetKey(set, kid)
+		require.NoError(t, err)
+		assert.Equal(t, keys.Keys[0].Use+"xxxxx", stored.Keys[0].Use)
+		assert.Equal(t, keys.Keys[0].Key, stored.Keys[0].Key)
+
+		require.NoError(t, m.DeleteKeySet(set))
+	}
+}
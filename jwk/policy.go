@@ -0,0 +1,85 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwk
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/square/go-jose"
+)
+
+// KeyPolicy rejects uploaded keys which are weaker than the configured minimums or use a denied algorithm. Minimums
+// which are zero are not enforced.
+type KeyPolicy struct {
+	// MinRSAKeySize is the minimum size of RSA keys in bits.
+	MinRSAKeySize int
+
+	// MinECKeySize is the minimum size of the curve of elliptic curve keys in bits, for example 256 rejects P-192.
+	MinECKeySize int
+
+	// MinSymmetricKeySize is the minimum size of symmetric keys in bits.
+	MinSymmetricKeySize int
+
+	// DeniedAlgorithms are the algorithms keys may not be used with. They are compared case-insensitively.
+	DeniedAlgorithms []string
+}
+
+// Check returns an error naming the constraint the key violates, or nil if it satisfies the policy. A nil policy
+// accepts all keys.
+func (p *KeyPolicy) Check(key *jose.JSONWebKey) error {
+	if p == nil {
+		return nil
+	}
+
+	for _, alg := range p.DeniedAlgorithms {
+		if strings.EqualFold(alg, key.Algorithm) {
+			return errors.Errorf("Key %s uses the algorithm %s, which is denied", key.KeyID, key.Algorithm)
+		}
+	}
+
+	switch k := key.Key.(type) {
+	case *rsa.PublicKey:
+		return p.checkSize(key, "RSA", k.N.BitLen(), p.MinRSAKeySize)
+	case *rsa.PrivateKey:
+		return p.checkSize(key, "RSA", k.N.BitLen(), p.MinRSAKeySize)
+	case *ecdsa.PublicKey:
+		return p.checkSize(key, "Elliptic curve", k.Curve.Params().BitSize, p.MinECKeySize)
+	case *ecdsa.PrivateKey:
+		return p.checkSize(key, "Elliptic curve", k.Curve.Params().BitSize, p.MinECKeySize)
+	case []byte:
+		return p.checkSize(key, "Symmetric", len(k)*8, p.MinSymmetricKeySize)
+	}
+	return nil
+}
+
+// CheckAll checks every key of the set.
+func (p *KeyPolicy) CheckAll(keys *jose.JSONWebKeySet) error {
+	for k := range keys.Keys {
+		if err := p.Check(&keys.Keys[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *KeyPolicy) checkSize(key *jose.JSONWebKey, kind string, size, min int) error {
+	if size < min {
+		return errors.Errorf("%s key %s has %d bits but must have at least %d bits", kind, key.KeyID, size, min)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwk
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+
+	"github.com/square/go-jose"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyPolicy(t *testing.T) {
+	weakRSA, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	strongRSA, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	weakEC, err := ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+	require.NoError(t, err)
+	strongEC, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	p := &KeyPolicy{
+		MinRSAKeySize:       2048,
+		MinECKeySize:        256,
+		MinSymmetricKeySize: 256,
+		DeniedAlgorithms:    []string{"none", "RS1"},
+	}
+
+	for k, c := range []struct {
+		key      jose.JSONWebKey
+		contains string
+	}{
+		{key: jose.JSONWebKey{KeyID: "weak-rsa", Key: weakRSA}, contains: "RSA key weak-rsa has 1024 bits but must have at least 2048 bits"},
+		{key: jose.JSONWebKey{KeyID: "weak-rsa-public", Key: &weakRSA.PublicKey}, contains: "1024 bits"},
+		{key: jose.JSONWebKey{KeyID: "strong-rsa", Key: strongRSA, Algorithm: "RS256"}},
+		{key: jose.JSONWebKey{KeyID: "strong-rsa", Key: &strongRSA.PublicKey, Algorithm: "rs1"}, contains: "algorithm rs1, which is denied"},
+		{key: jose.JSONWebKey{KeyID: "weak-ec", Key: weakEC}, contains: "Elliptic curve key weak-ec has 224 bits"},
+		{key: jose.JSONWebKey{KeyID: "strong-ec", Key: &strongEC.PublicKey, Algorithm: "ES256"}},
+		{key: jose.JSONWebKey{KeyID: "weak-hs", Key: make([]byte, 16)}, contains: "Symmetric key weak-hs has 128 bits"},
+		{key: jose.JSONWebKey{KeyID: "strong-hs", Key: make([]byte, 32), Algorithm: "HS256"}},
+		{key: jose.JSONWebKey{KeyID: "none", Key: make([]byte, 32), Algorithm: "none"}, contains: "algorithm none"},
+	} {
+		err := p.Check(&c.key)
+		if c.contains == "" {
+			assert.NoError(t, err, "case %d", k)
+		} else if assert.Error(t, err, "case %d", k) {
+			assert.Contains(t, err.Error(), c.contains, "case %d", k)
+		}
+	}
+
+	assert.Error(t, p.CheckAll(&jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+		{KeyID: "strong-hs", Key: make([]byte, 32)},
+		{KeyID: "weak-hs", Key: make([]byte, 16)},
+	}}))
+	assert.NoError(t, (&KeyPolicy{}).Check(&jose.JSONWebKey{KeyID: "weak-rsa", Key: weakRSA}))
+
+	var none *KeyPolicy
+	assert.NoError(t, none.Check(&jose.JSONWebKey{KeyID: "weak-rsa", Key: weakRSA, Algorithm: "none"}))
+}
@@ -0,0 +1,64 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// ConsentStatistics keeps track of consent flows which expired, and how many of those were abandoned because the
+// consent app never accepted or rejected them.
+type ConsentStatistics struct {
+	sync.RWMutex
+	Expired   uint64 `json:"expired"`
+	Abandoned uint64 `json:"abandoned"`
+}
+
+// Record adds the result of a consent request flush to the statistics.
+func (cs *ConsentStatistics) Record(expired, abandoned int) {
+	cs.Lock()
+	defer cs.Unlock()
+	cs.Expired += uint64(expired)
+	cs.Abandoned += uint64(abandoned)
+}
+
+// AbandonmentRate returns the share of expired consent flows which were abandoned, ranging from 0 to 1.
+func (cs *ConsentStatistics) AbandonmentRate() float64 {
+	cs.RLock()
+	defer cs.RUnlock()
+	return cs.abandonmentRate()
+}
+
+func (cs *ConsentStatistics) ToMap() map[string]interface{} {
+	cs.RLock()
+	defer cs.RUnlock()
+	return map[string]interface{}{
+		"expired":         cs.Expired,
+		"abandoned":       cs.Abandoned,
+		"abandonmentRate": cs.abandonmentRate(),
+	}
+}
+
+func (cs *ConsentStatistics) MarshalJSON() ([]byte, error) {
+	return json.Marshal(cs.ToMap())
+}
+
+func (cs *ConsentStatistics) abandonmentRate() float64 {
+	if cs.Expired == 0 {
+		return 0
+	}
+	return float64(cs.Abandoned) / float64(cs.Expired)
+}
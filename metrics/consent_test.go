@@ -0,0 +1,32 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsentStatistics(t *testing.T) {
+	cs := new(ConsentStatistics)
+	assert.Equal(t, float64(0), cs.AbandonmentRate())
+
+	cs.Record(4, 1)
+	cs.Record(4, 3)
+	assert.EqualValues(t, 8, cs.Expired)
+	assert.EqualValues(t, 4, cs.Abandoned)
+	assert.Equal(t, 0.5, cs.AbandonmentRate())
+}
@@ -0,0 +1,147 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+type dashboardPanel struct {
+	title   string
+	unit    string
+	targets [][2]string
+}
+
+var dashboardPanels = []dashboardPanel{
+	{
+		title: "Request rate",
+		unit:  "reqps",
+		targets: [][2]string{
+			{fmt.Sprintf(`sum by (endpoint) (rate(%s{job=~"$job"}[5m]))`, RequestsMetric), "{{endpoint}}"},
+		},
+	},
+	{
+		title: "Error ratio (5xx)",
+		unit:  "percentunit",
+		targets: [][2]string{
+			{fmt.Sprintf(`sum by (endpoint) (rate(%[1]s{job=~"$job",code=~"5.."}[5m])) / sum by (endpoint) (rate(%[1]s{job=~"$job"}[5m]))`, RequestsMetric), "{{endpoint}}"},
+		},
+	},
+	{
+		title: "Request duration (p99)",
+		unit:  "s",
+		targets: [][2]string{
+			{fmt.Sprintf(`histogram_quantile(0.99, sum by (endpoint, le) (rate(%s_bucket{job=~"$job"}[5m])))`, RequestDurationMetric), "{{endpoint}}"},
+		},
+	},
+	{
+		title: "Request duration (all endpoints)",
+		unit:  "s",
+		targets: [][2]string{
+			{fmt.Sprintf(`histogram_quantile(0.5, sum by (le) (rate(%s_bucket{job=~"$job"}[5m])))`, RequestDurationMetric), "p50"},
+			{fmt.Sprintf(`histogram_quantile(0.95, sum by (le) (rate(%s_bucket{job=~"$job"}[5m])))`, RequestDurationMetric), "p95"},
+			{fmt.Sprintf(`histogram_quantile(0.99, sum by (le) (rate(%s_bucket{job=~"$job"}[5m])))`, RequestDurationMetric), "p99"},
+		},
+	},
+}
+
+// GrafanaDashboard returns a Grafana dashboard showing the request rate, errors and duration per endpoint as
+// exposed at /health/metrics.
+func GrafanaDashboard() ([]byte, error) {
+	panels := make([]map[string]interface{}, len(dashboardPanels))
+	for i, p := range dashboardPanels {
+		targets := make([]map[string]interface{}, len(p.targets))
+		for j, t := range p.targets {
+			targets[j] = map[string]interface{}{
+				"expr":         t[0],
+				"legendFormat": t[1],
+				"refId":        string(rune('A' + j)),
+			}
+		}
+
+		panels[i] = map[string]interface{}{
+			"id":         i + 1,
+			"title":      p.title,
+			"type":       "graph",
+			"datasource": "$datasource",
+			"gridPos":    map[string]int{"h": 8, "w": 12, "x": (i % 2) * 12, "y": (i / 2) * 8},
+			"targets":    targets,
+			"yaxes": []map[string]interface{}{
+				{"format": p.unit, "min": 0, "show": true},
+				{"format": "short", "show": false},
+			},
+			"lines":     true,
+			"linewidth": 1,
+			"legend":    map[string]bool{"show": true},
+		}
+	}
+
+	out, err := json.MarshalIndent(map[string]interface{}{
+		"title":         "ORY Hydra",
+		"uid":           "ory-hydra-red",
+		"tags":          []string{"hydra"},
+		"schemaVersion": 16,
+		"time":          map[string]string{"from": "now-6h", "to": "now"},
+		"refresh":       "30s",
+		"panels":        panels,
+		"templating": map[string]interface{}{
+			"list": []map[string]interface{}{
+				{"name": "datasource", "type": "datasource", "query": "prometheus"},
+				{
+					"name":       "job",
+					"type":       "query",
+					"datasource": "$datasource",
+					"query":      fmt.Sprintf("label_values(%s, job)", RequestsMetric),
+					"multi":      true,
+					"includeAll": true,
+					"refresh":    2,
+				},
+			},
+		},
+	}, "", "  ")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return out, nil
+}
+
+// AlertingRules returns Prometheus alerting rules which fire if the share of failed requests to an endpoint exceeds
+// errorRatio or if the 99th percentile of the request duration exceeds latency seconds, each for ten minutes.
+func AlertingRules(errorRatio float64, latency float64) []byte {
+	return []byte(fmt.Sprintf(`groups:
+- name: hydra
+  rules:
+  - alert: HydraHighErrorRatio
+    expr: sum by (job, endpoint) (rate(%[1]s{code=~"5.."}[5m])) / sum by (job, endpoint) (rate(%[1]s[5m])) > %[3]s
+    for: 10m
+    labels:
+      severity: critical
+    annotations:
+      summary: 'More than %[5]s%% of requests to {{ $labels.endpoint }} fail'
+      description: 'The share of requests to {{ $labels.endpoint }} ({{ $labels.job }}) which failed with a 5xx status code in the last 5 minutes is {{ $value }}.'
+  - alert: HydraHighLatency
+    expr: histogram_quantile(0.99, sum by (job, endpoint, le) (rate(%[2]s_bucket[5m]))) > %[4]s
+    for: 10m
+    labels:
+      severity: warning
+    annotations:
+      summary: 'Requests to {{ $labels.endpoint }} are slow'
+      description: 'The 99th percentile of the request duration of {{ $labels.endpoint }} ({{ $labels.job }}) is {{ $value }}s.'
+`, RequestsMetric, RequestDurationMetric, formatFloat(errorRatio), formatFloat(latency), strconv.FormatFloat(errorRatio*100, 'g', -1, 64)))
+}
@@ -0,0 +1,48 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrafanaDashboard(t *testing.T) {
+	out, err := GrafanaDashboard()
+	require.NoError(t, err)
+
+	var dashboard struct {
+		Panels []struct {
+			Targets []struct {
+				Expr string `json:"expr"`
+			} `json:"targets"`
+		} `json:"panels"`
+	}
+	require.NoError(t, json.Unmarshal(out, &dashboard))
+	require.Len(t, dashboard.Panels, len(dashboardPanels))
+	assert.Contains(t, dashboard.Panels[0].Targets[0].Expr, RequestsMetric)
+	assert.Contains(t, dashboard.Panels[2].Targets[0].Expr, RequestDurationMetric+"_bucket")
+}
+
+func TestAlertingRules(t *testing.T) {
+	out := string(AlertingRules(0.01, 0.5))
+	assert.Contains(t, out, `rate(hydra_http_requests_total{code=~"5.."}[5m])`)
+	assert.Contains(t, out, "[5m])) > 0.01\n")
+	assert.Contains(t, out, "[5m]))) > 0.5\n")
+	assert.Contains(t, out, "More than 1% of requests")
+}
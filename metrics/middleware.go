@@ -47,13 +47,17 @@ type MetricsManager struct {
 	shouldCommit bool               `json:"-"`
 	salt         string
 
-	ID               string            `json:"id"`
-	UpTime           int64             `json:"uptime"`
-	MemoryStatistics *MemoryStatistics `json:"memory"`
-	BuildVersion     string            `json:"buildVersion"`
-	BuildHash        string            `json:"buildHash"`
-	BuildTime        string            `json:"buildTime"`
-	InstanceID       string            `json:"instanceId"`
+	ID                  string               `json:"id"`
+	UpTime              int64                `json:"uptime"`
+	MemoryStatistics    *MemoryStatistics    `json:"memory"`
+	ConsentStatistics   *ConsentStatistics   `json:"consent"`
+	RequestStatistics   *RequestStatistics   `json:"-"`
+	QueryStatistics     *QueryStatistics     `json:"-"`
+	OperationStatistics *OperationStatistics `json:"-"`
+	BuildVersion        string               `json:"buildVersion"`
+	BuildHash           string               `json:"buildHash"`
+	BuildTime           string               `json:"buildTime"`
+	InstanceID          string               `json:"instanceId"`
 }
 
 func shouldCommit(issuerURL string, databaseURL string) bool {
@@ -77,16 +81,20 @@ func NewMetricsManager(issuerURL string, databaseURL string, l logrus.FieldLogge
 	}
 
 	mm := &MetricsManager{
-		InstanceID:       uuid.New(),
-		Segment:          segment,
-		Logger:           l,
-		issuerURL:        issuerURL,
-		databaseURL:      databaseURL,
-		MemoryStatistics: &MemoryStatistics{},
-		ID:               hash(issuerURL),
-		start:            time.Now().UTC(),
-		shouldCommit:     shouldCommit(issuerURL, databaseURL),
-		salt:             uuid.New(),
+		InstanceID:          uuid.New(),
+		Segment:             segment,
+		Logger:              l,
+		issuerURL:           issuerURL,
+		databaseURL:         databaseURL,
+		MemoryStatistics:    &MemoryStatistics{},
+		ConsentStatistics:   &ConsentStatistics{},
+		RequestStatistics:   NewRequestStatistics(),
+		QueryStatistics:     NewQueryStatistics(),
+		OperationStatistics: NewOperationStatistics(),
+		ID:                  hash(issuerURL),
+		start:               time.Now().UTC(),
+		shouldCommit:        shouldCommit(issuerURL, databaseURL),
+		salt:                uuid.New(),
 	}
 	return mm
 }
@@ -184,29 +192,44 @@ func (sw *MetricsManager) ServeHTTP(rw http.ResponseWriter, r *http.Request, nex
 	})
 }
 
+// endpointPaths are the paths of the endpoints served by Hydra. Paths which are prefixes of other paths must be listed
+// after them.
+var endpointPaths = []string{
+	client.ClientsHandlerPath,
+	jwk.KeyHandlerPath,
+	jwk.WellKnownKeysPath,
+	oauth2.DefaultConsentPath,
+	oauth2.TokenPath,
+	oauth2.ResumePath,
+	oauth2.AuthPath,
+	oauth2.PushedAuthorizationRequestPath,
+	oauth2.UserinfoPath,
+	oauth2.WellKnownPath,
+	oauth2.TokenHistoryPath,
+	oauth2.IntrospectPath,
+	oauth2.RevocationPath,
+	oauth2.FlushPath,
+	oauth2.ConsentRequestPath,
+	"/policies",
+	"/warden/token/allowed",
+	"/warden/allowed",
+	"/warden/decisions",
+	"/warden/policies",
+	group.GroupsHandlerPath,
+	"/subjects/erasure",
+	"/health/status",
+	"/health/stats",
+	"/health/ready",
+	"/health/metrics",
+	"/metrics",
+	"/admin/config",
+	"/",
+}
+
 func anonymizePath(path string, salt string) string {
-	paths := []string{
-		client.ClientsHandlerPath,
-		jwk.KeyHandlerPath,
-		jwk.WellKnownKeysPath,
-		oauth2.DefaultConsentPath,
-		oauth2.TokenPath,
-		oauth2.AuthPath,
-		oauth2.UserinfoPath,
-		oauth2.WellKnownPath,
-		oauth2.IntrospectPath,
-		oauth2.RevocationPath,
-		oauth2.ConsentRequestPath,
-		"/policies",
-		"/warden/token/allowed",
-		"/warden/allowed",
-		group.GroupsHandlerPath,
-		"/health/status",
-		"/",
-	}
 	path = strings.ToLower(path)
 
-	for _, p := range paths {
+	for _, p := range endpointPaths {
 		p = strings.ToLower(p)
 		if len(path) == len(p) && path[:len(p)] == strings.ToLower(p) {
 			return p
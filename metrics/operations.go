@@ -0,0 +1,221 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// TokensIssuedMetric counts access tokens issued by the token endpoint by grant type.
+	TokensIssuedMetric = "hydra_oauth2_tokens_issued_total"
+
+	// IntrospectionDurationMetric is a histogram of token introspection durations in seconds by whether the token was
+	// active.
+	IntrospectionDurationMetric = "hydra_oauth2_introspection_duration_seconds"
+
+	// JWKCacheRequestsMetric counts lookups of the JSON Web Key cache by result, which is either "hit" or "miss".
+	JWKCacheRequestsMetric = "hydra_jwk_cache_requests_total"
+
+	// FirewallDecisionDurationMetric is a histogram of policy decision durations in seconds by decision, which is
+	// either "allowed" or "denied".
+	FirewallDecisionDurationMetric = "hydra_firewall_decision_duration_seconds"
+
+	// ClientAuthFailuresMetric counts failed client authentications at the token endpoint by whether the attempt was
+	// rejected because of a lockout.
+	ClientAuthFailuresMetric = "hydra_oauth2_client_authentication_failures_total"
+)
+
+// OperationStatistics records token issuance, token introspection, failed client authentications, JSON Web Key cache
+// lookups and firewall decisions and exposes them in the Prometheus text format.
+type OperationStatistics struct {
+	tokens        *labeledCounter
+	introspection *labeledHistogram
+	jwkCache      *labeledCounter
+	firewall      *labeledHistogram
+	clientAuth    *labeledCounter
+}
+
+func NewOperationStatistics() *OperationStatistics {
+	return &OperationStatistics{
+		tokens:        newLabeledCounter(TokensIssuedMetric, "Total number of access tokens issued by grant type.", "grant_type"),
+		introspection: newLabeledHistogram(IntrospectionDurationMetric, "Duration of token introspections in seconds by whether the token was active.", "active", DurationBuckets),
+		jwkCache:      newLabeledCounter(JWKCacheRequestsMetric, "Total number of JSON Web Key cache lookups by result.", "result"),
+		firewall:      newLabeledHistogram(FirewallDecisionDurationMetric, "Duration of access policy decisions in seconds by decision.", "decision", QueryDurationBuckets),
+		clientAuth:    newLabeledCounter(ClientAuthFailuresMetric, "Total number of failed client authentications at the token endpoint by whether the client was locked out.", "locked"),
+	}
+}
+
+// RecordTokenIssued counts an access token issued with the given grant type.
+func (ops *OperationStatistics) RecordTokenIssued(grantType string) {
+	ops.tokens.inc(grantType)
+}
+
+// RecordIntrospection adds a token introspection to the statistics.
+func (ops *OperationStatistics) RecordIntrospection(active bool, duration time.Duration) {
+	ops.introspection.observe(fmt.Sprintf("%t", active), duration)
+}
+
+// RecordClientAuthFailure counts a failed client authentication at the token endpoint.
+func (ops *OperationStatistics) RecordClientAuthFailure(locked bool) {
+	ops.clientAuth.inc(fmt.Sprintf("%t", locked))
+}
+
+// RecordJWKCacheLookup counts a lookup of the JSON Web Key cache.
+func (ops *OperationStatistics) RecordJWKCacheLookup(hit bool) {
+	if hit {
+		ops.jwkCache.inc("hit")
+	} else {
+		ops.jwkCache.inc("miss")
+	}
+}
+
+// RecordFirewallDecision adds an access policy decision to the statistics.
+func (ops *OperationStatistics) RecordFirewallDecision(allowed bool, duration time.Duration) {
+	if allowed {
+		ops.firewall.observe("allowed", duration)
+	} else {
+		ops.firewall.observe("denied", duration)
+	}
+}
+
+// WritePrometheus writes the statistics in the Prometheus text exposition format. If openMetrics is true, the
+// OpenMetrics format is used instead. The "# EOF" marker is never written, so the output can be followed by other
+// metrics.
+func (ops *OperationStatistics) WritePrometheus(w io.Writer, openMetrics bool) error {
+	var b bytes.Buffer
+	ops.tokens.write(&b, openMetrics)
+	ops.introspection.write(&b)
+	ops.jwkCache.write(&b, openMetrics)
+	ops.firewall.write(&b)
+	ops.clientAuth.write(&b, openMetrics)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// labeledCounter is a counter with a single label.
+type labeledCounter struct {
+	sync.RWMutex
+	name   string
+	help   string
+	label  string
+	values map[string]uint64
+}
+
+func newLabeledCounter(name, help, label string) *labeledCounter {
+	return &labeledCounter{name: name, help: help, label: label, values: map[string]uint64{}}
+}
+
+func (c *labeledCounter) inc(value string) {
+	c.Lock()
+	defer c.Unlock()
+	c.values[value]++
+}
+
+func (c *labeledCounter) write(b *bytes.Buffer, openMetrics bool) {
+	c.RLock()
+	defer c.RUnlock()
+
+	typ := c.name
+	if openMetrics {
+		typ = strings.TrimSuffix(c.name, "_total")
+	}
+
+	fmt.Fprintf(b, "# HELP %s %s\n", typ, c.help)
+	fmt.Fprintf(b, "# TYPE %s counter\n", typ)
+	for _, value := range sortedKeys(c.values) {
+		fmt.Fprintf(b, "%s{%s=%q} %d\n", c.name, c.label, value, c.values[value])
+	}
+}
+
+// labeledHistogram is a histogram of durations in seconds with a single label.
+type labeledHistogram struct {
+	sync.RWMutex
+	name    string
+	help    string
+	label   string
+	buckets []float64
+	series  map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	buckets []uint64
+	sum     float64
+	count   uint64
+}
+
+func newLabeledHistogram(name, help, label string, buckets []float64) *labeledHistogram {
+	return &labeledHistogram{name: name, help: help, label: label, buckets: buckets, series: map[string]*histogramSeries{}}
+}
+
+func (h *labeledHistogram) observe(value string, duration time.Duration) {
+	h.Lock()
+	defer h.Unlock()
+
+	s, ok := h.series[value]
+	if !ok {
+		s = &histogramSeries{buckets: make([]uint64, len(h.buckets))}
+		h.series[value] = s
+	}
+
+	seconds := duration.Seconds()
+	s.sum += seconds
+	s.count++
+	for i, le := range h.buckets {
+		if seconds <= le {
+			s.buckets[i]++
+		}
+	}
+}
+
+func (h *labeledHistogram) write(b *bytes.Buffer) {
+	h.RLock()
+	defer h.RUnlock()
+
+	values := make([]string, 0, len(h.series))
+	for value := range h.series {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+
+	fmt.Fprintf(b, "# HELP %s %s\n", h.name, h.help)
+	fmt.Fprintf(b, "# TYPE %s histogram\n", h.name)
+	for _, value := range values {
+		s := h.series[value]
+		labels := fmt.Sprintf("%s=%q", h.label, value)
+		for i, le := range h.buckets {
+			fmt.Fprintf(b, "%s_bucket{%s,le=%q} %d\n", h.name, labels, formatFloat(le), s.buckets[i])
+		}
+		fmt.Fprintf(b, "%s_bucket{%s,le=\"+Inf\"} %d\n", h.name, labels, s.count)
+		fmt.Fprintf(b, "%s_sum{%s} %s\n", h.name, labels, formatFloat(s.sum))
+		fmt.Fprintf(b, "%s_count{%s} %d\n", h.name, labels, s.count)
+	}
+}
+
+func sortedKeys(m map[string]uint64) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
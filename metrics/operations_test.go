@@ -0,0 +1,68 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOperationStatistics(t *testing.T) {
+	ops := NewOperationStatistics()
+	ops.RecordTokenIssued("client_credentials")
+	ops.RecordTokenIssued("client_credentials")
+	ops.RecordTokenIssued("authorization_code")
+	ops.RecordIntrospection(true, time.Millisecond*20)
+	ops.RecordIntrospection(false, time.Millisecond*2)
+	ops.RecordJWKCacheLookup(true)
+	ops.RecordJWKCacheLookup(true)
+	ops.RecordJWKCacheLookup(false)
+	ops.RecordFirewallDecision(true, time.Millisecond)
+	ops.RecordFirewallDecision(false, time.Millisecond*3)
+	ops.RecordClientAuthFailure(false)
+	ops.RecordClientAuthFailure(false)
+	ops.RecordClientAuthFailure(true)
+
+	var out bytes.Buffer
+	require.NoError(t, ops.WritePrometheus(&out, false))
+	text := out.String()
+
+	assert.Contains(t, text, "# TYPE hydra_oauth2_tokens_issued_total counter\n")
+	assert.Contains(t, text, `hydra_oauth2_tokens_issued_total{grant_type="client_credentials"} 2`+"\n")
+	assert.Contains(t, text, `hydra_oauth2_tokens_issued_total{grant_type="authorization_code"} 1`+"\n")
+	assert.Contains(t, text, "# TYPE hydra_oauth2_introspection_duration_seconds histogram\n")
+	assert.Contains(t, text, `hydra_oauth2_introspection_duration_seconds_bucket{active="true",le="0.01"} 0`+"\n")
+	assert.Contains(t, text, `hydra_oauth2_introspection_duration_seconds_bucket{active="true",le="0.025"} 1`+"\n")
+	assert.Contains(t, text, `hydra_oauth2_introspection_duration_seconds_count{active="false"} 1`+"\n")
+	assert.Contains(t, text, `hydra_jwk_cache_requests_total{result="hit"} 2`+"\n")
+	assert.Contains(t, text, `hydra_jwk_cache_requests_total{result="miss"} 1`+"\n")
+	assert.Contains(t, text, `hydra_firewall_decision_duration_seconds_bucket{decision="allowed",le="0.001"} 1`+"\n")
+	assert.Contains(t, text, `hydra_firewall_decision_duration_seconds_bucket{decision="denied",le="0.0025"} 0`+"\n")
+	assert.Contains(t, text, `hydra_firewall_decision_duration_seconds_count{decision="denied"} 1`+"\n")
+	assert.Contains(t, text, `hydra_oauth2_client_authentication_failures_total{locked="false"} 2`+"\n")
+	assert.Contains(t, text, `hydra_oauth2_client_authentication_failures_total{locked="true"} 1`+"\n")
+
+	out.Reset()
+	require.NoError(t, ops.WritePrometheus(&out, true))
+	text = out.String()
+
+	assert.Contains(t, text, "# TYPE hydra_oauth2_tokens_issued counter\n")
+	assert.Contains(t, text, "# TYPE hydra_jwk_cache_requests counter\n")
+	assert.NotContains(t, text, "# EOF")
+}
@@ -0,0 +1,116 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SlowQueriesMetric counts SQL queries which exceeded the slow query threshold by operation.
+const SlowQueriesMetric = "hydra_sql_slow_queries_total"
+
+// SlowQueryDurationMetric sums up the duration of slow SQL queries in seconds by operation.
+const SlowQueryDurationMetric = "hydra_sql_slow_query_duration_seconds_total"
+
+// QueryDurationMetric is a histogram of SQL query durations in seconds by operation.
+const QueryDurationMetric = "hydra_sql_query_duration_seconds"
+
+// QueryDurationBuckets are the upper bounds of the SQL query and firewall decision duration histograms in seconds.
+var QueryDurationBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5}
+
+type slowQueryStatistics struct {
+	count   uint64
+	seconds float64
+}
+
+// QueryStatistics records the duration of SQL queries and the queries which exceeded the slow query threshold and
+// exposes them in the Prometheus text format.
+type QueryStatistics struct {
+	sync.RWMutex
+	operations map[string]*slowQueryStatistics
+	durations  *labeledHistogram
+}
+
+func NewQueryStatistics() *QueryStatistics {
+	return &QueryStatistics{
+		operations: map[string]*slowQueryStatistics{},
+		durations:  newLabeledHistogram(QueryDurationMetric, "Duration of SQL queries in seconds by operation.", "operation", QueryDurationBuckets),
+	}
+}
+
+// Record adds a query to the duration histogram. The operation identifies the kind of query, for example
+// "SELECT hydra_client", and thereby the manager which sent it. It must not contain any parameters.
+func (qs *QueryStatistics) Record(operation string, duration time.Duration) {
+	qs.durations.observe(operation, duration)
+}
+
+// RecordSlow adds a slow query to the statistics. The operation identifies the kind of query, for example
+// "SELECT hydra_client", and must not contain any parameters.
+func (qs *QueryStatistics) RecordSlow(operation string, duration time.Duration) {
+	qs.Lock()
+	defer qs.Unlock()
+
+	s, ok := qs.operations[operation]
+	if !ok {
+		s = new(slowQueryStatistics)
+		qs.operations[operation] = s
+	}
+	s.count++
+	s.seconds += duration.Seconds()
+}
+
+// WritePrometheus writes the statistics in the Prometheus text exposition format. If openMetrics is true, the
+// OpenMetrics format is used instead. The "# EOF" marker is never written, so the output can be followed by other
+// metrics.
+func (qs *QueryStatistics) WritePrometheus(w io.Writer, openMetrics bool) error {
+	qs.RLock()
+	defer qs.RUnlock()
+
+	operations := make([]string, 0, len(qs.operations))
+	for operation := range qs.operations {
+		operations = append(operations, operation)
+	}
+	sort.Strings(operations)
+
+	var b bytes.Buffer
+	countType, durationType := SlowQueriesMetric, SlowQueryDurationMetric
+	if openMetrics {
+		countType = strings.TrimSuffix(SlowQueriesMetric, "_total")
+		durationType = strings.TrimSuffix(SlowQueryDurationMetric, "_total")
+	}
+
+	fmt.Fprintf(&b, "# HELP %s Total number of SQL queries which exceeded the slow query threshold by operation.\n", countType)
+	fmt.Fprintf(&b, "# TYPE %s counter\n", countType)
+	for _, operation := range operations {
+		fmt.Fprintf(&b, "%s{operation=%q} %d\n", SlowQueriesMetric, operation, qs.operations[operation].count)
+	}
+
+	fmt.Fprintf(&b, "# HELP %s Total duration of SQL queries which exceeded the slow query threshold in seconds by operation.\n", durationType)
+	fmt.Fprintf(&b, "# TYPE %s counter\n", durationType)
+	for _, operation := range operations {
+		fmt.Fprintf(&b, "%s{operation=%q} %s\n", SlowQueryDurationMetric, operation, formatFloat(qs.operations[operation].seconds))
+	}
+
+	qs.durations.write(&b)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,58 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryStatistics(t *testing.T) {
+	qs := NewQueryStatistics()
+	qs.RecordSlow("SELECT hydra_client", time.Second)
+	qs.RecordSlow("SELECT hydra_client", time.Second*2)
+	qs.RecordSlow("DELETE hydra_oauth2_access", time.Millisecond*500)
+
+	var out bytes.Buffer
+	require.NoError(t, qs.WritePrometheus(&out, false))
+	text := out.String()
+
+	assert.Contains(t, text, "# TYPE hydra_sql_slow_queries_total counter\n")
+	assert.Contains(t, text, `hydra_sql_slow_queries_total{operation="SELECT hydra_client"} 2`+"\n")
+	assert.Contains(t, text, `hydra_sql_slow_queries_total{operation="DELETE hydra_oauth2_access"} 1`+"\n")
+	assert.Contains(t, text, `hydra_sql_slow_query_duration_seconds_total{operation="SELECT hydra_client"} 3`+"\n")
+	assert.Contains(t, text, `hydra_sql_slow_query_duration_seconds_total{operation="DELETE hydra_oauth2_access"} 0.5`+"\n")
+
+	qs.Record("SELECT hydra_client", time.Millisecond*3)
+	out.Reset()
+	require.NoError(t, qs.WritePrometheus(&out, false))
+	text = out.String()
+
+	assert.Contains(t, text, "# TYPE hydra_sql_query_duration_seconds histogram\n")
+	assert.Contains(t, text, `hydra_sql_query_duration_seconds_bucket{operation="SELECT hydra_client",le="0.0025"} 0`+"\n")
+	assert.Contains(t, text, `hydra_sql_query_duration_seconds_bucket{operation="SELECT hydra_client",le="0.005"} 1`+"\n")
+	assert.Contains(t, text, `hydra_sql_query_duration_seconds_count{operation="SELECT hydra_client"} 1`+"\n")
+
+	out.Reset()
+	require.NoError(t, qs.WritePrometheus(&out, true))
+	text = out.String()
+
+	assert.Contains(t, text, "# TYPE hydra_sql_slow_queries counter\n")
+	assert.NotContains(t, text, "# EOF")
+}
@@ -0,0 +1,205 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/urfave/negroni"
+)
+
+const (
+	// RequestsMetric counts HTTP requests by method, endpoint and status code.
+	RequestsMetric = "hydra_http_requests_total"
+
+	// RequestDurationMetric is a histogram of HTTP request durations in seconds by method and endpoint.
+	RequestDurationMetric = "hydra_http_request_duration_seconds"
+
+	// ExemplarHeader is the request header whose value is attached as exemplar to request duration buckets.
+	ExemplarHeader = "X-Request-ID"
+)
+
+// DurationBuckets are the upper bounds of the request duration histogram in seconds.
+var DurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type endpoint struct {
+	method string
+	path   string
+}
+
+type exemplar struct {
+	requestID string
+	value     float64
+	at        time.Time
+}
+
+type endpointStatistics struct {
+	codes     map[int]uint64
+	buckets   []uint64
+	exemplars []*exemplar
+	sum       float64
+	count     uint64
+}
+
+// RequestStatistics records the rate, errors and duration of HTTP requests per endpoint and exposes them in the
+// Prometheus text format.
+type RequestStatistics struct {
+	sync.RWMutex
+	endpoints map[endpoint]*endpointStatistics
+}
+
+func NewRequestStatistics() *RequestStatistics {
+	return &RequestStatistics{endpoints: map[endpoint]*endpointStatistics{}}
+}
+
+func (rs *RequestStatistics) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	start := time.Now()
+	next(rw, r)
+
+	status := http.StatusOK
+	if res, ok := rw.(negroni.ResponseWriter); ok && res.Status() != 0 {
+		status = res.Status()
+	}
+
+	rs.Record(r.Method, EndpointPath(r.URL.Path), status, time.Since(start), r.Header.Get(ExemplarHeader))
+}
+
+// Record adds a request to the statistics. If requestID is not empty, it is stored as exemplar of the duration bucket
+// the request falls into.
+func (rs *RequestStatistics) Record(method, path string, status int, duration time.Duration, requestID string) {
+	rs.Lock()
+	defer rs.Unlock()
+
+	key := endpoint{method: method, path: path}
+	es, ok := rs.endpoints[key]
+	if !ok {
+		es = &endpointStatistics{
+			codes:     map[int]uint64{},
+			buckets:   make([]uint64, len(DurationBuckets)),
+			exemplars: make([]*exemplar, len(DurationBuckets)+1),
+		}
+		rs.endpoints[key] = es
+	}
+
+	seconds := duration.Seconds()
+	es.codes[status]++
+	es.sum += seconds
+	es.count++
+
+	bucket := len(DurationBuckets)
+	for i, le := range DurationBuckets {
+		if seconds <= le {
+			es.buckets[i]++
+			if i < bucket {
+				bucket = i
+			}
+		}
+	}
+
+	if requestID != "" && len(requestID) <= 64 {
+		es.exemplars[bucket] = &exemplar{requestID: requestID, value: seconds, at: time.Now()}
+	}
+}
+
+// WritePrometheus writes the statistics in the Prometheus text exposition format. If openMetrics is true, the
+// OpenMetrics format is used instead, which includes exemplars.
+func (rs *RequestStatistics) WritePrometheus(w io.Writer, openMetrics bool) error {
+	rs.RLock()
+	defer rs.RUnlock()
+
+	keys := make([]endpoint, 0, len(rs.endpoints))
+	for key := range rs.endpoints {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].path == keys[j].path {
+			return keys[i].method < keys[j].method
+		}
+		return keys[i].path < keys[j].path
+	})
+
+	var b bytes.Buffer
+	requestsType := RequestsMetric
+	if openMetrics {
+		requestsType = strings.TrimSuffix(RequestsMetric, "_total")
+	}
+
+	fmt.Fprintf(&b, "# HELP %s Total number of HTTP requests by method, endpoint and status code.\n", requestsType)
+	fmt.Fprintf(&b, "# TYPE %s counter\n", requestsType)
+	for _, key := range keys {
+		es := rs.endpoints[key]
+		codes := make([]int, 0, len(es.codes))
+		for code := range es.codes {
+			codes = append(codes, code)
+		}
+		sort.Ints(codes)
+		for _, code := range codes {
+			fmt.Fprintf(&b, "%s{method=%q,endpoint=%q,code=\"%d\"} %d\n", RequestsMetric, key.method, key.path, code, es.codes[code])
+		}
+	}
+
+	fmt.Fprintf(&b, "# HELP %s Duration of HTTP requests in seconds by method and endpoint.\n", RequestDurationMetric)
+	fmt.Fprintf(&b, "# TYPE %s histogram\n", RequestDurationMetric)
+	for _, key := range keys {
+		es := rs.endpoints[key]
+		labels := fmt.Sprintf("method=%q,endpoint=%q", key.method, key.path)
+		for i, le := range DurationBuckets {
+			fmt.Fprintf(&b, "%s_bucket{%s,le=%q} %d%s\n", RequestDurationMetric, labels, formatFloat(le), es.buckets[i], formatExemplar(es.exemplars[i], openMetrics))
+		}
+		fmt.Fprintf(&b, "%s_bucket{%s,le=\"+Inf\"} %d%s\n", RequestDurationMetric, labels, es.count, formatExemplar(es.exemplars[len(DurationBuckets)], openMetrics))
+		fmt.Fprintf(&b, "%s_sum{%s} %s\n", RequestDurationMetric, labels, formatFloat(es.sum))
+		fmt.Fprintf(&b, "%s_count{%s} %d\n", RequestDurationMetric, labels, es.count)
+	}
+
+	if openMetrics {
+		b.WriteString("# EOF\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func formatExemplar(e *exemplar, openMetrics bool) string {
+	if e == nil || !openMetrics {
+		return ""
+	}
+	return fmt.Sprintf(" # {request_id=%q} %s %s", e.requestID, formatFloat(e.value), formatFloat(float64(e.at.UnixNano())/1e9))
+}
+
+// EndpointPath maps a request path to the endpoint it belongs to, so that ids in the path do not end up as labels.
+func EndpointPath(path string) string {
+	path = strings.ToLower(path)
+	for _, p := range endpointPaths {
+		p = strings.ToLower(p)
+		if path == p {
+			return p
+		} else if strings.HasPrefix(path, p+"/") {
+			return p + "/*"
+		}
+	}
+	return "other"
+}
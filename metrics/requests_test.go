@@ -0,0 +1,85 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/negroni"
+)
+
+func TestEndpointPath(t *testing.T) {
+	assert.Equal(t, "/clients", EndpointPath("/clients"))
+	assert.Equal(t, "/keys/*", EndpointPath("/keys/foo/bar"))
+	assert.Equal(t, "/oauth2/introspect", EndpointPath("/oauth2/introspect"))
+	assert.Equal(t, "/oauth2/introspect/history", EndpointPath("/oauth2/introspect/history"))
+	assert.Equal(t, "/oauth2/auth/resume", EndpointPath("/oauth2/auth/resume"))
+	assert.Equal(t, "/", EndpointPath("/"))
+	assert.Equal(t, "other", EndpointPath("/does-not-exist"))
+}
+
+func TestRequestStatistics(t *testing.T) {
+	rs := NewRequestStatistics()
+	rs.Record("GET", "/clients", http.StatusOK, time.Millisecond*20, "request-1")
+	rs.Record("GET", "/clients", http.StatusInternalServerError, time.Second*20, "")
+	rs.Record("POST", "/oauth2/token", http.StatusOK, time.Millisecond, "")
+
+	var out bytes.Buffer
+	require.NoError(t, rs.WritePrometheus(&out, false))
+	text := out.String()
+
+	assert.Contains(t, text, "# TYPE hydra_http_requests_total counter\n")
+	assert.Contains(t, text, `hydra_http_requests_total{method="GET",endpoint="/clients",code="200"} 1`+"\n")
+	assert.Contains(t, text, `hydra_http_requests_total{method="GET",endpoint="/clients",code="500"} 1`+"\n")
+	assert.Contains(t, text, `hydra_http_request_duration_seconds_bucket{method="GET",endpoint="/clients",le="0.01"} 0`+"\n")
+	assert.Contains(t, text, `hydra_http_request_duration_seconds_bucket{method="GET",endpoint="/clients",le="0.025"} 1`+"\n")
+	assert.Contains(t, text, `hydra_http_request_duration_seconds_bucket{method="GET",endpoint="/clients",le="10"} 1`+"\n")
+	assert.Contains(t, text, `hydra_http_request_duration_seconds_bucket{method="GET",endpoint="/clients",le="+Inf"} 2`+"\n")
+	assert.Contains(t, text, `hydra_http_request_duration_seconds_count{method="POST",endpoint="/oauth2/token"} 1`+"\n")
+	assert.NotContains(t, text, "request-1")
+	assert.NotContains(t, text, "# EOF")
+
+	out.Reset()
+	require.NoError(t, rs.WritePrometheus(&out, true))
+	text = out.String()
+
+	assert.Contains(t, text, "# TYPE hydra_http_requests counter\n")
+	assert.Contains(t, text, `hydra_http_request_duration_seconds_bucket{method="GET",endpoint="/clients",le="0.025"} 1 # {request_id="request-1"} 0.02 `)
+	assert.True(t, strings.HasSuffix(text, "# EOF\n"))
+}
+
+func TestRequestStatisticsMiddleware(t *testing.T) {
+	rs := NewRequestStatistics()
+	n := negroni.New(rs)
+	n.UseHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+
+	req := httptest.NewRequest("GET", "/clients/foo", nil)
+	req.Header.Set(ExemplarHeader, "request-1")
+	n.ServeHTTP(httptest.NewRecorder(), req)
+
+	var out bytes.Buffer
+	require.NoError(t, rs.WritePrometheus(&out, true))
+	assert.Contains(t, out.String(), `hydra_http_requests_total{method="GET",endpoint="/clients/*",code="404"} 1`)
+	assert.Contains(t, out.String(), `request_id="request-1"`)
+}
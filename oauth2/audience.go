@@ -0,0 +1,75 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"strings"
+
+	"github.com/ory/fosite"
+	"github.com/ory/hydra/client"
+	"github.com/pkg/errors"
+)
+
+// requestedAudience returns the audiences of the token request. The audience parameter may be repeated or contain a
+// space-separated list of audiences.
+func requestedAudience(ar fosite.Requester) []string {
+	var audience []string
+	for _, value := range ar.GetRequestForm()["audience"] {
+		for _, aud := range strings.Fields(value) {
+			if !stringInSlice(aud, audience) {
+				audience = append(audience, aud)
+			}
+		}
+	}
+	return audience
+}
+
+// grantAudience checks the audiences of the token request against the audiences the client is allowed to request and
+// restricts the access tokens issued for the session to them. If the request has no audience parameter, the session
+// keeps the audiences granted before, for example to the refresh token.
+func grantAudience(ar fosite.AccessRequester) error {
+	audience := requestedAudience(ar)
+	if len(audience) == 0 {
+		return nil
+	}
+
+	allowed := allowedAudience(ar.GetClient())
+	for _, aud := range audience {
+		if !stringInSlice(aud, allowed) {
+			return errors.Wrapf(fosite.ErrInvalidRequest, "The client is not allowed to request audience \"%s\"", aud)
+		}
+	}
+
+	if hs, ok := ar.GetSession().(*Session); ok {
+		hs.Audience = audience
+	}
+	return nil
+}
+
+// allowedAudience returns the audiences the client may request access tokens for.
+func allowedAudience(c fosite.Client) []string {
+	if c, ok := c.(*client.Client); ok {
+		return c.Audience
+	}
+	return nil
+}
+
+// tokenAudience returns the audiences of access tokens issued for the session, which default to the client id.
+func tokenAudience(session fosite.Session, clientID string) []string {
+	if hs, ok := session.(*Session); ok && len(hs.Audience) > 0 {
+		return hs.Audience
+	}
+	return []string{clientID}
+}
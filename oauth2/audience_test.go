@@ -0,0 +1,67 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/ory/fosite"
+	"github.com/ory/hydra/client"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGrantAudience(t *testing.T) {
+	allowed := []string{"https://api.localhost", "https://billing.localhost"}
+	for k, tc := range []struct {
+		form      url.Values
+		client    fosite.Client
+		previous  []string
+		expected  []string
+		expectErr bool
+	}{
+		{form: url.Values{}, client: &client.Client{Audience: allowed}},
+		{form: url.Values{}, client: &client.Client{Audience: allowed}, previous: []string{"https://api.localhost"}, expected: []string{"https://api.localhost"}},
+		{form: url.Values{"audience": {"https://api.localhost"}}, client: &client.Client{Audience: allowed}, expected: []string{"https://api.localhost"}},
+		{form: url.Values{"audience": {"https://api.localhost https://billing.localhost"}}, client: &client.Client{Audience: allowed}, expected: allowed},
+		{form: url.Values{"audience": {"https://api.localhost", "https://billing.localhost", "https://api.localhost"}}, client: &client.Client{Audience: allowed}, expected: allowed},
+		{form: url.Values{"audience": {"https://billing.localhost"}}, client: &client.Client{Audience: allowed}, previous: allowed, expected: []string{"https://billing.localhost"}},
+		{form: url.Values{"audience": {"https://other.localhost"}}, client: &client.Client{Audience: allowed}, expectErr: true},
+		{form: url.Values{"audience": {"https://api.localhost https://other.localhost"}}, client: &client.Client{Audience: allowed}, expectErr: true},
+		{form: url.Values{"audience": {"https://api.localhost"}}, client: &client.Client{}, expectErr: true},
+		{form: url.Values{"audience": {"https://api.localhost"}}, client: &fosite.DefaultClient{}, expectErr: true},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			session := NewSession("peter")
+			session.Audience = tc.previous
+			ar := &fosite.AccessRequest{Request: fosite.Request{Form: tc.form, Client: tc.client, Session: session}}
+
+			err := grantAudience(ar)
+			if tc.expectErr {
+				assert.Equal(t, fosite.ErrInvalidRequest, errors.Cause(err))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, session.Audience)
+		})
+	}
+}
+
+func TestTokenAudience(t *testing.T) {
+	assert.Equal(t, []string{"client"}, tokenAudience(NewSession("peter"), "client"))
+	assert.Equal(t, []string{"https://api.localhost"}, tokenAudience(&Session{Audience: []string{"https://api.localhost"}}, "client"))
+}
@@ -0,0 +1,139 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/ory/fosite"
+	"github.com/ory/hydra/events"
+	"github.com/pkg/errors"
+)
+
+// ClaimsHookRequest describes a token which is about to be issued.
+type ClaimsHookRequest struct {
+	// Subject is the subject of the token.
+	Subject string `json:"subject"`
+
+	// ClientID is the id of the client the token is issued to.
+	ClientID string `json:"clientId"`
+
+	// GrantedScopes are the scopes granted to the client.
+	GrantedScopes []string `json:"grantedScopes"`
+
+	// GrantTypes are the grant types of the token request. They are empty for tokens issued by the authorization
+	// endpoint.
+	GrantTypes []string `json:"grantTypes"`
+
+	// AccessTokenExtra and IDTokenExtra are the claims which are currently set, for example by the consent app.
+	AccessTokenExtra map[string]interface{} `json:"accessTokenExtra"`
+	IDTokenExtra     map[string]interface{} `json:"idTokenExtra"`
+}
+
+// ClaimsHookResponse holds claims which are added to the tokens. Claims which are already set are overwritten.
+type ClaimsHookResponse struct {
+	AccessTokenExtra map[string]interface{} `json:"accessTokenExtra"`
+	IDTokenExtra     map[string]interface{} `json:"idTokenExtra"`
+}
+
+// ClaimsHook is invoked before tokens are issued and may add custom claims, for example a tenant id or roles, to ID
+// tokens and access tokens.
+type ClaimsHook interface {
+	Claims(ctx context.Context, r *ClaimsHookRequest) (*ClaimsHookResponse, error)
+}
+
+// WebhookClaimsHook asks for custom claims by sending the ClaimsHookRequest as JSON in a POST request to URL and
+// expects a ClaimsHookResponse as JSON in return. If Secret is set, the request body is signed with it, see
+// events.SignatureHeader.
+type WebhookClaimsHook struct {
+	URL    string
+	Secret []byte
+	Client *http.Client
+}
+
+func (h *WebhookClaimsHook) Claims(ctx context.Context, r *ClaimsHookRequest) (*ClaimsHookResponse, error) {
+	out, err := json.Marshal(r)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	c := h.Client
+	if c == nil {
+		c = http.DefaultClient
+	}
+
+	req, err := http.NewRequest("POST", h.URL, bytes.NewReader(out))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	if len(h.Secret) > 0 {
+		req.Header.Set(events.SignatureHeader, events.Sign(h.Secret, out))
+	}
+
+	res, err := c.Do(req)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, errors.Errorf("Expected 2xx status code from claims hook %s but got %d", h.URL, res.StatusCode)
+	}
+
+	var claims ClaimsHookResponse
+	if err := json.NewDecoder(res.Body).Decode(&claims); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &claims, nil
+}
+
+// applyClaimsHook adds the claims returned by the ClaimsHook to the session of the token which is about to be issued.
+func (h *Handler) applyClaimsHook(ctx context.Context, requester fosite.Requester, grantTypes []string, session *Session) error {
+	if h.ClaimsHook == nil {
+		return nil
+	}
+
+	if session.Extra == nil {
+		session.Extra = map[string]interface{}{}
+	}
+	if session.Claims.Extra == nil {
+		session.Claims.Extra = map[string]interface{}{}
+	}
+
+	claims, err := h.ClaimsHook.Claims(ctx, &ClaimsHookRequest{
+		Subject:          session.GetSubject(),
+		ClientID:         requester.GetClient().GetID(),
+		GrantedScopes:    requester.GetGrantedScopes(),
+		GrantTypes:       grantTypes,
+		AccessTokenExtra: session.Extra,
+		IDTokenExtra:     session.Claims.Extra,
+	})
+	if err != nil {
+		return errors.Wrap(fosite.ErrServerError, err.Error())
+	}
+
+	for k, v := range claims.AccessTokenExtra {
+		session.Extra[k] = v
+	}
+	for k, v := range claims.IDTokenExtra {
+		session.Claims.Extra[k] = v
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ory/fosite"
+	"github.com/ory/hydra/client"
+	"github.com/ory/hydra/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookClaimsHook(t *testing.T) {
+	secret := []byte("secret")
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		assert.True(t, events.VerifySignature(secret, body, r.Header.Get(events.SignatureHeader)))
+
+		var req ClaimsHookRequest
+		require.NoError(t, json.Unmarshal(body, &req))
+		assert.Equal(t, "peter", req.Subject)
+		assert.Equal(t, "app", req.ClientID)
+		assert.Equal(t, []string{"openid", "photos"}, req.GrantedScopes)
+		assert.Equal(t, "consent", req.IDTokenExtra["from"])
+
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(&ClaimsHookResponse{
+			AccessTokenExtra: map[string]interface{}{"tenant": "acme"},
+			IDTokenExtra:     map[string]interface{}{"roles": []string{"admin"}, "from": "hook"},
+		})
+	}))
+	defer server.Close()
+
+	h := &Handler{ClaimsHook: &WebhookClaimsHook{URL: server.URL, Secret: secret}}
+	newRequest := func() (*fosite.Request, *Session) {
+		session := NewSession("peter")
+		session.Claims.Extra = map[string]interface{}{"from": "consent"}
+		ar := fosite.NewRequest()
+		ar.Client = &client.Client{ID: "app"}
+		ar.GrantScope("openid")
+		ar.GrantScope("photos")
+		ar.Session = session
+		return ar, session
+	}
+
+	ar, session := newRequest()
+	require.NoError(t, h.applyClaimsHook(context.Background(), ar, nil, session))
+	assert.Equal(t, "acme", session.Extra["tenant"])
+	assert.Equal(t, []interface{}{"admin"}, session.Claims.Extra["roles"])
+	assert.Equal(t, "hook", session.Claims.Extra["from"])
+
+	status = http.StatusInternalServerError
+	ar, session = newRequest()
+	err := h.applyClaimsHook(context.Background(), ar, nil, session)
+	require.Error(t, err)
+	assert.Empty(t, session.Extra)
+}
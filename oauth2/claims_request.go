@@ -0,0 +1,64 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"encoding/json"
+
+	"github.com/ory/fosite"
+	"github.com/pkg/errors"
+)
+
+// ClaimsRequest is the claims parameter of an authorization request, which asks for individual claims to be returned
+// in the ID token or by the userinfo endpoint, see OpenID Connect Core 1.0 Section 5.5.
+//
+// swagger:model claimsRequest
+type ClaimsRequest struct {
+	// Userinfo are the claims requested from the userinfo endpoint, keyed by claim name.
+	Userinfo map[string]*ClaimRequest `json:"userinfo,omitempty"`
+
+	// IDToken are the claims requested in the ID token, keyed by claim name.
+	IDToken map[string]*ClaimRequest `json:"id_token,omitempty"`
+}
+
+// ClaimRequest describes how a claim is requested. Claims requested as null are voluntary claims without further
+// requirements.
+//
+// swagger:model claimRequest
+type ClaimRequest struct {
+	// Essential is true if the claim is needed for the client to work as intended, for example to provide the
+	// service the user asked for.
+	Essential bool `json:"essential,omitempty"`
+
+	// Value is the value the claim is requested to have.
+	Value interface{} `json:"value,omitempty"`
+
+	// Values lists the values the claim is requested to have one of, in order of preference.
+	Values []interface{} `json:"values,omitempty"`
+}
+
+// parseClaimsRequest returns the claims parameter of the authorization request or nil if it is not set.
+func parseClaimsRequest(ar fosite.AuthorizeRequester) (*ClaimsRequest, error) {
+	value := ar.GetRequestForm().Get("claims")
+	if value == "" {
+		return nil, nil
+	}
+
+	var claims ClaimsRequest
+	if err := json.Unmarshal([]byte(value), &claims); err != nil {
+		return nil, errors.Wrapf(fosite.ErrInvalidRequest, "Parameter claims is not a valid JSON object: %s", err)
+	}
+	return &claims, nil
+}
@@ -0,0 +1,48 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/ory/fosite"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseClaimsRequest(t *testing.T) {
+	newRequest := func(form url.Values) *fosite.AuthorizeRequest {
+		return &fosite.AuthorizeRequest{Request: fosite.Request{Form: form}}
+	}
+
+	claims, err := parseClaimsRequest(newRequest(url.Values{}))
+	require.NoError(t, err)
+	assert.Nil(t, claims)
+
+	claims, err = parseClaimsRequest(newRequest(url.Values{"claims": {`{
+		"userinfo": {"email": {"essential": true}, "picture": null},
+		"id_token": {"acr": {"values": ["urn:mace:incommon:iap:silver"]}}
+	}`}}))
+	require.NoError(t, err)
+	assert.Equal(t, &ClaimsRequest{
+		Userinfo: map[string]*ClaimRequest{"email": {Essential: true}, "picture": nil},
+		IDToken:  map[string]*ClaimRequest{"acr": {Values: []interface{}{"urn:mace:incommon:iap:silver"}}},
+	}, claims)
+
+	_, err = parseClaimsRequest(newRequest(url.Values{"claims": {`["email"]`}}))
+	assert.Equal(t, fosite.ErrInvalidRequest, errors.Cause(err))
+}
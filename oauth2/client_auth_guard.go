@@ -0,0 +1,140 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ory/hydra/ratelimit"
+)
+
+const clientAuthGuardSweepInterval = time.Minute
+
+// DefaultClientAuthGuardMaxEntries is the number of client id and IP address pairs ClientAuthGuard counts attempts
+// for unless configured otherwise.
+const DefaultClientAuthGuardMaxEntries = 100000
+
+// ClientAuthGuard counts failed client authentication attempts per client id and IP address. Once MaxFailures
+// attempts failed within Window, further attempts from that IP address are rejected for Lockout, even if they use the
+// correct secret. Attempts are counted in memory, so every instance enforces the lockout on its own.
+type ClientAuthGuard struct {
+	MaxFailures int
+	Window      time.Duration
+	Lockout     time.Duration
+
+	// TrustedProxies are the networks of proxies whose X-Forwarded-For header is used to find the IP address of the
+	// client.
+	TrustedProxies []*net.IPNet
+
+	// MaxEntries is the number of client id and IP address pairs attempts are counted for and defaults to
+	// DefaultClientAuthGuardMaxEntries. Once reached, failed attempts of further pairs are not counted until
+	// entries expire, so that random client ids can not grow the memory without limit.
+	MaxEntries int
+
+	sync.Mutex
+	attempts  map[string]*clientAuthAttempts
+	lastSweep time.Time
+}
+
+type clientAuthAttempts struct {
+	failures    int
+	windowEnd   time.Time
+	lockedUntil time.Time
+}
+
+func (g *ClientAuthGuard) key(r *http.Request, clientID string) string {
+	return clientID + "|" + ratelimit.RemoteIP(r, g.TrustedProxies)
+}
+
+// Locked returns how long attempts of the client from the request's IP address remain locked out, or zero if they
+// are not.
+func (g *ClientAuthGuard) Locked(r *http.Request, clientID string) time.Duration {
+	now := time.Now().UTC()
+
+	g.Lock()
+	defer g.Unlock()
+
+	a, ok := g.attempts[g.key(r, clientID)]
+	if !ok || !now.Before(a.lockedUntil) {
+		return 0
+	}
+	return a.lockedUntil.Sub(now)
+}
+
+// Fail counts a failed attempt of the client from the request's IP address. It returns true if the attempt caused a
+// lockout.
+func (g *ClientAuthGuard) Fail(r *http.Request, clientID string) bool {
+	now := time.Now().UTC()
+	key := g.key(r, clientID)
+
+	g.Lock()
+	defer g.Unlock()
+
+	if g.attempts == nil {
+		g.attempts = map[string]*clientAuthAttempts{}
+	}
+	g.sweep(now)
+
+	a, ok := g.attempts[key]
+	if !ok && len(g.attempts) >= g.maxEntries() {
+		return false
+	}
+	if !ok || !now.Before(a.windowEnd) {
+		a = &clientAuthAttempts{windowEnd: now.Add(g.Window)}
+		g.attempts[key] = a
+	}
+
+	a.failures++
+	if a.failures < g.MaxFailures {
+		return false
+	}
+
+	a.failures = 0
+	a.lockedUntil = now.Add(g.Lockout)
+	a.windowEnd = a.lockedUntil
+	return true
+}
+
+// Succeed forgets the failed attempts of the client from the request's IP address.
+func (g *ClientAuthGuard) Succeed(r *http.Request, clientID string) {
+	key := g.key(r, clientID)
+
+	g.Lock()
+	defer g.Unlock()
+	delete(g.attempts, key)
+}
+
+func (g *ClientAuthGuard) maxEntries() int {
+	if g.MaxEntries <= 0 {
+		return DefaultClientAuthGuardMaxEntries
+	}
+	return g.MaxEntries
+}
+
+func (g *ClientAuthGuard) sweep(now time.Time) {
+	if now.Sub(g.lastSweep) < clientAuthGuardSweepInterval {
+		return
+	}
+
+	for key, a := range g.attempts {
+		if !now.Before(a.windowEnd) && !now.Before(a.lockedUntil) {
+			delete(g.attempts, key)
+		}
+	}
+	g.lastSweep = now
+}
@@ -0,0 +1,63 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientAuthGuard(t *testing.T) {
+	g := &ClientAuthGuard{MaxFailures: 3, Window: time.Hour, Lockout: time.Hour}
+	request := func(remoteAddr string) *http.Request {
+		r := httptest.NewRequest("POST", TokenPath, nil)
+		r.RemoteAddr = remoteAddr
+		return r
+	}
+
+	foo := request("1.2.3.4:1234")
+	assert.False(t, g.Fail(foo, "foo"))
+	assert.False(t, g.Fail(foo, "foo"))
+	assert.EqualValues(t, 0, g.Locked(foo, "foo"))
+
+	g.Succeed(foo, "foo")
+	assert.False(t, g.Fail(foo, "foo"))
+	assert.False(t, g.Fail(foo, "foo"))
+	assert.True(t, g.Fail(foo, "foo"))
+
+	locked := g.Locked(foo, "foo")
+	assert.True(t, locked > 0 && locked <= time.Hour, "%s", locked)
+
+	// Other clients and other IP addresses are not locked out.
+	assert.EqualValues(t, 0, g.Locked(foo, "bar"))
+	assert.EqualValues(t, 0, g.Locked(request("4.3.2.1:1234"), "foo"))
+
+	short := &ClientAuthGuard{MaxFailures: 1, Window: time.Hour, Lockout: time.Millisecond * 50}
+	assert.True(t, short.Fail(foo, "foo"))
+	assert.True(t, short.Locked(foo, "foo") > 0)
+	time.Sleep(time.Millisecond * 60)
+	assert.EqualValues(t, 0, short.Locked(foo, "foo"))
+
+	full := &ClientAuthGuard{MaxFailures: 1, Window: time.Hour, Lockout: time.Hour, MaxEntries: 2}
+	assert.True(t, full.Fail(foo, "foo"))
+	assert.True(t, full.Fail(foo, "bar"))
+	assert.False(t, full.Fail(foo, "baz"))
+	assert.EqualValues(t, 0, full.Locked(foo, "baz"))
+	assert.True(t, full.Locked(foo, "foo") > 0)
+}
@@ -0,0 +1,178 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"net/http"
+
+	"github.com/ory/fosite"
+	"github.com/ory/hydra/client"
+	"github.com/ory/hydra/jwk"
+	"github.com/ory/hydra/pkg"
+	"github.com/pkg/errors"
+)
+
+// fosite only supports authenticating clients with a secret. Clients using tls_client_auth or
+// self_signed_tls_client_auth are therefore authenticated by the token handler, which then passes a random, single-use proof to fosite in place of
+// the client secret. TLSClientAuthStorage and TLSClientAuthHasher make fosite accept that proof.
+
+type tlsClientAuthContextKey struct{}
+
+type tlsClientAuthProof struct {
+	clientID string
+	secret   []byte
+}
+
+var tlsClientAuthProofPrefix = []byte("tls_client_auth:")
+
+// TLSClientAuthStorage wraps a FositeStorer and returns clients that were authenticated using a TLS client
+// certificate with the proof of that authentication in place of the hashed secret.
+type TLSClientAuthStorage struct {
+	pkg.FositeStorer
+}
+
+func (s *TLSClientAuthStorage) GetClient(ctx context.Context, id string) (fosite.Client, error) {
+	c, err := s.FositeStorer.GetClient(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if proof, ok := ctx.Value(tlsClientAuthContextKey{}).(*tlsClientAuthProof); ok && proof.clientID == id {
+		hash := append(append([]byte{}, tlsClientAuthProofPrefix...), proof.secret...)
+
+		// Hydra's clients are copied rather than wrapped, so that the client of the request can still be asserted to
+		// be a *client.Client.
+		if hc, ok := c.(*client.Client); ok {
+			authenticated := *hc
+			authenticated.Secret = string(hash)
+			return &authenticated, nil
+		}
+		return &tlsAuthenticatedClient{Client: c, proof: hash}, nil
+	}
+
+	return c, nil
+}
+
+type tlsAuthenticatedClient struct {
+	fosite.Client
+	proof []byte
+}
+
+func (c *tlsAuthenticatedClient) GetHashedSecret() []byte {
+	return c.proof
+}
+
+// TLSClientAuthHasher wraps a fosite.Hasher and accepts the proof returned by TLSClientAuthStorage.
+type TLSClientAuthHasher struct {
+	fosite.Hasher
+}
+
+func (h *TLSClientAuthHasher) Compare(hash, data []byte) error {
+	if !bytes.HasPrefix(hash, tlsClientAuthProofPrefix) {
+		return h.Hasher.Compare(hash, data)
+	}
+
+	if subtle.ConstantTimeCompare(hash[len(tlsClientAuthProofPrefix):], data) != 1 {
+		return errors.WithStack(fosite.ErrInvalidClient)
+	}
+	return nil
+}
+
+// authenticateTLSClient authenticates clients using tls_client_auth or self_signed_tls_client_auth. If the client
+// presented a certificate matching the one pinned or the subject registered for it, the returned context and request
+// carry a proof that TLSClientAuthStorage and TLSClientAuthHasher accept as the client secret. Requests that do not
+// use mutual TLS client authentication are returned unchanged.
+func (h *Handler) authenticateTLSClient(ctx context.Context, r *http.Request) (context.Context, *http.Request, error) {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return ctx, r, nil
+	} else if _, _, ok := r.BasicAuth(); ok {
+		return ctx, r, nil
+	}
+
+	if err := r.ParseForm(); err != nil {
+		return ctx, r, errors.WithStack(fosite.ErrInvalidRequest)
+	}
+
+	id := r.PostForm.Get("client_id")
+	if id == "" {
+		return ctx, r, nil
+	}
+
+	fc, err := h.Storage.GetClient(ctx, id)
+	if err != nil {
+		return ctx, r, nil
+	}
+
+	c, ok := fc.(*client.Client)
+	if !ok || !c.UsesTLSClientAuth() {
+		return ctx, r, nil
+	}
+
+	if c.TokenEndpointAuthMethod == client.TokenEndpointAuthMethodTLS {
+		if err := h.verifyTLSClientCertificateChain(r.TLS.PeerCertificates); err != nil {
+			return ctx, r, errors.Wrap(fosite.ErrInvalidClient, err.Error())
+		}
+	}
+
+	if err := c.VerifyTLSClientCertificate(r.TLS.PeerCertificates[0]); err != nil {
+		return ctx, r, errors.Wrap(fosite.ErrInvalidClient, err.Error())
+	}
+
+	secret, err := pkg.GenerateSecret(32)
+	if err != nil {
+		return ctx, r, errors.WithStack(err)
+	}
+
+	// The secret is passed through HTTP basic auth, encode it so that it does not contain a colon.
+	encoded := []byte(base64.RawURLEncoding.EncodeToString(secret))
+	ctx = context.WithValue(ctx, tlsClientAuthContextKey{}, &tlsClientAuthProof{clientID: id, secret: encoded})
+	r.SetBasicAuth(id, string(encoded))
+	return ctx, r, nil
+}
+
+// verifyTLSClientCertificateChain checks that the client certificate was issued by one of TLSClientAuthCAs. The TLS
+// handshake also accepts self-signed certificates, so the chain has to be verified again for tls_client_auth.
+func (h *Handler) verifyTLSClientCertificateChain(certs []*x509.Certificate) error {
+	if h.TLSClientAuthCAs == nil {
+		return errors.Errorf("No certificate authorities are trusted for %s", client.TokenEndpointAuthMethodTLS)
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	if _, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         h.TLSClientAuthCAs,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// bindToCertificate binds the access tokens issued for the session to the certificate the client authenticated with
+// by adding its "x5t#S256" thumbprint to the cnf claim, see IETF RFC 8705 Section 3.
+func bindToCertificate(session fosite.Session, cert *x509.Certificate) {
+	if hs, ok := session.(*Session); ok {
+		hs.Confirmation = map[string]interface{}{"x5t#S256": jwk.CertificateThumbprintSHA256(cert)}
+	}
+}
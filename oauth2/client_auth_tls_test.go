@@ -0,0 +1,130 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ory/fosite"
+	"github.com/ory/fosite/compose"
+	"github.com/ory/herodot"
+	"github.com/ory/hydra/client"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSelfSignedClientCertificate(t *testing.T) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "mtls-client"},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert
+}
+
+func TestTokenHandlerTLSClientAuth(t *testing.T) {
+	cert := newSelfSignedClientCertificate(t)
+	hash := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+
+	store := &FositeMemoryStore{
+		Manager:        client.NewMemoryManager(nil),
+		AuthorizeCodes: make(map[string]fosite.Requester),
+		IDSessions:     make(map[string]fosite.Requester),
+		AccessTokens:   make(map[string]fosite.Requester),
+		RefreshTokens:  make(map[string]fosite.Requester),
+	}
+	require.NoError(t, store.CreateClient(&client.Client{
+		ID:                           "mtls-client",
+		TokenEndpointAuthMethod:      client.TokenEndpointAuthMethodSelfSignedTLS,
+		TLSClientAuthPublicKeySHA256: base64.RawURLEncoding.EncodeToString(hash[:]),
+		GrantTypes:                   []string{"client_credentials"},
+		ResponseTypes:                []string{"token"},
+		Scope:                        "photos",
+		Audience:                     []string{"https://api.localhost"},
+		AccessTokenLifespan:          "5m",
+	}))
+
+	config := &compose.Config{AccessTokenLifespan: time.Hour, ScopeStrategy: fosite.HierarchicScopeStrategy}
+	h := &Handler{
+		OAuth2: compose.Compose(
+			config,
+			&TLSClientAuthStorage{FositeStorer: store},
+			&compose.CommonStrategy{CoreStrategy: compose.NewOAuth2HMACStrategy(config, []byte("1234567890123456789012345678901234567890"))},
+			&TLSClientAuthHasher{Hasher: &fosite.BCrypt{}},
+			func(config *compose.Config, storage interface{}, strategy interface{}) interface{} {
+				return &ClientLifespanHandler{}
+			},
+			compose.OAuth2ClientCredentialsGrantFactory,
+		),
+		Storage:       store,
+		ScopeStrategy: fosite.HierarchicScopeStrategy,
+		H:             herodot.NewJSONWriter(nil),
+		L:             logrus.New(),
+	}
+
+	form := url.Values{
+		"grant_type": {"client_credentials"},
+		"client_id":  {"mtls-client"},
+		"scope":      {"photos"},
+		"audience":   {"https://api.localhost"},
+	}
+	r := httptest.NewRequest("POST", "/oauth2/token", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+	w := httptest.NewRecorder()
+	h.TokenHandler(w, r, nil)
+	require.Equal(t, http.StatusOK, w.Code, "%s", w.Body.String())
+
+	var response struct {
+		ExpiresIn int64 `json:"expires_in"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.True(t, response.ExpiresIn > 0 && response.ExpiresIn <= 300, "expires in %d", response.ExpiresIn)
+
+	require.Len(t, store.AccessTokens, 1)
+	for _, ar := range store.AccessTokens {
+		session := ar.GetSession().(*Session)
+		assert.Equal(t, []string{"https://api.localhost"}, session.Audience)
+		assert.NotEmpty(t, session.Confirmation["x5t#S256"])
+		_, ok := ar.GetClient().(*client.Client)
+		assert.True(t, ok)
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"context"
+	"time"
+
+	"github.com/ory/fosite"
+	"github.com/ory/hydra/client"
+	"github.com/pkg/errors"
+)
+
+// ClientLifespanHandler applies the token lifespans clients configure for themselves to the tokens issued to them.
+// Access token, ID token and authorize code lifespans only shorten the lifespans configured for the server, while
+// the refresh token lifespan makes each refresh token expire after it. It must be composed before the handlers
+// issuing tokens.
+type ClientLifespanHandler struct{}
+
+func (h *ClientLifespanHandler) HandleAuthorizeEndpointRequest(ctx context.Context, ar fosite.AuthorizeRequester, resp fosite.AuthorizeResponder) error {
+	applyClientLifespans(ar.GetClient(), ar.GetSession())
+	return nil
+}
+
+func (h *ClientLifespanHandler) HandleTokenEndpointRequest(ctx context.Context, requester fosite.AccessRequester) error {
+	return errors.WithStack(fosite.ErrUnknownRequest)
+}
+
+// PopulateTokenEndpointResponse runs after all handlers handled the token request, when the session of the
+// authorize code or refresh token being exchanged has been restored, but before the tokens are issued.
+func (h *ClientLifespanHandler) PopulateTokenEndpointResponse(ctx context.Context, requester fosite.AccessRequester, responder fosite.AccessResponder) error {
+	if session := applyClientLifespans(requester.GetClient(), requester.GetSession()); session != nil {
+		if d, ok := session.Lifespans[fosite.RefreshToken]; ok {
+			session.DefaultSession.SetExpiresAt(fosite.RefreshToken, time.Now().UTC().Add(d))
+		}
+	}
+	return errors.WithStack(fosite.ErrUnknownRequest)
+}
+
+// applyClientLifespans stores the token lifespans of the client in the session and shortens the expiries which are
+// already set. It returns nil if the client or session are not hydra's.
+func applyClientLifespans(c fosite.Client, s fosite.Session) *Session {
+	hc, ok := c.(*client.Client)
+	if !ok {
+		return nil
+	}
+
+	session, ok := s.(*Session)
+	if !ok {
+		return nil
+	}
+
+	session.Lifespans = map[fosite.TokenType]time.Duration{}
+	for t, d := range map[fosite.TokenType]time.Duration{
+		fosite.AccessToken:   hc.GetAccessTokenLifespan(0),
+		fosite.RefreshToken:  hc.GetRefreshTokenLifespan(0),
+		fosite.AuthorizeCode: hc.GetAuthorizeCodeLifespan(0),
+	} {
+		if d > 0 {
+			session.Lifespans[t] = d
+		}
+	}
+
+	for _, t := range []fosite.TokenType{fosite.AccessToken, fosite.AuthorizeCode} {
+		if exp := session.GetExpiresAt(t); !exp.IsZero() {
+			session.SetExpiresAt(t, exp)
+		}
+	}
+
+	if d := hc.GetIDTokenLifespan(0); d > 0 && session.Claims != nil {
+		if max := time.Now().UTC().Add(d); session.Claims.ExpiresAt.IsZero() || session.Claims.ExpiresAt.After(max) {
+			session.Claims.ExpiresAt = max
+		}
+	}
+
+	return session
+}
@@ -0,0 +1,78 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ory/fosite"
+	"github.com/ory/hydra/client"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientLifespanHandler(t *testing.T) {
+	h := &ClientLifespanHandler{}
+	now := time.Now().UTC()
+	c := &client.Client{
+		ID:                    "short-lived",
+		AccessTokenLifespan:   "5m",
+		RefreshTokenLifespan:  "24h",
+		IDTokenLifespan:       "1m",
+		AuthorizeCodeLifespan: "30s",
+	}
+
+	t.Run("endpoint=authorize", func(t *testing.T) {
+		session := NewSession("peter")
+		session.Claims.ExpiresAt = now.Add(time.Hour)
+
+		ar := fosite.NewAuthorizeRequest()
+		ar.Client = c
+		ar.Session = session
+		require.NoError(t, h.HandleAuthorizeEndpointRequest(nil, ar, fosite.NewAuthorizeResponse()))
+		assert.WithinDuration(t, now.Add(time.Minute), session.Claims.ExpiresAt, time.Second)
+
+		session.SetExpiresAt(fosite.AuthorizeCode, now.Add(time.Minute*10))
+		assert.WithinDuration(t, now.Add(time.Second*30), session.GetExpiresAt(fosite.AuthorizeCode), time.Second)
+
+		session.SetExpiresAt(fosite.AccessToken, now.Add(time.Minute))
+		assert.WithinDuration(t, now.Add(time.Minute), session.GetExpiresAt(fosite.AccessToken), time.Second)
+	})
+
+	t.Run("endpoint=token", func(t *testing.T) {
+		session := NewSession("peter")
+		session.SetExpiresAt(fosite.AccessToken, now.Add(time.Hour))
+
+		ar := fosite.NewAccessRequest(session)
+		ar.Client = c
+		assert.Equal(t, fosite.ErrUnknownRequest, errors.Cause(h.HandleTokenEndpointRequest(nil, ar)))
+		assert.Equal(t, fosite.ErrUnknownRequest, errors.Cause(h.PopulateTokenEndpointResponse(nil, ar, fosite.NewAccessResponse())))
+		assert.WithinDuration(t, now.Add(time.Minute*5), session.GetExpiresAt(fosite.AccessToken), time.Second)
+		assert.WithinDuration(t, now.Add(time.Hour*24), session.GetExpiresAt(fosite.RefreshToken), time.Second)
+	})
+
+	t.Run("case=client without lifespans", func(t *testing.T) {
+		session := NewSession("peter")
+		session.SetExpiresAt(fosite.AccessToken, now.Add(time.Hour))
+
+		ar := fosite.NewAccessRequest(session)
+		ar.Client = &client.Client{ID: "default"}
+		assert.Equal(t, fosite.ErrUnknownRequest, errors.Cause(h.PopulateTokenEndpointResponse(nil, ar, fosite.NewAccessResponse())))
+		assert.Equal(t, now.Add(time.Hour), session.GetExpiresAt(fosite.AccessToken))
+		assert.True(t, session.GetExpiresAt(fosite.RefreshToken).IsZero())
+	})
+}
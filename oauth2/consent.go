@@ -15,11 +15,81 @@
 package oauth2
 
 import (
+	"time"
+
 	"github.com/gorilla/sessions"
 	"github.com/ory/fosite"
 )
 
+// ConsentStrategy performs the consent handshake of authorize requests. DefaultConsentStrategy is composed of a
+// ConsentChallengeIssuer, a ConsentResponseVerifier and a ConsentSessionBuilder, which can be replaced individually
+// to use another handshake transport without reimplementing the rest of the strategy.
 type ConsentStrategy interface {
 	ValidateConsentRequest(req fosite.AuthorizeRequester, session string, cookie *sessions.Session) (claims *Session, err error)
 	CreateConsentRequest(req fosite.AuthorizeRequester, redirectURL string, cookie *sessions.Session) (token string, err error)
+
+	// RememberedConsent returns the session of the consent the user agent's subject asked to remember for the client,
+	// or of the consent accepted on behalf of the subject if the client skips consent. It returns nil if the consent
+	// app must be asked.
+	RememberedConsent(req fosite.AuthorizeRequester, cookie *sessions.Session) (claims *Session, err error)
+}
+
+// LoginConsentStrategy is implemented by consent strategies supporting the two-step login and consent flow, see
+// LoginRequest.
+type LoginConsentStrategy interface {
+	// CreateLoggedInConsentRequest is CreateConsentRequest for the subject the login app authenticated when it
+	// accepted the login request.
+	CreateLoggedInConsentRequest(req fosite.AuthorizeRequester, redirectURL string, login *LoginRequest, cookie *sessions.Session) (challenge string, err error)
+
+	// SkippedLoggedInConsent accepts consent to a client which skips consent on behalf of the subject the login app
+	// authenticated, granting all requested scopes.
+	SkippedLoggedInConsent(req fosite.AuthorizeRequester, login *LoginRequest, cookie *sessions.Session) (*Session, error)
+}
+
+// ConsentChallengeIssuer starts the consent handshake of an authorize request.
+type ConsentChallengeIssuer interface {
+	// IssueConsentChallenge returns the challenge the user agent is redirected to the consent app with. The consent
+	// app must redirect the user agent back to redirectURL, appending the "consent" query parameter set to the
+	// challenge once the consent response can be verified.
+	IssueConsentChallenge(req fosite.AuthorizeRequester, redirectURL string, cookie *sessions.Session) (challenge string, err error)
+}
+
+// ConsentResponseVerifier completes the consent handshake of an authorize request.
+type ConsentResponseVerifier interface {
+	// VerifyConsentResponse returns the decision of the consent app for the challenge, or an error if consent was
+	// denied or the response can not be trusted.
+	VerifyConsentResponse(req fosite.AuthorizeRequester, challenge string, cookie *sessions.Session) (*ConsentDecision, error)
+}
+
+// ConsentSessionBuilder builds the session tokens are issued with once consent was given.
+type ConsentSessionBuilder interface {
+	BuildConsentSession(req fosite.AuthorizeRequester, decision *ConsentDecision, cookie *sessions.Session) (*Session, error)
+}
+
+// ConsentDecision is the verified outcome of a consent handshake.
+type ConsentDecision struct {
+	// Challenge is the challenge the decision was made for. It is empty for remembered consent.
+	Challenge string
+
+	// Subject is the subject which gave consent.
+	Subject string
+
+	// GrantedScopes are the scopes the subject granted to the client.
+	GrantedScopes []string
+
+	// IDTokenExtra and AccessTokenExtra are added to the ID token and the access token.
+	IDTokenExtra     map[string]interface{}
+	AccessTokenExtra map[string]interface{}
+
+	// UserinfoExtra is returned by the userinfo endpoint in addition to the claims of the ID token.
+	UserinfoExtra map[string]interface{}
+
+	// ACR and AMR are added to the ID token as the "acr" and "amr" claims, if set.
+	ACR string
+	AMR []string
+
+	// AuthTime is the time the subject authenticated and RequestedAt the time the authorization request was made.
+	// Both default to the time the decision is validated.
+	AuthTime    time.Time
+	RequestedAt time.Time
 }
@@ -0,0 +1,86 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"sort"
+	"time"
+)
+
+// ConsentGrant is the consent a subject has given to a client, accumulated over all accepted consent requests.
+//
+// swagger:model consentGrant
+type ConsentGrant struct {
+	// Subject is the subject which granted the scopes.
+	Subject string `json:"subject"`
+
+	// ClientID is the id of the client the scopes were granted to.
+	ClientID string `json:"clientId"`
+
+	// GrantedScopes are all scopes the subject has granted to the client.
+	GrantedScopes []string `json:"grantedScopes"`
+
+	// GrantedAt is the time the subject first granted scopes to the client.
+	GrantedAt time.Time `json:"grantedAt"`
+
+	// UpdatedAt is the time the subject last accepted a consent request of the client.
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// ConsentGrantManager keeps the consent subjects have given to clients, so that subjects can review and revoke it.
+type ConsentGrantManager interface {
+	// RecordConsentGrant adds scopes to the grant of the subject to the client, creating the grant if it does not
+	// exist.
+	RecordConsentGrant(subject, clientID string, scopes []string, at time.Time) error
+
+	// GetConsentGrants returns the grants of the subject, sorted by client id.
+	GetConsentGrants(subject string) ([]ConsentGrant, error)
+
+	// DeleteConsentGrant removes the grant of the subject to the client. It returns pkg.ErrNotFound if the subject
+	// has not granted any scopes to the client.
+	DeleteConsentGrant(subject, clientID string) error
+}
+
+// ConsentGrantRecorder records the granted scopes of consent requests in Grants when they are accepted.
+type ConsentGrantRecorder struct {
+	ConsentRequestManager
+	Grants ConsentGrantManager
+}
+
+func (r *ConsentGrantRecorder) AcceptConsentRequest(id string, payload *AcceptConsentRequestPayload) error {
+	request, err := r.ConsentRequestManager.GetConsentRequest(id)
+	if err != nil {
+		return err
+	}
+
+	if err := r.ConsentRequestManager.AcceptConsentRequest(id, payload); err != nil {
+		return err
+	}
+	return r.Grants.RecordConsentGrant(payload.Subject, request.ClientID, payload.GrantScopes, time.Now().UTC())
+}
+
+// mergeScopes returns the scopes contained in granted or scopes, sorted alphabetically. Empty scopes are dropped.
+func mergeScopes(granted, scopes []string) []string {
+	seen := map[string]bool{}
+	var merged []string
+	for _, scope := range append(append([]string{}, granted...), scopes...) {
+		if scope != "" && !seen[scope] {
+			seen[scope] = true
+			merged = append(merged, scope)
+		}
+	}
+	sort.Strings(merged)
+	return merged
+}
@@ -0,0 +1,82 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ory/hydra/pkg"
+	"github.com/pkg/errors"
+)
+
+type consentGrantKey struct {
+	subject  string
+	clientID string
+}
+
+type ConsentGrantMemoryManager struct {
+	grants map[consentGrantKey]ConsentGrant
+	sync.RWMutex
+}
+
+func NewConsentGrantMemoryManager() *ConsentGrantMemoryManager {
+	return &ConsentGrantMemoryManager{grants: map[consentGrantKey]ConsentGrant{}}
+}
+
+func (m *ConsentGrantMemoryManager) RecordConsentGrant(subject, clientID string, scopes []string, at time.Time) error {
+	m.Lock()
+	defer m.Unlock()
+
+	key := consentGrantKey{subject: subject, clientID: clientID}
+	grant, ok := m.grants[key]
+	if !ok {
+		grant = ConsentGrant{Subject: subject, ClientID: clientID, GrantedAt: at.UTC()}
+	}
+	grant.GrantedScopes = mergeScopes(grant.GrantedScopes, scopes)
+	grant.UpdatedAt = at.UTC()
+	m.grants[key] = grant
+	return nil
+}
+
+func (m *ConsentGrantMemoryManager) GetConsentGrants(subject string) ([]ConsentGrant, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	grants := []ConsentGrant{}
+	for key, grant := range m.grants {
+		if key.subject == subject {
+			grants = append(grants, grant)
+		}
+	}
+
+	sort.Slice(grants, func(i, j int) bool {
+		return grants[i].ClientID < grants[j].ClientID
+	})
+	return grants, nil
+}
+
+func (m *ConsentGrantMemoryManager) DeleteConsentGrant(subject, clientID string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	key := consentGrantKey{subject: subject, clientID: clientID}
+	if _, ok := m.grants[key]; !ok {
+		return errors.WithStack(pkg.ErrNotFound)
+	}
+	delete(m.grants, key)
+	return nil
+}
@@ -0,0 +1,108 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/ory/hydra/pkg"
+	"github.com/pkg/errors"
+)
+
+// redisConsentGrantKey is a hash containing the JSON encoded consent grants of the subject, indexed by client id.
+func redisConsentGrantKey(subject string) string {
+	return "hydra:consent:grants:" + subject
+}
+
+// redisConsentGrantMaxAttempts is how often a grant is recorded again if the grants of the subject were changed
+// while it was recorded.
+const redisConsentGrantMaxAttempts = 10
+
+type ConsentGrantRedisManager struct {
+	DB *redis.Client
+}
+
+func (m *ConsentGrantRedisManager) RecordConsentGrant(subject, clientID string, scopes []string, at time.Time) error {
+	key := redisConsentGrantKey(subject)
+
+	var err error
+	for attempt := 1; attempt <= redisConsentGrantMaxAttempts; attempt++ {
+		err = m.DB.Watch(func(tx *redis.Tx) error {
+			grant := ConsentGrant{Subject: subject, ClientID: clientID, GrantedAt: at.UTC()}
+			out, err := tx.HGet(key, clientID).Bytes()
+			if err == nil {
+				if err := json.Unmarshal(out, &grant); err != nil {
+					return errors.WithStack(err)
+				}
+			} else if err != redis.Nil {
+				return errors.WithStack(err)
+			}
+
+			grant.GrantedScopes = mergeScopes(grant.GrantedScopes, scopes)
+			grant.UpdatedAt = at.UTC()
+			if out, err = json.Marshal(&grant); err != nil {
+				return errors.WithStack(err)
+			}
+
+			_, err = tx.Pipelined(func(pipe redis.Pipeliner) error {
+				pipe.HSet(key, clientID, string(out))
+				return nil
+			})
+			return err
+		}, key)
+		if err != redis.TxFailedErr {
+			break
+		}
+	}
+
+	if err == redis.TxFailedErr {
+		return errors.Wrap(pkg.ErrConflict, "The consent grants were changed concurrently")
+	} else if err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func (m *ConsentGrantRedisManager) GetConsentGrants(subject string) ([]ConsentGrant, error) {
+	values, err := m.DB.HVals(redisConsentGrantKey(subject)).Result()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	grants := make([]ConsentGrant, len(values))
+	for k, value := range values {
+		if err := json.Unmarshal([]byte(value), &grants[k]); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	sort.Slice(grants, func(i, j int) bool {
+		return grants[i].ClientID < grants[j].ClientID
+	})
+	return grants, nil
+}
+
+func (m *ConsentGrantRedisManager) DeleteConsentGrant(subject, clientID string) error {
+	n, err := m.DB.HDel(redisConsentGrantKey(subject), clientID).Result()
+	if err != nil {
+		return errors.WithStack(err)
+	} else if n == 0 {
+		return errors.WithStack(pkg.ErrNotFound)
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/ory/hydra/pkg"
+	"github.com/pkg/errors"
+	"github.com/rubenv/sql-migrate"
+)
+
+var consentGrantMigrations = &migrate.MemoryMigrationSource{
+	Migrations: []*migrate.Migration{
+		{
+			Id: "1",
+			Up: []string{
+				`CREATE TABLE IF NOT EXISTS hydra_oauth2_consent_grant (
+	subject		varchar(255) NOT NULL,
+	client_id	varchar(255) NOT NULL,
+	scopes		text NOT NULL,
+	granted_at	timestamp NOT NULL,
+	updated_at	timestamp NOT NULL,
+	PRIMARY KEY (subject, client_id)
+)`,
+			},
+			Down: []string{
+				"DROP TABLE hydra_oauth2_consent_grant",
+			},
+		},
+	},
+}
+
+type ConsentGrantSQLManager struct {
+	DB *sqlx.DB
+}
+
+type sqlConsentGrant struct {
+	Subject   string    `db:"subject"`
+	ClientID  string    `db:"client_id"`
+	Scopes    string    `db:"scopes"`
+	GrantedAt time.Time `db:"granted_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+func (d *sqlConsentGrant) toConsentGrant() ConsentGrant {
+	return ConsentGrant{
+		Subject:       d.Subject,
+		ClientID:      d.ClientID,
+		GrantedScopes: mergeScopes(nil, strings.Split(d.Scopes, " ")),
+		GrantedAt:     d.GrantedAt.UTC(),
+		UpdatedAt:     d.UpdatedAt.UTC(),
+	}
+}
+
+// Migrations returns the SQL migrations embedded in the binary.
+func (m *ConsentGrantSQLManager) Migrations() *migrate.MemoryMigrationSource {
+	return consentGrantMigrations
+}
+
+func (m *ConsentGrantSQLManager) CreateSchemas() (int, error) {
+	migrate.SetTable("hydra_oauth2_consent_grant_migration")
+	n, err := migrate.Exec(m.DB.DB, m.DB.DriverName(), consentGrantMigrations, migrate.Up)
+	if err != nil {
+		return 0, errors.Wrapf(err, "Could not migrate sql schema, applied %d migrations", n)
+	}
+	return n, nil
+}
+
+func (m *ConsentGrantSQLManager) RecordConsentGrant(subject, clientID string, scopes []string, at time.Time) error {
+	return pkg.SQLTransaction(m.DB, func(tx *sqlx.Tx) error {
+		var d sqlConsentGrant
+		err := tx.Get(&d, tx.Rebind("SELECT * FROM hydra_oauth2_consent_grant WHERE subject=? AND client_id=?"), subject, clientID)
+		if err == sql.ErrNoRows {
+			if _, err := tx.Exec(
+				tx.Rebind("INSERT INTO hydra_oauth2_consent_grant (subject, client_id, scopes, granted_at, updated_at) VALUES (?, ?, ?, ?, ?)"),
+				subject, clientID, strings.Join(mergeScopes(nil, scopes), " "), at.UTC(), at.UTC(),
+			); err != nil {
+				return errors.WithStack(err)
+			}
+			return nil
+		} else if err != nil {
+			return errors.WithStack(err)
+		}
+
+		merged := mergeScopes(strings.Split(d.Scopes, " "), scopes)
+		if _, err := tx.Exec(
+			tx.Rebind("UPDATE hydra_oauth2_consent_grant SET scopes=?, updated_at=? WHERE subject=? AND client_id=?"),
+			strings.Join(merged, " "), at.UTC(), subject, clientID,
+		); err != nil {
+			return errors.WithStack(err)
+		}
+		return nil
+	})
+}
+
+func (m *ConsentGrantSQLManager) GetConsentGrants(subject string) ([]ConsentGrant, error) {
+	var d []sqlConsentGrant
+	if err := m.DB.Select(&d, m.DB.Rebind("SELECT * FROM hydra_oauth2_consent_grant WHERE subject=? ORDER BY client_id"), subject); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	grants := make([]ConsentGrant, len(d))
+	for k := range d {
+		grants[k] = d[k].toConsentGrant()
+	}
+	return grants, nil
+}
+
+func (m *ConsentGrantSQLManager) DeleteConsentGrant(subject, clientID string) error {
+	res, err := m.DB.Exec(m.DB.Rebind("DELETE FROM hydra_oauth2_consent_grant WHERE subject=? AND client_id=?"), subject, clientID)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return errors.WithStack(err)
+	} else if n == 0 {
+		return errors.WithStack(pkg.ErrNotFound)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2_test
+
+import (
+	"fmt"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/ory/hydra/integration"
+	. "github.com/ory/hydra/oauth2"
+	"github.com/ory/hydra/pkg"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var consentGrantManagers = map[string]ConsentGrantManager{
+	"memory": NewConsentGrantMemoryManager(),
+}
+
+func connectToMySQLConsentGrants() {
+	s := &ConsentGrantSQLManager{DB: integration.ConnectToMySQL()}
+	if _, err := s.CreateSchemas(); err != nil {
+		log.Fatalf("Could not create mysql schema: %v", err)
+	}
+
+	consentGrantManagers["mysql"] = s
+}
+
+func connectToPGConsentGrants() {
+	s := &ConsentGrantSQLManager{DB: integration.ConnectToPostgres()}
+	if _, err := s.CreateSchemas(); err != nil {
+		log.Fatalf("Could not create postgres schema: %v", err)
+	}
+
+	consentGrantManagers["postgres"] = s
+}
+
+func TestConsentGrantManagers(t *testing.T) {
+	first := time.Now().UTC().Round(time.Second).Add(-time.Hour)
+	last := first.Add(time.Minute * 30)
+	for k, m := range consentGrantManagers {
+		t.Run(fmt.Sprintf("case=%s", k), func(t *testing.T) {
+			subject, other := "peter-"+uuid.New(), "alice-"+uuid.New()
+
+			require.NoError(t, m.RecordConsentGrant(subject, "photos", []string{"openid", "photos.read"}, first))
+			require.NoError(t, m.RecordConsentGrant(subject, "photos", []string{"photos.write", "openid"}, last))
+			require.NoError(t, m.RecordConsentGrant(subject, "contacts", []string{"contacts"}, first))
+			require.NoError(t, m.RecordConsentGrant(other, "photos", []string{"openid"}, first))
+
+			grants, err := m.GetConsentGrants(subject)
+			require.NoError(t, err)
+			require.Len(t, grants, 2)
+
+			assert.Equal(t, "contacts", grants[0].ClientID)
+			assert.Equal(t, []string{"contacts"}, grants[0].GrantedScopes)
+
+			assert.Equal(t, subject, grants[1].Subject)
+			assert.Equal(t, "photos", grants[1].ClientID)
+			assert.Equal(t, []string{"openid", "photos.read", "photos.write"}, grants[1].GrantedScopes)
+			assert.Equal(t, first.Unix(), grants[1].GrantedAt.Unix())
+			assert.Equal(t, last.Unix(), grants[1].UpdatedAt.Unix())
+
+			require.NoError(t, m.DeleteConsentGrant(subject, "photos"))
+			assert.Equal(t, pkg.ErrNotFound, errors.Cause(m.DeleteConsentGrant(subject, "photos")))
+
+			grants, err = m.GetConsentGrants(subject)
+			require.NoError(t, err)
+			require.Len(t, grants, 1)
+			assert.Equal(t, "contacts", grants[0].ClientID)
+
+			grants, err = m.GetConsentGrants(other)
+			require.NoError(t, err)
+			require.Len(t, grants, 1)
+
+			grants, err = m.GetConsentGrants("unknown-" + uuid.New())
+			require.NoError(t, err)
+			assert.Empty(t, grants)
+		})
+	}
+}
+
+func TestConsentGrantRecorder(t *testing.T) {
+	grants := NewConsentGrantMemoryManager()
+	m := &ConsentGrantRecorder{ConsentRequestManager: NewConsentRequestMemoryManager(), Grants: grants}
+
+	for _, id := range []string{"accepted", "rejected"} {
+		require.NoError(t, m.PersistConsentRequest(&ConsentRequest{
+			ID:              id,
+			ClientID:        "app",
+			RequestedScopes: []string{"openid", "photos"},
+			ExpiresAt:       time.Now().Add(time.Hour),
+		}))
+	}
+	require.NoError(t, m.AcceptConsentRequest("accepted", &AcceptConsentRequestPayload{Subject: "peter", GrantScopes: []string{"openid"}}))
+	require.NoError(t, m.RejectConsentRequest("rejected", &RejectConsentRequestPayload{Reason: "no"}))
+	assert.Error(t, m.AcceptConsentRequest("unknown", &AcceptConsentRequestPayload{Subject: "peter", GrantScopes: []string{"photos"}}))
+
+	got, err := grants.GetConsentGrants("peter")
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, "app", got[0].ClientID)
+	assert.Equal(t, []string{"openid"}, got[0].GrantedScopes)
+}
@@ -15,13 +15,19 @@
 package oauth2
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
+	"github.com/ory/fosite"
 	"github.com/ory/herodot"
+	"github.com/ory/hydra/client"
 	"github.com/ory/hydra/firewall"
+	"github.com/ory/hydra/pkg"
 	"github.com/pkg/errors"
 )
 
@@ -33,6 +39,14 @@ const (
 
 	ConsentResource = "oauth2:consent:requests:%s"
 	ConsentScope    = "hydra.consent"
+
+	// ConsentScopeResource is the resource on which a client must be allowed the action "grant" for the scope to be
+	// granted without asking the user.
+	ConsentScopeResource = "oauth2:consent:scopes:%s"
+
+	// ConsentClientResource is the resource on which a client must be allowed the action "skip" for the consent screen
+	// to be skipped.
+	ConsentClientResource = "oauth2:consent:clients:%s"
 )
 
 type ConsentSessionHandler struct {
@@ -41,6 +55,22 @@ type ConsentSessionHandler struct {
 	W firewall.Firewall
 
 	ResourcePrefix string
+
+	// ParkLifespan is how long a parked consent request remains valid.
+	ParkLifespan time.Duration
+
+	// Issuer is used to build the URL that resumes a parked consent request.
+	Issuer string
+
+	// Clients, if set, is used to decide on AutoGrantedScopes and SkipConsent of consent requests using policies.
+	Clients client.Storage
+
+	// Statistics, if set, enables the consent statistics endpoint.
+	Statistics ConsentStatisticsManager
+
+	// Logins, if set, enables the endpoints of the two-step login and consent flow at LoginRequestPath and
+	// ConsentChallengePath.
+	Logins LoginRequestManager
 }
 
 func (h *ConsentSessionHandler) PrefixResource(resource string) string {
@@ -59,6 +89,18 @@ func (h *ConsentSessionHandler) SetRoutes(r *httprouter.Router) {
 	r.GET(ConsentRequestPath+"/:id", h.FetchConsentRequest)
 	r.PATCH(ConsentRequestPath+"/:id/reject", h.RejectConsentRequestHandler)
 	r.PATCH(ConsentRequestPath+"/:id/accept", h.AcceptConsentRequestHandler)
+	r.POST(ConsentRequestPath+"/:id/park", h.ParkConsentRequestHandler)
+	if h.Statistics != nil {
+		r.GET(ConsentStatisticsPath, h.ConsentStatisticsHandler)
+	}
+	if h.Logins != nil {
+		r.GET(LoginRequestPath+"/:challenge", h.GetLoginRequestHandler)
+		r.PUT(LoginRequestPath+"/:challenge/accept", h.AcceptLoginRequestHandler)
+		r.PUT(LoginRequestPath+"/:challenge/reject", h.RejectLoginRequestHandler)
+		r.GET(ConsentChallengePath+"/:challenge", h.GetConsentChallengeHandler)
+		r.PUT(ConsentChallengePath+"/:challenge/accept", h.AcceptConsentChallengeHandler)
+		r.PUT(ConsentChallengePath+"/:challenge/reject", h.RejectConsentChallengeHandler)
+	}
 }
 
 // swagger:route GET /oauth2/consent/requests/{id} oAuth2 getOAuth2ConsentRequest
@@ -79,6 +121,27 @@ func (h *ConsentSessionHandler) SetRoutes(r *httprouter.Router) {
 //  }
 //  ```
 //
+// The response tells which of the requested scopes policies allow to grant without asking the user and whether the
+// consent screen may be skipped altogether. The subject of these policies is the client id, the context keys
+// "firstParty", "internal" and "environment" are set to the metadata of the client. A first-party client may, for
+// example, skip the consent screen in production using:
+//
+//  ```
+//  {
+//    "subjects": ["<.*>"],
+//    "resources": ["rn:hydra:oauth2:consent:clients:<.*>"],
+//    "actions": ["skip"],
+//    "effect": "allow",
+//    "conditions": {
+//      "firstParty": { "type": "BooleanCondition", "options": { "value": true } },
+//      "environment": { "type": "StringEqualCondition", "options": { "equals": "production" } }
+//    }
+//  }
+//  ```
+//
+// Scopes are auto-granted by allowing the action "grant" on "rn:hydra:oauth2:consent:scopes:<scope>". Clients with
+// skip_consent set are granted all requested scopes and always skip the consent screen.
+//
 //     Consumes:
 //     - application/json
 //
@@ -103,12 +166,64 @@ func (h *ConsentSessionHandler) FetchConsentRequest(w http.ResponseWriter, r *ht
 		return
 	}
 
-	if session, err := h.M.GetConsentRequest(ps.ByName("id")); err != nil {
+	session, err := h.M.GetConsentRequest(ps.ByName("id"))
+	if err != nil {
 		h.H.WriteError(w, r, err)
 		return
-	} else {
-		h.H.Write(w, r, session)
 	}
+
+	if h.Clients != nil {
+		if err := h.applyConsentPolicies(r.Context(), session); err != nil {
+			h.H.WriteError(w, r, err)
+			return
+		}
+	}
+
+	h.H.Write(w, r, session)
+}
+
+// applyConsentPolicies sets the scopes of the consent request which policies allow to grant to the client without
+// asking the user, and whether the consent screen may be skipped. Clients which skip consent are granted all requested
+// scopes without evaluating policies.
+func (h *ConsentSessionHandler) applyConsentPolicies(ctx context.Context, consent *ConsentRequest) error {
+	c, err := h.Clients.GetConcreteClient(consent.ClientID)
+	if err != nil {
+		return err
+	}
+
+	if c.SkipConsent {
+		consent.AutoGrantedScopes = consent.RequestedScopes
+		consent.SkipConsent = true
+		return nil
+	}
+
+	consent.AutoGrantedScopes = []string{}
+	for _, scope := range consent.RequestedScopes {
+		allowed, err := h.isAllowed(ctx, c, fmt.Sprintf(h.PrefixResource(ConsentScopeResource), scope), "grant")
+		if err != nil {
+			return err
+		} else if allowed {
+			consent.AutoGrantedScopes = append(consent.AutoGrantedScopes, scope)
+		}
+	}
+
+	consent.SkipConsent, err = h.isAllowed(ctx, c, fmt.Sprintf(h.PrefixResource(ConsentClientResource), c.ID), "skip")
+	return err
+}
+
+// isAllowed returns false if policies deny the client the action on the resource, and an error if the policies
+// could not be evaluated.
+func (h *ConsentSessionHandler) isAllowed(ctx context.Context, c *client.Client, resource, action string) (bool, error) {
+	err := h.W.IsAllowed(ctx, &firewall.AccessRequest{
+		Subject:  c.ID,
+		Resource: resource,
+		Action:   action,
+		Context:  c.PolicyContext(),
+	})
+	if errors.Cause(err) == fosite.ErrRequestForbidden {
+		return false, nil
+	}
+	return err == nil, err
 }
 
 // swagger:route PATCH /oauth2/consent/requests/{id}/reject oAuth2 rejectOAuth2ConsentRequest
@@ -230,3 +345,90 @@ func (h *ConsentSessionHandler) AcceptConsentRequestHandler(w http.ResponseWrite
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// swagger:route POST /oauth2/consent/requests/{id}/park oAuth2 parkOAuth2ConsentRequest
+//
+// Park a consent request
+//
+// Call this endpoint when the login flow needs more than one step, for example when the user has to complete
+// multi-factor authentication at an external provider or reset their password. The consent request is kept
+// server-side for the duration configured with CONSENT_REQUEST_PARK_LIFESPAN and can be resumed by sending the
+// user agent to the returned `resumeUrl`, which redirects to the consent app using the original consent request id.
+//
+// Only consent requests that have not been accepted or rejected yet can be parked. Parking a request again replaces
+// the previous resumption handle.
+//
+//
+// The subject making the request needs to be assigned to a policy containing:
+//
+//  ```
+//  {
+//    "resources": ["rn:hydra:oauth2:consent:requests:<request-id>"],
+//    "actions": ["park"],
+//    "effect": "allow"
+//  }
+//  ```
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Security:
+//       oauth2: hydra.consent
+//
+//     Responses:
+//       200: oAuth2ConsentRequestParking
+//       400: genericError
+//       401: genericError
+//       500: genericError
+func (h *ConsentSessionHandler) ParkConsentRequestHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var id = ps.ByName("id")
+	if _, err := h.W.TokenAllowed(r.Context(), h.W.TokenFromRequest(r), &firewall.TokenAccessRequest{
+		Resource: fmt.Sprintf(h.PrefixResource(ConsentResource), id),
+		Action:   "park",
+	}, ConsentScope); err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	consent, err := h.M.GetConsentRequest(id)
+	if err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	if !consent.IsAbandoned() {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, errors.New("Consent request has already been accepted or rejected"))
+		return
+	} else if time.Now().UTC().After(consent.ExpiresAt) {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, errors.New("Consent request expired"))
+		return
+	}
+
+	handle, err := pkg.GenerateSecret(48)
+	if err != nil {
+		h.H.WriteError(w, r, errors.WithStack(err))
+		return
+	}
+
+	lifespan := h.ParkLifespan
+	if lifespan == 0 {
+		lifespan = time.Hour
+	}
+
+	expiresAt := time.Now().UTC().Add(lifespan)
+	if err := h.M.ParkConsentRequest(id, string(handle), expiresAt); err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	h.H.Write(w, r, &ConsentRequestParking{
+		Handle:    string(handle),
+		ExpiresAt: expiresAt,
+		ResumeURL: h.Issuer + ResumePath + "?handle=" + url.QueryEscape(string(handle)),
+	})
+}
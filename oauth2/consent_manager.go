@@ -34,19 +34,56 @@ type ConsentRequest struct {
 	// accepted or rejected.
 	RedirectURL string `json:"redirectUrl"`
 
+	// AutoGrantedScopes are the requested scopes which policies allow to grant to the client without asking the user.
+	// All requested scopes are granted automatically to clients which skip consent.
+	AutoGrantedScopes []string `json:"autoGrantedScopes,omitempty"`
+
+	// SkipConsent is true if the client skips consent or policies allow the consent app to accept the request without
+	// showing a consent screen, granting the AutoGrantedScopes once the user is authenticated.
+	SkipConsent bool `json:"skipConsent,omitempty"`
+
+	// ACRValues are the authentication context class references requested by the client using the acr_values
+	// parameter, in order of preference.
+	ACRValues []string `json:"acrValues,omitempty"`
+
+	// RequestedClaims are the claims the client requested for the ID token and the userinfo endpoint using the claims
+	// parameter. Essential claims are needed by the client to work as intended.
+	RequestedClaims *ClaimsRequest `json:"requestedClaims,omitempty"`
+
 	CSRF             string                 `json:"-"`
 	GrantedScopes    []string               `json:"-"`
 	Subject          string                 `json:"-"`
 	AccessTokenExtra map[string]interface{} `json:"-"`
 	IDTokenExtra     map[string]interface{} `json:"-"`
+	UserinfoExtra    map[string]interface{} `json:"-"`
 	Consent          string                 `json:"-"`
 	DenyReason       string                 `json:"-"`
+
+	// DenyError, if set, is the error the authorization request fails with because consent was rejected.
+	DenyError *RequestDeniedError `json:"-"`
+
+	// ACR and AMR are the authentication context class and methods the consent app reported when accepting the request.
+	ACR string   `json:"-"`
+	AMR []string `json:"-"`
+
+	// RequestedAt is the time the authorization request which started the consent flow was made. AuthTime is the
+	// time the subject authenticated, as reported by the consent app.
+	RequestedAt time.Time `json:"-"`
+	AuthTime    time.Time `json:"-"`
+
+	// ResumptionHandle is set when the consent app parked this request, see ParkConsentRequest.
+	ResumptionHandle string `json:"-"`
 }
 
 func (c *ConsentRequest) IsConsentGranted() bool {
 	return c.Consent == ConsentRequestAccepted
 }
 
+// IsAbandoned returns true if the consent app never accepted or rejected this request.
+func (c *ConsentRequest) IsAbandoned() bool {
+	return c.Consent == ""
+}
+
 // AcceptConsentRequestPayload represents data that will be used to accept a consent request.
 //
 // swagger:model consentRequestAcceptance
@@ -59,12 +96,52 @@ type AcceptConsentRequestPayload struct {
 	// if the user agrees to it and if the client requested an ID token.
 	IDTokenExtra map[string]interface{} `json:"idTokenExtra"`
 
+	// UserinfoExtra represents claims that will only be returned by the userinfo endpoint, for example the claims the
+	// client requested from the userinfo endpoint using the claims parameter. Claims for the ID token are granted
+	// using IDTokenExtra, which are returned by the userinfo endpoint as well.
+	UserinfoExtra map[string]interface{} `json:"userinfoExtra,omitempty"`
+
 	// Subject represents a unique identifier of the user (or service, or legal entity, ...) that accepted the
 	// OAuth2 request.
 	Subject string `json:"subject"`
 
 	// A list of scopes that the user agreed to grant. It should be a subset of requestedScopes from the consent request.
 	GrantScopes []string `json:"grantScopes"`
+
+	// RememberFor is the number of seconds for which the decision is remembered. Until then, authorization requests
+	// of the subject and client which do not request more than the granted scopes skip the consent app, unless the
+	// client asks for prompt=login or prompt=consent. The decision is not remembered if this is zero.
+	RememberFor int64 `json:"rememberFor"`
+
+	// ACR is the authentication context class reference satisfied by the authentication of the subject, for example
+	// one of the acrValues of the consent request. It is added to the ID token as the "acr" claim.
+	ACR string `json:"acr,omitempty"`
+
+	// AMR lists the methods used to authenticate the subject, for example "pwd" and "otp". It is added to the
+	// ID token as the "amr" claim.
+	AMR []string `json:"amr,omitempty"`
+
+	// AuthTime is the time the subject authenticated and defaults to the time the consent request is accepted. It
+	// must be set if the consent app did not ask the subject to authenticate again, for example because the subject
+	// was still signed in to the consent app. It is added to the ID token as the "auth_time" claim and checked against
+	// the prompt and max_age parameters of the authorization request.
+	AuthTime time.Time `json:"authTime,omitempty"`
+}
+
+// ConsentRequestParking represents a consent request that has been parked so that the login flow can be resumed
+// later on, for example after the user has been redirected to an external MFA provider.
+//
+// swagger:model consentRequestParking
+type ConsentRequestParking struct {
+	// Handle is the resumption handle of the parked consent request.
+	Handle string `json:"handle"`
+
+	// ExpiresAt is the time where the parked consent request will expire.
+	ExpiresAt time.Time `json:"expiresAt"`
+
+	// ResumeURL is the URL the user agent should be sent to in order to continue the login flow. It redirects
+	// to the consent app with the original consent request id.
+	ResumeURL string `json:"resumeUrl"`
 }
 
 // RejectConsentRequestPayload represents data that will be used to reject a consent request.
@@ -73,6 +150,9 @@ type AcceptConsentRequestPayload struct {
 type RejectConsentRequestPayload struct {
 	// Reason represents the reason why the user rejected the consent request.
 	Reason string `json:"reason"`
+
+	// Error, if set, is the error the authorization request fails with instead of "rejected_consent_request".
+	Error *RequestDeniedError `json:"error,omitempty"`
 }
 
 type ConsentRequestManager interface {
@@ -80,4 +160,14 @@ type ConsentRequestManager interface {
 	AcceptConsentRequest(id string, payload *AcceptConsentRequestPayload) error
 	RejectConsentRequest(id string, payload *RejectConsentRequestPayload) error
 	GetConsentRequest(id string) (*ConsentRequest, error)
+
+	// ParkConsentRequest assigns a resumption handle to the consent request and extends its lifetime to expiresAt.
+	ParkConsentRequest(id string, handle string, expiresAt time.Time) error
+
+	// GetConsentRequestByResumptionHandle returns the consent request that has been parked using handle.
+	GetConsentRequestByResumptionHandle(handle string) (*ConsentRequest, error)
+
+	// FlushExpiredConsentRequests removes all consent requests which expired before notAfter. It returns the number
+	// of removed requests and how many of them were abandoned, meaning they were never accepted or rejected.
+	FlushExpiredConsentRequests(notAfter time.Time) (expired int, abandoned int, err error)
 }
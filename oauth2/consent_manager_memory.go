@@ -16,6 +16,7 @@ package oauth2
 
 import (
 	"sync"
+	"time"
 
 	"github.com/ory/hydra/pkg"
 	"github.com/pkg/errors"
@@ -46,8 +47,12 @@ func (m *ConsentRequestMemoryManager) AcceptConsentRequest(id string, payload *A
 	session.Subject = payload.Subject
 	session.AccessTokenExtra = payload.AccessTokenExtra
 	session.IDTokenExtra = payload.IDTokenExtra
+	session.UserinfoExtra = payload.UserinfoExtra
 	session.Consent = ConsentRequestAccepted
 	session.GrantedScopes = payload.GrantScopes
+	session.ACR = payload.ACR
+	session.AMR = payload.AMR
+	session.AuthTime = payload.AuthTime
 
 	return m.PersistConsentRequest(session)
 }
@@ -60,6 +65,7 @@ func (m *ConsentRequestMemoryManager) RejectConsentRequest(id string, payload *R
 
 	session.Consent = ConsentRequestRejected
 	session.DenyReason = payload.Reason
+	session.DenyError = payload.Error
 	return m.PersistConsentRequest(session)
 }
 
@@ -72,3 +78,48 @@ func (m *ConsentRequestMemoryManager) GetConsentRequest(id string) (*ConsentRequ
 		return &session, nil
 	}
 }
+
+func (m *ConsentRequestMemoryManager) ParkConsentRequest(id string, handle string, expiresAt time.Time) error {
+	session, err := m.GetConsentRequest(id)
+	if err != nil {
+		return err
+	}
+
+	session.ResumptionHandle = handle
+	session.ExpiresAt = expiresAt
+	return m.PersistConsentRequest(session)
+}
+
+func (m *ConsentRequestMemoryManager) GetConsentRequestByResumptionHandle(handle string) (*ConsentRequest, error) {
+	m.RLock()
+	defer m.RUnlock()
+	if handle == "" {
+		return nil, errors.Wrap(pkg.ErrNotFound, "")
+	}
+
+	for _, session := range m.requests {
+		if session.ResumptionHandle == handle {
+			return &session, nil
+		}
+	}
+	return nil, errors.Wrap(pkg.ErrNotFound, "")
+}
+
+func (m *ConsentRequestMemoryManager) FlushExpiredConsentRequests(notAfter time.Time) (expired int, abandoned int, err error) {
+	m.Lock()
+	defer m.Unlock()
+
+	for id, request := range m.requests {
+		if !request.ExpiresAt.Before(notAfter) {
+			continue
+		}
+
+		if request.IsAbandoned() {
+			abandoned++
+		}
+		expired++
+		delete(m.requests, id)
+	}
+
+	return expired, abandoned, nil
+}
@@ -0,0 +1,200 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/ory/hydra/pkg"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+)
+
+// redisConsentExpiry is a sorted set containing the ids of all consent requests, scored by their expiry.
+const redisConsentExpiry = "hydra:consent:expiry"
+
+// redisConsentKey holds the JSON encoded consent request.
+func redisConsentKey(id string) string {
+	return "hydra:consent:request:" + id
+}
+
+// redisConsentHandleKey holds the id of the consent request which was parked using the resumption handle.
+func redisConsentHandleKey(handle string) string {
+	return "hydra:consent:handle:" + handle
+}
+
+// redisTimeScore returns t in milliseconds since the epoch, for use as a sorted set score.
+func redisTimeScore(t time.Time) float64 {
+	return float64(t.UnixNano() / int64(time.Millisecond))
+}
+
+type ConsentRequestRedisManager struct {
+	DB *redis.Client
+}
+
+func NewConsentRequestRedisManager(db *redis.Client) *ConsentRequestRedisManager {
+	return &ConsentRequestRedisManager{DB: db}
+}
+
+func (m *ConsentRequestRedisManager) PersistConsentRequest(request *ConsentRequest) error {
+	if request.ID == "" {
+		request.ID = uuid.New()
+	}
+
+	data, err := newConsentRequestSqlData(request)
+	if err != nil {
+		return err
+	}
+
+	out, err := json.Marshal(data)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if _, err := m.DB.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.Set(redisConsentKey(request.ID), string(out), 0)
+		pipe.ZAdd(redisConsentExpiry, redis.Z{Score: redisTimeScore(request.ExpiresAt), Member: request.ID})
+		if request.ResumptionHandle != "" {
+			pipe.Set(redisConsentHandleKey(request.ResumptionHandle), request.ID, 0)
+		}
+		return nil
+	}); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func (m *ConsentRequestRedisManager) AcceptConsentRequest(id string, payload *AcceptConsentRequestPayload) error {
+	r, err := m.GetConsentRequest(id)
+	if err != nil {
+		return err
+	}
+
+	r.Subject = payload.Subject
+	r.AccessTokenExtra = payload.AccessTokenExtra
+	r.IDTokenExtra = payload.IDTokenExtra
+	r.UserinfoExtra = payload.UserinfoExtra
+	r.Consent = ConsentRequestAccepted
+	r.GrantedScopes = payload.GrantScopes
+	r.ACR = payload.ACR
+	r.AMR = payload.AMR
+	r.AuthTime = payload.AuthTime
+
+	return m.PersistConsentRequest(r)
+}
+
+func (m *ConsentRequestRedisManager) RejectConsentRequest(id string, payload *RejectConsentRequestPayload) error {
+	r, err := m.GetConsentRequest(id)
+	if err != nil {
+		return err
+	}
+
+	r.Consent = ConsentRequestRejected
+	r.DenyReason = payload.Reason
+	r.DenyError = payload.Error
+
+	return m.PersistConsentRequest(r)
+}
+
+func (m *ConsentRequestRedisManager) GetConsentRequest(id string) (*ConsentRequest, error) {
+	out, err := m.DB.Get(redisConsentKey(id)).Bytes()
+	if err == redis.Nil {
+		return nil, errors.WithStack(pkg.ErrNotFound)
+	} else if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var d consentRequestSqlData
+	if err := json.Unmarshal(out, &d); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return d.toConsentRequest()
+}
+
+func (m *ConsentRequestRedisManager) ParkConsentRequest(id string, handle string, expiresAt time.Time) error {
+	r, err := m.GetConsentRequest(id)
+	if err != nil {
+		return err
+	}
+
+	r.ResumptionHandle = handle
+	r.ExpiresAt = expiresAt
+	return m.PersistConsentRequest(r)
+}
+
+func (m *ConsentRequestRedisManager) GetConsentRequestByResumptionHandle(handle string) (*ConsentRequest, error) {
+	if handle == "" {
+		return nil, errors.WithStack(pkg.ErrNotFound)
+	}
+
+	id, err := m.DB.Get(redisConsentHandleKey(handle)).Result()
+	if err == redis.Nil {
+		return nil, errors.WithStack(pkg.ErrNotFound)
+	} else if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	r, err := m.GetConsentRequest(id)
+	if err != nil {
+		return nil, err
+	} else if r.ResumptionHandle != handle {
+		return nil, errors.WithStack(pkg.ErrNotFound)
+	}
+	return r, nil
+}
+
+func (m *ConsentRequestRedisManager) FlushExpiredConsentRequests(notAfter time.Time) (expired int, abandoned int, err error) {
+	ids, err := m.DB.ZRangeByScore(redisConsentExpiry, redis.ZRangeBy{
+		Min: "-inf",
+		Max: "(" + strconv.FormatFloat(redisTimeScore(notAfter), 'f', -1, 64),
+	}).Result()
+	if err != nil {
+		return 0, 0, errors.WithStack(err)
+	}
+
+	for _, id := range ids {
+		r, err := m.GetConsentRequest(id)
+		if errors.Cause(err) == pkg.ErrNotFound {
+			if err := m.DB.ZRem(redisConsentExpiry, id).Err(); err != nil {
+				return expired, abandoned, errors.WithStack(err)
+			}
+			continue
+		} else if err != nil {
+			return expired, abandoned, err
+		}
+
+		if _, err := m.DB.TxPipelined(func(pipe redis.Pipeliner) error {
+			pipe.Del(redisConsentKey(id))
+			pipe.ZRem(redisConsentExpiry, id)
+			if r.ResumptionHandle != "" {
+				pipe.Del(redisConsentHandleKey(r.ResumptionHandle))
+			}
+			return nil
+		}); err != nil {
+			return expired, abandoned, errors.WithStack(err)
+		}
+
+		if r.IsAbandoned() {
+			abandoned++
+		}
+		expired++
+	}
+
+	return expired, abandoned, nil
+}
@@ -22,6 +22,7 @@ import (
 	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/ory/hydra/events"
 	"github.com/ory/hydra/pkg"
 	"github.com/pborman/uuid"
 	"github.com/pkg/errors"
@@ -31,7 +32,9 @@ import (
 var sqlConsentParams = []string{
 	"id", "client_id", "expires_at", "redirect_url", "requested_scopes",
 	"csrf", "granted_scopes", "access_token_extra", "id_token_extra",
-	"consent", "deny_reason", "subject",
+	"consent", "deny_reason", "subject", "resumption_handle",
+	"acr_values", "acr", "amr", "requested_at", "auth_time",
+	"requested_claims", "userinfo_extra", "deny_error",
 }
 
 var consentMigrations = &migrate.MemoryMigrationSource{
@@ -56,22 +59,170 @@ var consentMigrations = &migrate.MemoryMigrationSource{
 				"DROP TABLE hydra_consent_request",
 			},
 		},
+		{
+			Id: "2",
+			Up: []string{
+				"ALTER TABLE hydra_consent_request ADD resumption_handle varchar(64) NOT NULL DEFAULT ''",
+				"CREATE INDEX hydra_consent_request_resumption_handle_idx ON hydra_consent_request (resumption_handle)",
+			},
+			Down: []string{
+				"ALTER TABLE hydra_consent_request DROP COLUMN resumption_handle",
+			},
+		},
+		{
+			Id: "3",
+			Up: []string{
+				"ALTER TABLE hydra_consent_request ADD acr_values text",
+				"ALTER TABLE hydra_consent_request ADD acr text",
+				"ALTER TABLE hydra_consent_request ADD amr text",
+				"UPDATE hydra_consent_request SET acr_values='', acr='', amr=''",
+			},
+			Down: []string{
+				"ALTER TABLE hydra_consent_request DROP COLUMN acr_values",
+				"ALTER TABLE hydra_consent_request DROP COLUMN acr",
+				"ALTER TABLE hydra_consent_request DROP COLUMN amr",
+			},
+		},
+		{
+			Id: "4",
+			Up: []string{
+				"ALTER TABLE hydra_consent_request ADD requested_at timestamp NULL",
+				"ALTER TABLE hydra_consent_request ADD auth_time timestamp NULL",
+			},
+			Down: []string{
+				"ALTER TABLE hydra_consent_request DROP COLUMN requested_at",
+				"ALTER TABLE hydra_consent_request DROP COLUMN auth_time",
+			},
+		},
+		{
+			Id: "5",
+			Up: []string{
+				"ALTER TABLE hydra_consent_request ADD requested_claims text",
+				"ALTER TABLE hydra_consent_request ADD userinfo_extra text",
+				"UPDATE hydra_consent_request SET requested_claims='', userinfo_extra=''",
+			},
+			Down: []string{
+				"ALTER TABLE hydra_consent_request DROP COLUMN requested_claims",
+				"ALTER TABLE hydra_consent_request DROP COLUMN userinfo_extra",
+			},
+		},
+		{
+			Id: "6",
+			Up: []string{
+				"ALTER TABLE hydra_consent_request ADD deny_error text",
+				"UPDATE hydra_consent_request SET deny_error=''",
+			},
+			Down: []string{
+				"ALTER TABLE hydra_consent_request DROP COLUMN deny_error",
+			},
+		},
+	},
+}
+
+// cockroachConsentMigrations replaces consentMigrations on CockroachDB, which can not index a column that is added in
+// the same transaction. The resumption handle is therefore part of the initial table, which leaves migration 2 empty.
+// New migrations must be added to both sources with the same id.
+var cockroachConsentMigrations = &migrate.MemoryMigrationSource{
+	Migrations: []*migrate.Migration{
+		{
+			Id: "1",
+			Up: []string{`CREATE TABLE IF NOT EXISTS hydra_consent_request (
+	id      			varchar(36) NOT NULL PRIMARY KEY,
+	requested_scopes 	text NOT NULL,
+	client_id 			text NOT NULL,
+	expires_at 			timestamp NOT NULL,
+	redirect_url 		text NOT NULL,
+	csrf 				text NOT NULL,
+	granted_scopes		text NOT NULL,
+	access_token_extra	text NOT NULL,
+	id_token_extra		text NOT NULL,
+	consent				text NOT NULL,
+	deny_reason			text NOT NULL,
+	subject				text NOT NULL,
+	resumption_handle	varchar(64) NOT NULL DEFAULT '',
+	INDEX hydra_consent_request_resumption_handle_idx (resumption_handle)
+)`},
+			Down: []string{
+				"DROP TABLE hydra_consent_request",
+			},
+		},
+		{
+			Id:   "2",
+			Up:   []string{},
+			Down: []string{},
+		},
+		{
+			Id: "3",
+			Up: []string{
+				"ALTER TABLE hydra_consent_request ADD acr_values text",
+				"ALTER TABLE hydra_consent_request ADD acr text",
+				"ALTER TABLE hydra_consent_request ADD amr text",
+				"UPDATE hydra_consent_request SET acr_values='', acr='', amr=''",
+			},
+			Down: []string{
+				"ALTER TABLE hydra_consent_request DROP COLUMN acr_values",
+				"ALTER TABLE hydra_consent_request DROP COLUMN acr",
+				"ALTER TABLE hydra_consent_request DROP COLUMN amr",
+			},
+		},
+		{
+			Id: "4",
+			Up: []string{
+				"ALTER TABLE hydra_consent_request ADD requested_at timestamp NULL",
+				"ALTER TABLE hydra_consent_request ADD auth_time timestamp NULL",
+			},
+			Down: []string{
+				"ALTER TABLE hydra_consent_request DROP COLUMN requested_at",
+				"ALTER TABLE hydra_consent_request DROP COLUMN auth_time",
+			},
+		},
+		{
+			Id: "5",
+			Up: []string{
+				"ALTER TABLE hydra_consent_request ADD requested_claims text",
+				"ALTER TABLE hydra_consent_request ADD userinfo_extra text",
+				"UPDATE hydra_consent_request SET requested_claims='', userinfo_extra=''",
+			},
+			Down: []string{
+				"ALTER TABLE hydra_consent_request DROP COLUMN requested_claims",
+				"ALTER TABLE hydra_consent_request DROP COLUMN userinfo_extra",
+			},
+		},
+		{
+			Id: "6",
+			Up: []string{
+				"ALTER TABLE hydra_consent_request ADD deny_error text",
+				"UPDATE hydra_consent_request SET deny_error=''",
+			},
+			Down: []string{
+				"ALTER TABLE hydra_consent_request DROP COLUMN deny_error",
+			},
+		},
 	},
 }
 
 type consentRequestSqlData struct {
-	ID               string    `db:"id"`
-	RequestedScopes  string    `db:"requested_scopes"`
-	ClientID         string    `db:"client_id"`
-	ExpiresAt        time.Time `db:"expires_at"`
-	RedirectURL      string    `db:"redirect_url"`
-	CSRF             string    `db:"csrf"`
-	GrantedScopes    string    `db:"granted_scopes"`
-	AccessTokenExtra string    `db:"access_token_extra"`
-	IDTokenExtra     string    `db:"id_token_extra"`
-	Consent          string    `db:"consent"`
-	DenyReason       string    `db:"deny_reason"`
-	Subject          string    `db:"subject"`
+	ID               string     `db:"id"`
+	RequestedScopes  string     `db:"requested_scopes"`
+	ClientID         string     `db:"client_id"`
+	ExpiresAt        time.Time  `db:"expires_at"`
+	RedirectURL      string     `db:"redirect_url"`
+	CSRF             string     `db:"csrf"`
+	GrantedScopes    string     `db:"granted_scopes"`
+	AccessTokenExtra string     `db:"access_token_extra"`
+	IDTokenExtra     string     `db:"id_token_extra"`
+	Consent          string     `db:"consent"`
+	DenyReason       string     `db:"deny_reason"`
+	Subject          string     `db:"subject"`
+	ResumptionHandle string     `db:"resumption_handle"`
+	ACRValues        string     `db:"acr_values"`
+	ACR              string     `db:"acr"`
+	AMR              string     `db:"amr"`
+	RequestedAt      *time.Time `db:"requested_at"`
+	AuthTime         *time.Time `db:"auth_time"`
+	RequestedClaims  string     `db:"requested_claims"`
+	UserinfoExtra    string     `db:"userinfo_extra"`
+	DenyError        string     `db:"deny_error"`
 }
 
 func newConsentRequestSqlData(request *ConsentRequest) (*consentRequestSqlData, error) {
@@ -84,6 +235,9 @@ func newConsentRequestSqlData(request *ConsentRequest) (*consentRequestSqlData,
 
 	atext := ""
 	idtext := ""
+	uitext := ""
+	claimstext := ""
+	denytext := ""
 
 	if request.AccessTokenExtra != nil {
 		if out, err := json.Marshal(request.AccessTokenExtra); err != nil {
@@ -101,6 +255,30 @@ func newConsentRequestSqlData(request *ConsentRequest) (*consentRequestSqlData,
 		}
 	}
 
+	if request.UserinfoExtra != nil {
+		if out, err := json.Marshal(request.UserinfoExtra); err != nil {
+			return nil, errors.WithStack(err)
+		} else {
+			uitext = string(out)
+		}
+	}
+
+	if request.RequestedClaims != nil {
+		if out, err := json.Marshal(request.RequestedClaims); err != nil {
+			return nil, errors.WithStack(err)
+		} else {
+			claimstext = string(out)
+		}
+	}
+
+	if request.DenyError != nil {
+		if out, err := json.Marshal(request.DenyError); err != nil {
+			return nil, errors.WithStack(err)
+		} else {
+			denytext = string(out)
+		}
+	}
+
 	return &consentRequestSqlData{
 		ID:               request.ID,
 		RequestedScopes:  strings.Join(request.RequestedScopes, " "),
@@ -114,11 +292,22 @@ func newConsentRequestSqlData(request *ConsentRequest) (*consentRequestSqlData,
 		Consent:          request.Consent,
 		DenyReason:       request.DenyReason,
 		Subject:          request.Subject,
+		ResumptionHandle: request.ResumptionHandle,
+		ACRValues:        strings.Join(request.ACRValues, " "),
+		ACR:              request.ACR,
+		AMR:              strings.Join(request.AMR, " "),
+		RequestedAt:      nullTime(request.RequestedAt),
+		AuthTime:         nullTime(request.AuthTime),
+		RequestedClaims:  claimstext,
+		UserinfoExtra:    uitext,
+		DenyError:        denytext,
 	}, nil
 }
 
 func (r *consentRequestSqlData) toConsentRequest() (*ConsentRequest, error) {
-	var atext, idtext map[string]interface{}
+	var atext, idtext, uitext map[string]interface{}
+	var claims *ClaimsRequest
+	var denyError *RequestDeniedError
 
 	if r.IDTokenExtra != "" {
 		if err := json.Unmarshal([]byte(r.IDTokenExtra), &idtext); err != nil {
@@ -132,6 +321,32 @@ func (r *consentRequestSqlData) toConsentRequest() (*ConsentRequest, error) {
 		}
 	}
 
+	if r.UserinfoExtra != "" {
+		if err := json.Unmarshal([]byte(r.UserinfoExtra), &uitext); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	if r.RequestedClaims != "" {
+		if err := json.Unmarshal([]byte(r.RequestedClaims), &claims); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	if r.DenyError != "" {
+		if err := json.Unmarshal([]byte(r.DenyError), &denyError); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	var requestedAt, authTime time.Time
+	if r.RequestedAt != nil {
+		requestedAt = r.RequestedAt.UTC()
+	}
+	if r.AuthTime != nil {
+		authTime = r.AuthTime.UTC()
+	}
+
 	return &ConsentRequest{
 		ID:               r.ID,
 		ClientID:         r.ClientID,
@@ -145,20 +360,65 @@ func (r *consentRequestSqlData) toConsentRequest() (*ConsentRequest, error) {
 		AccessTokenExtra: atext,
 		IDTokenExtra:     idtext,
 		Subject:          r.Subject,
+		ResumptionHandle: r.ResumptionHandle,
+		ACRValues:        splitFields(r.ACRValues),
+		ACR:              r.ACR,
+		AMR:              splitFields(r.AMR),
+		RequestedAt:      requestedAt,
+		AuthTime:         authTime,
+		RequestedClaims:  claims,
+		UserinfoExtra:    uitext,
+		DenyError:        denyError,
 	}, nil
 }
 
+// splitFields splits a space separated list, returning nil instead of an empty slice if s is empty.
+func splitFields(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, " ")
+}
+
+// nullTime returns nil if t is zero, so that it is stored as NULL.
+func nullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	t = t.UTC()
+	return &t
+}
+
 type ConsentRequestSQLManager struct {
 	db *sqlx.DB
+
+	// Outbox, if set, stores an event for every accepted or rejected consent request in the same transaction as
+	// the change.
+	Outbox events.Outbox
 }
 
 func NewConsentRequestSQLManager(db *sqlx.DB) *ConsentRequestSQLManager {
 	return &ConsentRequestSQLManager{db: db}
 }
 
+// Migrations returns the SQL migrations embedded in the binary.
+func (m *ConsentRequestSQLManager) Migrations() *migrate.MemoryMigrationSource {
+	return consentMigrations
+}
+
 func (m *ConsentRequestSQLManager) CreateSchemas() (int, error) {
+	dialect, err := pkg.SQLDialect(m.db)
+	if err != nil {
+		return 0, err
+	}
+
+	source := consentMigrations
+	if dialect == pkg.SQLDialectCockroach {
+		source = cockroachConsentMigrations
+	}
+
 	migrate.SetTable("hydra_consent_request_migration")
-	n, err := migrate.Exec(m.db.DB, m.db.DriverName(), consentMigrations, migrate.Up)
+	n, err := migrate.Exec(m.db.DB, m.db.DriverName(), source, migrate.Up)
 	if err != nil {
 		return 0, errors.Wrapf(err, "Could not migrate sql schema, applied %d migrations", n)
 	}
@@ -195,10 +455,14 @@ func (m *ConsentRequestSQLManager) AcceptConsentRequest(id string, payload *Acce
 	r.Subject = payload.Subject
 	r.AccessTokenExtra = payload.AccessTokenExtra
 	r.IDTokenExtra = payload.IDTokenExtra
+	r.UserinfoExtra = payload.UserinfoExtra
 	r.Consent = ConsentRequestAccepted
 	r.GrantedScopes = payload.GrantScopes
+	r.ACR = payload.ACR
+	r.AMR = payload.AMR
+	r.AuthTime = payload.AuthTime
 
-	return m.updateConsentRequest(r)
+	return m.updateConsentRequest(r, events.ConsentAccepted)
 }
 
 func (m *ConsentRequestSQLManager) RejectConsentRequest(id string, payload *RejectConsentRequestPayload) error {
@@ -209,11 +473,12 @@ func (m *ConsentRequestSQLManager) RejectConsentRequest(id string, payload *Reje
 
 	r.Consent = ConsentRequestRejected
 	r.DenyReason = payload.Reason
+	r.DenyError = payload.Error
 
-	return m.updateConsentRequest(r)
+	return m.updateConsentRequest(r, events.ConsentRejected)
 }
 
-func (m *ConsentRequestSQLManager) updateConsentRequest(request *ConsentRequest) error {
+func (m *ConsentRequestSQLManager) updateConsentRequest(request *ConsentRequest, eventType string) error {
 	d, err := newConsentRequestSqlData(request)
 	if err != nil {
 		return errors.WithStack(err)
@@ -224,11 +489,21 @@ func (m *ConsentRequestSQLManager) updateConsentRequest(request *ConsentRequest)
 		query = append(query, fmt.Sprintf("%s=:%s", param, param))
 	}
 
-	if _, err := m.db.NamedExec(fmt.Sprintf(`UPDATE hydra_consent_request SET %s WHERE id=:id`, strings.Join(query, ", ")), d); err != nil {
-		return errors.WithStack(err)
+	e, err := events.NewEvent(eventType, map[string]string{
+		"consent_request_id": request.ID,
+		"client_id":          request.ClientID,
+		"subject":            request.Subject,
+	})
+	if err != nil {
+		return err
 	}
 
-	return nil
+	return events.Transaction(m.db, m.Outbox, e, func(tx *sqlx.Tx) error {
+		if _, err := tx.NamedExec(fmt.Sprintf(`UPDATE hydra_consent_request SET %s WHERE id=:id`, strings.Join(query, ", ")), d); err != nil {
+			return errors.WithStack(err)
+		}
+		return nil
+	})
 }
 
 func (m *ConsentRequestSQLManager) GetConsentRequest(id string) (*ConsentRequest, error) {
@@ -245,3 +520,60 @@ func (m *ConsentRequestSQLManager) GetConsentRequest(id string) (*ConsentRequest
 	}
 	return r, nil
 }
+
+func (m *ConsentRequestSQLManager) ParkConsentRequest(id string, handle string, expiresAt time.Time) error {
+	result, err := m.db.Exec(m.db.Rebind("UPDATE hydra_consent_request SET resumption_handle=?, expires_at=? WHERE id=?"), handle, expiresAt, id)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if rows, err := result.RowsAffected(); err != nil {
+		return errors.WithStack(err)
+	} else if rows == 0 {
+		return errors.WithStack(pkg.ErrNotFound)
+	}
+	return nil
+}
+
+func (m *ConsentRequestSQLManager) GetConsentRequestByResumptionHandle(handle string) (*ConsentRequest, error) {
+	if handle == "" {
+		return nil, errors.WithStack(pkg.ErrNotFound)
+	}
+
+	var d consentRequestSqlData
+	if err := m.db.Get(&d, m.db.Rebind("SELECT * FROM hydra_consent_request WHERE resumption_handle=?"), handle); err == sql.ErrNoRows {
+		return nil, errors.WithStack(pkg.ErrNotFound)
+	} else if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	r, err := d.toConsentRequest()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return r, nil
+}
+
+func (m *ConsentRequestSQLManager) FlushExpiredConsentRequests(notAfter time.Time) (expired int, abandoned int, err error) {
+	var result sql.Result
+	if err := pkg.SQLTransaction(m.db, func(tx *sqlx.Tx) error {
+		if err := tx.Get(&abandoned, m.db.Rebind("SELECT COUNT(*) FROM hydra_consent_request WHERE expires_at < ? AND consent = ''"), notAfter); err != nil {
+			return errors.WithStack(err)
+		}
+
+		var err error
+		if result, err = tx.Exec(m.db.Rebind("DELETE FROM hydra_consent_request WHERE expires_at < ?"), notAfter); err != nil {
+			return errors.WithStack(err)
+		}
+		return nil
+	}); err != nil {
+		return 0, 0, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, 0, errors.WithStack(err)
+	}
+
+	return int(rows), abandoned, nil
+}
@@ -45,6 +45,27 @@ func TestConsentRequestSqlDataTransforms(t *testing.T) {
 				Subject:          "Peter",
 			},
 		},
+		{
+			d: "request with authentication context",
+			r: &ConsentRequest{
+				ID:              "id",
+				ClientID:        "client-id",
+				RequestedScopes: []string{"openid"},
+				GrantedScopes:   []string{"openid"},
+				CSRF:            "some-csrf",
+				ExpiresAt:       time.Now().Round(time.Second),
+				Consent:         ConsentRequestAccepted,
+				RedirectURL:     "https://redirect-me/foo",
+				Subject:         "Peter",
+				ACRValues:       []string{"urn:mace:incommon:iap:silver", "urn:mace:incommon:iap:bronze"},
+				ACR:             "urn:mace:incommon:iap:silver",
+				AMR:             []string{"pwd", "otp"},
+				RequestedClaims: &ClaimsRequest{Userinfo: map[string]*ClaimRequest{"email": {Essential: true}, "picture": nil}},
+				UserinfoExtra:   map[string]interface{}{"email": "peter@example.com"},
+				RequestedAt:     time.Now().UTC().Round(time.Second),
+				AuthTime:        time.Now().UTC().Add(-time.Minute).Round(time.Second),
+			},
+		},
 	} {
 		t.Run(tc.d, func(t *testing.T) {
 			s, err := newConsentRequestSqlData(tc.r)
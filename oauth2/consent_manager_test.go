@@ -52,6 +52,17 @@ func connectToPGConsent() {
 	consentManagers["postgres"] = s
 }
 
+func connectToCockroachConsent() {
+	var db = integration.ConnectToCockroach()
+	s := NewConsentRequestSQLManager(db)
+
+	if _, err := s.CreateSchemas(); err != nil {
+		log.Fatalf("Could not create cockroach schema: %v", err)
+	}
+
+	consentManagers["cockroach"] = s
+}
+
 func tTestConsentRequestManagerReadWrite(t *testing.T) {
 	req := &ConsentRequest{
 		ID:               "id-1",
@@ -124,3 +135,88 @@ func TestConsentRequestManagerUpdate(t *testing.T) {
 		})
 	}
 }
+
+func TestConsentRequestManagerFlushExpired(t *testing.T) {
+	for k, m := range consentManagers {
+		t.Run(fmt.Sprintf("case=%s", k), func(t *testing.T) {
+			abandoned := &ConsentRequest{
+				ID:               "flush-abandoned-" + k,
+				ClientID:         "client-id",
+				RequestedScopes:  []string{"foo"},
+				GrantedScopes:    []string{},
+				ExpiresAt:        time.Now().UTC().Add(-time.Hour),
+				AccessTokenExtra: map[string]interface{}{},
+				IDTokenExtra:     map[string]interface{}{},
+			}
+			accepted := &ConsentRequest{
+				ID:               "flush-accepted-" + k,
+				ClientID:         "client-id",
+				RequestedScopes:  []string{"foo"},
+				GrantedScopes:    []string{"foo"},
+				ExpiresAt:        time.Now().UTC().Add(-time.Hour),
+				Consent:          ConsentRequestAccepted,
+				Subject:          "peter",
+				AccessTokenExtra: map[string]interface{}{},
+				IDTokenExtra:     map[string]interface{}{},
+			}
+			active := &ConsentRequest{
+				ID:               "flush-active-" + k,
+				ClientID:         "client-id",
+				RequestedScopes:  []string{"foo"},
+				GrantedScopes:    []string{},
+				ExpiresAt:        time.Now().UTC().Add(time.Hour),
+				AccessTokenExtra: map[string]interface{}{},
+				IDTokenExtra:     map[string]interface{}{},
+			}
+
+			for _, r := range []*ConsentRequest{abandoned, accepted, active} {
+				require.NoError(t, m.PersistConsentRequest(r))
+			}
+
+			expired, gone, err := m.FlushExpiredConsentRequests(time.Now().UTC().Add(-time.Minute))
+			require.NoError(t, err)
+			assert.True(t, expired >= 2)
+			assert.True(t, gone >= 1)
+
+			_, err = m.GetConsentRequest(abandoned.ID)
+			assert.Error(t, err)
+			_, err = m.GetConsentRequest(accepted.ID)
+			assert.Error(t, err)
+			_, err = m.GetConsentRequest(active.ID)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestConsentRequestManagerPark(t *testing.T) {
+	for k, m := range consentManagers {
+		t.Run(fmt.Sprintf("case=%s", k), func(t *testing.T) {
+			req := &ConsentRequest{
+				ID:               "park-" + k,
+				ClientID:         "client-id",
+				RequestedScopes:  []string{"foo"},
+				GrantedScopes:    []string{},
+				ExpiresAt:        time.Now().UTC().Add(time.Minute),
+				AccessTokenExtra: map[string]interface{}{},
+				IDTokenExtra:     map[string]interface{}{},
+			}
+			require.NoError(t, m.PersistConsentRequest(req))
+
+			_, err := m.GetConsentRequestByResumptionHandle("")
+			assert.Error(t, err)
+			_, err = m.GetConsentRequestByResumptionHandle("handle-" + k)
+			assert.Error(t, err)
+
+			expiresAt := time.Now().UTC().Add(time.Hour).Round(time.Second)
+			require.NoError(t, m.ParkConsentRequest(req.ID, "handle-"+k, expiresAt))
+			assert.Error(t, m.ParkConsentRequest("does-not-exist", "handle-"+k, expiresAt))
+
+			got, err := m.GetConsentRequestByResumptionHandle("handle-" + k)
+			require.NoError(t, err)
+			assert.Equal(t, req.ID, got.ID)
+			assert.Equal(t, "handle-"+k, got.ResumptionHandle)
+			assert.Equal(t, expiresAt.Unix(), got.ExpiresAt.Unix())
+			assert.True(t, got.IsAbandoned())
+		})
+	}
+}
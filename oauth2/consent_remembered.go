@@ -0,0 +1,100 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"time"
+)
+
+// RememberedConsent is a consent decision the consent app asked to remember, so that later authorization requests
+// of the subject and client skip the consent app until it expires.
+type RememberedConsent struct {
+	Subject  string
+	ClientID string
+
+	// ConsentRequestID is the id of the consent request the decision was made for.
+	ConsentRequestID string
+
+	GrantedScopes    []string
+	AccessTokenExtra map[string]interface{}
+	IDTokenExtra     map[string]interface{}
+
+	RememberedAt time.Time
+	ExpiresAt    time.Time
+}
+
+// RememberedConsentManager keeps remembered consent decisions.
+type RememberedConsentManager interface {
+	// RememberConsent stores the consent, replacing the consent remembered for the same subject and client.
+	RememberConsent(consent *RememberedConsent) error
+
+	// GetRememberedConsent returns the consent remembered for the subject and client. It returns pkg.ErrNotFound if
+	// no consent is remembered or if it expired.
+	GetRememberedConsent(subject, clientID string) (*RememberedConsent, error)
+
+	// ForgetConsent removes the consent remembered for the subject and client, if any.
+	ForgetConsent(subject, clientID string) error
+
+	// ForgetSubjectConsents removes all consents remembered for the subject, including expired ones, and returns the
+	// ids of the clients they were remembered for.
+	ForgetSubjectConsents(subject string) ([]string, error)
+}
+
+// ConsentRememberer remembers the decision of accepted consent requests whose payload sets RememberFor.
+type ConsentRememberer struct {
+	ConsentRequestManager
+	Remembered RememberedConsentManager
+}
+
+func (r *ConsentRememberer) AcceptConsentRequest(id string, payload *AcceptConsentRequestPayload) error {
+	request, err := r.ConsentRequestManager.GetConsentRequest(id)
+	if err != nil {
+		return err
+	}
+
+	if err := r.ConsentRequestManager.AcceptConsentRequest(id, payload); err != nil {
+		return err
+	}
+
+	if payload.RememberFor <= 0 {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	return r.Remembered.RememberConsent(&RememberedConsent{
+		Subject:          payload.Subject,
+		ClientID:         request.ClientID,
+		ConsentRequestID: id,
+		GrantedScopes:    payload.GrantScopes,
+		AccessTokenExtra: payload.AccessTokenExtra,
+		IDTokenExtra:     payload.IDTokenExtra,
+		RememberedAt:     now,
+		ExpiresAt:        now.Add(time.Duration(payload.RememberFor) * time.Second),
+	})
+}
+
+// covers returns true if all scopes have been granted by the remembered consent.
+func (c *RememberedConsent) covers(scopes []string) bool {
+	granted := map[string]bool{}
+	for _, scope := range c.GrantedScopes {
+		granted[scope] = true
+	}
+	for _, scope := range scopes {
+		if !granted[scope] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,77 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ory/hydra/pkg"
+	"github.com/pkg/errors"
+)
+
+type RememberedConsentMemoryManager struct {
+	consents map[consentGrantKey]RememberedConsent
+	sync.RWMutex
+}
+
+func NewRememberedConsentMemoryManager() *RememberedConsentMemoryManager {
+	return &RememberedConsentMemoryManager{consents: map[consentGrantKey]RememberedConsent{}}
+}
+
+func (m *RememberedConsentMemoryManager) RememberConsent(consent *RememberedConsent) error {
+	m.Lock()
+	defer m.Unlock()
+
+	c := *consent
+	c.GrantedScopes = mergeScopes(nil, consent.GrantedScopes)
+	m.consents[consentGrantKey{subject: consent.Subject, clientID: consent.ClientID}] = c
+	return nil
+}
+
+func (m *RememberedConsentMemoryManager) GetRememberedConsent(subject, clientID string) (*RememberedConsent, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	consent, ok := m.consents[consentGrantKey{subject: subject, clientID: clientID}]
+	if !ok || time.Now().UTC().After(consent.ExpiresAt) {
+		return nil, errors.WithStack(pkg.ErrNotFound)
+	}
+	return &consent, nil
+}
+
+func (m *RememberedConsentMemoryManager) ForgetConsent(subject, clientID string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	delete(m.consents, consentGrantKey{subject: subject, clientID: clientID})
+	return nil
+}
+
+func (m *RememberedConsentMemoryManager) ForgetSubjectConsents(subject string) ([]string, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	clientIDs := []string{}
+	for key := range m.consents {
+		if key.subject == subject {
+			clientIDs = append(clientIDs, key.clientID)
+			delete(m.consents, key)
+		}
+	}
+	sort.Strings(clientIDs)
+	return clientIDs, nil
+}
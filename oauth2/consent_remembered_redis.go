@@ -0,0 +1,91 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/ory/hydra/pkg"
+	"github.com/pkg/errors"
+)
+
+// redisRememberedConsentKey is a hash containing the JSON encoded consents remembered for the subject, indexed by
+// client id.
+func redisRememberedConsentKey(subject string) string {
+	return "hydra:consent:remembered:" + subject
+}
+
+type RememberedConsentRedisManager struct {
+	DB *redis.Client
+}
+
+func (m *RememberedConsentRedisManager) RememberConsent(consent *RememberedConsent) error {
+	c := *consent
+	c.GrantedScopes = mergeScopes(nil, consent.GrantedScopes)
+
+	out, err := json.Marshal(&c)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := m.DB.HSet(redisRememberedConsentKey(consent.Subject), consent.ClientID, string(out)).Err(); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func (m *RememberedConsentRedisManager) GetRememberedConsent(subject, clientID string) (*RememberedConsent, error) {
+	out, err := m.DB.HGet(redisRememberedConsentKey(subject), clientID).Bytes()
+	if err == redis.Nil {
+		return nil, errors.WithStack(pkg.ErrNotFound)
+	} else if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var consent RememberedConsent
+	if err := json.Unmarshal(out, &consent); err != nil {
+		return nil, errors.WithStack(err)
+	} else if time.Now().UTC().After(consent.ExpiresAt) {
+		return nil, errors.WithStack(pkg.ErrNotFound)
+	}
+	return &consent, nil
+}
+
+func (m *RememberedConsentRedisManager) ForgetConsent(subject, clientID string) error {
+	if err := m.DB.HDel(redisRememberedConsentKey(subject), clientID).Err(); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func (m *RememberedConsentRedisManager) ForgetSubjectConsents(subject string) ([]string, error) {
+	key := redisRememberedConsentKey(subject)
+
+	var keys *redis.StringSliceCmd
+	if _, err := m.DB.TxPipelined(func(pipe redis.Pipeliner) error {
+		keys = pipe.HKeys(key)
+		pipe.Del(key)
+		return nil
+	}); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	clientIDs := append([]string{}, keys.Val()...)
+	sort.Strings(clientIDs)
+	return clientIDs, nil
+}
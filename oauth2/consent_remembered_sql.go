@@ -0,0 +1,168 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/ory/hydra/pkg"
+	"github.com/pkg/errors"
+	"github.com/rubenv/sql-migrate"
+)
+
+var rememberedConsentMigrations = &migrate.MemoryMigrationSource{
+	Migrations: []*migrate.Migration{
+		{
+			Id: "1",
+			Up: []string{
+				`CREATE TABLE IF NOT EXISTS hydra_oauth2_consent_remembered (
+	subject				varchar(255) NOT NULL,
+	client_id			varchar(255) NOT NULL,
+	consent_request_id	varchar(36) NOT NULL,
+	scopes				text NOT NULL,
+	access_token_extra	text NOT NULL,
+	id_token_extra		text NOT NULL,
+	remembered_at		timestamp NOT NULL,
+	expires_at			timestamp NOT NULL,
+	PRIMARY KEY (subject, client_id)
+)`,
+			},
+			Down: []string{
+				"DROP TABLE hydra_oauth2_consent_remembered",
+			},
+		},
+	},
+}
+
+type RememberedConsentSQLManager struct {
+	DB *sqlx.DB
+}
+
+type sqlRememberedConsent struct {
+	Subject          string    `db:"subject"`
+	ClientID         string    `db:"client_id"`
+	ConsentRequestID string    `db:"consent_request_id"`
+	Scopes           string    `db:"scopes"`
+	AccessTokenExtra string    `db:"access_token_extra"`
+	IDTokenExtra     string    `db:"id_token_extra"`
+	RememberedAt     time.Time `db:"remembered_at"`
+	ExpiresAt        time.Time `db:"expires_at"`
+}
+
+func (d *sqlRememberedConsent) toRememberedConsent() (*RememberedConsent, error) {
+	var atext, idtext map[string]interface{}
+	if err := json.Unmarshal([]byte(d.AccessTokenExtra), &atext); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err := json.Unmarshal([]byte(d.IDTokenExtra), &idtext); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &RememberedConsent{
+		Subject:          d.Subject,
+		ClientID:         d.ClientID,
+		ConsentRequestID: d.ConsentRequestID,
+		GrantedScopes:    mergeScopes(nil, strings.Split(d.Scopes, " ")),
+		AccessTokenExtra: atext,
+		IDTokenExtra:     idtext,
+		RememberedAt:     d.RememberedAt.UTC(),
+		ExpiresAt:        d.ExpiresAt.UTC(),
+	}, nil
+}
+
+// Migrations returns the SQL migrations embedded in the binary.
+func (m *RememberedConsentSQLManager) Migrations() *migrate.MemoryMigrationSource {
+	return rememberedConsentMigrations
+}
+
+func (m *RememberedConsentSQLManager) CreateSchemas() (int, error) {
+	migrate.SetTable("hydra_oauth2_consent_remembered_migration")
+	n, err := migrate.Exec(m.DB.DB, m.DB.DriverName(), rememberedConsentMigrations, migrate.Up)
+	if err != nil {
+		return 0, errors.Wrapf(err, "Could not migrate sql schema, applied %d migrations", n)
+	}
+	return n, nil
+}
+
+func (m *RememberedConsentSQLManager) RememberConsent(consent *RememberedConsent) error {
+	atext, err := json.Marshal(consent.AccessTokenExtra)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	idtext, err := json.Marshal(consent.IDTokenExtra)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return pkg.SQLTransaction(m.DB, func(tx *sqlx.Tx) error {
+		if _, err := tx.Exec(
+			tx.Rebind("DELETE FROM hydra_oauth2_consent_remembered WHERE subject=? AND client_id=?"),
+			consent.Subject, consent.ClientID,
+		); err != nil {
+			return errors.WithStack(err)
+		}
+
+		if _, err := tx.Exec(
+			tx.Rebind("INSERT INTO hydra_oauth2_consent_remembered (subject, client_id, consent_request_id, scopes, access_token_extra, id_token_extra, remembered_at, expires_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)"),
+			consent.Subject, consent.ClientID, consent.ConsentRequestID, strings.Join(mergeScopes(nil, consent.GrantedScopes), " "),
+			string(atext), string(idtext), consent.RememberedAt.UTC(), consent.ExpiresAt.UTC(),
+		); err != nil {
+			return errors.WithStack(err)
+		}
+		return nil
+	})
+}
+
+func (m *RememberedConsentSQLManager) GetRememberedConsent(subject, clientID string) (*RememberedConsent, error) {
+	var d sqlRememberedConsent
+	if err := m.DB.Get(
+		&d, m.DB.Rebind("SELECT * FROM hydra_oauth2_consent_remembered WHERE subject=? AND client_id=? AND expires_at>?"),
+		subject, clientID, time.Now().UTC(),
+	); err == sql.ErrNoRows {
+		return nil, errors.WithStack(pkg.ErrNotFound)
+	} else if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return d.toRememberedConsent()
+}
+
+func (m *RememberedConsentSQLManager) ForgetConsent(subject, clientID string) error {
+	if _, err := m.DB.Exec(m.DB.Rebind("DELETE FROM hydra_oauth2_consent_remembered WHERE subject=? AND client_id=?"), subject, clientID); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func (m *RememberedConsentSQLManager) ForgetSubjectConsents(subject string) ([]string, error) {
+	var clientIDs []string
+	if err := pkg.SQLTransaction(m.DB, func(tx *sqlx.Tx) error {
+		clientIDs = []string{}
+		if err := tx.Select(&clientIDs, tx.Rebind("SELECT client_id FROM hydra_oauth2_consent_remembered WHERE subject=? ORDER BY client_id"), subject); err != nil {
+			return errors.WithStack(err)
+		}
+
+		if _, err := tx.Exec(tx.Rebind("DELETE FROM hydra_oauth2_consent_remembered WHERE subject=?"), subject); err != nil {
+			return errors.WithStack(err)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return clientIDs, nil
+}
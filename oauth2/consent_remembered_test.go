@@ -0,0 +1,136 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2_test
+
+import (
+	"fmt"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/ory/hydra/integration"
+	. "github.com/ory/hydra/oauth2"
+	"github.com/ory/hydra/pkg"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var rememberedConsentManagers = map[string]RememberedConsentManager{
+	"memory": NewRememberedConsentMemoryManager(),
+}
+
+func connectToMySQLRememberedConsent() {
+	s := &RememberedConsentSQLManager{DB: integration.ConnectToMySQL()}
+	if _, err := s.CreateSchemas(); err != nil {
+		log.Fatalf("Could not create mysql schema: %v", err)
+	}
+
+	rememberedConsentManagers["mysql"] = s
+}
+
+func connectToPGRememberedConsent() {
+	s := &RememberedConsentSQLManager{DB: integration.ConnectToPostgres()}
+	if _, err := s.CreateSchemas(); err != nil {
+		log.Fatalf("Could not create postgres schema: %v", err)
+	}
+
+	rememberedConsentManagers["postgres"] = s
+}
+
+func TestRememberedConsentManagers(t *testing.T) {
+	now := time.Now().UTC().Round(time.Second)
+	for k, m := range rememberedConsentManagers {
+		t.Run(fmt.Sprintf("case=%s", k), func(t *testing.T) {
+			subject := "peter-" + uuid.New()
+
+			require.NoError(t, m.RememberConsent(&RememberedConsent{
+				Subject:          subject,
+				ClientID:         "photos",
+				ConsentRequestID: "first",
+				GrantedScopes:    []string{"openid"},
+				RememberedAt:     now.Add(-time.Hour),
+				ExpiresAt:        now.Add(time.Hour),
+			}))
+			require.NoError(t, m.RememberConsent(&RememberedConsent{
+				Subject:          subject,
+				ClientID:         "photos",
+				ConsentRequestID: "second",
+				GrantedScopes:    []string{"photos.read", "openid"},
+				AccessTokenExtra: map[string]interface{}{"foo": "bar"},
+				RememberedAt:     now,
+				ExpiresAt:        now.Add(time.Hour),
+			}))
+			require.NoError(t, m.RememberConsent(&RememberedConsent{
+				Subject:          subject,
+				ClientID:         "contacts",
+				ConsentRequestID: "expired",
+				GrantedScopes:    []string{"contacts"},
+				RememberedAt:     now.Add(-time.Hour),
+				ExpiresAt:        now.Add(-time.Minute),
+			}))
+
+			consent, err := m.GetRememberedConsent(subject, "photos")
+			require.NoError(t, err)
+			assert.Equal(t, "second", consent.ConsentRequestID)
+			assert.Equal(t, []string{"openid", "photos.read"}, consent.GrantedScopes)
+			assert.Equal(t, map[string]interface{}{"foo": "bar"}, consent.AccessTokenExtra)
+			assert.Equal(t, now.Unix(), consent.RememberedAt.Unix())
+			assert.Equal(t, now.Add(time.Hour).Unix(), consent.ExpiresAt.Unix())
+
+			_, err = m.GetRememberedConsent(subject, "contacts")
+			assert.Equal(t, pkg.ErrNotFound, errors.Cause(err))
+
+			require.NoError(t, m.ForgetConsent(subject, "photos"))
+			require.NoError(t, m.ForgetConsent(subject, "photos"))
+			_, err = m.GetRememberedConsent(subject, "photos")
+			assert.Equal(t, pkg.ErrNotFound, errors.Cause(err))
+
+			clientIDs, err := m.ForgetSubjectConsents(subject)
+			require.NoError(t, err)
+			assert.Equal(t, []string{"contacts"}, clientIDs)
+
+			clientIDs, err = m.ForgetSubjectConsents(subject)
+			require.NoError(t, err)
+			assert.Empty(t, clientIDs)
+		})
+	}
+}
+
+func TestConsentRememberer(t *testing.T) {
+	remembered := NewRememberedConsentMemoryManager()
+	m := &ConsentRememberer{ConsentRequestManager: NewConsentRequestMemoryManager(), Remembered: remembered}
+
+	for _, id := range []string{"remembered", "once"} {
+		require.NoError(t, m.PersistConsentRequest(&ConsentRequest{
+			ID:              id,
+			ClientID:        id,
+			RequestedScopes: []string{"openid", "photos"},
+			ExpiresAt:       time.Now().Add(time.Hour),
+		}))
+	}
+	require.NoError(t, m.AcceptConsentRequest("remembered", &AcceptConsentRequestPayload{Subject: "peter", GrantScopes: []string{"openid"}, RememberFor: 60}))
+	require.NoError(t, m.AcceptConsentRequest("once", &AcceptConsentRequestPayload{Subject: "peter", GrantScopes: []string{"openid"}}))
+
+	consent, err := remembered.GetRememberedConsent("peter", "remembered")
+	require.NoError(t, err)
+	assert.Equal(t, "remembered", consent.ConsentRequestID)
+	assert.Equal(t, []string{"openid"}, consent.GrantedScopes)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), consent.ExpiresAt, time.Second*5)
+
+	_, err = remembered.GetRememberedConsent("peter", "once")
+	assert.Equal(t, pkg.ErrNotFound, errors.Cause(err))
+}
@@ -17,16 +17,19 @@ package oauth2_test
 import (
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 	"time"
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/ory/fosite"
 	"github.com/ory/herodot"
+	"github.com/ory/hydra/client"
 	"github.com/ory/hydra/compose"
 	. "github.com/ory/hydra/oauth2"
 	hydra "github.com/ory/hydra/sdk/go/hydra/swagger"
 	"github.com/ory/ladon"
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -101,3 +104,145 @@ func TestConsentSDK(t *testing.T) {
 	assert.Equal(t, "MyReason", gotMem.DenyReason)
 	assert.False(t, gotMem.IsConsentGranted())
 }
+
+func TestConsentSDKPark(t *testing.T) {
+	req := &ConsentRequest{
+		ID:               "id-park",
+		ClientID:         "client-id",
+		RequestedScopes:  []string{"foo"},
+		GrantedScopes:    []string{},
+		CSRF:             "some-csrf",
+		ExpiresAt:        time.Now().UTC().Add(time.Minute),
+		AccessTokenExtra: map[string]interface{}{},
+		IDTokenExtra:     map[string]interface{}{},
+		RedirectURL:      "https://redirect-me/foo",
+	}
+
+	memm := NewConsentRequestMemoryManager()
+	var localWarden, httpClient = compose.NewMockFirewall("foo", "app-client", fosite.Arguments{ConsentScope}, &ladon.DefaultPolicy{
+		ID:        "1",
+		Subjects:  []string{"app-client"},
+		Resources: []string{"rn:hydra:oauth2:consent:requests:<.*>"},
+		Actions:   []string{"park", "accept"},
+		Effect:    ladon.AllowAccess,
+	})
+
+	require.NoError(t, memm.PersistConsentRequest(req))
+	h := &ConsentSessionHandler{M: memm, W: localWarden, H: herodot.NewJSONWriter(nil), ParkLifespan: time.Hour, Issuer: "https://hydra.localhost"}
+
+	r := httprouter.New()
+	h.SetRoutes(r)
+	server := httptest.NewServer(r)
+
+	client := hydra.NewOAuth2ApiWithBasePath(server.URL)
+	client.Configuration.Transport = httpClient.Transport
+
+	parked, response, err := client.ParkOAuth2ConsentRequest(req.ID)
+	require.NoError(t, err)
+	require.EqualValues(t, http.StatusOK, response.StatusCode)
+	assert.NotEmpty(t, parked.Handle)
+	assert.True(t, parked.ExpiresAt.After(time.Now().UTC().Add(time.Minute*59)))
+	assert.Equal(t, "https://hydra.localhost"+ResumePath+"?handle="+url.QueryEscape(parked.Handle), parked.ResumeUrl)
+
+	consentURL, _ := url.Parse("https://consent.localhost/consent")
+	oh := &Handler{ConsentManager: memm, ConsentURL: *consentURL, L: logrus.New()}
+
+	t.Run("case=resume", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		oh.ResumeHandler(w, httptest.NewRequest("GET", ResumePath+"?handle="+url.QueryEscape(parked.Handle), nil), nil)
+		assert.Equal(t, http.StatusFound, w.Code)
+		assert.Equal(t, "https://consent.localhost/consent?consent="+req.ID, w.Header().Get("Location"))
+	})
+
+	t.Run("case=unknown handle", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		oh.ResumeHandler(w, httptest.NewRequest("GET", ResumePath+"?handle=foo", nil), nil)
+		assert.Equal(t, http.StatusFound, w.Code)
+		location, err := url.Parse(w.Header().Get("Location"))
+		require.NoError(t, err)
+		assert.Equal(t, "invalid_resumption_handle", location.Query().Get("error"))
+	})
+
+	response, err = client.AcceptOAuth2ConsentRequest(req.ID, hydra.ConsentRequestAcceptance{Subject: "peter"})
+	require.NoError(t, err)
+	assert.EqualValues(t, http.StatusNoContent, response.StatusCode)
+
+	t.Run("case=resume after accept", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		oh.ResumeHandler(w, httptest.NewRequest("GET", ResumePath+"?handle="+url.QueryEscape(parked.Handle), nil), nil)
+		location, err := url.Parse(w.Header().Get("Location"))
+		require.NoError(t, err)
+		assert.Equal(t, "invalid_resumption_handle", location.Query().Get("error"))
+	})
+
+	_, response, err = client.ParkOAuth2ConsentRequest(req.ID)
+	require.NoError(t, err)
+	assert.EqualValues(t, http.StatusBadRequest, response.StatusCode)
+}
+
+func TestConsentSDKPolicies(t *testing.T) {
+	clients := client.NewMemoryManager(&fosite.BCrypt{WorkFactor: 4})
+	require.NoError(t, clients.CreateClient(&client.Client{ID: "first-party", FirstParty: true, Environment: "production"}))
+	require.NoError(t, clients.CreateClient(&client.Client{ID: "third-party", Environment: "production"}))
+	require.NoError(t, clients.CreateClient(&client.Client{ID: "internal", SkipConsent: true}))
+
+	memm := NewConsentRequestMemoryManager()
+	for _, id := range []string{"first-party", "third-party", "internal"} {
+		require.NoError(t, memm.PersistConsentRequest(&ConsentRequest{
+			ID:              "id-" + id,
+			ClientID:        id,
+			RequestedScopes: []string{"openid", "offline", "photos"},
+			ExpiresAt:       time.Now().Add(time.Hour),
+		}))
+	}
+
+	var localWarden, httpClient = compose.NewMockFirewall("foo", "app-client", fosite.Arguments{ConsentScope}, &ladon.DefaultPolicy{
+		ID:        "1",
+		Subjects:  []string{"app-client"},
+		Resources: []string{"rn:hydra:oauth2:consent:requests:<.*>"},
+		Actions:   []string{"get"},
+		Effect:    ladon.AllowAccess,
+	}, &ladon.DefaultPolicy{
+		ID:        "2",
+		Subjects:  []string{"<.*>"},
+		Resources: []string{"rn:hydra:oauth2:consent:scopes:openid", "rn:hydra:oauth2:consent:scopes:photos"},
+		Actions:   []string{"grant"},
+		Effect:    ladon.AllowAccess,
+		Conditions: ladon.Conditions{
+			"firstParty": &ladon.BooleanCondition{BooleanValue: true},
+		},
+	}, &ladon.DefaultPolicy{
+		ID:        "3",
+		Subjects:  []string{"<.*>"},
+		Resources: []string{"rn:hydra:oauth2:consent:clients:<.*>"},
+		Actions:   []string{"skip"},
+		Effect:    ladon.AllowAccess,
+		Conditions: ladon.Conditions{
+			"firstParty":  &ladon.BooleanCondition{BooleanValue: true},
+			"environment": &ladon.StringEqualCondition{Equals: "production"},
+		},
+	})
+
+	h := &ConsentSessionHandler{M: memm, W: localWarden, H: herodot.NewJSONWriter(nil), Clients: clients}
+	r := httprouter.New()
+	h.SetRoutes(r)
+	server := httptest.NewServer(r)
+
+	sdk := hydra.NewOAuth2ApiWithBasePath(server.URL)
+	sdk.Configuration.Transport = httpClient.Transport
+
+	got, _, err := sdk.GetOAuth2ConsentRequest("id-first-party")
+	require.NoError(t, err)
+	assert.EqualValues(t, []string{"openid", "photos"}, got.AutoGrantedScopes)
+	assert.True(t, got.SkipConsent)
+
+	got, _, err = sdk.GetOAuth2ConsentRequest("id-third-party")
+	require.NoError(t, err)
+	assert.Empty(t, got.AutoGrantedScopes)
+	assert.False(t, got.SkipConsent)
+
+	got, _, err = sdk.GetOAuth2ConsentRequest("id-internal")
+	require.NoError(t, err)
+	assert.EqualValues(t, []string{"openid", "offline", "photos"}, got.AutoGrantedScopes)
+	assert.True(t, got.SkipConsent)
+}
@@ -0,0 +1,141 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"sort"
+	"time"
+)
+
+// ConsentStatisticsBucket is the granularity at which consent statistics are kept.
+const ConsentStatisticsBucket = time.Hour
+
+// ScopeStatistics counts how often a client requested a scope in consent requests, and how often users granted or
+// denied it.
+//
+// swagger:model consentScopeStatistics
+type ScopeStatistics struct {
+	// ClientID is the id of the client which requested the scope.
+	ClientID string `json:"clientId"`
+
+	// Scope is the requested scope.
+	Scope string `json:"scope"`
+
+	// Requested is the number of consent requests the scope was requested in.
+	Requested int64 `json:"requested"`
+
+	// Granted is the number of accepted consent requests the scope was granted in.
+	Granted int64 `json:"granted"`
+
+	// Denied is the number of accepted consent requests the scope was not granted in, plus the number of rejected
+	// consent requests the scope was requested in. Consent requests which were neither accepted nor rejected are
+	// neither counted as granted nor as denied.
+	Denied int64 `json:"denied"`
+}
+
+// ConsentStatisticsManager keeps per client and scope counts of requested, granted and denied scopes in buckets of
+// ConsentStatisticsBucket.
+type ConsentStatisticsManager interface {
+	// RecordScopeStatistics adds the requested, granted and denied scopes of a consent request of the client to the
+	// bucket containing at.
+	RecordScopeStatistics(clientID string, at time.Time, requested, granted, denied []string) error
+
+	// GetScopeStatistics returns the sums of all buckets starting in [since, until). If clientID is empty, the
+	// statistics of all clients are returned. The result is sorted by client id and the number of requests.
+	GetScopeStatistics(clientID string, since, until time.Time) ([]ScopeStatistics, error)
+}
+
+// ConsentStatisticsRecorder records the scopes of consent requests in Statistics when they are persisted, accepted
+// or rejected.
+type ConsentStatisticsRecorder struct {
+	ConsentRequestManager
+	Statistics ConsentStatisticsManager
+}
+
+func (r *ConsentStatisticsRecorder) PersistConsentRequest(request *ConsentRequest) error {
+	if err := r.ConsentRequestManager.PersistConsentRequest(request); err != nil {
+		return err
+	}
+	return r.Statistics.RecordScopeStatistics(request.ClientID, time.Now().UTC(), request.RequestedScopes, nil, nil)
+}
+
+func (r *ConsentStatisticsRecorder) AcceptConsentRequest(id string, payload *AcceptConsentRequestPayload) error {
+	request, err := r.ConsentRequestManager.GetConsentRequest(id)
+	if err != nil {
+		return err
+	}
+
+	if err := r.ConsentRequestManager.AcceptConsentRequest(id, payload); err != nil {
+		return err
+	}
+
+	granted := map[string]bool{}
+	for _, scope := range payload.GrantScopes {
+		granted[scope] = true
+	}
+
+	var denied []string
+	for _, scope := range request.RequestedScopes {
+		if !granted[scope] {
+			denied = append(denied, scope)
+		}
+	}
+	return r.Statistics.RecordScopeStatistics(request.ClientID, time.Now().UTC(), nil, payload.GrantScopes, denied)
+}
+
+func (r *ConsentStatisticsRecorder) RejectConsentRequest(id string, payload *RejectConsentRequestPayload) error {
+	request, err := r.ConsentRequestManager.GetConsentRequest(id)
+	if err != nil {
+		return err
+	}
+
+	if err := r.ConsentRequestManager.RejectConsentRequest(id, payload); err != nil {
+		return err
+	}
+	return r.Statistics.RecordScopeStatistics(request.ClientID, time.Now().UTC(), nil, nil, request.RequestedScopes)
+}
+
+// scopeCounts counts how often each scope appears in requested, granted and denied.
+func scopeCounts(requested, granted, denied []string) map[string]*ScopeStatistics {
+	counts := map[string]*ScopeStatistics{}
+	get := func(scope string) *ScopeStatistics {
+		if _, ok := counts[scope]; !ok {
+			counts[scope] = &ScopeStatistics{Scope: scope}
+		}
+		return counts[scope]
+	}
+
+	for _, scope := range requested {
+		get(scope).Requested++
+	}
+	for _, scope := range granted {
+		get(scope).Granted++
+	}
+	for _, scope := range denied {
+		get(scope).Denied++
+	}
+	return counts
+}
+
+func sortScopeStatistics(stats []ScopeStatistics) {
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].ClientID != stats[j].ClientID {
+			return stats[i].ClientID < stats[j].ClientID
+		} else if stats[i].Requested != stats[j].Requested {
+			return stats[i].Requested > stats[j].Requested
+		}
+		return stats[i].Scope < stats[j].Scope
+	})
+}
@@ -0,0 +1,81 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"sync"
+	"time"
+)
+
+type consentStatisticsKey struct {
+	clientID string
+	scope    string
+	bucket   int64
+}
+
+type ConsentStatisticsMemoryManager struct {
+	buckets map[consentStatisticsKey]ScopeStatistics
+	sync.RWMutex
+}
+
+func NewConsentStatisticsMemoryManager() *ConsentStatisticsMemoryManager {
+	return &ConsentStatisticsMemoryManager{buckets: map[consentStatisticsKey]ScopeStatistics{}}
+}
+
+func (m *ConsentStatisticsMemoryManager) RecordScopeStatistics(clientID string, at time.Time, requested, granted, denied []string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	bucket := at.UTC().Truncate(ConsentStatisticsBucket).Unix()
+	for scope, counts := range scopeCounts(requested, granted, denied) {
+		key := consentStatisticsKey{clientID: clientID, scope: scope, bucket: bucket}
+		stats := m.buckets[key]
+		stats.ClientID, stats.Scope = clientID, scope
+		stats.Requested += counts.Requested
+		stats.Granted += counts.Granted
+		stats.Denied += counts.Denied
+		m.buckets[key] = stats
+	}
+	return nil
+}
+
+func (m *ConsentStatisticsMemoryManager) GetScopeStatistics(clientID string, since, until time.Time) ([]ScopeStatistics, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	sums := map[consentStatisticsKey]*ScopeStatistics{}
+	for key, stats := range m.buckets {
+		if clientID != "" && key.clientID != clientID {
+			continue
+		} else if key.bucket < since.Unix() || key.bucket >= until.Unix() {
+			continue
+		}
+
+		sum := consentStatisticsKey{clientID: key.clientID, scope: key.scope}
+		if _, ok := sums[sum]; !ok {
+			sums[sum] = &ScopeStatistics{ClientID: key.clientID, Scope: key.scope}
+		}
+		sums[sum].Requested += stats.Requested
+		sums[sum].Granted += stats.Granted
+		sums[sum].Denied += stats.Denied
+	}
+
+	result := make([]ScopeStatistics, 0, len(sums))
+	for _, stats := range sums {
+		result = append(result, *stats)
+	}
+	sortScopeStatistics(result)
+	return result, nil
+}
@@ -0,0 +1,121 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/ory/hydra/pkg"
+	"github.com/pkg/errors"
+	"github.com/rubenv/sql-migrate"
+)
+
+var consentStatisticsMigrations = &migrate.MemoryMigrationSource{
+	Migrations: []*migrate.Migration{
+		{
+			Id: "1",
+			Up: []string{
+				`CREATE TABLE IF NOT EXISTS hydra_oauth2_consent_statistics (
+	client_id	varchar(255) NOT NULL,
+	scope		varchar(255) NOT NULL,
+	bucket		timestamp NOT NULL,
+	requested	bigint NOT NULL DEFAULT 0,
+	granted		bigint NOT NULL DEFAULT 0,
+	denied		bigint NOT NULL DEFAULT 0,
+	PRIMARY KEY (client_id, scope, bucket)
+)`,
+			},
+			Down: []string{
+				"DROP TABLE hydra_oauth2_consent_statistics",
+			},
+		},
+	},
+}
+
+type ConsentStatisticsSQLManager struct {
+	DB *sqlx.DB
+}
+
+// Migrations returns the SQL migrations embedded in the binary.
+func (m *ConsentStatisticsSQLManager) Migrations() *migrate.MemoryMigrationSource {
+	return consentStatisticsMigrations
+}
+
+func (m *ConsentStatisticsSQLManager) CreateSchemas() (int, error) {
+	migrate.SetTable("hydra_oauth2_consent_statistics_migration")
+	n, err := migrate.Exec(m.DB.DB, m.DB.DriverName(), consentStatisticsMigrations, migrate.Up)
+	if err != nil {
+		return 0, errors.Wrapf(err, "Could not migrate sql schema, applied %d migrations", n)
+	}
+	return n, nil
+}
+
+func (m *ConsentStatisticsSQLManager) RecordScopeStatistics(clientID string, at time.Time, requested, granted, denied []string) error {
+	dialect, err := pkg.SQLDialect(m.DB)
+	if err != nil {
+		return err
+	}
+
+	insert := "INSERT INTO hydra_oauth2_consent_statistics (client_id, scope, bucket) VALUES (?, ?, ?) ON CONFLICT DO NOTHING"
+	if dialect == pkg.SQLDialectMySQL {
+		insert = "INSERT IGNORE INTO hydra_oauth2_consent_statistics (client_id, scope, bucket) VALUES (?, ?, ?)"
+	}
+
+	bucket := at.UTC().Truncate(ConsentStatisticsBucket)
+	return pkg.SQLTransaction(m.DB, func(tx *sqlx.Tx) error {
+		for scope, counts := range scopeCounts(requested, granted, denied) {
+			if _, err := tx.Exec(tx.Rebind(insert), clientID, scope, bucket); err != nil {
+				return errors.WithStack(err)
+			}
+
+			if _, err := tx.Exec(tx.Rebind(`UPDATE hydra_oauth2_consent_statistics
+	SET requested=requested+?, granted=granted+?, denied=denied+?
+	WHERE client_id=? AND scope=? AND bucket=?`), counts.Requested, counts.Granted, counts.Denied, clientID, scope, bucket); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+		return nil
+	})
+}
+
+func (m *ConsentStatisticsSQLManager) GetScopeStatistics(clientID string, since, until time.Time) ([]ScopeStatistics, error) {
+	query := `SELECT client_id, scope, SUM(requested) AS requested, SUM(granted) AS granted, SUM(denied) AS denied
+	FROM hydra_oauth2_consent_statistics WHERE bucket >= ? AND bucket < ?`
+	args := []interface{}{since.UTC(), until.UTC()}
+	if clientID != "" {
+		query += " AND client_id=?"
+		args = append(args, clientID)
+	}
+	query += " GROUP BY client_id, scope"
+
+	var d []struct {
+		ClientID  string `db:"client_id"`
+		Scope     string `db:"scope"`
+		Requested int64  `db:"requested"`
+		Granted   int64  `db:"granted"`
+		Denied    int64  `db:"denied"`
+	}
+	if err := m.DB.Select(&d, m.DB.Rebind(query), args...); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	stats := make([]ScopeStatistics, len(d))
+	for k, row := range d {
+		stats[k] = ScopeStatistics{ClientID: row.ClientID, Scope: row.Scope, Requested: row.Requested, Granted: row.Granted, Denied: row.Denied}
+	}
+	sortScopeStatistics(stats)
+	return stats, nil
+}
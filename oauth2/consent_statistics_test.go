@@ -0,0 +1,107 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2_test
+
+import (
+	"fmt"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/ory/hydra/integration"
+	. "github.com/ory/hydra/oauth2"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var consentStatisticsManagers = map[string]ConsentStatisticsManager{
+	"memory": NewConsentStatisticsMemoryManager(),
+}
+
+func connectToMySQLConsentStatistics() {
+	s := &ConsentStatisticsSQLManager{DB: integration.ConnectToMySQL()}
+	if _, err := s.CreateSchemas(); err != nil {
+		log.Fatalf("Could not create mysql schema: %v", err)
+	}
+
+	consentStatisticsManagers["mysql"] = s
+}
+
+func connectToPGConsentStatistics() {
+	s := &ConsentStatisticsSQLManager{DB: integration.ConnectToPostgres()}
+	if _, err := s.CreateSchemas(); err != nil {
+		log.Fatalf("Could not create postgres schema: %v", err)
+	}
+
+	consentStatisticsManagers["postgres"] = s
+}
+
+func TestConsentStatisticsManagers(t *testing.T) {
+	bucket := time.Now().UTC().Truncate(time.Hour).Add(-time.Hour * 24)
+	for k, m := range consentStatisticsManagers {
+		t.Run(fmt.Sprintf("case=%s", k), func(t *testing.T) {
+			app, other := "app-"+uuid.New(), "other-"+uuid.New()
+
+			require.NoError(t, m.RecordScopeStatistics(app, bucket.Add(time.Minute), []string{"openid", "photos", "contacts"}, nil, nil))
+			require.NoError(t, m.RecordScopeStatistics(app, bucket.Add(time.Minute*2), nil, []string{"openid", "photos"}, []string{"contacts"}))
+			require.NoError(t, m.RecordScopeStatistics(app, bucket.Add(time.Hour), []string{"openid", "contacts"}, nil, nil))
+			require.NoError(t, m.RecordScopeStatistics(app, bucket.Add(time.Hour+time.Minute), nil, nil, []string{"openid", "contacts"}))
+			require.NoError(t, m.RecordScopeStatistics(other, bucket.Add(time.Hour), []string{"openid"}, []string{"openid"}, nil))
+
+			stats, err := m.GetScopeStatistics(app, bucket, bucket.Add(time.Hour*2))
+			require.NoError(t, err)
+			assert.Equal(t, []ScopeStatistics{
+				{ClientID: app, Scope: "contacts", Requested: 2, Denied: 2},
+				{ClientID: app, Scope: "openid", Requested: 2, Granted: 1, Denied: 1},
+				{ClientID: app, Scope: "photos", Requested: 1, Granted: 1},
+			}, stats)
+
+			stats, err = m.GetScopeStatistics(app, bucket.Add(time.Hour), bucket.Add(time.Hour*2))
+			require.NoError(t, err)
+			assert.Equal(t, []ScopeStatistics{
+				{ClientID: app, Scope: "contacts", Requested: 1, Denied: 1},
+				{ClientID: app, Scope: "openid", Requested: 1, Denied: 1},
+			}, stats)
+
+			stats, err = m.GetScopeStatistics(other, bucket, bucket.Add(time.Hour))
+			require.NoError(t, err)
+			assert.Empty(t, stats)
+		})
+	}
+}
+
+func TestConsentStatisticsRecorder(t *testing.T) {
+	stats := NewConsentStatisticsMemoryManager()
+	m := &ConsentStatisticsRecorder{ConsentRequestManager: NewConsentRequestMemoryManager(), Statistics: stats}
+
+	for _, id := range []string{"accepted", "rejected", "abandoned"} {
+		require.NoError(t, m.PersistConsentRequest(&ConsentRequest{
+			ID:              id,
+			ClientID:        "app",
+			RequestedScopes: []string{"openid", "photos"},
+			ExpiresAt:       time.Now().Add(time.Hour),
+		}))
+	}
+	require.NoError(t, m.AcceptConsentRequest("accepted", &AcceptConsentRequestPayload{Subject: "peter", GrantScopes: []string{"openid"}}))
+	require.NoError(t, m.RejectConsentRequest("rejected", &RejectConsentRequestPayload{Reason: "no"}))
+
+	got, err := stats.GetScopeStatistics("", time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, []ScopeStatistics{
+		{ClientID: "app", Scope: "openid", Requested: 3, Granted: 1, Denied: 1},
+		{ClientID: "app", Scope: "photos", Requested: 3, Denied: 2},
+	}, got)
+}
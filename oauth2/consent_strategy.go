@@ -16,27 +16,73 @@ package oauth2
 
 import (
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gorilla/sessions"
 	"github.com/ory/fosite"
 	"github.com/ory/fosite/handler/openid"
 	ejwt "github.com/ory/fosite/token/jwt"
+	"github.com/ory/hydra/client"
+	"github.com/ory/hydra/pkg"
 	"github.com/pborman/uuid"
 	"github.com/pkg/errors"
 )
 
 const (
 	CookieCSRFKey = "consent_csrf"
+
+	// CookieRememberedSubjectKey holds the subject which last asked to remember its consent in this user agent.
+	CookieRememberedSubjectKey = "consent_remembered_subject"
+
+	// CookieLoginSessionKey holds the id of the login session of the user agent, see LoginSession.
+	CookieLoginSessionKey = "consent_login_session"
 )
 
 type DefaultConsentStrategy struct {
 	Issuer string
 
-	KeyID                    string
-	DefaultIDTokenLifespan   time.Duration
+	KeyID                  string
+	DefaultIDTokenLifespan time.Duration
+
+	// DefaultChallengeLifespan is how long consent challenges remain valid unless the client configures its own
+	// consent challenge lifespan.
 	DefaultChallengeLifespan time.Duration
 	ConsentManager           ConsentRequestManager
+
+	// Remembered looks up remembered consent decisions, see RememberedConsent. Consent is never remembered if nil.
+	Remembered RememberedConsentManager
+
+	// LoginSessions, if set, tracks the clients of the login session of the user agent, which is sent to clients in
+	// the "sid" claim of ID tokens.
+	LoginSessions LoginSessionManager
+
+	// Challenges, Verifier and Sessions replace the consent challenges stored in ConsentManager, the verification of
+	// consent responses against ConsentManager and the sessions built by this strategy respectively, if set.
+	Challenges ConsentChallengeIssuer
+	Verifier   ConsentResponseVerifier
+	Sessions   ConsentSessionBuilder
+}
+
+func (s *DefaultConsentStrategy) challenges() ConsentChallengeIssuer {
+	if s.Challenges != nil {
+		return s.Challenges
+	}
+	return s
+}
+
+func (s *DefaultConsentStrategy) verifier() ConsentResponseVerifier {
+	if s.Verifier != nil {
+		return s.Verifier
+	}
+	return s
+}
+
+func (s *DefaultConsentStrategy) sessions() ConsentSessionBuilder {
+	if s.Sessions != nil {
+		return s.Sessions
+	}
+	return s
 }
 
 func (s *DefaultConsentStrategy) validateSession(req fosite.AuthorizeRequester, consent *ConsentRequest, cookie *sessions.Session) error {
@@ -66,14 +112,47 @@ func (s *DefaultConsentStrategy) validateSession(req fosite.AuthorizeRequester,
 }
 
 func (s *DefaultConsentStrategy) ValidateConsentRequest(req fosite.AuthorizeRequester, session string, cookie *sessions.Session) (claims *Session, err error) {
+	decision, err := s.verifier().VerifyConsentResponse(req, session, cookie)
+	if err != nil {
+		return nil, err
+	}
+
+	if decision.AuthTime.IsZero() {
+		decision.AuthTime = time.Now().UTC()
+	}
+	if err := validateAuthTime(req, decision.AuthTime, decision.RequestedAt); err != nil {
+		return nil, err
+	}
+	cookie.Values[CookieAuthTimeKey] = decision.AuthTime.Unix()
+
+	for _, scope := range decision.GrantedScopes {
+		req.GrantScope(scope)
+	}
+
+	if s.Remembered != nil && decision.Challenge != "" {
+		if remembered, err := s.Remembered.GetRememberedConsent(decision.Subject, req.GetClient().GetID()); err == nil && remembered.ConsentRequestID == decision.Challenge {
+			cookie.Values[CookieRememberedSubjectKey] = decision.Subject
+		} else if err != nil && errors.Cause(err) != pkg.ErrNotFound {
+			return nil, err
+		}
+	}
+
+	return s.sessions().BuildConsentSession(req, decision, cookie)
+}
+
+// VerifyConsentResponse verifies the consent request stored in ConsentManager against the CSRF token of the cookie
+// and the authorize request.
+func (s *DefaultConsentStrategy) VerifyConsentResponse(req fosite.AuthorizeRequester, challenge string, cookie *sessions.Session) (*ConsentDecision, error) {
 	defer delete(cookie.Values, CookieCSRFKey)
 
-	consent, err := s.ConsentManager.GetConsentRequest(session)
+	consent, err := s.ConsentManager.GetConsentRequest(challenge)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
 
-	if !consent.IsConsentGranted() {
+	if !consent.IsConsentGranted() && consent.DenyError != nil {
+		return nil, consent.DenyError.toRFC6749Error()
+	} else if !consent.IsConsentGranted() {
 		err := errors.New("The resource owner denied consent for this request")
 		return nil, &fosite.RFC6749Error{
 			Name:        "rejected_consent_request",
@@ -85,7 +164,7 @@ func (s *DefaultConsentStrategy) ValidateConsentRequest(req fosite.AuthorizeRequ
 	}
 
 	if err := s.validateSession(req, consent, cookie); err != nil {
-		if err := s.ConsentManager.RejectConsentRequest(session, &RejectConsentRequestPayload{
+		if err := s.ConsentManager.RejectConsentRequest(challenge, &RejectConsentRequestPayload{
 			Reason: "Unable to validate consent request",
 		}); err != nil {
 			return nil, err
@@ -93,52 +172,314 @@ func (s *DefaultConsentStrategy) ValidateConsentRequest(req fosite.AuthorizeRequ
 		return nil, err
 	}
 
-	for _, scope := range consent.GrantedScopes {
+	return &ConsentDecision{
+		Challenge:        consent.ID,
+		Subject:          consent.Subject,
+		GrantedScopes:    consent.GrantedScopes,
+		IDTokenExtra:     consent.IDTokenExtra,
+		AccessTokenExtra: consent.AccessTokenExtra,
+		UserinfoExtra:    consent.UserinfoExtra,
+		ACR:              consent.ACR,
+		AMR:              consent.AMR,
+		AuthTime:         consent.AuthTime,
+		RequestedAt:      consent.RequestedAt,
+	}, nil
+}
+
+// BuildConsentSession adds the client to the login session of the user agent and returns a session carrying the
+// subject, the authentication context and the extra claims of the decision.
+func (s *DefaultConsentStrategy) BuildConsentSession(req fosite.AuthorizeRequester, decision *ConsentDecision, cookie *sessions.Session) (*Session, error) {
+	sid, err := s.loginSession(req, decision.Subject, cookie)
+	if err != nil {
+		return nil, err
+	}
+
+	idTokenExtra := decision.IDTokenExtra
+	if sid != "" || decision.ACR != "" || len(decision.AMR) > 0 {
+		idTokenExtra = map[string]interface{}{}
+		for k, v := range decision.IDTokenExtra {
+			idTokenExtra[k] = v
+		}
+		if sid != "" {
+			idTokenExtra["sid"] = sid
+		}
+		if decision.ACR != "" {
+			idTokenExtra["acr"] = decision.ACR
+		}
+		if len(decision.AMR) > 0 {
+			idTokenExtra["amr"] = decision.AMR
+		}
+	}
+
+	return s.newSession(req, decision, idTokenExtra), nil
+}
+
+// RememberedConsent returns the session of the consent remembered for the subject stored in the cookie and the client,
+// if it grants all requested scopes. Clients can force the consent app to be asked using prompt=login, prompt=consent
+// or prompt=select_account, by requesting an authentication context class using acr_values or claims using the
+// claims parameter, or if the subject authenticated longer ago than max_age. Consent to clients which skip consent is
+// never remembered, see SkippedConsent.
+func (s *DefaultConsentStrategy) RememberedConsent(req fosite.AuthorizeRequester, cookie *sessions.Session) (*Session, error) {
+	if c, ok := req.GetClient().(*client.Client); ok && c.SkipConsent {
+		return s.SkippedConsent(req, cookie)
+	}
+
+	if s.Remembered == nil {
+		return nil, nil
+	}
+
+	subject, ok := cookie.Values[CookieRememberedSubjectKey].(string)
+	if !ok || subject == "" {
+		return nil, nil
+	}
+
+	if hasPrompt(req, "consent") || requiresAuthentication(req, cookie) {
+		return nil, nil
+	}
+
+	authTime, _ := cookieAuthTime(cookie)
+
+	remembered, err := s.Remembered.GetRememberedConsent(subject, req.GetClient().GetID())
+	if errors.Cause(err) == pkg.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if !remembered.covers(req.GetRequestedScopes()) {
+		return nil, nil
+	}
+
+	for _, scope := range req.GetRequestedScopes() {
 		req.GrantScope(scope)
 	}
 
+	return s.sessions().BuildConsentSession(req, &ConsentDecision{
+		Subject:          subject,
+		GrantedScopes:    req.GetRequestedScopes(),
+		IDTokenExtra:     remembered.IDTokenExtra,
+		AccessTokenExtra: remembered.AccessTokenExtra,
+		AuthTime:         authTime,
+	}, cookie)
+}
+
+// SkippedConsent accepts consent to a client which skips consent on behalf of the subject of the login session of the
+// user agent, granting all requested scopes. The consent app is asked to authenticate the subject if the user agent
+// has no login session, or if the client asks for authentication using prompt=login, prompt=select_account,
+// acr_values, claims or max_age. prompt=consent is ignored, these clients never show a consent screen.
+func (s *DefaultConsentStrategy) SkippedConsent(req fosite.AuthorizeRequester, cookie *sessions.Session) (*Session, error) {
+	subject, err := loginSessionSubject(s.LoginSessions, req, cookie)
+	if err != nil || subject == "" {
+		return nil, err
+	}
+
+	for _, scope := range req.GetRequestedScopes() {
+		req.GrantScope(scope)
+	}
+
+	authTime, _ := cookieAuthTime(cookie)
+	return s.sessions().BuildConsentSession(req, &ConsentDecision{
+		Subject:       subject,
+		GrantedScopes: req.GetRequestedScopes(),
+		AuthTime:      authTime,
+	}, cookie)
+}
+
+// loginSessionSubject returns the subject of the login session of the user agent, or an empty subject if the user
+// agent has no login session or the subject must authenticate again, see requiresAuthentication.
+func loginSessionSubject(manager LoginSessionManager, req fosite.AuthorizeRequester, cookie *sessions.Session) (string, error) {
+	if manager == nil || requiresAuthentication(req, cookie) {
+		return "", nil
+	}
+
+	id, _ := cookie.Values[CookieLoginSessionKey].(string)
+	if id == "" {
+		return "", nil
+	}
+
+	session, err := manager.GetLoginSession(id)
+	if errors.Cause(err) == pkg.ErrNotFound {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	return session.Subject, nil
+}
+
+// requiresAuthentication returns true if the consent app must authenticate the subject again, because the client
+// asked for it using prompt=login, prompt=select_account, acr_values or claims, or because the subject authenticated
+// longer ago than max_age.
+func requiresAuthentication(req fosite.AuthorizeRequester, cookie *sessions.Session) bool {
+	if hasPrompt(req, "login") || hasPrompt(req, "select_account") {
+		return true
+	}
+
+	if req.GetRequestForm().Get("acr_values") != "" || req.GetRequestForm().Get("claims") != "" {
+		return true
+	}
+
+	authTime, ok := cookieAuthTime(cookie)
+	age, hasMaxAge := maxAge(req)
+	return hasMaxAge && (!ok || time.Now().UTC().After(authTime.Add(age)))
+}
+
+// loginSession adds the client to the login session of the user agent and returns the session id. A new session is
+// started if the user agent has none or if it belongs to another subject. It returns an empty id if login sessions
+// are not tracked.
+func (s *DefaultConsentStrategy) loginSession(req fosite.AuthorizeRequester, subject string, cookie *sessions.Session) (string, error) {
+	if s.LoginSessions == nil {
+		return "", nil
+	}
+
+	id, _ := cookie.Values[CookieLoginSessionKey].(string)
+	if id != "" {
+		session, err := s.LoginSessions.GetLoginSession(id)
+		if err == nil && session.Subject != subject {
+			id = ""
+		} else if err != nil && errors.Cause(err) != pkg.ErrNotFound {
+			return "", err
+		}
+	}
+
+	if id == "" {
+		id = uuid.New()
+	}
+
+	if err := s.LoginSessions.AddLoginSessionClient(id, subject, req.GetClient().GetID()); err != nil {
+		return "", err
+	}
+
+	cookie.Values[CookieLoginSessionKey] = id
+	return id, nil
+}
+
+func (s *DefaultConsentStrategy) newSession(req fosite.AuthorizeRequester, decision *ConsentDecision, idTokenExtra map[string]interface{}) *Session {
 	timeNow := time.Now().UTC()
 
+	authTime, requestedAt := decision.AuthTime, decision.RequestedAt
+	if authTime.IsZero() {
+		authTime = timeNow
+	}
+	if requestedAt.IsZero() {
+		requestedAt = timeNow
+	}
+
+	// Access tokens issued by the authorization endpoint are restricted to the requested resources.
+	resources := requestedResources(req.GetRequestForm())
+
 	return &Session{
 		DefaultSession: &openid.DefaultSession{
 			Claims: &ejwt.IDTokenClaims{
 				Audience:    req.GetClient().GetID(),
-				Subject:     consent.Subject,
+				Subject:     decision.Subject,
 				Issuer:      s.Issuer,
 				IssuedAt:    timeNow,
 				ExpiresAt:   timeNow.Add(s.DefaultIDTokenLifespan).UTC(),
-				AuthTime:    timeNow,
-				RequestedAt: timeNow,
-				Extra:       consent.IDTokenExtra,
+				AuthTime:    authTime,
+				RequestedAt: requestedAt,
+				Extra:       idTokenExtra,
 			},
 			// required for lookup on jwk endpoint
 			Headers: &ejwt.Headers{Extra: map[string]interface{}{"kid": s.KeyID}},
-			Subject: consent.Subject,
+			Subject: decision.Subject,
 		},
-		Extra: consent.AccessTokenExtra,
-	}, err
+		Extra:         decision.AccessTokenExtra,
+		UserinfoExtra: decision.UserinfoExtra,
+		Audience:      resources,
+		Resources:     resources,
+	}
 }
 
 func (s *DefaultConsentStrategy) CreateConsentRequest(req fosite.AuthorizeRequester, redirectURL string, cookie *sessions.Session) (string, error) {
+	return s.challenges().IssueConsentChallenge(req, redirectURL, cookie)
+}
+
+// IssueConsentChallenge stores a consent request in ConsentManager and returns its id as the challenge.
+func (s *DefaultConsentStrategy) IssueConsentChallenge(req fosite.AuthorizeRequester, redirectURL string, cookie *sessions.Session) (string, error) {
+	consent, err := s.newConsentRequest(req, redirectURL, cookie)
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.ConsentManager.PersistConsentRequest(consent); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return consent.ID, nil
+}
+
+// CreateLoggedInConsentRequest stores a consent request for the subject the login app authenticated in ConsentManager
+// and returns its id as the challenge. The authentication of the subject is taken from the login request, the consent
+// app can not change it.
+func (s *DefaultConsentStrategy) CreateLoggedInConsentRequest(req fosite.AuthorizeRequester, redirectURL string, login *LoginRequest, cookie *sessions.Session) (string, error) {
+	if s.Challenges != nil {
+		return "", errors.New("Login challenges can not be used with a custom consent challenge issuer")
+	}
+
+	consent, err := s.newConsentRequest(req, redirectURL, cookie)
+	if err != nil {
+		return "", err
+	}
+
+	consent.Subject = login.Subject
+	consent.ACR = login.ACR
+	consent.AMR = login.AMR
+	consent.AuthTime = login.AuthTime
+	if !login.RequestedAt.IsZero() {
+		consent.RequestedAt = login.RequestedAt
+	}
+
+	if err := s.ConsentManager.PersistConsentRequest(consent); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return consent.ID, nil
+}
+
+// SkippedLoggedInConsent accepts consent to a client which skips consent on behalf of the subject the login app
+// authenticated, granting all requested scopes. The authentication of the subject is taken from the login request.
+func (s *DefaultConsentStrategy) SkippedLoggedInConsent(req fosite.AuthorizeRequester, login *LoginRequest, cookie *sessions.Session) (*Session, error) {
+	for _, scope := range req.GetRequestedScopes() {
+		req.GrantScope(scope)
+	}
+
+	return s.sessions().BuildConsentSession(req, &ConsentDecision{
+		Subject:       login.Subject,
+		GrantedScopes: req.GetRequestedScopes(),
+		ACR:           login.ACR,
+		AMR:           login.AMR,
+		AuthTime:      login.AuthTime,
+		RequestedAt:   login.RequestedAt,
+	}, cookie)
+}
+
+func (s *DefaultConsentStrategy) newConsentRequest(req fosite.AuthorizeRequester, redirectURL string, cookie *sessions.Session) (*ConsentRequest, error) {
 	csrf := uuid.New()
 	id := uuid.New()
 
+	lifespan := s.DefaultChallengeLifespan
+	if c, ok := req.GetClient().(*client.Client); ok {
+		lifespan = c.GetConsentChallengeLifespan(lifespan)
+	}
+
+	claims, err := parseClaimsRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
 	cookie.Values[CookieCSRFKey] = csrf
-	consent := &ConsentRequest{
+	return &ConsentRequest{
 		ID:               id,
 		CSRF:             csrf,
 		GrantedScopes:    []string{},
 		RequestedScopes:  req.GetRequestedScopes(),
+		ACRValues:        strings.Fields(req.GetRequestForm().Get("acr_values")),
+		RequestedClaims:  claims,
 		ClientID:         req.GetClient().GetID(),
-		ExpiresAt:        time.Now().Add(s.DefaultChallengeLifespan).UTC(),
+		ExpiresAt:        time.Now().Add(lifespan).UTC(),
+		RequestedAt:      time.Now().UTC(),
 		RedirectURL:      redirectURL + "&consent=" + id + "&consent_csrf=" + csrf,
 		AccessTokenExtra: map[string]interface{}{},
 		IDTokenExtra:     map[string]interface{}{},
-	}
-
-	if err := s.ConsentManager.PersistConsentRequest(consent); err != nil {
-		return "", errors.WithStack(err)
-	}
-
-	return id, nil
+	}, nil
 }
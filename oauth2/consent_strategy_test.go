@@ -22,6 +22,8 @@ import (
 
 	"github.com/gorilla/sessions"
 	"github.com/ory/fosite"
+	"github.com/ory/hydra/client"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -142,3 +144,311 @@ func TestConsentStrategy(t *testing.T) {
 		}
 	})
 }
+
+func TestConsentStrategyChallengeLifespan(t *testing.T) {
+	strategy := &DefaultConsentStrategy{
+		ConsentManager:           NewConsentRequestMemoryManager(),
+		DefaultChallengeLifespan: time.Minute * 5,
+	}
+
+	for k, tc := range []struct {
+		c        fosite.Client
+		expected time.Duration
+	}{
+		{c: &fosite.DefaultClient{ID: "default-client"}, expected: time.Minute * 5},
+		{c: &client.Client{ID: "web"}, expected: time.Minute * 5},
+		{c: &client.Client{ID: "kiosk", ConsentChallengeLifespan: "45m"}, expected: time.Minute * 45},
+		{c: &client.Client{ID: "invalid", ConsentChallengeLifespan: "forever"}, expected: time.Minute * 5},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			cookie := &sessions.Session{Values: map[interface{}]interface{}{}}
+			req := &fosite.AuthorizeRequest{Request: fosite.Request{Client: tc.c}}
+
+			id, err := strategy.CreateConsentRequest(req, "http://localhost/oauth2/auth?client_id="+tc.c.GetID(), cookie)
+			require.NoError(t, err)
+
+			consent, err := strategy.ConsentManager.GetConsentRequest(id)
+			require.NoError(t, err)
+			assert.WithinDuration(t, time.Now().Add(tc.expected), consent.ExpiresAt, time.Minute)
+		})
+	}
+}
+
+func TestConsentStrategyRememberedConsent(t *testing.T) {
+	consents := &ConsentRememberer{ConsentRequestManager: NewConsentRequestMemoryManager(), Remembered: NewRememberedConsentMemoryManager()}
+	strategy := &DefaultConsentStrategy{ConsentManager: consents, Remembered: consents.Remembered, DefaultChallengeLifespan: time.Hour}
+
+	newRequest := func(scopes []string, form url.Values) *fosite.AuthorizeRequest {
+		return &fosite.AuthorizeRequest{Request: fosite.Request{
+			Client:          &fosite.DefaultClient{ID: "client_id"},
+			RequestedScopes: scopes,
+			Form:            form,
+		}}
+	}
+
+	cookie := &sessions.Session{Values: map[interface{}]interface{}{}}
+	req := newRequest([]string{"openid", "photos"}, url.Values{})
+	_, err := strategy.RememberedConsent(req, cookie)
+	require.NoError(t, err)
+
+	id, err := strategy.CreateConsentRequest(req, "http://localhost/oauth2/auth?client_id=client_id", cookie)
+	require.NoError(t, err)
+	require.NoError(t, consents.AcceptConsentRequest(id, &AcceptConsentRequestPayload{
+		Subject:          "peter",
+		GrantScopes:      []string{"openid", "photos"},
+		AccessTokenExtra: map[string]interface{}{"foo": "bar"},
+		RememberFor:      3600,
+	}))
+
+	consent, err := consents.GetConsentRequest(id)
+	require.NoError(t, err)
+	_, err = strategy.ValidateConsentRequest(newRequest([]string{"openid", "photos"}, url.Values{"consent_csrf": {consent.CSRF}}), id, cookie)
+	require.NoError(t, err)
+	assert.Equal(t, "peter", cookie.Values[CookieRememberedSubjectKey])
+
+	for _, tc := range []struct {
+		d        string
+		scopes   []string
+		form     url.Values
+		cookie   *sessions.Session
+		expected bool
+	}{
+		{d: "remembered", scopes: []string{"photos"}, form: url.Values{}, cookie: cookie, expected: true},
+		{d: "remembered with prompt none", scopes: []string{"openid", "photos"}, form: url.Values{"prompt": {"none"}}, cookie: cookie, expected: true},
+		{d: "scope not remembered", scopes: []string{"openid", "contacts"}, form: url.Values{}, cookie: cookie},
+		{d: "prompt consent", scopes: []string{"photos"}, form: url.Values{"prompt": {"consent"}}, cookie: cookie},
+		{d: "prompt login", scopes: []string{"photos"}, form: url.Values{"prompt": {"none login"}}, cookie: cookie},
+		{d: "acr values", scopes: []string{"photos"}, form: url.Values{"acr_values": {"urn:mace:incommon:iap:silver"}}, cookie: cookie},
+		{d: "claims", scopes: []string{"photos"}, form: url.Values{"claims": {`{"userinfo":{"email":null}}`}}, cookie: cookie},
+		{d: "prompt select account", scopes: []string{"photos"}, form: url.Values{"prompt": {"select_account"}}, cookie: cookie},
+		{d: "max age", scopes: []string{"photos"}, form: url.Values{"max_age": {"3600"}}, cookie: cookie, expected: true},
+		{d: "max age exceeded", scopes: []string{"photos"}, form: url.Values{"max_age": {"0"}}, cookie: &sessions.Session{Values: map[interface{}]interface{}{
+			CookieRememberedSubjectKey: "peter",
+			CookieAuthTimeKey:          time.Now().Add(-time.Minute).Unix(),
+		}}},
+		{d: "other user agent", scopes: []string{"photos"}, form: url.Values{}, cookie: &sessions.Session{Values: map[interface{}]interface{}{}}},
+	} {
+		t.Run(fmt.Sprintf("case=%s", tc.d), func(t *testing.T) {
+			req := newRequest(tc.scopes, tc.form)
+			session, err := strategy.RememberedConsent(req, tc.cookie)
+			require.NoError(t, err)
+			if !tc.expected {
+				assert.Nil(t, session)
+				return
+			}
+
+			require.NotNil(t, session)
+			assert.Equal(t, "peter", session.Subject)
+			assert.Equal(t, map[string]interface{}{"foo": "bar"}, session.Extra)
+			assert.Equal(t, fosite.Arguments(tc.scopes), req.GetGrantedScopes())
+		})
+	}
+
+	t.Run("case=forgotten", func(t *testing.T) {
+		require.NoError(t, consents.Remembered.ForgetConsent("peter", "client_id"))
+		session, err := strategy.RememberedConsent(newRequest([]string{"photos"}, url.Values{}), cookie)
+		require.NoError(t, err)
+		assert.Nil(t, session)
+	})
+}
+
+func TestConsentStrategySkippedConsent(t *testing.T) {
+	strategy := &DefaultConsentStrategy{
+		ConsentManager:           NewConsentRequestMemoryManager(),
+		Remembered:               NewRememberedConsentMemoryManager(),
+		LoginSessions:            NewLoginSessionMemoryManager(),
+		DefaultChallengeLifespan: time.Hour,
+	}
+	require.NoError(t, strategy.LoginSessions.AddLoginSessionClient("session-id", "peter", "other-client"))
+
+	cookie := &sessions.Session{Values: map[interface{}]interface{}{
+		CookieLoginSessionKey: "session-id",
+		CookieAuthTimeKey:     time.Now().Add(-time.Minute).Unix(),
+	}}
+
+	for _, tc := range []struct {
+		d        string
+		client   *client.Client
+		form     url.Values
+		cookie   *sessions.Session
+		expected bool
+	}{
+		{d: "logged in", client: &client.Client{ID: "internal", SkipConsent: true}, form: url.Values{}, cookie: cookie, expected: true},
+		{d: "prompt consent", client: &client.Client{ID: "internal", SkipConsent: true}, form: url.Values{"prompt": {"consent"}}, cookie: cookie, expected: true},
+		{d: "prompt login", client: &client.Client{ID: "internal", SkipConsent: true}, form: url.Values{"prompt": {"login"}}, cookie: cookie},
+		{d: "max age exceeded", client: &client.Client{ID: "internal", SkipConsent: true}, form: url.Values{"max_age": {"30"}}, cookie: cookie},
+		{d: "acr values", client: &client.Client{ID: "internal", SkipConsent: true}, form: url.Values{"acr_values": {"urn:mace:incommon:iap:silver"}}, cookie: cookie},
+		{d: "not logged in", client: &client.Client{ID: "internal", SkipConsent: true}, form: url.Values{}, cookie: &sessions.Session{Values: map[interface{}]interface{}{}}},
+		{d: "unknown login session", client: &client.Client{ID: "internal", SkipConsent: true}, form: url.Values{}, cookie: &sessions.Session{Values: map[interface{}]interface{}{
+			CookieLoginSessionKey: "unknown",
+		}}},
+		{d: "client asks for consent", client: &client.Client{ID: "third-party"}, form: url.Values{}, cookie: cookie},
+	} {
+		t.Run(fmt.Sprintf("case=%s", tc.d), func(t *testing.T) {
+			req := &fosite.AuthorizeRequest{Request: fosite.Request{
+				Client:          tc.client,
+				RequestedScopes: []string{"openid", "photos"},
+				Form:            tc.form,
+			}}
+			session, err := strategy.RememberedConsent(req, tc.cookie)
+			require.NoError(t, err)
+			if !tc.expected {
+				assert.Nil(t, session)
+				return
+			}
+
+			require.NotNil(t, session)
+			assert.Equal(t, "peter", session.Subject)
+			assert.Equal(t, "session-id", session.DefaultSession.Claims.Extra["sid"])
+			assert.Equal(t, fosite.Arguments{"openid", "photos"}, req.GetGrantedScopes())
+		})
+	}
+}
+
+func TestConsentStrategyAuthenticationContext(t *testing.T) {
+	strategy := &DefaultConsentStrategy{ConsentManager: NewConsentRequestMemoryManager(), DefaultChallengeLifespan: time.Hour}
+
+	cookie := &sessions.Session{Values: map[interface{}]interface{}{}}
+	req := &fosite.AuthorizeRequest{Request: fosite.Request{
+		Client:          &fosite.DefaultClient{ID: "client_id"},
+		RequestedScopes: []string{"openid"},
+		Form: url.Values{
+			"acr_values": {"urn:mace:incommon:iap:silver urn:mace:incommon:iap:bronze"},
+			"claims":     {`{"userinfo":{"email":{"essential":true}}}`},
+		},
+	}}
+
+	id, err := strategy.CreateConsentRequest(req, "http://localhost/oauth2/auth?client_id=client_id", cookie)
+	require.NoError(t, err)
+
+	consent, err := strategy.ConsentManager.GetConsentRequest(id)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"urn:mace:incommon:iap:silver", "urn:mace:incommon:iap:bronze"}, consent.ACRValues)
+	assert.Equal(t, &ClaimsRequest{Userinfo: map[string]*ClaimRequest{"email": {Essential: true}}}, consent.RequestedClaims)
+
+	require.NoError(t, strategy.ConsentManager.AcceptConsentRequest(id, &AcceptConsentRequestPayload{
+		Subject:       "peter",
+		GrantScopes:   []string{"openid"},
+		IDTokenExtra:  map[string]interface{}{"foo": "bar"},
+		UserinfoExtra: map[string]interface{}{"email": "peter@example.com"},
+		ACR:           "urn:mace:incommon:iap:silver",
+		AMR:           []string{"pwd", "otp"},
+	}))
+
+	req.Form.Set("consent_csrf", consent.CSRF)
+	session, err := strategy.ValidateConsentRequest(req, id, cookie)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"foo": "bar",
+		"acr": "urn:mace:incommon:iap:silver",
+		"amr": []string{"pwd", "otp"},
+	}, session.DefaultSession.Claims.Extra)
+	assert.Equal(t, map[string]interface{}{"email": "peter@example.com"}, session.UserinfoExtra)
+}
+
+func TestConsentStrategyAuthTime(t *testing.T) {
+	strategy := &DefaultConsentStrategy{ConsentManager: NewConsentRequestMemoryManager(), DefaultChallengeLifespan: time.Hour}
+
+	for _, tc := range []struct {
+		d         string
+		form      url.Values
+		authTime  time.Time
+		expectErr bool
+	}{
+		{d: "defaults to now", form: url.Values{}},
+		{d: "reported", form: url.Values{}, authTime: time.Now().Add(-time.Hour)},
+		{d: "prompt login", form: url.Values{"prompt": {"login"}}},
+		{d: "prompt login without authentication", form: url.Values{"prompt": {"login"}}, authTime: time.Now().Add(-time.Hour), expectErr: true},
+		{d: "max age", form: url.Values{"max_age": {"7200"}}, authTime: time.Now().Add(-time.Hour)},
+		{d: "max age exceeded", form: url.Values{"max_age": {"60"}}, authTime: time.Now().Add(-time.Hour), expectErr: true},
+	} {
+		t.Run(fmt.Sprintf("case=%s", tc.d), func(t *testing.T) {
+			cookie := &sessions.Session{Values: map[interface{}]interface{}{}}
+			req := &fosite.AuthorizeRequest{Request: fosite.Request{
+				Client:          &fosite.DefaultClient{ID: "client_id"},
+				RequestedScopes: []string{"openid"},
+				Form:            tc.form,
+			}}
+
+			id, err := strategy.CreateConsentRequest(req, "http://localhost/oauth2/auth?client_id=client_id", cookie)
+			require.NoError(t, err)
+			require.NoError(t, strategy.ConsentManager.AcceptConsentRequest(id, &AcceptConsentRequestPayload{
+				Subject:     "peter",
+				GrantScopes: []string{"openid"},
+				AuthTime:    tc.authTime,
+			}))
+
+			consent, err := strategy.ConsentManager.GetConsentRequest(id)
+			require.NoError(t, err)
+			req.Form.Set("consent_csrf", consent.CSRF)
+
+			session, err := strategy.ValidateConsentRequest(req, id, cookie)
+			if tc.expectErr {
+				assert.Equal(t, fosite.ErrLoginRequired, errors.Cause(err))
+				return
+			}
+			require.NoError(t, err)
+
+			expected := tc.authTime
+			if expected.IsZero() {
+				expected = time.Now()
+			}
+			assert.WithinDuration(t, expected, session.DefaultSession.Claims.AuthTime, time.Second)
+			assert.Equal(t, session.DefaultSession.Claims.AuthTime.Unix(), cookie.Values[CookieAuthTimeKey])
+		})
+	}
+}
+
+type staticConsentHandshake struct {
+	challenge string
+	decision  *ConsentDecision
+}
+
+func (h *staticConsentHandshake) IssueConsentChallenge(req fosite.AuthorizeRequester, redirectURL string, cookie *sessions.Session) (string, error) {
+	return h.challenge, nil
+}
+
+func (h *staticConsentHandshake) VerifyConsentResponse(req fosite.AuthorizeRequester, challenge string, cookie *sessions.Session) (*ConsentDecision, error) {
+	if challenge != h.challenge {
+		return nil, fosite.ErrInvalidRequest
+	}
+	return h.decision, nil
+}
+
+func TestConsentStrategyComposition(t *testing.T) {
+	handshake := &staticConsentHandshake{
+		challenge: "grpc-challenge",
+		decision: &ConsentDecision{
+			Challenge:        "grpc-challenge",
+			Subject:          "peter",
+			GrantedScopes:    []string{"openid"},
+			AccessTokenExtra: map[string]interface{}{"foo": "bar"},
+		},
+	}
+	strategy := &DefaultConsentStrategy{
+		ConsentManager: NewConsentRequestMemoryManager(),
+		Challenges:     handshake,
+		Verifier:       handshake,
+		LoginSessions:  NewLoginSessionMemoryManager(),
+	}
+
+	cookie := &sessions.Session{Values: map[interface{}]interface{}{}}
+	req := &fosite.AuthorizeRequest{Request: fosite.Request{Client: &fosite.DefaultClient{ID: "client_id"}, GrantedScopes: []string{}}}
+
+	challenge, err := strategy.CreateConsentRequest(req, "http://localhost/oauth2/auth?client_id=client_id", cookie)
+	require.NoError(t, err)
+	assert.Equal(t, "grpc-challenge", challenge)
+
+	_, err = strategy.ConsentManager.GetConsentRequest(challenge)
+	assert.Error(t, err)
+
+	_, err = strategy.ValidateConsentRequest(req, "other", cookie)
+	assert.Error(t, err)
+
+	session, err := strategy.ValidateConsentRequest(req, challenge, cookie)
+	require.NoError(t, err)
+	assert.Equal(t, "peter", session.DefaultSession.Subject)
+	assert.Equal(t, "bar", session.Extra["foo"])
+	assert.NotEmpty(t, session.DefaultSession.Claims.Extra["sid"])
+	assert.Equal(t, fosite.Arguments{"openid"}, req.GetGrantedScopes())
+}
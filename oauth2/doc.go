@@ -32,6 +32,14 @@ type swaggerConsentRequest struct {
 	// Redirect URL is the URL where the user agent should be redirected to after the consent has been
 	// accepted or rejected.
 	RedirectURL string `json:"redirectUrl"`
+
+	// AutoGrantedScopes are the requested scopes which policies allow to grant to the client without asking the user.
+	// All requested scopes are granted automatically to clients which skip consent.
+	AutoGrantedScopes []string `json:"autoGrantedScopes,omitempty"`
+
+	// SkipConsent is true if the client skips consent or policies allow the consent app to accept the request without
+	// showing a consent screen, granting the AutoGrantedScopes once the user is authenticated.
+	SkipConsent bool `json:"skipConsent,omitempty"`
 }
 
 // swagger:parameters revokeOAuth2Token
@@ -41,6 +49,17 @@ type swaggerRevokeOAuth2TokenParameters struct {
 	Token string `json:"token"`
 }
 
+// swagger:parameters revokeOAuth2Tokens
+type swaggerRevokeOAuth2TokensParameters struct {
+	// The id of the client whose tokens are revoked.
+	// in: query
+	ClientID string `json:"client_id"`
+
+	// The subject whose tokens are revoked.
+	// in: query
+	Subject string `json:"subject"`
+}
+
 // swagger:parameters rejectOAuth2ConsentRequest
 type swaggerRejectConsentRequest struct {
 	// in: path
@@ -70,6 +89,177 @@ type swaggerOAuthConsentRequest struct {
 	Body swaggerConsentRequest
 }
 
+// swagger:parameters parkOAuth2ConsentRequest
+type swaggerParkConsentRequest struct {
+	// in: path
+	// required: true
+	ID string `json:"id"`
+}
+
+// The parked consent request response
+// swagger:response oAuth2ConsentRequestParking
+type swaggerOAuthConsentRequestParking struct {
+	// in: body
+	Body ConsentRequestParking
+}
+
+// The pushed authorization request response
+// swagger:response pushedAuthorizationResponse
+type swaggerPushedAuthorizationResponse struct {
+	// in: body
+	Body PushedAuthorizationResponse
+}
+
+// The consent statistics response
+// swagger:response oAuth2ConsentStatistics
+type swaggerOAuthConsentStatistics struct {
+	// in: body
+	// type: array
+	Body []ScopeStatistics
+}
+
+// swagger:parameters getLoginRequest getConsentChallenge
+type swaggerChallengeRequest struct {
+	// The login or consent challenge.
+	//
+	// in: path
+	// required: true
+	Challenge string `json:"challenge"`
+}
+
+// swagger:parameters acceptLoginRequest
+type swaggerAcceptLoginRequest struct {
+	// in: path
+	// required: true
+	Challenge string `json:"challenge"`
+
+	// in: body
+	// required: true
+	Body AcceptLoginRequestPayload
+}
+
+// swagger:parameters rejectLoginRequest rejectConsentChallenge
+type swaggerRejectChallengeRequest struct {
+	// in: path
+	// required: true
+	Challenge string `json:"challenge"`
+
+	// in: body
+	// required: true
+	Body RequestDeniedError
+}
+
+// swagger:parameters acceptConsentChallenge
+type swaggerAcceptConsentChallenge struct {
+	// in: path
+	// required: true
+	Challenge string `json:"challenge"`
+
+	// in: body
+	// required: true
+	Body AcceptConsentRequestPayload
+}
+
+// The login request response
+// swagger:response loginRequest
+type swaggerLoginRequestResponse struct {
+	// in: body
+	Body LoginRequest
+}
+
+// The consent challenge response
+// swagger:response consentChallenge
+type swaggerConsentChallengeResponse struct {
+	// in: body
+	Body ConsentChallenge
+}
+
+// The response of accepting or rejecting a login or consent challenge
+// swagger:response completedRequest
+type swaggerCompletedRequestResponse struct {
+	// in: body
+	Body CompletedRequest
+}
+
+// swagger:parameters getOAuth2ConsentStatistics
+type swaggerConsentStatisticsRequest struct {
+	// If set, only statistics of this client are returned.
+	//
+	// in: query
+	ClientID string `json:"client_id"`
+
+	// The start of the time window, as a RFC3339 timestamp. Defaults to 30 days before until.
+	//
+	// in: query
+	Since string `json:"since"`
+
+	// The end of the time window, as a RFC3339 timestamp. Defaults to the current time.
+	//
+	// in: query
+	Until string `json:"until"`
+}
+
+// The consent grants response
+// swagger:response oAuth2ConsentGrants
+type swaggerOAuthConsentGrants struct {
+	// in: body
+	// type: array
+	Body []ConsentGrant
+}
+
+// swagger:parameters listOAuth2ConsentGrants
+type swaggerListConsentGrantsRequest struct {
+	// The subject whose consent grants are returned.
+	//
+	// in: query
+	// required: true
+	Subject string `json:"subject"`
+}
+
+// swagger:parameters revokeOAuth2ConsentGrant
+type swaggerRevokeConsentGrantRequest struct {
+	// The subject whose consent grant is revoked.
+	//
+	// in: query
+	// required: true
+	Subject string `json:"subject"`
+
+	// The id of the client the consent was granted to.
+	//
+	// in: query
+	// required: true
+	ClientID string `json:"client_id"`
+}
+
+// swagger:parameters resumeOAuth2ConsentRequest
+type swaggerResumeConsentRequest struct {
+	// The resumption handle returned when the consent request was parked.
+	//
+	// in: query
+	// required: true
+	Handle string `json:"handle"`
+}
+
+// swagger:parameters logoutOAuth2Session
+type swaggerLogoutRequest struct {
+	// An ID token previously issued to the client, identifying the login session to end and the client whose
+	// post logout redirect URIs are allowed.
+	//
+	// in: query
+	IDTokenHint string `json:"id_token_hint"`
+
+	// The URL the user agent is redirected to after logging out. It must be one of the post logout redirect URIs of
+	// the client the ID token hint was issued to.
+	//
+	// in: query
+	PostLogoutRedirectURI string `json:"post_logout_redirect_uri"`
+
+	// An opaque value appended to the post logout redirect URI.
+	//
+	// in: query
+	State string `json:"state"`
+}
+
 // The userinfo response
 // swagger:response userinfoResponse
 type swaggeruserinfoResponse struct {
@@ -142,6 +332,13 @@ type swaggeruserinfoResponsePayload struct {
 	UpdatedAt int `json:"updated_at,omitempty"`
 }
 
+// The revocation filter, signed as a JSON Web Token
+// swagger:response revocationFilterResponse
+type swaggerRevocationFilterResponse struct {
+	// in: body
+	Body string
+}
+
 // The token response
 // swagger:response oauthTokenResponse
 type swaggerOAuthTokenResponse struct {
@@ -167,6 +364,9 @@ type swaggerOAuthTokenResponse struct {
 
 		// The type of the token issued
 		TokenType string `json:"token_type"`
+
+		// The type of the token issued by a token exchange, always "urn:ietf:params:oauth:token-type:access_token".
+		IssuedTokenType string `json:"issued_token_type,omitempty"`
 	}
 }
 
@@ -221,9 +421,10 @@ type swaggerOAuthIntrospectionResponsePayload struct {
 	// authorized this token.
 	Username string `json:"username,omitempty"`
 
-	// ClientID is a service-specific string identifier or list of string
-	// identifiers representing the intended audience for this token.
-	Audience string `json:"aud,omitempty"`
+	// Audience is a list of service-specific string identifiers representing the
+	// intended audience for this token. It contains the audiences requested at the
+	// token endpoint, or the client id if none were requested.
+	Audience []string `json:"aud,omitempty"`
 
 	// Issuer is a string representing the issuer of this token
 	Issuer string `json:"iss,omitempty"`
@@ -250,6 +451,43 @@ type swaggerOAuthIntrospectionRequest struct {
 	Scope string `json:"scope"`
 }
 
+// The token history introspection response
+// swagger:response introspectOAuth2TokenHistoryResponse
+type swaggerOAuthTokenHistoryResponse struct {
+	// in: body
+	Body TokenHistoryIntrospection
+}
+
+// swagger:parameters introspectOAuth2TokenHistory
+type swaggerOAuthTokenHistoryRequest struct {
+	// The string value of the access or refresh token.
+	//
+	// required: true
+	// in: formData
+	Token string `json:"token"`
+
+	// The point in time to check the token against, as a RFC3339 timestamp. Defaults to the current time.
+	//
+	// in: formData
+	At string `json:"at"`
+}
+
+// The token chain response
+// swagger:response getOAuth2TokenChainResponse
+type swaggerOAuthTokenChainResponse struct {
+	// in: body
+	Body TokenChain
+}
+
+// swagger:parameters getOAuth2TokenChain
+type swaggerOAuthTokenChainRequest struct {
+	// The string value of the refresh or access token.
+	//
+	// required: true
+	// in: formData
+	Token string `json:"token"`
+}
+
 // swagger:parameters getOAuth2ConsentRequest acceptConsentRequest rejectConsentRequest
 type swaggerOAuthConsentRequestPayload struct {
 	// The id of the OAuth 2.0 Consent Request.
@@ -0,0 +1,103 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"html/template"
+	"net/http"
+	"net/url"
+
+	"github.com/ory/fosite"
+	"github.com/ory/hydra/client"
+	"github.com/ory/hydra/pkg"
+	"github.com/pkg/errors"
+)
+
+// ResponseModeWebMessage is the response mode of authorization requests whose flow is embedded in an iframe of a
+// trusted first-party page. Instead of redirecting to the redirect URI, the authorization endpoint completes the flow
+// by posting the authorization response to the embedding page.
+const ResponseModeWebMessage = "web_message"
+
+var webMessagePage = template.Must(template.New("web_message").Parse(`<!DOCTYPE html>
+<html>
+<head>
+	<title>Authorization response</title>
+	<script>
+	(function () {
+		var target = window.parent !== window ? window.parent : window.opener;
+		if (target) {
+			target.postMessage({type: "authorization_response", response: {{ .Response }}}, {{ .Origin }});
+		}
+	})();
+	</script>
+</head>
+<body></body>
+</html>
+`))
+
+// isEmbedded returns true if the authorization request asks for the web_message response mode.
+func isEmbedded(ar fosite.AuthorizeRequester) bool {
+	return ar.GetRequestForm().Get("response_mode") == ResponseModeWebMessage
+}
+
+// embedOrigin returns the origin of the page embedding the authorization flow of ar, which is the origin of its
+// redirect URI. An error is returned if the client is not a first-party client or the origin is not one of the
+// EmbedOrigins.
+func (h *Handler) embedOrigin(ar fosite.AuthorizeRequester) (string, error) {
+	if c, ok := ar.GetClient().(*client.Client); !ok || !c.FirstParty {
+		return "", errors.Wrap(fosite.ErrInvalidRequest, "Only first-party clients may embed the authorization flow")
+	}
+
+	o := origin(ar.GetRedirectURI())
+	if !stringInSlice(o, h.EmbedOrigins) {
+		return "", errors.Wrapf(fosite.ErrInvalidRequest, "Origin %s is not allowed to embed the authorization flow", o)
+	}
+	return o, nil
+}
+
+// allowEmbedding restricts the pages which may frame the response to the origin of the embedding page.
+func allowEmbedding(w http.ResponseWriter, origin string) {
+	w.Header().Set("Content-Security-Policy", "frame-ancestors "+origin)
+}
+
+// writeWebMessage completes an embedded authorization flow by posting params, the parameters of the authorization
+// response, to the embedding page at origin. Other origins can neither frame the page nor receive the message.
+func (h *Handler) writeWebMessage(w http.ResponseWriter, origin string, params url.Values) {
+	response := map[string]string{}
+	for k := range params {
+		response[k] = params.Get(k)
+	}
+
+	allowEmbedding(w, origin)
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := webMessagePage.Execute(w, struct {
+		Origin   string
+		Response map[string]string
+	}{Origin: origin, Response: response}); err != nil {
+		pkg.LogError(errors.WithStack(err), h.L)
+	}
+}
+
+// writeWebMessageResponse posts the authorization response to the embedding page.
+func (h *Handler) writeWebMessageResponse(w http.ResponseWriter, origin string, resp fosite.AuthorizeResponder) {
+	h.writeWebMessage(w, origin, authorizeResponseParams(resp))
+}
+
+// writeWebMessageError posts err as authorization error response to the embedding page.
+func (h *Handler) writeWebMessageError(w http.ResponseWriter, r *http.Request, origin string, ar fosite.AuthorizeRequester, err error) {
+	h.writeWebMessage(w, origin, h.authorizeErrorParams(r, ar, err))
+}
@@ -0,0 +1,81 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/ory/fosite"
+	"github.com/ory/hydra/client"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbedOrigin(t *testing.T) {
+	h := &Handler{EmbedOrigins: []string{"https://shop.example.com"}, L: logrus.New()}
+
+	newRequest := func(c fosite.Client, redirectURI string) *fosite.AuthorizeRequest {
+		u, err := url.Parse(redirectURI)
+		require.NoError(t, err)
+		return &fosite.AuthorizeRequest{
+			RedirectURI: u,
+			Request:     fosite.Request{Client: c, Form: url.Values{"response_mode": {ResponseModeWebMessage}}},
+		}
+	}
+
+	firstParty := &client.Client{ID: "shop", FirstParty: true}
+	for k, tc := range []struct {
+		d         string
+		req       *fosite.AuthorizeRequest
+		expectErr bool
+	}{
+		{d: "trusted origin", req: newRequest(firstParty, "https://shop.example.com:443/callback")},
+		{d: "untrusted origin", req: newRequest(firstParty, "https://evil.example.com/callback"), expectErr: true},
+		{d: "third-party client", req: newRequest(&client.Client{ID: "other"}, "https://shop.example.com/callback"), expectErr: true},
+		{d: "unknown client type", req: newRequest(&fosite.DefaultClient{ID: "shop"}, "https://shop.example.com/callback"), expectErr: true},
+	} {
+		t.Run(tc.d, func(t *testing.T) {
+			assert.True(t, isEmbedded(tc.req), "%d", k)
+
+			o, err := h.embedOrigin(tc.req)
+			if tc.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "https://shop.example.com", o)
+		})
+	}
+
+	assert.False(t, isEmbedded(&fosite.AuthorizeRequest{Request: fosite.Request{Form: url.Values{}}}))
+}
+
+func TestWriteWebMessage(t *testing.T) {
+	h := &Handler{L: logrus.New()}
+
+	w := httptest.NewRecorder()
+	h.writeWebMessage(w, "https://shop.example.com", url.Values{"code": {"some-code"}, "state": {"</script>"}})
+
+	assert.Equal(t, "frame-ancestors https://shop.example.com", w.Header().Get("Content-Security-Policy"))
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+
+	body := w.Body.String()
+	assert.Contains(t, body, `"https://shop.example.com"`)
+	assert.Contains(t, body, `"code":"some-code"`)
+	assert.NotContains(t, body, "</script>\"")
+}
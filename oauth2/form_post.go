@@ -0,0 +1,111 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"html/template"
+	"net/http"
+	"net/url"
+
+	"github.com/ory/fosite"
+	"github.com/ory/hydra/pkg"
+	"github.com/pkg/errors"
+)
+
+// ResponseModeFormPost is the response mode of authorization requests whose response is sent to the redirect URI as
+// form parameters of a POST request, see https://openid.net/specs/oauth-v2-form-post-response-mode-1_0.html.
+const ResponseModeFormPost = "form_post"
+
+// formPostPage submits the form as soon as it is loaded. Redirect URIs with a scheme html/template considers unsafe,
+// such as the custom schemes of native apps, are replaced and can not be used with this response mode.
+var formPostPage = template.Must(template.New("form_post").Parse(`<!DOCTYPE html>
+<html>
+<head>
+	<title>Authorization response</title>
+</head>
+<body>
+	<form method="post" action="{{ .RedirectURI }}">
+		{{ range $key, $value := .Parameters }}<input type="hidden" name="{{ $key }}" value="{{ $value }}"/>
+		{{ end }}<noscript><button type="submit">Continue</button></noscript>
+	</form>
+	<script>document.forms[0].submit();</script>
+</body>
+</html>
+`))
+
+// isFormPost returns true if the authorization request asks for the form_post response mode.
+func isFormPost(ar fosite.AuthorizeRequester) bool {
+	return ar.GetRequestForm().Get("response_mode") == ResponseModeFormPost
+}
+
+// writeFormPost completes the authorization flow by rendering a page which posts params, the parameters of the
+// authorization response, to redirectURI.
+func (h *Handler) writeFormPost(w http.ResponseWriter, redirectURI *url.URL, params url.Values) {
+	parameters := map[string]string{}
+	for k := range params {
+		parameters[k] = params.Get(k)
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := formPostPage.Execute(w, struct {
+		RedirectURI string
+		Parameters  map[string]string
+	}{RedirectURI: redirectURI.String(), Parameters: parameters}); err != nil {
+		pkg.LogError(errors.WithStack(err), h.L)
+	}
+}
+
+// writeFormPostResponse posts the authorization response to the redirect URI of ar.
+func (h *Handler) writeFormPostResponse(w http.ResponseWriter, ar fosite.AuthorizeRequester, resp fosite.AuthorizeResponder) {
+	for k := range resp.GetHeader() {
+		w.Header().Set(k, resp.GetHeader().Get(k))
+	}
+	h.writeFormPost(w, ar.GetRedirectURI(), authorizeResponseParams(resp))
+}
+
+// writeFormPostError posts err as authorization error response to the redirect URI of ar.
+func (h *Handler) writeFormPostError(w http.ResponseWriter, r *http.Request, ar fosite.AuthorizeRequester, err error) {
+	h.writeFormPost(w, ar.GetRedirectURI(), h.authorizeErrorParams(r, ar, err))
+}
+
+// authorizeResponseParams returns the parameters fosite would add to the query or fragment of the redirect URI.
+func authorizeResponseParams(resp fosite.AuthorizeResponder) url.Values {
+	params := url.Values{}
+	for k, v := range resp.GetQuery() {
+		params[k] = v
+	}
+	for k, v := range resp.GetFragment() {
+		params[k] = v
+	}
+	return params
+}
+
+// authorizeErrorParams returns the parameters of the authorization error response for err.
+func (h *Handler) authorizeErrorParams(r *http.Request, ar fosite.AuthorizeRequester, err error) url.Values {
+	rfcerr := fosite.ErrorToRFC6749Error(h.localizeError(r, err))
+
+	params := url.Values{}
+	params.Set("error", rfcerr.Name)
+	params.Set("error_description", rfcerr.Description)
+	if rfcerr.Hint != "" {
+		params.Set("error_hint", rfcerr.Hint)
+	}
+	if state := ar.GetState(); state != "" {
+		params.Set("state", state)
+	}
+	return params
+}
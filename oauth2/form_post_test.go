@@ -0,0 +1,77 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/ory/fosite"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteFormPost(t *testing.T) {
+	h := &Handler{L: logrus.New()}
+
+	redirectURI, err := url.Parse("https://rp.example.com/callback?tenant=foo")
+	require.NoError(t, err)
+	ar := &fosite.AuthorizeRequest{
+		RedirectURI: redirectURI,
+		State:       "some-state",
+		Request:     fosite.Request{Form: url.Values{"response_mode": {ResponseModeFormPost}}},
+	}
+	assert.True(t, isFormPost(ar))
+	assert.False(t, isFormPost(&fosite.AuthorizeRequest{Request: fosite.Request{Form: url.Values{}}}))
+
+	t.Run("case=response", func(t *testing.T) {
+		resp := fosite.NewAuthorizeResponse()
+		resp.AddQuery("code", "some-code")
+		resp.AddFragment("id_token", "some-token")
+		resp.AddQuery("state", `"><script>alert(1)</script>`)
+
+		w := httptest.NewRecorder()
+		h.writeFormPostResponse(w, ar, resp)
+
+		assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+		assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+
+		body := w.Body.String()
+		assert.Contains(t, body, `action="https://rp.example.com/callback?tenant=foo"`)
+		assert.Contains(t, body, `name="code" value="some-code"`)
+		assert.Contains(t, body, `name="id_token" value="some-token"`)
+		assert.NotContains(t, body, "<script>alert(1)")
+	})
+
+	t.Run("case=error", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		h.writeFormPostError(w, httptest.NewRequest("GET", "/oauth2/auth", nil), ar, fosite.ErrInvalidScope)
+
+		body := w.Body.String()
+		assert.Contains(t, body, `name="error" value="invalid_scope"`)
+		assert.Contains(t, body, `name="state" value="some-state"`)
+	})
+
+	t.Run("case=unsafe redirect uri", func(t *testing.T) {
+		u, err := url.Parse("javascript:alert(1)")
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		h.writeFormPost(w, u, url.Values{})
+		assert.NotContains(t, w.Body.String(), "javascript:")
+	})
+}
@@ -16,6 +16,7 @@ package oauth2
 
 import (
 	"context"
+	"sort"
 	"sync"
 	"time"
 
@@ -191,6 +192,55 @@ func (s *FositeMemoryStore) RevokeAccessToken(ctx context.Context, id string) er
 	return nil
 }
 
+func (s *FositeMemoryStore) RevokeClientTokens(ctx context.Context, clientID string) ([]string, error) {
+	return s.revokeTokens(ctx, func(r fosite.Requester) bool {
+		return r.GetClient().GetID() == clientID
+	})
+}
+
+func (s *FositeMemoryStore) RevokeSubjectTokens(ctx context.Context, subject string) ([]string, error) {
+	return s.revokeTokens(ctx, func(r fosite.Requester) bool {
+		return r.GetSession() != nil && r.GetSession().GetSubject() == subject
+	})
+}
+
+func (s *FositeMemoryStore) RevokeSubjectClientTokens(ctx context.Context, subject, clientID string) ([]string, error) {
+	return s.revokeTokens(ctx, func(r fosite.Requester) bool {
+		return r.GetClient().GetID() == clientID && r.GetSession() != nil && r.GetSession().GetSubject() == subject
+	})
+}
+
+// revokeTokens deletes all access and refresh tokens matching the filter and returns the ids of their requests.
+func (s *FositeMemoryStore) revokeTokens(ctx context.Context, filter func(fosite.Requester) bool) ([]string, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	revoked := map[string]bool{}
+	for sig, token := range s.AccessTokens {
+		if filter(token) {
+			if err := s.deleteAccessTokenSession(ctx, sig); err != nil {
+				return nil, err
+			}
+			revoked[token.GetID()] = true
+		}
+	}
+	for sig, token := range s.RefreshTokens {
+		if filter(token) {
+			if err := s.deleteRefreshTokenSession(ctx, sig); err != nil {
+				return nil, err
+			}
+			revoked[token.GetID()] = true
+		}
+	}
+
+	requestIDs := make([]string, 0, len(revoked))
+	for id := range revoked {
+		requestIDs = append(requestIDs, id)
+	}
+	sort.Strings(requestIDs)
+	return requestIDs, nil
+}
+
 func (s *FositeMemoryStore) FlushInactiveAccessTokens(ctx context.Context, notAfter time.Time) error {
 	s.Lock()
 	defer s.Unlock()
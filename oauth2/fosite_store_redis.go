@@ -0,0 +1,369 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/ory/fosite"
+	"github.com/ory/hydra/client"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// FositeRedisStore stores OAuth 2.0 sessions in Redis. Tokens and authorize codes whose session has an expiry are
+// stored with a matching TTL, so Redis removes them once they are no longer valid.
+type FositeRedisStore struct {
+	client.Manager
+	DB                  *redis.Client
+	L                   logrus.FieldLogger
+	AccessTokenLifespan time.Duration
+}
+
+func NewFositeRedisStore(m client.Manager,
+	db *redis.Client,
+	l logrus.FieldLogger,
+	accessTokenLifespan time.Duration,
+) *FositeRedisStore {
+	return &FositeRedisStore{
+		Manager:             m,
+		L:                   l,
+		DB:                  db,
+		AccessTokenLifespan: accessTokenLifespan,
+	}
+}
+
+// redisSessionKey holds the JSON encoded session of a token.
+func redisSessionKey(table, signature string) string {
+	return "hydra:oauth2:" + table + ":" + signature
+}
+
+// redisRequestKey is a set containing the signatures of all tokens issued for a request.
+func redisRequestKey(table, requestID string) string {
+	return "hydra:oauth2:" + table + ":request:" + requestID
+}
+
+// redisClientKey is a sorted set containing the ids of all requests access or refresh tokens were issued to a client
+// for, scored by the time the latest token expires at. Requests are removed once their tokens have expired.
+func redisClientKey(table, clientID string) string {
+	return "hydra:oauth2:" + table + ":client:" + clientID
+}
+
+// redisSubjectKey is a sorted set like redisClientKey, containing the ids of all requests access or refresh tokens were
+// issued for on behalf of a subject.
+func redisSubjectKey(table, subject string) string {
+	return "hydra:oauth2:" + table + ":subject:" + subject
+}
+
+// redisAccessTokensRequestedAt is a sorted set containing the signatures of all access tokens, scored by the time
+// they were requested at.
+const redisAccessTokensRequestedAt = "hydra:oauth2:access:requested_at"
+
+// expiresAt returns the expiry the session of requester sets for the token stored in table, or the zero time if the
+// token does not expire.
+func (s *FositeRedisStore) expiresAt(requester fosite.Requester, table string) time.Time {
+	session := requester.GetSession()
+	if session == nil {
+		return time.Time{}
+	}
+
+	switch table {
+	case sqlTableAccess:
+		return session.GetExpiresAt(fosite.AccessToken)
+	case sqlTableCode:
+		return session.GetExpiresAt(fosite.AuthorizeCode)
+	case sqlTableRefresh:
+		return session.GetExpiresAt(fosite.RefreshToken)
+	}
+	return time.Time{}
+}
+
+func (s *FositeRedisStore) createSession(signature string, requester fosite.Requester, table string) error {
+	data, err := sqlSchemaFromRequest(signature, requester, s.L)
+	if err != nil {
+		return err
+	}
+
+	out, err := json.Marshal(data)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	expiresAt := s.expiresAt(requester, table)
+	if _, err := s.DB.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.Set(redisSessionKey(table, signature), string(out), 0)
+		pipe.SAdd(redisRequestKey(table, data.Request), signature)
+		if !expiresAt.IsZero() {
+			pipe.PExpireAt(redisSessionKey(table, signature), expiresAt)
+			pipe.PExpireAt(redisRequestKey(table, data.Request), expiresAt)
+		}
+		if table == sqlTableAccess {
+			pipe.ZAdd(redisAccessTokensRequestedAt, redis.Z{Score: redisTimeScore(data.RequestedAt), Member: signature})
+		}
+		if table == sqlTableAccess || table == sqlTableRefresh {
+			score := math.Inf(1)
+			if !expiresAt.IsZero() {
+				score = redisTimeScore(expiresAt)
+			}
+			expired := "(" + strconv.FormatFloat(redisTimeScore(time.Now()), 'f', -1, 64)
+			pipe.ZAdd(redisClientKey(table, data.Client), redis.Z{Score: score, Member: data.Request})
+			pipe.ZRemRangeByScore(redisClientKey(table, data.Client), "-inf", expired)
+			if data.Subject != "" {
+				pipe.ZAdd(redisSubjectKey(table, data.Subject), redis.Z{Score: score, Member: data.Request})
+				pipe.ZRemRangeByScore(redisSubjectKey(table, data.Subject), "-inf", expired)
+			}
+		}
+		return nil
+	}); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func (s *FositeRedisStore) findSessionBySignature(signature string, session fosite.Session, table string) (fosite.Requester, error) {
+	out, err := s.DB.Get(redisSessionKey(table, signature)).Bytes()
+	if err == redis.Nil {
+		return nil, errors.Wrap(fosite.ErrNotFound, "")
+	} else if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var d sqlData
+	if err := json.Unmarshal(out, &d); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return d.toRequest(session, s.Manager, s.L)
+}
+
+func (s *FositeRedisStore) deleteSession(signature string, table string) error {
+	if _, err := s.DB.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.Del(redisSessionKey(table, signature))
+		if table == sqlTableAccess {
+			pipe.ZRem(redisAccessTokensRequestedAt, signature)
+		}
+		return nil
+	}); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func (s *FositeRedisStore) CreateOpenIDConnectSession(_ context.Context, signature string, requester fosite.Requester) error {
+	return s.createSession(signature, requester, sqlTableOpenID)
+}
+
+func (s *FositeRedisStore) GetOpenIDConnectSession(_ context.Context, signature string, requester fosite.Requester) (fosite.Requester, error) {
+	return s.findSessionBySignature(signature, requester.GetSession(), sqlTableOpenID)
+}
+
+func (s *FositeRedisStore) DeleteOpenIDConnectSession(_ context.Context, signature string) error {
+	return s.deleteSession(signature, sqlTableOpenID)
+}
+
+func (s *FositeRedisStore) CreateAuthorizeCodeSession(_ context.Context, signature string, requester fosite.Requester) error {
+	return s.createSession(signature, requester, sqlTableCode)
+}
+
+func (s *FositeRedisStore) GetAuthorizeCodeSession(_ context.Context, signature string, session fosite.Session) (fosite.Requester, error) {
+	return s.findSessionBySignature(signature, session, sqlTableCode)
+}
+
+func (s *FositeRedisStore) DeleteAuthorizeCodeSession(_ context.Context, signature string) error {
+	return s.deleteSession(signature, sqlTableCode)
+}
+
+func (s *FositeRedisStore) CreateAccessTokenSession(_ context.Context, signature string, requester fosite.Requester) error {
+	return s.createSession(signature, requester, sqlTableAccess)
+}
+
+func (s *FositeRedisStore) GetAccessTokenSession(_ context.Context, signature string, session fosite.Session) (fosite.Requester, error) {
+	return s.findSessionBySignature(signature, session, sqlTableAccess)
+}
+
+func (s *FositeRedisStore) DeleteAccessTokenSession(_ context.Context, signature string) error {
+	return s.deleteSession(signature, sqlTableAccess)
+}
+
+func (s *FositeRedisStore) CreateRefreshTokenSession(_ context.Context, signature string, requester fosite.Requester) error {
+	return s.createSession(signature, requester, sqlTableRefresh)
+}
+
+func (s *FositeRedisStore) GetRefreshTokenSession(_ context.Context, signature string, session fosite.Session) (fosite.Requester, error) {
+	return s.findSessionBySignature(signature, session, sqlTableRefresh)
+}
+
+func (s *FositeRedisStore) DeleteRefreshTokenSession(_ context.Context, signature string) error {
+	return s.deleteSession(signature, sqlTableRefresh)
+}
+
+func (s *FositeRedisStore) CreateImplicitAccessTokenSession(ctx context.Context, signature string, requester fosite.Requester) error {
+	return s.CreateAccessTokenSession(ctx, signature, requester)
+}
+
+func (s *FositeRedisStore) RevokeRefreshToken(ctx context.Context, id string) error {
+	return s.revokeSession(id, sqlTableRefresh)
+}
+
+func (s *FositeRedisStore) RevokeAccessToken(ctx context.Context, id string) error {
+	return s.revokeSession(id, sqlTableAccess)
+}
+
+func (s *FositeRedisStore) revokeSession(id string, table string) error {
+	signatures, err := s.DB.SMembers(redisRequestKey(table, id)).Result()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if _, err := s.DB.TxPipelined(func(pipe redis.Pipeliner) error {
+		for _, signature := range signatures {
+			pipe.Del(redisSessionKey(table, signature))
+			if table == sqlTableAccess {
+				pipe.ZRem(redisAccessTokensRequestedAt, signature)
+			}
+		}
+		pipe.Del(redisRequestKey(table, id))
+		return nil
+	}); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// RevokeClientTokens revokes the tokens of all requests found in the client index, see redisClientKey. Tokens issued
+// before the index was introduced are not revoked.
+func (s *FositeRedisStore) RevokeClientTokens(ctx context.Context, clientID string) ([]string, error) {
+	return s.revokeTokens(func(table string) string {
+		return redisClientKey(table, clientID)
+	})
+}
+
+// RevokeSubjectTokens revokes the tokens of all requests found in the subject index, see redisSubjectKey. Tokens
+// issued before the index was introduced are not revoked.
+func (s *FositeRedisStore) RevokeSubjectTokens(ctx context.Context, subject string) ([]string, error) {
+	return s.revokeTokens(func(table string) string {
+		return redisSubjectKey(table, subject)
+	})
+}
+
+// RevokeSubjectClientTokens revokes the tokens of all requests found in both the subject and the client index, and
+// removes them from both indexes.
+func (s *FositeRedisStore) RevokeSubjectClientTokens(ctx context.Context, subject, clientID string) ([]string, error) {
+	revoked := map[string]bool{}
+	for _, table := range []string{sqlTableAccess, sqlTableRefresh} {
+		bySubject, err := s.DB.ZRange(redisSubjectKey(table, subject), 0, -1).Result()
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		byClient, err := s.DB.ZRange(redisClientKey(table, clientID), 0, -1).Result()
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		issuedToClient := map[string]bool{}
+		for _, id := range byClient {
+			issuedToClient[id] = true
+		}
+
+		var ids []interface{}
+		for _, id := range bySubject {
+			if !issuedToClient[id] {
+				continue
+			}
+
+			if err := s.revokeSession(id, table); err != nil {
+				return nil, err
+			}
+			revoked[id] = true
+			ids = append(ids, id)
+		}
+
+		if len(ids) == 0 {
+			continue
+		}
+		if err := s.DB.ZRem(redisSubjectKey(table, subject), ids...).Err(); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if err := s.DB.ZRem(redisClientKey(table, clientID), ids...).Err(); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	requestIDs := make([]string, 0, len(revoked))
+	for id := range revoked {
+		requestIDs = append(requestIDs, id)
+	}
+	sort.Strings(requestIDs)
+	return requestIDs, nil
+}
+
+// revokeTokens revokes the access and refresh tokens of all requests in the index returned by key for the table, and
+// deletes the index.
+func (s *FositeRedisStore) revokeTokens(key func(table string) string) ([]string, error) {
+	revoked := map[string]bool{}
+	for _, table := range []string{sqlTableAccess, sqlTableRefresh} {
+		ids, err := s.DB.ZRange(key(table), 0, -1).Result()
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		for _, id := range ids {
+			if err := s.revokeSession(id, table); err != nil {
+				return nil, err
+			}
+			revoked[id] = true
+		}
+
+		if err := s.DB.Del(key(table)).Err(); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	requestIDs := make([]string, 0, len(revoked))
+	for id := range revoked {
+		requestIDs = append(requestIDs, id)
+	}
+	sort.Strings(requestIDs)
+	return requestIDs, nil
+}
+
+func (s *FositeRedisStore) FlushInactiveAccessTokens(ctx context.Context, notAfter time.Time) error {
+	before := time.Now().Add(-s.AccessTokenLifespan)
+	if notAfter.Before(before) {
+		before = notAfter
+	}
+
+	signatures, err := s.DB.ZRangeByScore(redisAccessTokensRequestedAt, redis.ZRangeBy{
+		Min: "-inf",
+		Max: "(" + strconv.FormatFloat(redisTimeScore(before), 'f', -1, 64),
+	}).Result()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, signature := range signatures {
+		if err := s.deleteSession(signature, sqlTableAccess); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -20,12 +20,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/url"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/ory/fosite"
 	"github.com/ory/hydra/client"
+	"github.com/ory/hydra/events"
+	"github.com/ory/hydra/pkg"
 	"github.com/pkg/errors"
 	"github.com/rubenv/sql-migrate"
 	"github.com/sirupsen/logrus"
@@ -36,6 +39,11 @@ type FositeSQLStore struct {
 	DB                  *sqlx.DB
 	L                   logrus.FieldLogger
 	AccessTokenLifespan time.Duration
+
+	// Outbox, if set, stores an event for every revoked token in the same transaction as the revocation.
+	Outbox events.Outbox
+
+	statements pkg.SQLStatements
 }
 
 func NewFositeSQLStore(m client.Manager,
@@ -64,6 +72,7 @@ func sqlSchemaUp(table string, id string) string {
 	session_data  	text NOT NULL
 )`, table),
 		"2": fmt.Sprintf("ALTER TABLE hydra_oauth2_%s ADD subject varchar(255) NOT NULL DEFAULT ''", table),
+		"3": fmt.Sprintf("CREATE INDEX hydra_oauth2_%s_subject_idx ON hydra_oauth2_%s (subject)", table, table),
 	}
 
 	return schemas[id]
@@ -117,9 +126,37 @@ var migrations = &migrate.MemoryMigrationSource{
 				sqlSchemaDown(sqlTableOpenID, "2"),
 			},
 		},
+		{
+			Id: "3",
+			Up: []string{
+				sqlSchemaUp(sqlTableAccess, "3"),
+				sqlSchemaUp(sqlTableRefresh, "3"),
+				sqlSchemaUp(sqlTableCode, "3"),
+				sqlSchemaUp(sqlTableOpenID, "3"),
+			},
+			Down: []string{},
+		},
 	},
 }
 
+// SQLIndexes are the indexes the queries of FositeSQLStore rely on. Only the primary keys and the subject indexes are
+// created by the migrations, so large installations should create the others, see `hydra migrate verify-indexes`.
+var SQLIndexes = sqlIndexes(sqlTableAccess, sqlTableRefresh, sqlTableCode, sqlTableOpenID)
+
+func sqlIndexes(tables ...string) []pkg.SQLIndex {
+	var indexes []pkg.SQLIndex
+	for _, table := range tables {
+		table = "hydra_oauth2_" + table
+		indexes = append(indexes,
+			pkg.SQLIndex{Table: table, Columns: []string{"signature"}, Query: "Token signature lookup"},
+			pkg.SQLIndex{Table: table, Columns: []string{"request_id"}, Query: "Token revocation by request id"},
+			pkg.SQLIndex{Table: table, Columns: []string{"client_id"}, Query: "Token revocation by client id", MySQLPrefixLength: 255},
+			pkg.SQLIndex{Table: table, Columns: []string{"subject"}, Query: "Token revocation by subject"},
+		)
+	}
+	return indexes
+}
+
 var sqlParams = []string{
 	"signature",
 	"request_id",
@@ -214,15 +251,25 @@ func (s *FositeSQLStore) createSession(signature string, requester fosite.Reques
 		strings.Join(sqlParams, ", "),
 		":"+strings.Join(sqlParams, ", :"),
 	)
-	if _, err := s.DB.NamedExec(query, data); err != nil {
+	stmt, err := s.statements.PrepareNamed(s.DB, query)
+	if err != nil {
+		return err
+	}
+
+	if _, err := stmt.Exec(data); err != nil {
 		return errors.WithStack(err)
 	}
 	return nil
 }
 
 func (s *FositeSQLStore) findSessionBySignature(signature string, session fosite.Session, table string) (fosite.Requester, error) {
+	stmt, err := s.statements.Preparex(s.DB, fmt.Sprintf("SELECT * FROM hydra_oauth2_%s WHERE signature=?", table))
+	if err != nil {
+		return nil, err
+	}
+
 	var d sqlData
-	if err := s.DB.Get(&d, s.DB.Rebind(fmt.Sprintf("SELECT * FROM hydra_oauth2_%s WHERE signature=?", table)), signature); err == sql.ErrNoRows {
+	if err := stmt.Get(&d, signature); err == sql.ErrNoRows {
 		return nil, errors.Wrap(fosite.ErrNotFound, "")
 	} else if err != nil {
 		return nil, errors.WithStack(err)
@@ -232,12 +279,22 @@ func (s *FositeSQLStore) findSessionBySignature(signature string, session fosite
 }
 
 func (s *FositeSQLStore) deleteSession(signature string, table string) error {
-	if _, err := s.DB.Exec(s.DB.Rebind(fmt.Sprintf("DELETE FROM hydra_oauth2_%s WHERE signature=?", table)), signature); err != nil {
+	stmt, err := s.statements.Preparex(s.DB, fmt.Sprintf("DELETE FROM hydra_oauth2_%s WHERE signature=?", table))
+	if err != nil {
+		return err
+	}
+
+	if _, err := stmt.Exec(signature); err != nil {
 		return errors.WithStack(err)
 	}
 	return nil
 }
 
+// Migrations returns the SQL migrations embedded in the binary.
+func (s *FositeSQLStore) Migrations() *migrate.MemoryMigrationSource {
+	return migrations
+}
+
 func (s *FositeSQLStore) CreateSchemas() (int, error) {
 	migrate.SetTable("hydra_oauth2_migration")
 	n, err := migrate.Exec(s.DB.DB, s.DB.DriverName(), migrations, migrate.Up)
@@ -300,20 +357,95 @@ func (s *FositeSQLStore) CreateImplicitAccessTokenSession(ctx context.Context, s
 }
 
 func (s *FositeSQLStore) RevokeRefreshToken(ctx context.Context, id string) error {
-	return s.revokeSession(id, sqlTableRefresh)
+	return s.revokeSession(id, sqlTableRefresh, "refresh_token")
 }
 
 func (s *FositeSQLStore) RevokeAccessToken(ctx context.Context, id string) error {
-	return s.revokeSession(id, sqlTableAccess)
+	return s.revokeSession(id, sqlTableAccess, "access_token")
 }
 
-func (s *FositeSQLStore) revokeSession(id string, table string) error {
-	if _, err := s.DB.Exec(s.DB.Rebind(fmt.Sprintf("DELETE FROM hydra_oauth2_%s WHERE request_id=?", table)), id); err == sql.ErrNoRows {
-		return errors.Wrap(fosite.ErrNotFound, "")
-	} else if err != nil {
-		return errors.WithStack(err)
+func (s *FositeSQLStore) revokeSession(id string, table string, tokenType string) error {
+	var e *events.Event
+	if s.Outbox != nil {
+		var err error
+		if e, err = events.NewEvent(events.TokenRevoked, map[string]string{"request_id": id, "token_type": tokenType}); err != nil {
+			return err
+		}
 	}
-	return nil
+
+	return events.Transaction(s.DB, s.Outbox, e, func(tx *sqlx.Tx) error {
+		if _, err := tx.Exec(tx.Rebind(fmt.Sprintf("DELETE FROM hydra_oauth2_%s WHERE request_id=?", table)), id); err == sql.ErrNoRows {
+			return errors.Wrap(fosite.ErrNotFound, "")
+		} else if err != nil {
+			return errors.WithStack(err)
+		}
+		return nil
+	})
+}
+
+func (s *FositeSQLStore) RevokeClientTokens(ctx context.Context, clientID string) ([]string, error) {
+	return s.revokeTokens(map[string]string{"client_id": clientID})
+}
+
+func (s *FositeSQLStore) RevokeSubjectTokens(ctx context.Context, subject string) ([]string, error) {
+	return s.revokeTokens(map[string]string{"subject": subject})
+}
+
+func (s *FositeSQLStore) RevokeSubjectClientTokens(ctx context.Context, subject, clientID string) ([]string, error) {
+	return s.revokeTokens(map[string]string{"subject": subject, "client_id": clientID})
+}
+
+// revokeTokens deletes all access and refresh tokens whose columns equal the values of filter and returns the ids of
+// their requests.
+func (s *FositeSQLStore) revokeTokens(filter map[string]string) ([]string, error) {
+	var e *events.Event
+	if s.Outbox != nil {
+		var err error
+		if e, err = events.NewEvent(events.TokenRevoked, filter); err != nil {
+			return nil, err
+		}
+	}
+
+	columns := make([]string, 0, len(filter))
+	for column := range filter {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	conditions := make([]string, len(columns))
+	values := make([]interface{}, len(columns))
+	for k, column := range columns {
+		conditions[k] = column + "=?"
+		values[k] = filter[column]
+	}
+	where := strings.Join(conditions, " AND ")
+
+	revoked := map[string]bool{}
+	if err := events.Transaction(s.DB, s.Outbox, e, func(tx *sqlx.Tx) error {
+		for _, table := range []string{sqlTableAccess, sqlTableRefresh} {
+			var ids []string
+			if err := tx.Select(&ids, tx.Rebind(fmt.Sprintf("SELECT request_id FROM hydra_oauth2_%s WHERE %s", table, where)), values...); err != nil {
+				return errors.WithStack(err)
+			}
+			for _, id := range ids {
+				revoked[id] = true
+			}
+
+			if _, err := tx.Exec(tx.Rebind(fmt.Sprintf("DELETE FROM hydra_oauth2_%s WHERE %s", table, where)), values...); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	requestIDs := make([]string, 0, len(revoked))
+	for id := range revoked {
+		requestIDs = append(requestIDs, id)
+	}
+	sort.Strings(requestIDs)
+	return requestIDs, nil
 }
 
 func (s *FositeSQLStore) FlushInactiveAccessTokens(ctx context.Context, notAfter time.Time) error {
@@ -27,6 +27,8 @@ import (
 	. "github.com/ory/hydra/oauth2"
 	"github.com/ory/hydra/pkg"
 	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var clientManagers = map[string]pkg.FositeStorer{}
@@ -53,6 +55,24 @@ func TestMain(m *testing.M) {
 			connectToMySQL,
 			connectToPGConsent,
 			connectToMySQLConsent,
+			connectToPGTokenHistory,
+			connectToMySQLTokenHistory,
+			connectToPGConsentStatistics,
+			connectToMySQLConsentStatistics,
+			connectToPGConsentGrants,
+			connectToMySQLConsentGrants,
+			connectToPGRememberedConsent,
+			connectToMySQLRememberedConsent,
+			connectToPGLoginSessions,
+			connectToMySQLLoginSessions,
+			connectToPGPushedRequests,
+			connectToMySQLPushedRequests,
+			connectToPGLoginRequests,
+			connectToMySQLLoginRequests,
+			connectToPGJanitor,
+			connectToMySQLJanitor,
+			connectToRedis,
+			connectToCockroachConsent,
 		})
 	}
 
@@ -81,6 +101,17 @@ func connectToMySQL() {
 	clientManagers["mysql"] = s
 }
 
+func connectToRedis() {
+	var db = integration.ConnectToRedis()
+	clientManagers["redis"] = &FositeRedisStore{DB: db, Manager: clientManager, L: logrus.New(), AccessTokenLifespan: time.Hour}
+	consentManagers["redis"] = NewConsentRequestRedisManager(db)
+	rememberedConsentManagers["redis"] = &RememberedConsentRedisManager{DB: db}
+	loginRequestManagers["redis"] = &LoginRequestRedisManager{DB: db}
+	loginSessionManagers["redis"] = &LoginSessionRedisManager{DB: db}
+	pushedRequestManagers["redis"] = &PushedAuthorizationRequestRedisManager{DB: db}
+	consentGrantManagers["redis"] = &ConsentGrantRedisManager{DB: db}
+}
+
 func TestCreateGetDeleteAuthorizeCodes(t *testing.T) {
 	t.Parallel()
 	for k, m := range clientManagers {
@@ -116,9 +147,53 @@ func TestRevokeRefreshToken(t *testing.T) {
 	}
 }
 
+func TestRevokeClientTokens(t *testing.T) {
+	t.Parallel()
+	for k, m := range clientManagers {
+		t.Run(fmt.Sprintf("case=%s", k), TestHelperRevokeClientTokens(m))
+	}
+}
+
+func TestRevokeSubjectTokens(t *testing.T) {
+	t.Parallel()
+	for k, m := range clientManagers {
+		t.Run(fmt.Sprintf("case=%s", k), TestHelperRevokeSubjectTokens(m))
+	}
+}
+
+func TestRevokeSubjectClientTokens(t *testing.T) {
+	t.Parallel()
+	for k, m := range clientManagers {
+		t.Run(fmt.Sprintf("case=%s", k), TestHelperRevokeSubjectClientTokens(m))
+	}
+}
+
 func TestFlushAccessTokens(t *testing.T) {
 	t.Parallel()
 	for k, m := range clientManagers {
 		t.Run(fmt.Sprintf("case=%s", k), TestHelperFlushTokens(m, time.Hour))
 	}
 }
+
+func TestFositeSQLStoreMissingIndexes(t *testing.T) {
+	for k, m := range clientManagers {
+		s, ok := m.(*FositeSQLStore)
+		if !ok {
+			continue
+		}
+
+		t.Run(fmt.Sprintf("case=%s", k), func(t *testing.T) {
+			indexes := append([]pkg.SQLIndex{}, SQLIndexes...)
+			indexes = append(indexes, pkg.SQLIndex{Table: "hydra_oauth2_does_not_exist", Columns: []string{"signature"}})
+
+			missing, err := pkg.MissingSQLIndexes(s.DB, indexes)
+			require.NoError(t, err)
+
+			// Only the primary key on the signature is created by the migrations.
+			assert.Len(t, missing, 8)
+			for _, index := range missing {
+				assert.NotEqual(t, []string{"signature"}, index.Columns)
+			}
+		})
+	}
+}
@@ -107,6 +107,91 @@ func TestHelperRevokeRefreshToken(m pkg.FositeStorer) func(t *testing.T) {
 	}
 
 }
+func TestHelperRevokeClientTokens(m pkg.FositeStorer) func(t *testing.T) {
+	return func(t *testing.T) {
+		ctx := context.Background()
+		revoked, kept := uuid.New(), uuid.New()
+		leaked := &client.Client{ID: "leaked-" + uuid.New()}
+
+		require.NoError(t, m.CreateAccessTokenSession(ctx, "revoke-client-access", &fosite.Request{ID: revoked, Client: leaked, RequestedAt: time.Now().Round(time.Second), Session: &fosite.DefaultSession{}}))
+		require.NoError(t, m.CreateRefreshTokenSession(ctx, "revoke-client-refresh", &fosite.Request{ID: revoked, Client: leaked, RequestedAt: time.Now().Round(time.Second), Session: &fosite.DefaultSession{}}))
+		require.NoError(t, m.CreateAccessTokenSession(ctx, "revoke-client-kept", &fosite.Request{ID: kept, Client: &client.Client{ID: "foobar"}, RequestedAt: time.Now().Round(time.Second), Session: &fosite.DefaultSession{}}))
+
+		requestIDs, err := m.RevokeClientTokens(ctx, leaked.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{revoked}, requestIDs)
+
+		_, err = m.GetAccessTokenSession(ctx, "revoke-client-access", &fosite.DefaultSession{})
+		assert.NotNil(t, err)
+		_, err = m.GetRefreshTokenSession(ctx, "revoke-client-refresh", &fosite.DefaultSession{})
+		assert.NotNil(t, err)
+		_, err = m.GetAccessTokenSession(ctx, "revoke-client-kept", &fosite.DefaultSession{})
+		assert.NoError(t, err)
+
+		requestIDs, err = m.RevokeClientTokens(ctx, leaked.ID)
+		require.NoError(t, err)
+		assert.Empty(t, requestIDs)
+	}
+}
+
+func TestHelperRevokeSubjectTokens(m pkg.FositeStorer) func(t *testing.T) {
+	return func(t *testing.T) {
+		ctx := context.Background()
+		revoked, kept := uuid.New(), uuid.New()
+		subject := "signed-out-" + uuid.New()
+
+		require.NoError(t, m.CreateAccessTokenSession(ctx, "revoke-subject-access", &fosite.Request{ID: revoked, Client: &client.Client{ID: "foobar"}, RequestedAt: time.Now().Round(time.Second), Session: &fosite.DefaultSession{Subject: subject}}))
+		require.NoError(t, m.CreateRefreshTokenSession(ctx, "revoke-subject-refresh", &fosite.Request{ID: revoked, Client: &client.Client{ID: "foobar"}, RequestedAt: time.Now().Round(time.Second), Session: &fosite.DefaultSession{Subject: subject}}))
+		require.NoError(t, m.CreateAccessTokenSession(ctx, "revoke-subject-kept", &fosite.Request{ID: kept, Client: &client.Client{ID: "foobar"}, RequestedAt: time.Now().Round(time.Second), Session: &fosite.DefaultSession{Subject: "peter"}}))
+
+		requestIDs, err := m.RevokeSubjectTokens(ctx, subject)
+		require.NoError(t, err)
+		assert.Equal(t, []string{revoked}, requestIDs)
+
+		_, err = m.GetAccessTokenSession(ctx, "revoke-subject-access", &fosite.DefaultSession{})
+		assert.NotNil(t, err)
+		_, err = m.GetRefreshTokenSession(ctx, "revoke-subject-refresh", &fosite.DefaultSession{})
+		assert.NotNil(t, err)
+		_, err = m.GetAccessTokenSession(ctx, "revoke-subject-kept", &fosite.DefaultSession{})
+		assert.NoError(t, err)
+
+		requestIDs, err = m.RevokeSubjectTokens(ctx, subject)
+		require.NoError(t, err)
+		assert.Empty(t, requestIDs)
+	}
+}
+
+func TestHelperRevokeSubjectClientTokens(m pkg.FositeStorer) func(t *testing.T) {
+	return func(t *testing.T) {
+		ctx := context.Background()
+		revoked, otherClient, otherSubject := uuid.New(), uuid.New(), uuid.New()
+		subject := "disconnected-" + uuid.New()
+		app := &client.Client{ID: "app-" + uuid.New()}
+
+		require.NoError(t, m.CreateAccessTokenSession(ctx, "revoke-grant-access", &fosite.Request{ID: revoked, Client: app, RequestedAt: time.Now().Round(time.Second), Session: &fosite.DefaultSession{Subject: subject}}))
+		require.NoError(t, m.CreateRefreshTokenSession(ctx, "revoke-grant-refresh", &fosite.Request{ID: revoked, Client: app, RequestedAt: time.Now().Round(time.Second), Session: &fosite.DefaultSession{Subject: subject}}))
+		require.NoError(t, m.CreateAccessTokenSession(ctx, "revoke-grant-other-client", &fosite.Request{ID: otherClient, Client: &client.Client{ID: "foobar"}, RequestedAt: time.Now().Round(time.Second), Session: &fosite.DefaultSession{Subject: subject}}))
+		require.NoError(t, m.CreateAccessTokenSession(ctx, "revoke-grant-other-subject", &fosite.Request{ID: otherSubject, Client: app, RequestedAt: time.Now().Round(time.Second), Session: &fosite.DefaultSession{Subject: "peter"}}))
+
+		requestIDs, err := m.RevokeSubjectClientTokens(ctx, subject, app.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{revoked}, requestIDs)
+
+		_, err = m.GetAccessTokenSession(ctx, "revoke-grant-access", &fosite.DefaultSession{})
+		assert.NotNil(t, err)
+		_, err = m.GetRefreshTokenSession(ctx, "revoke-grant-refresh", &fosite.DefaultSession{})
+		assert.NotNil(t, err)
+		_, err = m.GetAccessTokenSession(ctx, "revoke-grant-other-client", &fosite.DefaultSession{})
+		assert.NoError(t, err)
+		_, err = m.GetAccessTokenSession(ctx, "revoke-grant-other-subject", &fosite.DefaultSession{})
+		assert.NoError(t, err)
+
+		requestIDs, err = m.RevokeSubjectClientTokens(ctx, subject, app.ID)
+		require.NoError(t, err)
+		assert.Empty(t, requestIDs)
+	}
+}
+
 func TestHelperCreateGetDeleteAuthorizeCodes(m pkg.FositeStorer) func(t *testing.T) {
 	return func(t *testing.T) {
 		ctx := context.Background()
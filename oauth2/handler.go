@@ -18,18 +18,21 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"net"
+	"math"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/gorilla/sessions"
 	"github.com/julienschmidt/httprouter"
 	"github.com/ory/fosite"
+	"github.com/ory/hydra/client"
 	"github.com/ory/hydra/firewall"
+	"github.com/ory/hydra/i18n"
 	"github.com/ory/hydra/pkg"
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 )
 
 const (
@@ -38,6 +41,7 @@ const (
 	DefaultConsentPath = "/oauth2/consent-fallback"
 	TokenPath          = "/oauth2/token"
 	AuthPath           = "/oauth2/auth"
+	ResumePath         = "/oauth2/auth/resume"
 
 	UserinfoPath  = "/userinfo"
 	WellKnownPath = "/.well-known/openid-configuration"
@@ -48,6 +52,15 @@ const (
 	RevocationPath = "/oauth2/revoke"
 	FlushPath      = "/oauth2/flush"
 
+	// TokenHistoryPath points to the endpoint telling whether a token was active at a point in time.
+	TokenHistoryPath = "/oauth2/introspect/history"
+
+	// TokenChainPath points to the endpoint returning the graph of tokens issued for the same authorization.
+	TokenChainPath = "/oauth2/introspect/chain"
+
+	// RevocationFilterPath points to the signed bloom filter of revoked access tokens.
+	RevocationFilterPath = "/.well-known/revoked-tokens"
+
 	IntrospectScope = "hydra.introspect"
 
 	consentCookieName = "consent_session"
@@ -115,6 +128,74 @@ type WellKnown struct {
 	//
 	// required: true
 	IDTokenSigningAlgValuesSupported []string `json:"id_token_signing_alg_values_supported"`
+
+	// URL of the OP's Dynamic Client Registration Endpoint.
+	RegistrationEndpoint string `json:"registration_endpoint,omitempty"`
+
+	// URL at the OP to which an RP can perform a redirect to request that the End-User be logged out at the OP.
+	EndSessionEndpoint string `json:"end_session_endpoint,omitempty"`
+
+	// URL of an OP iframe that supports cross-origin communications for session state information with the RP Client,
+	// using the HTML5 postMessage API.
+	CheckSessionIframe string `json:"check_session_iframe,omitempty"`
+
+	// Boolean value specifying whether the OP supports HTTP-based logout, with true indicating support.
+	FrontChannelLogoutSupported bool `json:"frontchannel_logout_supported"`
+
+	// Boolean value specifying whether the OP can pass iss (issuer) and sid (session ID) query parameters to identify
+	// the RP session with the OP when the frontchannel_logout_uri is used.
+	FrontChannelLogoutSessionSupported bool `json:"frontchannel_logout_session_supported"`
+
+	// Boolean value specifying whether the OP supports back-channel logout, with true indicating support.
+	BackChannelLogoutSupported bool `json:"backchannel_logout_supported"`
+
+	// Boolean value specifying whether the OP can pass a sid (session ID) Claim in the Logout Token to identify the RP
+	// session with the OP.
+	BackChannelLogoutSessionSupported bool `json:"backchannel_logout_session_supported"`
+
+	// URL of the authorization server's pushed authorization request endpoint.
+	PushedAuthorizationRequestEndpoint string `json:"pushed_authorization_request_endpoint,omitempty"`
+
+	// Boolean value indicating server support for mutual TLS client certificate bound access tokens.
+	TLSClientCertificateBoundAccessTokens bool `json:"tls_client_certificate_bound_access_tokens"`
+
+	// URL of the authorization server's OAuth 2.0 introspection endpoint.
+	IntrospectionEndpoint string `json:"introspection_endpoint,omitempty"`
+
+	// URL of the authorization server's OAuth 2.0 revocation endpoint.
+	RevocationEndpoint string `json:"revocation_endpoint,omitempty"`
+
+	// JSON array containing a list of the OAuth 2.0 Grant Type values that this OP supports.
+	GrantTypesSupported []string `json:"grant_types_supported,omitempty"`
+
+	// JSON array containing a list of the OAuth 2.0 response_mode values that this OP supports.
+	ResponseModesSupported []string `json:"response_modes_supported,omitempty"`
+
+	// JSON array containing a list of the PKCE code challenge methods supported by this authorization server.
+	CodeChallengeMethodsSupported []string `json:"code_challenge_methods_supported,omitempty"`
+
+	// JSON array containing a list of the JWS signing algorithms (alg values) supported by the UserInfo Endpoint to
+	// encode the Claims in a JWT.
+	UserinfoSigningAlgValuesSupported []string `json:"userinfo_signing_alg_values_supported,omitempty"`
+
+	// JSON array containing a list of the JWE encryption algorithms (alg values) supported by the OP for the ID Token
+	// to encode the Claims in a JWT.
+	IDTokenEncryptionAlgValuesSupported []string `json:"id_token_encryption_alg_values_supported,omitempty"`
+
+	// JSON array containing a list of the JWE encryption algorithms (enc values) supported by the OP for the ID Token
+	// to encode the Claims in a JWT.
+	IDTokenEncryptionEncValuesSupported []string `json:"id_token_encryption_enc_values_supported,omitempty"`
+
+	// JSON array containing a list of the JWE encryption algorithms (alg values) supported by the UserInfo Endpoint
+	// to encode the Claims in a JWT.
+	UserinfoEncryptionAlgValuesSupported []string `json:"userinfo_encryption_alg_values_supported,omitempty"`
+
+	// JSON array containing a list of the JWE encryption algorithms (enc values) supported by the UserInfo Endpoint
+	// to encode the Claims in a JWT.
+	UserinfoEncryptionEncValuesSupported []string `json:"userinfo_encryption_enc_values_supported,omitempty"`
+
+	// Boolean value specifying whether the OP supports use of the claims parameter, with true indicating support.
+	ClaimsParameterSupported bool `json:"claims_parameter_supported"`
 }
 
 // swagger:model flushInactiveOAuth2TokensRequest
@@ -129,12 +210,33 @@ func (h *Handler) SetRoutes(r *httprouter.Router) {
 	r.GET(AuthPath, h.AuthHandler)
 	r.POST(AuthPath, h.AuthHandler)
 	r.GET(DefaultConsentPath, h.DefaultConsentHandler)
+	r.GET(ResumePath, h.ResumeHandler)
 	r.POST(IntrospectPath, h.IntrospectHandler)
 	r.POST(RevocationPath, h.RevocationHandler)
 	r.GET(WellKnownPath, h.WellKnownHandler)
 	r.GET(UserinfoPath, h.UserinfoHandler)
 	r.POST(UserinfoPath, h.UserinfoHandler)
 	r.POST(FlushPath, h.FlushHandler)
+	r.DELETE(TokensPath, h.RevokeTokensHandler)
+	if h.TokenHistory != nil {
+		r.POST(TokenHistoryPath, h.TokenHistoryHandler)
+		r.POST(TokenChainPath, h.TokenChainHandler)
+	}
+	if h.RevocationFilter != nil {
+		r.GET(RevocationFilterPath, h.RevocationFilterHandler)
+	}
+	if h.ConsentGrants != nil {
+		r.GET(ConsentGrantsPath, h.ListConsentGrantsHandler)
+		r.DELETE(ConsentGrantsPath, h.RevokeConsentGrantHandler)
+	}
+	if h.PushedAuthorizationRequests != nil {
+		r.POST(PushedAuthorizationRequestPath, h.PushedAuthorizationRequestHandler)
+	}
+	if h.Logout != nil {
+		r.GET(LogoutPath, h.LogoutHandler)
+		r.POST(LogoutPath, h.LogoutHandler)
+		r.GET(CheckSessionPath, h.CheckSessionHandler)
+	}
 }
 
 // swagger:route GET /.well-known/openid-configuration oAuth2 getWellKnown
@@ -145,6 +247,8 @@ func (h *Handler) SetRoutes(r *httprouter.Router) {
 // your own OpenID Connect client but to use an OpenID Connect client library instead. You can learn more on this
 // flow at https://openid.net/specs/openid-connect-discovery-1_0.html
 //
+// If ISSUER_BY_HOST maps the host the request was sent to to an issuer, the document is built for that issuer.
+//
 //     Produces:
 //     - application/json
 //
@@ -155,7 +259,8 @@ func (h *Handler) SetRoutes(r *httprouter.Router) {
 //       401: genericError
 //       500: genericError
 func (h *Handler) WellKnownHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
-	userInfoEndpoint := h.Issuer + UserinfoPath
+	issuer := h.issuer(r)
+	userInfoEndpoint := issuer + UserinfoPath
 	if h.UserinfoEndpoint != "" {
 		userInfoEndpoint = h.UserinfoEndpoint
 	}
@@ -170,18 +275,79 @@ func (h *Handler) WellKnownHandler(w http.ResponseWriter, r *http.Request, _ htt
 		scopesSupported = append(scopesSupported, strings.Split(h.ScopesSupported, ",")...)
 	}
 
+	idTokenSigningAlg := "RS256"
+	if h.IDTokenSigningAlgorithm != "" {
+		idTokenSigningAlg = h.IDTokenSigningAlgorithm
+	}
+
+	var registrationEndpoint string
+	if h.DynamicClientRegistration {
+		registrationEndpoint = issuer + client.DynamicRegistrationPath
+	}
+
+	var pushedAuthorizationRequestEndpoint string
+	if h.PushedAuthorizationRequests != nil {
+		pushedAuthorizationRequestEndpoint = issuer + PushedAuthorizationRequestPath
+	}
+
+	var endSessionEndpoint, checkSessionIframe string
+	if h.Logout != nil {
+		endSessionEndpoint = issuer + LogoutPath
+		checkSessionIframe = issuer + CheckSessionPath
+	}
+
+	// The plain code challenge method is disabled in the fosite configuration.
+	codeChallengeMethods := []string{"S256"}
+
+	responseModes := []string{"query", "fragment", ResponseModeFormPost}
+	if len(h.EmbedOrigins) > 0 {
+		responseModes = append(responseModes, ResponseModeWebMessage)
+	}
+
+	var userinfoSigningAlgs []string
+	if h.UserinfoSigner != nil {
+		userinfoSigningAlgs = []string{string(h.UserinfoSigner.Algorithm)}
+	}
+
+	var encryptionAlgs, encryptionEncs []string
+	if h.ResponseEncrypter != nil {
+		encryptionAlgs, encryptionEncs = client.ResponseEncryptionAlgs, client.ResponseEncryptionEncs
+	}
+
 	h.H.Write(w, r, &WellKnown{
-		Issuer:                            h.Issuer,
-		AuthURL:                           h.Issuer + AuthPath,
-		TokenURL:                          h.Issuer + TokenPath,
-		JWKsURI:                           h.Issuer + JWKPath,
-		SubjectTypes:                      []string{"pairwise", "public"},
-		ResponseTypes:                     []string{"code", "code id_token", "id_token", "token id_token", "token", "token id_token code"},
-		ClaimsSupported:                   claimsSupported,
-		ScopesSupported:                   scopesSupported,
-		UserinfoEndpoint:                  userInfoEndpoint,
-		TokenEndpointAuthMethodsSupported: []string{"client_secret_post", "client_secret_basic"},
-		IDTokenSigningAlgValuesSupported:  []string{"RS256"},
+		Issuer:                             issuer,
+		AuthURL:                            issuer + AuthPath,
+		TokenURL:                           issuer + TokenPath,
+		JWKsURI:                            issuer + JWKPath,
+		SubjectTypes:                       []string{"pairwise", "public"},
+		ResponseTypes:                      []string{"code", "code id_token", "id_token", "token id_token", "token", "token id_token code"},
+		ClaimsSupported:                    claimsSupported,
+		ScopesSupported:                    scopesSupported,
+		UserinfoEndpoint:                   userInfoEndpoint,
+		TokenEndpointAuthMethodsSupported:  []string{"client_secret_post", "client_secret_basic", "tls_client_auth", "self_signed_tls_client_auth"},
+		IDTokenSigningAlgValuesSupported:   []string{idTokenSigningAlg},
+		RegistrationEndpoint:               registrationEndpoint,
+		EndSessionEndpoint:                 endSessionEndpoint,
+		CheckSessionIframe:                 checkSessionIframe,
+		FrontChannelLogoutSupported:        h.Logout != nil,
+		FrontChannelLogoutSessionSupported: h.Logout != nil,
+		BackChannelLogoutSupported:         h.Logout != nil,
+		BackChannelLogoutSessionSupported:  h.Logout != nil,
+
+		PushedAuthorizationRequestEndpoint:    pushedAuthorizationRequestEndpoint,
+		TLSClientCertificateBoundAccessTokens: true,
+
+		IntrospectionEndpoint:                issuer + IntrospectPath,
+		RevocationEndpoint:                   issuer + RevocationPath,
+		GrantTypesSupported:                  []string{"authorization_code", "implicit", "client_credentials", "refresh_token", GrantTypeTokenExchange},
+		ResponseModesSupported:               responseModes,
+		CodeChallengeMethodsSupported:        codeChallengeMethods,
+		UserinfoSigningAlgValuesSupported:    userinfoSigningAlgs,
+		IDTokenEncryptionAlgValuesSupported:  encryptionAlgs,
+		IDTokenEncryptionEncValuesSupported:  encryptionEncs,
+		UserinfoEncryptionAlgValuesSupported: encryptionAlgs,
+		UserinfoEncryptionEncValuesSupported: encryptionEncs,
+		ClaimsParameterSupported:             true,
 	})
 }
 
@@ -189,11 +355,17 @@ func (h *Handler) WellKnownHandler(w http.ResponseWriter, r *http.Request, _ htt
 //
 // OpenID Connect Userinfo
 //
-// This endpoint returns the payload of the ID Token, including the idTokenExtra values, of the provided OAuth 2.0 access token.
+// This endpoint returns the payload of the ID Token, including the idTokenExtra and userinfoExtra values, of the provided OAuth 2.0 access token.
 // The endpoint implements http://openid.net/specs/openid-connect-core-1_0.html#UserInfo .
 //
+// If the client registered a userinfo_signed_response_alg, the claims are returned as a JSON Web Token with content
+// type application/jwt, signed using the OpenID Connect key and including the "iss" and "aud" claims. If the client
+// registered a userinfo_encrypted_response_alg, the JSON Web Token or, if it is not signed, the claims are encrypted
+// to the client's public key.
+//
 //     Produces:
 //     - application/json
+//     - application/jwt
 //
 //     Schemes: http, https
 //
@@ -212,7 +384,8 @@ func (h *Handler) UserinfoHandler(w http.ResponseWriter, r *http.Request, _ http
 		return
 	}
 
-	interim := ar.GetSession().(*Session).IDTokenClaims().ToMap()
+	s := ar.GetSession().(*Session)
+	interim := s.IDTokenClaims().ToMap()
 	delete(interim, "aud")
 	delete(interim, "iss")
 	delete(interim, "nonce")
@@ -223,6 +396,15 @@ func (h *Handler) UserinfoHandler(w http.ResponseWriter, r *http.Request, _ http
 	delete(interim, "rat")
 	delete(interim, "exp")
 
+	for k, v := range s.UserinfoExtra {
+		interim[k] = v
+	}
+
+	if c, ok := ar.GetClient().(*client.Client); ok && (c.UserinfoSignedResponseAlg != "" || c.UserinfoEncryptedResponseAlg != "") {
+		h.writeUserinfoJWT(w, r, c, interim)
+		return
+	}
+
 	h.H.Write(w, r, interim)
 }
 
@@ -247,7 +429,7 @@ func (h *Handler) UserinfoHandler(w http.ResponseWriter, r *http.Request, _ http
 //       401: genericError
 //       500: genericError
 func (h *Handler) RevocationHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
-	var ctx = fosite.NewContext()
+	var ctx = r.Context()
 
 	err := h.OAuth2.NewRevocationRequest(ctx, r)
 	if err != nil {
@@ -265,6 +447,14 @@ func (h *Handler) RevocationHandler(w http.ResponseWriter, r *http.Request, _ ht
 // is neither expired nor revoked. If a token is active, additional information on the token will be included. You can
 // set additional data for a token by setting `accessTokenExtra` during the consent flow.
 //
+// Resource servers which are allowed to cache introspection results are told for how long using the `Cache-Control`
+// header, which never exceeds the remaining lifetime of the token. All other responses are sent with
+// `Cache-Control: no-store`.
+//
+// OAuth 2.0 Clients which registered a `resource_server_audience` may only introspect access tokens issued for one of
+// these audiences, other tokens are reported as inactive. Their introspection responses only contain the audiences of
+// the resource server and the scopes of these audiences, and never the `ext` and `username` claims.
+//
 //  ```
 //  {
 //    "resources": ["rn:hydra:oauth2:tokens"],
@@ -290,15 +480,20 @@ func (h *Handler) RevocationHandler(w http.ResponseWriter, r *http.Request, _ ht
 //       401: genericError
 //       500: genericError
 func (h *Handler) IntrospectHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var caller, callerClient string
+	var basicAuth bool
 	if token := h.W.TokenFromRequest(r); token != "" {
-		if _, err := h.W.TokenAllowed(r.Context(), token, &firewall.TokenAccessRequest{
+		c, err := h.W.TokenAllowed(r.Context(), token, &firewall.TokenAccessRequest{
 			Resource: fmt.Sprintf(h.PrefixResource("oauth2:tokens")),
 			Action:   "introspect",
-		}, IntrospectScope); err != nil {
+		}, IntrospectScope)
+		if err != nil {
 			h.H.WriteError(w, r, err)
 			return
 		}
+		caller, callerClient = c.Subject, c.ClientID
 	} else if client, _, ok := r.BasicAuth(); ok {
+		caller, callerClient, basicAuth = client, client, true
 		// If no token is given, we do not need a scope.
 		if err := h.W.IsAllowed(r.Context(), &firewall.AccessRequest{
 			Subject:  client,
@@ -313,10 +508,47 @@ func (h *Handler) IntrospectHandler(w http.ResponseWriter, r *http.Request, _ ht
 		return
 	}
 
+	if err := r.ParseForm(); err != nil {
+		h.OAuth2.WriteIntrospectionError(w, errors.Wrap(fosite.ErrInvalidRequest, err.Error()))
+		return
+	}
+
+	rs, err := h.resourceServer(r.Context(), callerClient)
+	if err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	start := time.Now()
+	token, scope := r.PostForm.Get("token"), r.PostForm.Get("scope")
+	if h.IntrospectionCache != nil {
+		if cached, ok := h.IntrospectionCache.Get(token, scope); ok {
+			// Cached results are returned without asking fosite, which is where the client secret is checked.
+			if basicAuth {
+				if err := h.authenticateIntrospectingClient(r.Context(), r); err != nil {
+					h.H.WriteError(w, r, err)
+					return
+				}
+			}
+			if h.IntrospectionObserved != nil {
+				h.IntrospectionObserved(true, time.Since(start))
+			}
+			cached.Issuer = h.issuer(r)
+			if rs != nil {
+				cached = h.filterIntrospection(rs, cached)
+			}
+			h.writeIntrospection(w, caller, cached)
+			return
+		}
+	}
+
 	var session = NewSession("")
 
-	var ctx = fosite.NewContext()
+	var ctx = r.Context()
 	resp, err := h.OAuth2.NewIntrospectionRequest(ctx, r, session)
+	if h.IntrospectionObserved != nil {
+		h.IntrospectionObserved(err == nil, time.Since(start))
+	}
 	if err != nil {
 		pkg.LogError(err, h.L)
 		h.OAuth2.WriteIntrospectionError(w, err)
@@ -328,8 +560,7 @@ func (h *Handler) IntrospectHandler(w http.ResponseWriter, r *http.Request, _ ht
 		exp = resp.GetAccessRequester().GetRequestedAt().Add(h.AccessTokenLifespan)
 	}
 
-	w.Header().Set("Content-Type", "application/json;charset=UTF-8")
-	if err = json.NewEncoder(w).Encode(&Introspection{
+	introspection := &Introspection{
 		Active:    true,
 		ClientID:  resp.GetAccessRequester().GetClient().GetID(),
 		Scope:     strings.Join(resp.GetAccessRequester().GetGrantedScopes(), " "),
@@ -338,12 +569,82 @@ func (h *Handler) IntrospectHandler(w http.ResponseWriter, r *http.Request, _ ht
 		Subject:   resp.GetAccessRequester().GetSession().GetSubject(),
 		Username:  resp.GetAccessRequester().GetSession().GetUsername(),
 		Extra:     resp.GetAccessRequester().GetSession().(*Session).Extra,
-		Issuer:    h.Issuer,
-	}); err != nil {
+		Actor:     resp.GetAccessRequester().GetSession().(*Session).Actor,
+		Issuer:    h.issuer(r),
+
+		Confirmation: resp.GetAccessRequester().GetSession().(*Session).Confirmation,
+		Audience:     tokenAudience(resp.GetAccessRequester().GetSession(), resp.GetAccessRequester().GetClient().GetID()),
+	}
+
+	if h.IntrospectionCache != nil {
+		h.IntrospectionCache.Add(token, scope, resp.GetAccessRequester().GetID(), introspection)
+	}
+
+	if rs != nil {
+		introspection = h.filterIntrospection(rs, introspection)
+	}
+	h.writeIntrospection(w, caller, introspection)
+}
+
+// authenticateIntrospectingClient checks the secret of the client authenticating the introspection request using
+// basic authorization, like fosite does for introspection requests which are not answered from the cache.
+func (h *Handler) authenticateIntrospectingClient(ctx context.Context, r *http.Request) error {
+	c, err := h.Storage.GetClient(ctx, tokenRequestClientID(r))
+	if err != nil {
+		return errors.Wrap(fosite.ErrRequestUnauthorized, err.Error())
+	}
+
+	if err := h.ClientSecretHasher.Compare(c.GetHashedSecret(), []byte(tokenRequestClientSecret(r))); err != nil {
+		return errors.Wrap(fosite.ErrRequestUnauthorized, err.Error())
+	}
+	return nil
+}
+
+// writeIntrospection writes the result of introspecting an active token. Trusted callers are allowed to cache it
+// until IntrospectionCacheControlMaxAge passed or the token expires, whichever comes first.
+func (h *Handler) writeIntrospection(w http.ResponseWriter, caller string, introspection *Introspection) {
+	if maxAge := h.introspectionMaxAge(caller, introspection); maxAge > 0 {
+		w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", maxAge))
+	} else {
+		w.Header().Set("Cache-Control", "no-store")
+	}
+
+	w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	if err := json.NewEncoder(w).Encode(introspection); err != nil {
 		pkg.LogError(err, h.L)
 	}
 }
 
+// introspectionMaxAge returns the number of seconds the caller may cache the introspection result for, or zero if
+// the caller is not trusted to cache it.
+func (h *Handler) introspectionMaxAge(caller string, introspection *Introspection) int64 {
+	if h.IntrospectionCacheControlMaxAge <= 0 || caller == "" || !introspection.Active {
+		return 0
+	}
+
+	var trusted bool
+	for _, subject := range h.IntrospectionCacheControlSubjects {
+		if subject == caller {
+			trusted = true
+			break
+		}
+	}
+	if !trusted {
+		return 0
+	}
+
+	maxAge := int64(h.IntrospectionCacheControlMaxAge / time.Second)
+	if introspection.ExpiresAt > 0 {
+		if remaining := introspection.ExpiresAt - time.Now().Unix(); remaining < maxAge {
+			maxAge = remaining
+		}
+	}
+	if maxAge < 0 {
+		return 0
+	}
+	return maxAge
+}
+
 // swagger:route POST /oauth2/flush oAuth2 flushInactiveOAuth2Tokens
 //
 // Flush Expired OAuth2 Access Tokens
@@ -415,6 +716,21 @@ func (h *Handler) FlushHandler(w http.ResponseWriter, r *http.Request, _ httprou
 //
 // To learn more about this flow please refer to the specification: https://tools.ietf.org/html/rfc6749
 //
+// Clients with the grant type "urn:ietf:params:oauth:grant-type:token-exchange" can exchange an access token issued
+// to another client for an access token issued to themselves, acting on behalf of the token's subject, see
+// https://tools.ietf.org/html/rfc8693. The exchange must be allowed by a policy granting the client the action "exchange"
+// on "rn:hydra:oauth2:token-exchange:<client id of the subject token>". The exchanged token carries the act claim and
+// does not outlive the subject token.
+//
+// Clients can restrict access tokens to resource servers using the audience parameter, which may be repeated or
+// contain a space-separated list of audiences. Every audience must be registered in the client's audience field. The
+// audiences are the aud claim of JSON Web Token access tokens and are returned by the introspection endpoint.
+//
+// The `resource` parameter restricts the access token to some of the resources authorized at the authorization
+// endpoint, or for grants without an authorization, to resources registered in the client's audience field. Access
+// tokens for resources listed in OAUTH2_RESOURCE_SCOPES only carry the granted scopes of these resources, so that a
+// refresh token can be used to obtain differently scoped access tokens for each resource.
+//
 //     Consumes:
 //     - application/x-www-form-urlencoded
 //
@@ -433,15 +749,53 @@ func (h *Handler) FlushHandler(w http.ResponseWriter, r *http.Request, _ httprou
 //       500: genericError
 func (h *Handler) TokenHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
 	var session = NewSession("")
-	var ctx = fosite.NewContext()
+	var ctx = withTokenExchange(r.Context())
+
+	clientID := tokenRequestClientID(r)
+	if h.ClientAuthGuard != nil && clientID != "" {
+		if locked := h.ClientAuthGuard.Locked(r, clientID); locked > 0 {
+			if h.ClientAuthFailed != nil {
+				h.ClientAuthFailed(true)
+			}
+
+			err := errors.Wrap(fosite.ErrInvalidClient, "Too many failed authentication attempts")
+			pkg.LogError(err, h.L)
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(locked.Seconds()))))
+			h.OAuth2.WriteAccessError(w, fosite.NewAccessRequest(session), err)
+			return
+		}
+	}
+
+	ctx, r, err := h.authenticateTLSClient(ctx, r)
+	if err != nil {
+		h.observeClientAuthentication(r, clientID, err)
+		pkg.LogError(err, h.L)
+		h.OAuth2.WriteAccessError(w, fosite.NewAccessRequest(session), err)
+		return
+	}
 
 	accessRequest, err := h.OAuth2.NewAccessRequest(ctx, r, session)
+	h.observeClientAuthentication(r, clientID, err)
 	if err != nil {
 		pkg.LogError(err, h.L)
 		h.OAuth2.WriteAccessError(w, accessRequest, err)
 		return
 	}
 
+	if err := grantAudience(accessRequest); err != nil {
+		pkg.LogError(err, h.L)
+		h.OAuth2.WriteAccessError(w, accessRequest, err)
+		return
+	}
+
+	if ctx.Value(tlsClientAuthContextKey{}) != nil {
+		bindToCertificate(accessRequest.GetSession(), r.TLS.PeerCertificates[0])
+	} else if h.ClientSecretVerified != nil {
+		if secret := tokenRequestClientSecret(r); secret != "" {
+			h.ClientSecretVerified(accessRequest.GetClient().GetID(), []byte(secret))
+		}
+	}
+
 	if accessRequest.GetGrantTypes().Exact("client_credentials") {
 		session.Subject = accessRequest.GetClient().GetID()
 		for _, scope := range accessRequest.GetRequestedScopes() {
@@ -449,6 +803,18 @@ func (h *Handler) TokenHandler(w http.ResponseWriter, r *http.Request, _ httprou
 				accessRequest.GrantScope(scope)
 			}
 		}
+
+		if err := h.applyClaimsHook(ctx, accessRequest, accessRequest.GetGrantTypes(), session); err != nil {
+			pkg.LogError(err, h.L)
+			h.OAuth2.WriteAccessError(w, accessRequest, err)
+			return
+		}
+	}
+
+	if err := h.grantResources(accessRequest); err != nil {
+		pkg.LogError(err, h.L)
+		h.OAuth2.WriteAccessError(w, accessRequest, err)
+		return
 	}
 
 	accessResponse, err := h.OAuth2.NewAccessResponse(ctx, accessRequest)
@@ -458,7 +824,80 @@ func (h *Handler) TokenHandler(w http.ResponseWriter, r *http.Request, _ httprou
 		return
 	}
 
+	if idToken, ok := accessResponse.GetExtra("id_token").(string); ok && idToken != "" {
+		encrypted, err := h.encryptIDToken(ctx, accessRequest.GetClient(), idToken)
+		if err != nil {
+			pkg.LogError(err, h.L)
+			h.OAuth2.WriteAccessError(w, accessRequest, err)
+			return
+		}
+		accessResponse.SetExtra("id_token", encrypted)
+	}
+
 	h.OAuth2.WriteAccessResponse(w, accessRequest, accessResponse)
+	if h.TokenIssued != nil {
+		h.TokenIssued(strings.Join(accessRequest.GetGrantTypes(), " "))
+	}
+}
+
+// tokenRequestClientID returns the client id a token request authenticates with, or an empty string if it is not
+// set.
+func tokenRequestClientID(r *http.Request) string {
+	if id, _, ok := r.BasicAuth(); ok {
+		if unescaped, err := url.QueryUnescape(id); err == nil {
+			return unescaped
+		}
+		return id
+	}
+
+	if err := r.ParseForm(); err != nil {
+		return ""
+	}
+	return r.PostForm.Get("client_id")
+}
+
+// tokenRequestClientSecret returns the client secret of a token request, read the same way fosite reads it.
+func tokenRequestClientSecret(r *http.Request) string {
+	if _, secret, ok := r.BasicAuth(); ok {
+		if unescaped, err := url.QueryUnescape(secret); err == nil {
+			return unescaped
+		}
+		return secret
+	}
+	return r.PostForm.Get("client_secret")
+}
+
+// observeClientAuthentication counts failed client authentications and locks out clients that fail too often. err
+// is the error of authenticating the client, which may be nil.
+func (h *Handler) observeClientAuthentication(r *http.Request, clientID string, err error) {
+	if clientID == "" {
+		return
+	} else if err == nil {
+		if h.ClientAuthGuard != nil {
+			h.ClientAuthGuard.Succeed(r, clientID)
+		}
+		return
+	} else if errors.Cause(err) != fosite.ErrInvalidClient {
+		return
+	}
+
+	if h.ClientAuthFailed != nil {
+		h.ClientAuthFailed(false)
+	}
+
+	if h.ClientAuthGuard == nil {
+		return
+	} else if _, err := h.Storage.GetClient(r.Context(), clientID); err != nil {
+		// Unknown clients can not authenticate anyway, counting their attempts would only grow the guard.
+		return
+	}
+
+	if h.ClientAuthGuard.Fail(r, clientID) {
+		h.L.WithField("client_id", clientID).Warnf("Locked out client for %s after too many failed authentication attempts", h.ClientAuthGuard.Lockout)
+		if h.ClientLockedOut != nil {
+			h.ClientLockedOut(r, clientID)
+		}
+	}
 }
 
 // swagger:route GET /oauth2/auth oAuth2 oauthAuth
@@ -470,22 +909,86 @@ func (h *Handler) TokenHandler(w http.ResponseWriter, r *http.Request, _ httprou
 //
 // To learn more about this flow please refer to the specification: https://tools.ietf.org/html/rfc6749
 //
+// First-party clients may embed the flow in an iframe of a page served at one of the origins configured in
+// OAUTH2_EMBED_ALLOWED_ORIGINS by setting `response_mode=web_message`. The origin of the redirect URI must be the
+// origin of the embedding page. The consent app receives the origin in the `embed_origin` query parameter and should
+// allow it to frame the login and consent screens. Instead of redirecting to the redirect URI, the flow is completed
+// by posting the message `{"type": "authorization_response", "response": {...}}` to the embedding page.
+//
+// Clients may set `response_mode=form_post` to receive the authorization response as form parameters of a POST request
+// to the redirect URI instead of in its query or fragment. The endpoint then responds with a page that submits the
+// form automatically.
+//
+// The `prompt`, `max_age` and `acr_values` parameters are passed on to the consent app. Remembered consent is not
+// used if the client asks for `prompt=login`, `prompt=consent` or `prompt=select_account`, or if the user authenticated
+// longer ago than `max_age` seconds. With `prompt=none` the consent app is never shown and the endpoint responds with
+// `login_required` or `consent_required` instead.
+//
+// Clients can request tokens for resource servers using the `resource` parameter, which may be repeated, see
+// https://tools.ietf.org/html/rfc8707. Every resource must be registered in the client's audience field. The resources
+// are passed on to the consent app and become the audience of the issued access tokens.
+//
 //     Consumes:
 //     - application/x-www-form-urlencoded
 //
 //     Schemes: http, https
 //
 //     Responses:
+//       200: emptyResponse
 //       302: emptyResponse
 //       401: genericError
 //       500: genericError
 func (h *Handler) AuthHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
-	var ctx = fosite.NewContext()
+	var ctx = r.Context()
+
+	pushed, err := h.resolvePushedAuthorizationRequest(r)
+	if err != nil {
+		pkg.LogError(err, h.L)
+		h.writeBrowserError(w, r, err)
+		return
+	}
 
 	authorizeRequest, err := h.OAuth2.NewAuthorizeRequest(ctx, r)
 	if err != nil {
 		pkg.LogError(err, h.L)
-		h.writeAuthorizeError(w, authorizeRequest, err)
+		h.writeAuthorizeError(w, r, authorizeRequest, err)
+		return
+	}
+
+	// Requests returning from the login or consent app were pushed before they were sent to the login or consent app.
+	if !pushed && requiresPushedAuthorizationRequests(authorizeRequest.GetClient()) &&
+		authorizeRequest.GetRequestForm().Get("consent") == "" && !h.returnsFromLogin(r, authorizeRequest) {
+		err := errors.Wrap(fosite.ErrInvalidRequest, "The client must push its authorization requests to the pushed authorization request endpoint")
+		pkg.LogError(err, h.L)
+		h.writeAuthorizeError(w, r, authorizeRequest, err)
+		return
+	}
+
+	// Embedded flows are completed with a web message to the embedding page, which must be trusted.
+	var embedded string
+	if isEmbedded(authorizeRequest) {
+		if embedded, err = h.embedOrigin(authorizeRequest); err != nil {
+			pkg.LogError(err, h.L)
+			h.writeBrowserError(w, r, err)
+			return
+		}
+	}
+
+	if err := validatePrompt(authorizeRequest); err != nil {
+		pkg.LogError(err, h.L)
+		h.writeAuthorizeError(w, r, authorizeRequest, err)
+		return
+	}
+
+	if _, err := parseClaimsRequest(authorizeRequest); err != nil {
+		pkg.LogError(err, h.L)
+		h.writeAuthorizeError(w, r, authorizeRequest, err)
+		return
+	}
+
+	if err := validateResources(authorizeRequest); err != nil {
+		pkg.LogError(err, h.L)
+		h.writeAuthorizeError(w, r, authorizeRequest, err)
 		return
 	}
 
@@ -495,42 +998,95 @@ func (h *Handler) AuthHandler(w http.ResponseWriter, r *http.Request, _ httprout
           if errorParam[0] == "login_required" {
              err := errors.New("Login required!!!!!!!!!!!!!!")
              pkg.LogError(err, h.L)
-             h.writeAuthorizeError(w, authorizeRequest, errors.Wrapf(fosite.ErrLoginRequired, "Login required: %s", err))
+             h.writeAuthorizeError(w, r, authorizeRequest, errors.Wrapf(fosite.ErrLoginRequired, "Login required: %s", err))
 	          return
           }
 	}
 
+	var session *Session
+	var cookie *sessions.Session
+
 	// A session_token will be available if the user was authenticated an gave consent
 	consent := authorizeRequest.GetRequestForm().Get("consent")
+	loginVerifier := h.loginVerifier(authorizeRequest)
 	if consent == "" {
+		// Error can be ignored because a session will always be returned
+		cookie, _ = h.CookieStore.Get(r, consentCookieName)
+	}
+
+	// the consent of the subject the login app just authenticated must be asked for, even if the subject of this user
+	// agent remembered its consent
+	if consent == "" && loginVerifier == "" {
+		// the consent app is not asked if the subject of this user agent remembered its consent to the client
+		session, err = h.Consent.RememberedConsent(authorizeRequest, cookie)
+		if err != nil {
+			pkg.LogError(err, h.L)
+			h.writeAuthorizeError(w, r, authorizeRequest, errors.Wrapf(fosite.ErrServerError, "Could not look up remembered consent: %s", err))
+			return
+		}
+	}
+
+	// clients which skip consent are never sent to the consent app, they are granted all requested scopes as soon as
+	// the login app authenticated the subject
+	if consent == "" && loginVerifier != "" && skipsConsent(authorizeRequest) {
+		session, err = h.skippedLoggedInConsent(authorizeRequest, loginVerifier, cookie)
+		if err != nil {
+			pkg.LogError(err, h.L)
+			h.writeAuthorizeError(w, r, authorizeRequest, err)
+			return
+		}
+	}
+
+	if consent == "" && session == nil {
+		// the consent app must not interact with the user if the client asked for prompt=none
+		if hasPrompt(authorizeRequest, "none") {
+			err := interactionRequired(authorizeRequest, cookie)
+			pkg.LogError(err, h.L)
+			h.writeAuthorizeError(w, r, authorizeRequest, err)
+			return
+		}
+
 		// otherwise redirect to log in endpoint
-		if err := h.redirectToConsent(w, r, authorizeRequest); err != nil {
+		if err := h.redirectToLoginOrConsent(w, r, authorizeRequest, loginVerifier); err != nil {
 			pkg.LogError(err, h.L)
-			h.writeAuthorizeError(w, authorizeRequest, err)
+			h.writeAuthorizeError(w, r, authorizeRequest, err)
 			return
 		}
 		return
 	}
 
-	cookie, err := h.CookieStore.Get(r, consentCookieName)
-	if err != nil {
-		pkg.LogError(err, h.L)
-		h.writeAuthorizeError(w, authorizeRequest, errors.Wrapf(fosite.ErrServerError, "Could not open session: %s", err))
-		return
+	if consent != "" {
+		cookie, err = h.CookieStore.Get(r, consentCookieName)
+		if err != nil {
+			pkg.LogError(err, h.L)
+			h.writeAuthorizeError(w, r, authorizeRequest, errors.Wrapf(fosite.ErrServerError, "Could not open session: %s", err))
+			return
+		}
+
+		// decode consent_token claims
+		// verify anti-CSRF (inject state) and anti-replay token (expiry time, good value would be 10 seconds)
+		session, err = h.Consent.ValidateConsentRequest(authorizeRequest, consent, cookie)
+		if err != nil {
+			pkg.LogError(err, h.L)
+			h.writeAuthorizeError(w, r, authorizeRequest, err)
+			return
+		}
 	}
 
-	// decode consent_token claims
-	// verify anti-CSRF (inject state) and anti-replay token (expiry time, good value would be 10 seconds)
-	session, err := h.Consent.ValidateConsentRequest(authorizeRequest, consent, cookie)
-	if err != nil {
+	// ID tokens carry the issuer of the host the authorization request was sent to.
+	if issuer, ok := h.issuerForHost(r); ok && session != nil && session.DefaultSession != nil && session.Claims != nil {
+		session.Claims.Issuer = issuer
+	}
+
+	if err := h.applyClaimsHook(ctx, authorizeRequest, nil, session); err != nil {
 		pkg.LogError(err, h.L)
-		h.writeAuthorizeError(w, authorizeRequest, err)
+		h.writeAuthorizeError(w, r, authorizeRequest, err)
 		return
 	}
 
 	if err := cookie.Save(r, w); err != nil {
 		pkg.LogError(err, h.L)
-		h.writeAuthorizeError(w, authorizeRequest, errors.Wrapf(fosite.ErrServerError, "Could not store session cookie: %s", err))
+		h.writeAuthorizeError(w, r, authorizeRequest, errors.Wrapf(fosite.ErrServerError, "Could not store session cookie: %s", err))
 		return
 	}
 
@@ -538,7 +1094,31 @@ func (h *Handler) AuthHandler(w http.ResponseWriter, r *http.Request, _ httprout
 	response, err := h.OAuth2.NewAuthorizeResponse(ctx, authorizeRequest, session)
 	if err != nil {
 		pkg.LogError(err, h.L)
-		h.writeAuthorizeError(w, authorizeRequest, err)
+		h.writeAuthorizeError(w, r, authorizeRequest, err)
+		return
+	}
+
+	if idToken := response.GetFragment().Get("id_token"); idToken != "" {
+		encrypted, err := h.encryptIDToken(ctx, authorizeRequest.GetClient(), idToken)
+		if err != nil {
+			pkg.LogError(err, h.L)
+			h.writeAuthorizeError(w, r, authorizeRequest, err)
+			return
+		}
+		response.GetFragment().Set("id_token", encrypted)
+	}
+
+	if err := h.addSessionState(w, authorizeRequest, response, cookie); err != nil {
+		pkg.LogError(err, h.L)
+		h.writeAuthorizeError(w, r, authorizeRequest, errors.Wrapf(fosite.ErrServerError, "Could not compute session state: %s", err))
+		return
+	}
+
+	if embedded != "" {
+		h.writeWebMessageResponse(w, embedded, response)
+		return
+	} else if isFormPost(authorizeRequest) {
+		h.writeFormPostResponse(w, authorizeRequest, response)
 		return
 	}
 
@@ -549,26 +1129,10 @@ func (h *Handler) redirectToConsent(w http.ResponseWriter, r *http.Request, auth
 	// Error can be ignored because a session will always be returned
 	cookie, _ := h.CookieStore.Get(r, consentCookieName)
 
-	host, _, err := net.SplitHostPort(r.Host)
-	if err != nil {
-		host = r.Host
-	}
-
-	authUrl, err := url.Parse(h.Issuer + AuthPath)
+	authUrl, err := h.authorizationURL(r)
 	if err != nil {
 		return err
 	}
-	authHost, _, err := net.SplitHostPort(authUrl.Host)
-	if err != nil {
-		authHost = authUrl.Host
-	}
-	if authHost != host {
-		h.L.WithFields(logrus.Fields{
-			"request_host": host,
-			"issuer_host":  authHost,
-		}).Warnln("Host from auth request does not match issuer host. The consent return redirect may fail.")
-	}
-	authUrl.RawQuery = r.URL.RawQuery
 
 	challenge, err := h.Consent.CreateConsentRequest(authorizeRequest, authUrl.String(), cookie)
 	if err != nil {
@@ -579,6 +1143,28 @@ func (h *Handler) redirectToConsent(w http.ResponseWriter, r *http.Request, auth
 	q := p.Query()
 	q.Set("consent", challenge)
 
+	// The consent app must allow the embedding page to frame the login and consent screens.
+	if isEmbedded(authorizeRequest) {
+		if embedded, err := h.embedOrigin(authorizeRequest); err == nil {
+			q.Set("embed_origin", embedded)
+		}
+	}
+
+	// The consent app should authenticate the user with one of the requested authentication context classes.
+	if acrValues := authorizeRequest.GetRequestForm().Get("acr_values"); acrValues != "" {
+		q.Set("acr_values", acrValues)
+	}
+
+	// The consent app must authenticate the user again if the last authentication is older than max_age seconds.
+	if age := authorizeRequest.GetRequestForm().Get("max_age"); age != "" {
+		q.Set("max_age", age)
+	}
+
+	// The consent app may show the resources the tokens are requested for.
+	if resources := requestedResources(authorizeRequest.GetRequestForm()); len(resources) > 0 {
+		q["resource"] = resources
+	}
+
 	vals := r.URL.Query()
         if vals["prompt"] != nil {
            fmt.Printf("Setting prompt params to %s", vals["prompt"][0])
@@ -595,20 +1181,65 @@ func (h *Handler) redirectToConsent(w http.ResponseWriter, r *http.Request, auth
 	return nil
 }
 
-func (h *Handler) writeAuthorizeError(w http.ResponseWriter, ar fosite.AuthorizeRequester, err error) {
+func (h *Handler) writeAuthorizeError(w http.ResponseWriter, r *http.Request, ar fosite.AuthorizeRequester, err error) {
 	if !ar.IsRedirectURIValid() {
-		var rfcerr = fosite.ErrorToRFC6749Error(err)
-
-		redirectURI := h.ConsentURL
-		query := redirectURI.Query()
-		query.Add("error", rfcerr.Name)
-		query.Add("error_description", rfcerr.Description)
-		redirectURI.RawQuery = query.Encode()
+		h.writeBrowserError(w, r, err)
+		return
+	}
 
-		w.Header().Add("Location", redirectURI.String())
-		w.WriteHeader(http.StatusFound)
+	if isEmbedded(ar) {
+		embedded, embedErr := h.embedOrigin(ar)
+		if embedErr != nil {
+			h.writeBrowserError(w, r, err)
+			return
+		}
+		h.writeWebMessageError(w, r, embedded, ar, err)
+		return
+	} else if isFormPost(ar) {
+		h.writeFormPostError(w, r, ar, err)
 		return
 	}
 
-	h.OAuth2.WriteAuthorizeError(w, ar, err)
+	h.OAuth2.WriteAuthorizeError(w, ar, h.localizeError(r, err))
+}
+
+// writeBrowserError redirects the user agent to the error URL (or the consent URL if none is set) for errors which
+// can not be sent back to the client's redirect URI.
+func (h *Handler) writeBrowserError(w http.ResponseWriter, r *http.Request, err error) {
+	var rfcerr = fosite.ErrorToRFC6749Error(h.localizeError(r, err))
+
+	redirectURI := h.ConsentURL
+	if h.ErrorURL.String() != "" {
+		redirectURI = h.ErrorURL
+	}
+
+	query := redirectURI.Query()
+	query.Add("error", rfcerr.Name)
+	query.Add("error_description", rfcerr.Description)
+	if rfcerr.Hint != "" {
+		query.Add("error_hint", rfcerr.Hint)
+	}
+	redirectURI.RawQuery = query.Encode()
+
+	w.Header().Add("Location", redirectURI.String())
+	w.WriteHeader(http.StatusFound)
+}
+
+// localizeError returns err with its description translated to the language preferred by the user agent, which is
+// read from the ui_locales parameter and the Accept-Language header. err is returned as is if there is no error
+// catalog or the catalog has no translation.
+func (h *Handler) localizeError(r *http.Request, err error) error {
+	if h.ErrorCatalog == nil {
+		return err
+	}
+
+	rfcerr := fosite.ErrorToRFC6749Error(err)
+	description := h.ErrorCatalog.Describe(i18n.Preferred(r, r.FormValue("ui_locales")), rfcerr.Name, rfcerr.Description)
+	if description == rfcerr.Description {
+		return err
+	}
+
+	localized := *rfcerr
+	localized.Description = description
+	return &localized
 }
@@ -0,0 +1,455 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/hydra/firewall"
+	"github.com/ory/hydra/pkg"
+	"github.com/pkg/errors"
+)
+
+const (
+	// LoginRequestPath points to the endpoints the login app uses to fetch, accept and reject login challenges.
+	LoginRequestPath = "/oauth2/auth/requests/login"
+
+	// ConsentChallengePath points to the endpoints the consent app uses to fetch, accept and reject consent
+	// challenges.
+	ConsentChallengePath = "/oauth2/auth/requests/consent"
+
+	// LoginResource is the resource on which the actions "get", "accept" and "reject" must be allowed to handle a
+	// login challenge.
+	LoginResource = "oauth2:login:requests:%s"
+)
+
+// ConsentChallenge is a consent request including the subject the login app authenticated.
+//
+// swagger:model consentChallenge
+type ConsentChallenge struct {
+	*ConsentRequest
+
+	// Subject is the subject the login app authenticated. It is empty if the consent request was not preceded by a
+	// login challenge, in which case the consent app must set the subject when accepting the request.
+	Subject string `json:"subject,omitempty"`
+}
+
+// swagger:route GET /oauth2/auth/requests/login/{challenge} oAuth2 getLoginRequest
+//
+// Get a login request
+//
+// When the two-step login and consent flow is enabled by setting LOGIN_URL, the authorization endpoint redirects
+// the user agent to the login app with the query parameter `login_challenge`, before sending it to the consent app.
+// Call this endpoint to fetch the login request of the challenge. If `skip` is true, the subject is still logged in
+// and the login app must accept the request for the returned subject without asking for credentials.
+//
+//
+// The subject making the request needs to be assigned to a policy containing:
+//
+//  ```
+//  {
+//    "resources": ["rn:hydra:oauth2:login:requests:<challenge>"],
+//    "actions": ["get"],
+//    "effect": "allow"
+//  }
+//  ```
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Security:
+//       oauth2: hydra.consent
+//
+//     Responses:
+//       200: loginRequest
+//       401: genericError
+//       403: genericError
+//       404: genericError
+//       500: genericError
+func (h *ConsentSessionHandler) GetLoginRequestHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	if !h.isTokenAllowed(w, r, LoginResource, ps.ByName("challenge"), "get") {
+		return
+	}
+
+	login, err := h.Logins.GetLoginRequest(ps.ByName("challenge"))
+	if err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	h.H.Write(w, r, login)
+}
+
+// swagger:route PUT /oauth2/auth/requests/login/{challenge}/accept oAuth2 acceptLoginRequest
+//
+// Accept a login request
+//
+// Call this endpoint once the login app authenticated the subject. The user agent must be redirected to the returned
+// URL, which continues with the consent challenge for the subject. If the login request was skipped, the subject
+// must be the subject of the login request.
+//
+//
+// The subject making the request needs to be assigned to a policy containing:
+//
+//  ```
+//  {
+//    "resources": ["rn:hydra:oauth2:login:requests:<challenge>"],
+//    "actions": ["accept"],
+//    "effect": "allow"
+//  }
+//  ```
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Security:
+//       oauth2: hydra.consent
+//
+//     Responses:
+//       200: completedRequest
+//       400: genericError
+//       401: genericError
+//       403: genericError
+//       404: genericError
+//       409: genericError
+//       500: genericError
+func (h *ConsentSessionHandler) AcceptLoginRequestHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var challenge = ps.ByName("challenge")
+	if !h.isTokenAllowed(w, r, LoginResource, challenge, "accept") {
+		return
+	}
+
+	var payload AcceptLoginRequestPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		h.H.WriteError(w, r, errors.WithStack(err))
+		return
+	}
+
+	login, err := h.Logins.GetLoginRequest(challenge)
+	if err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	if payload.Subject == "" {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, errors.New("Subject must be set"))
+		return
+	} else if login.Skip && payload.Subject != login.Subject {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, errors.New("Subject must be the subject of the skipped login request"))
+		return
+	} else if login.IsHandled() {
+		h.H.WriteError(w, r, errors.WithStack(pkg.ErrConflict))
+		return
+	}
+
+	if payload.AuthTime.IsZero() {
+		payload.AuthTime = time.Now().UTC()
+	}
+
+	if err := h.Logins.AcceptLoginRequest(challenge, &payload); err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	h.H.Write(w, r, &CompletedRequest{RedirectTo: login.RequestURL + "&login_verifier=" + login.Verifier})
+}
+
+// swagger:route PUT /oauth2/auth/requests/login/{challenge}/reject oAuth2 rejectLoginRequest
+//
+// Reject a login request
+//
+// Call this endpoint if the subject could not be authenticated, for example because the user cancelled the login.
+// The user agent must be redirected to the returned URL, which sends the error to the client.
+//
+//
+// The subject making the request needs to be assigned to a policy containing:
+//
+//  ```
+//  {
+//    "resources": ["rn:hydra:oauth2:login:requests:<challenge>"],
+//    "actions": ["reject"],
+//    "effect": "allow"
+//  }
+//  ```
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Security:
+//       oauth2: hydra.consent
+//
+//     Responses:
+//       200: completedRequest
+//       401: genericError
+//       403: genericError
+//       404: genericError
+//       409: genericError
+//       500: genericError
+func (h *ConsentSessionHandler) RejectLoginRequestHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var challenge = ps.ByName("challenge")
+	if !h.isTokenAllowed(w, r, LoginResource, challenge, "reject") {
+		return
+	}
+
+	var payload RequestDeniedError
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		h.H.WriteError(w, r, errors.WithStack(err))
+		return
+	}
+
+	login, err := h.Logins.GetLoginRequest(challenge)
+	if err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	} else if login.IsHandled() {
+		h.H.WriteError(w, r, errors.WithStack(pkg.ErrConflict))
+		return
+	}
+
+	if err := h.Logins.RejectLoginRequest(challenge, &payload); err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	h.H.Write(w, r, &CompletedRequest{RedirectTo: login.RequestURL + "&login_verifier=" + login.Verifier})
+}
+
+// swagger:route GET /oauth2/auth/requests/consent/{challenge} oAuth2 getConsentChallenge
+//
+// Get a consent challenge
+//
+// The consent app receives the challenge in the query parameter `consent_challenge` once the login app accepted the
+// login request. The response includes the authenticated subject, and which scopes may be granted without asking the
+// subject, see getOAuth2ConsentRequest.
+//
+//
+// The subject making the request needs to be assigned to a policy containing:
+//
+//  ```
+//  {
+//    "resources": ["rn:hydra:oauth2:consent:requests:<challenge>"],
+//    "actions": ["get"],
+//    "effect": "allow"
+//  }
+//  ```
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Security:
+//       oauth2: hydra.consent
+//
+//     Responses:
+//       200: consentChallenge
+//       401: genericError
+//       403: genericError
+//       404: genericError
+//       500: genericError
+func (h *ConsentSessionHandler) GetConsentChallengeHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	if !h.isTokenAllowed(w, r, ConsentResource, ps.ByName("challenge"), "get") {
+		return
+	}
+
+	consent, err := h.M.GetConsentRequest(ps.ByName("challenge"))
+	if err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	if h.Clients != nil {
+		if err := h.applyConsentPolicies(r.Context(), consent); err != nil {
+			h.H.WriteError(w, r, err)
+			return
+		}
+	}
+
+	h.H.Write(w, r, &ConsentChallenge{ConsentRequest: consent, Subject: consent.Subject})
+}
+
+// swagger:route PUT /oauth2/auth/requests/consent/{challenge}/accept oAuth2 acceptConsentChallenge
+//
+// Accept a consent challenge
+//
+// Call this endpoint once the subject granted scopes to the client. The user agent must be redirected to the returned
+// URL, which issues the tokens. The subject and its authentication are taken from the login request and can not be
+// changed by the consent app.
+//
+//
+// The subject making the request needs to be assigned to a policy containing:
+//
+//  ```
+//  {
+//    "resources": ["rn:hydra:oauth2:consent:requests:<challenge>"],
+//    "actions": ["accept"],
+//    "effect": "allow"
+//  }
+//  ```
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Security:
+//       oauth2: hydra.consent
+//
+//     Responses:
+//       200: completedRequest
+//       400: genericError
+//       401: genericError
+//       403: genericError
+//       404: genericError
+//       409: genericError
+//       500: genericError
+func (h *ConsentSessionHandler) AcceptConsentChallengeHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var challenge = ps.ByName("challenge")
+	if !h.isTokenAllowed(w, r, ConsentResource, challenge, "accept") {
+		return
+	}
+
+	var payload AcceptConsentRequestPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		h.H.WriteError(w, r, errors.WithStack(err))
+		return
+	}
+
+	consent, err := h.M.GetConsentRequest(challenge)
+	if err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	} else if !consent.IsAbandoned() {
+		h.H.WriteError(w, r, errors.WithStack(pkg.ErrConflict))
+		return
+	}
+
+	if consent.Subject != "" {
+		payload.Subject = consent.Subject
+		payload.ACR = consent.ACR
+		payload.AMR = consent.AMR
+		payload.AuthTime = consent.AuthTime
+	} else if payload.Subject == "" {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, errors.New("Subject must be set"))
+		return
+	}
+
+	if err := h.M.AcceptConsentRequest(challenge, &payload); err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	h.H.Write(w, r, &CompletedRequest{RedirectTo: consent.RedirectURL})
+}
+
+// swagger:route PUT /oauth2/auth/requests/consent/{challenge}/reject oAuth2 rejectConsentChallenge
+//
+// Reject a consent challenge
+//
+// Call this endpoint if the subject denied the client access. The user agent must be redirected to the returned URL,
+// which sends the error to the client.
+//
+//
+// The subject making the request needs to be assigned to a policy containing:
+//
+//  ```
+//  {
+//    "resources": ["rn:hydra:oauth2:consent:requests:<challenge>"],
+//    "actions": ["reject"],
+//    "effect": "allow"
+//  }
+//  ```
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Security:
+//       oauth2: hydra.consent
+//
+//     Responses:
+//       200: completedRequest
+//       401: genericError
+//       403: genericError
+//       404: genericError
+//       409: genericError
+//       500: genericError
+func (h *ConsentSessionHandler) RejectConsentChallengeHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var challenge = ps.ByName("challenge")
+	if !h.isTokenAllowed(w, r, ConsentResource, challenge, "reject") {
+		return
+	}
+
+	var payload RequestDeniedError
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		h.H.WriteError(w, r, errors.WithStack(err))
+		return
+	}
+
+	consent, err := h.M.GetConsentRequest(challenge)
+	if err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	} else if !consent.IsAbandoned() {
+		h.H.WriteError(w, r, errors.WithStack(pkg.ErrConflict))
+		return
+	}
+
+	if err := h.M.RejectConsentRequest(challenge, &RejectConsentRequestPayload{
+		Reason: payload.Description,
+		Error:  &payload,
+	}); err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	h.H.Write(w, r, &CompletedRequest{RedirectTo: consent.RedirectURL})
+}
+
+// isTokenAllowed writes an error and returns false unless the access token of the request is allowed the action on
+// the resource of the challenge.
+func (h *ConsentSessionHandler) isTokenAllowed(w http.ResponseWriter, r *http.Request, resource, challenge, action string) bool {
+	if _, err := h.W.TokenAllowed(r.Context(), h.W.TokenFromRequest(r), &firewall.TokenAccessRequest{
+		Resource: fmt.Sprintf(h.PrefixResource(resource), challenge),
+		Action:   action,
+	}, ConsentScope); err != nil {
+		h.H.WriteError(w, r, err)
+		return false
+	}
+	return true
+}
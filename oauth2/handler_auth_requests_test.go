@@ -0,0 +1,356 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/herodot"
+	hc "github.com/ory/hydra/client"
+	. "github.com/ory/hydra/oauth2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func doChallengeRequest(t *testing.T, method, u string, payload interface{}, result interface{}) int {
+	var body bytes.Buffer
+	if payload != nil {
+		require.NoError(t, json.NewEncoder(&body).Encode(payload))
+	}
+
+	req, err := http.NewRequest(method, u, &body)
+	require.NoError(t, err)
+
+	res, err := httpClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	if result != nil && res.StatusCode == http.StatusOK {
+		require.NoError(t, json.NewDecoder(res.Body).Decode(result))
+	}
+	return res.StatusCode
+}
+
+func TestAuthCodeWithLoginChallenge(t *testing.T) {
+	logins := NewLoginRequestMemoryManager()
+	admin := &ConsentSessionHandler{M: consentManager, W: localWarden, H: herodot.NewJSONWriter(nil), Logins: logins}
+	adminRouter := httprouter.New()
+	admin.SetRoutes(adminRouter)
+	adminServer := httptest.NewServer(adminRouter)
+	defer adminServer.Close()
+
+	loginURL, err := url.Parse(ts.URL + "/login")
+	require.NoError(t, err)
+	handler.LoginURL = *loginURL
+	handler.Logins = logins
+	handler.LoginChallengeLifespan = time.Hour
+	defer func() {
+		handler.LoginURL = url.URL{}
+		handler.Logins = nil
+	}()
+
+	var loginHandler, challengeHandler, callbackHandler httprouter.Handle
+	router.GET("/login", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		loginHandler(w, r, ps)
+	})
+	router.GET("/consent-challenge", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		challengeHandler(w, r, ps)
+	})
+	router.GET("/login-callback", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		callbackHandler(w, r, ps)
+	})
+
+	consentURL := handler.ConsentURL
+	c, err := url.Parse(ts.URL + "/consent-challenge")
+	require.NoError(t, err)
+	handler.ConsentURL = *c
+	defer func() { handler.ConsentURL = consentURL }()
+
+	config := *oauthConfig
+	config.RedirectURL = ts.URL + "/login-callback"
+	store.Manager.(*hc.MemoryManager).Clients[0].RedirectURIs = append(store.Manager.(*hc.MemoryManager).Clients[0].RedirectURIs, config.RedirectURL)
+
+	authorize := func(t *testing.T) *http.Response {
+		cookieJar, _ := cookiejar.New(nil)
+		res, err := (&http.Client{Jar: cookieJar}).Get(config.AuthCodeURL("some-foo-state"))
+		require.NoError(t, err)
+		return res
+	}
+
+	t.Run("case=login and consent are accepted", func(t *testing.T) {
+		var code string
+
+		loginHandler = func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+			challenge := r.URL.Query().Get("login_challenge")
+			require.NotEmpty(t, challenge)
+
+			var login LoginRequest
+			require.Equal(t, http.StatusOK, doChallengeRequest(t, "GET", adminServer.URL+LoginRequestPath+"/"+challenge, nil, &login))
+			assert.Equal(t, challenge, login.Challenge)
+			assert.Equal(t, "app-client", login.ClientID)
+			assert.EqualValues(t, []string{"hydra.*", "offline", "openid"}, login.RequestedScopes)
+			assert.False(t, login.Skip)
+
+			assert.Equal(t, http.StatusBadRequest, doChallengeRequest(t, "PUT", adminServer.URL+LoginRequestPath+"/"+challenge+"/accept", &AcceptLoginRequestPayload{}, nil))
+
+			var completed CompletedRequest
+			require.Equal(t, http.StatusOK, doChallengeRequest(t, "PUT", adminServer.URL+LoginRequestPath+"/"+challenge+"/accept", &AcceptLoginRequestPayload{
+				Subject: "foo",
+				ACR:     "pwd",
+			}, &completed))
+			assert.Contains(t, completed.RedirectTo, "login_verifier=")
+
+			assert.Equal(t, http.StatusConflict, doChallengeRequest(t, "PUT", adminServer.URL+LoginRequestPath+"/"+challenge+"/accept", &AcceptLoginRequestPayload{Subject: "bar"}, nil))
+
+			http.Redirect(w, r, completed.RedirectTo, http.StatusFound)
+		}
+
+		challengeHandler = func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+			challenge := r.URL.Query().Get("consent_challenge")
+			require.NotEmpty(t, challenge)
+
+			var consent ConsentChallenge
+			require.Equal(t, http.StatusOK, doChallengeRequest(t, "GET", adminServer.URL+ConsentChallengePath+"/"+challenge, nil, &consent))
+			assert.Equal(t, "foo", consent.Subject)
+			assert.Equal(t, "app-client", consent.ClientID)
+
+			var completed CompletedRequest
+			require.Equal(t, http.StatusOK, doChallengeRequest(t, "PUT", adminServer.URL+ConsentChallengePath+"/"+challenge+"/accept", &AcceptConsentRequestPayload{
+				Subject:     "not-foo",
+				GrantScopes: []string{"hydra.*", "offline", "openid"},
+			}, &completed))
+
+			stored, err := consentManager.GetConsentRequest(challenge)
+			require.NoError(t, err)
+			assert.Equal(t, "foo", stored.Subject)
+			assert.Equal(t, "pwd", stored.ACR)
+
+			http.Redirect(w, r, completed.RedirectTo, http.StatusFound)
+		}
+
+		callbackHandler = func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+			code = r.URL.Query().Get("code")
+			w.WriteHeader(http.StatusNoContent)
+		}
+
+		res := authorize(t)
+		defer res.Body.Close()
+		require.NotEmpty(t, code)
+
+		token, err := config.Exchange(oauth2.NoContext, code)
+		require.NoError(t, err)
+		assert.NotEmpty(t, token.AccessToken)
+	})
+
+	t.Run("case=clients skipping consent are not sent to the consent app", func(t *testing.T) {
+		store.Manager.(*hc.MemoryManager).Clients[0].SkipConsent = true
+		defer func() { store.Manager.(*hc.MemoryManager).Clients[0].SkipConsent = false }()
+
+		var code string
+		loginHandler = func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+			var completed CompletedRequest
+			require.Equal(t, http.StatusOK, doChallengeRequest(t, "PUT", adminServer.URL+LoginRequestPath+"/"+r.URL.Query().Get("login_challenge")+"/accept", &AcceptLoginRequestPayload{Subject: "foo"}, &completed))
+			http.Redirect(w, r, completed.RedirectTo, http.StatusFound)
+		}
+
+		challengeHandler = func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+			t.Error("The consent app must not be asked for clients which skip consent")
+		}
+
+		callbackHandler = func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+			code = r.URL.Query().Get("code")
+			w.WriteHeader(http.StatusNoContent)
+		}
+
+		res := authorize(t)
+		defer res.Body.Close()
+		require.NotEmpty(t, code)
+
+		token, err := config.Exchange(oauth2.NoContext, code)
+		require.NoError(t, err)
+		assert.NotEmpty(t, token.AccessToken)
+		assert.Equal(t, "hydra.* offline openid", token.Extra("scope"))
+	})
+
+	t.Run("case=login is rejected", func(t *testing.T) {
+		loginHandler = func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+			challenge := r.URL.Query().Get("login_challenge")
+
+			var completed CompletedRequest
+			require.Equal(t, http.StatusOK, doChallengeRequest(t, "PUT", adminServer.URL+LoginRequestPath+"/"+challenge+"/reject", &RequestDeniedError{
+				Name:        "login_required",
+				Description: "The user cancelled the login",
+				StatusCode:  http.StatusUnauthorized,
+			}, &completed))
+
+			http.Redirect(w, r, completed.RedirectTo, http.StatusFound)
+		}
+
+		challengeHandler = func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+			t.Error("The consent app must not be asked if the login was rejected")
+		}
+
+		callbackHandler = func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+			assert.Equal(t, "login_required", r.URL.Query().Get("error"))
+			assert.Equal(t, "The user cancelled the login", r.URL.Query().Get("error_description"))
+			w.WriteHeader(http.StatusNoContent)
+		}
+
+		res := authorize(t)
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+	})
+
+	t.Run("case=consent is rejected", func(t *testing.T) {
+		loginHandler = func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+			var completed CompletedRequest
+			require.Equal(t, http.StatusOK, doChallengeRequest(t, "PUT", adminServer.URL+LoginRequestPath+"/"+r.URL.Query().Get("login_challenge")+"/accept", &AcceptLoginRequestPayload{Subject: "foo"}, &completed))
+			http.Redirect(w, r, completed.RedirectTo, http.StatusFound)
+		}
+
+		challengeHandler = func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+			var completed CompletedRequest
+			require.Equal(t, http.StatusOK, doChallengeRequest(t, "PUT", adminServer.URL+ConsentChallengePath+"/"+r.URL.Query().Get("consent_challenge")+"/reject", &RequestDeniedError{
+				Description: "The user denied access",
+			}, &completed))
+			http.Redirect(w, r, completed.RedirectTo, http.StatusFound)
+		}
+
+		callbackHandler = func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+			assert.Equal(t, "access_denied", r.URL.Query().Get("error"))
+			assert.Equal(t, "The user denied access", r.URL.Query().Get("error_description"))
+			w.WriteHeader(http.StatusNoContent)
+		}
+
+		res := authorize(t)
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+	})
+
+	t.Run("case=login verifier can not be replayed", func(t *testing.T) {
+		var redirectTo string
+		loginHandler = func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+			var completed CompletedRequest
+			require.Equal(t, http.StatusOK, doChallengeRequest(t, "PUT", adminServer.URL+LoginRequestPath+"/"+r.URL.Query().Get("login_challenge")+"/accept", &AcceptLoginRequestPayload{Subject: "foo"}, &completed))
+			redirectTo = completed.RedirectTo
+			w.WriteHeader(http.StatusNoContent)
+		}
+
+		res := authorize(t)
+		res.Body.Close()
+		require.NotEmpty(t, redirectTo)
+
+		// A user agent without the login CSRF cookie must not redeem the verifier.
+		res, err := (&http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}}).Get(redirectTo)
+		require.NoError(t, err)
+		res.Body.Close()
+
+		location, err := url.Parse(res.Header.Get("Location"))
+		require.NoError(t, err)
+		assert.Equal(t, "request_forbidden", location.Query().Get("error"))
+
+		// The verifier was used up by the failed attempt.
+		u, err := url.Parse(redirectTo)
+		require.NoError(t, err)
+		_, err = logins.GetLoginRequestByVerifier(u.Query().Get("login_verifier"))
+		assert.Error(t, err)
+	})
+
+	t.Run("case=pushed requests return from the login and consent app", func(t *testing.T) {
+		handler.PushedAuthorizationRequests = NewPushedAuthorizationRequestMemoryManager()
+		handler.ClientSecretHasher = hasher
+		handler.Storage = store
+		store.Manager.(*hc.MemoryManager).Clients[0].RequirePushedAuthorizationRequests = true
+		defer func() {
+			handler.PushedAuthorizationRequests = nil
+			handler.ClientSecretHasher = nil
+			handler.Storage = nil
+			store.Manager.(*hc.MemoryManager).Clients[0].RequirePushedAuthorizationRequests = false
+		}()
+		router.POST(PushedAuthorizationRequestPath, handler.PushedAuthorizationRequestHandler)
+
+		loginHandler = func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+			var completed CompletedRequest
+			require.Equal(t, http.StatusOK, doChallengeRequest(t, "PUT", adminServer.URL+LoginRequestPath+"/"+r.URL.Query().Get("login_challenge")+"/accept", &AcceptLoginRequestPayload{Subject: "foo"}, &completed))
+			http.Redirect(w, r, completed.RedirectTo, http.StatusFound)
+		}
+
+		challengeHandler = func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+			var completed CompletedRequest
+			require.Equal(t, http.StatusOK, doChallengeRequest(t, "PUT", adminServer.URL+ConsentChallengePath+"/"+r.URL.Query().Get("consent_challenge")+"/accept", &AcceptConsentRequestPayload{
+				GrantScopes: []string{"hydra.*", "offline", "openid"},
+			}, &completed))
+			http.Redirect(w, r, completed.RedirectTo, http.StatusFound)
+		}
+
+		var code, callbackError string
+		callbackHandler = func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+			code = r.URL.Query().Get("code")
+			callbackError = r.URL.Query().Get("error")
+			w.WriteHeader(http.StatusNoContent)
+		}
+
+		// Requests which were not pushed are rejected.
+		res := authorize(t)
+		res.Body.Close()
+		assert.Empty(t, code)
+		assert.Equal(t, "invalid_request", callbackError)
+
+		form := url.Values{
+			"response_type": {"code"},
+			"scope":         {"hydra.* offline openid"},
+			"state":         {"some-foo-state"},
+			"redirect_uri":  {config.RedirectURL},
+		}
+		req, err := http.NewRequest("POST", ts.URL+PushedAuthorizationRequestPath, strings.NewReader(form.Encode()))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetBasicAuth(config.ClientID, config.ClientSecret)
+
+		res, err = http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		require.Equal(t, http.StatusCreated, res.StatusCode)
+
+		var pushed PushedAuthorizationResponse
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&pushed))
+
+		cookieJar, _ := cookiejar.New(nil)
+		res, err = (&http.Client{Jar: cookieJar}).Get(ts.URL + AuthPath + "?" + url.Values{
+			"client_id":   {config.ClientID},
+			"request_uri": {pushed.RequestURI},
+		}.Encode())
+		require.NoError(t, err)
+		defer res.Body.Close()
+		assert.Empty(t, callbackError)
+		require.NotEmpty(t, code)
+
+		token, err := config.Exchange(oauth2.NoContext, code)
+		require.NoError(t, err)
+		assert.NotEmpty(t, token.AccessToken)
+	})
+}
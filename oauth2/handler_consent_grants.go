@@ -0,0 +1,158 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/hydra/firewall"
+	"github.com/pkg/errors"
+)
+
+const (
+	// ConsentGrantsPath points to the endpoint listing and revoking the consent a subject has given to clients.
+	ConsentGrantsPath = "/oauth2/auth/sessions/consent"
+
+	// ConsentGrantsResource is the resource on which the action "get" must be allowed to list the consent grants of a
+	// subject, and the action "delete" to revoke them.
+	ConsentGrantsResource = "oauth2:consent:grants:%s"
+)
+
+// swagger:route GET /oauth2/auth/sessions/consent oAuth2 listOAuth2ConsentGrants
+//
+// List the clients a subject has granted consent to
+//
+// This endpoint returns all clients the subject identified by the subject query parameter has granted scopes to,
+// with the scopes granted over all accepted consent requests and when they were first and last granted. Use it to
+// render a page of connected applications.
+//
+//
+// The subject making the request needs to be assigned to a policy containing:
+//
+//  ```
+//  {
+//    "resources": ["rn:hydra:oauth2:consent:grants:<subject>"],
+//    "actions": ["get"],
+//    "effect": "allow"
+//  }
+//  ```
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Security:
+//       oauth2: hydra.consent
+//
+//     Responses:
+//       200: oAuth2ConsentGrants
+//       400: genericError
+//       401: genericError
+//       403: genericError
+//       500: genericError
+func (h *Handler) ListConsentGrantsHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	subject := r.URL.Query().Get("subject")
+	if subject == "" {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, errors.New("Query parameter subject must be set"))
+		return
+	}
+
+	if _, err := h.W.TokenAllowed(r.Context(), h.W.TokenFromRequest(r), &firewall.TokenAccessRequest{
+		Resource: fmt.Sprintf(h.PrefixResource(ConsentGrantsResource), subject),
+		Action:   "get",
+	}, ConsentScope); err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	grants, err := h.ConsentGrants.GetConsentGrants(subject)
+	if err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	h.H.Write(w, r, grants)
+}
+
+// swagger:route DELETE /oauth2/auth/sessions/consent oAuth2 revokeOAuth2ConsentGrant
+//
+// Revoke the consent a subject has granted to a client
+//
+// This endpoint revokes the consent the subject identified by the subject query parameter has granted to the client
+// identified by the client_id query parameter, forgets the consent if it was remembered, and revokes all access and
+// refresh tokens issued to the client on behalf of the subject. The client has to ask for consent again to be issued
+// new tokens.
+//
+//
+// The subject making the request needs to be assigned to a policy containing:
+//
+//  ```
+//  {
+//    "resources": ["rn:hydra:oauth2:consent:grants:<subject>"],
+//    "actions": ["delete"],
+//    "effect": "allow"
+//  }
+//  ```
+//
+//     Schemes: http, https
+//
+//     Security:
+//       oauth2: hydra.consent
+//
+//     Responses:
+//       204: emptyResponse
+//       400: genericError
+//       401: genericError
+//       403: genericError
+//       404: genericError
+//       500: genericError
+func (h *Handler) RevokeConsentGrantHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	subject := r.URL.Query().Get("subject")
+	clientID := r.URL.Query().Get("client_id")
+	if subject == "" || clientID == "" {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, errors.New("Query parameters subject and client_id must be set"))
+		return
+	}
+
+	if _, err := h.W.TokenAllowed(r.Context(), h.W.TokenFromRequest(r), &firewall.TokenAccessRequest{
+		Resource: fmt.Sprintf(h.PrefixResource(ConsentGrantsResource), subject),
+		Action:   "delete",
+	}, ConsentScope); err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	if err := h.ConsentGrants.DeleteConsentGrant(subject, clientID); err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	if h.RememberedConsents != nil {
+		if err := h.RememberedConsents.ForgetConsent(subject, clientID); err != nil {
+			h.H.WriteError(w, r, err)
+			return
+		}
+	}
+
+	if _, err := h.Storage.RevokeSubjectClientTokens(r.Context(), subject, clientID); err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,105 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/hydra/firewall"
+	"github.com/pkg/errors"
+)
+
+const (
+	// ConsentStatisticsPath points to the endpoint returning how often scopes were requested, granted and denied.
+	ConsentStatisticsPath = "/oauth2/consent/statistics"
+
+	// ConsentStatisticsResource is the resource on which the action "get" must be allowed to read consent statistics.
+	ConsentStatisticsResource = "oauth2:consent:statistics"
+
+	// DefaultConsentStatisticsWindow is the time window consent statistics are returned for if since is not set.
+	DefaultConsentStatisticsWindow = time.Hour * 24 * 30
+)
+
+// swagger:route GET /oauth2/consent/statistics oAuth2 getOAuth2ConsentStatistics
+//
+// Get statistics of requested, granted and denied scopes
+//
+// This endpoint returns how often each client requested a scope in consent requests, and how often users granted
+// or denied it, within a time window. Clients whose scopes are often denied may request more scopes than users are
+// comfortable with. The endpoint is only available if CONSENT_STATISTICS is enabled. Statistics are kept per hour,
+// so the window starts at the full hour before since and ends at the end of the hour containing until. The window
+// defaults to the last 30 days.
+//
+//
+//  ```
+//  {
+//    "resources": ["rn:hydra:oauth2:consent:statistics"],
+//    "actions": ["get"],
+//    "effect": "allow"
+//  }
+//  ```
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Security:
+//       oauth2: hydra.consent
+//
+//     Responses:
+//       200: oAuth2ConsentStatistics
+//       400: genericError
+//       401: genericError
+//       403: genericError
+//       500: genericError
+func (h *ConsentSessionHandler) ConsentStatisticsHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	if _, err := h.W.TokenAllowed(r.Context(), h.W.TokenFromRequest(r), &firewall.TokenAccessRequest{
+		Resource: h.PrefixResource(ConsentStatisticsResource),
+		Action:   "get",
+	}, ConsentScope); err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	query := r.URL.Query()
+	until := time.Now().UTC()
+	if raw := query.Get("until"); raw != "" {
+		var err error
+		if until, err = time.Parse(time.RFC3339, raw); err != nil {
+			h.H.WriteErrorCode(w, r, http.StatusBadRequest, errors.Errorf("Query parameter until must be a RFC3339 timestamp: %s", err))
+			return
+		}
+	}
+
+	since := until.Add(-DefaultConsentStatisticsWindow)
+	if raw := query.Get("since"); raw != "" {
+		var err error
+		if since, err = time.Parse(time.RFC3339, raw); err != nil {
+			h.H.WriteErrorCode(w, r, http.StatusBadRequest, errors.Errorf("Query parameter since must be a RFC3339 timestamp: %s", err))
+			return
+		}
+	}
+
+	stats, err := h.Statistics.GetScopeStatistics(query.Get("client_id"), since.UTC().Truncate(ConsentStatisticsBucket), until.UTC())
+	if err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	h.H.Write(w, r, stats)
+}
@@ -0,0 +1,282 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/sessions"
+	"github.com/ory/fosite"
+	"github.com/ory/hydra/client"
+	"github.com/ory/hydra/pkg"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// CookieLoginCSRFKey holds the CSRF token of the login request the user agent was redirected to the login app with.
+const CookieLoginCSRFKey = "login_csrf"
+
+// skipsConsent returns true if the client of the request is trusted to skip consent, see client.Client.SkipConsent.
+func skipsConsent(ar fosite.AuthorizeRequester) bool {
+	c, ok := ar.GetClient().(*client.Client)
+	return ok && c.SkipConsent
+}
+
+// usesLoginChallenges returns true if the two-step login and consent flow is enabled, see LoginRequest.
+func (h *Handler) usesLoginChallenges() bool {
+	return h.Logins != nil && h.LoginURL.String() != ""
+}
+
+// loginVerifier returns the login verifier the login app sent the user agent back with, if the two-step login and
+// consent flow is enabled.
+func (h *Handler) loginVerifier(ar fosite.AuthorizeRequester) string {
+	if !h.usesLoginChallenges() {
+		return ""
+	}
+	return ar.GetRequestForm().Get("login_verifier")
+}
+
+// redirectToLoginOrConsent redirects to the consent app, or to the login app first if the two-step login and consent
+// flow is enabled.
+func (h *Handler) redirectToLoginOrConsent(w http.ResponseWriter, r *http.Request, ar fosite.AuthorizeRequester, loginVerifier string) error {
+	if !h.usesLoginChallenges() {
+		return h.redirectToConsent(w, r, ar)
+	} else if loginVerifier != "" {
+		return h.redirectToLoggedInConsent(w, r, ar, loginVerifier)
+	}
+	return h.redirectToLogin(w, r, ar)
+}
+
+// redirectToLogin issues a login challenge and redirects to the login app.
+func (h *Handler) redirectToLogin(w http.ResponseWriter, r *http.Request, ar fosite.AuthorizeRequester) error {
+	// Error can be ignored because a session will always be returned
+	cookie, _ := h.CookieStore.Get(r, consentCookieName)
+
+	authURL, err := h.authorizationURL(r)
+	if err != nil {
+		return err
+	}
+
+	subject, err := loginSessionSubject(h.LoginSessions, ar, cookie)
+	if err != nil {
+		return errors.Wrapf(fosite.ErrServerError, "Could not look up login session: %s", err)
+	}
+
+	lifespan := h.LoginChallengeLifespan
+	if c, ok := ar.GetClient().(*client.Client); ok {
+		lifespan = c.GetConsentChallengeLifespan(lifespan)
+	}
+
+	now := time.Now().UTC()
+	login := &LoginRequest{
+		Challenge:       uuid.New(),
+		Verifier:        uuid.New(),
+		CSRF:            uuid.New(),
+		ClientID:        ar.GetClient().GetID(),
+		RequestedScopes: ar.GetRequestedScopes(),
+		ACRValues:       strings.Fields(ar.GetRequestForm().Get("acr_values")),
+		RequestURL:      authURL.String(),
+		Skip:            subject != "",
+		Subject:         subject,
+		ExpiresAt:       now.Add(lifespan),
+		RequestedAt:     now,
+	}
+	if err := h.Logins.CreateLoginRequest(login); err != nil {
+		return err
+	}
+
+	cookie.Values[CookieLoginCSRFKey] = login.CSRF
+	if err := cookie.Save(r, w); err != nil {
+		return err
+	}
+
+	p := h.LoginURL
+	q := p.Query()
+	q.Set("login_challenge", login.Challenge)
+	h.setEmbedOrigin(q, ar)
+	p.RawQuery = q.Encode()
+
+	http.Redirect(w, r, p.String(), http.StatusFound)
+	return nil
+}
+
+// verifyLogin verifies the login request the login app sent the user agent back with and returns it if the login app
+// accepted it. The login verifier can only be used once.
+func (h *Handler) verifyLogin(ar fosite.AuthorizeRequester, verifier string, cookie *sessions.Session) (*LoginRequest, error) {
+	login, err := h.Logins.GetLoginRequestByVerifier(verifier)
+	if errors.Cause(err) == pkg.ErrNotFound {
+		return nil, errors.Wrap(fosite.ErrInvalidRequest, "The login verifier is invalid or was already used")
+	} else if err != nil {
+		return nil, errors.Wrapf(fosite.ErrServerError, "Could not look up login request: %s", err)
+	}
+
+	// Login verifiers can only be used once.
+	if err := h.Logins.DeleteLoginRequest(login.Challenge); err != nil {
+		return nil, errors.Wrapf(fosite.ErrServerError, "Could not remove login request: %s", err)
+	}
+
+	if csrf, _ := cookie.Values[CookieLoginCSRFKey].(string); csrf == "" || csrf != login.CSRF {
+		return nil, errors.Wrap(fosite.ErrRequestForbidden, "The login CSRF token of the session cookie does not match the login request")
+	}
+	delete(cookie.Values, CookieLoginCSRFKey)
+
+	if time.Now().UTC().After(login.ExpiresAt) {
+		return nil, errors.Wrap(fosite.ErrInvalidRequest, "The login request expired")
+	} else if login.ClientID != ar.GetClient().GetID() {
+		return nil, errors.Wrap(fosite.ErrInvalidRequest, "The login request was issued for another client")
+	}
+
+	switch login.Login {
+	case LoginRequestAccepted:
+		return login, nil
+	case LoginRequestRejected:
+		if login.DenyError == nil {
+			return nil, (&RequestDeniedError{}).toRFC6749Error()
+		}
+		return nil, login.DenyError.toRFC6749Error()
+	default:
+		return nil, errors.Wrap(fosite.ErrInvalidRequest, "The login request was neither accepted nor rejected")
+	}
+}
+
+// returnsFromLogin returns true if the user agent returns from the login app with the login verifier of a login
+// request issued for exactly this authorization request. The login request is not consumed, see verifyLogin.
+func (h *Handler) returnsFromLogin(r *http.Request, ar fosite.AuthorizeRequester) bool {
+	verifier := h.loginVerifier(ar)
+	if verifier == "" {
+		return false
+	}
+
+	login, err := h.Logins.GetLoginRequestByVerifier(verifier)
+	if err != nil || login.ClientID != ar.GetClient().GetID() {
+		return false
+	}
+
+	requestURL, err := url.Parse(login.RequestURL)
+	if err != nil {
+		return false
+	}
+
+	query := r.URL.Query()
+	query.Del("login_verifier")
+	return query.Encode() == requestURL.Query().Encode()
+}
+
+// loginConsentStrategy returns the consent strategy if it supports login challenges.
+func (h *Handler) loginConsentStrategy() (LoginConsentStrategy, error) {
+	strategy, ok := h.Consent.(LoginConsentStrategy)
+	if !ok {
+		return nil, errors.Wrap(fosite.ErrServerError, "The consent strategy does not support login challenges")
+	}
+	return strategy, nil
+}
+
+// skippedLoggedInConsent verifies the login request the login app sent the user agent back with and, because the
+// client skips consent, accepts consent on behalf of the authenticated subject instead of asking the consent app.
+func (h *Handler) skippedLoggedInConsent(ar fosite.AuthorizeRequester, verifier string, cookie *sessions.Session) (*Session, error) {
+	login, err := h.verifyLogin(ar, verifier, cookie)
+	if err != nil {
+		return nil, err
+	}
+
+	strategy, err := h.loginConsentStrategy()
+	if err != nil {
+		return nil, err
+	}
+	return strategy.SkippedLoggedInConsent(ar, login, cookie)
+}
+
+// redirectToLoggedInConsent verifies the login request the login app sent the user agent back with and redirects to
+// the consent app with a consent challenge for the authenticated subject.
+func (h *Handler) redirectToLoggedInConsent(w http.ResponseWriter, r *http.Request, ar fosite.AuthorizeRequester, verifier string) error {
+	// Error can be ignored because a session will always be returned
+	cookie, _ := h.CookieStore.Get(r, consentCookieName)
+
+	login, err := h.verifyLogin(ar, verifier, cookie)
+	if err != nil {
+		return err
+	}
+
+	strategy, err := h.loginConsentStrategy()
+	if err != nil {
+		return err
+	}
+
+	authURL, err := h.authorizationURL(r)
+	if err != nil {
+		return err
+	}
+	query := authURL.Query()
+	query.Del("login_verifier")
+	authURL.RawQuery = query.Encode()
+
+	challenge, err := strategy.CreateLoggedInConsentRequest(ar, authURL.String(), login, cookie)
+	if err != nil {
+		return err
+	}
+
+	if err := cookie.Save(r, w); err != nil {
+		return err
+	}
+
+	p := h.ConsentURL
+	q := p.Query()
+	q.Set("consent_challenge", challenge)
+	h.setEmbedOrigin(q, ar)
+	p.RawQuery = q.Encode()
+
+	http.Redirect(w, r, p.String(), http.StatusFound)
+	return nil
+}
+
+// setEmbedOrigin tells the login or consent app to allow the embedding page to frame its screens.
+func (h *Handler) setEmbedOrigin(q url.Values, ar fosite.AuthorizeRequester) {
+	if !isEmbedded(ar) {
+		return
+	}
+	if embedded, err := h.embedOrigin(ar); err == nil {
+		q.Set("embed_origin", embedded)
+	}
+}
+
+// authorizationURL returns the URL of the authorization request, which the login and consent app redirect back to.
+func (h *Handler) authorizationURL(r *http.Request) (*url.URL, error) {
+	host, _, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		host = r.Host
+	}
+
+	authURL, err := url.Parse(h.issuer(r) + AuthPath)
+	if err != nil {
+		return nil, err
+	}
+	authHost, _, err := net.SplitHostPort(authURL.Host)
+	if err != nil {
+		authHost = authURL.Host
+	}
+	if authHost != host {
+		h.L.WithFields(logrus.Fields{
+			"request_host": host,
+			"issuer_host":  authHost,
+		}).Warnln("Host from auth request does not match issuer host. The consent return redirect may fail.")
+	}
+	authURL.RawQuery = r.URL.RawQuery
+	return authURL, nil
+}
@@ -0,0 +1,172 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"html/template"
+	"net/http"
+	"net/url"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/fosite"
+	"github.com/ory/hydra/client"
+	"github.com/ory/hydra/pkg"
+	"github.com/pkg/errors"
+)
+
+// LogoutPath points to the OpenID Connect end session endpoint.
+const LogoutPath = "/oauth2/sessions/logout"
+
+var logoutPage = template.Must(template.New("logout").Parse(`<!DOCTYPE html>
+<html>
+<head>
+	<title>Logged out</title>
+{{- if .RedirectURL }}
+	<script>window.onload = function () { window.location.replace({{ .RedirectURL }}); };</script>
+{{- end }}
+</head>
+<body>
+<p>You have been logged out.</p>
+{{- range .FrontChannelLogoutURIs }}
+<iframe src="{{ . }}" style="display: none"></iframe>
+{{- end }}
+</body>
+</html>
+`))
+
+func newLogoutError(hint string) *fosite.RFC6749Error {
+	return &fosite.RFC6749Error{
+		Name:        "invalid_logout_request",
+		Description: "The logout request is invalid",
+		Debug:       hint,
+		Hint:        hint,
+		Code:        http.StatusBadRequest,
+	}
+}
+
+// swagger:route GET /oauth2/sessions/logout oAuth2 logoutOAuth2Session
+//
+// OpenID Connect end session endpoint
+//
+// This endpoint is opened by the user agent to log out, as described in OpenID Connect RP-Initiated Logout. It ends
+// the login session of the user agent, or the session of the ID token sent in `id_token_hint`, so that the consent
+// app is asked again on the next authorization request. Clients which took part in the session are notified: logout
+// tokens are POSTed to their `backchannel_logout_uri`, and their `frontchannel_logout_uri` is loaded in an iframe of
+// the logout page.
+//
+// The user agent is redirected to `post_logout_redirect_uri` afterwards if it is one of the
+// `post_logout_redirect_uris` of the client the ID token hint was issued to.
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: emptyResponse
+//       302: emptyResponse
+func (h *Handler) LogoutHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	if err := r.ParseForm(); err != nil {
+		h.writeBrowserError(w, r, newLogoutError("The request could not be parsed"))
+		return
+	}
+
+	// Error can be ignored because a session will always be returned
+	cookie, _ := h.CookieStore.Get(r, consentCookieName)
+	sid, _ := cookie.Values[CookieLoginSessionKey].(string)
+
+	var hint *IDTokenHint
+	if token := r.Form.Get("id_token_hint"); token != "" {
+		var err error
+		if hint, err = h.Logout.VerifyIDTokenHint(token); err != nil {
+			pkg.LogError(err, h.L)
+			h.writeBrowserError(w, r, newLogoutError("The ID token hint is invalid"))
+			return
+		} else if hint.SessionID != "" {
+			sid = hint.SessionID
+		}
+	}
+
+	var redirectURL string
+	if uri := r.Form.Get("post_logout_redirect_uri"); uri != "" {
+		if hint == nil {
+			h.writeBrowserError(w, r, newLogoutError("The post logout redirect URI requires an ID token hint"))
+			return
+		}
+
+		c, err := h.Storage.GetClient(r.Context(), hint.ClientID)
+		if err != nil {
+			pkg.LogError(err, h.L)
+			h.writeBrowserError(w, r, newLogoutError("The client of the ID token hint is unknown"))
+			return
+		} else if hc, ok := c.(*client.Client); !ok || !stringInSlice(uri, hc.PostLogoutRedirectURIs) {
+			h.writeBrowserError(w, r, newLogoutError("The post logout redirect URI is not registered for the client"))
+			return
+		}
+
+		u, err := url.Parse(uri)
+		if err != nil {
+			h.writeBrowserError(w, r, newLogoutError("The post logout redirect URI is invalid"))
+			return
+		}
+		if state := r.Form.Get("state"); state != "" {
+			q := u.Query()
+			q.Set("state", state)
+			u.RawQuery = q.Encode()
+		}
+		redirectURL = u.String()
+	}
+
+	frontChannel, err := h.Logout.EndSession(r.Context(), h.issuer(r), sid)
+	if err != nil {
+		pkg.LogError(err, h.L)
+		h.writeBrowserError(w, r, errors.Wrap(fosite.ErrServerError, err.Error()))
+		return
+	}
+
+	// The consent app must be asked again, even if the subject remembered its consent.
+	delete(cookie.Values, CookieLoginSessionKey)
+	delete(cookie.Values, CookieRememberedSubjectKey)
+	if err := cookie.Save(r, w); err != nil {
+		pkg.LogError(err, h.L)
+		h.writeBrowserError(w, r, errors.Wrapf(fosite.ErrServerError, "Could not store session cookie: %s", err))
+		return
+	}
+
+	// The check session iframe reports the session as changed to relying parties.
+	clearBrowserState(w)
+
+	if len(frontChannel) == 0 && redirectURL != "" {
+		http.Redirect(w, r, redirectURL, http.StatusFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := logoutPage.Execute(w, struct {
+		RedirectURL            string
+		FrontChannelLogoutURIs []string
+	}{
+		RedirectURL:            redirectURL,
+		FrontChannelLogoutURIs: frontChannel,
+	}); err != nil {
+		pkg.LogError(errors.WithStack(err), h.L)
+	}
+}
+
+func stringInSlice(needle string, haystack []string) bool {
+	for _, s := range haystack {
+		if s == needle {
+			return true
+		}
+	}
+	return false
+}
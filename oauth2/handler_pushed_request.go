@@ -0,0 +1,238 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/fosite"
+	"github.com/ory/hydra/client"
+	"github.com/ory/hydra/pkg"
+	"github.com/ory/hydra/rand/sequence"
+	"github.com/pkg/errors"
+)
+
+const (
+	// PushedAuthorizationRequestPath points to the pushed authorization request endpoint.
+	PushedAuthorizationRequestPath = "/oauth2/par"
+
+	// RequestURIPrefix is the prefix of the request URIs referring to pushed authorization requests.
+	RequestURIPrefix = "urn:ietf:params:oauth:request_uri:"
+)
+
+// PushedAuthorizationResponse is returned by the pushed authorization request endpoint.
+//
+// swagger:model pushedAuthorizationResponse
+type PushedAuthorizationResponse struct {
+	// RequestURI refers to the pushed authorization request in the request_uri parameter of the authorization
+	// request.
+	RequestURI string `json:"request_uri"`
+
+	// ExpiresIn is the lifetime of the request URI in seconds.
+	ExpiresIn int `json:"expires_in"`
+}
+
+func newInvalidRequestURIError(hint string) *fosite.RFC6749Error {
+	return &fosite.RFC6749Error{
+		Name:        "invalid_request_uri",
+		Description: "The request_uri in the authorization request is invalid or expired",
+		Debug:       hint,
+		Hint:        hint,
+		Code:        http.StatusBadRequest,
+	}
+}
+
+// swagger:route POST /oauth2/par oAuth2 pushOAuth2AuthorizationRequest
+//
+// Push an OAuth 2.0 authorization request
+//
+// Clients push the parameters of an authorization request to this endpoint, authenticating the same way as at the
+// token endpoint, and receive a `request_uri`. The authorization request then only carries the `client_id` and the
+// `request_uri`, which can be used once and until it expires. The parameters are validated when they are pushed.
+//
+// Clients with `require_pushed_authorization_requests` set must push all their authorization requests.
+//
+//     Consumes:
+//     - application/x-www-form-urlencoded
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Security:
+//       basic:
+//
+//     Responses:
+//       201: pushedAuthorizationResponse
+//       400: genericError
+//       401: genericError
+//       500: genericError
+func (h *Handler) PushedAuthorizationRequestHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var session = NewSession("")
+	var ctx = r.Context()
+
+	if err := r.ParseForm(); err != nil {
+		h.OAuth2.WriteAccessError(w, fosite.NewAccessRequest(session), errors.Wrap(fosite.ErrInvalidRequest, err.Error()))
+		return
+	}
+
+	clientID := tokenRequestClientID(r)
+	if h.ClientAuthGuard != nil && clientID != "" {
+		if locked := h.ClientAuthGuard.Locked(r, clientID); locked > 0 {
+			err := errors.Wrap(fosite.ErrInvalidClient, "Too many failed authentication attempts")
+			pkg.LogError(err, h.L)
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(locked.Seconds()))))
+			h.OAuth2.WriteAccessError(w, fosite.NewAccessRequest(session), err)
+			return
+		}
+	}
+
+	c, err := h.authenticatePushingClient(ctx, r)
+	h.observeClientAuthentication(r, clientID, err)
+	if err != nil {
+		pkg.LogError(err, h.L)
+		h.OAuth2.WriteAccessError(w, fosite.NewAccessRequest(session), err)
+		return
+	}
+
+	form := url.Values{}
+	for k, v := range r.PostForm {
+		switch k {
+		case "client_secret":
+			continue
+		case "request_uri":
+			err := errors.Wrap(fosite.ErrInvalidRequest, "Pushed authorization requests may not contain a request_uri")
+			h.OAuth2.WriteAccessError(w, fosite.NewAccessRequest(session), err)
+			return
+		}
+		form[k] = v
+	}
+	form.Set("client_id", c.GetID())
+
+	// The pushed parameters are validated the same way the authorization endpoint validates them.
+	ar := &http.Request{Method: "GET", URL: &url.URL{Path: AuthPath, RawQuery: form.Encode()}, Header: http.Header{}}
+	if _, err := h.OAuth2.NewAuthorizeRequest(ctx, ar.WithContext(ctx)); err != nil {
+		pkg.LogError(err, h.L)
+		h.OAuth2.WriteAccessError(w, fosite.NewAccessRequest(session), err)
+		return
+	}
+
+	id, err := sequence.RuneSequence(32, sequence.AlphaNum)
+	if err != nil {
+		h.H.WriteError(w, r, errors.WithStack(err))
+		return
+	}
+
+	lifespan := h.PushedAuthorizationRequestLifespan
+	if lifespan <= 0 {
+		lifespan = time.Minute
+	}
+
+	if err := h.PushedAuthorizationRequests.CreatePushedAuthorizationRequest(&PushedAuthorizationRequest{
+		ID:        string(id),
+		ClientID:  c.GetID(),
+		Form:      form,
+		ExpiresAt: time.Now().UTC().Add(lifespan),
+	}); err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	h.H.WriteCode(w, r, http.StatusCreated, &PushedAuthorizationResponse{
+		RequestURI: RequestURIPrefix + string(id),
+		ExpiresIn:  int(lifespan / time.Second),
+	})
+}
+
+// authenticatePushingClient authenticates the client of a pushed authorization request using its secret or its TLS
+// client certificate, like the token endpoint does. Public clients only have to send their id.
+func (h *Handler) authenticatePushingClient(ctx context.Context, r *http.Request) (fosite.Client, error) {
+	ctx, r, err := h.authenticateTLSClient(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	id := tokenRequestClientID(r)
+	if id == "" {
+		return nil, errors.Wrap(fosite.ErrInvalidClient, "The client id is missing")
+	}
+
+	c, err := (&TLSClientAuthStorage{FositeStorer: h.Storage}).GetClient(ctx, id)
+	if err != nil {
+		return nil, errors.Wrap(fosite.ErrInvalidClient, err.Error())
+	} else if c.IsPublic() {
+		return c, nil
+	}
+
+	hasher := &TLSClientAuthHasher{Hasher: h.ClientSecretHasher}
+	if err := hasher.Compare(c.GetHashedSecret(), []byte(tokenRequestClientSecret(r))); err != nil {
+		return nil, errors.Wrap(fosite.ErrInvalidClient, err.Error())
+	}
+	return c, nil
+}
+
+// resolvePushedAuthorizationRequest replaces the parameters of an authorization request referring to a pushed
+// authorization request with the pushed parameters, so that the consent app returns to the expanded request. The
+// pushed request is removed, it can only be used once. pushed is false if the request does not refer to a pushed
+// request.
+func (h *Handler) resolvePushedAuthorizationRequest(r *http.Request) (pushed bool, err error) {
+	query := r.URL.Query()
+	uri := query.Get("request_uri")
+	if h.PushedAuthorizationRequests == nil || !strings.HasPrefix(uri, RequestURIPrefix) {
+		return false, nil
+	}
+
+	id := strings.TrimPrefix(uri, RequestURIPrefix)
+	p, err := h.PushedAuthorizationRequests.GetPushedAuthorizationRequest(id)
+	if errors.Cause(err) == pkg.ErrNotFound {
+		return false, errors.WithStack(newInvalidRequestURIError("The request_uri does not refer to a pushed authorization request"))
+	} else if err != nil {
+		return false, err
+	}
+
+	// Other clients must not be able to use up the request.
+	if p.ClientID != query.Get("client_id") {
+		return false, errors.WithStack(newInvalidRequestURIError("The request_uri was pushed by another client"))
+	}
+
+	if err := h.PushedAuthorizationRequests.DeletePushedAuthorizationRequest(id); err != nil {
+		return false, err
+	}
+
+	if p.ExpiresAt.Before(time.Now().UTC()) {
+		return false, errors.WithStack(newInvalidRequestURIError("The request_uri expired"))
+	}
+
+	r.URL.RawQuery = p.Form.Encode()
+	r.Form = p.Form
+	r.PostForm = url.Values{}
+	return true, nil
+}
+
+// requiresPushedAuthorizationRequests returns true if the client must push its authorization requests.
+func requiresPushedAuthorizationRequests(c fosite.Client) bool {
+	hc, ok := c.(*client.Client)
+	return ok && hc.RequirePushedAuthorizationRequests
+}
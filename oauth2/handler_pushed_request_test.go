@@ -0,0 +1,195 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ory/fosite"
+	"github.com/ory/fosite/compose"
+	"github.com/ory/herodot"
+	"github.com/ory/hydra/client"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolvePushedAuthorizationRequest(t *testing.T) {
+	h := &Handler{PushedAuthorizationRequests: NewPushedAuthorizationRequestMemoryManager()}
+	form := url.Values{"client_id": {"photos"}, "response_type": {"code"}, "scope": {"openid"}, "state": {"some-state"}}
+
+	push := func(id string, expiresAt time.Time) {
+		require.NoError(t, h.PushedAuthorizationRequests.CreatePushedAuthorizationRequest(&PushedAuthorizationRequest{
+			ID:        id,
+			ClientID:  "photos",
+			Form:      form,
+			ExpiresAt: expiresAt,
+		}))
+	}
+
+	newRequest := func(query string) *http.Request {
+		r, err := http.NewRequest("GET", "https://hydra.localhost"+AuthPath+"?"+query, nil)
+		require.NoError(t, err)
+		return r
+	}
+
+	t.Run("case=requests without request uri are unchanged", func(t *testing.T) {
+		r := newRequest("client_id=photos&state=foo")
+		pushed, err := h.resolvePushedAuthorizationRequest(r)
+		require.NoError(t, err)
+		assert.False(t, pushed)
+		assert.Equal(t, "client_id=photos&state=foo", r.URL.RawQuery)
+	})
+
+	t.Run("case=expands pushed requests once", func(t *testing.T) {
+		push("valid", time.Now().UTC().Add(time.Minute))
+
+		r := newRequest("client_id=photos&request_uri=" + url.QueryEscape(RequestURIPrefix+"valid"))
+		pushed, err := h.resolvePushedAuthorizationRequest(r)
+		require.NoError(t, err)
+		assert.True(t, pushed)
+		assert.Equal(t, form, r.URL.Query())
+		assert.Equal(t, "some-state", r.FormValue("state"))
+
+		r = newRequest("client_id=photos&request_uri=" + url.QueryEscape(RequestURIPrefix+"valid"))
+		_, err = h.resolvePushedAuthorizationRequest(r)
+		assert.Error(t, err)
+	})
+
+	t.Run("case=rejects requests of other clients", func(t *testing.T) {
+		push("other", time.Now().UTC().Add(time.Minute))
+
+		_, err := h.resolvePushedAuthorizationRequest(newRequest("client_id=contacts&request_uri=" + url.QueryEscape(RequestURIPrefix+"other")))
+		assert.Error(t, err)
+
+		pushed, err := h.resolvePushedAuthorizationRequest(newRequest("client_id=photos&request_uri=" + url.QueryEscape(RequestURIPrefix+"other")))
+		require.NoError(t, err)
+		assert.True(t, pushed)
+	})
+
+	t.Run("case=rejects expired requests", func(t *testing.T) {
+		push("expired", time.Now().UTC().Add(-time.Second))
+
+		_, err := h.resolvePushedAuthorizationRequest(newRequest("client_id=photos&request_uri=" + url.QueryEscape(RequestURIPrefix+"expired")))
+		assert.Error(t, err)
+	})
+}
+
+func TestPushedAuthorizationRequestHandler(t *testing.T) {
+	store := &FositeMemoryStore{
+		Manager:        client.NewMemoryManager(nil),
+		AuthorizeCodes: make(map[string]fosite.Requester),
+		IDSessions:     make(map[string]fosite.Requester),
+		AccessTokens:   make(map[string]fosite.Requester),
+		RefreshTokens:  make(map[string]fosite.Requester),
+	}
+	require.NoError(t, store.CreateClient(&client.Client{
+		ID:            "photos",
+		Secret:        "secret",
+		RedirectURIs:  []string{"https://photos.localhost/callback"},
+		GrantTypes:    []string{"authorization_code"},
+		ResponseTypes: []string{"code"},
+		Scope:         "openid offline",
+	}))
+
+	config := &compose.Config{ScopeStrategy: fosite.HierarchicScopeStrategy}
+	h := &Handler{
+		OAuth2: compose.Compose(
+			config,
+			store,
+			&compose.CommonStrategy{CoreStrategy: compose.NewOAuth2HMACStrategy(config, []byte("1234567890123456789012345678901234567890"))},
+			nil,
+			compose.OAuth2AuthorizeExplicitFactory,
+		),
+		Storage:                            store,
+		ClientSecretHasher:                 &fosite.BCrypt{},
+		PushedAuthorizationRequests:        NewPushedAuthorizationRequestMemoryManager(),
+		PushedAuthorizationRequestLifespan: time.Second * 30,
+		H:                                  herodot.NewJSONWriter(nil),
+		L:                                  logrus.New(),
+	}
+
+	push := func(secret string, form url.Values) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("POST", PushedAuthorizationRequestPath, strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.SetBasicAuth("photos", secret)
+		w := httptest.NewRecorder()
+		h.PushedAuthorizationRequestHandler(w, r, nil)
+		return w
+	}
+
+	form := url.Values{
+		"response_type": {"code"},
+		"redirect_uri":  {"https://photos.localhost/callback"},
+		"scope":         {"openid offline"},
+		"state":         {"some-state"},
+	}
+
+	t.Run("case=rejects clients with invalid credentials", func(t *testing.T) {
+		w := push("wrong-secret", form)
+		assert.Equal(t, http.StatusUnauthorized, w.Code, "%s", w.Body.String())
+	})
+
+	t.Run("case=rejects pushed request uris", func(t *testing.T) {
+		f := url.Values{"request_uri": {RequestURIPrefix + "other"}}
+		for k, v := range form {
+			f[k] = v
+		}
+
+		w := push("secret", f)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "%s", w.Body.String())
+	})
+
+	t.Run("case=validates the pushed parameters", func(t *testing.T) {
+		f := url.Values{}
+		for k, v := range form {
+			f[k] = v
+		}
+		f.Set("redirect_uri", "https://evil.localhost/callback")
+
+		w := push("secret", f)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "%s", w.Body.String())
+	})
+
+	t.Run("case=stores valid requests", func(t *testing.T) {
+		w := push("secret", form)
+		require.Equal(t, http.StatusCreated, w.Code, "%s", w.Body.String())
+		assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+
+		var response PushedAuthorizationResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+		assert.Equal(t, 30, response.ExpiresIn)
+		require.True(t, strings.HasPrefix(response.RequestURI, RequestURIPrefix), "%s", response.RequestURI)
+
+		p, err := h.PushedAuthorizationRequests.GetPushedAuthorizationRequest(strings.TrimPrefix(response.RequestURI, RequestURIPrefix))
+		require.NoError(t, err)
+		assert.Equal(t, "photos", p.ClientID)
+		assert.Equal(t, "photos", p.Form.Get("client_id"))
+		assert.Equal(t, "some-state", p.Form.Get("state"))
+		assert.Empty(t, p.Form.Get("client_secret"))
+	})
+}
+
+func TestRequiresPushedAuthorizationRequests(t *testing.T) {
+	assert.True(t, requiresPushedAuthorizationRequests(&client.Client{RequirePushedAuthorizationRequests: true}))
+	assert.False(t, requiresPushedAuthorizationRequests(&client.Client{}))
+	assert.False(t, requiresPushedAuthorizationRequests(&fosite.DefaultClient{}))
+}
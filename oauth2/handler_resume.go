@@ -0,0 +1,79 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/fosite"
+	"github.com/ory/hydra/pkg"
+	"github.com/pkg/errors"
+)
+
+func newResumeError(hint string) *fosite.RFC6749Error {
+	return &fosite.RFC6749Error{
+		Name:        "invalid_resumption_handle",
+		Description: "The login flow can not be resumed",
+		Debug:       hint,
+		Hint:        hint,
+		Code:        http.StatusBadRequest,
+	}
+}
+
+// swagger:route GET /oauth2/auth/resume oAuth2 resumeOAuth2ConsentRequest
+//
+// Resume a parked consent request
+//
+// This endpoint is opened by the user agent to continue a login flow that has been parked using the
+// `/oauth2/consent/requests/{id}/park` endpoint. It redirects to the consent app with the original consent request
+// id in the `consent` query parameter. The flow must be finished in the same browser that started it, because the
+// anti-CSRF cookie set by the authorization endpoint is still validated once the consent request is accepted.
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       302: emptyResponse
+func (h *Handler) ResumeHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	handle := r.URL.Query().Get("handle")
+	if handle == "" {
+		h.writeBrowserError(w, r, newResumeError("The resumption handle is missing"))
+		return
+	}
+
+	consent, err := h.ConsentManager.GetConsentRequestByResumptionHandle(handle)
+	if errors.Cause(err) == pkg.ErrNotFound {
+		h.writeBrowserError(w, r, newResumeError("The resumption handle is unknown"))
+		return
+	} else if err != nil {
+		pkg.LogError(err, h.L)
+		h.writeBrowserError(w, r, errors.Wrap(fosite.ErrServerError, err.Error()))
+		return
+	} else if !consent.IsAbandoned() {
+		h.writeBrowserError(w, r, newResumeError("The consent request has already been accepted or rejected"))
+		return
+	} else if time.Now().UTC().After(consent.ExpiresAt) {
+		h.writeBrowserError(w, r, newResumeError("The parked consent request expired"))
+		return
+	}
+
+	p := h.ConsentURL
+	q := p.Query()
+	q.Set("consent", consent.ID)
+	p.RawQuery = q.Encode()
+
+	http.Redirect(w, r, p.String(), http.StatusFound)
+}
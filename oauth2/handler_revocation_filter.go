@@ -0,0 +1,73 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/hydra/pkg"
+	"github.com/pkg/errors"
+)
+
+// swagger:route GET /.well-known/revoked-tokens oAuth2 getRevocationFilter
+//
+// Get a signed bloom filter of revoked access tokens
+//
+// This endpoint returns a bloom filter of the signatures of all revoked access tokens which have not expired yet,
+// signed as a JSON Web Token using the OpenID Connect key, so edge validators of access tokens can check for revocation
+// without introspecting every token. The signature of a token is the last segment of the token. Look up the signature
+// in the filter as follows: the i-th of the k bit positions is (h1 + i*h2) mod m, where h1 and h2 are the first and
+// second eight bytes of the SHA-256 hash of the signature read as big endian unsigned integers. Bit j is stored in
+// byte j/8 of the base64url decoded bits at position j%8, counting from the least significant bit. If all k bits are
+// set, the token has probably been revoked and should be introspected. Otherwise it had not been revoked when the filter
+// was issued.
+//
+// The filter is regenerated every REVOCATION_FILTER_INTERVAL and expires after twice that interval, validators should
+// not use expired filters. The endpoint is only available if OAUTH2_TOKEN_HISTORY is enabled.
+//
+//     Produces:
+//     - application/jwt
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: revocationFilterResponse
+//       304: emptyResponse
+//       503: genericError
+func (h *Handler) RevocationFilterHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	token, _ := h.RevocationFilter.Current()
+	if token == "" {
+		h.H.WriteErrorCode(w, r, http.StatusServiceUnavailable, errors.New("The revocation filter has not been generated yet"))
+		return
+	}
+
+	etag, err := pkg.ETag(token)
+	if err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(h.RevocationFilter.Interval.Seconds())))
+	if pkg.ETagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/jwt")
+	w.Write([]byte(token))
+}
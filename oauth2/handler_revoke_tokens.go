@@ -0,0 +1,120 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/fosite"
+	"github.com/ory/hydra/firewall"
+	"github.com/pkg/errors"
+)
+
+const (
+	// TokensPath points to the endpoint revoking all tokens issued to a client or on behalf of a subject.
+	TokensPath = "/oauth2/tokens"
+
+	// ClientTokensResource is the resource on which the action "revoke" must be allowed to revoke the tokens of a
+	// client.
+	ClientTokensResource = "oauth2:tokens:clients:%s"
+
+	// SubjectTokensResource is the resource on which the action "revoke" must be allowed to revoke the tokens of a
+	// subject.
+	SubjectTokensResource = "oauth2:tokens:subjects:%s"
+)
+
+// swagger:route DELETE /oauth2/tokens oAuth2 revokeOAuth2Tokens
+//
+// Revoke all OAuth2 tokens of a client or subject
+//
+// This endpoint revokes all access and refresh tokens issued to the client identified by the client_id query
+// parameter, or issued on behalf of the subject identified by the subject query parameter. Exactly one of the two
+// must be set.
+//
+// Revoke the tokens of a client after its secret was leaked, and rotate the client's credentials afterwards, or the
+// client can be issued new tokens. Revoke the tokens of a subject when the user signs out everywhere. Tokens which are
+// not stored by this server, such as JSON Web Token access tokens which are validated without introspection, remain
+// valid until they expire.
+//
+// The subject making the request needs to be assigned to a policy containing one of:
+//
+//  ```
+//  {
+//    "resources": ["rn:hydra:oauth2:tokens:clients:<client-id>"],
+//    "actions": ["revoke"],
+//    "effect": "allow"
+//  }
+//  ```
+//
+//  ```
+//  {
+//    "resources": ["rn:hydra:oauth2:tokens:subjects:<subject>"],
+//    "actions": ["revoke"],
+//    "effect": "allow"
+//  }
+//  ```
+//
+//     Schemes: http, https
+//
+//     Security:
+//       oauth2: hydra.oauth2.revoke
+//
+//     Responses:
+//       204: emptyResponse
+//       400: genericError
+//       401: genericError
+//       403: genericError
+//       500: genericError
+func (h *Handler) RevokeTokensHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	clientID := r.URL.Query().Get("client_id")
+	subject := r.URL.Query().Get("subject")
+	if (clientID == "") == (subject == "") {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, errors.New("Exactly one of the query parameters client_id and subject must be set"))
+		return
+	}
+
+	resource := fmt.Sprintf(h.PrefixResource(ClientTokensResource), clientID)
+	if subject != "" {
+		resource = fmt.Sprintf(h.PrefixResource(SubjectTokensResource), subject)
+	}
+
+	if token := h.W.TokenFromRequest(r); token != "" {
+		if _, err := h.W.TokenAllowed(r.Context(), token, &firewall.TokenAccessRequest{
+			Resource: resource,
+			Action:   "revoke",
+		}, "hydra.oauth2.revoke"); err != nil {
+			h.H.WriteError(w, r, err)
+			return
+		}
+	} else {
+		h.H.WriteError(w, r, errors.WithStack(fosite.ErrRequestUnauthorized))
+		return
+	}
+
+	var err error
+	if subject != "" {
+		_, err = h.Storage.RevokeSubjectTokens(r.Context(), subject)
+	} else {
+		_, err = h.Storage.RevokeClientTokens(r.Context(), clientID)
+	}
+	if err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
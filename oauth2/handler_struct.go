@@ -15,13 +15,19 @@
 package oauth2
 
 import (
+	"crypto/x509"
+	"net"
+	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/gorilla/sessions"
 	"github.com/ory/fosite"
+	foauth2 "github.com/ory/fosite/handler/oauth2"
 	"github.com/ory/herodot"
 	"github.com/ory/hydra/firewall"
+	"github.com/ory/hydra/i18n"
 	"github.com/ory/hydra/pkg"
 	"github.com/sirupsen/logrus"
 )
@@ -31,11 +37,39 @@ type Handler struct {
 	Consent ConsentStrategy
 	Storage pkg.FositeStorer
 
+	// ConsentManager is used to look up parked consent requests when a login flow is resumed.
+	ConsentManager ConsentRequestManager
+
+	// TokenHistory, if set, enables the token history endpoint. TokenStrategy is used to compute the signature of the
+	// token that is looked up.
+	TokenHistory  TokenHistoryManager
+	TokenStrategy foauth2.CoreStrategy
+
 	H herodot.Writer
 
 	ForcedHTTP bool
 	ConsentURL url.URL
 
+	// LoginURL, if set together with Logins, splits the consent flow into a login challenge, which is sent to the
+	// login app at LoginURL, and a consent challenge for the authenticated subject, which is sent to the consent app
+	// at ConsentURL. Login challenges expire after LoginChallengeLifespan unless the client configures its own
+	// consent challenge lifespan.
+	LoginURL               url.URL
+	Logins                 LoginRequestManager
+	LoginChallengeLifespan time.Duration
+
+	// LoginSessions, if set, tells the login app whether the user agent already has a login session, see
+	// LoginRequest.Skip.
+	LoginSessions LoginSessionManager
+
+	// ErrorURL is the location browser-facing errors are redirected to when the error can not be sent back to the
+	// client's redirect URI. If empty, ConsentURL is used.
+	ErrorURL url.URL
+
+	// ErrorCatalog, if set, translates the descriptions of errors shown to end users, either through the error URL or
+	// the client's redirect URI.
+	ErrorCatalog *i18n.Catalog
+
 	AccessTokenLifespan time.Duration
 	CookieStore         sessions.Store
 
@@ -43,8 +77,15 @@ type Handler struct {
 
 	ScopeStrategy fosite.ScopeStrategy
 
+	// ResourceScopes narrows the scopes of access tokens restricted to resources to the scopes of these resources.
+	ResourceScopes ResourceScopes
+
 	Issuer string
 
+	// IssuersByHost maps lower-case hostnames, optionally including the port, to the issuer used for requests sent to
+	// that host. Requests to other hosts use Issuer.
+	IssuersByHost map[string]string
+
 	W firewall.Firewall
 
 	ResourcePrefix string
@@ -52,6 +93,83 @@ type Handler struct {
 	ClaimsSupported  string
 	ScopesSupported  string
 	UserinfoEndpoint string
+
+	IDTokenSigningAlgorithm string
+
+	// TokenIssued, if set, is called with the grant type of every access token issued by the token endpoint.
+	TokenIssued func(grantType string)
+
+	// IntrospectionObserved, if set, is called with the result and duration of every token introspection.
+	IntrospectionObserved func(active bool, duration time.Duration)
+
+	// IntrospectionCache, if set, caches the results of introspecting active tokens.
+	IntrospectionCache *IntrospectionCache
+
+	// IntrospectionCacheControlMaxAge, if set, allows the subjects of IntrospectionCacheControlSubjects, which are the
+	// clients or subjects of the access tokens authorizing the introspection request, to cache the results of
+	// introspecting active tokens for this duration. The response tells them using the Cache-Control header.
+	IntrospectionCacheControlMaxAge   time.Duration
+	IntrospectionCacheControlSubjects []string
+
+	// ClientAuthGuard, if set, locks out clients which fail to authenticate at the token endpoint too often.
+	ClientAuthGuard *ClientAuthGuard
+
+	// ClientAuthFailed, if set, is called for every failed client authentication at the token endpoint. locked is
+	// true if the attempt was rejected because of a lockout.
+	ClientAuthFailed func(locked bool)
+
+	// ClientLockedOut, if set, is called with the request which caused the lockout of a client.
+	ClientLockedOut func(r *http.Request, clientID string)
+
+	// TLSClientAuthCAs are the certificate authorities trusted to issue the certificates of clients using
+	// tls_client_auth. If nil, clients using tls_client_auth can not authenticate.
+	TLSClientAuthCAs *x509.CertPool
+
+	// ClientSecretVerified, if set, is called with the id and secret of every client which authenticated at the token
+	// endpoint using its secret, allowing the secret to be rehashed.
+	ClientSecretVerified func(clientID string, secret []byte)
+
+	// ClaimsHook, if set, may add custom claims to the tokens issued by the authorization endpoint and to tokens
+	// issued for the client credentials grant. Tokens issued for other grants inherit the claims of the authorization
+	// or token they are issued for.
+	ClaimsHook ClaimsHook
+
+	// RevocationFilter, if set, is published at RevocationFilterPath.
+	RevocationFilter *RevocationFilter
+
+	// ConsentGrants, if set, enables the endpoints listing and revoking consent grants at ConsentGrantsPath.
+	ConsentGrants ConsentGrantManager
+
+	// RememberedConsents, if set, forgets the remembered consent of a subject to a client when its consent grant is
+	// revoked.
+	RememberedConsents RememberedConsentManager
+
+	// Logout, if set, enables the OpenID Connect end session endpoint at LogoutPath.
+	Logout *Logout
+
+	// PushedAuthorizationRequests, if set, enables the pushed authorization request endpoint at
+	// PushedAuthorizationRequestPath. Pushed requests expire after PushedAuthorizationRequestLifespan.
+	PushedAuthorizationRequests        PushedAuthorizationRequestManager
+	PushedAuthorizationRequestLifespan time.Duration
+
+	// ClientSecretHasher compares the secrets of clients authenticating at the pushed authorization request endpoint.
+	ClientSecretHasher fosite.Hasher
+
+	// EmbedOrigins are the origins of trusted first-party pages which may embed the authorization flow of first-party
+	// clients in an iframe, using the web_message response mode.
+	EmbedOrigins []string
+
+	// UserinfoSigner signs the userinfo responses of clients which registered a userinfo signing algorithm. If nil,
+	// userinfo requests of such clients fail.
+	UserinfoSigner *UserinfoSigner
+
+	// ResponseEncrypter encrypts ID tokens and userinfo responses of clients which registered an encryption
+	// algorithm. If nil, requests of such clients fail.
+	ResponseEncrypter *ResponseEncrypter
+
+	// DynamicClientRegistration publishes client.DynamicRegistrationPath as the registration endpoint in the
+	// discovery document.
+	DynamicClientRegistration bool
 }
 
 func (h *Handler) PrefixResource(resource string) string {
@@ -65,3 +183,30 @@ func (h *Handler) PrefixResource(resource string) string {
 
 	return h.ResourcePrefix + ":" + resource
 }
+
+// issuer returns the issuer for the host the request was sent to.
+func (h *Handler) issuer(r *http.Request) string {
+	if issuer, ok := h.issuerForHost(r); ok {
+		return issuer
+	}
+	return h.Issuer
+}
+
+func (h *Handler) issuerForHost(r *http.Request) (string, bool) {
+	if len(h.IssuersByHost) == 0 {
+		return "", false
+	}
+
+	host := strings.ToLower(r.Host)
+	if issuer, ok := h.IssuersByHost[host]; ok {
+		return issuer, true
+	}
+
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		if issuer, ok := h.IssuersByHost[hostname]; ok {
+			return issuer, true
+		}
+	}
+
+	return "", false
+}
@@ -19,6 +19,7 @@ import (
 	"crypto/rand"
 	"crypto/rsa"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -37,6 +38,7 @@ import (
 	hydra "github.com/ory/hydra/sdk/go/hydra/swagger"
 	"github.com/ory/ladon"
 	"github.com/sirupsen/logrus"
+	"github.com/square/go-jose"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -143,6 +145,86 @@ func TestHandlerFlushHandler(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestHandlerTokenHistory(t *testing.T) {
+	localWarden, httpClient := c2.NewMockFirewall(
+		"tests",
+		"alice",
+		fosite.Arguments{
+			"hydra.oauth2.audit",
+		}, &ladon.DefaultPolicy{
+			ID:        "1",
+			Subjects:  []string{"<.*>"},
+			Resources: []string{"rn:hydra:oauth2:tokens"},
+			Actions:   []string{"audit"},
+			Effect:    ladon.AllowAccess,
+		},
+	)
+
+	strategy := compose.NewOAuth2HMACStrategy(&compose.Config{AccessTokenLifespan: lifespan}, []byte("some super secret secret secret!"))
+	history := oauth2.NewTokenHistoryMemoryManager()
+	h := &oauth2.Handler{
+		H:             herodot.NewJSONWriter(nil),
+		W:             localWarden,
+		ScopeStrategy: fosite.HierarchicScopeStrategy,
+		Issuer:        "http://hydra.localhost",
+		TokenHistory:  history,
+		TokenStrategy: strategy,
+	}
+
+	token, signature, err := strategy.GenerateAccessToken(nil, &fosite.Request{Session: new(fosite.DefaultSession)})
+	require.NoError(t, err)
+
+	issued := time.Now().UTC().Round(time.Second).Add(-time.Hour * 2)
+	require.NoError(t, history.CreateTokenRecord(&oauth2.TokenRecord{
+		Signature: signature,
+		TokenType: fosite.AccessToken,
+		RequestID: "history-1",
+		ClientID:  "foobar",
+		Subject:   "peter",
+		Scopes:    []string{"foo", "bar"},
+		IssuedAt:  issued,
+		ExpiresAt: issued.Add(lifespan * 2),
+		RevokedAt: issued.Add(lifespan),
+	}))
+
+	r := httprouter.New()
+	h.SetRoutes(r)
+	ts := httptest.NewServer(r)
+	c := hydra.NewOAuth2ApiWithBasePath(ts.URL)
+	c.Configuration.Transport = httpClient.Transport
+
+	result, resp, err := c.IntrospectOAuth2TokenHistory(token, issued.Add(time.Minute).Format(time.RFC3339))
+	require.NoError(t, err)
+	require.EqualValues(t, http.StatusOK, resp.StatusCode)
+	assert.True(t, result.Active)
+	assert.Equal(t, "access_token", result.TokenType)
+	assert.Equal(t, "foobar", result.ClientId)
+	assert.Equal(t, "peter", result.Sub)
+	assert.Equal(t, "foo bar", result.Scope)
+	assert.Equal(t, issued.Unix(), result.Iat)
+	assert.Equal(t, issued.Add(lifespan).Unix(), result.RevokedAt)
+
+	result, resp, err = c.IntrospectOAuth2TokenHistory(token, issued.Add(-time.Minute).Format(time.RFC3339))
+	require.NoError(t, err)
+	require.EqualValues(t, http.StatusOK, resp.StatusCode)
+	assert.False(t, result.Active)
+
+	result, resp, err = c.IntrospectOAuth2TokenHistory(token, "")
+	require.NoError(t, err)
+	require.EqualValues(t, http.StatusOK, resp.StatusCode)
+	assert.False(t, result.Active)
+
+	result, resp, err = c.IntrospectOAuth2TokenHistory("unknown-token", "")
+	require.NoError(t, err)
+	require.EqualValues(t, http.StatusOK, resp.StatusCode)
+	assert.False(t, result.Active)
+	assert.Empty(t, result.ClientId)
+
+	_, resp, err = c.IntrospectOAuth2TokenHistory(token, "yesterday")
+	require.NoError(t, err)
+	assert.EqualValues(t, http.StatusBadRequest, resp.StatusCode)
+}
+
 func TestHandlerWellKnown(t *testing.T) {
 	h := &oauth2.Handler{
 		H:             herodot.NewJSONWriter(nil),
@@ -172,8 +254,17 @@ func TestHandlerWellKnown(t *testing.T) {
 		ClaimsSupported:                   []string{"sub"},
 		ScopesSupported:                   []string{"offline", "openid"},
 		UserinfoEndpoint:                  h.Issuer + oauth2.UserinfoPath,
-		TokenEndpointAuthMethodsSupported: []string{"client_secret_post", "client_secret_basic"},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_post", "client_secret_basic", "tls_client_auth", "self_signed_tls_client_auth"},
 		IDTokenSigningAlgValuesSupported:  []string{"RS256"},
+
+		TLSClientCertificateBoundAccessTokens: true,
+
+		IntrospectionEndpoint:         h.Issuer + oauth2.IntrospectPath,
+		RevocationEndpoint:            h.Issuer + oauth2.RevocationPath,
+		GrantTypesSupported:           []string{"authorization_code", "implicit", "client_credentials", "refresh_token", oauth2.GrantTypeTokenExchange},
+		ResponseModesSupported:        []string{"query", "fragment", "form_post"},
+		CodeChallengeMethodsSupported: []string{"S256"},
+		ClaimsParameterSupported:      true,
 	}
 	var wellKnownResp oauth2.WellKnown
 	err = json.NewDecoder(res.Body).Decode(&wellKnownResp)
@@ -192,6 +283,60 @@ func TestHandlerWellKnown(t *testing.T) {
 	assert.EqualValues(t, wellKnownResp.ClaimsSupported, []string{"sub", "baz", "oof"})
 	assert.EqualValues(t, wellKnownResp.ScopesSupported, []string{"offline", "openid", "foo", "bar"})
 	assert.Equal(t, wellKnownResp.UserinfoEndpoint, "bar")
+
+	h.EmbedOrigins = []string{"https://shop.localhost"}
+	h.UserinfoSigner = &oauth2.UserinfoSigner{Algorithm: jose.RS256}
+	h.ResponseEncrypter = &oauth2.ResponseEncrypter{}
+
+	res, err = http.Get(ts.URL + "/.well-known/openid-configuration")
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&wellKnownResp))
+
+	assert.EqualValues(t, []string{"query", "fragment", "form_post", "web_message"}, wellKnownResp.ResponseModesSupported)
+	assert.EqualValues(t, []string{"RS256"}, wellKnownResp.UserinfoSigningAlgValuesSupported)
+	assert.EqualValues(t, client.ResponseEncryptionAlgs, wellKnownResp.IDTokenEncryptionAlgValuesSupported)
+	assert.EqualValues(t, client.ResponseEncryptionEncs, wellKnownResp.UserinfoEncryptionEncValuesSupported)
+}
+
+func TestHandlerWellKnownIssuerByHost(t *testing.T) {
+	h := &oauth2.Handler{
+		H:             herodot.NewJSONWriter(nil),
+		ScopeStrategy: fosite.HierarchicScopeStrategy,
+		Issuer:        "http://hydra.localhost",
+		IssuersByHost: map[string]string{"auth.example.com": "https://auth.example.com"},
+	}
+
+	r := httprouter.New()
+	h.SetRoutes(r)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	for k, tc := range []struct {
+		host   string
+		issuer string
+	}{
+		{host: "auth.example.com", issuer: "https://auth.example.com"},
+		{host: "AUTH.example.com:4444", issuer: "https://auth.example.com"},
+		{host: "other.example.com", issuer: "http://hydra.localhost"},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			req, err := http.NewRequest("GET", ts.URL+"/.well-known/openid-configuration", nil)
+			require.NoError(t, err)
+			req.Host = tc.host
+
+			res, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer res.Body.Close()
+
+			var wellKnownResp oauth2.WellKnown
+			require.NoError(t, json.NewDecoder(res.Body).Decode(&wellKnownResp))
+			assert.Equal(t, tc.issuer, wellKnownResp.Issuer)
+			assert.Equal(t, tc.issuer+"/oauth2/auth", wellKnownResp.AuthURL)
+			assert.Equal(t, tc.issuer+"/oauth2/token", wellKnownResp.TokenURL)
+			assert.Equal(t, tc.issuer+"/.well-known/jwks.json", wellKnownResp.JWKsURI)
+		})
+	}
 }
 
 type FakeConsentStrategy struct {
@@ -207,6 +352,10 @@ func (s *FakeConsentStrategy) CreateConsentRequest(authorizeRequest fosite.Autho
 	return "token", nil
 }
 
+func (s *FakeConsentStrategy) RememberedConsent(authorizeRequest fosite.AuthorizeRequester, session *sessions.Session) (claims *oauth2.Session, err error) {
+	return nil, nil
+}
+
 func TestIssuerRedirect(t *testing.T) {
 	storage := storage.NewExampleStore()
 	secret := []byte("my super secret password password password password")
@@ -256,3 +405,59 @@ func TestIssuerRedirect(t *testing.T) {
 
 	defer res.Body.Close()
 }
+
+func TestAuthHandlerErrorURL(t *testing.T) {
+	secret := []byte("my super secret password password password password")
+	privateKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	consentUrl, _ := url.Parse("http://consent.localhost")
+	errorUrl, _ := url.Parse("http://error.localhost/oauth2/error?theme=dark")
+
+	h := &oauth2.Handler{
+		H:             herodot.NewJSONWriter(nil),
+		Issuer:        "http://hydra.localhost",
+		OAuth2:        compose.ComposeAllEnabled(&compose.Config{}, storage.NewExampleStore(), secret, privateKey),
+		ConsentURL:    *consentUrl,
+		ScopeStrategy: fosite.WildcardScopeStrategy,
+		CookieStore:   sessions.NewCookieStore([]byte("my super secret password")),
+		Consent:       &FakeConsentStrategy{},
+		L:             logrus.New(),
+	}
+
+	r := httprouter.New()
+	h.SetRoutes(r)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	authorize := func(t *testing.T) *url.URL {
+		res, err := client.Get(ts.URL + "/oauth2/auth?response_type=code&client_id=unknown-client&state=some-state-value")
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		require.Equal(t, http.StatusFound, res.StatusCode)
+		location, err := url.Parse(res.Header.Get("Location"))
+		require.NoError(t, err)
+		return location
+	}
+
+	t.Run("case=falls back to consent url", func(t *testing.T) {
+		location := authorize(t)
+		assert.Equal(t, "consent.localhost", location.Host)
+		assert.NotEmpty(t, location.Query().Get("error"))
+	})
+
+	t.Run("case=redirects to error url", func(t *testing.T) {
+		h.ErrorURL = *errorUrl
+		location := authorize(t)
+		assert.Equal(t, "error.localhost", location.Host)
+		assert.Equal(t, "/oauth2/error", location.Path)
+		assert.Equal(t, "dark", location.Query().Get("theme"))
+		assert.NotEmpty(t, location.Query().Get("error"))
+		assert.NotEmpty(t, location.Query().Get("error_description"))
+	})
+}
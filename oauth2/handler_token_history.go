@@ -0,0 +1,333 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/fosite"
+	"github.com/ory/hydra/firewall"
+	"github.com/ory/hydra/pkg"
+	"github.com/pkg/errors"
+)
+
+// TokenHistoryIntrospection tells whether a token was active at a given point in time.
+//
+// swagger:model oAuth2TokenHistoryIntrospection
+type TokenHistoryIntrospection struct {
+	// Active is true if the token had been issued, had not expired and had not been revoked at the requested time.
+	Active bool `json:"active"`
+
+	// At is the point in time the token was checked against, as an integer timestamp measured in the number of seconds
+	// since January 1 1970 UTC.
+	At int64 `json:"at"`
+
+	// TokenType is either access_token or refresh_token.
+	TokenType string `json:"token_type,omitempty"`
+
+	// ClientID is the client identifier for the OAuth 2.0 client that requested this token.
+	ClientID string `json:"client_id,omitempty"`
+
+	// Subject of the token.
+	Subject string `json:"sub,omitempty"`
+
+	// Scope is a space-separated list of scopes granted to this token.
+	Scope string `json:"scope,omitempty"`
+
+	// IssuedAt is an integer timestamp indicating when this token was issued.
+	IssuedAt int64 `json:"iat,omitempty"`
+
+	// ExpiresAt is an integer timestamp indicating when this token expires or expired.
+	ExpiresAt int64 `json:"exp,omitempty"`
+
+	// RevokedAt is an integer timestamp indicating when this token was revoked or deleted.
+	RevokedAt int64 `json:"revoked_at,omitempty"`
+}
+
+// swagger:route POST /oauth2/introspect/history oAuth2 introspectOAuth2TokenHistory
+//
+// Check if a token was active at a point in time
+//
+// This endpoint answers whether an access or refresh token was active at a point in time in the past, using the
+// token's issuance, expiry and revocation time. It is meant for incident forensics, for example to determine whether
+// access at a past point in time was legitimate. The endpoint is only available if OAUTH2_TOKEN_HISTORY is enabled, and
+// only knows about tokens issued after it was enabled. Unknown tokens are reported as inactive.
+//
+//
+//  ```
+//  {
+//    "resources": ["rn:hydra:oauth2:tokens"],
+//    "actions": ["audit"],
+//    "effect": "allow"
+//  }
+//  ```
+//
+//     Consumes:
+//     - application/x-www-form-urlencoded
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Security:
+//       oauth2: hydra.oauth2.audit
+//
+//     Responses:
+//       200: introspectOAuth2TokenHistoryResponse
+//       400: genericError
+//       401: genericError
+//       403: genericError
+//       500: genericError
+func (h *Handler) TokenHistoryHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	token, ok := h.auditedToken(w, r)
+	if !ok {
+		return
+	}
+
+	at := time.Now().UTC()
+	if raw := r.PostForm.Get("at"); raw != "" {
+		var err error
+		if at, err = time.Parse(time.RFC3339, raw); err != nil {
+			h.H.WriteErrorCode(w, r, http.StatusBadRequest, errors.Errorf("Parameter at must be a RFC3339 timestamp: %s", err))
+			return
+		}
+	}
+
+	result := &TokenHistoryIntrospection{At: at.Unix()}
+	record, err := h.TokenHistory.GetTokenRecord(h.TokenStrategy.AccessTokenSignature(token))
+	if errors.Cause(err) == pkg.ErrNotFound {
+		h.H.Write(w, r, result)
+		return
+	} else if err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	result.Active = record.IsActiveAt(at)
+	result.TokenType = string(record.TokenType)
+	result.ClientID = record.ClientID
+	result.Subject = record.Subject
+	result.Scope = strings.Join(record.Scopes, " ")
+	result.IssuedAt = record.IssuedAt.Unix()
+	if !record.ExpiresAt.IsZero() {
+		result.ExpiresAt = record.ExpiresAt.Unix()
+	}
+	if !record.RevokedAt.IsZero() {
+		result.RevokedAt = record.RevokedAt.Unix()
+	}
+
+	h.H.Write(w, r, result)
+}
+
+// auditedToken checks that the caller is allowed to audit tokens and returns the token form parameter. If false is
+// returned, the error has been written.
+func (h *Handler) auditedToken(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if token := h.W.TokenFromRequest(r); token != "" {
+		if _, err := h.W.TokenAllowed(r.Context(), token, &firewall.TokenAccessRequest{
+			Resource: fmt.Sprintf(h.PrefixResource("oauth2:tokens")),
+			Action:   "audit",
+		}, "hydra.oauth2.audit"); err != nil {
+			h.H.WriteError(w, r, err)
+			return "", false
+		}
+	} else {
+		h.H.WriteError(w, r, errors.WithStack(fosite.ErrRequestUnauthorized))
+		return "", false
+	}
+
+	if err := r.ParseForm(); err != nil {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, errors.WithStack(err))
+		return "", false
+	}
+
+	token := r.PostForm.Get("token")
+	if token == "" {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, errors.New("Parameter token is missing"))
+		return "", false
+	}
+	return token, true
+}
+
+// TokenChain is the graph of the authorize code, access and refresh tokens issued for one authorization.
+//
+// swagger:model oAuth2TokenChain
+type TokenChain struct {
+	// RequestID identifies the authorization. All tokens of the chain were issued for it.
+	RequestID string `json:"request_id"`
+
+	// ClientID is the client identifier for the OAuth 2.0 client the tokens were issued to.
+	ClientID string `json:"client_id"`
+
+	// Subject of the tokens.
+	Subject string `json:"sub"`
+
+	// Nodes are the tokens of the chain, ordered by the time they were issued.
+	Nodes []TokenChainNode `json:"nodes"`
+
+	// Edges lead from every authorize code or refresh token that was exchanged to the tokens issued in exchange.
+	Edges []TokenChainEdge `json:"edges"`
+}
+
+// TokenChainNode is a token of a TokenChain.
+type TokenChainNode struct {
+	// ID identifies the token within the chain. It is derived from the token's signature and can not be used in place
+	// of the token.
+	ID string `json:"id"`
+
+	// TokenType is either authorize_code, access_token or refresh_token.
+	TokenType string `json:"token_type"`
+
+	// Scope is a space-separated list of scopes granted to this token.
+	Scope string `json:"scope,omitempty"`
+
+	// Active is true if the token has not expired and has not been revoked. Authorize codes are revoked once they are
+	// exchanged.
+	Active bool `json:"active"`
+
+	// Requested is true for the token the chain was requested for.
+	Requested bool `json:"requested,omitempty"`
+
+	// IssuedAt is an integer timestamp indicating when this token was issued.
+	IssuedAt int64 `json:"iat"`
+
+	// ExpiresAt is an integer timestamp indicating when this token expires or expired.
+	ExpiresAt int64 `json:"exp,omitempty"`
+
+	// RevokedAt is an integer timestamp indicating when this token was revoked, deleted or exchanged.
+	RevokedAt int64 `json:"revoked_at,omitempty"`
+}
+
+// TokenChainEdge leads from an authorize code or refresh token to a token issued in exchange for it.
+type TokenChainEdge struct {
+	// From is the id of the node that was exchanged.
+	From string `json:"from"`
+
+	// To is the id of the node that was issued in exchange.
+	To string `json:"to"`
+}
+
+// swagger:route POST /oauth2/introspect/chain oAuth2 getOAuth2TokenChain
+//
+// Get the token chain of a token
+//
+// This endpoint returns the graph of all tokens issued for the same authorization as the given refresh or access
+// token: the authorize code, every access and refresh token issued from it, which token was exchanged for which, and
+// whether each of them is still active. It is meant to debug why a client is still able to access resources. The
+// endpoint is only available if OAUTH2_TOKEN_HISTORY is enabled, and only knows about tokens issued after it was
+// enabled.
+//
+//
+//  ```
+//  {
+//    "resources": ["rn:hydra:oauth2:tokens"],
+//    "actions": ["audit"],
+//    "effect": "allow"
+//  }
+//  ```
+//
+//     Consumes:
+//     - application/x-www-form-urlencoded
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Security:
+//       oauth2: hydra.oauth2.audit
+//
+//     Responses:
+//       200: getOAuth2TokenChainResponse
+//       400: genericError
+//       401: genericError
+//       403: genericError
+//       404: genericError
+//       500: genericError
+func (h *Handler) TokenChainHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	token, ok := h.auditedToken(w, r)
+	if !ok {
+		return
+	}
+
+	record, err := h.TokenHistory.GetTokenRecord(h.TokenStrategy.RefreshTokenSignature(token))
+	if errors.Cause(err) == pkg.ErrNotFound {
+		record, err = h.TokenHistory.GetTokenRecord(h.TokenStrategy.AccessTokenSignature(token))
+	}
+	if err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	records, err := h.TokenHistory.GetTokenRecordsByRequestID(record.RequestID)
+	if err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	h.H.Write(w, r, newTokenChain(record, records, time.Now().UTC()))
+}
+
+// newTokenChain returns the chain of records, which must have been issued for the same request as requested.
+func newTokenChain(requested *TokenRecord, records []TokenRecord, now time.Time) *TokenChain {
+	chain := &TokenChain{
+		RequestID: requested.RequestID,
+		ClientID:  requested.ClientID,
+		Subject:   requested.Subject,
+		Nodes:     []TokenChainNode{},
+		Edges:     []TokenChainEdge{},
+	}
+
+	ids := map[string]string{}
+	for _, record := range records {
+		ids[record.Signature] = tokenChainNodeID(record.Signature)
+	}
+
+	for _, record := range records {
+		node := TokenChainNode{
+			ID:        ids[record.Signature],
+			TokenType: string(record.TokenType),
+			Scope:     strings.Join(record.Scopes, " "),
+			Active:    record.IsActiveAt(now),
+			Requested: record.Signature == requested.Signature,
+			IssuedAt:  record.IssuedAt.Unix(),
+		}
+		if !record.ExpiresAt.IsZero() {
+			node.ExpiresAt = record.ExpiresAt.Unix()
+		}
+		if !record.RevokedAt.IsZero() {
+			node.RevokedAt = record.RevokedAt.Unix()
+		}
+		chain.Nodes = append(chain.Nodes, node)
+
+		if parent, ok := ids[record.ParentSignature]; ok && record.ParentSignature != "" {
+			chain.Edges = append(chain.Edges, TokenChainEdge{From: parent, To: node.ID})
+		}
+	}
+
+	return chain
+}
+
+// tokenChainNodeID returns the first 16 bytes of the hex encoded SHA-256 hash of signature.
+func tokenChainNodeID(signature string) string {
+	hash := sha256.Sum256([]byte(signature))
+	return hex.EncodeToString(hash[:16])
+}
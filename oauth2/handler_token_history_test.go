@@ -0,0 +1,118 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/ory/fosite"
+	"github.com/ory/hydra/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenHistoryStorageRecordsExchanges(t *testing.T) {
+	history := NewTokenHistoryMemoryManager()
+	store := &TokenHistoryStorage{
+		FositeStorer: NewFositeMemoryStore(nil, time.Hour),
+		History:      history,
+	}
+	req := &fosite.Request{
+		ID:          "chain-request",
+		RequestedAt: time.Now().UTC(),
+		Client:      &client.Client{ID: "foobar"},
+		Form:        url.Values{},
+		Session:     &fosite.DefaultSession{Subject: "peter"},
+	}
+
+	require.NoError(t, store.CreateAuthorizeCodeSession(context.Background(), "chain-code", req))
+
+	ctx := withTokenExchange(context.Background())
+	_, err := store.GetAuthorizeCodeSession(ctx, "chain-code", new(fosite.DefaultSession))
+	require.NoError(t, err)
+	require.NoError(t, store.DeleteAuthorizeCodeSession(ctx, "chain-code"))
+	require.NoError(t, store.CreateAccessTokenSession(ctx, "chain-at-1", req))
+	require.NoError(t, store.CreateRefreshTokenSession(ctx, "chain-rt-1", req))
+
+	ctx = withTokenExchange(context.Background())
+	_, err = store.GetRefreshTokenSession(ctx, "chain-rt-1", new(fosite.DefaultSession))
+	require.NoError(t, err)
+	require.NoError(t, store.CreateAccessTokenSession(ctx, "chain-at-2", req))
+
+	code, err := history.GetTokenRecord("chain-code")
+	require.NoError(t, err)
+	assert.Equal(t, fosite.AuthorizeCode, code.TokenType)
+	assert.Empty(t, code.ParentSignature)
+	assert.False(t, code.RevokedAt.IsZero())
+
+	for signature, parent := range map[string]string{"chain-at-1": "chain-code", "chain-rt-1": "chain-code", "chain-at-2": "chain-rt-1"} {
+		r, err := history.GetTokenRecord(signature)
+		require.NoError(t, err)
+		assert.Equal(t, parent, r.ParentSignature, "%s", signature)
+	}
+
+	// Tokens issued outside of the token endpoint have no parent.
+	require.NoError(t, store.CreateAccessTokenSession(context.Background(), "chain-at-3", req))
+	r, err := history.GetTokenRecord("chain-at-3")
+	require.NoError(t, err)
+	assert.Empty(t, r.ParentSignature)
+}
+
+func TestNewTokenChain(t *testing.T) {
+	now := time.Now().UTC().Round(time.Second)
+	records := []TokenRecord{
+		{Signature: "code", TokenType: fosite.AuthorizeCode, RequestID: "request", ClientID: "foo", Subject: "peter", IssuedAt: now.Add(-time.Hour), RevokedAt: now.Add(-time.Hour)},
+		{Signature: "at-1", TokenType: fosite.AccessToken, RequestID: "request", ClientID: "foo", Subject: "peter", Scopes: []string{"foo", "offline"}, IssuedAt: now.Add(-time.Hour), ExpiresAt: now.Add(-time.Minute), ParentSignature: "code"},
+		{Signature: "rt-1", TokenType: fosite.RefreshToken, RequestID: "request", ClientID: "foo", Subject: "peter", IssuedAt: now.Add(-time.Hour), RevokedAt: now.Add(-time.Minute), ParentSignature: "code"},
+		{Signature: "at-2", TokenType: fosite.AccessToken, RequestID: "request", ClientID: "foo", Subject: "peter", IssuedAt: now.Add(-time.Minute), ExpiresAt: now.Add(time.Hour), ParentSignature: "rt-1"},
+		{Signature: "rt-2", TokenType: fosite.RefreshToken, RequestID: "request", ClientID: "foo", Subject: "peter", IssuedAt: now.Add(-time.Minute), ParentSignature: "rt-1"},
+		{Signature: "at-3", TokenType: fosite.AccessToken, RequestID: "request", ClientID: "foo", Subject: "peter", IssuedAt: now.Add(-time.Minute), ParentSignature: "unknown"},
+	}
+
+	chain := newTokenChain(&records[4], records, now)
+	assert.Equal(t, "request", chain.RequestID)
+	assert.Equal(t, "foo", chain.ClientID)
+	assert.Equal(t, "peter", chain.Subject)
+
+	require.Len(t, chain.Nodes, 6)
+	assert.Equal(t, "authorize_code", chain.Nodes[0].TokenType)
+	assert.False(t, chain.Nodes[0].Active)
+	assert.Equal(t, "foo offline", chain.Nodes[1].Scope)
+	assert.False(t, chain.Nodes[1].Active)
+	assert.False(t, chain.Nodes[2].Active)
+	assert.Equal(t, now.Add(-time.Minute).Unix(), chain.Nodes[2].RevokedAt)
+	assert.True(t, chain.Nodes[3].Active)
+	assert.True(t, chain.Nodes[4].Active)
+	assert.True(t, chain.Nodes[4].Requested)
+	assert.False(t, chain.Nodes[3].Requested)
+
+	ids := map[string]bool{}
+	for _, node := range chain.Nodes {
+		assert.Len(t, node.ID, 32)
+		assert.NotContains(t, node.ID, "at-")
+		ids[node.ID] = true
+	}
+	assert.Len(t, ids, 6)
+
+	assert.Equal(t, []TokenChainEdge{
+		{From: chain.Nodes[0].ID, To: chain.Nodes[1].ID},
+		{From: chain.Nodes[0].ID, To: chain.Nodes[2].ID},
+		{From: chain.Nodes[2].ID, To: chain.Nodes[3].ID},
+		{From: chain.Nodes[2].ID, To: chain.Nodes[4].ID},
+	}, chain.Edges)
+}
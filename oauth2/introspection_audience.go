@@ -0,0 +1,79 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ory/hydra/client"
+	"github.com/ory/hydra/pkg"
+	"github.com/pkg/errors"
+)
+
+// resourceServer returns the client authorizing the introspection request if it registered itself as a resource
+// server, or nil otherwise.
+func (h *Handler) resourceServer(ctx context.Context, clientID string) (*client.Client, error) {
+	if clientID == "" || h.Storage == nil {
+		return nil, nil
+	}
+
+	c, err := h.Storage.GetClient(ctx, clientID)
+	if errors.Cause(err) == pkg.ErrNotFound {
+		// Unknown clients fail to authenticate when the token is introspected.
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if hc, ok := c.(*client.Client); ok && hc.IsResourceServer() {
+		return hc, nil
+	}
+	return nil, nil
+}
+
+// filterIntrospection restricts the result of introspecting an active token to the claims relevant to the resource
+// server. Tokens which were not issued for one of the audiences of the resource server are reported as inactive, so
+// that resource servers can not learn anything about tokens issued for others. The audience is narrowed to the
+// audiences of the resource server, the scope to the scopes ResourceScopes maps them to, and the extra data and
+// username, which are meant for the client, are removed.
+func (h *Handler) filterIntrospection(rs *client.Client, introspection *Introspection) *Introspection {
+	var audience []string
+	for _, aud := range introspection.Audience {
+		if stringInSlice(aud, rs.ResourceServerAudience) {
+			audience = append(audience, aud)
+		}
+	}
+	if len(audience) == 0 {
+		return &Introspection{Active: false}
+	}
+
+	filtered := *introspection
+	filtered.Audience = audience
+	filtered.Username = ""
+	filtered.Extra = nil
+
+	if scopes, restricted := h.ResourceScopes.Scopes(audience); restricted {
+		var granted []string
+		for _, scope := range strings.Fields(introspection.Scope) {
+			if h.ScopeStrategy(scopes, scope) {
+				granted = append(granted, scope)
+			}
+		}
+		filtered.Scope = strings.Join(granted, " ")
+	}
+
+	return &filtered
+}
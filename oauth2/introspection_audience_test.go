@@ -0,0 +1,88 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ory/fosite"
+	"github.com/ory/hydra/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceServer(t *testing.T) {
+	store := &client.MemoryManager{
+		Clients: []client.Client{
+			{ID: "api", ResourceServerAudience: []string{"https://api.localhost"}},
+			{ID: "app"},
+		},
+		Hasher: &fosite.BCrypt{},
+	}
+	h := &Handler{Storage: &FositeMemoryStore{Manager: store}}
+
+	rs, err := h.resourceServer(context.Background(), "api")
+	require.NoError(t, err)
+	require.NotNil(t, rs)
+	assert.Equal(t, "api", rs.ID)
+
+	for _, id := range []string{"app", "unknown", ""} {
+		rs, err = h.resourceServer(context.Background(), id)
+		require.NoError(t, err)
+		assert.Nil(t, rs, "%s", id)
+	}
+}
+
+func TestFilterIntrospection(t *testing.T) {
+	h := &Handler{
+		ScopeStrategy:  fosite.HierarchicScopeStrategy,
+		ResourceScopes: ResourceScopes{"https://photos.localhost": {"photos"}},
+	}
+	introspection := &Introspection{
+		Active:   true,
+		Subject:  "peter",
+		ClientID: "app",
+		Scope:    "photos.read billing",
+		Username: "peter@localhost",
+		Audience: []string{"https://photos.localhost", "https://billing.localhost"},
+		Extra:    map[string]interface{}{"foo": "bar"},
+	}
+
+	t.Run("case=inactive for other audiences", func(t *testing.T) {
+		got := h.filterIntrospection(&client.Client{ResourceServerAudience: []string{"https://api.localhost"}}, introspection)
+		assert.Equal(t, &Introspection{Active: false}, got)
+	})
+
+	t.Run("case=narrows audience and scope", func(t *testing.T) {
+		got := h.filterIntrospection(&client.Client{ResourceServerAudience: []string{"https://photos.localhost"}}, introspection)
+		assert.True(t, got.Active)
+		assert.Equal(t, "peter", got.Subject)
+		assert.Equal(t, "app", got.ClientID)
+		assert.Equal(t, []string{"https://photos.localhost"}, got.Audience)
+		assert.Equal(t, "photos.read", got.Scope)
+		assert.Empty(t, got.Username)
+		assert.Empty(t, got.Extra)
+	})
+
+	t.Run("case=keeps scopes of unmapped audiences", func(t *testing.T) {
+		got := h.filterIntrospection(&client.Client{ResourceServerAudience: []string{"https://billing.localhost"}}, introspection)
+		assert.True(t, got.Active)
+		assert.Equal(t, []string{"https://billing.localhost"}, got.Audience)
+		assert.Equal(t, "photos.read billing", got.Scope)
+	})
+
+	assert.Equal(t, map[string]interface{}{"foo": "bar"}, introspection.Extra, "the result is not modified")
+}
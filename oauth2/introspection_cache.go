@@ -0,0 +1,177 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// DefaultIntrospectionCacheMaxEntries is the number of results an IntrospectionCache holds if MaxEntries is not set.
+const DefaultIntrospectionCacheMaxEntries = 10000
+
+// IntrospectionCache caches the results of introspecting active tokens for a given time to live, so that resource
+// servers introspecting the same token repeatedly do not cause a database lookup every time. Entries are keyed by the
+// hash of the token and the scopes the token was introspected for. Inactive tokens are never cached. When MaxEntries
+// is reached, the least recently used entry is evicted.
+//
+// Entries never outlive the token they were created for and are invalidated when the token is revoked through
+// warden.CacheInvalidatingStorage.
+type IntrospectionCache struct {
+	TTL        time.Duration
+	MaxEntries int
+
+	sync.Mutex
+	entries  map[string]*list.Element
+	requests map[string]map[string]bool
+	lru      *list.List
+}
+
+type cachedIntrospection struct {
+	key           string
+	requestID     string
+	introspection Introspection
+	expiresAt     time.Time
+}
+
+func NewIntrospectionCache(ttl time.Duration, maxEntries int) *IntrospectionCache {
+	return &IntrospectionCache{
+		TTL:        ttl,
+		MaxEntries: maxEntries,
+		entries:    map[string]*list.Element{},
+		requests:   map[string]map[string]bool{},
+		lru:        list.New(),
+	}
+}
+
+func introspectionCacheKey(token, scope string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:]) + "\x00" + scope
+}
+
+// Get returns the cached result of introspecting the token for the scopes, if any.
+func (c *IntrospectionCache) Get(token, scope string) (*Introspection, bool) {
+	c.Lock()
+	defer c.Unlock()
+
+	el, ok := c.entries[introspectionCacheKey(token, scope)]
+	if !ok {
+		return nil, false
+	}
+
+	i := el.Value.(*cachedIntrospection)
+	if !time.Now().Before(i.expiresAt) {
+		c.remove(el)
+		return nil, false
+	}
+
+	c.lru.MoveToFront(el)
+	result := i.introspection
+	return &result, true
+}
+
+// Add caches the result of introspecting an active token for the scopes. requestID is the id of the token's request
+// and is used to invalidate the entry when the token is revoked.
+func (c *IntrospectionCache) Add(token, scope, requestID string, introspection *Introspection) {
+	if !introspection.Active {
+		return
+	}
+
+	expiresAt := time.Now().Add(c.TTL)
+	if introspection.ExpiresAt > 0 && time.Unix(introspection.ExpiresAt, 0).Before(expiresAt) {
+		expiresAt = time.Unix(introspection.ExpiresAt, 0)
+	}
+
+	key := introspectionCacheKey(token, scope)
+
+	c.Lock()
+	defer c.Unlock()
+
+	c.init()
+	if el, ok := c.entries[key]; ok {
+		c.remove(el)
+	}
+
+	c.entries[key] = c.lru.PushFront(&cachedIntrospection{key: key, requestID: requestID, introspection: *introspection, expiresAt: expiresAt})
+	if c.requests[requestID] == nil {
+		c.requests[requestID] = map[string]bool{}
+	}
+	c.requests[requestID][key] = true
+
+	max := c.MaxEntries
+	if max <= 0 {
+		max = DefaultIntrospectionCacheMaxEntries
+	}
+	for c.lru.Len() > max {
+		c.remove(c.lru.Back())
+	}
+}
+
+// InvalidateRequest removes all entries of tokens which were issued by the request with the given id.
+func (c *IntrospectionCache) InvalidateRequest(requestID string) {
+	c.Lock()
+	defer c.Unlock()
+
+	for key := range c.requests[requestID] {
+		if el, ok := c.entries[key]; ok {
+			c.remove(el)
+		}
+	}
+}
+
+// Purge removes all entries.
+func (c *IntrospectionCache) Purge() {
+	c.Lock()
+	defer c.Unlock()
+
+	c.entries = map[string]*list.Element{}
+	c.requests = map[string]map[string]bool{}
+	c.lru = list.New()
+}
+
+// Len returns the number of cached entries, including expired ones which were not evicted yet.
+func (c *IntrospectionCache) Len() int {
+	c.Lock()
+	defer c.Unlock()
+
+	c.init()
+	return c.lru.Len()
+}
+
+func (c *IntrospectionCache) init() {
+	if c.entries == nil {
+		c.entries = map[string]*list.Element{}
+	}
+	if c.requests == nil {
+		c.requests = map[string]map[string]bool{}
+	}
+	if c.lru == nil {
+		c.lru = list.New()
+	}
+}
+
+func (c *IntrospectionCache) remove(el *list.Element) {
+	i := c.lru.Remove(el).(*cachedIntrospection)
+	delete(c.entries, i.key)
+	if keys := c.requests[i.requestID]; keys != nil {
+		delete(keys, i.key)
+		if len(keys) == 0 {
+			delete(c.requests, i.requestID)
+		}
+	}
+}
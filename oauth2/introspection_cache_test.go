@@ -0,0 +1,109 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntrospectionCacheKey(t *testing.T) {
+	k1 := introspectionCacheKey("token", "core")
+	assert.NotContains(t, k1, "token")
+	assert.NotEqual(t, k1, introspectionCacheKey("other-token", "core"))
+	assert.NotEqual(t, k1, introspectionCacheKey("token", "core hydra"))
+	assert.NotEqual(t, k1, introspectionCacheKey("token", ""))
+}
+
+func TestIntrospectionCache(t *testing.T) {
+	active := &Introspection{Active: true, Subject: "peter", ExpiresAt: time.Now().Add(time.Hour).Unix()}
+
+	t.Run("case=get", func(t *testing.T) {
+		c := NewIntrospectionCache(time.Minute, 0)
+		_, ok := c.Get("foo", "core")
+		assert.False(t, ok)
+
+		c.Add("foo", "core", "request-1", active)
+		got, ok := c.Get("foo", "core")
+		require.True(t, ok)
+		assert.Equal(t, "peter", got.Subject)
+
+		_, ok = c.Get("foo", "hydra")
+		assert.False(t, ok)
+	})
+
+	t.Run("case=does not cache inactive tokens", func(t *testing.T) {
+		c := NewIntrospectionCache(time.Minute, 0)
+		c.Add("foo", "core", "request-1", &Introspection{Active: false})
+		_, ok := c.Get("foo", "core")
+		assert.False(t, ok)
+		assert.Equal(t, 0, c.Len())
+	})
+
+	t.Run("case=expires with ttl", func(t *testing.T) {
+		c := NewIntrospectionCache(time.Millisecond*10, 0)
+		c.Add("foo", "core", "request-1", active)
+		time.Sleep(time.Millisecond * 20)
+		_, ok := c.Get("foo", "core")
+		assert.False(t, ok)
+		assert.Equal(t, 0, c.Len())
+	})
+
+	t.Run("case=expires with token", func(t *testing.T) {
+		c := NewIntrospectionCache(time.Hour, 0)
+		c.Add("foo", "core", "request-1", &Introspection{Active: true, ExpiresAt: time.Now().Add(-time.Second).Unix()})
+		_, ok := c.Get("foo", "core")
+		assert.False(t, ok)
+	})
+
+	t.Run("case=evicts least recently used", func(t *testing.T) {
+		c := NewIntrospectionCache(time.Minute, 2)
+		c.Add("foo", "core", "request-1", active)
+		c.Add("bar", "core", "request-2", active)
+		_, ok := c.Get("foo", "core")
+		require.True(t, ok)
+
+		c.Add("baz", "core", "request-3", active)
+		assert.Equal(t, 2, c.Len())
+		_, ok = c.Get("bar", "core")
+		assert.False(t, ok)
+		_, ok = c.Get("foo", "core")
+		assert.True(t, ok)
+		_, ok = c.Get("baz", "core")
+		assert.True(t, ok)
+	})
+
+	t.Run("case=invalidate request", func(t *testing.T) {
+		c := NewIntrospectionCache(time.Minute, 0)
+		c.Add("foo", "core", "request-1", active)
+		c.Add("foo", "hydra", "request-1", active)
+		c.Add("baz", "core", "request-2", active)
+
+		c.InvalidateRequest("request-1")
+		assert.Equal(t, 1, c.Len())
+		_, ok := c.Get("baz", "core")
+		assert.True(t, ok)
+	})
+
+	t.Run("case=purge", func(t *testing.T) {
+		c := NewIntrospectionCache(time.Minute, 0)
+		c.Add("foo", "core", "request-1", active)
+		c.Purge()
+		assert.Equal(t, 0, c.Len())
+	})
+}
@@ -59,13 +59,22 @@ type Introspection struct {
 	// authorized this token.
 	Username string `json:"username,omitempty"`
 
-	// ClientID is a service-specific string identifier or list of string
-	// identifiers representing the intended audience for this token.
-	Audience string `json:"aud,omitempty"`
+	// Audience is a list of service-specific string identifiers representing the
+	// intended audience for this token. It contains the audiences requested at the
+	// token endpoint, or the client id if none were requested.
+	Audience []string `json:"aud,omitempty"`
 
 	// Issuer is a string representing the issuer of this token
 	Issuer string `json:"iss,omitempty"`
 
 	// Extra is arbitrary data set by the session.
 	Extra map[string]interface{} `json:"ext,omitempty"`
+
+	// Actor identifies the party acting on behalf of the subject if the token was issued by a token exchange, see
+	// IETF RFC 8693 Section 4.1.
+	Actor map[string]interface{} `json:"act,omitempty"`
+
+	// Confirmation contains the "x5t#S256" thumbprint of the certificate the token is bound to if the client
+	// authenticated using mutual TLS, see IETF RFC 8705 Section 3.2.
+	Confirmation map[string]interface{} `json:"cnf,omitempty"`
 }
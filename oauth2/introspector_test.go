@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
 	"time"
@@ -27,6 +28,7 @@ import (
 	"github.com/ory/fosite/compose"
 	"github.com/ory/fosite/storage"
 	"github.com/ory/herodot"
+	hc "github.com/ory/hydra/client"
 	compose2 "github.com/ory/hydra/compose"
 	"github.com/ory/hydra/oauth2"
 	"github.com/ory/hydra/pkg"
@@ -186,3 +188,72 @@ func TestIntrospectorSDK(t *testing.T) {
 		}
 	})
 }
+
+func TestIntrospectorCacheAuthenticatesClient(t *testing.T) {
+	store := &oauth2.FositeMemoryStore{
+		Manager:        hc.NewMemoryManager(hasher),
+		AuthorizeCodes: make(map[string]fosite.Requester),
+		IDSessions:     make(map[string]fosite.Requester),
+		AccessTokens:   make(map[string]fosite.Requester),
+		RefreshTokens:  make(map[string]fosite.Requester),
+	}
+	require.NoError(t, store.CreateClient(&hc.Client{ID: "resource-server", Secret: "secret"}))
+
+	localWarden, _ := compose2.NewMockFirewall("foo", "resource-server", fosite.Arguments{"hydra.introspect"}, &ladon.DefaultPolicy{
+		ID:        "1",
+		Subjects:  []string{"resource-server"},
+		Resources: []string{"rn:hydra:oauth2:tokens"},
+		Actions:   []string{"introspect"},
+		Effect:    ladon.AllowAccess,
+	})
+
+	strategy := compose.NewOAuth2HMACStrategy(fc, []byte("1234567890123456789012345678901234567890"))
+	router := httprouter.New()
+	handler := &oauth2.Handler{
+		ScopeStrategy: fosite.WildcardScopeStrategy,
+		OAuth2: compose.Compose(
+			fc,
+			store,
+			&compose.CommonStrategy{
+				CoreStrategy:               strategy,
+				OpenIDConnectTokenStrategy: compose.NewOpenIDConnectStrategy(pkg.MustINSECURELOWENTROPYRSAKEYFORTEST()),
+			},
+			nil,
+			compose.OAuth2TokenIntrospectionFactory,
+		),
+		Storage:            store,
+		ClientSecretHasher: hasher,
+		IntrospectionCache: oauth2.NewIntrospectionCache(time.Minute, 0),
+		H:                  herodot.NewJSONWriter(nil),
+		W:                  localWarden,
+	}
+	handler.SetRoutes(router)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	ar := fosite.NewAccessRequest(oauth2.NewSession("alice"))
+	ar.GrantedScopes = fosite.Arguments{"core"}
+	ar.RequestedAt = time.Now()
+	ar.Client = &fosite.DefaultClient{ID: "my-client"}
+	ar.Session.SetExpiresAt(fosite.AccessToken, time.Now().Add(time.Hour))
+	token, signature, err := strategy.GenerateAccessToken(nil, ar)
+	require.NoError(t, err)
+	require.NoError(t, store.CreateAccessTokenSession(nil, signature, ar))
+
+	introspect := func(secret string) *http.Response {
+		req, err := http.NewRequest("POST", server.URL+"/oauth2/introspect", strings.NewReader(url.Values{"token": {token}}.Encode()))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetBasicAuth("resource-server", secret)
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		res.Body.Close()
+		return res
+	}
+
+	assert.Equal(t, http.StatusOK, introspect("secret").StatusCode)
+	require.Equal(t, 1, handler.IntrospectionCache.Len())
+
+	assert.Equal(t, http.StatusUnauthorized, introspect("wrong-secret").StatusCode)
+	assert.Equal(t, http.StatusOK, introspect("secret").StatusCode)
+}
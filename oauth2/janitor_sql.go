@@ -0,0 +1,154 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/ory/hydra/pkg"
+	"github.com/pkg/errors"
+)
+
+// DefaultJanitorBatchSize is the number of records SQLJanitor removes per statement unless configured otherwise.
+const DefaultJanitorBatchSize = 100
+
+// SQLJanitor removes expired access tokens, authorize codes, OpenID Connect sessions, consent requests, login requests
+// and pushed authorization requests from the SQL database. Records are removed in batches, so that the tables are
+// never locked for long. Refresh tokens do not expire and are kept until they are used or revoked. Authorize codes are
+// deleted when they are exchanged, so only codes which were never used are left to remove.
+type SQLJanitor struct {
+	DB *sqlx.DB
+
+	// AccessTokenLifespan and AuthCodeLifespan are the lifespans access tokens and authorize codes are issued with.
+	// Authorize codes and OpenID Connect sessions are removed once AuthCodeLifespan passed.
+	AccessTokenLifespan time.Duration
+	AuthCodeLifespan    time.Duration
+
+	// BatchSize is the number of records removed per statement and defaults to DefaultJanitorBatchSize.
+	BatchSize int
+
+	// Limit is the maximum number of records removed from each table per run. The number is unlimited if zero.
+	Limit int
+
+	// DecisionRetention, if set, is how long warden decisions are kept. Older decisions are removed as well.
+	DecisionRetention time.Duration
+}
+
+// JanitorResult is the number of records SQLJanitor removed from a table. Abandoned is the number of removed consent
+// requests the user never answered, like the abandoned count of ConsentRequestManager.FlushExpiredConsentRequests.
+type JanitorResult struct {
+	Table     string
+	Removed   int
+	Abandoned int
+}
+
+type janitorTable struct {
+	name     string
+	key      string
+	column   string
+	notAfter time.Time
+
+	// abandoned, if set, is the condition matching removed records which are counted as abandoned.
+	abandoned string
+}
+
+func (j *SQLJanitor) tables(now time.Time) []janitorTable {
+	tables := []janitorTable{
+		{name: "hydra_oauth2_" + sqlTableAccess, key: "signature", column: "requested_at", notAfter: now.Add(-j.AccessTokenLifespan)},
+		{name: "hydra_oauth2_" + sqlTableCode, key: "signature", column: "requested_at", notAfter: now.Add(-j.AuthCodeLifespan)},
+		{name: "hydra_oauth2_" + sqlTableOpenID, key: "signature", column: "requested_at", notAfter: now.Add(-j.AuthCodeLifespan)},
+		{name: "hydra_consent_request", key: "id", column: "expires_at", notAfter: now, abandoned: "consent = ''"},
+		{name: "hydra_oauth2_login_request", key: "challenge", column: "expires_at", notAfter: now},
+		{name: "hydra_oauth2_pushed_request", key: "id", column: "expires_at", notAfter: now},
+	}
+	if j.DecisionRetention > 0 {
+		tables = append(tables, janitorTable{name: "hydra_warden_decision", key: "id", column: "created_at", notAfter: now.Add(-j.DecisionRetention)})
+	}
+	return tables
+}
+
+// PurgeExpired removes the records which expired before now and returns how many records were removed per table.
+// If an error occurs, the records removed until then are reported along with the error.
+func (j *SQLJanitor) PurgeExpired(now time.Time) ([]JanitorResult, error) {
+	var results []JanitorResult
+	for _, t := range j.tables(now) {
+		removed, abandoned, err := j.purgeTable(t)
+		results = append(results, JanitorResult{Table: t.name, Removed: removed, Abandoned: abandoned})
+		if err != nil {
+			return results, errors.Wrapf(err, "Could not remove expired records from %s", t.name)
+		}
+	}
+	return results, nil
+}
+
+func (j *SQLJanitor) purgeTable(t janitorTable) (removed int, abandoned int, err error) {
+	batchSize := j.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultJanitorBatchSize
+	}
+
+	for j.Limit <= 0 || removed < j.Limit {
+		limit := batchSize
+		if j.Limit > 0 && j.Limit-removed < limit {
+			limit = j.Limit - removed
+		}
+
+		// DELETE ... LIMIT is not supported by all databases, the keys of the batch are looked up first instead.
+		var keys []string
+		if err := j.DB.Select(&keys, j.DB.Rebind(fmt.Sprintf("SELECT %s FROM %s WHERE %s < ? ORDER BY %s LIMIT %d", t.key, t.name, t.column, t.column, limit)), t.notAfter); err != nil {
+			return removed, abandoned, errors.WithStack(err)
+		} else if len(keys) == 0 {
+			break
+		}
+
+		query, args, err := sqlx.In(fmt.Sprintf("DELETE FROM %s WHERE %s IN (?)", t.name, t.key), keys)
+		if err != nil {
+			return removed, abandoned, errors.WithStack(err)
+		}
+
+		var n int64
+		var a int
+		if err := pkg.SQLTransaction(j.DB, func(tx *sqlx.Tx) error {
+			if t.abandoned != "" {
+				count, countArgs, err := sqlx.In(fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s IN (?) AND %s", t.name, t.key, t.abandoned), keys)
+				if err != nil {
+					return errors.WithStack(err)
+				} else if err := tx.Get(&a, tx.Rebind(count), countArgs...); err != nil {
+					return errors.WithStack(err)
+				}
+			}
+
+			result, err := tx.Exec(tx.Rebind(query), args...)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+
+			n, err = result.RowsAffected()
+			return errors.WithStack(err)
+		}); err != nil {
+			return removed, abandoned, err
+		}
+
+		removed += int(n)
+		abandoned += a
+		if len(keys) < limit {
+			break
+		}
+	}
+
+	return removed, abandoned, nil
+}
@@ -0,0 +1,145 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/ory/fosite"
+	"github.com/ory/hydra/client"
+	"github.com/ory/hydra/integration"
+	. "github.com/ory/hydra/oauth2"
+	"github.com/ory/hydra/warden/decision"
+	"github.com/pborman/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var janitorDatabases = map[string]*sqlx.DB{}
+
+func createJanitorSchemas(db *sqlx.DB) {
+	for _, s := range []interface {
+		CreateSchemas() (int, error)
+	}{
+		&FositeSQLStore{DB: db},
+		NewConsentRequestSQLManager(db),
+		&LoginRequestSQLManager{DB: db},
+		&PushedAuthorizationRequestSQLManager{DB: db},
+		&decision.SQLManager{DB: db},
+	} {
+		if _, err := s.CreateSchemas(); err != nil {
+			log.Fatalf("Could not create schema: %v", err)
+		}
+	}
+}
+
+func connectToMySQLJanitor() {
+	db := integration.ConnectToMySQL()
+	createJanitorSchemas(db)
+	janitorDatabases["mysql"] = db
+}
+
+func connectToPGJanitor() {
+	db := integration.ConnectToPostgres()
+	createJanitorSchemas(db)
+	janitorDatabases["postgres"] = db
+}
+
+func newJanitorRequest(requestedAt time.Time) *fosite.Request {
+	return &fosite.Request{
+		ID:            uuid.New(),
+		RequestedAt:   requestedAt,
+		Client:        &client.Client{ID: "foobar"},
+		Scopes:        fosite.Arguments{"fa"},
+		GrantedScopes: fosite.Arguments{"fa"},
+		Form:          url.Values{"foo": []string{"bar"}},
+		Session:       &fosite.DefaultSession{Subject: "bar"},
+	}
+}
+
+func TestSQLJanitor(t *testing.T) {
+	for k, db := range janitorDatabases {
+		t.Run(fmt.Sprintf("case=%s", k), func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Now().UTC().Round(time.Second)
+			store := &FositeSQLStore{DB: db, Manager: clientManager, L: logrus.New(), AccessTokenLifespan: time.Hour}
+			consents := NewConsentRequestSQLManager(db)
+
+			for i := 0; i < 3; i++ {
+				require.NoError(t, store.CreateAccessTokenSession(ctx, fmt.Sprintf("janitor-expired-at-%d", i), newJanitorRequest(now.Add(-time.Hour*2))))
+			}
+			require.NoError(t, store.CreateAccessTokenSession(ctx, "janitor-at", newJanitorRequest(now)))
+			require.NoError(t, store.CreateAuthorizeCodeSession(ctx, "janitor-expired-code", newJanitorRequest(now.Add(-time.Minute*20))))
+			require.NoError(t, store.CreateAuthorizeCodeSession(ctx, "janitor-code", newJanitorRequest(now)))
+
+			for _, c := range []*ConsentRequest{
+				{ID: "janitor-expired-consent", ClientID: "foobar", ExpiresAt: now.Add(-time.Minute), RequestedAt: now.Add(-time.Hour)},
+				{ID: "janitor-consent", ClientID: "foobar", ExpiresAt: now.Add(time.Hour), RequestedAt: now},
+			} {
+				require.NoError(t, consents.PersistConsentRequest(c))
+			}
+
+			decisions := &decision.SQLManager{DB: db}
+			require.NoError(t, decisions.AddDecision(&decision.Decision{Subject: "janitor-old", CreatedAt: now.Add(-time.Hour * 48)}))
+			require.NoError(t, decisions.AddDecision(&decision.Decision{Subject: "janitor-new", CreatedAt: now}))
+
+			janitor := &SQLJanitor{DB: db, AccessTokenLifespan: time.Hour, AuthCodeLifespan: time.Minute * 10, BatchSize: 1, Limit: 2, DecisionRetention: time.Hour * 24}
+			results, err := janitor.PurgeExpired(now)
+			require.NoError(t, err)
+			assert.Equal(t, []JanitorResult{
+				{Table: "hydra_oauth2_access", Removed: 2},
+				{Table: "hydra_oauth2_code", Removed: 1},
+				{Table: "hydra_oauth2_oidc", Removed: 0},
+				{Table: "hydra_consent_request", Removed: 1, Abandoned: 1},
+				{Table: "hydra_oauth2_login_request", Removed: 0},
+				{Table: "hydra_oauth2_pushed_request", Removed: 0},
+				{Table: "hydra_warden_decision", Removed: 1},
+			}, results)
+
+			janitor.Limit = 0
+			results, err = janitor.PurgeExpired(now)
+			require.NoError(t, err)
+			assert.Equal(t, JanitorResult{Table: "hydra_oauth2_access", Removed: 1}, results[0])
+
+			for i := 0; i < 3; i++ {
+				_, err = store.GetAccessTokenSession(ctx, fmt.Sprintf("janitor-expired-at-%d", i), &fosite.DefaultSession{})
+				assert.Error(t, err)
+			}
+			_, err = store.GetAuthorizeCodeSession(ctx, "janitor-expired-code", &fosite.DefaultSession{})
+			assert.Error(t, err)
+			_, err = consents.GetConsentRequest("janitor-expired-consent")
+			assert.Error(t, err)
+
+			_, err = store.GetAccessTokenSession(ctx, "janitor-at", &fosite.DefaultSession{})
+			assert.NoError(t, err)
+			_, err = store.GetAuthorizeCodeSession(ctx, "janitor-code", &fosite.DefaultSession{})
+			assert.NoError(t, err)
+			_, err = consents.GetConsentRequest("janitor-consent")
+			assert.NoError(t, err)
+
+			ds, err := decisions.GetDecisions(now.Add(-time.Hour*72), now.Add(time.Second), 10, 0)
+			require.NoError(t, err)
+			require.Len(t, ds, 1)
+			assert.Equal(t, "janitor-new", ds[0].Subject)
+		})
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/ory/fosite"
+)
+
+const (
+	LoginRequestAccepted = "accepted"
+	LoginRequestRejected = "rejected"
+)
+
+// LoginRequest is the login challenge of the two-step login and consent flow. The authorization endpoint redirects
+// the user agent to the login app with the challenge, which authenticates the subject and accepts or rejects the
+// request. The user agent is then sent back to the authorization endpoint with the verifier, which redirects to the
+// consent app with a consent challenge for the authenticated subject.
+//
+// swagger:model loginRequest
+type LoginRequest struct {
+	// Challenge identifies the login request and is sent to the login app in the "login_challenge" query parameter.
+	Challenge string `json:"challenge"`
+
+	// ClientID is the id of the client which initiated the authorization request.
+	ClientID string `json:"clientId"`
+
+	// RequestedScopes are the scopes the client requested.
+	RequestedScopes []string `json:"requestedScopes"`
+
+	// ACRValues are the authentication context class references requested by the client using the acr_values
+	// parameter, in order of preference.
+	ACRValues []string `json:"acrValues,omitempty"`
+
+	// RequestURL is the authorization request the login challenge was issued for.
+	RequestURL string `json:"requestUrl"`
+
+	// Skip is true if the subject of the user agent is still logged in. The login app must not ask for credentials
+	// but accept the request for Subject.
+	Skip bool `json:"skip"`
+
+	// Subject is the subject of the user agent if Skip is true.
+	Subject string `json:"subject,omitempty"`
+
+	// ExpiresAt is the time the login request expires.
+	ExpiresAt time.Time `json:"expiresAt"`
+
+	Verifier string `json:"-"`
+	CSRF     string `json:"-"`
+
+	// Login is LoginRequestAccepted or LoginRequestRejected once the login app handled the request.
+	Login string `json:"-"`
+
+	// DenyError is the error the login app rejected the request with.
+	DenyError *RequestDeniedError `json:"-"`
+
+	// ACR, AMR and AuthTime describe the authentication of the subject, as reported by the login app.
+	ACR      string    `json:"-"`
+	AMR      []string  `json:"-"`
+	AuthTime time.Time `json:"-"`
+
+	// RequestedAt is the time the authorization request was made.
+	RequestedAt time.Time `json:"-"`
+}
+
+// IsHandled returns true if the login app accepted or rejected the request.
+func (r *LoginRequest) IsHandled() bool {
+	return r.Login != ""
+}
+
+// AcceptLoginRequestPayload represents data that will be used to accept a login request.
+//
+// swagger:model loginRequestAcceptance
+type AcceptLoginRequestPayload struct {
+	// Subject represents a unique identifier of the user (or service, or legal entity, ...) that authenticated. It
+	// must be the subject of the login request if the login request was skipped.
+	Subject string `json:"subject"`
+
+	// ACR is the authentication context class reference satisfied by the authentication of the subject, for example
+	// one of the acrValues of the login request. It is added to the ID token as the "acr" claim.
+	ACR string `json:"acr,omitempty"`
+
+	// AMR lists the methods used to authenticate the subject, for example "pwd" and "otp". It is added to the
+	// ID token as the "amr" claim.
+	AMR []string `json:"amr,omitempty"`
+
+	// AuthTime is the time the subject authenticated and defaults to the time the login request is accepted. It
+	// is added to the ID token as the "auth_time" claim and checked against the prompt and max_age parameters of the
+	// authorization request.
+	AuthTime time.Time `json:"authTime,omitempty"`
+}
+
+// RequestDeniedError is the error a login or consent request is rejected with. It is sent to the client as the
+// error of the authorization request.
+//
+// swagger:model requestDeniedError
+type RequestDeniedError struct {
+	// Name is the OAuth 2.0 error code, for example "access_denied" or "login_required". Defaults to
+	// "access_denied".
+	Name string `json:"error"`
+
+	// Description is a human-readable description of the error.
+	Description string `json:"errorDescription,omitempty"`
+
+	// Hint helps the client to fix the error.
+	Hint string `json:"errorHint,omitempty"`
+
+	// Debug contains debug information, which is only sent to the client if debugging is enabled.
+	Debug string `json:"errorDebug,omitempty"`
+
+	// StatusCode is the HTTP status code of the error. Defaults to 403.
+	StatusCode int `json:"statusCode,omitempty"`
+}
+
+func (e *RequestDeniedError) toRFC6749Error() *fosite.RFC6749Error {
+	err := &fosite.RFC6749Error{
+		Name:        e.Name,
+		Description: e.Description,
+		Hint:        e.Hint,
+		Debug:       e.Debug,
+		Code:        e.StatusCode,
+	}
+	if err.Name == "" {
+		err.Name = "access_denied"
+	}
+	if err.Description == "" {
+		err.Description = "The resource owner or authorization server denied the request"
+	}
+	if err.Code == 0 {
+		err.Code = http.StatusForbidden
+	}
+	return err
+}
+
+// CompletedRequest is returned when a login or consent request was accepted or rejected.
+//
+// swagger:model completedRequest
+type CompletedRequest struct {
+	// RedirectTo is the URL the user agent must be redirected to.
+	RedirectTo string `json:"redirectTo"`
+}
+
+// LoginRequestManager stores login requests until they are used or expire.
+type LoginRequestManager interface {
+	// CreateLoginRequest stores the request and removes all expired requests.
+	CreateLoginRequest(r *LoginRequest) error
+
+	// GetLoginRequest returns the request or pkg.ErrNotFound if it does not exist. Expired requests may still be
+	// returned.
+	GetLoginRequest(challenge string) (*LoginRequest, error)
+
+	// GetLoginRequestByVerifier returns the request with the verifier or pkg.ErrNotFound if it does not exist.
+	GetLoginRequestByVerifier(verifier string) (*LoginRequest, error)
+
+	// AcceptLoginRequest marks the request as accepted for the subject of the payload.
+	AcceptLoginRequest(challenge string, payload *AcceptLoginRequestPayload) error
+
+	// RejectLoginRequest marks the request as rejected with the error.
+	RejectLoginRequest(challenge string, payload *RequestDeniedError) error
+
+	// DeleteLoginRequest removes the request, if it exists.
+	DeleteLoginRequest(challenge string) error
+
+	// DeleteSubjectLoginRequests removes all requests of the subject and returns how many were removed.
+	DeleteSubjectLoginRequests(subject string) (int, error)
+}
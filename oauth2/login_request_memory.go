@@ -0,0 +1,125 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ory/hydra/pkg"
+	"github.com/pkg/errors"
+)
+
+type LoginRequestMemoryManager struct {
+	requests map[string]LoginRequest
+	sync.RWMutex
+}
+
+func NewLoginRequestMemoryManager() *LoginRequestMemoryManager {
+	return &LoginRequestMemoryManager{requests: map[string]LoginRequest{}}
+}
+
+func (m *LoginRequestMemoryManager) CreateLoginRequest(r *LoginRequest) error {
+	m.Lock()
+	defer m.Unlock()
+
+	now := time.Now().UTC()
+	for challenge, request := range m.requests {
+		if request.ExpiresAt.Before(now) {
+			delete(m.requests, challenge)
+		}
+	}
+
+	m.requests[r.Challenge] = *r
+	return nil
+}
+
+func (m *LoginRequestMemoryManager) GetLoginRequest(challenge string) (*LoginRequest, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	request, ok := m.requests[challenge]
+	if !ok {
+		return nil, errors.WithStack(pkg.ErrNotFound)
+	}
+	return &request, nil
+}
+
+func (m *LoginRequestMemoryManager) GetLoginRequestByVerifier(verifier string) (*LoginRequest, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	for _, request := range m.requests {
+		if verifier != "" && request.Verifier == verifier {
+			return &request, nil
+		}
+	}
+	return nil, errors.WithStack(pkg.ErrNotFound)
+}
+
+func (m *LoginRequestMemoryManager) AcceptLoginRequest(challenge string, payload *AcceptLoginRequestPayload) error {
+	m.Lock()
+	defer m.Unlock()
+
+	request, ok := m.requests[challenge]
+	if !ok {
+		return errors.WithStack(pkg.ErrNotFound)
+	}
+
+	request.Login = LoginRequestAccepted
+	request.Subject = payload.Subject
+	request.ACR = payload.ACR
+	request.AMR = payload.AMR
+	request.AuthTime = payload.AuthTime
+	m.requests[challenge] = request
+	return nil
+}
+
+func (m *LoginRequestMemoryManager) RejectLoginRequest(challenge string, payload *RequestDeniedError) error {
+	m.Lock()
+	defer m.Unlock()
+
+	request, ok := m.requests[challenge]
+	if !ok {
+		return errors.WithStack(pkg.ErrNotFound)
+	}
+
+	request.Login = LoginRequestRejected
+	request.DenyError = payload
+	m.requests[challenge] = request
+	return nil
+}
+
+func (m *LoginRequestMemoryManager) DeleteLoginRequest(challenge string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	delete(m.requests, challenge)
+	return nil
+}
+
+func (m *LoginRequestMemoryManager) DeleteSubjectLoginRequests(subject string) (int, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	var n int
+	for challenge, r := range m.requests {
+		if r.Subject == subject {
+			delete(m.requests, challenge)
+			n++
+		}
+	}
+	return n, nil
+}
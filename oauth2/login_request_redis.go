@@ -0,0 +1,198 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"encoding/json"
+
+	"github.com/go-redis/redis"
+	"github.com/ory/hydra/pkg"
+	"github.com/pkg/errors"
+)
+
+// redisLoginRequestKey holds the JSON encoded login request. It expires together with the login request.
+func redisLoginRequestKey(challenge string) string {
+	return "hydra:login:request:" + challenge
+}
+
+// redisLoginVerifierKey holds the challenge of the login request the verifier was issued for.
+func redisLoginVerifierKey(verifier string) string {
+	return "hydra:login:verifier:" + verifier
+}
+
+// redisLoginSubjectKey is a set containing the challenges of all login requests of the subject.
+func redisLoginSubjectKey(subject string) string {
+	return "hydra:login:subject:" + subject
+}
+
+type LoginRequestRedisManager struct {
+	DB *redis.Client
+}
+
+func (m *LoginRequestRedisManager) CreateLoginRequest(r *LoginRequest) error {
+	return m.persistLoginRequest(r)
+}
+
+func (m *LoginRequestRedisManager) persistLoginRequest(r *LoginRequest) error {
+	d, err := newSQLLoginRequest(r)
+	if err != nil {
+		return err
+	}
+
+	out, err := json.Marshal(d)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if _, err := m.DB.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.Set(redisLoginRequestKey(r.Challenge), string(out), 0)
+		if r.Verifier != "" {
+			pipe.Set(redisLoginVerifierKey(r.Verifier), r.Challenge, 0)
+		}
+		if r.Subject != "" {
+			pipe.SAdd(redisLoginSubjectKey(r.Subject), r.Challenge)
+		}
+		if !r.ExpiresAt.IsZero() {
+			pipe.PExpireAt(redisLoginRequestKey(r.Challenge), r.ExpiresAt)
+			if r.Verifier != "" {
+				pipe.PExpireAt(redisLoginVerifierKey(r.Verifier), r.ExpiresAt)
+			}
+			if r.Subject != "" {
+				pipe.PExpireAt(redisLoginSubjectKey(r.Subject), r.ExpiresAt)
+			}
+		}
+		return nil
+	}); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func (m *LoginRequestRedisManager) GetLoginRequest(challenge string) (*LoginRequest, error) {
+	out, err := m.DB.Get(redisLoginRequestKey(challenge)).Bytes()
+	if err == redis.Nil {
+		return nil, errors.WithStack(pkg.ErrNotFound)
+	} else if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var d sqlLoginRequest
+	if err := json.Unmarshal(out, &d); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return d.toLoginRequest()
+}
+
+func (m *LoginRequestRedisManager) GetLoginRequestByVerifier(verifier string) (*LoginRequest, error) {
+	if verifier == "" {
+		return nil, errors.WithStack(pkg.ErrNotFound)
+	}
+
+	challenge, err := m.DB.Get(redisLoginVerifierKey(verifier)).Result()
+	if err == redis.Nil {
+		return nil, errors.WithStack(pkg.ErrNotFound)
+	} else if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	r, err := m.GetLoginRequest(challenge)
+	if err != nil {
+		return nil, err
+	} else if r.Verifier != verifier {
+		return nil, errors.WithStack(pkg.ErrNotFound)
+	}
+	return r, nil
+}
+
+func (m *LoginRequestRedisManager) AcceptLoginRequest(challenge string, payload *AcceptLoginRequestPayload) error {
+	r, err := m.GetLoginRequest(challenge)
+	if err != nil {
+		return err
+	}
+
+	r.Login = LoginRequestAccepted
+	r.Subject = payload.Subject
+	r.ACR = payload.ACR
+	r.AMR = payload.AMR
+	r.AuthTime = payload.AuthTime
+	return m.persistLoginRequest(r)
+}
+
+func (m *LoginRequestRedisManager) RejectLoginRequest(challenge string, payload *RequestDeniedError) error {
+	r, err := m.GetLoginRequest(challenge)
+	if err != nil {
+		return err
+	}
+
+	r.Login = LoginRequestRejected
+	r.DenyError = payload
+	return m.persistLoginRequest(r)
+}
+
+func (m *LoginRequestRedisManager) DeleteLoginRequest(challenge string) error {
+	r, err := m.GetLoginRequest(challenge)
+	if errors.Cause(err) == pkg.ErrNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	return m.deleteLoginRequest(r)
+}
+
+func (m *LoginRequestRedisManager) deleteLoginRequest(r *LoginRequest) error {
+	if _, err := m.DB.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.Del(redisLoginRequestKey(r.Challenge))
+		if r.Verifier != "" {
+			pipe.Del(redisLoginVerifierKey(r.Verifier))
+		}
+		if r.Subject != "" {
+			pipe.SRem(redisLoginSubjectKey(r.Subject), r.Challenge)
+		}
+		return nil
+	}); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func (m *LoginRequestRedisManager) DeleteSubjectLoginRequests(subject string) (int, error) {
+	challenges, err := m.DB.SMembers(redisLoginSubjectKey(subject)).Result()
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	var n int
+	for _, challenge := range challenges {
+		r, err := m.GetLoginRequest(challenge)
+		if errors.Cause(err) == pkg.ErrNotFound {
+			continue
+		} else if err != nil {
+			return n, err
+		} else if r.Subject != subject {
+			continue
+		}
+
+		if err := m.deleteLoginRequest(r); err != nil {
+			return n, err
+		}
+		n++
+	}
+
+	if err := m.DB.Del(redisLoginSubjectKey(subject)).Err(); err != nil {
+		return n, errors.WithStack(err)
+	}
+	return n, nil
+}
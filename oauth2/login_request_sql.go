@@ -0,0 +1,264 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/ory/hydra/pkg"
+	"github.com/pkg/errors"
+	"github.com/rubenv/sql-migrate"
+)
+
+var loginRequestMigrations = &migrate.MemoryMigrationSource{
+	Migrations: []*migrate.Migration{
+		{
+			Id: "1",
+			Up: []string{
+				`CREATE TABLE IF NOT EXISTS hydra_oauth2_login_request (
+	challenge			varchar(36) NOT NULL PRIMARY KEY,
+	verifier			varchar(36) NOT NULL UNIQUE,
+	csrf				varchar(36) NOT NULL,
+	client_id			varchar(255) NOT NULL,
+	requested_scopes	text NOT NULL,
+	acr_values			text NOT NULL,
+	request_url			text NOT NULL,
+	skip_login			boolean NOT NULL,
+	subject				varchar(255) NOT NULL,
+	expires_at			timestamp NOT NULL,
+	login				varchar(16) NOT NULL,
+	deny_error			text NOT NULL,
+	acr					text NOT NULL,
+	amr					text NOT NULL,
+	auth_time			timestamp NULL,
+	requested_at		timestamp NOT NULL
+)`,
+			},
+			Down: []string{
+				"DROP TABLE hydra_oauth2_login_request",
+			},
+		},
+	},
+}
+
+var sqlLoginRequestParams = []string{
+	"challenge", "verifier", "csrf", "client_id", "requested_scopes", "acr_values", "request_url", "skip_login",
+	"subject", "expires_at", "login", "deny_error", "acr", "amr", "auth_time", "requested_at",
+}
+
+type LoginRequestSQLManager struct {
+	DB *sqlx.DB
+}
+
+type sqlLoginRequest struct {
+	Challenge       string     `db:"challenge"`
+	Verifier        string     `db:"verifier"`
+	CSRF            string     `db:"csrf"`
+	ClientID        string     `db:"client_id"`
+	RequestedScopes string     `db:"requested_scopes"`
+	ACRValues       string     `db:"acr_values"`
+	RequestURL      string     `db:"request_url"`
+	Skip            bool       `db:"skip_login"`
+	Subject         string     `db:"subject"`
+	ExpiresAt       time.Time  `db:"expires_at"`
+	Login           string     `db:"login"`
+	DenyError       string     `db:"deny_error"`
+	ACR             string     `db:"acr"`
+	AMR             string     `db:"amr"`
+	AuthTime        *time.Time `db:"auth_time"`
+	RequestedAt     time.Time  `db:"requested_at"`
+}
+
+func newSQLLoginRequest(r *LoginRequest) (*sqlLoginRequest, error) {
+	var denyError string
+	if r.DenyError != nil {
+		out, err := json.Marshal(r.DenyError)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		denyError = string(out)
+	}
+
+	return &sqlLoginRequest{
+		Challenge:       r.Challenge,
+		Verifier:        r.Verifier,
+		CSRF:            r.CSRF,
+		ClientID:        r.ClientID,
+		RequestedScopes: strings.Join(r.RequestedScopes, " "),
+		ACRValues:       strings.Join(r.ACRValues, " "),
+		RequestURL:      r.RequestURL,
+		Skip:            r.Skip,
+		Subject:         r.Subject,
+		ExpiresAt:       r.ExpiresAt.UTC(),
+		Login:           r.Login,
+		DenyError:       denyError,
+		ACR:             r.ACR,
+		AMR:             strings.Join(r.AMR, " "),
+		AuthTime:        nullTime(r.AuthTime),
+		RequestedAt:     r.RequestedAt.UTC(),
+	}, nil
+}
+
+func (d *sqlLoginRequest) toLoginRequest() (*LoginRequest, error) {
+	var denyError *RequestDeniedError
+	if d.DenyError != "" {
+		if err := json.Unmarshal([]byte(d.DenyError), &denyError); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	var authTime time.Time
+	if d.AuthTime != nil {
+		authTime = d.AuthTime.UTC()
+	}
+
+	return &LoginRequest{
+		Challenge:       d.Challenge,
+		Verifier:        d.Verifier,
+		CSRF:            d.CSRF,
+		ClientID:        d.ClientID,
+		RequestedScopes: splitFields(d.RequestedScopes),
+		ACRValues:       splitFields(d.ACRValues),
+		RequestURL:      d.RequestURL,
+		Skip:            d.Skip,
+		Subject:         d.Subject,
+		ExpiresAt:       d.ExpiresAt.UTC(),
+		Login:           d.Login,
+		DenyError:       denyError,
+		ACR:             d.ACR,
+		AMR:             splitFields(d.AMR),
+		AuthTime:        authTime,
+		RequestedAt:     d.RequestedAt.UTC(),
+	}, nil
+}
+
+// Migrations returns the SQL migrations embedded in the binary.
+func (m *LoginRequestSQLManager) Migrations() *migrate.MemoryMigrationSource {
+	return loginRequestMigrations
+}
+
+func (m *LoginRequestSQLManager) CreateSchemas() (int, error) {
+	migrate.SetTable("hydra_oauth2_login_request_migration")
+	n, err := migrate.Exec(m.DB.DB, m.DB.DriverName(), loginRequestMigrations, migrate.Up)
+	if err != nil {
+		return 0, errors.Wrapf(err, "Could not migrate sql schema, applied %d migrations", n)
+	}
+	return n, nil
+}
+
+func (m *LoginRequestSQLManager) CreateLoginRequest(r *LoginRequest) error {
+	d, err := newSQLLoginRequest(r)
+	if err != nil {
+		return err
+	}
+
+	return pkg.SQLTransaction(m.DB, func(tx *sqlx.Tx) error {
+		if _, err := tx.Exec(tx.Rebind("DELETE FROM hydra_oauth2_login_request WHERE expires_at < ?"), time.Now().UTC()); err != nil {
+			return errors.WithStack(err)
+		}
+
+		query := fmt.Sprintf(
+			"INSERT INTO hydra_oauth2_login_request (%s) VALUES (%s)",
+			strings.Join(sqlLoginRequestParams, ", "),
+			":"+strings.Join(sqlLoginRequestParams, ", :"),
+		)
+		if _, err := tx.NamedExec(query, d); err != nil {
+			return errors.WithStack(err)
+		}
+		return nil
+	})
+}
+
+func (m *LoginRequestSQLManager) GetLoginRequest(challenge string) (*LoginRequest, error) {
+	return m.getLoginRequest("challenge", challenge)
+}
+
+func (m *LoginRequestSQLManager) GetLoginRequestByVerifier(verifier string) (*LoginRequest, error) {
+	return m.getLoginRequest("verifier", verifier)
+}
+
+func (m *LoginRequestSQLManager) getLoginRequest(column, value string) (*LoginRequest, error) {
+	var d sqlLoginRequest
+	query := fmt.Sprintf("SELECT %s FROM hydra_oauth2_login_request WHERE %s=?", strings.Join(sqlLoginRequestParams, ", "), column)
+	if err := m.DB.Get(&d, m.DB.Rebind(query), value); err == sql.ErrNoRows {
+		return nil, errors.WithStack(pkg.ErrNotFound)
+	} else if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return d.toLoginRequest()
+}
+
+func (m *LoginRequestSQLManager) AcceptLoginRequest(challenge string, payload *AcceptLoginRequestPayload) error {
+	return m.updateLoginRequest(challenge, func(r *LoginRequest) {
+		r.Login = LoginRequestAccepted
+		r.Subject = payload.Subject
+		r.ACR = payload.ACR
+		r.AMR = payload.AMR
+		r.AuthTime = payload.AuthTime
+	})
+}
+
+func (m *LoginRequestSQLManager) RejectLoginRequest(challenge string, payload *RequestDeniedError) error {
+	return m.updateLoginRequest(challenge, func(r *LoginRequest) {
+		r.Login = LoginRequestRejected
+		r.DenyError = payload
+	})
+}
+
+func (m *LoginRequestSQLManager) updateLoginRequest(challenge string, update func(r *LoginRequest)) error {
+	r, err := m.GetLoginRequest(challenge)
+	if err != nil {
+		return err
+	}
+
+	update(r)
+	d, err := newSQLLoginRequest(r)
+	if err != nil {
+		return err
+	}
+
+	if _, err := m.DB.NamedExec(
+		"UPDATE hydra_oauth2_login_request SET subject=:subject, login=:login, deny_error=:deny_error, acr=:acr, amr=:amr, auth_time=:auth_time WHERE challenge=:challenge",
+		d,
+	); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func (m *LoginRequestSQLManager) DeleteLoginRequest(challenge string) error {
+	if _, err := m.DB.Exec(m.DB.Rebind("DELETE FROM hydra_oauth2_login_request WHERE challenge=?"), challenge); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func (m *LoginRequestSQLManager) DeleteSubjectLoginRequests(subject string) (int, error) {
+	res, err := m.DB.Exec(m.DB.Rebind("DELETE FROM hydra_oauth2_login_request WHERE subject=?"), subject)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	return int(n), nil
+}
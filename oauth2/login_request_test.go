@@ -0,0 +1,134 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2_test
+
+import (
+	"fmt"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/ory/hydra/integration"
+	. "github.com/ory/hydra/oauth2"
+	"github.com/ory/hydra/pkg"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var loginRequestManagers = map[string]LoginRequestManager{
+	"memory": NewLoginRequestMemoryManager(),
+}
+
+func connectToMySQLLoginRequests() {
+	s := &LoginRequestSQLManager{DB: integration.ConnectToMySQL()}
+	if _, err := s.CreateSchemas(); err != nil {
+		log.Fatalf("Could not create mysql schema: %v", err)
+	}
+
+	loginRequestManagers["mysql"] = s
+}
+
+func connectToPGLoginRequests() {
+	s := &LoginRequestSQLManager{DB: integration.ConnectToPostgres()}
+	if _, err := s.CreateSchemas(); err != nil {
+		log.Fatalf("Could not create postgres schema: %v", err)
+	}
+
+	loginRequestManagers["postgres"] = s
+}
+
+func TestLoginRequestManagers(t *testing.T) {
+	for k, m := range loginRequestManagers {
+		t.Run(fmt.Sprintf("case=%s", k), func(t *testing.T) {
+			expired := &LoginRequest{
+				Challenge:       uuid.New(),
+				Verifier:        uuid.New(),
+				CSRF:            uuid.New(),
+				ClientID:        "photos",
+				RequestedScopes: []string{"openid"},
+				RequestURL:      "https://hydra.localhost/oauth2/auth?client_id=photos",
+				ExpiresAt:       time.Now().UTC().Add(-time.Minute),
+				RequestedAt:     time.Now().UTC().Add(-time.Hour),
+			}
+			require.NoError(t, m.CreateLoginRequest(expired))
+
+			request := &LoginRequest{
+				Challenge:       uuid.New(),
+				Verifier:        uuid.New(),
+				CSRF:            uuid.New(),
+				ClientID:        "photos",
+				RequestedScopes: []string{"openid", "photos"},
+				ACRValues:       []string{"urn:mace:incommon:iap:silver"},
+				RequestURL:      "https://hydra.localhost/oauth2/auth?client_id=photos",
+				Skip:            true,
+				Subject:         "peter",
+				ExpiresAt:       time.Now().UTC().Add(time.Minute).Round(time.Second),
+				RequestedAt:     time.Now().UTC().Round(time.Second),
+			}
+			require.NoError(t, m.CreateLoginRequest(request))
+
+			_, err := m.GetLoginRequest(expired.Challenge)
+			assert.Equal(t, pkg.ErrNotFound, errors.Cause(err))
+			_, err = m.GetLoginRequestByVerifier(uuid.New())
+			assert.Equal(t, pkg.ErrNotFound, errors.Cause(err))
+
+			got, err := m.GetLoginRequest(request.Challenge)
+			require.NoError(t, err)
+			assert.EqualValues(t, request, got)
+			assert.False(t, got.IsHandled())
+
+			authTime := time.Now().UTC().Round(time.Second)
+			require.NoError(t, m.AcceptLoginRequest(request.Challenge, &AcceptLoginRequestPayload{
+				Subject:  "peter",
+				ACR:      "urn:mace:incommon:iap:silver",
+				AMR:      []string{"pwd", "otp"},
+				AuthTime: authTime,
+			}))
+
+			got, err = m.GetLoginRequestByVerifier(request.Verifier)
+			require.NoError(t, err)
+			assert.Equal(t, request.Challenge, got.Challenge)
+			assert.True(t, got.IsHandled())
+			assert.Equal(t, LoginRequestAccepted, got.Login)
+			assert.Equal(t, "peter", got.Subject)
+			assert.Equal(t, "urn:mace:incommon:iap:silver", got.ACR)
+			assert.Equal(t, []string{"pwd", "otp"}, got.AMR)
+			assert.Equal(t, authTime, got.AuthTime)
+
+			require.NoError(t, m.RejectLoginRequest(request.Challenge, &RequestDeniedError{Name: "login_required", Description: "The user cancelled the login"}))
+			got, err = m.GetLoginRequest(request.Challenge)
+			require.NoError(t, err)
+			assert.Equal(t, LoginRequestRejected, got.Login)
+			assert.Equal(t, &RequestDeniedError{Name: "login_required", Description: "The user cancelled the login"}, got.DenyError)
+
+			assert.Equal(t, pkg.ErrNotFound, errors.Cause(m.AcceptLoginRequest(uuid.New(), &AcceptLoginRequestPayload{Subject: "peter"})))
+
+			require.NoError(t, m.DeleteLoginRequest(request.Challenge))
+			require.NoError(t, m.DeleteLoginRequest(request.Challenge))
+			_, err = m.GetLoginRequest(request.Challenge)
+			assert.Equal(t, pkg.ErrNotFound, errors.Cause(err))
+
+			request.Challenge, request.Verifier, request.CSRF = uuid.New(), uuid.New(), uuid.New()
+			require.NoError(t, m.CreateLoginRequest(request))
+			n, err := m.DeleteSubjectLoginRequests("peter")
+			require.NoError(t, err)
+			assert.Equal(t, 1, n)
+			_, err = m.GetLoginRequest(request.Challenge)
+			assert.Equal(t, pkg.ErrNotFound, errors.Cause(err))
+		})
+	}
+}
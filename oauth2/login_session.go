@@ -0,0 +1,42 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+// LoginSession is the session a subject started in a user agent by accepting a consent request. Its id is sent to
+// clients in the "sid" claim of ID tokens and logout tokens, so they can tell which of their sessions ended when the
+// subject logs out.
+type LoginSession struct {
+	ID      string
+	Subject string
+
+	// ClientIDs are the ids of all clients which were issued tokens in this session.
+	ClientIDs []string
+}
+
+// LoginSessionManager keeps track of login sessions and the clients which took part in them.
+type LoginSessionManager interface {
+	// AddLoginSessionClient adds the client to the login session of the subject, starting the session if it does not
+	// exist yet.
+	AddLoginSessionClient(id, subject, clientID string) error
+
+	// GetLoginSession returns the login session or pkg.ErrNotFound if it does not exist.
+	GetLoginSession(id string) (*LoginSession, error)
+
+	// DeleteLoginSession ends the login session, if it exists.
+	DeleteLoginSession(id string) error
+
+	// DeleteSubjectLoginSessions ends all login sessions of the subject and returns how many were ended.
+	DeleteSubjectLoginSessions(subject string) (int, error)
+}
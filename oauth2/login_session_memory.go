@@ -0,0 +1,86 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/ory/hydra/pkg"
+	"github.com/pkg/errors"
+)
+
+type LoginSessionMemoryManager struct {
+	sessions map[string]LoginSession
+	sync.RWMutex
+}
+
+func NewLoginSessionMemoryManager() *LoginSessionMemoryManager {
+	return &LoginSessionMemoryManager{sessions: map[string]LoginSession{}}
+}
+
+func (m *LoginSessionMemoryManager) AddLoginSessionClient(id, subject, clientID string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	session, ok := m.sessions[id]
+	if !ok {
+		session = LoginSession{ID: id, Subject: subject}
+	}
+
+	for _, c := range session.ClientIDs {
+		if c == clientID {
+			return nil
+		}
+	}
+
+	session.ClientIDs = append(append([]string{}, session.ClientIDs...), clientID)
+	sort.Strings(session.ClientIDs)
+	m.sessions[id] = session
+	return nil
+}
+
+func (m *LoginSessionMemoryManager) GetLoginSession(id string) (*LoginSession, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	session, ok := m.sessions[id]
+	if !ok {
+		return nil, errors.WithStack(pkg.ErrNotFound)
+	}
+	return &session, nil
+}
+
+func (m *LoginSessionMemoryManager) DeleteLoginSession(id string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	delete(m.sessions, id)
+	return nil
+}
+
+func (m *LoginSessionMemoryManager) DeleteSubjectLoginSessions(subject string) (int, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	var n int
+	for id, session := range m.sessions {
+		if session.Subject == subject {
+			delete(m.sessions, id)
+			n++
+		}
+	}
+	return n, nil
+}
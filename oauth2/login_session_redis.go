@@ -0,0 +1,119 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"sort"
+
+	"github.com/go-redis/redis"
+	"github.com/ory/hydra/pkg"
+	"github.com/pkg/errors"
+)
+
+// redisLoginSessionKey is a hash containing the subject of the login session, indexed by the ids of the clients which
+// took part in it.
+func redisLoginSessionKey(id string) string {
+	return "hydra:login:session:" + id
+}
+
+// redisLoginSessionSubjectKey is a set containing the ids of all login sessions of the subject.
+func redisLoginSessionSubjectKey(subject string) string {
+	return "hydra:login:session-subject:" + subject
+}
+
+type LoginSessionRedisManager struct {
+	DB *redis.Client
+}
+
+func (m *LoginSessionRedisManager) AddLoginSessionClient(id, subject, clientID string) error {
+	if _, err := m.DB.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.HSetNX(redisLoginSessionKey(id), clientID, subject)
+		pipe.SAdd(redisLoginSessionSubjectKey(subject), id)
+		return nil
+	}); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func (m *LoginSessionRedisManager) GetLoginSession(id string) (*LoginSession, error) {
+	clients, err := m.DB.HGetAll(redisLoginSessionKey(id)).Result()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	} else if len(clients) == 0 {
+		return nil, errors.WithStack(pkg.ErrNotFound)
+	}
+
+	session := &LoginSession{ID: id}
+	for clientID := range clients {
+		session.ClientIDs = append(session.ClientIDs, clientID)
+	}
+	sort.Strings(session.ClientIDs)
+	session.Subject = clients[session.ClientIDs[0]]
+	return session, nil
+}
+
+func (m *LoginSessionRedisManager) DeleteLoginSession(id string) error {
+	clients, err := m.DB.HGetAll(redisLoginSessionKey(id)).Result()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if _, err := m.DB.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.Del(redisLoginSessionKey(id))
+		for _, subject := range clients {
+			pipe.SRem(redisLoginSessionSubjectKey(subject), id)
+		}
+		return nil
+	}); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func (m *LoginSessionRedisManager) DeleteSubjectLoginSessions(subject string) (int, error) {
+	ids, err := m.DB.SMembers(redisLoginSessionSubjectKey(subject)).Result()
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	var n int
+	for _, id := range ids {
+		clients, err := m.DB.HGetAll(redisLoginSessionKey(id)).Result()
+		if err != nil {
+			return n, errors.WithStack(err)
+		}
+
+		var fields []string
+		for clientID, s := range clients {
+			if s == subject {
+				fields = append(fields, clientID)
+			}
+		}
+		if len(fields) == 0 {
+			continue
+		}
+
+		if err := m.DB.HDel(redisLoginSessionKey(id), fields...).Err(); err != nil {
+			return n, errors.WithStack(err)
+		}
+		n++
+	}
+
+	if err := m.DB.Del(redisLoginSessionSubjectKey(subject)).Err(); err != nil {
+		return n, errors.WithStack(err)
+	}
+	return n, nil
+}
@@ -0,0 +1,124 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/ory/hydra/pkg"
+	"github.com/pkg/errors"
+	"github.com/rubenv/sql-migrate"
+)
+
+var loginSessionMigrations = &migrate.MemoryMigrationSource{
+	Migrations: []*migrate.Migration{
+		{
+			Id: "1",
+			Up: []string{
+				`CREATE TABLE IF NOT EXISTS hydra_oauth2_login_session (
+	id			varchar(36) NOT NULL,
+	subject		varchar(255) NOT NULL,
+	client_id	varchar(255) NOT NULL,
+	created_at	timestamp NOT NULL,
+	PRIMARY KEY (id, client_id)
+)`,
+			},
+			Down: []string{
+				"DROP TABLE hydra_oauth2_login_session",
+			},
+		},
+	},
+}
+
+type LoginSessionSQLManager struct {
+	DB *sqlx.DB
+}
+
+type sqlLoginSessionClient struct {
+	ID        string    `db:"id"`
+	Subject   string    `db:"subject"`
+	ClientID  string    `db:"client_id"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// Migrations returns the SQL migrations embedded in the binary.
+func (m *LoginSessionSQLManager) Migrations() *migrate.MemoryMigrationSource {
+	return loginSessionMigrations
+}
+
+func (m *LoginSessionSQLManager) CreateSchemas() (int, error) {
+	migrate.SetTable("hydra_oauth2_login_session_migration")
+	n, err := migrate.Exec(m.DB.DB, m.DB.DriverName(), loginSessionMigrations, migrate.Up)
+	if err != nil {
+		return 0, errors.Wrapf(err, "Could not migrate sql schema, applied %d migrations", n)
+	}
+	return n, nil
+}
+
+func (m *LoginSessionSQLManager) AddLoginSessionClient(id, subject, clientID string) error {
+	return pkg.SQLTransaction(m.DB, func(tx *sqlx.Tx) error {
+		var d sqlLoginSessionClient
+		err := tx.Get(&d, tx.Rebind("SELECT * FROM hydra_oauth2_login_session WHERE id=? AND client_id=?"), id, clientID)
+		if err == nil {
+			return nil
+		} else if err != sql.ErrNoRows {
+			return errors.WithStack(err)
+		}
+
+		if _, err := tx.Exec(
+			tx.Rebind("INSERT INTO hydra_oauth2_login_session (id, subject, client_id, created_at) VALUES (?, ?, ?, ?)"),
+			id, subject, clientID, time.Now().UTC(),
+		); err != nil {
+			return errors.WithStack(err)
+		}
+		return nil
+	})
+}
+
+func (m *LoginSessionSQLManager) GetLoginSession(id string) (*LoginSession, error) {
+	var d []sqlLoginSessionClient
+	if err := m.DB.Select(&d, m.DB.Rebind("SELECT * FROM hydra_oauth2_login_session WHERE id=? ORDER BY client_id"), id); err != nil {
+		return nil, errors.WithStack(err)
+	} else if len(d) == 0 {
+		return nil, errors.WithStack(pkg.ErrNotFound)
+	}
+
+	session := &LoginSession{ID: id, Subject: d[0].Subject}
+	for _, c := range d {
+		session.ClientIDs = append(session.ClientIDs, c.ClientID)
+	}
+	return session, nil
+}
+
+func (m *LoginSessionSQLManager) DeleteLoginSession(id string) error {
+	if _, err := m.DB.Exec(m.DB.Rebind("DELETE FROM hydra_oauth2_login_session WHERE id=?"), id); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func (m *LoginSessionSQLManager) DeleteSubjectLoginSessions(subject string) (int, error) {
+	var n int
+	if err := m.DB.Get(&n, m.DB.Rebind("SELECT COUNT(DISTINCT id) FROM hydra_oauth2_login_session WHERE subject=?"), subject); err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	if _, err := m.DB.Exec(m.DB.Rebind("DELETE FROM hydra_oauth2_login_session WHERE subject=?"), subject); err != nil {
+		return 0, errors.WithStack(err)
+	}
+	return n, nil
+}
@@ -0,0 +1,89 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2_test
+
+import (
+	"fmt"
+	"log"
+	"testing"
+
+	"github.com/ory/hydra/integration"
+	. "github.com/ory/hydra/oauth2"
+	"github.com/ory/hydra/pkg"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var loginSessionManagers = map[string]LoginSessionManager{
+	"memory": NewLoginSessionMemoryManager(),
+}
+
+func connectToMySQLLoginSessions() {
+	s := &LoginSessionSQLManager{DB: integration.ConnectToMySQL()}
+	if _, err := s.CreateSchemas(); err != nil {
+		log.Fatalf("Could not create mysql schema: %v", err)
+	}
+
+	loginSessionManagers["mysql"] = s
+}
+
+func connectToPGLoginSessions() {
+	s := &LoginSessionSQLManager{DB: integration.ConnectToPostgres()}
+	if _, err := s.CreateSchemas(); err != nil {
+		log.Fatalf("Could not create postgres schema: %v", err)
+	}
+
+	loginSessionManagers["postgres"] = s
+}
+
+func TestLoginSessionManagers(t *testing.T) {
+	for k, m := range loginSessionManagers {
+		t.Run(fmt.Sprintf("case=%s", k), func(t *testing.T) {
+			id, other := uuid.New(), uuid.New()
+
+			_, err := m.GetLoginSession(id)
+			assert.Equal(t, pkg.ErrNotFound, errors.Cause(err))
+
+			require.NoError(t, m.AddLoginSessionClient(id, "peter", "photos"))
+			require.NoError(t, m.AddLoginSessionClient(id, "peter", "contacts"))
+			require.NoError(t, m.AddLoginSessionClient(id, "peter", "photos"))
+			require.NoError(t, m.AddLoginSessionClient(other, "alice", "photos"))
+
+			session, err := m.GetLoginSession(id)
+			require.NoError(t, err)
+			assert.Equal(t, id, session.ID)
+			assert.Equal(t, "peter", session.Subject)
+			assert.Equal(t, []string{"contacts", "photos"}, session.ClientIDs)
+
+			require.NoError(t, m.DeleteLoginSession(id))
+			require.NoError(t, m.DeleteLoginSession(id))
+			_, err = m.GetLoginSession(id)
+			assert.Equal(t, pkg.ErrNotFound, errors.Cause(err))
+
+			session, err = m.GetLoginSession(other)
+			require.NoError(t, err)
+			assert.Equal(t, []string{"photos"}, session.ClientIDs)
+
+			require.NoError(t, m.AddLoginSessionClient(id, "alice", "contacts"))
+			n, err := m.DeleteSubjectLoginSessions("alice")
+			require.NoError(t, err)
+			assert.Equal(t, 2, n)
+			_, err = m.GetLoginSession(other)
+			assert.Equal(t, pkg.ErrNotFound, errors.Cause(err))
+		})
+	}
+}
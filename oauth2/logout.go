@@ -0,0 +1,207 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ory/fosite"
+	"github.com/ory/hydra/client"
+	"github.com/ory/hydra/pkg"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/square/go-jose"
+)
+
+// BackChannelLogoutEvent is the member of the "events" claim which identifies a JSON Web Token as a logout token.
+const BackChannelLogoutEvent = "http://schemas.openid.net/event/backchannel-logout"
+
+// IDTokenHint holds the claims of a valid ID token sent to the end session endpoint.
+type IDTokenHint struct {
+	Subject   string
+	ClientID  string
+	SessionID string
+}
+
+// Logout ends login sessions and notifies the clients which took part in them using OpenID Connect front-channel and
+// back-channel logout. Logout tokens are signed and ID token hints are verified using the OpenID Connect key.
+type Logout struct {
+	Sessions LoginSessionManager
+	Clients  fosite.ClientManager
+
+	PrivateKey crypto.Signer
+	Algorithm  jose.SignatureAlgorithm
+	KeyID      string
+
+	// HTTPClient sends logout tokens to back-channel logout URIs.
+	HTTPClient *http.Client
+
+	L logrus.FieldLogger
+}
+
+// VerifyIDTokenHint verifies the signature of an ID token issued by this server and returns its claims. Expired ID
+// tokens are accepted, because logging out is most likely after the ID token expired.
+func (l *Logout) VerifyIDTokenHint(token string) (*IDTokenHint, error) {
+	signed, err := jose.ParseSigned(token)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	payload, err := signed.Verify(l.PrivateKey.Public())
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var claims struct {
+		Subject   string      `json:"sub"`
+		Audience  interface{} `json:"aud"`
+		SessionID string      `json:"sid"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	hint := &IDTokenHint{Subject: claims.Subject, SessionID: claims.SessionID}
+	switch aud := claims.Audience.(type) {
+	case string:
+		hint.ClientID = aud
+	case []interface{}:
+		if len(aud) == 1 {
+			hint.ClientID, _ = aud[0].(string)
+		}
+	}
+
+	if hint.Subject == "" || hint.ClientID == "" {
+		return nil, errors.New("The ID token hint must contain a subject and a single audience")
+	}
+	return hint, nil
+}
+
+// LogoutToken returns a logout token for the client telling it that the login session sid of subject ended.
+func (l *Logout) LogoutToken(issuer, clientID, subject, sid string) (string, error) {
+	payload, err := json.Marshal(map[string]interface{}{
+		"iss":    issuer,
+		"aud":    clientID,
+		"iat":    time.Now().UTC().Unix(),
+		"jti":    uuid.New(),
+		"sub":    subject,
+		"sid":    sid,
+		"events": map[string]interface{}{BackChannelLogoutEvent: map[string]interface{}{}},
+	})
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	options := new(jose.SignerOptions).WithType("JWT").WithHeader("kid", l.KeyID)
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: l.Algorithm, Key: l.PrivateKey}, options)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	signed, err := signer.Sign(payload)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	token, err := signed.CompactSerialize()
+	return token, errors.WithStack(err)
+}
+
+// EndSession ends the login session sid and sends logout tokens to the back-channel logout URIs of its clients. It
+// returns the front-channel logout URIs of the clients, which the user agent must load to finish the logout. Failed
+// back-channel notifications are logged, they do not keep the session from ending.
+func (l *Logout) EndSession(ctx context.Context, issuer, sid string) ([]string, error) {
+	if sid == "" {
+		return nil, nil
+	}
+
+	session, err := l.Sessions.GetLoginSession(sid)
+	if errors.Cause(err) == pkg.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := l.Sessions.DeleteLoginSession(sid); err != nil {
+		return nil, err
+	}
+
+	var frontChannel []string
+	var wg sync.WaitGroup
+	for _, id := range session.ClientIDs {
+		fc, err := l.Clients.GetClient(ctx, id)
+		if errors.Cause(err) == pkg.ErrNotFound {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		c, ok := fc.(*client.Client)
+		if !ok {
+			continue
+		}
+
+		if c.FrontChannelLogoutURI != "" {
+			u, err := url.Parse(c.FrontChannelLogoutURI)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			q := u.Query()
+			q.Set("iss", issuer)
+			q.Set("sid", sid)
+			u.RawQuery = q.Encode()
+			frontChannel = append(frontChannel, u.String())
+		}
+
+		if c.BackChannelLogoutURI != "" {
+			wg.Add(1)
+			go func(c *client.Client) {
+				defer wg.Done()
+				if err := l.notifyBackChannel(issuer, c, session.Subject, sid); err != nil {
+					l.L.WithError(err).WithField("client", c.ID).Warnln("Could not notify the back-channel logout URI of the client")
+				}
+			}(c)
+		}
+	}
+	wg.Wait()
+
+	return frontChannel, nil
+}
+
+func (l *Logout) notifyBackChannel(issuer string, c *client.Client, subject, sid string) error {
+	token, err := l.LogoutToken(issuer, c.ID, subject, sid)
+	if err != nil {
+		return err
+	}
+
+	res, err := l.HTTPClient.Post(c.BackChannelLogoutURI, "application/x-www-form-urlencoded", strings.NewReader(url.Values{"logout_token": {token}}.Encode()))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return errors.Errorf("Expected a 2xx status code but got %d", res.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/ory/fosite"
+	"github.com/ory/hydra/client"
+	"github.com/ory/hydra/pkg"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/square/go-jose"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogout(t *testing.T) {
+	key := pkg.MustINSECURELOWENTROPYRSAKEYFORTEST()
+
+	tokens := make(chan string, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokens <- r.PostFormValue("logout_token")
+	}))
+	defer ts.Close()
+
+	clients := client.NewMemoryManager(&fosite.BCrypt{WorkFactor: 4})
+	require.NoError(t, clients.CreateClient(&client.Client{
+		ID:                    "photos",
+		FrontChannelLogoutURI: "https://photos.localhost/logout?tenant=foo",
+	}))
+	require.NoError(t, clients.CreateClient(&client.Client{
+		ID:                   "contacts",
+		BackChannelLogoutURI: ts.URL,
+	}))
+
+	sessions := NewLoginSessionMemoryManager()
+	l := &Logout{
+		Sessions:   sessions,
+		Clients:    clients,
+		PrivateKey: key,
+		Algorithm:  jose.RS256,
+		KeyID:      "public:foo",
+		HTTPClient: http.DefaultClient,
+		L:          logrus.New(),
+	}
+
+	t.Run("case=verifies id token hints", func(t *testing.T) {
+		signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: key}, nil)
+		require.NoError(t, err)
+
+		sign := func(claims map[string]interface{}) string {
+			payload, err := json.Marshal(claims)
+			require.NoError(t, err)
+			signed, err := signer.Sign(payload)
+			require.NoError(t, err)
+			token, err := signed.CompactSerialize()
+			require.NoError(t, err)
+			return token
+		}
+
+		hint, err := l.VerifyIDTokenHint(sign(map[string]interface{}{"sub": "peter", "aud": []string{"photos"}, "sid": "session"}))
+		require.NoError(t, err)
+		assert.Equal(t, &IDTokenHint{Subject: "peter", ClientID: "photos", SessionID: "session"}, hint)
+
+		_, err = l.VerifyIDTokenHint(sign(map[string]interface{}{"sub": "peter", "aud": []string{"photos", "contacts"}}))
+		assert.Error(t, err)
+
+		otherKey, err := rsa.GenerateKey(rand.Reader, 1024)
+		require.NoError(t, err)
+		other, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: otherKey}, nil)
+		require.NoError(t, err)
+		signed, err := other.Sign([]byte(`{"sub":"peter","aud":"photos"}`))
+		require.NoError(t, err)
+		token, err := signed.CompactSerialize()
+		require.NoError(t, err)
+		_, err = l.VerifyIDTokenHint(token)
+		assert.Error(t, err)
+	})
+
+	t.Run("case=ends sessions and notifies clients", func(t *testing.T) {
+		require.NoError(t, sessions.AddLoginSessionClient("session", "peter", "photos"))
+		require.NoError(t, sessions.AddLoginSessionClient("session", "peter", "contacts"))
+
+		frontChannel, err := l.EndSession(context.Background(), "https://hydra.localhost", "session")
+		require.NoError(t, err)
+		require.Len(t, frontChannel, 1)
+
+		u, err := url.Parse(frontChannel[0])
+		require.NoError(t, err)
+		assert.Equal(t, "foo", u.Query().Get("tenant"))
+		assert.Equal(t, "https://hydra.localhost", u.Query().Get("iss"))
+		assert.Equal(t, "session", u.Query().Get("sid"))
+
+		signed, err := jose.ParseSigned(<-tokens)
+		require.NoError(t, err)
+		assert.Equal(t, "public:foo", signed.Signatures[0].Header.KeyID)
+
+		payload, err := signed.Verify(&key.PublicKey)
+		require.NoError(t, err)
+
+		var claims map[string]interface{}
+		require.NoError(t, json.Unmarshal(payload, &claims))
+		assert.Equal(t, "https://hydra.localhost", claims["iss"])
+		assert.Equal(t, "contacts", claims["aud"])
+		assert.Equal(t, "peter", claims["sub"])
+		assert.Equal(t, "session", claims["sid"])
+		assert.Contains(t, claims["events"], BackChannelLogoutEvent)
+		assert.Nil(t, claims["nonce"])
+
+		_, err = sessions.GetLoginSession("session")
+		assert.Equal(t, pkg.ErrNotFound, errors.Cause(err))
+
+		frontChannel, err = l.EndSession(context.Background(), "https://hydra.localhost", "session")
+		require.NoError(t, err)
+		assert.Empty(t, frontChannel)
+	})
+}
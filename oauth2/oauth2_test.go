@@ -89,7 +89,7 @@ var oauthClientConfig *clientcredentials.Config
 var localWarden, httpClient = hcompose.NewMockFirewall("foo", "app-client", fosite.Arguments{ConsentScope}, &ladon.DefaultPolicy{
 	ID:        "1",
 	Subjects:  []string{"app-client"},
-	Resources: []string{"rn:hydra:oauth2:consent:requests:<.*>"},
+	Resources: []string{"rn:hydra:oauth2:consent:requests:<.*>", "rn:hydra:oauth2:login:requests:<.*>"},
 	Actions:   []string{"get", "accept", "reject"},
 	Effect:    ladon.AllowAccess,
 })
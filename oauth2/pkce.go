@@ -0,0 +1,71 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"context"
+
+	"github.com/ory/fosite"
+	"github.com/ory/hydra/client"
+	"github.com/pkg/errors"
+)
+
+// PKCEEnforcementHandler rejects authorization code flows of clients which are required to use PKCE but do not use it
+// with the S256 code challenge method. The code verifier itself is checked against the code challenge by fosite's
+// PKCE handler.
+type PKCEEnforcementHandler struct {
+	// RequireForPublicClients forces all public clients to use PKCE, in addition to clients with RequirePKCE set.
+	RequireForPublicClients bool
+}
+
+func (h *PKCEEnforcementHandler) requiresPKCE(c fosite.Client) bool {
+	if h.RequireForPublicClients && c.IsPublic() {
+		return true
+	}
+
+	hc, ok := c.(*client.Client)
+	return ok && hc.RequirePKCE
+}
+
+func (h *PKCEEnforcementHandler) HandleAuthorizeEndpointRequest(ctx context.Context, ar fosite.AuthorizeRequester, resp fosite.AuthorizeResponder) error {
+	if !ar.GetResponseTypes().Has("code") || !h.requiresPKCE(ar.GetClient()) {
+		return nil
+	}
+
+	form := ar.GetRequestForm()
+	if form.Get("code_challenge") == "" {
+		return errors.Wrap(fosite.ErrInvalidRequest, "The client must use PKCE, parameter code_challenge is missing")
+	} else if method := form.Get("code_challenge_method"); method != "S256" {
+		return errors.Wrapf(fosite.ErrInvalidRequest, "The client must use the S256 code challenge method but used \"%s\"", method)
+	}
+
+	return nil
+}
+
+func (h *PKCEEnforcementHandler) HandleTokenEndpointRequest(ctx context.Context, requester fosite.AccessRequester) error {
+	if !requester.GetGrantTypes().Exact("authorization_code") || !h.requiresPKCE(requester.GetClient()) {
+		return errors.WithStack(fosite.ErrUnknownRequest)
+	}
+
+	if requester.GetRequestForm().Get("code_verifier") == "" {
+		return errors.Wrap(fosite.ErrInvalidRequest, "The client must use PKCE, parameter code_verifier is missing")
+	}
+
+	return errors.WithStack(fosite.ErrUnknownRequest)
+}
+
+func (h *PKCEEnforcementHandler) PopulateTokenEndpointResponse(ctx context.Context, requester fosite.AccessRequester, responder fosite.AccessResponder) error {
+	return errors.WithStack(fosite.ErrUnknownRequest)
+}
@@ -0,0 +1,97 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/ory/fosite"
+	"github.com/ory/hydra/client"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPKCEEnforcementHandler(t *testing.T) {
+	h := &PKCEEnforcementHandler{RequireForPublicClients: true}
+	confidential := &client.Client{ID: "confidential"}
+	required := &client.Client{ID: "required", RequirePKCE: true}
+	public := &client.Client{ID: "public", Public: true}
+
+	t.Run("endpoint=authorize", func(t *testing.T) {
+		for k, tc := range []struct {
+			c             fosite.Client
+			responseTypes fosite.Arguments
+			form          url.Values
+			expectErr     bool
+		}{
+			{c: confidential, responseTypes: fosite.Arguments{"code"}, form: url.Values{}},
+			{c: required, responseTypes: fosite.Arguments{"token"}, form: url.Values{}},
+			{c: required, responseTypes: fosite.Arguments{"code"}, form: url.Values{}, expectErr: true},
+			{c: public, responseTypes: fosite.Arguments{"code", "id_token"}, form: url.Values{}, expectErr: true},
+			{c: required, responseTypes: fosite.Arguments{"code"}, form: url.Values{"code_challenge": {"foo"}}, expectErr: true},
+			{c: required, responseTypes: fosite.Arguments{"code"}, form: url.Values{"code_challenge": {"foo"}, "code_challenge_method": {"plain"}}, expectErr: true},
+			{c: required, responseTypes: fosite.Arguments{"code"}, form: url.Values{"code_challenge": {"foo"}, "code_challenge_method": {"S256"}}},
+			{c: public, responseTypes: fosite.Arguments{"code"}, form: url.Values{"code_challenge": {"foo"}, "code_challenge_method": {"S256"}}},
+		} {
+			t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+				ar := fosite.NewAuthorizeRequest()
+				ar.Client = tc.c
+				ar.ResponseTypes = tc.responseTypes
+				ar.Form = tc.form
+
+				err := h.HandleAuthorizeEndpointRequest(nil, ar, fosite.NewAuthorizeResponse())
+				if tc.expectErr {
+					assert.Equal(t, fosite.ErrInvalidRequest, errors.Cause(err))
+				} else {
+					assert.NoError(t, err)
+				}
+			})
+		}
+	})
+
+	t.Run("endpoint=token", func(t *testing.T) {
+		for k, tc := range []struct {
+			c         fosite.Client
+			grantType string
+			form      url.Values
+			expectErr error
+		}{
+			{c: confidential, grantType: "authorization_code", form: url.Values{}, expectErr: fosite.ErrUnknownRequest},
+			{c: required, grantType: "refresh_token", form: url.Values{}, expectErr: fosite.ErrUnknownRequest},
+			{c: required, grantType: "authorization_code", form: url.Values{}, expectErr: fosite.ErrInvalidRequest},
+			{c: public, grantType: "authorization_code", form: url.Values{}, expectErr: fosite.ErrInvalidRequest},
+			{c: required, grantType: "authorization_code", form: url.Values{"code_verifier": {"foo"}}, expectErr: fosite.ErrUnknownRequest},
+		} {
+			t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+				ar := fosite.NewAccessRequest(NewSession(""))
+				ar.Client = tc.c
+				ar.GrantTypes = fosite.Arguments{tc.grantType}
+				ar.Form = tc.form
+
+				assert.Equal(t, tc.expectErr, errors.Cause(h.HandleTokenEndpointRequest(nil, ar)))
+			})
+		}
+	})
+
+	t.Run("case=public clients are not forced by default", func(t *testing.T) {
+		ar := fosite.NewAuthorizeRequest()
+		ar.Client = public
+		ar.ResponseTypes = fosite.Arguments{"code"}
+		ar.Form = url.Values{}
+		assert.NoError(t, (&PKCEEnforcementHandler{}).HandleAuthorizeEndpointRequest(nil, ar, fosite.NewAuthorizeResponse()))
+	})
+}
@@ -0,0 +1,109 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/sessions"
+	"github.com/ory/fosite"
+	"github.com/pkg/errors"
+)
+
+// CookieAuthTimeKey holds the time, in seconds since the epoch, the subject of the user agent last authenticated at
+// the consent app.
+const CookieAuthTimeKey = "consent_auth_time"
+
+var supportedPrompts = []string{"none", "login", "consent", "select_account"}
+
+// hasPrompt returns true if the authorization request asks for the prompt value.
+func hasPrompt(ar fosite.AuthorizeRequester, value string) bool {
+	for _, prompt := range strings.Fields(ar.GetRequestForm().Get("prompt")) {
+		if prompt == value {
+			return true
+		}
+	}
+	return false
+}
+
+// maxAge returns the max_age parameter of the authorization request and false if it is not set.
+func maxAge(ar fosite.AuthorizeRequester) (time.Duration, bool) {
+	seconds, err := strconv.ParseInt(ar.GetRequestForm().Get("max_age"), 10, 64)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// validatePrompt rejects authorization requests with unknown prompt values, with prompt=none combined with another
+// value or with a max_age which is not a number of seconds.
+func validatePrompt(ar fosite.AuthorizeRequester) error {
+	prompts := strings.Fields(ar.GetRequestForm().Get("prompt"))
+	for _, prompt := range prompts {
+		if !stringInSlice(prompt, supportedPrompts) {
+			return errors.Wrapf(fosite.ErrInvalidRequest, "Prompt value \"%s\" is not supported", prompt)
+		}
+	}
+
+	if hasPrompt(ar, "none") && len(prompts) > 1 {
+		return errors.Wrap(fosite.ErrInvalidRequest, "Prompt value \"none\" can not be combined with other values")
+	}
+
+	if value := ar.GetRequestForm().Get("max_age"); value != "" {
+		if _, ok := maxAge(ar); !ok {
+			return errors.Wrapf(fosite.ErrInvalidRequest, "Parameter max_age must be a non-negative number of seconds but is \"%s\"", value)
+		}
+	}
+
+	return nil
+}
+
+// validateAuthTime returns login_required if the subject authenticated before the authorization request was made
+// although the client asked for prompt=login, or longer ago than the max_age of the authorization request.
+func validateAuthTime(ar fosite.AuthorizeRequester, authTime, requestedAt time.Time) error {
+	if hasPrompt(ar, "login") && authTime.Before(requestedAt) {
+		return errors.Wrap(fosite.ErrLoginRequired, "The client asked for prompt=login but the subject did not authenticate again")
+	}
+
+	if age, ok := maxAge(ar); ok && time.Now().UTC().After(authTime.Add(age)) {
+		return errors.Wrapf(fosite.ErrLoginRequired, "The subject authenticated more than max_age=%d seconds ago", int64(age/time.Second))
+	}
+
+	return nil
+}
+
+// cookieAuthTime returns the time the subject of the user agent last authenticated and false if it is not known.
+func cookieAuthTime(cookie *sessions.Session) (time.Time, bool) {
+	seconds, ok := cookie.Values[CookieAuthTimeKey].(int64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(seconds, 0).UTC(), true
+}
+
+// interactionRequired returns the error sent to clients which asked for prompt=none if the consent app would have to
+// be shown to the user: login_required if the subject of the user agent is unknown or has to authenticate again and
+// consent_required otherwise.
+func interactionRequired(ar fosite.AuthorizeRequester, cookie *sessions.Session) error {
+	authTime, ok := cookieAuthTime(cookie)
+	if !ok {
+		return errors.Wrap(fosite.ErrLoginRequired, "The client asked for prompt=none but the user agent is not authenticated")
+	} else if age, ok := maxAge(ar); ok && time.Now().UTC().After(authTime.Add(age)) {
+		return errors.Wrapf(fosite.ErrLoginRequired, "The client asked for prompt=none but the subject authenticated more than max_age=%d seconds ago", int64(age/time.Second))
+	}
+	return errors.Wrap(fosite.ErrConsentRequired, "The client asked for prompt=none but consent has not been given yet")
+}
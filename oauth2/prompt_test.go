@@ -0,0 +1,72 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gorilla/sessions"
+	"github.com/ory/fosite"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidatePrompt(t *testing.T) {
+	for k, tc := range []struct {
+		form      url.Values
+		expectErr bool
+	}{
+		{form: url.Values{}},
+		{form: url.Values{"prompt": {"none"}}},
+		{form: url.Values{"prompt": {"login consent"}}},
+		{form: url.Values{"prompt": {"select_account"}}},
+		{form: url.Values{"prompt": {"none login"}}, expectErr: true},
+		{form: url.Values{"prompt": {"foo"}}, expectErr: true},
+		{form: url.Values{"max_age": {"0"}}},
+		{form: url.Values{"max_age": {"3600"}}},
+		{form: url.Values{"max_age": {"-1"}}, expectErr: true},
+		{form: url.Values{"max_age": {"1h"}}, expectErr: true},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			err := validatePrompt(&fosite.AuthorizeRequest{Request: fosite.Request{Form: tc.form}})
+			if tc.expectErr {
+				assert.Equal(t, fosite.ErrInvalidRequest, errors.Cause(err))
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestInteractionRequired(t *testing.T) {
+	for k, tc := range []struct {
+		form     url.Values
+		values   map[interface{}]interface{}
+		expected error
+	}{
+		{form: url.Values{"prompt": {"none"}}, values: map[interface{}]interface{}{}, expected: fosite.ErrLoginRequired},
+		{form: url.Values{"prompt": {"none"}}, values: map[interface{}]interface{}{CookieAuthTimeKey: time.Now().Add(-time.Hour).Unix()}, expected: fosite.ErrConsentRequired},
+		{form: url.Values{"prompt": {"none"}, "max_age": {"7200"}}, values: map[interface{}]interface{}{CookieAuthTimeKey: time.Now().Add(-time.Hour).Unix()}, expected: fosite.ErrConsentRequired},
+		{form: url.Values{"prompt": {"none"}, "max_age": {"60"}}, values: map[interface{}]interface{}{CookieAuthTimeKey: time.Now().Add(-time.Hour).Unix()}, expected: fosite.ErrLoginRequired},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			err := interactionRequired(&fosite.AuthorizeRequest{Request: fosite.Request{Form: tc.form}}, &sessions.Session{Values: tc.values})
+			assert.Equal(t, tc.expected, errors.Cause(err))
+		})
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"net/url"
+	"time"
+)
+
+// PushedAuthorizationRequest holds the parameters of an authorization request a client pushed to the pushed
+// authorization request endpoint. The authorization request refers to it using the request URI RequestURIPrefix + ID.
+type PushedAuthorizationRequest struct {
+	ID        string
+	ClientID  string
+	Form      url.Values
+	ExpiresAt time.Time
+}
+
+// PushedAuthorizationRequestManager stores pushed authorization requests until they are used or expire.
+type PushedAuthorizationRequestManager interface {
+	// CreatePushedAuthorizationRequest stores the request and removes all expired requests.
+	CreatePushedAuthorizationRequest(r *PushedAuthorizationRequest) error
+
+	// GetPushedAuthorizationRequest returns the request or pkg.ErrNotFound if it does not exist. Expired requests may
+	// still be returned.
+	GetPushedAuthorizationRequest(id string) (*PushedAuthorizationRequest, error)
+
+	// DeletePushedAuthorizationRequest removes the request, if it exists.
+	DeletePushedAuthorizationRequest(id string) error
+}
@@ -0,0 +1,78 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/ory/hydra/pkg"
+	"github.com/pkg/errors"
+)
+
+type PushedAuthorizationRequestMemoryManager struct {
+	requests map[string]PushedAuthorizationRequest
+	sync.RWMutex
+}
+
+func NewPushedAuthorizationRequestMemoryManager() *PushedAuthorizationRequestMemoryManager {
+	return &PushedAuthorizationRequestMemoryManager{requests: map[string]PushedAuthorizationRequest{}}
+}
+
+func (m *PushedAuthorizationRequestMemoryManager) CreatePushedAuthorizationRequest(r *PushedAuthorizationRequest) error {
+	m.Lock()
+	defer m.Unlock()
+
+	now := time.Now().UTC()
+	for id, request := range m.requests {
+		if request.ExpiresAt.Before(now) {
+			delete(m.requests, id)
+		}
+	}
+
+	request := *r
+	request.Form = copyValues(r.Form)
+	m.requests[r.ID] = request
+	return nil
+}
+
+func (m *PushedAuthorizationRequestMemoryManager) GetPushedAuthorizationRequest(id string) (*PushedAuthorizationRequest, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	request, ok := m.requests[id]
+	if !ok {
+		return nil, errors.WithStack(pkg.ErrNotFound)
+	}
+	request.Form = copyValues(request.Form)
+	return &request, nil
+}
+
+func (m *PushedAuthorizationRequestMemoryManager) DeletePushedAuthorizationRequest(id string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	delete(m.requests, id)
+	return nil
+}
+
+func copyValues(values url.Values) url.Values {
+	c := url.Values{}
+	for k, v := range values {
+		c[k] = append([]string{}, v...)
+	}
+	return c
+}
@@ -0,0 +1,88 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"encoding/json"
+	"net/url"
+
+	"github.com/go-redis/redis"
+	"github.com/ory/hydra/pkg"
+	"github.com/pkg/errors"
+)
+
+// redisPushedRequestKey holds the JSON encoded pushed authorization request. It expires together with the request.
+func redisPushedRequestKey(id string) string {
+	return "hydra:oauth2:pushed-request:" + id
+}
+
+type PushedAuthorizationRequestRedisManager struct {
+	DB *redis.Client
+}
+
+func (m *PushedAuthorizationRequestRedisManager) CreatePushedAuthorizationRequest(r *PushedAuthorizationRequest) error {
+	out, err := json.Marshal(&sqlPushedRequest{
+		ID:        r.ID,
+		ClientID:  r.ClientID,
+		Form:      r.Form.Encode(),
+		ExpiresAt: r.ExpiresAt.UTC(),
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	// Expired requests are removed by Redis.
+	if _, err := m.DB.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.Set(redisPushedRequestKey(r.ID), string(out), 0)
+		pipe.PExpireAt(redisPushedRequestKey(r.ID), r.ExpiresAt)
+		return nil
+	}); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func (m *PushedAuthorizationRequestRedisManager) GetPushedAuthorizationRequest(id string) (*PushedAuthorizationRequest, error) {
+	out, err := m.DB.Get(redisPushedRequestKey(id)).Bytes()
+	if err == redis.Nil {
+		return nil, errors.WithStack(pkg.ErrNotFound)
+	} else if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var d sqlPushedRequest
+	if err := json.Unmarshal(out, &d); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	form, err := url.ParseQuery(d.Form)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &PushedAuthorizationRequest{
+		ID:        d.ID,
+		ClientID:  d.ClientID,
+		Form:      form,
+		ExpiresAt: d.ExpiresAt.UTC(),
+	}, nil
+}
+
+func (m *PushedAuthorizationRequestRedisManager) DeletePushedAuthorizationRequest(id string) error {
+	if err := m.DB.Del(redisPushedRequestKey(id)).Err(); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"database/sql"
+	"net/url"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/ory/hydra/pkg"
+	"github.com/pkg/errors"
+	"github.com/rubenv/sql-migrate"
+)
+
+var pushedRequestMigrations = &migrate.MemoryMigrationSource{
+	Migrations: []*migrate.Migration{
+		{
+			Id: "1",
+			Up: []string{
+				`CREATE TABLE IF NOT EXISTS hydra_oauth2_pushed_request (
+	id			varchar(64) NOT NULL PRIMARY KEY,
+	client_id	varchar(255) NOT NULL,
+	form		text NOT NULL,
+	expires_at	timestamp NOT NULL
+)`,
+			},
+			Down: []string{
+				"DROP TABLE hydra_oauth2_pushed_request",
+			},
+		},
+	},
+}
+
+type PushedAuthorizationRequestSQLManager struct {
+	DB *sqlx.DB
+}
+
+type sqlPushedRequest struct {
+	ID        string    `db:"id"`
+	ClientID  string    `db:"client_id"`
+	Form      string    `db:"form"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+// Migrations returns the SQL migrations embedded in the binary.
+func (m *PushedAuthorizationRequestSQLManager) Migrations() *migrate.MemoryMigrationSource {
+	return pushedRequestMigrations
+}
+
+func (m *PushedAuthorizationRequestSQLManager) CreateSchemas() (int, error) {
+	migrate.SetTable("hydra_oauth2_pushed_request_migration")
+	n, err := migrate.Exec(m.DB.DB, m.DB.DriverName(), pushedRequestMigrations, migrate.Up)
+	if err != nil {
+		return 0, errors.Wrapf(err, "Could not migrate sql schema, applied %d migrations", n)
+	}
+	return n, nil
+}
+
+func (m *PushedAuthorizationRequestSQLManager) CreatePushedAuthorizationRequest(r *PushedAuthorizationRequest) error {
+	return pkg.SQLTransaction(m.DB, func(tx *sqlx.Tx) error {
+		if _, err := tx.Exec(tx.Rebind("DELETE FROM hydra_oauth2_pushed_request WHERE expires_at < ?"), time.Now().UTC()); err != nil {
+			return errors.WithStack(err)
+		}
+
+		if _, err := tx.Exec(
+			tx.Rebind("INSERT INTO hydra_oauth2_pushed_request (id, client_id, form, expires_at) VALUES (?, ?, ?, ?)"),
+			r.ID, r.ClientID, r.Form.Encode(), r.ExpiresAt.UTC(),
+		); err != nil {
+			return errors.WithStack(err)
+		}
+		return nil
+	})
+}
+
+func (m *PushedAuthorizationRequestSQLManager) GetPushedAuthorizationRequest(id string) (*PushedAuthorizationRequest, error) {
+	var d sqlPushedRequest
+	if err := m.DB.Get(&d, m.DB.Rebind("SELECT * FROM hydra_oauth2_pushed_request WHERE id=?"), id); err == sql.ErrNoRows {
+		return nil, errors.WithStack(pkg.ErrNotFound)
+	} else if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	form, err := url.ParseQuery(d.Form)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &PushedAuthorizationRequest{
+		ID:        d.ID,
+		ClientID:  d.ClientID,
+		Form:      form,
+		ExpiresAt: d.ExpiresAt.UTC(),
+	}, nil
+}
+
+func (m *PushedAuthorizationRequestSQLManager) DeletePushedAuthorizationRequest(id string) error {
+	if _, err := m.DB.Exec(m.DB.Rebind("DELETE FROM hydra_oauth2_pushed_request WHERE id=?"), id); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2_test
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/ory/hydra/integration"
+	. "github.com/ory/hydra/oauth2"
+	"github.com/ory/hydra/pkg"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var pushedRequestManagers = map[string]PushedAuthorizationRequestManager{
+	"memory": NewPushedAuthorizationRequestMemoryManager(),
+}
+
+func connectToMySQLPushedRequests() {
+	s := &PushedAuthorizationRequestSQLManager{DB: integration.ConnectToMySQL()}
+	if _, err := s.CreateSchemas(); err != nil {
+		log.Fatalf("Could not create mysql schema: %v", err)
+	}
+
+	pushedRequestManagers["mysql"] = s
+}
+
+func connectToPGPushedRequests() {
+	s := &PushedAuthorizationRequestSQLManager{DB: integration.ConnectToPostgres()}
+	if _, err := s.CreateSchemas(); err != nil {
+		log.Fatalf("Could not create postgres schema: %v", err)
+	}
+
+	pushedRequestManagers["postgres"] = s
+}
+
+func TestPushedAuthorizationRequestManagers(t *testing.T) {
+	for k, m := range pushedRequestManagers {
+		t.Run(fmt.Sprintf("case=%s", k), func(t *testing.T) {
+			id, expired := uuid.New(), uuid.New()
+
+			_, err := m.GetPushedAuthorizationRequest(id)
+			assert.Equal(t, pkg.ErrNotFound, errors.Cause(err))
+
+			require.NoError(t, m.CreatePushedAuthorizationRequest(&PushedAuthorizationRequest{
+				ID:        expired,
+				ClientID:  "photos",
+				Form:      url.Values{"client_id": {"photos"}},
+				ExpiresAt: time.Now().UTC().Add(-time.Minute),
+			}))
+
+			form := url.Values{"client_id": {"photos"}, "scope": {"openid offline"}, "state": {"some-state"}}
+			require.NoError(t, m.CreatePushedAuthorizationRequest(&PushedAuthorizationRequest{
+				ID:        id,
+				ClientID:  "photos",
+				Form:      form,
+				ExpiresAt: time.Now().UTC().Add(time.Minute),
+			}))
+
+			_, err = m.GetPushedAuthorizationRequest(expired)
+			assert.Equal(t, pkg.ErrNotFound, errors.Cause(err))
+
+			p, err := m.GetPushedAuthorizationRequest(id)
+			require.NoError(t, err)
+			assert.Equal(t, id, p.ID)
+			assert.Equal(t, "photos", p.ClientID)
+			assert.Equal(t, form, p.Form)
+			assert.True(t, p.ExpiresAt.After(time.Now().UTC()))
+
+			require.NoError(t, m.DeletePushedAuthorizationRequest(id))
+			require.NoError(t, m.DeletePushedAuthorizationRequest(id))
+			_, err = m.GetPushedAuthorizationRequest(id)
+			assert.Equal(t, pkg.ErrNotFound, errors.Cause(err))
+		})
+	}
+}
@@ -0,0 +1,194 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/ory/fosite"
+	"github.com/ory/hydra/pkg"
+	"github.com/pkg/errors"
+)
+
+// ResourceScopes maps resource indicators, see IETF RFC 8707, to the scopes access tokens for the resource may carry.
+type ResourceScopes map[string][]string
+
+// ParseResourceScopes parses a comma separated list of resource indicators with their scopes separated by "|", for
+// example "https://photos.myapp.com/=photos|photos.read,https://billing.myapp.com/=billing".
+func ParseResourceScopes(s string) (ResourceScopes, error) {
+	scopes := ResourceScopes{}
+	for _, definition := range pkg.SplitNonEmpty(s, ",") {
+		// Resource indicators may contain "=" in their query, scopes can not.
+		i := strings.LastIndex(definition, "=")
+		if i < 0 {
+			return nil, errors.Errorf("Resource scope %s must have the format <resource>=<scope>|<scope>", definition)
+		}
+
+		resource := strings.TrimSpace(definition[:i])
+		if err := validateResource(resource); err != nil {
+			return nil, err
+		} else if _, ok := scopes[resource]; ok {
+			return nil, errors.Errorf("Resource %s is mapped twice", resource)
+		}
+
+		var granted []string
+		for _, scope := range strings.Split(definition[i+1:], "|") {
+			if scope = strings.TrimSpace(scope); scope != "" {
+				granted = append(granted, scope)
+			}
+		}
+		if len(granted) == 0 {
+			return nil, errors.Errorf("Resource %s has no scopes", resource)
+		}
+		scopes[resource] = granted
+	}
+	return scopes, nil
+}
+
+// Scopes returns the scopes access tokens for the resources may carry. It returns false if there are no resources or
+// if one of them is not mapped, in which case the scopes are not restricted.
+func (s ResourceScopes) Scopes(resources []string) ([]string, bool) {
+	if len(resources) == 0 {
+		return nil, false
+	}
+
+	var scopes []string
+	for _, resource := range resources {
+		mapped, ok := s[resource]
+		if !ok {
+			return nil, false
+		}
+		scopes = append(scopes, mapped...)
+	}
+	return scopes, true
+}
+
+func newInvalidTargetError(hint string) *fosite.RFC6749Error {
+	return &fosite.RFC6749Error{
+		Name:        "invalid_target",
+		Description: "The requested resource is invalid, missing, unknown, or malformed",
+		Debug:       hint,
+		Hint:        hint,
+		Code:        http.StatusBadRequest,
+	}
+}
+
+// requestedResources returns the resource indicators of the request. The resource parameter may be repeated.
+func requestedResources(form url.Values) []string {
+	var resources []string
+	for _, resource := range form["resource"] {
+		if resource != "" && !stringInSlice(resource, resources) {
+			resources = append(resources, resource)
+		}
+	}
+	return resources
+}
+
+// validateResource checks that the resource indicator is an absolute URI without a fragment, see IETF RFC 8707
+// Section 2.
+func validateResource(resource string) error {
+	u, err := url.Parse(resource)
+	if err != nil {
+		return errors.Errorf("Could not parse resource %s: %s", resource, err)
+	} else if !u.IsAbs() {
+		return errors.Errorf("The resource %s must be an absolute URI", resource)
+	} else if u.Fragment != "" {
+		return errors.Errorf("The resource %s must not contain a fragment", resource)
+	}
+	return nil
+}
+
+// validateResources checks the resource indicators of the authorization request against the audiences the client is
+// allowed to request.
+func validateResources(ar fosite.AuthorizeRequester) error {
+	allowed := allowedAudience(ar.GetClient())
+	for _, resource := range requestedResources(ar.GetRequestForm()) {
+		if err := validateResource(resource); err != nil {
+			return newInvalidTargetError(err.Error())
+		} else if !stringInSlice(resource, allowed) {
+			return newInvalidTargetError(fmt.Sprintf("The client is not allowed to request resource \"%s\"", resource))
+		}
+	}
+	return nil
+}
+
+// grantResources restricts the access tokens issued for the token request to the resources of its resource
+// parameter. These must have been authorized at the authorization endpoint, or be allowed for the client if the grant
+// has no authorization. The granted scopes are then narrowed to the scopes ResourceScopes maps the audiences of the
+// token to.
+func (h *Handler) grantResources(ar fosite.AccessRequester) error {
+	hs, ok := ar.GetSession().(*Session)
+	if !ok {
+		return nil
+	}
+
+	resources := requestedResources(ar.GetRequestForm())
+	for _, resource := range resources {
+		if err := validateResource(resource); err != nil {
+			return newInvalidTargetError(err.Error())
+		} else if len(hs.Resources) > 0 && !stringInSlice(resource, hs.Resources) {
+			return newInvalidTargetError(fmt.Sprintf("The resource \"%s\" was not authorized", resource))
+		} else if len(hs.Resources) == 0 && !stringInSlice(resource, allowedAudience(ar.GetClient())) {
+			return newInvalidTargetError(fmt.Sprintf("The client is not allowed to request resource \"%s\"", resource))
+		}
+	}
+
+	if len(resources) > 0 {
+		audience := requestedAudience(ar)
+		for _, resource := range resources {
+			if !stringInSlice(resource, audience) {
+				audience = append(audience, resource)
+			}
+		}
+		hs.Audience = audience
+	}
+
+	h.restrictScopes(ar, hs)
+	return nil
+}
+
+// restrictScopes narrows the granted scopes to the scopes of the audiences of the session. The scopes granted before
+// are kept in the session so that tokens refreshed for other resources can be granted them again. The openid and
+// offline scopes are never removed.
+func (h *Handler) restrictScopes(ar fosite.AccessRequester, hs *Session) {
+	req, ok := ar.(*fosite.AccessRequest)
+	if !ok {
+		return
+	}
+
+	if len(hs.AuthorizedScopes) > 0 {
+		req.GrantedScopes = fosite.Arguments(hs.AuthorizedScopes)
+	}
+
+	scopes, restricted := h.ResourceScopes.Scopes(hs.Audience)
+	if !restricted {
+		return
+	}
+
+	if len(hs.AuthorizedScopes) == 0 {
+		hs.AuthorizedScopes = append([]string{}, req.GrantedScopes...)
+	}
+
+	var granted fosite.Arguments
+	for _, scope := range hs.AuthorizedScopes {
+		if scope == "openid" || scope == "offline" || h.ScopeStrategy(scopes, scope) {
+			granted = append(granted, scope)
+		}
+	}
+	req.GrantedScopes = granted
+}
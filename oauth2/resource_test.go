@@ -0,0 +1,181 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/ory/fosite"
+	"github.com/ory/hydra/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseResourceScopes(t *testing.T) {
+	scopes, err := ParseResourceScopes("https://photos.localhost/=photos|photos.read, https://billing.localhost/?v=1 = billing")
+	require.NoError(t, err)
+	assert.Equal(t, ResourceScopes{
+		"https://photos.localhost/":      {"photos", "photos.read"},
+		"https://billing.localhost/?v=1": {"billing"},
+	}, scopes)
+
+	scopes, err = ParseResourceScopes("")
+	require.NoError(t, err)
+	assert.Empty(t, scopes)
+
+	for _, invalid := range []string{
+		"https://photos.localhost/",
+		"https://photos.localhost/=",
+		"photos=photos",
+		"https://photos.localhost/#photos=photos",
+		"https://photos.localhost/=photos,https://photos.localhost/=photos.read",
+	} {
+		_, err := ParseResourceScopes(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestResourceScopes(t *testing.T) {
+	s := ResourceScopes{
+		"https://photos.localhost/":  {"photos"},
+		"https://billing.localhost/": {"billing"},
+	}
+
+	scopes, restricted := s.Scopes([]string{"https://photos.localhost/", "https://billing.localhost/"})
+	assert.True(t, restricted)
+	assert.Equal(t, []string{"photos", "billing"}, scopes)
+
+	_, restricted = s.Scopes([]string{"https://photos.localhost/", "https://other.localhost/"})
+	assert.False(t, restricted)
+
+	_, restricted = s.Scopes(nil)
+	assert.False(t, restricted)
+}
+
+func TestValidateResources(t *testing.T) {
+	c := &client.Client{Audience: []string{"https://photos.localhost/"}}
+	for k, tc := range []struct {
+		form      url.Values
+		expectErr bool
+	}{
+		{form: url.Values{}},
+		{form: url.Values{"resource": {"https://photos.localhost/"}}},
+		{form: url.Values{"resource": {"https://billing.localhost/"}}, expectErr: true},
+		{form: url.Values{"resource": {"/photos"}}, expectErr: true},
+		{form: url.Values{"resource": {"https://photos.localhost/#photos"}}, expectErr: true},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			err := validateResources(&fosite.AuthorizeRequest{Request: fosite.Request{Form: tc.form, Client: c}})
+			if tc.expectErr {
+				require.Error(t, err)
+				assert.Equal(t, "invalid_target", err.(*fosite.RFC6749Error).Name)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestGrantResources(t *testing.T) {
+	h := &Handler{
+		ScopeStrategy: fosite.HierarchicScopeStrategy,
+		ResourceScopes: ResourceScopes{
+			"https://photos.localhost/":  {"photos"},
+			"https://billing.localhost/": {"billing"},
+		},
+	}
+	c := &client.Client{Audience: []string{"https://photos.localhost/", "https://billing.localhost/", "https://other.localhost/"}}
+	authorized := []string{"https://photos.localhost/", "https://billing.localhost/"}
+
+	for k, tc := range []struct {
+		form             url.Values
+		resources        []string
+		authorizedScopes []string
+		expectedAudience []string
+		expectedScopes   fosite.Arguments
+		expectErr        bool
+	}{
+		{
+			form:           url.Values{},
+			expectedScopes: fosite.Arguments{"openid", "offline", "photos.read", "billing"},
+		},
+		{
+			form:             url.Values{},
+			resources:        authorized,
+			expectedAudience: authorized,
+			expectedScopes:   fosite.Arguments{"openid", "offline", "photos.read", "billing"},
+		},
+		{
+			form:             url.Values{"resource": {"https://photos.localhost/"}},
+			resources:        authorized,
+			expectedAudience: []string{"https://photos.localhost/"},
+			expectedScopes:   fosite.Arguments{"openid", "offline", "photos.read"},
+		},
+		{
+			form:             url.Values{"resource": {"https://billing.localhost/"}},
+			resources:        authorized,
+			authorizedScopes: []string{"openid", "offline", "photos.read", "billing"},
+			expectedAudience: []string{"https://billing.localhost/"},
+			expectedScopes:   fosite.Arguments{"openid", "offline", "billing"},
+		},
+		{
+			form:             url.Values{"resource": {"https://other.localhost/"}},
+			expectedAudience: []string{"https://other.localhost/"},
+			expectedScopes:   fosite.Arguments{"openid", "offline", "photos.read", "billing"},
+		},
+		{
+			form:      url.Values{"resource": {"https://other.localhost/"}},
+			resources: authorized,
+			expectErr: true,
+		},
+		{
+			form:      url.Values{"resource": {"https://unknown.localhost/"}},
+			expectErr: true,
+		},
+		{
+			form:      url.Values{"resource": {"photos"}},
+			expectErr: true,
+		},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			session := NewSession("peter")
+			session.Resources = tc.resources
+			session.Audience = tc.resources
+			session.AuthorizedScopes = tc.authorizedScopes
+
+			ar := fosite.NewAccessRequest(session)
+			ar.Form = tc.form
+			ar.Client = c
+			if tc.authorizedScopes == nil {
+				ar.GrantedScopes = fosite.Arguments{"openid", "offline", "photos.read", "billing"}
+			} else {
+				// Tokens refreshed from a token restricted to another resource.
+				ar.GrantedScopes = fosite.Arguments{"openid", "offline", "photos.read"}
+			}
+
+			err := h.grantResources(ar)
+			if tc.expectErr {
+				require.Error(t, err)
+				assert.Equal(t, "invalid_target", err.(*fosite.RFC6749Error).Name)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedAudience, session.Audience)
+			assert.Equal(t, tc.expectedScopes, ar.GetGrantedScopes())
+		})
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ory/fosite"
+	"github.com/ory/hydra/client"
+	"github.com/pkg/errors"
+	"github.com/square/go-jose"
+)
+
+// maxKeySetSize is the largest JSON Web Key Set fetched from the jwks_uri of a client.
+const maxKeySetSize = 1 << 20
+
+// ResponseEncrypter encrypts ID tokens and userinfo responses to the public keys of clients which registered
+// id_token_encrypted_response_alg or userinfo_encrypted_response_alg.
+type ResponseEncrypter struct {
+	// HTTPClient fetches the JSON Web Key Sets of clients which registered a jwks_uri.
+	HTTPClient *http.Client
+
+	// KeySetLifespan is how long a JSON Web Key Set fetched from a jwks_uri is used before it is fetched again.
+	KeySetLifespan time.Duration
+
+	sync.Mutex
+	keySets map[string]cachedKeySet
+}
+
+type cachedKeySet struct {
+	keys      *jose.JSONWebKeySet
+	fetchedAt time.Time
+}
+
+// EncryptIDToken encrypts idToken if c registered an ID token encryption algorithm, and returns it unchanged
+// otherwise.
+func (e *ResponseEncrypter) EncryptIDToken(ctx context.Context, c *client.Client, idToken string) (string, error) {
+	alg, enc := c.GetIDTokenEncryption()
+	if alg == "" {
+		return idToken, nil
+	}
+	return e.encrypt(ctx, c, alg, enc, "JWT", []byte(idToken))
+}
+
+// EncryptUserinfo encrypts the userinfo response to the key of c. The payload is either the JSON encoded claims, or a
+// signed JSON Web Token if contentType is "JWT". It must only be called if c registered a userinfo encryption
+// algorithm.
+func (e *ResponseEncrypter) EncryptUserinfo(ctx context.Context, c *client.Client, payload []byte, contentType string) (string, error) {
+	alg, enc := c.GetUserinfoEncryption()
+	if alg == "" {
+		return "", errors.Errorf("Client %s did not register a userinfo encryption algorithm", c.ID)
+	}
+	return e.encrypt(ctx, c, alg, enc, contentType, payload)
+}
+
+func (e *ResponseEncrypter) encrypt(ctx context.Context, c *client.Client, alg, enc, contentType string, payload []byte) (string, error) {
+	keys, err := e.keySet(ctx, c)
+	if err != nil {
+		return "", err
+	}
+
+	key, err := client.EncryptionKey(keys, alg)
+	if err != nil {
+		return "", errors.Wrapf(fosite.ErrServerError, "Could not encrypt response for client %s: %s", c.ID, err)
+	}
+
+	opts := new(jose.EncrypterOptions)
+	if contentType != "" {
+		opts = opts.WithContentType(jose.ContentType(contentType))
+	}
+
+	// Passing the JSON Web Key instead of the public key sets the kid header of the encrypted token.
+	encrypter, err := jose.NewEncrypter(jose.ContentEncryption(enc), jose.Recipient{Algorithm: jose.KeyAlgorithm(alg), Key: *key}, opts)
+	if err != nil {
+		return "", errors.Wrapf(fosite.ErrServerError, "Could not encrypt response for client %s: %s", c.ID, err)
+	}
+
+	encrypted, err := encrypter.Encrypt(payload)
+	if err != nil {
+		return "", errors.Wrapf(fosite.ErrServerError, "Could not encrypt response for client %s: %s", c.ID, err)
+	}
+	return encrypted.CompactSerialize()
+}
+
+// keySet returns the registered JSON Web Key Set of c, fetching it from its jwks_uri if necessary.
+func (e *ResponseEncrypter) keySet(ctx context.Context, c *client.Client) (*jose.JSONWebKeySet, error) {
+	if c.JSONWebKeysURI == "" {
+		if c.JSONWebKeys == nil {
+			return nil, errors.Wrapf(fosite.ErrServerError, "Client %s registered neither jwks nor jwks_uri", c.ID)
+		}
+		return c.JSONWebKeys, nil
+	}
+
+	e.Lock()
+	cached, ok := e.keySets[c.JSONWebKeysURI]
+	e.Unlock()
+	if ok && time.Since(cached.fetchedAt) < e.KeySetLifespan {
+		return cached.keys, nil
+	}
+
+	keys, err := e.fetchKeySet(ctx, c.JSONWebKeysURI)
+	if err != nil {
+		return nil, errors.Wrapf(fosite.ErrServerError, "Could not fetch the JSON Web Key Set of client %s: %s", c.ID, err)
+	}
+
+	e.Lock()
+	defer e.Unlock()
+	if e.keySets == nil {
+		e.keySets = map[string]cachedKeySet{}
+	}
+	e.keySets[c.JSONWebKeysURI] = cachedKeySet{keys: keys, fetchedAt: time.Now()}
+	return keys, nil
+}
+
+func (e *ResponseEncrypter) fetchKeySet(ctx context.Context, uri string) (*jose.JSONWebKeySet, error) {
+	req, err := http.NewRequest("GET", uri, nil)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	hc := e.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+
+	res, err := hc.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("Expected status code %d but got %d from %s", http.StatusOK, res.StatusCode, uri)
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(res.Body, maxKeySetSize))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var keys jose.JSONWebKeySet
+	if err := json.Unmarshal(body, &keys); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &keys, nil
+}
+
+// encryptIDToken encrypts idToken if c registered an ID token encryption algorithm.
+func (h *Handler) encryptIDToken(ctx context.Context, c fosite.Client, idToken string) (string, error) {
+	hc, ok := c.(*client.Client)
+	if !ok || hc.IDTokenEncryptedResponseAlg == "" {
+		return idToken, nil
+	} else if h.ResponseEncrypter == nil {
+		return "", errors.Wrapf(fosite.ErrServerError, "Client %s requires encrypted ID tokens but response encryption is disabled", hc.ID)
+	}
+	return h.ResponseEncrypter.EncryptIDToken(ctx, hc, idToken)
+}
@@ -0,0 +1,87 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ory/hydra/client"
+	"github.com/ory/hydra/pkg"
+	"github.com/square/go-jose"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseEncrypter(t *testing.T) {
+	key := pkg.MustINSECURELOWENTROPYRSAKEYFORTEST()
+	keys := &jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: &key.PublicKey, KeyID: "enc", Use: "enc"}}}
+
+	var fetched int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetched++
+		json.NewEncoder(w).Encode(keys)
+	}))
+	defer ts.Close()
+
+	e := &ResponseEncrypter{HTTPClient: http.DefaultClient, KeySetLifespan: time.Hour}
+
+	decrypt := func(t *testing.T, token string) (*jose.JSONWebEncryption, []byte) {
+		encrypted, err := jose.ParseEncrypted(token)
+		require.NoError(t, err)
+		payload, err := encrypted.Decrypt(key)
+		require.NoError(t, err)
+		return encrypted, payload
+	}
+
+	t.Run("case=leaves ID tokens of other clients unchanged", func(t *testing.T) {
+		token, err := e.EncryptIDToken(context.Background(), &client.Client{ID: "foo"}, "id.token.signature")
+		require.NoError(t, err)
+		assert.Equal(t, "id.token.signature", token)
+	})
+
+	t.Run("case=encrypts ID tokens to registered keys", func(t *testing.T) {
+		c := &client.Client{ID: "foo", JSONWebKeys: keys, IDTokenEncryptedResponseAlg: "RSA-OAEP-256"}
+		token, err := e.EncryptIDToken(context.Background(), c, "id.token.signature")
+		require.NoError(t, err)
+
+		encrypted, payload := decrypt(t, token)
+		assert.Equal(t, "id.token.signature", string(payload))
+		assert.Equal(t, "enc", encrypted.Header.KeyID)
+		assert.Equal(t, "RSA-OAEP-256", encrypted.Header.Algorithm)
+	})
+
+	t.Run("case=encrypts userinfo to keys fetched from the jwks uri", func(t *testing.T) {
+		c := &client.Client{ID: "foo", JSONWebKeysURI: ts.URL, UserinfoEncryptedResponseAlg: "RSA-OAEP", UserinfoEncryptedResponseEnc: "A256GCM"}
+		for i := 0; i < 2; i++ {
+			token, err := e.EncryptUserinfo(context.Background(), c, []byte(`{"sub":"peter"}`), "")
+			require.NoError(t, err)
+
+			_, payload := decrypt(t, token)
+			assert.Equal(t, `{"sub":"peter"}`, string(payload))
+		}
+		assert.Equal(t, 1, fetched)
+	})
+
+	t.Run("case=fails without a matching key", func(t *testing.T) {
+		c := &client.Client{ID: "foo", JSONWebKeys: keys, IDTokenEncryptedResponseAlg: "ECDH-ES"}
+		_, err := e.EncryptIDToken(context.Background(), c, "id.token.signature")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,144 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"crypto"
+	"encoding/base64"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/ory/fosite"
+	"github.com/ory/hydra/pkg"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/square/go-jose"
+)
+
+// RevocationFilterClaims is the payload of the signed revocation filter. Edge validators of access tokens look up the
+// token's signature, the last segment of JSON Web Tokens and opaque tokens alike, using the algorithm of
+// pkg.BloomFilter. A match means that the token has probably been revoked and should be introspected, no match means
+// that it has certainly not been revoked before the filter was issued.
+type RevocationFilterClaims struct {
+	Issuer    string `json:"iss"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+
+	// Bits is the base64url encoded bit array of the filter.
+	Bits string `json:"bits"`
+
+	// M is the size of the filter in bits.
+	M uint64 `json:"m"`
+
+	// K is the number of hash functions.
+	K uint64 `json:"k"`
+
+	// N is the number of revoked tokens in the filter.
+	N int `json:"n"`
+}
+
+// RevocationFilter periodically publishes a bloom filter of the signatures of revoked access tokens which have not
+// expired yet, signed as a JSON Web Token.
+type RevocationFilter struct {
+	History TokenHistoryManager
+
+	PrivateKey crypto.Signer
+	Algorithm  jose.SignatureAlgorithm
+	KeyID      string
+	Issuer     string
+
+	// Interval is the time between two filters, published filters expire after twice the interval.
+	Interval          time.Duration
+	FalsePositiveRate float64
+
+	L logrus.FieldLogger
+
+	sync.RWMutex
+	token    string
+	issuedAt time.Time
+}
+
+// Run regenerates the filter every Interval until stop is closed.
+func (f *RevocationFilter) Run(stop <-chan struct{}) {
+	for {
+		if err := f.Refresh(); err != nil {
+			f.L.WithError(err).Errorln("Could not generate the revocation filter")
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-time.After(f.Interval):
+		}
+	}
+}
+
+// Refresh generates and signs a new filter.
+func (f *RevocationFilter) Refresh() error {
+	now := time.Now().UTC()
+	signatures, err := f.History.GetRevokedTokenSignatures(fosite.AccessToken, now)
+	if err != nil {
+		return err
+	}
+
+	filter := pkg.NewBloomFilter(len(signatures), f.FalsePositiveRate)
+	for _, signature := range signatures {
+		filter.Add(signature)
+	}
+
+	payload, err := json.Marshal(&RevocationFilterClaims{
+		Issuer:    f.Issuer,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(f.Interval * 2).Unix(),
+		Bits:      base64.RawURLEncoding.EncodeToString(filter.Bits),
+		M:         filter.M,
+		K:         filter.K,
+		N:         len(signatures),
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	options := new(jose.SignerOptions).WithType("JWT").WithHeader("kid", f.KeyID)
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: f.Algorithm, Key: f.PrivateKey}, options)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	signed, err := signer.Sign(payload)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	token, err := signed.CompactSerialize()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	f.Lock()
+	defer f.Unlock()
+	f.token = token
+	f.issuedAt = now
+	return nil
+}
+
+// Current returns the latest filter and the time it was issued at. The token is empty if no filter has been
+// generated yet.
+func (f *RevocationFilter) Current() (string, time.Time) {
+	f.RLock()
+	defer f.RUnlock()
+	return f.token, f.issuedAt
+}
@@ -0,0 +1,140 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/fosite"
+	"github.com/ory/herodot"
+	. "github.com/ory/hydra/oauth2"
+	"github.com/ory/hydra/pkg"
+	"github.com/sirupsen/logrus"
+	"github.com/square/go-jose"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRevocationFilter(t *testing.T) {
+	now := time.Now().UTC()
+	history := NewTokenHistoryMemoryManager()
+	for _, r := range []*TokenRecord{
+		{Signature: "revoked", TokenType: fosite.AccessToken, IssuedAt: now, ExpiresAt: now.Add(time.Hour), RevokedAt: now},
+		{Signature: "revoked-no-expiry", TokenType: fosite.AccessToken, IssuedAt: now, RevokedAt: now},
+		{Signature: "revoked-expired", TokenType: fosite.AccessToken, IssuedAt: now.Add(-time.Hour), ExpiresAt: now.Add(-time.Minute), RevokedAt: now.Add(-time.Hour)},
+		{Signature: "revoked-refresh-token", TokenType: fosite.RefreshToken, IssuedAt: now, RevokedAt: now},
+		{Signature: "active", TokenType: fosite.AccessToken, IssuedAt: now, ExpiresAt: now.Add(time.Hour)},
+	} {
+		require.NoError(t, history.CreateTokenRecord(r))
+	}
+
+	key := pkg.MustINSECURELOWENTROPYRSAKEYFORTEST()
+	f := &RevocationFilter{
+		History:           history,
+		PrivateKey:        key,
+		Algorithm:         jose.RS256,
+		KeyID:             "public:foo",
+		Issuer:            "http://hydra.localhost",
+		Interval:          time.Minute,
+		FalsePositiveRate: 0.0001,
+		L:                 logrus.New(),
+	}
+
+	h := &Handler{H: herodot.NewJSONWriter(nil), RevocationFilter: f}
+	router := httprouter.New()
+	h.SetRoutes(router)
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	res, err := http.Get(ts.URL + RevocationFilterPath)
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+
+	require.NoError(t, f.Refresh())
+
+	res, err = http.Get(ts.URL + RevocationFilterPath)
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "application/jwt", res.Header.Get("Content-Type"))
+	assert.Equal(t, "public, max-age=60", res.Header.Get("Cache-Control"))
+
+	signed, err := jose.ParseSigned(string(body))
+	require.NoError(t, err)
+	assert.Equal(t, "public:foo", signed.Signatures[0].Header.KeyID)
+	payload, err := signed.Verify(&key.PublicKey)
+	require.NoError(t, err)
+
+	var claims RevocationFilterClaims
+	require.NoError(t, json.Unmarshal(payload, &claims))
+	assert.Equal(t, "http://hydra.localhost", claims.Issuer)
+	assert.Equal(t, claims.IssuedAt+120, claims.ExpiresAt)
+	assert.Equal(t, 2, claims.N)
+
+	bits, err := base64.RawURLEncoding.DecodeString(claims.Bits)
+	require.NoError(t, err)
+	filter := &pkg.BloomFilter{Bits: bits, M: claims.M, K: claims.K}
+	assert.True(t, filter.Test("revoked"))
+	assert.True(t, filter.Test("revoked-no-expiry"))
+	assert.False(t, filter.Test("revoked-expired"))
+	assert.False(t, filter.Test("revoked-refresh-token"))
+	assert.False(t, filter.Test("active"))
+
+	req, err := http.NewRequest("GET", ts.URL+RevocationFilterPath, nil)
+	require.NoError(t, err)
+	req.Header.Set("If-None-Match", res.Header.Get("ETag"))
+	res, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusNotModified, res.StatusCode)
+}
+
+func TestRevocationFilterRunStops(t *testing.T) {
+	f := &RevocationFilter{
+		History:           NewTokenHistoryMemoryManager(),
+		PrivateKey:        pkg.MustINSECURELOWENTROPYRSAKEYFORTEST(),
+		Algorithm:         jose.RS256,
+		Interval:          time.Hour,
+		FalsePositiveRate: 0.0001,
+		L:                 logrus.New(),
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		f.Run(stop)
+		close(done)
+	}()
+	close(stop)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second * 5):
+		t.Fatal("Run did not return after stop was closed")
+	}
+
+	token, _ := f.Current()
+	assert.NotEmpty(t, token)
+}
@@ -15,6 +15,8 @@
 package oauth2
 
 import (
+	"time"
+
 	"github.com/mohae/deepcopy"
 	"github.com/ory/fosite"
 	"github.com/ory/fosite/handler/openid"
@@ -24,6 +26,32 @@ import (
 type Session struct {
 	*openid.DefaultSession `json:"idToken"`
 	Extra                  map[string]interface{} `json:"extra"`
+
+	// UserinfoExtra are claims returned by the userinfo endpoint in addition to the claims of the ID token.
+	UserinfoExtra map[string]interface{} `json:"userinfoExtra,omitempty"`
+
+	// Actor is the act claim of tokens issued by a token exchange, see IETF RFC 8693 Section 4.1.
+	Actor map[string]interface{} `json:"actor,omitempty"`
+
+	// Confirmation is the cnf claim of access tokens bound to the certificate the client authenticated with, see
+	// IETF RFC 8705 Section 3.1.
+	Confirmation map[string]interface{} `json:"confirmation,omitempty"`
+
+	// Audience are the audiences the access tokens issued for the session are restricted to, requested using the
+	// audience parameter at the token endpoint.
+	Audience []string `json:"audience,omitempty"`
+
+	// Resources are the resource indicators authorized at the authorization endpoint, see IETF RFC 8707. Tokens
+	// requested for the authorization can only be restricted to these resources.
+	Resources []string `json:"resources,omitempty"`
+
+	// AuthorizedScopes are the scopes granted by the authorization before they were narrowed to the scopes of the
+	// resources the tokens are restricted to.
+	AuthorizedScopes []string `json:"authorizedScopes,omitempty"`
+
+	// Lifespans are the token lifespans configured by the client the session belongs to. Expiries set for these token
+	// types are shortened to the lifespan, see ClientLifespanHandler.
+	Lifespans map[fosite.TokenType]time.Duration `json:"lifespans,omitempty"`
 }
 
 func NewSession(subject string) *Session {
@@ -43,3 +71,13 @@ func (s *Session) Clone() fosite.Session {
 
 	return deepcopy.Copy(s).(fosite.Session)
 }
+
+// SetExpiresAt sets the expiry of the token type, shortened to the lifespan the client configured for it.
+func (s *Session) SetExpiresAt(key fosite.TokenType, exp time.Time) {
+	if d, ok := s.Lifespans[key]; ok {
+		if max := time.Now().UTC().Add(d); exp.IsZero() || exp.After(max) {
+			exp = max
+		}
+	}
+	s.DefaultSession.SetExpiresAt(key, exp)
+}
@@ -0,0 +1,167 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gorilla/sessions"
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/fosite"
+	"github.com/ory/hydra/pkg"
+	"github.com/ory/hydra/rand/sequence"
+	"github.com/pkg/errors"
+)
+
+const (
+	// CheckSessionPath points to the check session iframe of OpenID Connect Session Management.
+	CheckSessionPath = "/oauth2/sessions/check"
+
+	// BrowserStateCookieName is the name of the cookie holding the browser state of the login session. Unlike the
+	// consent session cookie it is readable by JavaScript, so that the check session iframe can compare it to the
+	// session state of relying parties.
+	BrowserStateCookieName = "oauth2_browser_state"
+)
+
+var checkSessionPage = template.Must(template.New("check_session").Parse(`<!DOCTYPE html>
+<html>
+<head>
+	<title>Check session</title>
+	<script>
+	function browserState() {
+		var cookies = document.cookie.split(";");
+		for (var i = 0; i < cookies.length; i++) {
+			var cookie = cookies[i].replace(/^\s+/, "");
+			if (cookie.indexOf({{ . }} + "=") === 0) {
+				return cookie.substring({{ . }}.length + 1);
+			}
+		}
+		return "";
+	}
+
+	window.addEventListener("message", function (e) {
+		var message = typeof e.data === "string" ? e.data : "";
+		var i = message.lastIndexOf(" ");
+		var sessionState = message.substring(i + 1);
+		var salt = sessionState.split(".")[1];
+		if (i <= 0 || !salt || !window.crypto || !window.crypto.subtle || !window.TextEncoder) {
+			e.source.postMessage("error", e.origin);
+			return;
+		}
+
+		var data = new TextEncoder().encode(message.substring(0, i) + " " + e.origin + " " + browserState() + " " + salt);
+		window.crypto.subtle.digest("SHA-256", data).then(function (hash) {
+			var hex = Array.prototype.map.call(new Uint8Array(hash), function (b) {
+				return ("0" + b.toString(16)).slice(-2);
+			}).join("");
+			e.source.postMessage(hex + "." + salt === sessionState ? "unchanged" : "changed", e.origin);
+		}, function () {
+			e.source.postMessage("error", e.origin);
+		});
+	}, false);
+	</script>
+</head>
+<body></body>
+</html>
+`))
+
+// BrowserState returns the browser state of the login session with the id sid. The session id can not be derived
+// from it.
+func BrowserState(sid string) string {
+	sum := sha256.Sum256([]byte(sid))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// SessionState computes the session_state of OpenID Connect Session Management for a client whose redirect URI is
+// served at origin.
+func SessionState(clientID, origin, browserState, salt string) string {
+	sum := sha256.Sum256([]byte(clientID + " " + origin + " " + browserState + " " + salt))
+	return hex.EncodeToString(sum[:]) + "." + salt
+}
+
+// swagger:route GET /oauth2/sessions/check oAuth2 checkOAuth2Session
+//
+// OpenID Connect check session iframe
+//
+// This page is loaded in a hidden iframe by relying parties implementing OpenID Connect Session Management. They
+// post the message "<client_id> <session_state>" to it, using the `session_state` returned by the authorization
+// endpoint, and receive "unchanged", "changed" or "error" in response. A changed session means that the user logged
+// out or logged in as someone else, and the relying party should check the login state with a `prompt=none`
+// authorization request.
+//
+//     Produces:
+//     - text/html
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: emptyResponse
+func (h *Handler) CheckSessionHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := checkSessionPage.Execute(w, BrowserStateCookieName); err != nil {
+		pkg.LogError(errors.WithStack(err), h.L)
+	}
+}
+
+// addSessionState adds the session_state to authorization responses of the openid scope and sets the browser state
+// cookie read by the check session iframe. Nothing is added if login sessions are not tracked.
+func (h *Handler) addSessionState(w http.ResponseWriter, ar fosite.AuthorizeRequester, resp fosite.AuthorizeResponder, cookie *sessions.Session) error {
+	sid, _ := cookie.Values[CookieLoginSessionKey].(string)
+	if h.Logout == nil || sid == "" || !ar.GetGrantedScopes().Has("openid") {
+		return nil
+	}
+
+	salt, err := sequence.RuneSequence(16, sequence.AlphaNum)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	browserState := BrowserState(sid)
+	state := SessionState(ar.GetClient().GetID(), origin(ar.GetRedirectURI()), browserState, string(salt))
+	if len(resp.GetFragment()) > 0 {
+		resp.AddFragment("session_state", state)
+	} else {
+		resp.AddQuery("session_state", state)
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:   BrowserStateCookieName,
+		Value:  browserState,
+		Path:   "/",
+		Secure: !h.ForcedHTTP,
+	})
+	return nil
+}
+
+// clearBrowserState removes the browser state cookie, so that the check session iframe reports the session as
+// changed.
+func clearBrowserState(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{Name: BrowserStateCookieName, Path: "/", MaxAge: -1})
+}
+
+// origin returns the origin of u as computed by user agents, which omit default ports.
+func origin(u *url.URL) string {
+	host := u.Host
+	if port := u.Port(); (u.Scheme == "https" && port == "443") || (u.Scheme == "http" && port == "80") {
+		host = strings.TrimSuffix(host, ":"+port)
+	}
+	return u.Scheme + "://" + host
+}
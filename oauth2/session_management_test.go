@@ -0,0 +1,86 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/sessions"
+	"github.com/ory/fosite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionState(t *testing.T) {
+	state := SessionState("photos", "https://photos.localhost", BrowserState("session"), "salt")
+	sum := sha256.Sum256([]byte("photos https://photos.localhost " + BrowserState("session") + " salt"))
+	assert.Equal(t, hex.EncodeToString(sum[:])+".salt", state)
+	assert.NotContains(t, BrowserState("session"), "session")
+
+	for k, c := range []struct {
+		u        string
+		expected string
+	}{
+		{u: "https://photos.localhost/callback?foo=bar", expected: "https://photos.localhost"},
+		{u: "https://photos.localhost:443/callback", expected: "https://photos.localhost"},
+		{u: "http://photos.localhost:80/callback", expected: "http://photos.localhost"},
+		{u: "http://photos.localhost:8080/callback", expected: "http://photos.localhost:8080"},
+	} {
+		u, err := url.Parse(c.u)
+		require.NoError(t, err)
+		assert.Equal(t, c.expected, origin(u), "case %d", k)
+	}
+}
+
+func TestAddSessionState(t *testing.T) {
+	h := &Handler{Logout: &Logout{}}
+	redirectURI, _ := url.Parse("https://photos.localhost/callback")
+
+	ar := fosite.NewAuthorizeRequest()
+	ar.Client = &fosite.DefaultClient{ID: "photos"}
+	ar.RedirectURI = redirectURI
+	ar.GrantScope("openid")
+
+	cookie := sessions.NewSession(sessions.NewCookieStore([]byte("secret")), consentCookieName)
+
+	resp := fosite.NewAuthorizeResponse()
+	w := httptest.NewRecorder()
+	require.NoError(t, h.addSessionState(w, ar, resp, cookie))
+	assert.Empty(t, resp.GetQuery().Get("session_state"))
+
+	cookie.Values[CookieLoginSessionKey] = "session"
+	require.NoError(t, h.addSessionState(w, ar, resp, cookie))
+	state := resp.GetQuery().Get("session_state")
+	require.NotEmpty(t, state)
+	assert.Equal(t, SessionState("photos", "https://photos.localhost", BrowserState("session"), state[strings.Index(state, ".")+1:]), state)
+
+	cookies := (&http.Response{Header: w.Header()}).Cookies()
+	require.Len(t, cookies, 1)
+	assert.Equal(t, BrowserStateCookieName, cookies[0].Name)
+	assert.Equal(t, BrowserState("session"), cookies[0].Value)
+	assert.False(t, cookies[0].HttpOnly)
+
+	resp = fosite.NewAuthorizeResponse()
+	resp.AddFragment("id_token", "token")
+	require.NoError(t, h.addSessionState(httptest.NewRecorder(), ar, resp, cookie))
+	assert.NotEmpty(t, resp.GetFragment().Get("session_state"))
+	assert.Empty(t, resp.GetQuery().Get("session_state"))
+}
@@ -0,0 +1,107 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/ory/fosite"
+	"github.com/ory/fosite/handler/openid"
+	"github.com/pkg/errors"
+	"github.com/square/go-jose"
+	"golang.org/x/crypto/ed25519"
+)
+
+// EdDSAOpenIDConnectStrategy signs OpenID Connect ID Tokens using an Ed25519 private key (JWS algorithm "EdDSA").
+type EdDSAOpenIDConnectStrategy struct {
+	PrivateKey ed25519.PrivateKey
+
+	// Expiry is the lifespan of ID Tokens which do not set an expiry. Defaults to an hour.
+	Expiry time.Duration
+	Issuer string
+}
+
+func (s *EdDSAOpenIDConnectStrategy) GenerateIDToken(_ context.Context, requester fosite.Requester) (string, error) {
+	expiry := s.Expiry
+	if expiry == 0 {
+		expiry = time.Hour
+	}
+
+	sess, ok := requester.GetSession().(openid.Session)
+	if !ok {
+		return "", errors.New("Failed to generate id token because session must be of type fosite/handler/openid.Session")
+	}
+
+	claims := sess.IDTokenClaims()
+	if claims.Subject == "" {
+		return "", errors.New("Failed to generate id token because subject is an empty string")
+	}
+
+	if nonce := requester.GetRequestForm().Get("nonce"); len(nonce) > 0 && len(nonce) < fosite.MinParameterEntropy {
+		return "", errors.Wrapf(fosite.ErrInsufficientEntropy, "Parameter nonce must be at least %d characters long", fosite.MinParameterEntropy)
+	} else if claims.Nonce == "" {
+		claims.Nonce = nonce
+	}
+
+	if claims.ExpiresAt.IsZero() {
+		claims.ExpiresAt = time.Now().UTC().Add(expiry)
+	}
+
+	if claims.ExpiresAt.Before(time.Now().UTC()) {
+		return "", errors.New("Failed to generate id token because expiry claim can not be in the past")
+	}
+
+	if claims.Issuer == "" {
+		claims.Issuer = s.Issuer
+	}
+
+	claims.Audience = requester.GetClient().GetID()
+	claims.IssuedAt = time.Now().UTC()
+
+	payload, err := json.Marshal(claims.ToMap())
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	options := new(jose.SignerOptions)
+	if headers := sess.IDTokenHeaders(); headers != nil {
+		for k, v := range headers.ToMap() {
+			if k == "alg" || k == "typ" {
+				continue
+			}
+			options = options.WithHeader(jose.HeaderKey(k), v)
+		}
+	}
+	options = options.WithType("JWT")
+
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.EdDSA, Key: s.PrivateKey}, options)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	signed, err := signer.Sign(payload)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	token, err := signed.CompactSerialize()
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return token, nil
+}
@@ -0,0 +1,76 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/ory/fosite"
+	"github.com/ory/fosite/handler/openid"
+	ejwt "github.com/ory/fosite/token/jwt"
+	"github.com/square/go-jose"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ed25519"
+)
+
+func TestEdDSAOpenIDConnectStrategy(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	strategy := &EdDSAOpenIDConnectStrategy{PrivateKey: private, Expiry: time.Hour, Issuer: "https://hydra.localhost"}
+
+	newRequest := func(subject string) *fosite.Request {
+		return &fosite.Request{
+			Client: &fosite.DefaultClient{ID: "client"},
+			Form:   url.Values{"nonce": {"some-secure-random-nonce-value"}},
+			Session: &openid.DefaultSession{
+				Claims:  &ejwt.IDTokenClaims{Subject: subject},
+				Headers: &ejwt.Headers{Extra: map[string]interface{}{"kid": "public:foo"}},
+			},
+		}
+	}
+
+	t.Run("case=rejects empty subject", func(t *testing.T) {
+		_, err := strategy.GenerateIDToken(context.Background(), newRequest(""))
+		assert.Error(t, err)
+	})
+
+	t.Run("case=signs id token", func(t *testing.T) {
+		token, err := strategy.GenerateIDToken(context.Background(), newRequest("peter"))
+		require.NoError(t, err)
+
+		signed, err := jose.ParseSigned(token)
+		require.NoError(t, err)
+		require.Len(t, signed.Signatures, 1)
+		assert.Equal(t, "EdDSA", signed.Signatures[0].Header.Algorithm)
+		assert.Equal(t, "public:foo", signed.Signatures[0].Header.KeyID)
+
+		payload, err := signed.Verify(public)
+		require.NoError(t, err)
+
+		var claims map[string]interface{}
+		require.NoError(t, json.Unmarshal(payload, &claims))
+		assert.Equal(t, "peter", claims["sub"])
+		assert.Equal(t, "client", claims["aud"])
+		assert.Equal(t, "https://hydra.localhost", claims["iss"])
+		assert.Equal(t, "some-secure-random-nonce-value", claims["nonce"])
+	})
+}
@@ -0,0 +1,159 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/ory/fosite"
+	foauth2 "github.com/ory/fosite/handler/oauth2"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+	"github.com/square/go-jose"
+)
+
+const (
+	// AccessTokenStrategyOpaque issues opaque, HMAC signed access tokens.
+	AccessTokenStrategyOpaque = "opaque"
+
+	// AccessTokenStrategyJWT issues access tokens as signed JSON Web Tokens.
+	AccessTokenStrategyJWT = "jwt"
+)
+
+// JWTAccessTokenStrategy issues access tokens as signed JSON Web Tokens and delegates authorize codes and refresh
+// tokens to the embedded opaque strategy. Both forms of access tokens are accepted: the signature of a JWT is used
+// as its storage key, just like the signature of an opaque token, so both resolve to the session stored when the
+// token was issued. This keeps opaque tokens issued before the strategy was enabled working until they expire.
+type JWTAccessTokenStrategy struct {
+	foauth2.CoreStrategy
+
+	// PrivateKey signs access tokens, its public key verifies them.
+	PrivateKey crypto.Signer
+	Algorithm  jose.SignatureAlgorithm
+	KeyID      string
+	Issuer     string
+
+	AccessTokenLifespan time.Duration
+}
+
+// IsJWT returns true if token looks like a compact serialized JSON Web Token rather than an opaque token.
+func IsJWT(token string) bool {
+	return strings.Count(token, ".") == 2
+}
+
+func (s *JWTAccessTokenStrategy) AccessTokenSignature(token string) string {
+	if !IsJWT(token) {
+		return s.CoreStrategy.AccessTokenSignature(token)
+	}
+	return token[strings.LastIndex(token, ".")+1:]
+}
+
+func (s *JWTAccessTokenStrategy) GenerateAccessToken(_ context.Context, requester fosite.Requester) (token string, signature string, err error) {
+	now := time.Now().UTC()
+	session := requester.GetSession()
+
+	expiresAt := session.GetExpiresAt(fosite.AccessToken)
+	if expiresAt.IsZero() {
+		expiresAt = now.Add(s.AccessTokenLifespan)
+	}
+
+	claims := map[string]interface{}{
+		"jti":       uuid.New(),
+		"iss":       s.Issuer,
+		"sub":       session.GetSubject(),
+		"aud":       requester.GetClient().GetID(),
+		"client_id": requester.GetClient().GetID(),
+		"iat":       now.Unix(),
+		"nbf":       now.Unix(),
+		"exp":       expiresAt.Unix(),
+		"scp":       []string(requester.GetGrantedScopes()),
+	}
+
+	if hs, ok := session.(*Session); ok {
+		if len(hs.Extra) > 0 {
+			claims["ext"] = hs.Extra
+		}
+		if len(hs.Actor) > 0 {
+			claims["act"] = hs.Actor
+		}
+		if len(hs.Confirmation) > 0 {
+			claims["cnf"] = hs.Confirmation
+		}
+		if len(hs.Audience) > 0 {
+			claims["aud"] = hs.Audience
+		}
+	}
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", "", errors.WithStack(err)
+	}
+
+	options := new(jose.SignerOptions).WithType("JWT").WithHeader("kid", s.KeyID)
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: s.Algorithm, Key: s.PrivateKey}, options)
+	if err != nil {
+		return "", "", errors.WithStack(err)
+	}
+
+	signed, err := signer.Sign(payload)
+	if err != nil {
+		return "", "", errors.WithStack(err)
+	}
+
+	token, err = signed.CompactSerialize()
+	if err != nil {
+		return "", "", errors.WithStack(err)
+	}
+
+	return token, s.AccessTokenSignature(token), nil
+}
+
+func (s *JWTAccessTokenStrategy) ValidateAccessToken(ctx context.Context, requester fosite.Requester, token string) error {
+	if !IsJWT(token) {
+		return s.CoreStrategy.ValidateAccessToken(ctx, requester, token)
+	}
+
+	signed, err := jose.ParseSigned(token)
+	if err != nil {
+		return errors.Wrap(fosite.ErrTokenSignatureMismatch, err.Error())
+	}
+
+	payload, err := signed.Verify(s.PrivateKey.Public())
+	if err != nil {
+		return errors.Wrap(fosite.ErrTokenSignatureMismatch, err.Error())
+	}
+
+	var claims struct {
+		ExpiresAt int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return errors.Wrap(fosite.ErrTokenSignatureMismatch, err.Error())
+	}
+
+	now := time.Now().UTC()
+	if claims.ExpiresAt > 0 && now.After(time.Unix(claims.ExpiresAt, 0)) {
+		return errors.Wrap(fosite.ErrTokenExpired, "Access token expired")
+	}
+
+	if exp := requester.GetSession().GetExpiresAt(fosite.AccessToken); !exp.IsZero() && now.After(exp) {
+		return errors.Wrap(fosite.ErrTokenExpired, "Access token expired")
+	}
+
+	return nil
+}
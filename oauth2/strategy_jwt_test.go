@@ -0,0 +1,160 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ory/fosite"
+	foauth2 "github.com/ory/fosite/handler/oauth2"
+	"github.com/ory/fosite/storage"
+	"github.com/ory/fosite/token/hmac"
+	"github.com/ory/hydra/pkg"
+	"github.com/square/go-jose"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJWTAccessTokenStrategy(t *testing.T) {
+	key := pkg.MustINSECURELOWENTROPYRSAKEYFORTEST()
+	strategy := &JWTAccessTokenStrategy{
+		CoreStrategy: &foauth2.HMACSHAStrategy{
+			Enigma:                &hmac.HMACStrategy{GlobalSecret: []byte("1234567890123456789012345678901234567890")},
+			AccessTokenLifespan:   time.Hour,
+			AuthorizeCodeLifespan: time.Hour,
+		},
+		PrivateKey:          key,
+		Algorithm:           jose.RS256,
+		KeyID:               "public:foo",
+		Issuer:              "https://hydra.localhost",
+		AccessTokenLifespan: time.Hour,
+	}
+	store := storage.NewExampleStore()
+	ctx := context.Background()
+
+	newRequest := func(subject string, expiresAt time.Time) *fosite.Request {
+		r := fosite.NewRequest()
+		r.Client = &fosite.DefaultClient{ID: "client"}
+		r.GrantedScopes = fosite.Arguments{"photos"}
+		r.Session = NewSession(subject)
+		r.Session.(*Session).Extra = map[string]interface{}{"foo": "bar"}
+		r.Session.SetExpiresAt(fosite.AccessToken, expiresAt)
+		return r
+	}
+
+	resolve := func(token string) (fosite.Requester, error) {
+		or, err := store.GetAccessTokenSession(ctx, strategy.AccessTokenSignature(token), NewSession(""))
+		if err != nil {
+			return nil, err
+		}
+		return or, strategy.ValidateAccessToken(ctx, or, token)
+	}
+
+	t.Run("case=issues signed json web tokens", func(t *testing.T) {
+		token, signature, err := strategy.GenerateAccessToken(ctx, newRequest("peter", time.Now().Add(time.Hour)))
+		require.NoError(t, err)
+		assert.True(t, IsJWT(token))
+		assert.Equal(t, signature, strategy.AccessTokenSignature(token))
+
+		signed, err := jose.ParseSigned(token)
+		require.NoError(t, err)
+		require.Len(t, signed.Signatures, 1)
+		assert.Equal(t, "public:foo", signed.Signatures[0].Header.KeyID)
+
+		payload, err := signed.Verify(&key.PublicKey)
+		require.NoError(t, err)
+
+		var claims map[string]interface{}
+		require.NoError(t, json.Unmarshal(payload, &claims))
+		assert.Equal(t, "peter", claims["sub"])
+		assert.Equal(t, "client", claims["aud"])
+		assert.Equal(t, "client", claims["client_id"])
+		assert.Equal(t, "https://hydra.localhost", claims["iss"])
+		assert.Equal(t, []interface{}{"photos"}, claims["scp"])
+		assert.Equal(t, map[string]interface{}{"foo": "bar"}, claims["ext"])
+	})
+
+	t.Run("case=restricts the audience to the granted audiences", func(t *testing.T) {
+		req := newRequest("peter", time.Now().Add(time.Hour))
+		req.Session.(*Session).Audience = []string{"https://api.localhost", "https://billing.localhost"}
+
+		token, _, err := strategy.GenerateAccessToken(ctx, req)
+		require.NoError(t, err)
+
+		signed, err := jose.ParseSigned(token)
+		require.NoError(t, err)
+
+		payload, err := signed.Verify(&key.PublicKey)
+		require.NoError(t, err)
+
+		var claims map[string]interface{}
+		require.NoError(t, json.Unmarshal(payload, &claims))
+		assert.Equal(t, []interface{}{"https://api.localhost", "https://billing.localhost"}, claims["aud"])
+		assert.Equal(t, "client", claims["client_id"])
+	})
+
+	t.Run("case=resolves opaque and json web tokens to their sessions", func(t *testing.T) {
+		req := newRequest("peter", time.Now().Add(time.Hour))
+
+		jwt, jwtSignature, err := strategy.GenerateAccessToken(ctx, req)
+		require.NoError(t, err)
+		require.NoError(t, store.CreateAccessTokenSession(ctx, jwtSignature, req))
+
+		opaque, opaqueSignature, err := strategy.CoreStrategy.GenerateAccessToken(ctx, req)
+		require.NoError(t, err)
+		require.NoError(t, store.CreateAccessTokenSession(ctx, opaqueSignature, req))
+		assert.False(t, IsJWT(opaque))
+
+		for _, token := range []string{jwt, opaque} {
+			or, err := resolve(token)
+			require.NoError(t, err)
+			assert.Equal(t, "peter", or.GetSession().GetSubject())
+			assert.Equal(t, "client", or.GetClient().GetID())
+		}
+	})
+
+	t.Run("case=rejects expired json web tokens", func(t *testing.T) {
+		req := newRequest("peter", time.Now().Add(-time.Minute))
+
+		token, signature, err := strategy.GenerateAccessToken(ctx, req)
+		require.NoError(t, err)
+		require.NoError(t, store.CreateAccessTokenSession(ctx, signature, req))
+
+		_, err = resolve(token)
+		assert.Error(t, err)
+	})
+
+	t.Run("case=rejects json web tokens with an invalid signature", func(t *testing.T) {
+		req := newRequest("peter", time.Now().Add(time.Hour))
+
+		token, signature, err := strategy.GenerateAccessToken(ctx, req)
+		require.NoError(t, err)
+		require.NoError(t, store.CreateAccessTokenSession(ctx, signature, req))
+
+		other, _, err := strategy.GenerateAccessToken(ctx, newRequest("alice", time.Now().Add(time.Hour)))
+		require.NoError(t, err)
+
+		// Combine the header and claims of another token with the stored signature.
+		tampered := other[:len(other)-len(strategy.AccessTokenSignature(other))] + signature
+		assert.Equal(t, signature, strategy.AccessTokenSignature(tampered))
+		assert.NotEqual(t, token, tampered)
+
+		_, err = resolve(tampered)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,66 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ory/fosite"
+	foauth2 "github.com/ory/fosite/handler/oauth2"
+)
+
+// PrefixedTokenStrategy prepends recognizable prefixes to the opaque access and refresh tokens issued by the embedded
+// strategy, so leaked tokens can be found by secret scanners. The prefix is not part of the token signature, which
+// keeps tokens issued before a prefix was configured or changed working until they expire. Authorize codes are
+// short-lived and never leave the redirect and are therefore not prefixed.
+type PrefixedTokenStrategy struct {
+	foauth2.CoreStrategy
+
+	AccessTokenPrefix  string
+	RefreshTokenPrefix string
+}
+
+func (s *PrefixedTokenStrategy) AccessTokenSignature(token string) string {
+	return s.CoreStrategy.AccessTokenSignature(strings.TrimPrefix(token, s.AccessTokenPrefix))
+}
+
+func (s *PrefixedTokenStrategy) GenerateAccessToken(ctx context.Context, requester fosite.Requester) (token string, signature string, err error) {
+	token, signature, err = s.CoreStrategy.GenerateAccessToken(ctx, requester)
+	if err != nil {
+		return "", "", err
+	}
+	return s.AccessTokenPrefix + token, signature, nil
+}
+
+func (s *PrefixedTokenStrategy) ValidateAccessToken(ctx context.Context, requester fosite.Requester, token string) error {
+	return s.CoreStrategy.ValidateAccessToken(ctx, requester, strings.TrimPrefix(token, s.AccessTokenPrefix))
+}
+
+func (s *PrefixedTokenStrategy) RefreshTokenSignature(token string) string {
+	return s.CoreStrategy.RefreshTokenSignature(strings.TrimPrefix(token, s.RefreshTokenPrefix))
+}
+
+func (s *PrefixedTokenStrategy) GenerateRefreshToken(ctx context.Context, requester fosite.Requester) (token string, signature string, err error) {
+	token, signature, err = s.CoreStrategy.GenerateRefreshToken(ctx, requester)
+	if err != nil {
+		return "", "", err
+	}
+	return s.RefreshTokenPrefix + token, signature, nil
+}
+
+func (s *PrefixedTokenStrategy) ValidateRefreshToken(ctx context.Context, requester fosite.Requester, token string) error {
+	return s.CoreStrategy.ValidateRefreshToken(ctx, requester, strings.TrimPrefix(token, s.RefreshTokenPrefix))
+}
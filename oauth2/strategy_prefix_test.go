@@ -0,0 +1,83 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ory/fosite"
+	foauth2 "github.com/ory/fosite/handler/oauth2"
+	"github.com/ory/fosite/token/hmac"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrefixedTokenStrategy(t *testing.T) {
+	strategy := &PrefixedTokenStrategy{
+		CoreStrategy: &foauth2.HMACSHAStrategy{
+			Enigma:                &hmac.HMACStrategy{GlobalSecret: []byte("1234567890123456789012345678901234567890")},
+			AccessTokenLifespan:   time.Hour,
+			AuthorizeCodeLifespan: time.Hour,
+		},
+		AccessTokenPrefix:  "hyd_at_",
+		RefreshTokenPrefix: "hyd_rt_",
+	}
+	ctx := context.Background()
+
+	req := fosite.NewRequest()
+	req.Client = &fosite.DefaultClient{ID: "client"}
+	req.Session = NewSession("peter")
+	req.Session.SetExpiresAt(fosite.AccessToken, time.Now().Add(time.Hour))
+
+	t.Run("case=prefixes access tokens", func(t *testing.T) {
+		token, signature, err := strategy.GenerateAccessToken(ctx, req)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(token, "hyd_at_"))
+		assert.False(t, IsJWT(token))
+		assert.Equal(t, signature, strategy.AccessTokenSignature(token))
+		assert.NoError(t, strategy.ValidateAccessToken(ctx, req, token))
+	})
+
+	t.Run("case=prefixes refresh tokens", func(t *testing.T) {
+		token, signature, err := strategy.GenerateRefreshToken(ctx, req)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(token, "hyd_rt_"))
+		assert.Equal(t, signature, strategy.RefreshTokenSignature(token))
+		assert.NoError(t, strategy.ValidateRefreshToken(ctx, req, token))
+	})
+
+	t.Run("case=accepts tokens issued without a prefix", func(t *testing.T) {
+		token, signature, err := strategy.CoreStrategy.GenerateAccessToken(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, signature, strategy.AccessTokenSignature(token))
+		assert.NoError(t, strategy.ValidateAccessToken(ctx, req, token))
+	})
+
+	t.Run("case=does not prefix authorize codes", func(t *testing.T) {
+		code, _, err := strategy.GenerateAuthorizeCode(ctx, req)
+		require.NoError(t, err)
+		assert.False(t, strings.HasPrefix(code, "hyd_at_"))
+		assert.False(t, strings.HasPrefix(code, "hyd_rt_"))
+	})
+
+	t.Run("case=rejects tampered tokens", func(t *testing.T) {
+		token, _, err := strategy.GenerateAccessToken(ctx, req)
+		require.NoError(t, err)
+		assert.Error(t, strategy.ValidateAccessToken(ctx, req, token+"a"))
+	})
+}
@@ -0,0 +1,202 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ory/fosite"
+	foauth2 "github.com/ory/fosite/handler/oauth2"
+	"github.com/ory/hydra/firewall"
+	"github.com/pkg/errors"
+)
+
+const (
+	// GrantTypeTokenExchange is the grant type of the OAuth 2.0 Token Exchange, see IETF RFC 8693.
+	GrantTypeTokenExchange = "urn:ietf:params:oauth:grant-type:token-exchange"
+
+	// TokenTypeAccessToken identifies access tokens issued by this server in token exchange requests and responses.
+	TokenTypeAccessToken = "urn:ietf:params:oauth:token-type:access_token"
+
+	// TokenExchangeResource is the resource, relative to the resource prefix, a client needs to be allowed to
+	// "exchange" in order to exchange access tokens issued to the client identified by the last segment.
+	TokenExchangeResource = "oauth2:token-exchange:%s"
+)
+
+// TokenExchangeHandler implements the token exchange grant (IETF RFC 8693) for access tokens issued by this server.
+// The client exchanges a subject token for a new access token issued to the client, on behalf of the subject token's
+// subject and with the client, or the subject of the actor token if one is given, as the actor.
+type TokenExchangeHandler struct {
+	foauth2.CoreStrategy
+	foauth2.CoreStorage
+	ScopeStrategy fosite.ScopeStrategy
+
+	AccessTokenLifespan time.Duration
+	ResourcePrefix      string
+
+	// Allowed decides whether the client may perform the exchange. All exchanges are denied if it is not set.
+	Allowed func(ctx context.Context, r *firewall.AccessRequest) error
+}
+
+func (h *TokenExchangeHandler) HandleTokenEndpointRequest(ctx context.Context, requester fosite.AccessRequester) error {
+	if !requester.GetGrantTypes().Exact(GrantTypeTokenExchange) {
+		return errors.WithStack(fosite.ErrUnknownRequest)
+	}
+
+	client := requester.GetClient()
+	if !client.GetGrantTypes().Has(GrantTypeTokenExchange) {
+		return errors.Wrapf(fosite.ErrInvalidGrant, "The client is not allowed to use grant type %s", GrantTypeTokenExchange)
+	}
+
+	session, ok := requester.GetSession().(*Session)
+	if !ok {
+		return errors.New("Session must be of type *oauth2.Session")
+	}
+
+	form := requester.GetRequestForm()
+	if rt := form.Get("requested_token_type"); rt != "" && rt != TokenTypeAccessToken {
+		return errors.Wrapf(fosite.ErrInvalidRequest, "Requested token type %s is not supported", rt)
+	}
+
+	subject, err := h.introspect(ctx, form.Get("subject_token"), form.Get("subject_token_type"), "subject")
+	if err != nil {
+		return err
+	}
+
+	actor := client.GetID()
+	if token := form.Get("actor_token"); token != "" {
+		or, err := h.introspect(ctx, token, form.Get("actor_token_type"), "actor")
+		if err != nil {
+			return err
+		}
+		actor = or.GetSession().GetSubject()
+	} else if form.Get("actor_token_type") != "" {
+		return errors.Wrap(fosite.ErrInvalidRequest, "Parameter actor_token_type must not be set without actor_token")
+	}
+
+	// The tokens are bearer credentials and must not be stored with the request of the exchanged token.
+	form.Del("subject_token")
+	form.Del("actor_token")
+
+	if h.Allowed == nil {
+		return errors.Wrap(fosite.ErrUnauthorizedClient, "Token exchange is not enabled")
+	} else if err := h.Allowed(ctx, &firewall.AccessRequest{
+		Subject:  client.GetID(),
+		Resource: prefixResource(h.ResourcePrefix, TokenExchangeResource, subject.GetClient().GetID()),
+		Action:   "exchange",
+		Context: map[string]interface{}{
+			"subject": subject.GetSession().GetSubject(),
+			"actor":   actor,
+		},
+	}); err != nil {
+		return errors.Wrapf(fosite.ErrUnauthorizedClient, "The client is not allowed to exchange tokens issued to client %s: %s", subject.GetClient().GetID(), err)
+	}
+
+	// Without requested scopes, the exchanged token is granted all scopes of the subject token the client may request.
+	if len(requester.GetRequestedScopes()) == 0 {
+		for _, scope := range subject.GetGrantedScopes() {
+			if h.ScopeStrategy(client.GetScopes(), scope) {
+				requester.GrantScope(scope)
+			}
+		}
+	}
+	for _, scope := range requester.GetRequestedScopes() {
+		if !h.ScopeStrategy(subject.GetGrantedScopes(), scope) {
+			return errors.Wrapf(fosite.ErrInvalidScope, "Scope %s was not granted to the subject token", scope)
+		} else if !h.ScopeStrategy(client.GetScopes(), scope) {
+			return errors.Wrapf(fosite.ErrInvalidScope, "The client is not allowed to request scope %s", scope)
+		}
+		requester.GrantScope(scope)
+	}
+
+	session.Subject = subject.GetSession().GetSubject()
+	session.Username = subject.GetSession().GetUsername()
+	if ss, ok := subject.GetSession().(*Session); ok {
+		session.Extra = ss.Extra
+		session.Actor = newActor(actor, ss.Actor)
+	} else {
+		session.Actor = newActor(actor, nil)
+	}
+
+	// The exchanged token must not outlive the subject token.
+	expiresAt := time.Now().UTC().Add(h.AccessTokenLifespan)
+	if exp := subject.GetSession().GetExpiresAt(fosite.AccessToken); !exp.IsZero() && exp.Before(expiresAt) {
+		expiresAt = exp
+	}
+	session.SetExpiresAt(fosite.AccessToken, expiresAt)
+
+	return nil
+}
+
+func (h *TokenExchangeHandler) PopulateTokenEndpointResponse(ctx context.Context, requester fosite.AccessRequester, responder fosite.AccessResponder) error {
+	if !requester.GetGrantTypes().Exact(GrantTypeTokenExchange) {
+		return errors.WithStack(fosite.ErrUnknownRequest)
+	}
+
+	token, signature, err := h.CoreStrategy.GenerateAccessToken(ctx, requester)
+	if err != nil {
+		return errors.Wrap(fosite.ErrServerError, err.Error())
+	} else if err := h.CoreStorage.CreateAccessTokenSession(ctx, signature, requester); err != nil {
+		return errors.Wrap(fosite.ErrServerError, err.Error())
+	}
+
+	responder.SetAccessToken(token)
+	responder.SetTokenType("bearer")
+	responder.SetExpiresIn(time.Until(requester.GetSession().GetExpiresAt(fosite.AccessToken)))
+	responder.SetScopes(requester.GetGrantedScopes())
+	responder.SetExtra("issued_token_type", TokenTypeAccessToken)
+	return nil
+}
+
+// introspect returns the request the access token given as the subject or actor token of an exchange was issued for.
+func (h *TokenExchangeHandler) introspect(ctx context.Context, token, tokenType, name string) (fosite.Requester, error) {
+	if token == "" {
+		return nil, errors.Wrapf(fosite.ErrInvalidRequest, "Parameter %s_token is missing", name)
+	} else if tokenType != TokenTypeAccessToken {
+		return nil, errors.Wrapf(fosite.ErrInvalidRequest, "Parameter %s_token_type must be %s", name, TokenTypeAccessToken)
+	}
+
+	sig := h.CoreStrategy.AccessTokenSignature(token)
+	or, err := h.CoreStorage.GetAccessTokenSession(ctx, sig, NewSession(""))
+	if err != nil {
+		return nil, errors.Wrapf(fosite.ErrInvalidRequest, "The %s token is not active: %s", name, err)
+	} else if err := h.CoreStrategy.ValidateAccessToken(ctx, or, token); err != nil {
+		return nil, errors.Wrapf(fosite.ErrInvalidRequest, "The %s token is not active: %s", name, err)
+	}
+
+	return or, nil
+}
+
+// newActor returns the act claim identifying subject as the current actor. If the subject token was itself issued
+// by an exchange, its actor is nested as the prior actor, see IETF RFC 8693 Section 4.1.
+func newActor(subject string, prior map[string]interface{}) map[string]interface{} {
+	actor := map[string]interface{}{"sub": subject}
+	if len(prior) > 0 {
+		actor["act"] = prior
+	}
+	return actor
+}
+
+// prefixResource formats resource and prepends prefix, which defaults to "rn:hydra".
+func prefixResource(prefix, resource string, args ...interface{}) string {
+	if prefix == "" {
+		prefix = "rn:hydra"
+	}
+	prefix = strings.TrimSuffix(prefix, ":")
+	return prefix + ":" + fmt.Sprintf(resource, args...)
+}
@@ -0,0 +1,194 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/ory/fosite"
+	"github.com/ory/fosite/compose"
+	hc "github.com/ory/hydra/client"
+	"github.com/ory/hydra/firewall"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenExchangeHandler(t *testing.T) {
+	fc := &compose.Config{AccessTokenLifespan: time.Hour}
+	store := NewFositeMemoryStore(hc.NewMemoryManager(nil), time.Hour)
+	var allowed *firewall.AccessRequest
+	h := &TokenExchangeHandler{
+		CoreStrategy:        compose.NewOAuth2HMACStrategy(fc, []byte("some super secret secret secret secret")),
+		CoreStorage:         store,
+		ScopeStrategy:       fosite.WildcardScopeStrategy,
+		AccessTokenLifespan: time.Hour,
+		Allowed: func(_ context.Context, r *firewall.AccessRequest) error {
+			allowed = r
+			if r.Subject != "service" {
+				return errors.New("denied")
+			}
+			return nil
+		},
+	}
+
+	issue := func(clientID, subject string, actor map[string]interface{}, expiresAt time.Time, scopes ...string) string {
+		session := NewSession(subject)
+		session.Extra = map[string]interface{}{"foo": "bar"}
+		session.Actor = actor
+		session.SetExpiresAt(fosite.AccessToken, expiresAt)
+		ar := fosite.NewAccessRequest(session)
+		ar.Client = &hc.Client{ID: clientID}
+		for _, scope := range scopes {
+			ar.GrantScope(scope)
+		}
+
+		token, signature, err := h.CoreStrategy.GenerateAccessToken(nil, ar)
+		require.NoError(t, err)
+		require.NoError(t, store.CreateAccessTokenSession(nil, signature, ar))
+		return token
+	}
+
+	exchange := func(clientID string, form url.Values, scopes ...string) (*fosite.AccessRequest, error) {
+		ar := fosite.NewAccessRequest(NewSession(""))
+		ar.Client = &hc.Client{
+			ID:         clientID,
+			GrantTypes: []string{GrantTypeTokenExchange},
+			Scope:      "foo bar baz",
+		}
+		ar.GrantTypes = fosite.Arguments{GrantTypeTokenExchange}
+		ar.Form = form
+		ar.SetRequestedScopes(scopes)
+		return ar, h.HandleTokenEndpointRequest(nil, ar)
+	}
+
+	expiresAt := time.Now().UTC().Add(time.Minute).Round(time.Second)
+	subjectToken := issue("frontend", "alice", nil, expiresAt, "foo", "bar", "other")
+
+	t.Run("case=exchanges the subject token", func(t *testing.T) {
+		ar, err := exchange("service", url.Values{
+			"subject_token":      {subjectToken},
+			"subject_token_type": {TokenTypeAccessToken},
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, &firewall.AccessRequest{
+			Subject:  "service",
+			Resource: "rn:hydra:oauth2:token-exchange:frontend",
+			Action:   "exchange",
+			Context:  map[string]interface{}{"subject": "alice", "actor": "service"},
+		}, allowed)
+		assert.EqualValues(t, fosite.Arguments{"foo", "bar"}, ar.GetGrantedScopes())
+		assert.Empty(t, ar.GetRequestForm().Get("subject_token"))
+
+		session := ar.GetSession().(*Session)
+		assert.Equal(t, "alice", session.Subject)
+		assert.Equal(t, map[string]interface{}{"foo": "bar"}, session.Extra)
+		assert.Equal(t, map[string]interface{}{"sub": "service"}, session.Actor)
+		assert.Equal(t, expiresAt, session.GetExpiresAt(fosite.AccessToken))
+
+		resp := fosite.NewAccessResponse()
+		require.NoError(t, h.PopulateTokenEndpointResponse(nil, ar, resp))
+		assert.Equal(t, TokenTypeAccessToken, resp.GetExtra("issued_token_type"))
+
+		stored, err := store.GetAccessTokenSession(nil, h.CoreStrategy.AccessTokenSignature(resp.GetAccessToken()), NewSession(""))
+		require.NoError(t, err)
+		assert.Equal(t, "service", stored.GetClient().GetID())
+		assert.Equal(t, "alice", stored.GetSession().GetSubject())
+	})
+
+	t.Run("case=nests the actor of an exchanged subject token", func(t *testing.T) {
+		token := issue("service", "alice", map[string]interface{}{"sub": "service"}, expiresAt, "foo")
+		actorToken := issue("service", "batch-job", nil, expiresAt)
+
+		ar, err := exchange("service", url.Values{
+			"subject_token":      {token},
+			"subject_token_type": {TokenTypeAccessToken},
+			"actor_token":        {actorToken},
+			"actor_token_type":   {TokenTypeAccessToken},
+		}, "foo")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{
+			"sub": "batch-job",
+			"act": map[string]interface{}{"sub": "service"},
+		}, ar.GetSession().(*Session).Actor)
+	})
+
+	for k, tc := range []struct {
+		d        string
+		clientID string
+		form     url.Values
+		scopes   []string
+		expected error
+	}{
+		{
+			d:        "not allowed by policy",
+			clientID: "other-service",
+			form:     url.Values{"subject_token": {subjectToken}, "subject_token_type": {TokenTypeAccessToken}},
+			expected: fosite.ErrUnauthorizedClient,
+		},
+		{
+			d:        "missing subject token",
+			clientID: "service",
+			form:     url.Values{"subject_token_type": {TokenTypeAccessToken}},
+			expected: fosite.ErrInvalidRequest,
+		},
+		{
+			d:        "unsupported subject token type",
+			clientID: "service",
+			form:     url.Values{"subject_token": {subjectToken}, "subject_token_type": {"urn:ietf:params:oauth:token-type:id_token"}},
+			expected: fosite.ErrInvalidRequest,
+		},
+		{
+			d:        "unknown subject token",
+			clientID: "service",
+			form:     url.Values{"subject_token": {"foo.bar"}, "subject_token_type": {TokenTypeAccessToken}},
+			expected: fosite.ErrInvalidRequest,
+		},
+		{
+			d:        "scope not granted to the subject token",
+			clientID: "service",
+			form:     url.Values{"subject_token": {subjectToken}, "subject_token_type": {TokenTypeAccessToken}},
+			scopes:   []string{"baz"},
+			expected: fosite.ErrInvalidScope,
+		},
+		{
+			d:        "scope not allowed for the client",
+			clientID: "service",
+			form:     url.Values{"subject_token": {subjectToken}, "subject_token_type": {TokenTypeAccessToken}},
+			scopes:   []string{"other"},
+			expected: fosite.ErrInvalidScope,
+		},
+	} {
+		t.Run(fmt.Sprintf("case=%d/description=%s", k, tc.d), func(t *testing.T) {
+			_, err := exchange(tc.clientID, tc.form, tc.scopes...)
+			assert.Equal(t, tc.expected, errors.Cause(err), "%+v", err)
+		})
+	}
+
+	t.Run("case=requires the grant type", func(t *testing.T) {
+		ar := fosite.NewAccessRequest(NewSession(""))
+		ar.Client = &hc.Client{ID: "service", GrantTypes: []string{"client_credentials"}}
+		ar.GrantTypes = fosite.Arguments{GrantTypeTokenExchange}
+		assert.Equal(t, fosite.ErrInvalidGrant, errors.Cause(h.HandleTokenEndpointRequest(nil, ar)))
+
+		ar.GrantTypes = fosite.Arguments{"client_credentials"}
+		assert.Equal(t, fosite.ErrUnknownRequest, errors.Cause(h.HandleTokenEndpointRequest(nil, ar)))
+	})
+}
@@ -0,0 +1,253 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"context"
+	"time"
+
+	"github.com/ory/fosite"
+	"github.com/ory/hydra/pkg"
+)
+
+// TokenRecord is the history entry of an access or refresh token. Unlike the token itself, it is neither removed when
+// the token is revoked nor when expired tokens are flushed.
+type TokenRecord struct {
+	Signature string
+	TokenType fosite.TokenType
+	RequestID string
+	ClientID  string
+	Subject   string
+	Scopes    []string
+	IssuedAt  time.Time
+
+	// ExpiresAt is zero if the token does not expire.
+	ExpiresAt time.Time
+
+	// RevokedAt is zero if the token has not been revoked. Authorize codes are revoked once they are exchanged.
+	RevokedAt time.Time
+
+	// ParentSignature is the signature of the authorize code or refresh token that was exchanged for this token. It
+	// is empty if the token was not issued by exchanging another one.
+	ParentSignature string
+}
+
+// IsActiveAt returns true if the token had been issued, had not expired and had not been revoked at the given time.
+func (r *TokenRecord) IsActiveAt(at time.Time) bool {
+	if at.Before(r.IssuedAt) {
+		return false
+	} else if !r.ExpiresAt.IsZero() && !at.Before(r.ExpiresAt) {
+		return false
+	} else if !r.RevokedAt.IsZero() && !at.Before(r.RevokedAt) {
+		return false
+	}
+	return true
+}
+
+type TokenHistoryManager interface {
+	CreateTokenRecord(record *TokenRecord) error
+	GetTokenRecord(signature string) (*TokenRecord, error)
+
+	// GetTokenRecordsByRequestID returns all tokens that were issued for the request, ordered by the time they were
+	// issued.
+	GetTokenRecordsByRequestID(requestID string) ([]TokenRecord, error)
+
+	// GetRevokedTokenSignatures returns the signatures of all revoked tokens of the given type which do not expire or
+	// expire after the given time, ordered by signature.
+	GetRevokedTokenSignatures(tokenType fosite.TokenType, expiresAfter time.Time) ([]string, error)
+
+	// RevokeTokenRecord marks the token as revoked at the given time, unless it has been revoked before.
+	RevokeTokenRecord(signature string, at time.Time) error
+
+	// RevokeTokenRecordsByRequestID marks all tokens of the given type that were issued for the request as revoked at
+	// the given time, unless they have been revoked before.
+	RevokeTokenRecordsByRequestID(requestID string, tokenType fosite.TokenType, at time.Time) error
+
+	// AnonymizeSubjectTokenRecords replaces the subject of all records issued on behalf of subject with pseudonym and
+	// returns how many records were changed. Records are kept, because revoked tokens are looked up in the history.
+	AnonymizeSubjectTokenRecords(subject, pseudonym string) (int, error)
+}
+
+type tokenExchangeContextKey struct{}
+
+// tokenExchange remembers the authorize code or refresh token that a token request exchanges.
+type tokenExchange struct {
+	signature string
+}
+
+// withTokenExchange returns a context in which TokenHistoryStorage records the authorize code or refresh token that
+// is looked up as the parent of the tokens that are issued.
+func withTokenExchange(ctx context.Context) context.Context {
+	return context.WithValue(ctx, tokenExchangeContextKey{}, &tokenExchange{})
+}
+
+func rememberTokenExchange(ctx context.Context, signature string) {
+	if exchange, ok := ctx.Value(tokenExchangeContextKey{}).(*tokenExchange); ok {
+		exchange.signature = signature
+	}
+}
+
+// TokenHistoryStorage records issued, revoked and deleted authorize codes, access and refresh tokens in History.
+type TokenHistoryStorage struct {
+	pkg.FositeStorer
+	History TokenHistoryManager
+
+	// AccessTokenLifespan is used to compute the expiry of access tokens whose session does not carry one.
+	AccessTokenLifespan time.Duration
+}
+
+func (s *TokenHistoryStorage) record(ctx context.Context, signature string, tokenType fosite.TokenType, requester fosite.Requester) error {
+	now := time.Now().UTC()
+	record := &TokenRecord{
+		Signature: signature,
+		TokenType: tokenType,
+		RequestID: requester.GetID(),
+		ClientID:  requester.GetClient().GetID(),
+		Scopes:    requester.GetGrantedScopes(),
+		IssuedAt:  now,
+	}
+
+	if exchange, ok := ctx.Value(tokenExchangeContextKey{}).(*tokenExchange); ok {
+		record.ParentSignature = exchange.signature
+	}
+
+	if session := requester.GetSession(); session != nil {
+		record.Subject = session.GetSubject()
+		record.ExpiresAt = session.GetExpiresAt(tokenType).UTC()
+	}
+
+	if record.ExpiresAt.IsZero() && tokenType == fosite.AccessToken && s.AccessTokenLifespan > 0 {
+		record.ExpiresAt = now.Add(s.AccessTokenLifespan)
+	}
+
+	return s.History.CreateTokenRecord(record)
+}
+
+func (s *TokenHistoryStorage) CreateAuthorizeCodeSession(ctx context.Context, signature string, requester fosite.Requester) error {
+	if err := s.record(ctx, signature, fosite.AuthorizeCode, requester); err != nil {
+		return err
+	}
+	return s.FositeStorer.CreateAuthorizeCodeSession(ctx, signature, requester)
+}
+
+func (s *TokenHistoryStorage) GetAuthorizeCodeSession(ctx context.Context, signature string, session fosite.Session) (fosite.Requester, error) {
+	requester, err := s.FositeStorer.GetAuthorizeCodeSession(ctx, signature, session)
+	if err == nil {
+		rememberTokenExchange(ctx, signature)
+	}
+	return requester, err
+}
+
+func (s *TokenHistoryStorage) DeleteAuthorizeCodeSession(ctx context.Context, signature string) error {
+	if err := s.FositeStorer.DeleteAuthorizeCodeSession(ctx, signature); err != nil {
+		return err
+	}
+	return s.History.RevokeTokenRecord(signature, time.Now().UTC())
+}
+
+func (s *TokenHistoryStorage) GetRefreshTokenSession(ctx context.Context, signature string, session fosite.Session) (fosite.Requester, error) {
+	requester, err := s.FositeStorer.GetRefreshTokenSession(ctx, signature, session)
+	if err == nil {
+		rememberTokenExchange(ctx, signature)
+	}
+	return requester, err
+}
+
+func (s *TokenHistoryStorage) CreateAccessTokenSession(ctx context.Context, signature string, requester fosite.Requester) error {
+	if err := s.record(ctx, signature, fosite.AccessToken, requester); err != nil {
+		return err
+	}
+	return s.FositeStorer.CreateAccessTokenSession(ctx, signature, requester)
+}
+
+func (s *TokenHistoryStorage) CreateImplicitAccessTokenSession(ctx context.Context, signature string, requester fosite.Requester) error {
+	if err := s.record(ctx, signature, fosite.AccessToken, requester); err != nil {
+		return err
+	}
+	return s.FositeStorer.CreateImplicitAccessTokenSession(ctx, signature, requester)
+}
+
+func (s *TokenHistoryStorage) CreateRefreshTokenSession(ctx context.Context, signature string, requester fosite.Requester) error {
+	if err := s.record(ctx, signature, fosite.RefreshToken, requester); err != nil {
+		return err
+	}
+	return s.FositeStorer.CreateRefreshTokenSession(ctx, signature, requester)
+}
+
+func (s *TokenHistoryStorage) DeleteAccessTokenSession(ctx context.Context, signature string) error {
+	if err := s.FositeStorer.DeleteAccessTokenSession(ctx, signature); err != nil {
+		return err
+	}
+	return s.History.RevokeTokenRecord(signature, time.Now().UTC())
+}
+
+func (s *TokenHistoryStorage) DeleteRefreshTokenSession(ctx context.Context, signature string) error {
+	if err := s.FositeStorer.DeleteRefreshTokenSession(ctx, signature); err != nil {
+		return err
+	}
+	return s.History.RevokeTokenRecord(signature, time.Now().UTC())
+}
+
+func (s *TokenHistoryStorage) RevokeAccessToken(ctx context.Context, requestID string) error {
+	if err := s.FositeStorer.RevokeAccessToken(ctx, requestID); err != nil {
+		return err
+	}
+	return s.History.RevokeTokenRecordsByRequestID(requestID, fosite.AccessToken, time.Now().UTC())
+}
+
+func (s *TokenHistoryStorage) RevokeClientTokens(ctx context.Context, clientID string) ([]string, error) {
+	requestIDs, err := s.FositeStorer.RevokeClientTokens(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	return requestIDs, s.revokeTokenRecords(requestIDs)
+}
+
+func (s *TokenHistoryStorage) RevokeSubjectTokens(ctx context.Context, subject string) ([]string, error) {
+	requestIDs, err := s.FositeStorer.RevokeSubjectTokens(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+	return requestIDs, s.revokeTokenRecords(requestIDs)
+}
+
+func (s *TokenHistoryStorage) RevokeSubjectClientTokens(ctx context.Context, subject, clientID string) ([]string, error) {
+	requestIDs, err := s.FositeStorer.RevokeSubjectClientTokens(ctx, subject, clientID)
+	if err != nil {
+		return nil, err
+	}
+	return requestIDs, s.revokeTokenRecords(requestIDs)
+}
+
+// revokeTokenRecords marks the access and refresh token records of the requests as revoked.
+func (s *TokenHistoryStorage) revokeTokenRecords(requestIDs []string) error {
+	now := time.Now().UTC()
+	for _, id := range requestIDs {
+		if err := s.History.RevokeTokenRecordsByRequestID(id, fosite.AccessToken, now); err != nil {
+			return err
+		}
+		if err := s.History.RevokeTokenRecordsByRequestID(id, fosite.RefreshToken, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *TokenHistoryStorage) RevokeRefreshToken(ctx context.Context, requestID string) error {
+	if err := s.FositeStorer.RevokeRefreshToken(ctx, requestID); err != nil {
+		return err
+	}
+	return s.History.RevokeTokenRecordsByRequestID(requestID, fosite.RefreshToken, time.Now().UTC())
+}
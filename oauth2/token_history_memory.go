@@ -0,0 +1,126 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ory/fosite"
+	"github.com/ory/hydra/pkg"
+	"github.com/pkg/errors"
+)
+
+type TokenHistoryMemoryManager struct {
+	records map[string]TokenRecord
+	sync.RWMutex
+}
+
+func NewTokenHistoryMemoryManager() *TokenHistoryMemoryManager {
+	return &TokenHistoryMemoryManager{records: map[string]TokenRecord{}}
+}
+
+func (m *TokenHistoryMemoryManager) CreateTokenRecord(record *TokenRecord) error {
+	m.Lock()
+	defer m.Unlock()
+	m.records[record.Signature] = *record
+	return nil
+}
+
+func (m *TokenHistoryMemoryManager) GetTokenRecord(signature string) (*TokenRecord, error) {
+	m.RLock()
+	defer m.RUnlock()
+	if record, found := m.records[signature]; !found {
+		return nil, errors.Wrap(pkg.ErrNotFound, "")
+	} else {
+		return &record, nil
+	}
+}
+
+func (m *TokenHistoryMemoryManager) GetTokenRecordsByRequestID(requestID string) ([]TokenRecord, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	records := []TokenRecord{}
+	for _, record := range m.records {
+		if record.RequestID == requestID {
+			records = append(records, record)
+		}
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].IssuedAt.Equal(records[j].IssuedAt) {
+			return records[i].Signature < records[j].Signature
+		}
+		return records[i].IssuedAt.Before(records[j].IssuedAt)
+	})
+	return records, nil
+}
+
+func (m *TokenHistoryMemoryManager) GetRevokedTokenSignatures(tokenType fosite.TokenType, expiresAfter time.Time) ([]string, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	signatures := []string{}
+	for signature, record := range m.records {
+		if record.TokenType != tokenType || record.RevokedAt.IsZero() {
+			continue
+		} else if !record.ExpiresAt.IsZero() && !record.ExpiresAt.After(expiresAfter) {
+			continue
+		}
+		signatures = append(signatures, signature)
+	}
+
+	sort.Strings(signatures)
+	return signatures, nil
+}
+
+func (m *TokenHistoryMemoryManager) RevokeTokenRecord(signature string, at time.Time) error {
+	m.Lock()
+	defer m.Unlock()
+	if record, found := m.records[signature]; found && record.RevokedAt.IsZero() {
+		record.RevokedAt = at
+		m.records[signature] = record
+	}
+	return nil
+}
+
+func (m *TokenHistoryMemoryManager) RevokeTokenRecordsByRequestID(requestID string, tokenType fosite.TokenType, at time.Time) error {
+	m.Lock()
+	defer m.Unlock()
+	for signature, record := range m.records {
+		if record.RequestID == requestID && record.TokenType == tokenType && record.RevokedAt.IsZero() {
+			record.RevokedAt = at
+			m.records[signature] = record
+		}
+	}
+	return nil
+}
+
+func (m *TokenHistoryMemoryManager) AnonymizeSubjectTokenRecords(subject, pseudonym string) (int, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	var n int
+	for signature, record := range m.records {
+		if record.Subject == subject {
+			record.Subject = pseudonym
+			m.records[signature] = record
+			n++
+		}
+	}
+	return n, nil
+}
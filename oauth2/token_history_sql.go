@@ -0,0 +1,200 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/ory/fosite"
+	"github.com/ory/hydra/pkg"
+	"github.com/pkg/errors"
+	"github.com/rubenv/sql-migrate"
+)
+
+var tokenHistoryMigrations = &migrate.MemoryMigrationSource{
+	Migrations: []*migrate.Migration{
+		{
+			Id: "1",
+			Up: []string{
+				`CREATE TABLE IF NOT EXISTS hydra_oauth2_token_history (
+	signature	varchar(255) NOT NULL PRIMARY KEY,
+	token_type	varchar(32) NOT NULL,
+	request_id	varchar(40) NOT NULL,
+	client_id	text NOT NULL,
+	subject		text NOT NULL,
+	scope		text NOT NULL,
+	issued_at	timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	expires_at	timestamp NULL,
+	revoked_at	timestamp NULL
+)`,
+				"CREATE INDEX hydra_oauth2_token_history_request_id_idx ON hydra_oauth2_token_history (request_id)",
+			},
+			Down: []string{
+				"DROP TABLE hydra_oauth2_token_history",
+			},
+		},
+		{
+			Id: "2",
+			Up: []string{
+				`ALTER TABLE hydra_oauth2_token_history ADD parent_signature varchar(255) NOT NULL DEFAULT ''`,
+			},
+			Down: []string{
+				`ALTER TABLE hydra_oauth2_token_history DROP COLUMN parent_signature`,
+			},
+		},
+	},
+}
+
+type tokenRecordSqlData struct {
+	Signature       string     `db:"signature"`
+	TokenType       string     `db:"token_type"`
+	RequestID       string     `db:"request_id"`
+	ClientID        string     `db:"client_id"`
+	Subject         string     `db:"subject"`
+	Scope           string     `db:"scope"`
+	IssuedAt        time.Time  `db:"issued_at"`
+	ExpiresAt       *time.Time `db:"expires_at"`
+	RevokedAt       *time.Time `db:"revoked_at"`
+	ParentSignature string     `db:"parent_signature"`
+}
+
+func nullableTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func (d *tokenRecordSqlData) toTokenRecord() *TokenRecord {
+	r := &TokenRecord{
+		Signature:       d.Signature,
+		TokenType:       fosite.TokenType(d.TokenType),
+		RequestID:       d.RequestID,
+		ClientID:        d.ClientID,
+		Subject:         d.Subject,
+		Scopes:          strings.Fields(d.Scope),
+		IssuedAt:        d.IssuedAt.UTC(),
+		ParentSignature: d.ParentSignature,
+	}
+	if d.ExpiresAt != nil {
+		r.ExpiresAt = d.ExpiresAt.UTC()
+	}
+	if d.RevokedAt != nil {
+		r.RevokedAt = d.RevokedAt.UTC()
+	}
+	return r
+}
+
+type TokenHistorySQLManager struct {
+	DB *sqlx.DB
+}
+
+// Migrations returns the SQL migrations embedded in the binary.
+func (m *TokenHistorySQLManager) Migrations() *migrate.MemoryMigrationSource {
+	return tokenHistoryMigrations
+}
+
+func (m *TokenHistorySQLManager) CreateSchemas() (int, error) {
+	migrate.SetTable("hydra_oauth2_token_history_migration")
+	n, err := migrate.Exec(m.DB.DB, m.DB.DriverName(), tokenHistoryMigrations, migrate.Up)
+	if err != nil {
+		return 0, errors.Wrapf(err, "Could not migrate sql schema, applied %d migrations", n)
+	}
+	return n, nil
+}
+
+func (m *TokenHistorySQLManager) CreateTokenRecord(record *TokenRecord) error {
+	data := &tokenRecordSqlData{
+		Signature:       record.Signature,
+		TokenType:       string(record.TokenType),
+		RequestID:       record.RequestID,
+		ClientID:        record.ClientID,
+		Subject:         record.Subject,
+		Scope:           strings.Join(record.Scopes, " "),
+		IssuedAt:        record.IssuedAt,
+		ExpiresAt:       nullableTime(record.ExpiresAt),
+		RevokedAt:       nullableTime(record.RevokedAt),
+		ParentSignature: record.ParentSignature,
+	}
+
+	if _, err := m.DB.NamedExec(`INSERT INTO hydra_oauth2_token_history
+	(signature, token_type, request_id, client_id, subject, scope, issued_at, expires_at, revoked_at, parent_signature)
+	VALUES (:signature, :token_type, :request_id, :client_id, :subject, :scope, :issued_at, :expires_at, :revoked_at, :parent_signature)`, data); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func (m *TokenHistorySQLManager) GetTokenRecord(signature string) (*TokenRecord, error) {
+	var d tokenRecordSqlData
+	if err := m.DB.Get(&d, m.DB.Rebind("SELECT * FROM hydra_oauth2_token_history WHERE signature=?"), signature); err == sql.ErrNoRows {
+		return nil, errors.Wrap(pkg.ErrNotFound, "")
+	} else if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return d.toTokenRecord(), nil
+}
+
+func (m *TokenHistorySQLManager) GetTokenRecordsByRequestID(requestID string) ([]TokenRecord, error) {
+	var d []tokenRecordSqlData
+	if err := m.DB.Select(&d, m.DB.Rebind("SELECT * FROM hydra_oauth2_token_history WHERE request_id=? ORDER BY issued_at, signature"), requestID); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	records := make([]TokenRecord, len(d))
+	for k := range d {
+		records[k] = *d[k].toTokenRecord()
+	}
+	return records, nil
+}
+
+func (m *TokenHistorySQLManager) GetRevokedTokenSignatures(tokenType fosite.TokenType, expiresAfter time.Time) ([]string, error) {
+	signatures := []string{}
+	if err := m.DB.Select(&signatures, m.DB.Rebind(`SELECT signature FROM hydra_oauth2_token_history
+	WHERE token_type=? AND revoked_at IS NOT NULL AND (expires_at IS NULL OR expires_at > ?) ORDER BY signature`), string(tokenType), expiresAfter); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return signatures, nil
+}
+
+func (m *TokenHistorySQLManager) RevokeTokenRecord(signature string, at time.Time) error {
+	if _, err := m.DB.Exec(m.DB.Rebind("UPDATE hydra_oauth2_token_history SET revoked_at=? WHERE signature=? AND revoked_at IS NULL"), at, signature); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func (m *TokenHistorySQLManager) RevokeTokenRecordsByRequestID(requestID string, tokenType fosite.TokenType, at time.Time) error {
+	if _, err := m.DB.Exec(m.DB.Rebind("UPDATE hydra_oauth2_token_history SET revoked_at=? WHERE request_id=? AND token_type=? AND revoked_at IS NULL"), at, requestID, string(tokenType)); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func (m *TokenHistorySQLManager) AnonymizeSubjectTokenRecords(subject, pseudonym string) (int, error) {
+	res, err := m.DB.Exec(m.DB.Rebind("UPDATE hydra_oauth2_token_history SET subject=? WHERE subject=?"), pseudonym, subject)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	return int(n), nil
+}
@@ -0,0 +1,201 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/ory/fosite"
+	"github.com/ory/hydra/client"
+	"github.com/ory/hydra/integration"
+	. "github.com/ory/hydra/oauth2"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var tokenHistoryManagers = map[string]TokenHistoryManager{
+	"memory": NewTokenHistoryMemoryManager(),
+}
+
+func connectToMySQLTokenHistory() {
+	s := &TokenHistorySQLManager{DB: integration.ConnectToMySQL()}
+	if _, err := s.CreateSchemas(); err != nil {
+		log.Fatalf("Could not create mysql schema: %v", err)
+	}
+
+	tokenHistoryManagers["mysql"] = s
+}
+
+func connectToPGTokenHistory() {
+	s := &TokenHistorySQLManager{DB: integration.ConnectToPostgres()}
+	if _, err := s.CreateSchemas(); err != nil {
+		log.Fatalf("Could not create postgres schema: %v", err)
+	}
+
+	tokenHistoryManagers["postgres"] = s
+}
+
+func TestTokenRecordIsActiveAt(t *testing.T) {
+	now := time.Now().UTC()
+	for k, tc := range []struct {
+		r      TokenRecord
+		at     time.Time
+		expect bool
+	}{
+		{r: TokenRecord{IssuedAt: now}, at: now.Add(-time.Second), expect: false},
+		{r: TokenRecord{IssuedAt: now}, at: now, expect: true},
+		{r: TokenRecord{IssuedAt: now}, at: now.Add(time.Hour * 24 * 365), expect: true},
+		{r: TokenRecord{IssuedAt: now, ExpiresAt: now.Add(time.Hour)}, at: now.Add(time.Minute), expect: true},
+		{r: TokenRecord{IssuedAt: now, ExpiresAt: now.Add(time.Hour)}, at: now.Add(time.Hour), expect: false},
+		{r: TokenRecord{IssuedAt: now, ExpiresAt: now.Add(time.Hour), RevokedAt: now.Add(time.Minute)}, at: now.Add(time.Second), expect: true},
+		{r: TokenRecord{IssuedAt: now, ExpiresAt: now.Add(time.Hour), RevokedAt: now.Add(time.Minute)}, at: now.Add(time.Minute), expect: false},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			assert.Equal(t, tc.expect, tc.r.IsActiveAt(tc.at))
+		})
+	}
+}
+
+func TestTokenHistoryManagers(t *testing.T) {
+	issued := time.Now().UTC().Round(time.Second).Add(-time.Hour)
+	first := issued.Add(time.Minute)
+	second := issued.Add(time.Minute * 2)
+
+	for k, m := range tokenHistoryManagers {
+		t.Run(fmt.Sprintf("case=%s", k), func(t *testing.T) {
+			_, err := m.GetTokenRecord("does-not-exist")
+			assert.Error(t, err)
+
+			for _, r := range []*TokenRecord{
+				{Signature: "history-at-1", TokenType: fosite.AccessToken, RequestID: "history-request-1", ClientID: "foo", Subject: "peter", Scopes: []string{"foo", "bar"}, IssuedAt: issued, ExpiresAt: issued.Add(time.Hour)},
+				{Signature: "history-rt-1", TokenType: fosite.RefreshToken, RequestID: "history-request-1", ClientID: "foo", Subject: "peter", IssuedAt: issued},
+				{Signature: "history-at-2", TokenType: fosite.AccessToken, RequestID: "history-request-2", ClientID: "foo", Subject: "peter", IssuedAt: issued},
+				{Signature: "history-at-3", TokenType: fosite.AccessToken, RequestID: "history-request-1", ClientID: "foo", Subject: "peter", IssuedAt: second, ParentSignature: "history-rt-1"},
+			} {
+				require.NoError(t, m.CreateTokenRecord(r))
+			}
+
+			got, err := m.GetTokenRecord("history-at-1")
+			require.NoError(t, err)
+			assert.Equal(t, fosite.AccessToken, got.TokenType)
+			assert.Equal(t, "history-request-1", got.RequestID)
+			assert.Equal(t, "foo", got.ClientID)
+			assert.Equal(t, "peter", got.Subject)
+			assert.Equal(t, []string{"foo", "bar"}, got.Scopes)
+			assert.Equal(t, issued.Unix(), got.IssuedAt.Unix())
+			assert.Equal(t, issued.Add(time.Hour).Unix(), got.ExpiresAt.Unix())
+			assert.True(t, got.RevokedAt.IsZero())
+
+			require.NoError(t, m.RevokeTokenRecordsByRequestID("history-request-1", fosite.AccessToken, first))
+			require.NoError(t, m.RevokeTokenRecordsByRequestID("history-request-1", fosite.AccessToken, second))
+			require.NoError(t, m.RevokeTokenRecord("history-at-2", first))
+			require.NoError(t, m.RevokeTokenRecord("history-at-2", second))
+
+			got, err = m.GetTokenRecord("history-at-1")
+			require.NoError(t, err)
+			assert.Equal(t, first.Unix(), got.RevokedAt.Unix())
+
+			got, err = m.GetTokenRecord("history-at-2")
+			require.NoError(t, err)
+			assert.Equal(t, first.Unix(), got.RevokedAt.Unix())
+
+			got, err = m.GetTokenRecord("history-rt-1")
+			require.NoError(t, err)
+			assert.True(t, got.RevokedAt.IsZero())
+			assert.True(t, got.ExpiresAt.IsZero())
+
+			records, err := m.GetTokenRecordsByRequestID("history-request-1")
+			require.NoError(t, err)
+			require.Len(t, records, 3)
+			assert.Equal(t, "history-at-1", records[0].Signature)
+			assert.Equal(t, "history-rt-1", records[1].Signature)
+			assert.Equal(t, "history-at-3", records[2].Signature)
+			assert.Equal(t, "history-rt-1", records[2].ParentSignature)
+
+			records, err = m.GetTokenRecordsByRequestID("does-not-exist")
+			require.NoError(t, err)
+			assert.Empty(t, records)
+
+			signatures, err := m.GetRevokedTokenSignatures(fosite.AccessToken, issued.Add(time.Minute*30))
+			require.NoError(t, err)
+			assert.Subset(t, signatures, []string{"history-at-1", "history-at-2", "history-at-3"})
+			assert.NotContains(t, signatures, "history-rt-1")
+
+			signatures, err = m.GetRevokedTokenSignatures(fosite.AccessToken, issued.Add(time.Hour))
+			require.NoError(t, err)
+			assert.Subset(t, signatures, []string{"history-at-2", "history-at-3"})
+			assert.NotContains(t, signatures, "history-at-1")
+
+			subject := "alice-" + uuid.New()
+			require.NoError(t, m.CreateTokenRecord(&TokenRecord{Signature: "history-at-" + subject, TokenType: fosite.AccessToken, RequestID: "history-request-3", ClientID: "foo", Subject: subject, IssuedAt: issued}))
+			n, err := m.AnonymizeSubjectTokenRecords(subject, "hmac:alice")
+			require.NoError(t, err)
+			assert.Equal(t, 1, n)
+
+			got, err = m.GetTokenRecord("history-at-" + subject)
+			require.NoError(t, err)
+			assert.Equal(t, "hmac:alice", got.Subject)
+		})
+	}
+}
+
+func TestTokenHistoryStorage(t *testing.T) {
+	history := NewTokenHistoryMemoryManager()
+	store := &TokenHistoryStorage{
+		FositeStorer:        NewFositeMemoryStore(nil, time.Hour),
+		History:             history,
+		AccessTokenLifespan: time.Hour,
+	}
+	ctx := context.Background()
+	req := &fosite.Request{
+		ID:            "history-storage-1",
+		RequestedAt:   time.Now().UTC(),
+		Client:        &client.Client{ID: "foobar"},
+		GrantedScopes: fosite.Arguments{"foo"},
+		Form:          url.Values{},
+		Session:       &fosite.DefaultSession{Subject: "peter"},
+	}
+
+	require.NoError(t, store.CreateAccessTokenSession(ctx, "history-storage-at", req))
+	require.NoError(t, store.CreateRefreshTokenSession(ctx, "history-storage-rt", req))
+
+	at, err := history.GetTokenRecord("history-storage-at")
+	require.NoError(t, err)
+	assert.Equal(t, "foobar", at.ClientID)
+	assert.Equal(t, "peter", at.Subject)
+	assert.Equal(t, []string{"foo"}, at.Scopes)
+	assert.WithinDuration(t, at.IssuedAt.Add(time.Hour), at.ExpiresAt, time.Second)
+	assert.True(t, at.IsActiveAt(time.Now().UTC()))
+
+	require.NoError(t, store.RevokeAccessToken(ctx, req.ID))
+	require.NoError(t, store.DeleteRefreshTokenSession(ctx, "history-storage-rt"))
+
+	_, err = store.GetAccessTokenSession(ctx, "history-storage-at", new(fosite.DefaultSession))
+	assert.Error(t, err)
+
+	for _, signature := range []string{"history-storage-at", "history-storage-rt"} {
+		r, err := history.GetTokenRecord(signature)
+		require.NoError(t, err)
+		assert.False(t, r.RevokedAt.IsZero())
+		assert.True(t, r.IsActiveAt(r.IssuedAt))
+		assert.False(t, r.IsActiveAt(time.Now().UTC().Add(time.Second)))
+	}
+}
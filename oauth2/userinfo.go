@@ -0,0 +1,112 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"crypto"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/ory/fosite"
+	"github.com/ory/hydra/client"
+	"github.com/pkg/errors"
+	"github.com/square/go-jose"
+)
+
+// UserinfoSigner signs the userinfo responses of clients which registered userinfo_signed_response_alg using the
+// OpenID Connect key.
+type UserinfoSigner struct {
+	PrivateKey crypto.Signer
+	Algorithm  jose.SignatureAlgorithm
+	KeyID      string
+}
+
+// Sign returns the userinfo claims as a JSON Web Token issued by issuer to the client clientID. The claims are not
+// modified.
+func (s *UserinfoSigner) Sign(issuer, clientID string, claims map[string]interface{}) (string, error) {
+	signed := map[string]interface{}{}
+	for k, v := range claims {
+		signed[k] = v
+	}
+	signed["iss"] = issuer
+	signed["aud"] = clientID
+	signed["iat"] = time.Now().UTC().Unix()
+
+	payload, err := json.Marshal(signed)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	options := new(jose.SignerOptions).WithType("JWT").WithHeader("kid", s.KeyID)
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: s.Algorithm, Key: s.PrivateKey}, options)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	jws, err := signer.Sign(payload)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	token, err := jws.CompactSerialize()
+	return token, errors.WithStack(err)
+}
+
+// writeUserinfoJWT writes the userinfo claims as a JSON Web Token, signed if c registered a userinfo signing algorithm
+// and then encrypted if c registered a userinfo encryption algorithm.
+func (h *Handler) writeUserinfoJWT(w http.ResponseWriter, r *http.Request, c *client.Client, claims map[string]interface{}) {
+	var payload []byte
+	var contentType string
+	if alg := c.UserinfoSignedResponseAlg; alg != "" {
+		if h.UserinfoSigner == nil {
+			h.H.WriteError(w, r, errors.Wrapf(fosite.ErrServerError, "Client %s requires signed userinfo responses but userinfo signing is disabled", c.ID))
+			return
+		} else if alg != string(h.UserinfoSigner.Algorithm) {
+			h.H.WriteError(w, r, errors.Wrapf(fosite.ErrServerError, "Client %s requires userinfo responses signed using %s but the OpenID Connect key uses %s", c.ID, alg, h.UserinfoSigner.Algorithm))
+			return
+		}
+
+		token, err := h.UserinfoSigner.Sign(h.issuer(r), c.ID, claims)
+		if err != nil {
+			h.H.WriteError(w, r, err)
+			return
+		}
+		payload, contentType = []byte(token), "JWT"
+	} else {
+		var err error
+		if payload, err = json.Marshal(claims); err != nil {
+			h.H.WriteError(w, r, errors.WithStack(err))
+			return
+		}
+	}
+
+	if c.UserinfoEncryptedResponseAlg != "" {
+		if h.ResponseEncrypter == nil {
+			h.H.WriteError(w, r, errors.Wrapf(fosite.ErrServerError, "Client %s requires encrypted userinfo responses but response encryption is disabled", c.ID))
+			return
+		}
+
+		encrypted, err := h.ResponseEncrypter.EncryptUserinfo(r.Context(), c, payload, contentType)
+		if err != nil {
+			h.H.WriteError(w, r, err)
+			return
+		}
+		payload = []byte(encrypted)
+	}
+
+	w.Header().Set("Content-Type", "application/jwt")
+	w.Write(payload)
+}
@@ -0,0 +1,101 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ory/herodot"
+	"github.com/ory/hydra/client"
+	"github.com/ory/hydra/pkg"
+	"github.com/square/go-jose"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteUserinfoJWT(t *testing.T) {
+	key := pkg.MustINSECURELOWENTROPYRSAKEYFORTEST()
+	h := &Handler{
+		H:                 herodot.NewJSONWriter(nil),
+		Issuer:            "https://hydra.localhost",
+		UserinfoSigner:    &UserinfoSigner{PrivateKey: key, Algorithm: jose.RS256, KeyID: "public:foo"},
+		ResponseEncrypter: &ResponseEncrypter{},
+	}
+	claims := map[string]interface{}{"sub": "peter", "email": "peter@hydra.localhost"}
+
+	write := func(t *testing.T, c *client.Client) *http.Response {
+		w := httptest.NewRecorder()
+		h.writeUserinfoJWT(w, httptest.NewRequest("POST", "https://hydra.localhost/userinfo", nil), c, claims)
+		return w.Result()
+	}
+
+	verify := func(t *testing.T, token string) map[string]interface{} {
+		signed, err := jose.ParseSigned(token)
+		require.NoError(t, err)
+		assert.Equal(t, "public:foo", signed.Signatures[0].Header.KeyID)
+
+		payload, err := signed.Verify(&key.PublicKey)
+		require.NoError(t, err)
+
+		var result map[string]interface{}
+		require.NoError(t, json.Unmarshal(payload, &result))
+		return result
+	}
+
+	t.Run("case=signs claims", func(t *testing.T) {
+		res := write(t, &client.Client{ID: "photos", UserinfoSignedResponseAlg: "RS256"})
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "application/jwt", res.Header.Get("Content-Type"))
+
+		body, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+
+		result := verify(t, string(body))
+		assert.Equal(t, "peter", result["sub"])
+		assert.Equal(t, "peter@hydra.localhost", result["email"])
+		assert.Equal(t, "https://hydra.localhost", result["iss"])
+		assert.Equal(t, "photos", result["aud"])
+		assert.Nil(t, claims["iss"])
+	})
+
+	t.Run("case=signs and encrypts claims", func(t *testing.T) {
+		res := write(t, &client.Client{
+			ID:                           "photos",
+			UserinfoSignedResponseAlg:    "RS256",
+			UserinfoEncryptedResponseAlg: "RSA-OAEP",
+			JSONWebKeys:                  &jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: &key.PublicKey, KeyID: "enc"}}},
+		})
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		body, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+
+		encrypted, err := jose.ParseEncrypted(string(body))
+		require.NoError(t, err)
+		token, err := encrypted.Decrypt(key)
+		require.NoError(t, err)
+
+		assert.Equal(t, "peter", verify(t, string(token))["sub"])
+	})
+
+	t.Run("case=rejects algorithms not matching the OpenID Connect key", func(t *testing.T) {
+		res := write(t, &client.Client{ID: "photos", UserinfoSignedResponseAlg: "EdDSA"})
+		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+	})
+}
@@ -0,0 +1,77 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math"
+)
+
+// BloomFilter is a bloom filter using double hashing: the i-th of the K bit positions of a value is
+// (h1 + i*h2) mod M, where h1 and h2 are the first and second eight bytes of the value's SHA-256 hash read as big
+// endian unsigned integers. Bit j is stored in byte j/8 at position j%8, counting from the least significant bit.
+type BloomFilter struct {
+	Bits []byte
+	M    uint64
+	K    uint64
+}
+
+// NewBloomFilter returns a bloom filter sized for n values with the given false positive rate.
+func NewBloomFilter(n int, falsePositiveRate float64) *BloomFilter {
+	if n < 1 {
+		n = 1
+	}
+
+	m := uint64(math.Ceil(-float64(n) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+	if m < 8 {
+		m = 8
+	}
+
+	k := uint64(math.Ceil(float64(m) / float64(n) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+
+	return &BloomFilter{Bits: make([]byte, (m+7)/8), M: m, K: k}
+}
+
+func (f *BloomFilter) positions(value string) []uint64 {
+	sum := sha256.Sum256([]byte(value))
+	h1, h2 := binary.BigEndian.Uint64(sum[:8]), binary.BigEndian.Uint64(sum[8:16])
+
+	positions := make([]uint64, f.K)
+	for i := range positions {
+		positions[i] = (h1 + uint64(i)*h2) % f.M
+	}
+	return positions
+}
+
+// Add adds value to the filter.
+func (f *BloomFilter) Add(value string) {
+	for _, p := range f.positions(value) {
+		f.Bits[p/8] |= 1 << (p % 8)
+	}
+}
+
+// Test returns false if value has not been added to the filter and true if it probably has.
+func (f *BloomFilter) Test(value string) bool {
+	for _, p := range f.positions(value) {
+		if f.Bits[p/8]&(1<<(p%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,49 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBloomFilter(t *testing.T) {
+	f := NewBloomFilter(1000, 0.01)
+	assert.Equal(t, uint64(9586), f.M)
+	assert.Equal(t, uint64(7), f.K)
+	assert.Len(t, f.Bits, 1199)
+
+	for i := 0; i < 1000; i++ {
+		f.Add(fmt.Sprintf("added-%d", i))
+	}
+	for i := 0; i < 1000; i++ {
+		assert.True(t, f.Test(fmt.Sprintf("added-%d", i)))
+	}
+
+	var positives int
+	for i := 0; i < 10000; i++ {
+		if f.Test(fmt.Sprintf("not-added-%d", i)) {
+			positives++
+		}
+	}
+	assert.True(t, positives < 200, "%d false positives", positives)
+
+	empty := NewBloomFilter(0, 0.01)
+	assert.False(t, empty.Test("foo"))
+	empty.Add("foo")
+	assert.True(t, empty.Test("foo"))
+}
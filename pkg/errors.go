@@ -28,6 +28,11 @@ var (
 		Status: http.StatusNotFound,
 		error:  errors.New("Not found"),
 	}
+
+	ErrConflict = &RichError{
+		Status: http.StatusConflict,
+		error:  errors.New("Conflict"),
+	}
 )
 
 type RichError struct {
@@ -32,5 +32,17 @@ type FositeStorer interface {
 
 	RevokeAccessToken(ctx context.Context, requestID string) error
 
+	// RevokeClientTokens revokes all access and refresh tokens issued to the client and returns the ids of the
+	// requests the revoked tokens were issued for.
+	RevokeClientTokens(ctx context.Context, clientID string) (requestIDs []string, err error)
+
+	// RevokeSubjectTokens revokes all access and refresh tokens issued on behalf of the subject and returns the ids of
+	// the requests the revoked tokens were issued for.
+	RevokeSubjectTokens(ctx context.Context, subject string) (requestIDs []string, err error)
+
+	// RevokeSubjectClientTokens revokes all access and refresh tokens issued to the client on behalf of the subject and
+	// returns the ids of the requests the revoked tokens were issued for.
+	RevokeSubjectClientTokens(ctx context.Context, subject, clientID string) (requestIDs []string, err error)
+
 	FlushInactiveAccessTokens(ctx context.Context, notAfter time.Time) error
 }
@@ -0,0 +1,101 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/evanphx/json-patch"
+	"github.com/pkg/errors"
+)
+
+const (
+	// MergePatchContentType is the content type of a JSON Merge Patch as defined in RFC 7386.
+	MergePatchContentType = "application/merge-patch+json"
+
+	// JSONPatchContentType is the content type of a JSON Patch as defined in RFC 6902.
+	JSONPatchContentType = "application/json-patch+json"
+)
+
+var (
+	ErrUnsupportedPatch = &RichError{
+		Status: http.StatusUnsupportedMediaType,
+		error:  errors.Errorf("The patch must be sent as %s or %s", MergePatchContentType, JSONPatchContentType),
+	}
+
+	ErrPreconditionFailed = &RichError{
+		Status: http.StatusPreconditionFailed,
+		error:  errors.New("The resource was modified, its ETag does not match If-Match"),
+	}
+)
+
+// ApplyPatch applies patch to the JSON document doc. The content type of the request body selects whether patch is a
+// JSON Merge Patch or a JSON Patch. A malformed patch yields a 400 error, a JSON Patch which can not be applied to doc,
+// for example because a "test" operation failed, yields a 409 error.
+func ApplyPatch(contentType string, doc, patch []byte) ([]byte, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, errors.WithStack(ErrUnsupportedPatch)
+	}
+
+	switch mediaType {
+	case MergePatchContentType:
+		out, err := jsonpatch.MergePatch(doc, patch)
+		if err != nil {
+			return nil, errors.WithStack(&RichError{Status: http.StatusBadRequest, error: errors.Wrap(err, "Could not apply merge patch")})
+		}
+		return out, nil
+	case JSONPatchContentType:
+		p, err := jsonpatch.DecodePatch(patch)
+		if err != nil {
+			return nil, errors.WithStack(&RichError{Status: http.StatusBadRequest, error: errors.Wrap(err, "Could not decode JSON patch")})
+		}
+
+		out, err := p.Apply(doc)
+		if err != nil {
+			return nil, errors.WithStack(&RichError{Status: http.StatusConflict, error: errors.Wrap(err, "Could not apply JSON patch")})
+		}
+		return out, nil
+	}
+	return nil, errors.WithStack(ErrUnsupportedPatch)
+}
+
+// ETag returns a strong entity tag for the JSON encoding of v.
+func ETag(v interface{}) (string, error) {
+	out, err := json.Marshal(v)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	hash := sha256.Sum256(out)
+	return `"` + hex.EncodeToString(hash[:]) + `"`, nil
+}
+
+// ETagMatches returns true if etag is one of the comma separated entity tags in header, as sent in If-Match and
+// If-None-Match, or if header is "*". Weak entity tags are compared by their value.
+func ETagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyPatch(t *testing.T) {
+	doc := []byte(`{"a":"b","c":["d"]}`)
+
+	for k, tc := range []struct {
+		contentType string
+		patch       string
+		expected    string
+		status      int
+	}{
+		{contentType: MergePatchContentType, patch: `{"a":null,"e":"f"}`, expected: `{"c":["d"],"e":"f"}`},
+		{contentType: MergePatchContentType + "; charset=utf-8", patch: `{"a":"g"}`, expected: `{"a":"g","c":["d"]}`},
+		{contentType: JSONPatchContentType, patch: `[{"op":"add","path":"/c/-","value":"e"}]`, expected: `{"a":"b","c":["d","e"]}`},
+		{contentType: JSONPatchContentType, patch: `[{"op":"test","path":"/a","value":"x"}]`, status: http.StatusConflict},
+		{contentType: JSONPatchContentType, patch: `{}`, status: http.StatusBadRequest},
+		{contentType: "application/json", patch: `{}`, status: http.StatusUnsupportedMediaType},
+		{contentType: "", patch: `{}`, status: http.StatusUnsupportedMediaType},
+	} {
+		out, err := ApplyPatch(tc.contentType, doc, []byte(tc.patch))
+		if tc.status != 0 {
+			require.Error(t, err, "case %d", k)
+			assert.Equal(t, tc.status, errors.Cause(err).(*RichError).StatusCode(), "case %d", k)
+			continue
+		}
+
+		require.NoError(t, err, "case %d", k)
+		assert.JSONEq(t, tc.expected, string(out), "case %d", k)
+	}
+}
+
+func TestETagMatches(t *testing.T) {
+	etag, err := ETag(map[string]string{"foo": "bar"})
+	require.NoError(t, err)
+
+	assert.True(t, ETagMatches(etag, etag))
+	assert.True(t, ETagMatches(`"foo", W/`+etag, etag))
+	assert.True(t, ETagMatches("*", etag))
+	assert.False(t, ETagMatches(`"foo"`, etag))
+	assert.False(t, ETagMatches("", etag))
+}
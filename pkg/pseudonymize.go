@@ -0,0 +1,44 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// PseudonymPrefix is prepended to pseudonymized subjects.
+const PseudonymPrefix = "hmac:"
+
+// Pseudonymizer replaces subject identifiers with a keyed HMAC before they are written to logs or audit sinks.
+// Anyone holding the key can re-identify a subject by computing the pseudonym of a known identifier, while the
+// identifier can not be derived from the pseudonym alone. A nil Pseudonymizer, or one without key, returns subjects
+// unchanged.
+type Pseudonymizer struct {
+	Key []byte
+}
+
+// Pseudonymize returns PseudonymPrefix followed by the hex encoded HMAC-SHA256 of subject. Empty subjects are
+// returned unchanged.
+func (p *Pseudonymizer) Pseudonymize(subject string) string {
+	if p == nil || len(p.Key) == 0 || subject == "" {
+		return subject
+	}
+
+	mac := hmac.New(sha256.New, p.Key)
+	mac.Write([]byte(subject))
+	return PseudonymPrefix + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,37 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPseudonymizer(t *testing.T) {
+	var disabled *Pseudonymizer
+	assert.Equal(t, "peter", disabled.Pseudonymize("peter"))
+	assert.Equal(t, "peter", (&Pseudonymizer{}).Pseudonymize("peter"))
+
+	p := &Pseudonymizer{Key: []byte("some-key")}
+	pseudonym := p.Pseudonymize("peter")
+	assert.True(t, strings.HasPrefix(pseudonym, PseudonymPrefix))
+	assert.NotContains(t, pseudonym, "peter")
+	assert.Equal(t, pseudonym, p.Pseudonymize("peter"))
+	assert.NotEqual(t, pseudonym, p.Pseudonymize("alice"))
+	assert.NotEqual(t, pseudonym, (&Pseudonymizer{Key: []byte("other-key")}).Pseudonymize("peter"))
+	assert.Equal(t, "", p.Pseudonymize(""))
+}
@@ -0,0 +1,27 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+// ExactScopeStrategy is a fosite.ScopeStrategy which only matches scopes which are equal to one of the granted
+// scopes. Unlike the hierarchic strategy, "photos" does not match "photos.read", and unlike the wildcard strategy,
+// "photos.*" does not match "photos.read".
+func ExactScopeStrategy(haystack []string, needle string) bool {
+	for _, scope := range haystack {
+		if scope == needle {
+			return true
+		}
+	}
+	return false
+}
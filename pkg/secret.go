@@ -14,7 +14,31 @@
 
 package pkg
 
-import "github.com/ory/hydra/rand/sequence"
+import (
+	"crypto/sha256"
+	"io"
+
+	"github.com/ory/hydra/rand/sequence"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/hkdf"
+)
+
+// Purposes of the keys derived from the system secret. A key derived for one purpose reveals nothing about the keys
+// derived for the other purposes.
+const (
+	SecretPurposeCookie    = "cookie"
+	SecretPurposeJWK       = "jwk"
+	SecretPurposeTokenHMAC = "token-hmac"
+)
+
+const (
+	// SecretDerivationLegacy uses the system key for every purpose.
+	SecretDerivationLegacy = 0
+
+	// SecretDerivationHKDF derives a separate 256 bit key per purpose from the system key using HKDF-SHA256, with
+	// "hydra/v1/" followed by the purpose as info.
+	SecretDerivationHKDF = 1
+)
 
 var secretCharSet = []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ1234567890_-.~")
 
@@ -25,3 +49,20 @@ func GenerateSecret(length int) ([]byte, error) {
 	}
 	return []byte(string(secret)), nil
 }
+
+// DeriveSecret derives the key for purpose from the system key, which is the SHA-256 hash of the system secret, using
+// the given derivation version. Versions are never changed once released, so keys derived by one release can be
+// derived again by every later release.
+func DeriveSecret(systemKey []byte, version int, purpose string) ([]byte, error) {
+	switch version {
+	case SecretDerivationLegacy:
+		return systemKey, nil
+	case SecretDerivationHKDF:
+		key := make([]byte, sha256.Size)
+		if _, err := io.ReadFull(hkdf.New(sha256.New, systemKey, nil, []byte("hydra/v1/"+purpose)), key); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return key, nil
+	}
+	return nil, errors.Errorf("Unknown secret derivation version %d", version)
+}
@@ -0,0 +1,45 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeriveSecret(t *testing.T) {
+	systemKey := sha256.Sum256([]byte("some-super-secret-system-secret"))
+
+	legacy, err := DeriveSecret(systemKey[:], SecretDerivationLegacy, SecretPurposeCookie)
+	require.NoError(t, err)
+	assert.Equal(t, systemKey[:], legacy)
+
+	cookie, err := DeriveSecret(systemKey[:], SecretDerivationHKDF, SecretPurposeCookie)
+	require.NoError(t, err)
+	assert.Equal(t, "8d7a3be39ff134e4cf4ee6bc37adf08b281cafafc6a3e335b6583e7586ae42ba", hex.EncodeToString(cookie))
+
+	jwk, err := DeriveSecret(systemKey[:], SecretDerivationHKDF, SecretPurposeJWK)
+	require.NoError(t, err)
+	assert.Len(t, jwk, 32)
+	assert.NotEqual(t, cookie, jwk)
+	assert.NotEqual(t, systemKey[:], jwk)
+
+	_, err = DeriveSecret(systemKey[:], 2, SecretPurposeCookie)
+	assert.Error(t, err)
+}
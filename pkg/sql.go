@@ -0,0 +1,230 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+const (
+	SQLDialectMySQL     = "mysql"
+	SQLDialectPostgres  = "postgres"
+	SQLDialectCockroach = "cockroach"
+)
+
+// SQLTransactionMaxAttempts is the number of times a transaction is tried before a serialization failure is returned
+// to the caller.
+const SQLTransactionMaxAttempts = 5
+
+// SQLMaxBindParameters is the number of bind parameters a single statement may use. PostgreSQL and MySQL both allow
+// at most 65535.
+const SQLMaxBindParameters = 65535
+
+// SQLDialect returns the SQL dialect spoken by db. CockroachDB speaks the PostgreSQL wire protocol and uses the
+// postgres driver, so it is told apart by its version string.
+func SQLDialect(db *sqlx.DB) (string, error) {
+	if db.DriverName() == "mysql" {
+		return SQLDialectMySQL, nil
+	}
+
+	var version string
+	if err := db.Get(&version, "SELECT version()"); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	if strings.Contains(version, "CockroachDB") {
+		return SQLDialectCockroach, nil
+	}
+	return SQLDialectPostgres, nil
+}
+
+// IsRetryableSQLError returns true if err signals that a transaction was aborted because it conflicted with a
+// concurrent transaction and may succeed when run again. CockroachDB reports these as serialization failures.
+func IsRetryableSQLError(err error) bool {
+	switch e := errors.Cause(err).(type) {
+	case *pq.Error:
+		return e.Code == "40001"
+	case pq.Error:
+		return e.Code == "40001"
+	case *mysql.MySQLError:
+		// ER_LOCK_DEADLOCK
+		return e.Number == 1213
+	}
+	return false
+}
+
+// SQLTransaction runs fn in a SQL transaction and commits it. If the transaction fails with a retryable error, it is
+// rolled back and fn is run again in a new transaction, up to SQLTransactionMaxAttempts times. fn must therefore not
+// have side effects besides the statements it executes in tx.
+func SQLTransaction(db *sqlx.DB, fn func(tx *sqlx.Tx) error) (err error) {
+	wait := time.Millisecond * 10
+	for attempt := 1; attempt <= SQLTransactionMaxAttempts; attempt++ {
+		if err = sqlTransaction(db, fn); err == nil || !IsRetryableSQLError(err) {
+			return err
+		}
+
+		time.Sleep(wait)
+		wait = wait * 2
+	}
+	return err
+}
+
+func sqlTransaction(db *sqlx.DB, fn func(tx *sqlx.Tx) error) error {
+	tx, err := db.Beginx()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := fn(tx); err != nil {
+		if re := tx.Rollback(); re != nil {
+			return errors.Wrap(err, re.Error())
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		if re := tx.Rollback(); re != nil {
+			return errors.Wrap(err, re.Error())
+		}
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// SQLStatements caches prepared statements by query, so frequently executed queries are prepared once instead of on
+// every execution. The zero value is ready to use.
+type SQLStatements struct {
+	mu    sync.RWMutex
+	stmts map[string]*sqlx.Stmt
+	named map[string]*sqlx.NamedStmt
+}
+
+// Preparex returns the prepared statement for query, which uses "?" bind vars, preparing it on db if it was not
+// prepared before. Use tx.Stmtx to execute it in a transaction.
+func (s *SQLStatements) Preparex(db *sqlx.DB, query string) (*sqlx.Stmt, error) {
+	s.mu.RLock()
+	stmt, ok := s.stmts[query]
+	s.mu.RUnlock()
+	if ok {
+		return stmt, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if stmt, ok := s.stmts[query]; ok {
+		return stmt, nil
+	}
+
+	stmt, err := db.Preparex(db.Rebind(query))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if s.stmts == nil {
+		s.stmts = map[string]*sqlx.Stmt{}
+	}
+	s.stmts[query] = stmt
+	return stmt, nil
+}
+
+// PrepareNamed returns the prepared statement for query, which uses named bind vars, preparing it on db if it was not
+// prepared before. Use tx.NamedStmt to execute it in a transaction.
+func (s *SQLStatements) PrepareNamed(db *sqlx.DB, query string) (*sqlx.NamedStmt, error) {
+	s.mu.RLock()
+	stmt, ok := s.named[query]
+	s.mu.RUnlock()
+	if ok {
+		return stmt, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if stmt, ok := s.named[query]; ok {
+		return stmt, nil
+	}
+
+	stmt, err := db.PrepareNamed(query)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if s.named == nil {
+		s.named = map[string]*sqlx.NamedStmt{}
+	}
+	s.named[query] = stmt
+	return stmt, nil
+}
+
+// Close closes all cached statements.
+func (s *SQLStatements) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var err error
+	for _, stmt := range s.stmts {
+		if e := stmt.Close(); e != nil {
+			err = errors.WithStack(e)
+		}
+	}
+	for _, stmt := range s.named {
+		if e := stmt.Close(); e != nil {
+			err = errors.WithStack(e)
+		}
+	}
+	s.stmts, s.named = nil, nil
+	return err
+}
+
+// SQLBatchInsert inserts rows into table using as few INSERT statements with multiple VALUES lists as the bind
+// parameter limit allows. Every row must contain one value per column.
+func SQLBatchInsert(tx *sqlx.Tx, table string, columns []string, rows [][]interface{}) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	batch := SQLMaxBindParameters / len(columns)
+	placeholders := "(?" + strings.Repeat(", ?", len(columns)-1) + ")"
+	for len(rows) > 0 {
+		n := batch
+		if len(rows) < n {
+			n = len(rows)
+		}
+
+		values := make([]string, n)
+		args := make([]interface{}, 0, n*len(columns))
+		for k, row := range rows[:n] {
+			if len(row) != len(columns) {
+				return errors.Errorf("Row %d has %d values but %d columns are inserted", k, len(row), len(columns))
+			}
+			values[k] = placeholders
+			args = append(args, row...)
+		}
+
+		query := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s", table, strings.Join(columns, ", "), strings.Join(values, ", "))
+		if _, err := tx.Exec(tx.Rebind(query), args...); err != nil {
+			return errors.WithStack(err)
+		}
+		rows = rows[n:]
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+// SQLIndex is an index which a frequently executed query relies on.
+//
+// swagger:model sqlIndex
+type SQLIndex struct {
+	// Table is the indexed table.
+	Table string `json:"table"`
+
+	// Columns are the indexed columns. Any index whose leading columns are these columns satisfies the index.
+	Columns []string `json:"columns"`
+
+	// Query describes the query relying on the index.
+	Query string `json:"query"`
+
+	// MySQLPrefixLength is the prefix length MySQL needs to index TEXT columns. Zero for other column types.
+	MySQLPrefixLength int `json:"-"`
+}
+
+// Name returns the name under which the index is suggested to be created.
+func (i SQLIndex) Name() string {
+	return fmt.Sprintf("%s_%s_idx", i.Table, strings.Join(i.Columns, "_"))
+}
+
+// CreateStatement returns the statement creating the index in the given dialect.
+func (i SQLIndex) CreateStatement(dialect string) string {
+	columns := i.Columns
+	if dialect == SQLDialectMySQL && i.MySQLPrefixLength > 0 {
+		columns = make([]string, len(i.Columns))
+		for k, c := range i.Columns {
+			columns[k] = fmt.Sprintf("%s(%d)", c, i.MySQLPrefixLength)
+		}
+	}
+	return fmt.Sprintf("CREATE INDEX %s ON %s (%s)", i.Name(), i.Table, strings.Join(columns, ", "))
+}
+
+type sqlIndexColumn struct {
+	Index    string `db:"index_name"`
+	Column   string `db:"column_name"`
+	Position int    `db:"position"`
+}
+
+var sqlIndexColumnsQueries = map[string]string{
+	SQLDialectPostgres: `SELECT ic.relname AS index_name, a.attname AS column_name, k.n AS position
+FROM pg_index ix
+JOIN pg_class t ON t.oid = ix.indrelid
+JOIN pg_class ic ON ic.oid = ix.indexrelid
+JOIN LATERAL unnest(ix.indkey::int2[]) WITH ORDINALITY AS k(attnum, n) ON true
+JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = k.attnum
+WHERE t.relname = ? AND pg_table_is_visible(t.oid)`,
+	SQLDialectCockroach: `SELECT index_name, column_name, seq_in_index AS position FROM information_schema.statistics
+WHERE table_catalog = current_database() AND table_name = ?`,
+	SQLDialectMySQL: `SELECT index_name AS index_name, column_name AS column_name, seq_in_index AS position
+FROM information_schema.statistics WHERE table_schema = DATABASE() AND table_name = ?`,
+}
+
+// MissingSQLIndexes returns the indexes for which the table has no index starting with the index's columns. Indexes
+// on tables which do not exist, for example because their component is not used, are skipped.
+func MissingSQLIndexes(db *sqlx.DB, indexes []SQLIndex) ([]SQLIndex, error) {
+	dialect, err := SQLDialect(db)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := map[string][][]string{}
+	missing := []SQLIndex{}
+	for _, index := range indexes {
+		tableIndexes, ok := existing[index.Table]
+		if !ok {
+			var columns []sqlIndexColumn
+			if err := db.Select(&columns, db.Rebind(sqlIndexColumnsQueries[dialect]), index.Table); err != nil {
+				return nil, errors.WithStack(err)
+			}
+
+			tableIndexes = groupSQLIndexColumns(columns)
+			existing[index.Table] = tableIndexes
+		}
+
+		// Every table has a primary key, so a table without any index does not exist.
+		if len(tableIndexes) == 0 || hasSQLIndex(tableIndexes, index.Columns) {
+			continue
+		}
+		missing = append(missing, index)
+	}
+	return missing, nil
+}
+
+func groupSQLIndexColumns(columns []sqlIndexColumn) [][]string {
+	sort.Slice(columns, func(i, j int) bool {
+		if columns[i].Index != columns[j].Index {
+			return columns[i].Index < columns[j].Index
+		}
+		return columns[i].Position < columns[j].Position
+	})
+
+	var indexes [][]string
+	for k, c := range columns {
+		if k == 0 || columns[k-1].Index != c.Index {
+			indexes = append(indexes, []string{})
+		}
+		indexes[len(indexes)-1] = append(indexes[len(indexes)-1], strings.ToLower(c.Column))
+	}
+	return indexes
+}
+
+func hasSQLIndex(indexes [][]string, columns []string) bool {
+	for _, index := range indexes {
+		if len(index) < len(columns) {
+			continue
+		}
+
+		matches := true
+		for k, c := range columns {
+			if index[k] != strings.ToLower(c) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSQLIndexCreateStatement(t *testing.T) {
+	i := SQLIndex{Table: "hydra_oauth2_access", Columns: []string{"client_id"}, MySQLPrefixLength: 255}
+	assert.Equal(t, "CREATE INDEX hydra_oauth2_access_client_id_idx ON hydra_oauth2_access (client_id)", i.CreateStatement(SQLDialectPostgres))
+	assert.Equal(t, "CREATE INDEX hydra_oauth2_access_client_id_idx ON hydra_oauth2_access (client_id(255))", i.CreateStatement(SQLDialectMySQL))
+}
+
+func TestHasSQLIndex(t *testing.T) {
+	indexes := groupSQLIndexColumns([]sqlIndexColumn{
+		{Index: "pkey", Column: "signature", Position: 1},
+		{Index: "rel_pkey", Column: "subject", Position: 2},
+		{Index: "rel_pkey", Column: "Policy", Position: 1},
+	})
+	assert.Equal(t, [][]string{{"signature"}, {"policy", "subject"}}, indexes)
+
+	for k, tc := range []struct {
+		columns  []string
+		expected bool
+	}{
+		{columns: []string{"signature"}, expected: true},
+		{columns: []string{"policy"}, expected: true},
+		{columns: []string{"POLICY", "subject"}, expected: true},
+		{columns: []string{"subject"}, expected: false},
+		{columns: []string{"signature", "subject"}, expected: false},
+	} {
+		assert.Equal(t, tc.expected, hasSQLIndex(indexes, tc.columns), "%d", k)
+	}
+}
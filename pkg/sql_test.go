@@ -0,0 +1,41 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsRetryableSQLError(t *testing.T) {
+	for k, tc := range []struct {
+		err       error
+		retryable bool
+	}{
+		{err: errors.New("foo"), retryable: false},
+		{err: &pq.Error{Code: "40001"}, retryable: true},
+		{err: errors.WithStack(&pq.Error{Code: "40001"}), retryable: true},
+		{err: errors.Wrap(&pq.Error{Code: "40001"}, "sql: transaction has already been committed or rolled back"), retryable: true},
+		{err: &pq.Error{Code: "23505"}, retryable: false},
+		{err: &mysql.MySQLError{Number: 1213}, retryable: true},
+		{err: &mysql.MySQLError{Number: 1062}, retryable: false},
+	} {
+		assert.Equal(t, tc.retryable, IsRetryableSQLError(tc.err), "%d", k)
+	}
+}
@@ -0,0 +1,26 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import "github.com/ory/hydra/pkg"
+
+// SQLIndexes are the indexes ladon's SQL manager relies on when searching the policies matching the subject and
+// resource of an access request.
+var SQLIndexes = []pkg.SQLIndex{
+	{Table: "ladon_subject", Columns: []string{"template"}, Query: "Policy search by subject"},
+	{Table: "ladon_policy_subject_rel", Columns: []string{"subject"}, Query: "Policy search by subject"},
+	{Table: "ladon_resource", Columns: []string{"template"}, Query: "Policy search by resource"},
+	{Table: "ladon_policy_resource_rel", Columns: []string{"resource"}, Query: "Policy search by resource"},
+}
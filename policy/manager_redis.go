@@ -0,0 +1,158 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"encoding/json"
+
+	"github.com/go-redis/redis"
+	"github.com/ory/hydra/pkg"
+	"github.com/ory/ladon"
+	"github.com/pkg/errors"
+)
+
+const (
+	// redisPolicies is a hash containing the JSON encoded policies, indexed by policy id.
+	redisPolicies = "hydra:policy:policies"
+
+	// redisPolicyIDs is a sorted set containing all policy ids. All members have the same score, so they are
+	// ordered by id.
+	redisPolicyIDs = "hydra:policy:ids"
+)
+
+// RedisManager is a ladon.Manager which stores access control policies in Redis. Like ladon's in-memory manager, it
+// returns all policies as request candidates and leaves matching them to the warden.
+type RedisManager struct {
+	DB *redis.Client
+}
+
+func (m *RedisManager) Create(policy ladon.Policy) error {
+	if exists, err := m.DB.HExists(redisPolicies, policy.GetID()).Result(); err != nil {
+		return errors.WithStack(err)
+	} else if exists {
+		return errors.Errorf("Policy %s already exists", policy.GetID())
+	}
+	return m.store(policy)
+}
+
+func (m *RedisManager) Update(policy ladon.Policy) error {
+	if exists, err := m.DB.HExists(redisPolicies, policy.GetID()).Result(); err != nil {
+		return errors.WithStack(err)
+	} else if !exists {
+		return errors.WithStack(pkg.ErrNotFound)
+	}
+	return m.store(policy)
+}
+
+func (m *RedisManager) Get(id string) (ladon.Policy, error) {
+	out, err := m.DB.HGet(redisPolicies, id).Bytes()
+	if err == redis.Nil {
+		return nil, errors.WithStack(pkg.ErrNotFound)
+	} else if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var policy ladon.DefaultPolicy
+	if err := json.Unmarshal(out, &policy); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &policy, nil
+}
+
+func (m *RedisManager) Delete(id string) error {
+	if _, err := m.DB.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.HDel(redisPolicies, id)
+		pipe.ZRem(redisPolicyIDs, id)
+		return nil
+	}); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func (m *RedisManager) GetAll(limit, offset int64) (ladon.Policies, error) {
+	if limit <= 0 {
+		return ladon.Policies{}, nil
+	}
+
+	ids, err := m.DB.ZRange(redisPolicyIDs, offset, offset+limit-1).Result()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return m.getPolicies(ids)
+}
+
+func (m *RedisManager) FindRequestCandidates(r *ladon.Request) (ladon.Policies, error) {
+	return m.all()
+}
+
+func (m *RedisManager) FindPoliciesForSubject(subject string) (ladon.Policies, error) {
+	return m.all()
+}
+
+func (m *RedisManager) FindPoliciesForResource(resource string) (ladon.Policies, error) {
+	return m.all()
+}
+
+func (m *RedisManager) all() (ladon.Policies, error) {
+	ids, err := m.DB.ZRange(redisPolicyIDs, 0, -1).Result()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return m.getPolicies(ids)
+}
+
+func (m *RedisManager) getPolicies(ids []string) (ladon.Policies, error) {
+	policies := ladon.Policies{}
+	if len(ids) == 0 {
+		return policies, nil
+	}
+
+	values, err := m.DB.HMGet(redisPolicies, ids...).Result()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	for _, value := range values {
+		out, ok := value.(string)
+		if !ok {
+			// The policy was deleted in the meantime.
+			continue
+		}
+
+		var policy ladon.DefaultPolicy
+		if err := json.Unmarshal([]byte(out), &policy); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		policies = append(policies, &policy)
+	}
+	return policies, nil
+}
+
+func (m *RedisManager) store(policy ladon.Policy) error {
+	out, err := json.Marshal(policy)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if _, err := m.DB.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.HSet(redisPolicies, policy.GetID(), string(out))
+		pipe.ZAdd(redisPolicyIDs, redis.Z{Member: policy.GetID()})
+		return nil
+	}); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
@@ -16,6 +16,7 @@ package sequence
 
 import (
 	"crypto/rand"
+	"io"
 	"math/big"
 )
 
@@ -32,11 +33,16 @@ var (
 )
 
 func RuneSequence(l int, allowedRunes []rune) (seq []rune, err error) {
+	return RuneSequenceFrom(rander, l, allowedRunes)
+}
+
+// RuneSequenceFrom returns a sequence of l runes picked uniformly from allowedRunes using the entropy source source.
+func RuneSequenceFrom(source io.Reader, l int, allowedRunes []rune) (seq []rune, err error) {
 	c := big.NewInt(int64(len(allowedRunes)))
 	seq = make([]rune, l)
 
 	for i := 0; i < l; i++ {
-		r, err := rand.Int(rander, c)
+		r, err := rand.Int(source, c)
 		if err != nil {
 			return seq, err
 		}
@@ -0,0 +1,126 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ratelimit limits how many requests a client or IP address may send to an endpoint in a time window.
+package ratelimit
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Limit allows Requests requests per Window. A Limit without requests is disabled.
+type Limit struct {
+	Requests int
+	Window   time.Duration
+}
+
+// ParseLimit parses a limit such as "100/1m". An empty string yields a disabled limit.
+func ParseLimit(s string) (Limit, error) {
+	if s == "" {
+		return Limit{}, nil
+	}
+
+	parts := strings.SplitN(s, "/", 2)
+	if len(parts) != 2 {
+		return Limit{}, errors.Errorf("Rate limit %s must have the format <requests>/<window>, for example 100/1m", s)
+	}
+
+	requests, err := strconv.Atoi(parts[0])
+	if err != nil || requests <= 0 {
+		return Limit{}, errors.Errorf("Rate limit %s must allow a positive number of requests", s)
+	}
+
+	window, err := time.ParseDuration(parts[1])
+	if err != nil || window <= 0 {
+		return Limit{}, errors.Errorf("Rate limit %s must have a positive window, for example 1s or 1m", s)
+	}
+
+	return Limit{Requests: requests, Window: window}, nil
+}
+
+// Enabled returns true if the limit allows a finite number of requests.
+func (l Limit) Enabled() bool {
+	return l.Requests > 0 && l.Window > 0
+}
+
+// Limiter counts requests in fixed windows which start at multiples of the limit's window.
+type Limiter interface {
+	// Allow counts a request for key and returns whether it is within limit. If it is not, the returned duration
+	// is the time until the current window ends.
+	Allow(key string, limit Limit) (bool, time.Duration, error)
+}
+
+// window returns the start and end of the window now falls into.
+func window(now time.Time, limit Limit) (time.Time, time.Time) {
+	start := now.Truncate(limit.Window)
+	return start, start.Add(limit.Window)
+}
+
+// MemoryLimiter counts requests in memory, so every instance enforces the limits on its own.
+type MemoryLimiter struct {
+	sync.Mutex
+	counters  map[string]*memoryCounter
+	lastSweep time.Time
+}
+
+type memoryCounter struct {
+	end   time.Time
+	count int
+}
+
+// memorySweepInterval is how often counters of ended windows are removed.
+const memorySweepInterval = time.Minute
+
+func (m *MemoryLimiter) Allow(key string, limit Limit) (bool, time.Duration, error) {
+	now := time.Now().UTC()
+	_, end := window(now, limit)
+
+	m.Lock()
+	defer m.Unlock()
+
+	if m.counters == nil {
+		m.counters = map[string]*memoryCounter{}
+	}
+	m.sweep(now)
+
+	c, ok := m.counters[key]
+	if !ok || !c.end.Equal(end) {
+		c = &memoryCounter{end: end}
+		m.counters[key] = c
+	}
+
+	c.count++
+	if c.count > limit.Requests {
+		return false, end.Sub(now), nil
+	}
+	return true, 0, nil
+}
+
+func (m *MemoryLimiter) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < memorySweepInterval {
+		return
+	}
+
+	for key, c := range m.counters {
+		if !c.end.After(now) {
+			delete(m.counters, key)
+		}
+	}
+	m.lastSweep = now
+}
@@ -0,0 +1,49 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/pkg/errors"
+)
+
+// RedisLimiter counts requests in Redis, so the limits are shared by all instances using the same Redis.
+type RedisLimiter struct {
+	DB *redis.Client
+}
+
+func (m *RedisLimiter) Allow(key string, limit Limit) (bool, time.Duration, error) {
+	now := time.Now().UTC()
+	start, end := window(now, limit)
+	name := "hydra:ratelimit:" + key + ":" + strconv.FormatInt(start.UnixNano(), 10)
+
+	var incr *redis.IntCmd
+	if _, err := m.DB.TxPipelined(func(pipe redis.Pipeliner) error {
+		incr = pipe.Incr(name)
+		// Keep the counter a little longer than the window, in case the clocks of the instances differ.
+		pipe.ExpireAt(name, end.Add(time.Minute))
+		return nil
+	}); err != nil {
+		return false, 0, errors.WithStack(err)
+	}
+
+	if incr.Val() > int64(limit.Requests) {
+		return false, end.Sub(now), nil
+	}
+	return true, 0, nil
+}
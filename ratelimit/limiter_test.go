@@ -0,0 +1,79 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLimit(t *testing.T) {
+	for k, c := range []struct {
+		in     string
+		out    Limit
+		failed bool
+	}{
+		{in: "", out: Limit{}},
+		{in: "100/1m", out: Limit{Requests: 100, Window: time.Minute}},
+		{in: "5/10s", out: Limit{Requests: 5, Window: 10 * time.Second}},
+		{in: "100", failed: true},
+		{in: "0/1m", failed: true},
+		{in: "-1/1m", failed: true},
+		{in: "foo/1m", failed: true},
+		{in: "100/foo", failed: true},
+		{in: "100/0s", failed: true},
+	} {
+		l, err := ParseLimit(c.in)
+		if c.failed {
+			assert.Error(t, err, "case %d", k)
+			continue
+		}
+		require.NoError(t, err, "case %d", k)
+		assert.Equal(t, c.out, l, "case %d", k)
+		assert.Equal(t, c.in != "", l.Enabled(), "case %d", k)
+	}
+}
+
+func TestMemoryLimiter(t *testing.T) {
+	m := new(MemoryLimiter)
+	limit := Limit{Requests: 2, Window: time.Hour}
+
+	for i := 0; i < 2; i++ {
+		allowed, _, err := m.Allow("foo", limit)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	}
+
+	allowed, retryAfter, err := m.Allow("foo", limit)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.True(t, retryAfter > 0 && retryAfter <= time.Hour, "%s", retryAfter)
+
+	allowed, _, err = m.Allow("bar", limit)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	short := Limit{Requests: 1, Window: 50 * time.Millisecond}
+	allowed, _, err = m.Allow("baz", short)
+	require.NoError(t, err)
+	require.True(t, allowed)
+	time.Sleep(60 * time.Millisecond)
+	allowed, _, err = m.Allow("baz", short)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+}
@@ -0,0 +1,154 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ory/herodot"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Rule limits the requests to paths starting with one of Prefixes.
+type Rule struct {
+	// Name identifies the rule in counter keys and logs, for example "token".
+	Name     string
+	Prefixes []string
+
+	// PerClient limits the requests of every client, as returned by Middleware.ClientID.
+	PerClient Limit
+
+	// PerIP limits the requests of every IP address.
+	PerIP Limit
+}
+
+func (r *Rule) matches(path string) bool {
+	for _, prefix := range r.Prefixes {
+		if path == prefix || strings.HasPrefix(path, strings.TrimRight(prefix, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Middleware rejects requests which exceed the limits of the first matching rule with 429 and a Retry-After header.
+// If the Limiter fails, requests are let through.
+type Middleware struct {
+	Limiter Limiter
+	Rules   []Rule
+	H       herodot.Writer
+	L       logrus.FieldLogger
+
+	// ClientID returns the id of the client a request was made by, or an empty string if it is unknown. Requests of
+	// unknown clients are only limited per IP address.
+	ClientID func(r *http.Request) string
+
+	// TrustedProxies are the networks of proxies whose X-Forwarded-For header is used to find the IP address of the
+	// client.
+	TrustedProxies []*net.IPNet
+}
+
+func (m *Middleware) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	rule := m.rule(r.URL.Path)
+	if rule == nil {
+		next(rw, r)
+		return
+	}
+
+	if rule.PerIP.Enabled() {
+		if ip := RemoteIP(r, m.TrustedProxies); ip != "" && !m.allow(rw, r, rule.Name+":ip:"+ip, rule.PerIP) {
+			return
+		}
+	}
+
+	if rule.PerClient.Enabled() && m.ClientID != nil {
+		if id := m.ClientID(r); id != "" && !m.allow(rw, r, rule.Name+":client:"+id, rule.PerClient) {
+			return
+		}
+	}
+
+	next(rw, r)
+}
+
+func (m *Middleware) rule(path string) *Rule {
+	for k := range m.Rules {
+		if m.Rules[k].matches(path) {
+			return &m.Rules[k]
+		}
+	}
+	return nil
+}
+
+// allow counts the request for key and writes the error response if the limit is exceeded.
+func (m *Middleware) allow(rw http.ResponseWriter, r *http.Request, key string, limit Limit) bool {
+	allowed, retryAfter, err := m.Limiter.Allow(key, limit)
+	if err != nil {
+		m.L.WithError(err).WithField("key", key).Warnln("Could not check rate limit, letting the request through")
+		return true
+	} else if allowed {
+		return true
+	}
+
+	m.L.WithField("key", key).Debugln("Rate limit exceeded")
+	rw.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	m.H.WriteErrorCode(rw, r, http.StatusTooManyRequests, errors.Errorf("Rate limit of %d requests per %s exceeded", limit.Requests, limit.Window))
+	return false
+}
+
+// RemoteIP returns the IP address of the client. If the request was forwarded by proxies in trustedProxies, it is
+// the right most address in X-Forwarded-For which does not belong to a trusted proxy.
+func RemoteIP(r *http.Request, trustedProxies []*net.IPNet) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+
+	if !trusted(ip, trustedProxies) {
+		return ip
+	}
+
+	forwarded := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for k := len(forwarded) - 1; k >= 0; k-- {
+		candidate := strings.TrimSpace(forwarded[k])
+		if candidate == "" {
+			continue
+		}
+
+		ip = candidate
+		if !trusted(candidate, trustedProxies) {
+			break
+		}
+	}
+	return ip
+}
+
+func trusted(ip string, trustedProxies []*net.IPNet) bool {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+
+	for _, network := range trustedProxies {
+		if network.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
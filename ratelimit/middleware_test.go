@@ -0,0 +1,104 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ory/herodot"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestMiddleware(rule Rule) *Middleware {
+	_, proxies, _ := net.ParseCIDR("10.0.0.0/8")
+	return &Middleware{
+		Limiter:        new(MemoryLimiter),
+		Rules:          []Rule{rule},
+		H:              herodot.NewJSONWriter(logrus.New()),
+		L:              logrus.New(),
+		TrustedProxies: []*net.IPNet{proxies},
+		ClientID: func(r *http.Request) string {
+			id, _, _ := r.BasicAuth()
+			return id
+		},
+	}
+}
+
+func serve(m *Middleware, path, remoteAddr string, modify func(r *http.Request)) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("POST", path, nil)
+	r.RemoteAddr = remoteAddr
+	if modify != nil {
+		modify(r)
+	}
+
+	rw := httptest.NewRecorder()
+	m.ServeHTTP(rw, r, func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusNoContent)
+	})
+	return rw
+}
+
+func TestMiddlewarePerIP(t *testing.T) {
+	m := newTestMiddleware(Rule{Name: "token", Prefixes: []string{"/oauth2/token"}, PerIP: Limit{Requests: 1, Window: time.Hour}})
+
+	assert.Equal(t, http.StatusNoContent, serve(m, "/oauth2/token", "1.2.3.4:1234", nil).Code)
+
+	rw := serve(m, "/oauth2/token", "1.2.3.4:1234", nil)
+	assert.Equal(t, http.StatusTooManyRequests, rw.Code)
+	assert.NotEmpty(t, rw.Header().Get("Retry-After"))
+
+	assert.Equal(t, http.StatusNoContent, serve(m, "/oauth2/token", "4.3.2.1:1234", nil).Code)
+	assert.Equal(t, http.StatusNoContent, serve(m, "/oauth2/tokens", "1.2.3.4:1234", nil).Code)
+	assert.Equal(t, http.StatusNoContent, serve(m, "/clients", "1.2.3.4:1234", nil).Code)
+}
+
+func TestMiddlewarePerClient(t *testing.T) {
+	m := newTestMiddleware(Rule{Name: "admin", Prefixes: []string{"/clients"}, PerClient: Limit{Requests: 1, Window: time.Hour}})
+	auth := func(id string) func(r *http.Request) {
+		return func(r *http.Request) {
+			r.SetBasicAuth(id, "secret")
+		}
+	}
+
+	assert.Equal(t, http.StatusNoContent, serve(m, "/clients/foo", "1.2.3.4:1234", auth("foo")).Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve(m, "/clients", "4.3.2.1:1234", auth("foo")).Code)
+	assert.Equal(t, http.StatusNoContent, serve(m, "/clients", "1.2.3.4:1234", auth("bar")).Code)
+
+	// Requests of unknown clients are not limited per client.
+	assert.Equal(t, http.StatusNoContent, serve(m, "/clients", "1.2.3.4:1234", nil).Code)
+	assert.Equal(t, http.StatusNoContent, serve(m, "/clients", "1.2.3.4:1234", nil).Code)
+}
+
+func TestMiddlewareTrustedProxies(t *testing.T) {
+	m := newTestMiddleware(Rule{Name: "token", Prefixes: []string{"/oauth2/token"}, PerIP: Limit{Requests: 1, Window: time.Hour}})
+	forward := func(ips string) func(r *http.Request) {
+		return func(r *http.Request) {
+			r.Header.Set("X-Forwarded-For", ips)
+		}
+	}
+
+	assert.Equal(t, http.StatusNoContent, serve(m, "/oauth2/token", "10.0.0.1:1234", forward("1.2.3.4, 10.0.0.2")).Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve(m, "/oauth2/token", "10.0.0.3:1234", forward("1.2.3.4")).Code)
+	assert.Equal(t, http.StatusNoContent, serve(m, "/oauth2/token", "10.0.0.1:1234", forward("4.3.2.1")).Code)
+
+	// X-Forwarded-For of untrusted peers is ignored.
+	assert.Equal(t, http.StatusNoContent, serve(m, "/oauth2/token", "5.6.7.8:1234", forward("8.8.8.8")).Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve(m, "/oauth2/token", "5.6.7.8:1234", forward("9.9.9.9")).Code)
+}
@@ -2,5 +2,7 @@
 
 set -euo pipefail
 
-gox -ldflags "-X github.com/ory/hydra/cmd.Version=`git describe --tags` -X github.com/ory/hydra/cmd.BuildTime=`TZ=UTC date -u '+%Y-%m-%dT%H:%M:%SZ'` -X github.com/ory/hydra/cmd.GitHash=`git rev-parse HEAD`" -output "dist/{{.Dir}}-{{.OS}}-{{.Arch}}";
+# Release binaries are built without cgo so that they run on any distribution and architecture. SQL migrations are
+# embedded in the binary, database plugins are disabled in these builds (see `hydra version`).
+CGO_ENABLED=0 gox -osarch "darwin/amd64 linux/386 linux/amd64 linux/arm linux/arm64 windows/386 windows/amd64 freebsd/amd64" -ldflags "-X github.com/ory/hydra/cmd.Version=`git describe --tags` -X github.com/ory/hydra/cmd.BuildTime=`TZ=UTC date -u '+%Y-%m-%dT%H:%M:%SZ'` -X github.com/ory/hydra/cmd.GitHash=`git rev-parse HEAD`" -output "dist/{{.Dir}}-{{.OS}}-{{.Arch}}";
 npm version -f --no-git-tag-version $(git describe --tag);
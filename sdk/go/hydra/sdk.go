@@ -31,6 +31,7 @@ type CodeGenSDK struct {
 	*swagger.PolicyApi
 
 	Configuration      *Configuration
+	warden             *ResilientWarden
 	oAuth2ClientConfig *clientcredentials.Config
 	oAuth2Config       *oauth2.Config
 }
@@ -49,6 +50,10 @@ type Configuration struct {
 
 	// Scopes is a list of scopes the CodeGenSDK should request. If no scopes are given, this defaults to `hydra.*`
 	Scopes []string
+
+	// Warden, if set, enables caching of warden decisions and configures how warden access requests behave
+	// when ORY Hydra is unreachable, see WardenOptions.
+	Warden *WardenOptions
 }
 
 func removeTrailingSlash(path string) string {
@@ -65,6 +70,24 @@ func (s *CodeGenSDK) GetOAuth2Config() *oauth2.Config {
 	return s.oAuth2Config
 }
 
+// DoesWardenAllowAccessRequest checks if a subject is allowed to perform an action on a resource. If
+// Configuration.Warden is set, decisions are cached and failures are handled as configured.
+func (s *CodeGenSDK) DoesWardenAllowAccessRequest(body swagger.WardenAccessRequest) (*swagger.WardenAccessRequestResponse, *swagger.APIResponse, error) {
+	if s.warden != nil {
+		return s.warden.DoesWardenAllowAccessRequest(body)
+	}
+	return s.WardenApi.DoesWardenAllowAccessRequest(body)
+}
+
+// DoesWardenAllowTokenAccessRequest checks if a token is valid and allowed to perform an action on a resource. If
+// Configuration.Warden is set, decisions are cached and failures are handled as configured.
+func (s *CodeGenSDK) DoesWardenAllowTokenAccessRequest(body swagger.WardenTokenAccessRequest) (*swagger.WardenTokenAccessRequestResponse, *swagger.APIResponse, error) {
+	if s.warden != nil {
+		return s.warden.DoesWardenAllowTokenAccessRequest(body)
+	}
+	return s.WardenApi.DoesWardenAllowTokenAccessRequest(body)
+}
+
 // NewSDK instantiates a new CodeGenSDK instance or returns an error.
 func NewSDK(c *Configuration) (*CodeGenSDK, error) {
 	if c.EndpointURL == "" {
@@ -123,5 +146,9 @@ func NewSDK(c *Configuration) (*CodeGenSDK, error) {
 		oAuth2Config:       oAuth2Config,
 	}
 
+	if c.Warden != nil {
+		sdk.warden = NewResilientWarden(w, *c.Warden)
+	}
+
 	return sdk, nil
 }
@@ -132,26 +132,41 @@ type WardenAPI interface {
 }
 
 type JWKApi interface {
+	AppendJsonWebKey(kid string, set string, body swagger.JsonWebKeySetGeneratorRequest) (*swagger.JsonWebKeySet, *swagger.APIResponse, error)
 	CreateJsonWebKeySet(set string, body swagger.JsonWebKeySetGeneratorRequest) (*swagger.JsonWebKeySet, *swagger.APIResponse, error)
+	CreateJsonWebKeySetCredentials(set string, body swagger.JsonWebKeySetCredentialsRequest) (*swagger.JsonWebKeySetCredentials, *swagger.APIResponse, error)
 	DeleteJsonWebKey(kid string, set string) (*swagger.APIResponse, error)
 	DeleteJsonWebKeySet(set string) (*swagger.APIResponse, error)
+	ExportJsonWebKeySets(body swagger.JsonWebKeyBackupExportRequest) (*swagger.JsonWebKeyBackup, *swagger.APIResponse, error)
 	GetJsonWebKey(kid string, set string) (*swagger.JsonWebKeySet, *swagger.APIResponse, error)
 	GetJsonWebKeySet(set string) (*swagger.JsonWebKeySet, *swagger.APIResponse, error)
+	ImportJsonWebKeySets(body swagger.JsonWebKeyBackupImportRequest) ([]swagger.JsonWebKeySetSummary, *swagger.APIResponse, error)
+	ImportJsonWebKeys(set string, body string) (*swagger.JsonWebKeySet, *swagger.APIResponse, error)
+	ListJsonWebKeySets(limit int64, offset int64) ([]swagger.JsonWebKeySetSummary, *swagger.APIResponse, error)
 	UpdateJsonWebKey(kid string, set string, body swagger.JsonWebKey) (*swagger.JsonWebKey, *swagger.APIResponse, error)
 	UpdateJsonWebKeySet(set string, body swagger.JsonWebKeySet) (*swagger.JsonWebKeySet, *swagger.APIResponse, error)
 }
 
 type OAuth2API interface {
 	AcceptOAuth2ConsentRequest(id string, body swagger.ConsentRequestAcceptance) (*swagger.APIResponse, error)
+	ApproveOAuth2Client(id string) (*swagger.OAuth2Client, *swagger.APIResponse, error)
 	CreateOAuth2Client(body swagger.OAuth2Client) (*swagger.OAuth2Client, *swagger.APIResponse, error)
 	DeleteOAuth2Client(id string) (*swagger.APIResponse, error)
 	GetOAuth2Client(id string) (*swagger.OAuth2Client, *swagger.APIResponse, error)
 	GetOAuth2ConsentRequest(id string) (*swagger.OAuth2ConsentRequest, *swagger.APIResponse, error)
+	GetOAuth2ConsentStatistics(clientId string, since string, until string) ([]swagger.ConsentScopeStatistics, *swagger.APIResponse, error)
 	GetWellKnown() (*swagger.WellKnown, *swagger.APIResponse, error)
 	IntrospectOAuth2Token(token string, scope string) (*swagger.OAuth2TokenIntrospection, *swagger.APIResponse, error)
-	ListOAuth2Clients(limit int64, offset int64) ([]swagger.OAuth2Client, *swagger.APIResponse, error)
+	IntrospectOAuth2TokenHistory(token string, at string) (*swagger.OAuth2TokenHistoryIntrospection, *swagger.APIResponse, error)
+	ListOAuth2ConsentGrants(subject string) ([]swagger.ConsentGrant, *swagger.APIResponse, error)
+	ListOAuth2Clients(limit int64, offset int64, status string) ([]swagger.OAuth2Client, *swagger.APIResponse, error)
+	ParkOAuth2ConsentRequest(id string) (*swagger.ConsentRequestParking, *swagger.APIResponse, error)
+	RegisterOAuth2Client(body swagger.OAuth2Client) (*swagger.OAuth2Client, *swagger.APIResponse, error)
+	RejectOAuth2Client(id string) (*swagger.APIResponse, error)
 	RejectOAuth2ConsentRequest(id string, body swagger.ConsentRequestRejection) (*swagger.APIResponse, error)
+	RevokeOAuth2ConsentGrant(subject string, clientId string) (*swagger.APIResponse, error)
 	RevokeOAuth2Token(token string) (*swagger.APIResponse, error)
+	RevokeOAuth2Tokens(clientId string, subject string) (*swagger.APIResponse, error)
 	UpdateOAuth2Client(id string, body swagger.OAuth2Client) (*swagger.OAuth2Client, *swagger.APIResponse, error)
 
 	FlushInactiveOAuth2Tokens(body swagger.FlushInactiveOAuth2TokensRequest) (*swagger.APIResponse, error)
@@ -10,17 +10,36 @@
 
 package swagger
 
+import (
+	"time"
+)
+
 type ConsentRequestAcceptance struct {
 
 	// AccessTokenExtra represents arbitrary data that will be added to the access token and that will be returned on introspection and warden requests.
 	AccessTokenExtra map[string]interface{} `json:"accessTokenExtra,omitempty"`
 
+	// ACR is the authentication context class reference satisfied by the authentication of the subject, for example one of the acrValues of the consent request. It is added to the ID token as the \"acr\" claim.
+	Acr string `json:"acr,omitempty"`
+
+	// AMR lists the methods used to authenticate the subject, for example \"pwd\" and \"otp\". It is added to the ID token as the \"amr\" claim.
+	Amr []string `json:"amr,omitempty"`
+
+	// AuthTime is the time the subject authenticated and defaults to the time the consent request is accepted. It must be set if the consent app did not ask the subject to authenticate again, for example because the subject was still signed in to the consent app. It is added to the ID token as the \"auth_time\" claim and checked against the prompt and max_age parameters of the authorization request.
+	AuthTime time.Time `json:"authTime,omitempty"`
+
 	// A list of scopes that the user agreed to grant. It should be a subset of requestedScopes from the consent request.
 	GrantScopes []string `json:"grantScopes,omitempty"`
 
 	// IDTokenExtra represents arbitrary data that will be added to the ID token. The ID token will only be issued if the user agrees to it and if the client requested an ID token.
 	IdTokenExtra map[string]interface{} `json:"idTokenExtra,omitempty"`
 
+	// RememberFor is the number of seconds for which the decision is remembered. Until then, authorization requests of the subject and client which do not request more than the granted scopes skip the consent app, unless the client asks for prompt&#x3D;login or prompt&#x3D;consent. The decision is not remembered if this is zero.
+	RememberFor int64 `json:"rememberFor,omitempty"`
+
 	// Subject represents a unique identifier of the user (or service, or legal entity, ...) that accepted the OAuth2 request.
 	Subject string `json:"subject,omitempty"`
+
+	// UserinfoExtra represents claims that will only be returned by the userinfo endpoint, for example the claims the client requested from the userinfo endpoint using the claims parameter. Claims for the ID token are granted using IDTokenExtra, which are returned by the userinfo endpoint as well.
+	UserinfoExtra map[string]interface{} `json:"userinfoExtra,omitempty"`
 }
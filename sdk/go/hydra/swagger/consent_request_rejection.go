@@ -12,6 +12,8 @@ package swagger
 
 type ConsentRequestRejection struct {
 
+	Error_ RequestDeniedError `json:"error,omitempty"`
+
 	// Reason represents the reason why the user rejected the consent request.
 	Reason string `json:"reason,omitempty"`
 }
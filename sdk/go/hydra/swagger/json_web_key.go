@@ -25,6 +25,9 @@ type JsonWebKey struct {
 
 	E string `json:"e,omitempty"`
 
+	// The time, in seconds since the epoch, at which the key expires. Only set for keys created with an expiry.
+	Exp int64 `json:"exp,omitempty"`
+
 	K string `json:"k,omitempty"`
 
 	// The \"kid\" (key ID) parameter is used to match a specific key.  This is used, for instance, to choose among a set of keys within a JWK Set during key rollover.  The structure of the \"kid\" value is unspecified.  When \"kid\" values are used within a JWK Set, different keys within the JWK Set SHOULD use distinct \"kid\" values.  (One example in which different keys might use the same \"kid\" value is if they have different \"kty\" (key type) values but are considered to be equivalent alternatives by the application using them.)  The \"kid\" value is a case-sensitive string.
@@ -35,6 +38,9 @@ type JsonWebKey struct {
 
 	N string `json:"n,omitempty"`
 
+	// The time, in seconds since the epoch, before which the key must not be used. Only set for keys created with a not before time.
+	Nbf int64 `json:"nbf,omitempty"`
+
 	P string `json:"p,omitempty"`
 
 	Q string `json:"q,omitempty"`
@@ -49,5 +55,8 @@ type JsonWebKey struct {
 	// The \"x5c\" (X.509 certificate chain) parameter contains a chain of one or more PKIX certificates [RFC5280].  The certificate chain is represented as a JSON array of certificate value strings.  Each string in the array is a base64-encoded (Section 4 of [RFC4648] -- not base64url-encoded) DER [ITU.X690.1994] PKIX certificate value. The PKIX certificate containing the key value MUST be the first certificate.
 	X5c []string `json:"x5c,omitempty"`
 
+	// The \"x5t#S256\" (X.509 certificate SHA-256 thumbprint) parameter is a base64url-encoded SHA-256 thumbprint (a.k.a. digest) of the DER encoding of the first certificate of the \"x5c\" chain.
+	X5tS256 string `json:"x5t#S256,omitempty"`
+
 	Y string `json:"y,omitempty"`
 }
@@ -0,0 +1,33 @@
+/*
+ * Hydra OAuth2 & OpenID Connect Server
+ *
+ * Please refer to the user guide for in-depth documentation: https://ory.gitbooks.io/hydra/content/   Hydra offers OAuth 2.0 and OpenID Connect Core 1.0 capabilities as a service. Hydra is different, because it works with any existing authentication infrastructure, not just LDAP or SAML. By implementing a consent app (works with any programming language) you build a bridge between Hydra and your authentication infrastructure. Hydra is able to securely manage JSON Web Keys, and has a sophisticated policy-based access control you can use if you want to. Hydra is suitable for green- (new) and brownfield (existing) projects. If you are not familiar with OAuth 2.0 and are working on a greenfield project, we recommend evaluating if OAuth 2.0 really serves your purpose. Knowledge of OAuth 2.0 is imperative in understanding what Hydra does and how it works.   The official repository is located at https://github.com/ory/hydra   ### Important REST API Documentation Notes  The swagger generator used to create this documentation does currently not support example responses. To see request and response payloads click on **\"Show JSON schema\"**: ![Enable JSON Schema on Apiary](https://storage.googleapis.com/ory.am/hydra/json-schema.png)   The API documentation always refers to the latest tagged version of ORY Hydra. For previous API documentations, please refer to https://github.com/ory/hydra/blob/<tag-id>/docs/api.swagger.yaml - for example:  0.9.13: https://github.com/ory/hydra/blob/v0.9.13/docs/api.swagger.yaml 0.8.1: https://github.com/ory/hydra/blob/v0.8.1/docs/api.swagger.yaml
+ *
+ * OpenAPI spec version: Latest
+ * Contact: hi@ory.am
+ * Generated by: https://github.com/swagger-api/swagger-codegen.git
+ */
+
+package swagger
+
+// Credentials are OAuth 2.0 Client credentials which may only manage the keys of a single JSON Web Key Set.
+type JsonWebKeySetCredentials struct {
+
+	// Actions are the actions the credentials may perform on the keys of the set.
+	Actions []string `json:"actions,omitempty"`
+
+	// ClientID is the id of the OAuth 2.0 Client.
+	ClientId string `json:"client_id,omitempty"`
+
+	// ClientSecret is the secret of the OAuth 2.0 Client. It is only returned once.
+	ClientSecret string `json:"client_secret,omitempty"`
+
+	// PolicyID is the id of the policy which grants the actions on the set to the client.
+	PolicyId string `json:"policy_id,omitempty"`
+
+	// Scope is the scope the OAuth 2.0 Client may request, one hydra.keys.<action> scope per action.
+	Scope string `json:"scope,omitempty"`
+
+	// Set is the name of the JSON Web Key Set the credentials may manage.
+	Set string `json:"set,omitempty"`
+}
@@ -12,9 +12,21 @@ package swagger
 
 type JsonWebKeySetGeneratorRequest struct {
 
-	// The algorithm to be used for creating the key. Supports \"RS256\", \"ES512\", \"HS512\", and \"HS256\"
+	// The algorithm to be used for creating the key. Supports \"RS256\", \"ES256\", \"ES384\", \"ES512\", \"EdDSA\", \"HS512\", and \"HS256\"
 	Alg string `json:"alg"`
 
 	// The kid of the key to be created
 	Kid string `json:"kid"`
+
+	// The size of the key in bits. Only supported by \"RS256\", must be between 2048 and 8192 and defaults to 4096.
+	Bits int64 `json:"bits,omitempty"`
+
+	// The intended use of the key, either \"sig\" (signature) or \"enc\" (encryption). Defaults to the use chosen by the generator.
+	Use string `json:"use,omitempty"`
+
+	// The time, in seconds since the epoch, before which the key must not be used.
+	Nbf int64 `json:"nbf,omitempty"`
+
+	// The time, in seconds since the epoch, at which the key expires. Expired keys are no longer published at /.well-known/jwks.json and are removed from their set periodically.
+	Exp int64 `json:"exp,omitempty"`
 }
@@ -11,6 +11,20 @@
 package swagger
 
 type OAuth2Client struct {
+	// AccessTokenLifespan shortens the lifespan of access tokens issued to this client, for example \"5m\" for high-risk clients. Valid time units are \"s\", \"m\" and \"h\". If empty or longer than ACCESS_TOKEN_LIFESPAN, the ACCESS_TOKEN_LIFESPAN is used.
+	AccessTokenLifespan string `json:"access_token_lifespan,omitempty"`
+
+	// AllowedCORSOrigins is an array of origins, for example \"https://app.mydomain.com\", single-page apps of the client are served at. Cross-origin requests from these origins to the token, revocation and userinfo endpoints and the well-known documents are allowed.
+	AllowedCorsOrigins []string `json:"allowed_cors_origins,omitempty"`
+
+	// Audience is an array of the audiences, for example the URLs of resource servers, the client may request access tokens for using the audience parameter at the token endpoint. If empty, the client can not request an audience.
+	Audience []string `json:"audience,omitempty"`
+
+	// AuthorizeCodeLifespan shortens the lifespan of authorize codes issued to this client. Valid time units are \"s\", \"m\" and \"h\". If empty or longer than AUTH_CODE_LIFESPAN, the AUTH_CODE_LIFESPAN is used.
+	AuthorizeCodeLifespan string `json:"authorize_code_lifespan,omitempty"`
+
+	// BackChannelLogoutURI receives a logout token, a JSON Web Token signed using the OpenID Connect key, in the \"logout_token\" form parameter of a POST request when the user logs out.
+	BackchannelLogoutUri string `json:"backchannel_logout_uri,omitempty"`
 
 	// Name is the human-readable string name of the client to be presented to the end-user during authorization.
 	ClientName string `json:"client_name,omitempty"`
@@ -18,18 +32,50 @@ type OAuth2Client struct {
 	// Secret is the client's secret. The secret will be included in the create request as cleartext, and then never again. The secret is stored using BCrypt so it is impossible to recover it. Tell your users that they need to write the secret down as it will not be made available again.
 	ClientSecret string `json:"client_secret,omitempty"`
 
+	ClientSecretGeneration OAuth2ClientSecretGeneration `json:"client_secret_generation,omitempty"`
+
 	// ClientURI is an URL string of a web page providing information about the client. If present, the server SHOULD display this URL to the end-user in a clickable fashion.
 	ClientUri string `json:"client_uri,omitempty"`
 
+	// ConsentChallengeLifespan is how long the consent challenge of this client remains valid, for example \"30m\" for kiosk flows where the user needs more time to sign in. Valid time units are \"s\", \"m\" and \"h\". If empty, the CHALLENGE_TOKEN_LIFESPAN is used.
+	ConsentChallengeLifespan string `json:"consent_challenge_lifespan,omitempty"`
+
 	// Contacts is a array of strings representing ways to contact people responsible for this client, typically email addresses.
 	Contacts []string `json:"contacts,omitempty"`
 
+	// Environment is the environment the client is deployed to, for example \"production\" or \"staging\". Policies deciding on consent can refer to it using the \"environment\" context key.
+	Environment string `json:"environment,omitempty"`
+
+	// FirstParty marks clients operated by the same organization as this server, for example its own web and mobile apps. Policies deciding on consent can refer to it using the \"firstParty\" context key.
+	FirstParty bool `json:"first_party,omitempty"`
+
+	// FrontChannelLogoutURI is loaded in an iframe of the logout page when the user logs out, with the issuer and the session id in the \"iss\" and \"sid\" query parameters, so the client can end its own session.
+	FrontchannelLogoutUri string `json:"frontchannel_logout_uri,omitempty"`
+
 	// GrantTypes is an array of grant types the client is allowed to use.
 	GrantTypes []string `json:"grant_types,omitempty"`
 
 	// ID is the id for this client.
 	Id string `json:"id,omitempty"`
 
+	// IDTokenEncryptedResponseAlg is the algorithm used to encrypt the content encryption key of ID tokens issued to this client. If set, ID tokens are signed and then encrypted to a key from jwks or jwks_uri.
+	IdTokenEncryptedResponseAlg string `json:"id_token_encrypted_response_alg,omitempty"`
+
+	// IDTokenEncryptedResponseEnc is the algorithm used to encrypt the content of ID tokens issued to this client. Requires id_token_encrypted_response_alg and defaults to A128CBC-HS256.
+	IdTokenEncryptedResponseEnc string `json:"id_token_encrypted_response_enc,omitempty"`
+
+	// IDTokenLifespan shortens the lifespan of ID tokens issued to this client. Valid time units are \"s\", \"m\" and \"h\". If empty or longer than ID_TOKEN_LIFESPAN, the ID_TOKEN_LIFESPAN is used.
+	IdTokenLifespan string `json:"id_token_lifespan,omitempty"`
+
+	// Internal marks clients which are only used by employees or internal services. Policies deciding on consent can refer to it using the \"internal\" context key.
+	Internal bool `json:"internal,omitempty"`
+
+	// JSONWebKeys is the JSON Web Key Set containing the public keys of the client. ID tokens and userinfo responses are encrypted to one of these keys if the client registered an encryption algorithm. Must not be set together with jwks_uri.
+	Jwks *JsonWebKeySet `json:"jwks,omitempty"`
+
+	// JSONWebKeysURI is the URL of the JSON Web Key Set containing the public keys of the client. Clients which rotate their keys should use it instead of jwks.
+	JwksUri string `json:"jwks_uri,omitempty"`
+
 	// LogoURI is an URL string that references a logo for the client.
 	LogoUri string `json:"logo_uri,omitempty"`
 
@@ -39,18 +85,72 @@ type OAuth2Client struct {
 	// PolicyURI is a URL string that points to a human-readable privacy policy document that describes how the deployment organization collects, uses, retains, and discloses personal data.
 	PolicyUri string `json:"policy_uri,omitempty"`
 
+	// PostLogoutRedirectURIs is an array of URLs the user agent may be redirected to after logging out at the OpenID Connect end session endpoint.
+	PostLogoutRedirectUris []string `json:"post_logout_redirect_uris,omitempty"`
+
 	// Public is a boolean that identifies this client as public, meaning that it does not have a secret. It will disable the client_credentials grant type for this client if set.
 	Public bool `json:"public,omitempty"`
 
 	// RedirectURIs is an array of allowed redirect urls for the client, for example http://mydomain/oauth/callback .
 	RedirectUris []string `json:"redirect_uris,omitempty"`
 
+	// RefreshTokenLifespan is how long refresh tokens issued to this client remain valid, for example \"720h\". Valid time units are \"s\", \"m\" and \"h\". If empty, refresh tokens do not expire.
+	RefreshTokenLifespan string `json:"refresh_token_lifespan,omitempty"`
+
+	// RequirePKCE forces the client to use PKCE with the S256 code challenge method in the authorization code flow. Authorization requests without a code challenge and code exchanges without a code verifier are rejected.
+	RequirePkce bool `json:"require_pkce,omitempty"`
+
+	// RequirePushedAuthorizationRequests forces the client to push its authorization requests to the pushed authorization request endpoint. Authorization requests which do not refer to a pushed request are rejected.
+	RequirePushedAuthorizationRequests bool `json:"require_pushed_authorization_requests,omitempty"`
+
+	// ResourceServerAudience marks the client as a resource server. It is an array of the audiences, for example the URL of the resource server, the client serves. The client may only introspect access tokens issued for one of these audiences and the introspection response only contains the claims relevant to it.
+	ResourceServerAudience []string `json:"resource_server_audience,omitempty"`
+
 	// ResponseTypes is an array of the OAuth 2.0 response type strings that the client can use at the authorization endpoint.
 	ResponseTypes []string `json:"response_types,omitempty"`
 
 	// Scope is a string containing a space-separated list of scope values (as described in Section 3.3 of OAuth 2.0 [RFC6749]) that the client can use when requesting access tokens.
 	Scope string `json:"scope,omitempty"`
 
+	// SkipConsent marks trusted clients, for example internal applications used by employees, which are granted all requested scopes without showing a consent screen. Once the subject has logged in, the authorization endpoint accepts consent on behalf of the subject instead of redirecting to the consent app.
+	SkipConsent bool `json:"skip_consent,omitempty"`
+
+	// Status is \"pending\" for clients which registered themselves using dynamic client registration and are waiting for approval, and empty for all other clients. It can not be changed by updating the client, use the approve endpoint instead.
+	Status string `json:"status,omitempty"`
+
+	// TLSClientAuthCertificate is the PEM encoded, self-signed certificate the client presents over mutual TLS when using self_signed_tls_client_auth.
+	TlsClientAuthCertificate string `json:"tls_client_auth_certificate,omitempty"`
+
+	// TLSClientAuthPublicKeySHA256 is the base64url encoded SHA-256 hash of the subject public key info of the certificate the client presents over mutual TLS when using self_signed_tls_client_auth. Pinning the public key instead of the certificate allows the client to renew its certificate without updating the client.
+	TlsClientAuthPublicKeySha256 string `json:"tls_client_auth_public_key_sha256,omitempty"`
+
+	// TLSClientAuthSANDNS is the dNSName subject alternative name of the certificate the client presents when using tls_client_auth.
+	TlsClientAuthSanDns string `json:"tls_client_auth_san_dns,omitempty"`
+
+	// TLSClientAuthSANEmail is the rfc822Name subject alternative name of the certificate the client presents when using tls_client_auth.
+	TlsClientAuthSanEmail string `json:"tls_client_auth_san_email,omitempty"`
+
+	// TLSClientAuthSANIP is the iPAddress subject alternative name of the certificate the client presents when using tls_client_auth, in dotted decimal notation for IPv4 or colon-delimited hexadecimal for IPv6.
+	TlsClientAuthSanIp string `json:"tls_client_auth_san_ip,omitempty"`
+
+	// TLSClientAuthSANURI is the uniformResourceIdentifier subject alternative name of the certificate the client presents when using tls_client_auth.
+	TlsClientAuthSanUri string `json:"tls_client_auth_san_uri,omitempty"`
+
+	// TLSClientAuthSubjectDN is the RFC 4514 string representation of the subject distinguished name of the certificate the client presents when using tls_client_auth, for example \"CN=client,O=Example,C=US\". Exactly one of the tls_client_auth_subject_dn and tls_client_auth_san_* values must be set when using tls_client_auth.
+	TlsClientAuthSubjectDn string `json:"tls_client_auth_subject_dn,omitempty"`
+
+	// TokenEndpointAuthMethod is the requested client authentication method for the token endpoint. Supported values are client_secret_basic (default), tls_client_auth and self_signed_tls_client_auth.
+	TokenEndpointAuthMethod string `json:"token_endpoint_auth_method,omitempty"`
+
 	// TermsOfServiceURI is a URL string that points to a human-readable terms of service document for the client that describes a contractual relationship between the end-user and the client that the end-user accepts when authorizing the client.
 	TosUri string `json:"tos_uri,omitempty"`
+
+	// UserinfoEncryptedResponseAlg is the algorithm used to encrypt the content encryption key of userinfo responses. If set, the userinfo endpoint responds with an encrypted JSON Web Token of content type application/jwt.
+	UserinfoEncryptedResponseAlg string `json:"userinfo_encrypted_response_alg,omitempty"`
+
+	// UserinfoEncryptedResponseEnc is the algorithm used to encrypt the content of userinfo responses. Requires userinfo_encrypted_response_alg and defaults to A128CBC-HS256.
+	UserinfoEncryptedResponseEnc string `json:"userinfo_encrypted_response_enc,omitempty"`
+
+	// UserinfoSignedResponseAlg is the algorithm used to sign userinfo responses. If set, the userinfo endpoint responds with a JSON Web Token of content type application/jwt, signed using the OpenID Connect key. It must match the algorithm of the OpenID Connect key.
+	UserinfoSignedResponseAlg string `json:"userinfo_signed_response_alg,omitempty"`
 }
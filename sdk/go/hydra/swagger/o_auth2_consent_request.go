@@ -12,6 +12,12 @@ package swagger
 
 type OAuth2ConsentRequest struct {
 
+	// ACRValues are the authentication context class references requested by the client using the acr_values parameter, in order of preference.
+	AcrValues []string `json:"acrValues,omitempty"`
+
+	// AutoGrantedScopes are the requested scopes which policies allow to grant to the client without asking the user. All requested scopes are granted automatically to clients which skip consent.
+	AutoGrantedScopes []string `json:"autoGrantedScopes,omitempty"`
+
 	// ClientID is the client id that initiated the OAuth2 request.
 	ClientId string `json:"clientId,omitempty"`
 
@@ -24,6 +30,11 @@ type OAuth2ConsentRequest struct {
 	// Redirect URL is the URL where the user agent should be redirected to after the consent has been accepted or rejected.
 	RedirectUrl string `json:"redirectUrl,omitempty"`
 
+	RequestedClaims ClaimsRequest `json:"requestedClaims,omitempty"`
+
 	// RequestedScopes represents a list of scopes that have been requested by the OAuth2 request initiator.
 	RequestedScopes []string `json:"requestedScopes,omitempty"`
+
+	// SkipConsent is true if the client skips consent or policies allow the consent app to accept the request without showing a consent screen, granting the AutoGrantedScopes once the user is authenticated.
+	SkipConsent bool `json:"skipConsent,omitempty"`
 }
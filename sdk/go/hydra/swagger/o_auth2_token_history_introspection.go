@@ -0,0 +1,42 @@
+/*
+ * Hydra OAuth2 & OpenID Connect Server
+ *
+ * Please refer to the user guide for in-depth documentation: https://ory.gitbooks.io/hydra/content/   Hydra offers OAuth 2.0 and OpenID Connect Core 1.0 capabilities as a service. Hydra is different, because it works with any existing authentication infrastructure, not just LDAP or SAML. By implementing a consent app (works with any programming language) you build a bridge between Hydra and your authentication infrastructure. Hydra is able to securely manage JSON Web Keys, and has a sophisticated policy-based access control you can use if you want to. Hydra is suitable for green- (new) and brownfield (existing) projects. If you are not familiar with OAuth 2.0 and are working on a greenfield project, we recommend evaluating if OAuth 2.0 really serves your purpose. Knowledge of OAuth 2.0 is imperative in understanding what Hydra does and how it works.   The official repository is located at https://github.com/ory/hydra   ### Important REST API Documentation Notes  The swagger generator used to create this documentation does currently not support example responses. To see request and response payloads click on **\"Show JSON schema\"**: ![Enable JSON Schema on Apiary](https://storage.googleapis.com/ory.am/hydra/json-schema.png)   The API documentation always refers to the latest tagged version of ORY Hydra. For previous API documentations, please refer to https://github.com/ory/hydra/blob/<tag-id>/docs/api.swagger.yaml - for example:  0.9.13: https://github.com/ory/hydra/blob/v0.9.13/docs/api.swagger.yaml 0.8.1: https://github.com/ory/hydra/blob/v0.8.1/docs/api.swagger.yaml
+ *
+ * OpenAPI spec version: Latest
+ * Contact: hi@ory.am
+ * Generated by: https://github.com/swagger-api/swagger-codegen.git
+ */
+
+package swagger
+
+// TokenHistoryIntrospection tells whether a token was active at a given point in time.
+type OAuth2TokenHistoryIntrospection struct {
+
+	// Active is true if the token had been issued, had not expired and had not been revoked at the requested time.
+	Active bool `json:"active,omitempty"`
+
+	// At is the point in time the token was checked against, as an integer timestamp measured in the number of seconds since January 1 1970 UTC.
+	At int64 `json:"at,omitempty"`
+
+	// ClientID is the client identifier for the OAuth 2.0 client that requested this token.
+	ClientId string `json:"client_id,omitempty"`
+
+	// ExpiresAt is an integer timestamp indicating when this token expires or expired.
+	Exp int64 `json:"exp,omitempty"`
+
+	// IssuedAt is an integer timestamp indicating when this token was issued.
+	Iat int64 `json:"iat,omitempty"`
+
+	// RevokedAt is an integer timestamp indicating when this token was revoked or deleted.
+	RevokedAt int64 `json:"revoked_at,omitempty"`
+
+	// Scope is a space-separated list of scopes granted to this token.
+	Scope string `json:"scope,omitempty"`
+
+	// Subject of the token.
+	Sub string `json:"sub,omitempty"`
+
+	// TokenType is either access_token or refresh_token.
+	TokenType string `json:"token_type,omitempty"`
+}
@@ -15,8 +15,8 @@ type OAuth2TokenIntrospection struct {
 	// Active is a boolean indicator of whether or not the presented token is currently active.  The specifics of a token's \"active\" state will vary depending on the implementation of the authorization server and the information it keeps about its tokens, but a \"true\" value return for the \"active\" property will generally indicate that a given token has been issued by this authorization server, has not been revoked by the resource owner, and is within its given time window of validity (e.g., after its issuance time and before its expiration time).
 	Active bool `json:"active,omitempty"`
 
-	// ClientID is a service-specific string identifier or list of string identifiers representing the intended audience for this token.
-	Aud string `json:"aud,omitempty"`
+	// Audience is a list of service-specific string identifiers representing the intended audience for this token. It contains the audiences requested at the token endpoint, or the client id if none were requested.
+	Aud []string `json:"aud,omitempty"`
 
 	// ClientID is aclient identifier for the OAuth 2.0 client that requested this token.
 	ClientId string `json:"client_id,omitempty"`
@@ -0,0 +1,270 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hydra
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ory/hydra/sdk/go/hydra/swagger"
+	"github.com/pkg/errors"
+)
+
+// ErrWardenUnavailable is returned by ResilientWarden when ORY Hydra is considered unreachable and the circuit
+// breaker is open.
+var ErrWardenUnavailable = errors.New("The warden is unavailable")
+
+// WardenOptions configures caching and failure handling of warden access requests. The zero value disables caching
+// and the circuit breaker.
+type WardenOptions struct {
+	// CacheTTL is the duration for which access request decisions are cached. Decisions for access tokens are never
+	// cached beyond the token's expiry. Set to zero to disable the cache.
+	CacheTTL time.Duration
+
+	// CacheSize is the maximum number of cached decisions, defaults to 10000.
+	CacheSize int
+
+	// FailureThreshold is the number of consecutive failures after which the circuit breaker opens. While the circuit
+	// is open, no requests are sent to ORY Hydra. Set to zero to disable the circuit breaker.
+	FailureThreshold int
+
+	// OpenDuration is the duration the circuit breaker stays open before a single request is let through to check if
+	// ORY Hydra recovered, defaults to 30 seconds.
+	OpenDuration time.Duration
+
+	// FailOpen allows all access requests while ORY Hydra is unreachable. By default, access requests are denied and
+	// an error is returned.
+	FailOpen bool
+}
+
+type wardenDecider interface {
+	DoesWardenAllowAccessRequest(body swagger.WardenAccessRequest) (*swagger.WardenAccessRequestResponse, *swagger.APIResponse, error)
+	DoesWardenAllowTokenAccessRequest(body swagger.WardenTokenAccessRequest) (*swagger.WardenTokenAccessRequestResponse, *swagger.APIResponse, error)
+}
+
+// ResilientWarden wraps the warden API with a decision cache and a circuit breaker, so that short outages of ORY
+// Hydra do not turn into outages of the services relying on it.
+type ResilientWarden struct {
+	Warden  wardenDecider
+	Options WardenOptions
+
+	cache    map[string]*cachedDecision
+	failures int
+	openedAt time.Time
+	sync.Mutex
+}
+
+type cachedDecision struct {
+	access    *swagger.WardenAccessRequestResponse
+	token     *swagger.WardenTokenAccessRequestResponse
+	response  *swagger.APIResponse
+	expiresAt time.Time
+}
+
+// NewResilientWarden returns a ResilientWarden for the given warden API.
+func NewResilientWarden(w wardenDecider, o WardenOptions) *ResilientWarden {
+	if o.CacheSize <= 0 {
+		o.CacheSize = 10000
+	}
+	if o.OpenDuration <= 0 {
+		o.OpenDuration = time.Second * 30
+	}
+
+	return &ResilientWarden{
+		Warden:  w,
+		Options: o,
+		cache:   map[string]*cachedDecision{},
+	}
+}
+
+func (w *ResilientWarden) DoesWardenAllowAccessRequest(body swagger.WardenAccessRequest) (*swagger.WardenAccessRequestResponse, *swagger.APIResponse, error) {
+	key := cacheKey("access", body)
+	if cached := w.get(key); cached != nil {
+		return cached.access, cached.response, nil
+	}
+
+	if !w.ready() {
+		return w.failAccess(nil, ErrWardenUnavailable)
+	}
+
+	result, response, err := w.Warden.DoesWardenAllowAccessRequest(body)
+	if isWardenFailure(response, err) {
+		w.failed()
+		return w.failAccess(response, err)
+	}
+	w.succeeded()
+
+	if response.StatusCode == http.StatusOK {
+		w.set(key, &cachedDecision{access: result, response: response}, time.Time{})
+	}
+	return result, response, err
+}
+
+func (w *ResilientWarden) DoesWardenAllowTokenAccessRequest(body swagger.WardenTokenAccessRequest) (*swagger.WardenTokenAccessRequestResponse, *swagger.APIResponse, error) {
+	key := cacheKey("token", body)
+	if cached := w.get(key); cached != nil {
+		return cached.token, cached.response, nil
+	}
+
+	if !w.ready() {
+		return w.failToken(nil, ErrWardenUnavailable)
+	}
+
+	result, response, err := w.Warden.DoesWardenAllowTokenAccessRequest(body)
+	if isWardenFailure(response, err) {
+		w.failed()
+		return w.failToken(response, err)
+	}
+	w.succeeded()
+
+	if response.StatusCode == http.StatusOK {
+		expiresAt, _ := time.Parse(time.RFC3339, result.ExpiresAt)
+		w.set(key, &cachedDecision{token: result, response: response}, expiresAt)
+	}
+	return result, response, err
+}
+
+func (w *ResilientWarden) failAccess(response *swagger.APIResponse, err error) (*swagger.WardenAccessRequestResponse, *swagger.APIResponse, error) {
+	if w.Options.FailOpen {
+		return &swagger.WardenAccessRequestResponse{Allowed: true}, unavailableResponse(nil, http.StatusOK), nil
+	}
+	return &swagger.WardenAccessRequestResponse{Allowed: false}, unavailableResponse(response, http.StatusServiceUnavailable), wardenError(err)
+}
+
+func (w *ResilientWarden) failToken(response *swagger.APIResponse, err error) (*swagger.WardenTokenAccessRequestResponse, *swagger.APIResponse, error) {
+	if w.Options.FailOpen {
+		return &swagger.WardenTokenAccessRequestResponse{Allowed: true}, unavailableResponse(nil, http.StatusOK), nil
+	}
+	return &swagger.WardenTokenAccessRequestResponse{Allowed: false}, unavailableResponse(response, http.StatusServiceUnavailable), wardenError(err)
+}
+
+// ready returns false if the circuit breaker is open. Once OpenDuration elapsed, a single request is let through.
+func (w *ResilientWarden) ready() bool {
+	w.Lock()
+	defer w.Unlock()
+
+	if w.Options.FailureThreshold <= 0 || w.failures < w.Options.FailureThreshold {
+		return true
+	}
+
+	if time.Since(w.openedAt) < w.Options.OpenDuration {
+		return false
+	}
+
+	// Half-open: let this request through and keep the circuit open for everyone else until it completes.
+	w.openedAt = time.Now()
+	return true
+}
+
+func (w *ResilientWarden) failed() {
+	w.Lock()
+	defer w.Unlock()
+
+	w.failures++
+	if w.Options.FailureThreshold > 0 && w.failures >= w.Options.FailureThreshold {
+		w.openedAt = time.Now()
+	}
+}
+
+func (w *ResilientWarden) succeeded() {
+	w.Lock()
+	defer w.Unlock()
+	w.failures = 0
+}
+
+func (w *ResilientWarden) get(key string) *cachedDecision {
+	if w.Options.CacheTTL <= 0 {
+		return nil
+	}
+
+	w.Lock()
+	defer w.Unlock()
+
+	cached, ok := w.cache[key]
+	if !ok {
+		return nil
+	} else if time.Now().After(cached.expiresAt) {
+		delete(w.cache, key)
+		return nil
+	}
+	return cached
+}
+
+func (w *ResilientWarden) set(key string, d *cachedDecision, notAfter time.Time) {
+	if w.Options.CacheTTL <= 0 {
+		return
+	}
+
+	d.expiresAt = time.Now().Add(w.Options.CacheTTL)
+	if !notAfter.IsZero() && notAfter.Before(d.expiresAt) {
+		d.expiresAt = notAfter
+	}
+
+	w.Lock()
+	defer w.Unlock()
+
+	if len(w.cache) >= w.Options.CacheSize {
+		now := time.Now()
+		for k, v := range w.cache {
+			if now.After(v.expiresAt) {
+				delete(w.cache, k)
+			}
+		}
+	}
+
+	for k := range w.cache {
+		if len(w.cache) < w.Options.CacheSize {
+			break
+		}
+		delete(w.cache, k)
+	}
+
+	w.cache[key] = d
+}
+
+func isWardenFailure(response *swagger.APIResponse, err error) bool {
+	return err != nil || response == nil || response.Response == nil || response.StatusCode >= http.StatusInternalServerError
+}
+
+func wardenError(err error) error {
+	if err == nil {
+		return ErrWardenUnavailable
+	}
+	return errors.Wrap(err, ErrWardenUnavailable.Error())
+}
+
+// unavailableResponse returns response if it contains a HTTP response, or a response with the given status code
+// otherwise. Callers usually check the status code without checking the response for nil first.
+func unavailableResponse(response *swagger.APIResponse, status int) *swagger.APIResponse {
+	if response != nil && response.Response != nil {
+		return response
+	}
+
+	return &swagger.APIResponse{
+		Response: &http.Response{StatusCode: status},
+		Message:  ErrWardenUnavailable.Error(),
+	}
+}
+
+// cacheKey hashes the request so that access tokens are not kept in memory in plain text.
+func cacheKey(prefix string, body interface{}) string {
+	out, _ := json.Marshal(body)
+	hash := sha256.Sum256(out)
+	return prefix + ":" + hex.EncodeToString(hash[:])
+}
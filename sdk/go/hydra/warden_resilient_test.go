@@ -0,0 +1,110 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hydra
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ory/hydra/sdk/go/hydra/swagger"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeWarden struct {
+	calls   int
+	status  int
+	err     error
+	allowed bool
+	expires string
+}
+
+func (f *fakeWarden) DoesWardenAllowAccessRequest(body swagger.WardenAccessRequest) (*swagger.WardenAccessRequestResponse, *swagger.APIResponse, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, &swagger.APIResponse{}, f.err
+	}
+	return &swagger.WardenAccessRequestResponse{Allowed: f.allowed}, &swagger.APIResponse{Response: &http.Response{StatusCode: f.status}}, nil
+}
+
+func (f *fakeWarden) DoesWardenAllowTokenAccessRequest(body swagger.WardenTokenAccessRequest) (*swagger.WardenTokenAccessRequestResponse, *swagger.APIResponse, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, &swagger.APIResponse{}, f.err
+	}
+	return &swagger.WardenTokenAccessRequestResponse{Allowed: f.allowed, ExpiresAt: f.expires}, &swagger.APIResponse{Response: &http.Response{StatusCode: f.status}}, nil
+}
+
+func TestResilientWardenCache(t *testing.T) {
+	f := &fakeWarden{status: http.StatusOK, allowed: true}
+	w := NewResilientWarden(f, WardenOptions{CacheTTL: time.Minute})
+
+	req := swagger.WardenAccessRequest{Subject: "alice", Resource: "foo", Action: "bar"}
+	for i := 0; i < 3; i++ {
+		result, response, err := w.DoesWardenAllowAccessRequest(req)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+		assert.True(t, result.Allowed)
+	}
+	assert.Equal(t, 1, f.calls)
+
+	_, _, err := w.DoesWardenAllowAccessRequest(swagger.WardenAccessRequest{Subject: "bob", Resource: "foo", Action: "bar"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, f.calls)
+
+	// Decisions for expired tokens are not cached.
+	f.expires = time.Now().Add(-time.Hour).Format(time.RFC3339)
+	for i := 0; i < 2; i++ {
+		_, _, err := w.DoesWardenAllowTokenAccessRequest(swagger.WardenTokenAccessRequest{Token: "token"})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 4, f.calls)
+}
+
+func TestResilientWardenCircuitBreaker(t *testing.T) {
+	f := &fakeWarden{err: errors.New("connection refused")}
+	w := NewResilientWarden(f, WardenOptions{FailureThreshold: 2, OpenDuration: time.Millisecond * 50})
+
+	req := swagger.WardenAccessRequest{Subject: "alice", Resource: "foo", Action: "bar"}
+	for i := 0; i < 4; i++ {
+		result, response, err := w.DoesWardenAllowAccessRequest(req)
+		require.Error(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, response.StatusCode)
+		assert.False(t, result.Allowed)
+	}
+	assert.Equal(t, 2, f.calls)
+
+	time.Sleep(time.Millisecond * 60)
+	f.err = nil
+	f.status = http.StatusOK
+	f.allowed = true
+
+	result, _, err := w.DoesWardenAllowAccessRequest(req)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 3, f.calls)
+}
+
+func TestResilientWardenFailOpen(t *testing.T) {
+	f := &fakeWarden{status: http.StatusBadGateway}
+	w := NewResilientWarden(f, WardenOptions{FailOpen: true})
+
+	result, response, err := w.DoesWardenAllowTokenAccessRequest(swagger.WardenTokenAccessRequest{Token: "token"})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.True(t, result.Allowed)
+}
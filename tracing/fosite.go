@@ -0,0 +1,196 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"github.com/ory/fosite"
+	foauth2 "github.com/ory/fosite/handler/oauth2"
+	"github.com/ory/fosite/handler/openid"
+	"github.com/ory/hydra/pkg"
+)
+
+// FositeStore adds a span to every call of the wrapped storage.
+type FositeStore struct {
+	pkg.FositeStorer
+}
+
+func (s *FositeStore) GetClient(ctx context.Context, id string) (c fosite.Client, err error) {
+	span, ctx := startSpan(ctx, "fosite.GetClient")
+	defer func() { finish(span, err) }()
+	return s.FositeStorer.GetClient(ctx, id)
+}
+
+func (s *FositeStore) CreateAuthorizeCodeSession(ctx context.Context, code string, req fosite.Requester) (err error) {
+	span, ctx := startSpan(ctx, "fosite.CreateAuthorizeCodeSession")
+	defer func() { finish(span, err) }()
+	return s.FositeStorer.CreateAuthorizeCodeSession(ctx, code, req)
+}
+
+func (s *FositeStore) GetAuthorizeCodeSession(ctx context.Context, code string, session fosite.Session) (req fosite.Requester, err error) {
+	span, ctx := startSpan(ctx, "fosite.GetAuthorizeCodeSession")
+	defer func() { finish(span, err) }()
+	return s.FositeStorer.GetAuthorizeCodeSession(ctx, code, session)
+}
+
+func (s *FositeStore) DeleteAuthorizeCodeSession(ctx context.Context, code string) (err error) {
+	span, ctx := startSpan(ctx, "fosite.DeleteAuthorizeCodeSession")
+	defer func() { finish(span, err) }()
+	return s.FositeStorer.DeleteAuthorizeCodeSession(ctx, code)
+}
+
+func (s *FositeStore) CreateAccessTokenSession(ctx context.Context, signature string, req fosite.Requester) (err error) {
+	span, ctx := startSpan(ctx, "fosite.CreateAccessTokenSession")
+	defer func() { finish(span, err) }()
+	return s.FositeStorer.CreateAccessTokenSession(ctx, signature, req)
+}
+
+func (s *FositeStore) GetAccessTokenSession(ctx context.Context, signature string, session fosite.Session) (req fosite.Requester, err error) {
+	span, ctx := startSpan(ctx, "fosite.GetAccessTokenSession")
+	defer func() { finish(span, err) }()
+	return s.FositeStorer.GetAccessTokenSession(ctx, signature, session)
+}
+
+func (s *FositeStore) DeleteAccessTokenSession(ctx context.Context, signature string) (err error) {
+	span, ctx := startSpan(ctx, "fosite.DeleteAccessTokenSession")
+	defer func() { finish(span, err) }()
+	return s.FositeStorer.DeleteAccessTokenSession(ctx, signature)
+}
+
+func (s *FositeStore) CreateRefreshTokenSession(ctx context.Context, signature string, req fosite.Requester) (err error) {
+	span, ctx := startSpan(ctx, "fosite.CreateRefreshTokenSession")
+	defer func() { finish(span, err) }()
+	return s.FositeStorer.CreateRefreshTokenSession(ctx, signature, req)
+}
+
+func (s *FositeStore) GetRefreshTokenSession(ctx context.Context, signature string, session fosite.Session) (req fosite.Requester, err error) {
+	span, ctx := startSpan(ctx, "fosite.GetRefreshTokenSession")
+	defer func() { finish(span, err) }()
+	return s.FositeStorer.GetRefreshTokenSession(ctx, signature, session)
+}
+
+func (s *FositeStore) DeleteRefreshTokenSession(ctx context.Context, signature string) (err error) {
+	span, ctx := startSpan(ctx, "fosite.DeleteRefreshTokenSession")
+	defer func() { finish(span, err) }()
+	return s.FositeStorer.DeleteRefreshTokenSession(ctx, signature)
+}
+
+func (s *FositeStore) CreateOpenIDConnectSession(ctx context.Context, authorizeCode string, req fosite.Requester) (err error) {
+	span, ctx := startSpan(ctx, "fosite.CreateOpenIDConnectSession")
+	defer func() { finish(span, err) }()
+	return s.FositeStorer.CreateOpenIDConnectSession(ctx, authorizeCode, req)
+}
+
+func (s *FositeStore) GetOpenIDConnectSession(ctx context.Context, authorizeCode string, requester fosite.Requester) (req fosite.Requester, err error) {
+	span, ctx := startSpan(ctx, "fosite.GetOpenIDConnectSession")
+	defer func() { finish(span, err) }()
+	return s.FositeStorer.GetOpenIDConnectSession(ctx, authorizeCode, requester)
+}
+
+func (s *FositeStore) DeleteOpenIDConnectSession(ctx context.Context, authorizeCode string) (err error) {
+	span, ctx := startSpan(ctx, "fosite.DeleteOpenIDConnectSession")
+	defer func() { finish(span, err) }()
+	return s.FositeStorer.DeleteOpenIDConnectSession(ctx, authorizeCode)
+}
+
+func (s *FositeStore) RevokeRefreshToken(ctx context.Context, requestID string) (err error) {
+	span, ctx := startSpan(ctx, "fosite.RevokeRefreshToken")
+	defer func() { finish(span, err) }()
+	return s.FositeStorer.RevokeRefreshToken(ctx, requestID)
+}
+
+func (s *FositeStore) RevokeAccessToken(ctx context.Context, requestID string) (err error) {
+	span, ctx := startSpan(ctx, "fosite.RevokeAccessToken")
+	defer func() { finish(span, err) }()
+	return s.FositeStorer.RevokeAccessToken(ctx, requestID)
+}
+
+func (s *FositeStore) RevokeClientTokens(ctx context.Context, clientID string) (requestIDs []string, err error) {
+	span, ctx := startSpan(ctx, "fosite.RevokeClientTokens")
+	defer func() { finish(span, err) }()
+	return s.FositeStorer.RevokeClientTokens(ctx, clientID)
+}
+
+func (s *FositeStore) RevokeSubjectTokens(ctx context.Context, subject string) (requestIDs []string, err error) {
+	span, ctx := startSpan(ctx, "fosite.RevokeSubjectTokens")
+	defer func() { finish(span, err) }()
+	return s.FositeStorer.RevokeSubjectTokens(ctx, subject)
+}
+
+func (s *FositeStore) RevokeSubjectClientTokens(ctx context.Context, subject, clientID string) (requestIDs []string, err error) {
+	span, ctx := startSpan(ctx, "fosite.RevokeSubjectClientTokens")
+	defer func() { finish(span, err) }()
+	return s.FositeStorer.RevokeSubjectClientTokens(ctx, subject, clientID)
+}
+
+func (s *FositeStore) FlushInactiveAccessTokens(ctx context.Context, notAfter time.Time) (err error) {
+	span, ctx := startSpan(ctx, "fosite.FlushInactiveAccessTokens")
+	defer func() { finish(span, err) }()
+	return s.FositeStorer.FlushInactiveAccessTokens(ctx, notAfter)
+}
+
+// CoreStrategy adds a span to every generation and validation of access tokens, refresh tokens and authorize codes.
+type CoreStrategy struct {
+	foauth2.CoreStrategy
+}
+
+func (s *CoreStrategy) GenerateAccessToken(ctx context.Context, requester fosite.Requester) (token string, signature string, err error) {
+	span, ctx := startSpan(ctx, "fosite.GenerateAccessToken")
+	defer func() { finish(span, err) }()
+	return s.CoreStrategy.GenerateAccessToken(ctx, requester)
+}
+
+func (s *CoreStrategy) ValidateAccessToken(ctx context.Context, requester fosite.Requester, token string) (err error) {
+	span, ctx := startSpan(ctx, "fosite.ValidateAccessToken")
+	defer func() { finish(span, err) }()
+	return s.CoreStrategy.ValidateAccessToken(ctx, requester, token)
+}
+
+func (s *CoreStrategy) GenerateRefreshToken(ctx context.Context, requester fosite.Requester) (token string, signature string, err error) {
+	span, ctx := startSpan(ctx, "fosite.GenerateRefreshToken")
+	defer func() { finish(span, err) }()
+	return s.CoreStrategy.GenerateRefreshToken(ctx, requester)
+}
+
+func (s *CoreStrategy) ValidateRefreshToken(ctx context.Context, requester fosite.Requester, token string) (err error) {
+	span, ctx := startSpan(ctx, "fosite.ValidateRefreshToken")
+	defer func() { finish(span, err) }()
+	return s.CoreStrategy.ValidateRefreshToken(ctx, requester, token)
+}
+
+func (s *CoreStrategy) GenerateAuthorizeCode(ctx context.Context, requester fosite.Requester) (token string, signature string, err error) {
+	span, ctx := startSpan(ctx, "fosite.GenerateAuthorizeCode")
+	defer func() { finish(span, err) }()
+	return s.CoreStrategy.GenerateAuthorizeCode(ctx, requester)
+}
+
+func (s *CoreStrategy) ValidateAuthorizeCode(ctx context.Context, requester fosite.Requester, token string) (err error) {
+	span, ctx := startSpan(ctx, "fosite.ValidateAuthorizeCode")
+	defer func() { finish(span, err) }()
+	return s.CoreStrategy.ValidateAuthorizeCode(ctx, requester, token)
+}
+
+// OpenIDConnectTokenStrategy adds a span to every generation of an ID token.
+type OpenIDConnectTokenStrategy struct {
+	openid.OpenIDConnectTokenStrategy
+}
+
+func (s *OpenIDConnectTokenStrategy) GenerateIDToken(ctx context.Context, requester fosite.Requester) (token string, err error) {
+	span, ctx := startSpan(ctx, "fosite.GenerateIDToken")
+	defer func() { finish(span, err) }()
+	return s.OpenIDConnectTokenStrategy.GenerateIDToken(ctx, requester)
+}
@@ -0,0 +1,170 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/ory/fosite"
+	"github.com/ory/hydra/client"
+	"github.com/ory/hydra/jwk"
+	"github.com/square/go-jose"
+)
+
+// KeyManager adds a span to every call of the wrapped manager. jwk.Manager does not accept a context, so these spans
+// start new traces instead of being added to the trace of the request that caused them.
+type KeyManager struct {
+	jwk.Manager
+}
+
+func (m *KeyManager) AddKey(set string, key *jose.JSONWebKey) (err error) {
+	span := opentracing.StartSpan("jwk.AddKey")
+	defer func() { finish(span, err) }()
+	return m.Manager.AddKey(set, key)
+}
+
+func (m *KeyManager) AddKeySet(set string, keys *jose.JSONWebKeySet) (err error) {
+	span := opentracing.StartSpan("jwk.AddKeySet")
+	defer func() { finish(span, err) }()
+	return m.Manager.AddKeySet(set, keys)
+}
+
+func (m *KeyManager) GetKey(set, kid string) (keys *jose.JSONWebKeySet, err error) {
+	span := opentracing.StartSpan("jwk.GetKey")
+	defer func() { finish(span, err) }()
+	return m.Manager.GetKey(set, kid)
+}
+
+func (m *KeyManager) GetKeySet(set string) (keys *jose.JSONWebKeySet, err error) {
+	span := opentracing.StartSpan("jwk.GetKeySet")
+	defer func() { finish(span, err) }()
+	return m.Manager.GetKeySet(set)
+}
+
+func (m *KeyManager) DeleteKey(set, kid string) (err error) {
+	span := opentracing.StartSpan("jwk.DeleteKey")
+	defer func() { finish(span, err) }()
+	return m.Manager.DeleteKey(set, kid)
+}
+
+func (m *KeyManager) DeleteKeySet(set string) (err error) {
+	span := opentracing.StartSpan("jwk.DeleteKeySet")
+	defer func() { finish(span, err) }()
+	return m.Manager.DeleteKeySet(set)
+}
+
+func (m *KeyManager) ListKeySets(limit, offset int) (summaries []jwk.KeySetSummary, err error) {
+	span := opentracing.StartSpan("jwk.ListKeySets")
+	defer func() { finish(span, err) }()
+	return m.Manager.ListKeySets(limit, offset)
+}
+
+func (m *KeyManager) SetKeyLifetime(set, kid string, lifetime jwk.KeyLifetime) (err error) {
+	span := opentracing.StartSpan("jwk.SetKeyLifetime")
+	defer func() { finish(span, err) }()
+	return m.Manager.SetKeyLifetime(set, kid, lifetime)
+}
+
+func (m *KeyManager) GetKeyLifetimes(set string) (lifetimes map[string]jwk.KeyLifetime, err error) {
+	span := opentracing.StartSpan("jwk.GetKeyLifetimes")
+	defer func() { finish(span, err) }()
+	return m.Manager.GetKeyLifetimes(set)
+}
+
+func (m *KeyManager) DeleteExpiredKeys(now time.Time) (deleted int, err error) {
+	span := opentracing.StartSpan("jwk.DeleteExpiredKeys")
+	defer func() { finish(span, err) }()
+	return m.Manager.DeleteExpiredKeys(now)
+}
+
+func (m *KeyManager) RotateKeySet(set string, since time.Time, generate func() (*jose.JSONWebKeySet, error)) (keys *jose.JSONWebKeySet, rotated bool, err error) {
+	span := opentracing.StartSpan("jwk.RotateKeySet")
+	defer func() { finish(span, err) }()
+	return m.Manager.RotateKeySet(set, since, generate)
+}
+
+func (m *KeyManager) EnsureKeySet(set string, find func(keys *jose.JSONWebKeySet) bool, generate func() (*jose.JSONWebKeySet, error)) (keys *jose.JSONWebKeySet, generated bool, err error) {
+	span := opentracing.StartSpan("jwk.EnsureKeySet")
+	defer func() { finish(span, err) }()
+	return m.Manager.EnsureKeySet(set, find, generate)
+}
+
+func (m *KeyManager) PatchKey(set, kid string, patch func(key *jose.JSONWebKey) (*jose.JSONWebKey, error)) (key *jose.JSONWebKey, err error) {
+	span := opentracing.StartSpan("jwk.PatchKey")
+	defer func() { finish(span, err) }()
+	return m.Manager.PatchKey(set, kid, patch)
+}
+
+// ClientManager adds a span to every call of the wrapped manager. Only GetClient accepts a context, so the spans of
+// the other methods start new traces instead of being added to the trace of the request that caused them.
+type ClientManager struct {
+	client.Manager
+}
+
+func (m *ClientManager) GetClient(ctx context.Context, id string) (c fosite.Client, err error) {
+	span, ctx := startSpan(ctx, "client.GetClient")
+	defer func() { finish(span, err) }()
+	return m.Manager.GetClient(ctx, id)
+}
+
+func (m *ClientManager) Authenticate(id string, secret []byte) (c *client.Client, err error) {
+	span := opentracing.StartSpan("client.Authenticate")
+	defer func() { finish(span, err) }()
+	return m.Manager.Authenticate(id, secret)
+}
+
+func (m *ClientManager) CreateClient(c *client.Client) (err error) {
+	span := opentracing.StartSpan("client.CreateClient")
+	defer func() { finish(span, err) }()
+	return m.Manager.CreateClient(c)
+}
+
+func (m *ClientManager) UpdateClient(c *client.Client) (err error) {
+	span := opentracing.StartSpan("client.UpdateClient")
+	defer func() { finish(span, err) }()
+	return m.Manager.UpdateClient(c)
+}
+
+func (m *ClientManager) DeleteClient(id string) (err error) {
+	span := opentracing.StartSpan("client.DeleteClient")
+	defer func() { finish(span, err) }()
+	return m.Manager.DeleteClient(id)
+}
+
+func (m *ClientManager) GetClients(limit, offset int) (clients map[string]client.Client, err error) {
+	span := opentracing.StartSpan("client.GetClients")
+	defer func() { finish(span, err) }()
+	return m.Manager.GetClients(limit, offset)
+}
+
+func (m *ClientManager) GetConcreteClient(id string) (c *client.Client, err error) {
+	span := opentracing.StartSpan("client.GetConcreteClient")
+	defer func() { finish(span, err) }()
+	return m.Manager.GetConcreteClient(id)
+}
+
+func (m *ClientManager) ApproveClient(id string) (err error) {
+	span := opentracing.StartSpan("client.ApproveClient")
+	defer func() { finish(span, err) }()
+	return m.Manager.ApproveClient(id)
+}
+
+func (m *ClientManager) PatchClient(id string, patch func(c *client.Client) (*client.Client, error)) (c *client.Client, err error) {
+	span := opentracing.StartSpan("client.PatchClient")
+	defer func() { finish(span, err) }()
+	return m.Manager.PatchClient(id, patch)
+}
@@ -0,0 +1,46 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/ory/hydra/jwk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyManager(t *testing.T) {
+	tracer := mocktracer.New()
+	opentracing.SetGlobalTracer(tracer)
+	defer opentracing.SetGlobalTracer(opentracing.NoopTracer{})
+
+	m := &KeyManager{Manager: &jwk.MemoryManager{}}
+	keys, err := (&jwk.RS256Generator{}).Generate("foo")
+	require.NoError(t, err)
+	require.NoError(t, m.AddKeySet("foo", keys))
+
+	_, err = m.GetKeySet("bar")
+	require.Error(t, err)
+
+	spans := tracer.FinishedSpans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "jwk.AddKeySet", spans[0].OperationName)
+	assert.Nil(t, spans[0].Tag("error"))
+	assert.Equal(t, "jwk.GetKeySet", spans[1].OperationName)
+	assert.Equal(t, true, spans[1].Tag("error"))
+}
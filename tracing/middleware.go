@@ -0,0 +1,58 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"net/http"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/ory/hydra/metrics"
+	"github.com/urfave/negroni"
+)
+
+// Middleware starts a span for every HTTP request and stores it in the request's context, so that handlers, fosite
+// and the managers they call can add child spans. If the request carries trace headers, the span continues the
+// caller's trace. Spans are named after the method and endpoint, for example "POST /oauth2/token", so that ids in the
+// path do not end up in the operation name.
+type Middleware struct {
+	Tracer opentracing.Tracer
+}
+
+func (m *Middleware) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	var opts []opentracing.StartSpanOption
+	if parent, err := m.Tracer.Extract(opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(r.Header)); err == nil {
+		opts = append(opts, ext.RPCServerOption(parent))
+	} else {
+		opts = append(opts, ext.SpanKindRPCServer)
+	}
+
+	span := m.Tracer.StartSpan(r.Method+" "+metrics.EndpointPath(r.URL.Path), opts...)
+	defer span.Finish()
+
+	ext.HTTPMethod.Set(span, r.Method)
+	ext.HTTPUrl.Set(span, r.URL.Path)
+
+	next(rw, r.WithContext(opentracing.ContextWithSpan(r.Context(), span)))
+
+	status := http.StatusOK
+	if res, ok := rw.(negroni.ResponseWriter); ok && res.Status() != 0 {
+		status = res.Status()
+	}
+	ext.HTTPStatusCode.Set(span, uint16(status))
+	if status >= http.StatusInternalServerError {
+		ext.Error.Set(span, true)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/negroni"
+)
+
+func TestMiddleware(t *testing.T) {
+	tracer := mocktracer.New()
+	n := negroni.New()
+	n.Use(&Middleware{Tracer: tracer})
+	n.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		span, _ := startSpan(r.Context(), "child")
+		span.Finish()
+		rw.WriteHeader(http.StatusNotFound)
+	})
+
+	parent := tracer.StartSpan("caller")
+	r := httptest.NewRequest("GET", "/keys/foo/bar", nil)
+	require.NoError(t, tracer.Inject(parent.Context(), opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(r.Header)))
+
+	opentracing.SetGlobalTracer(tracer)
+	defer opentracing.SetGlobalTracer(opentracing.NoopTracer{})
+	n.ServeHTTP(httptest.NewRecorder(), r)
+
+	spans := tracer.FinishedSpans()
+	require.Len(t, spans, 2)
+	child, server := spans[0], spans[1]
+
+	assert.Equal(t, "GET /keys/*", server.OperationName)
+	assert.Equal(t, parent.Context().(mocktracer.MockSpanContext).TraceID, server.SpanContext.TraceID)
+	assert.Equal(t, parent.Context().(mocktracer.MockSpanContext).SpanID, server.ParentID)
+	assert.EqualValues(t, http.StatusNotFound, server.Tag("http.status_code"))
+	assert.Equal(t, "/keys/foo/bar", server.Tag("http.url"))
+
+	assert.Equal(t, "child", child.OperationName)
+	assert.Equal(t, server.SpanContext.SpanID, child.ParentID)
+}
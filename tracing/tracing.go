@@ -0,0 +1,87 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tracing instruments HTTP handlers, fosite strategies and storage, and managers with OpenTracing spans.
+package tracing
+
+import (
+	"context"
+	"io"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/log"
+	"github.com/pkg/errors"
+	"github.com/uber/jaeger-client-go"
+	jaegercfg "github.com/uber/jaeger-client-go/config"
+)
+
+// ProviderJaeger reports spans to a Jaeger agent.
+const ProviderJaeger = "jaeger"
+
+// Config configures the tracer returned by New.
+type Config struct {
+	// Provider is the tracing backend. Only ProviderJaeger is supported.
+	Provider string
+
+	// ServiceName is the name spans are reported under.
+	ServiceName string
+
+	// JaegerAgentAddress is the host and port of the Jaeger agent spans are sent to via UDP.
+	JaegerAgentAddress string
+
+	// SampleRate is the share of traces which are sampled, ranging from 0 to 1. Traces that were sampled by the
+	// caller, as signalled by the propagated trace headers, are always sampled.
+	SampleRate float64
+}
+
+// New creates a tracer for c. The returned io.Closer flushes buffered spans and must be closed on shutdown.
+func New(c *Config) (opentracing.Tracer, io.Closer, error) {
+	switch c.Provider {
+	case ProviderJaeger:
+		cfg := jaegercfg.Configuration{
+			Sampler: &jaegercfg.SamplerConfig{
+				Type:  jaeger.SamplerTypeProbabilistic,
+				Param: c.SampleRate,
+			},
+			Reporter: &jaegercfg.ReporterConfig{
+				LocalAgentHostPort: c.JaegerAgentAddress,
+			},
+		}
+
+		tracer, closer, err := cfg.New(c.ServiceName)
+		if err != nil {
+			return nil, nil, errors.WithStack(err)
+		}
+		return tracer, closer, nil
+	}
+	return nil, nil, errors.Errorf("Unknown tracing provider %s", c.Provider)
+}
+
+// startSpan starts a span as child of the span in ctx, if any, using the global tracer.
+func startSpan(ctx context.Context, operation string) (opentracing.Span, context.Context) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return opentracing.StartSpanFromContext(ctx, operation)
+}
+
+// finish marks span as failed if err is not nil and finishes it.
+func finish(span opentracing.Span, err error) {
+	if err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.Error(err))
+	}
+	span.Finish()
+}
@@ -0,0 +1,89 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package warden
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/ory/hydra/pkg"
+	"github.com/pkg/errors"
+)
+
+// ActionGroups maps the name of an action group to its actions, for example "write" to create, update and delete.
+//
+// A policy allowing or denying a group applies to each of its actions, and an access request for a group is only
+// allowed if each of its actions is allowed.
+type ActionGroups map[string][]string
+
+// ParseActionGroups parses a comma separated list of groups with their actions separated by "|", for example
+// "write=create|update|delete,read=get|list". Groups can not contain other groups.
+func ParseActionGroups(s string) (ActionGroups, error) {
+	groups := ActionGroups{}
+	for _, definition := range pkg.SplitNonEmpty(s, ",") {
+		parts := strings.SplitN(definition, "=", 2)
+		name := strings.TrimSpace(parts[0])
+		if len(parts) != 2 || name == "" {
+			return nil, errors.Errorf("Action group %s must have the format <name>=<action>|<action>", definition)
+		} else if _, ok := groups[name]; ok {
+			return nil, errors.Errorf("Action group %s is defined twice", name)
+		}
+
+		var actions []string
+		for _, action := range strings.Split(parts[1], "|") {
+			if action = strings.TrimSpace(action); action != "" {
+				actions = append(actions, action)
+			}
+		}
+		if len(actions) == 0 {
+			return nil, errors.Errorf("Action group %s has no actions", name)
+		}
+		groups[name] = actions
+	}
+
+	for name, actions := range groups {
+		for _, action := range actions {
+			if _, ok := groups[action]; ok {
+				return nil, errors.Errorf("Action group %s can not contain the action group %s", name, action)
+			}
+		}
+	}
+
+	return groups, nil
+}
+
+// Expand returns the actions of the group named action, or action itself if it is not a group.
+func (g ActionGroups) Expand(action string) []string {
+	if actions, ok := g[action]; ok {
+		return actions
+	}
+	return []string{action}
+}
+
+// Aliases returns action followed by the names of the groups containing it, in alphabetical order.
+func (g ActionGroups) Aliases(action string) []string {
+	var names []string
+	for name, actions := range g {
+		for _, member := range actions {
+			if member == action {
+				names = append(names, name)
+				break
+			}
+		}
+	}
+
+	sort.Strings(names)
+	return append([]string{action}, names...)
+}
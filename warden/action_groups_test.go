@@ -0,0 +1,141 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package warden_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/ory/hydra/firewall"
+	"github.com/ory/hydra/pkg"
+	"github.com/ory/hydra/warden"
+	"github.com/ory/hydra/warden/group"
+	"github.com/ory/ladon"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseActionGroups(t *testing.T) {
+	groups, err := warden.ParseActionGroups("write=create|update|delete, read = get | list")
+	require.NoError(t, err)
+	assert.Equal(t, warden.ActionGroups{
+		"write": {"create", "update", "delete"},
+		"read":  {"get", "list"},
+	}, groups)
+
+	groups, err = warden.ParseActionGroups("")
+	require.NoError(t, err)
+	assert.Empty(t, groups)
+
+	for _, invalid := range []string{
+		"write",
+		"=create",
+		"write=",
+		"write=|",
+		"write=create,write=update",
+		"write=create|update,all=write|get",
+	} {
+		_, err := warden.ParseActionGroups(invalid)
+		assert.Error(t, err, "%s", invalid)
+	}
+}
+
+func TestActionGroupsExpandAndAliases(t *testing.T) {
+	groups := warden.ActionGroups{
+		"write":  {"create", "update", "delete"},
+		"modify": {"update"},
+	}
+
+	assert.Equal(t, []string{"create", "update", "delete"}, groups.Expand("write"))
+	assert.Equal(t, []string{"get"}, groups.Expand("get"))
+	assert.Equal(t, []string{"update", "modify", "write"}, groups.Aliases("update"))
+	assert.Equal(t, []string{"get"}, groups.Aliases("get"))
+
+	var none warden.ActionGroups
+	assert.Equal(t, []string{"write"}, none.Expand("write"))
+	assert.Equal(t, []string{"write"}, none.Aliases("write"))
+}
+
+func TestLocalWardenActionGroups(t *testing.T) {
+	w := &warden.LocalWarden{
+		Warden: pkg.LadonWarden(map[string]ladon.Policy{
+			"1": &ladon.DefaultPolicy{
+				ID:        "1",
+				Subjects:  []string{"alice"},
+				Resources: []string{"documents", "archive"},
+				Actions:   []string{"write"},
+				Effect:    ladon.AllowAccess,
+			},
+			"2": &ladon.DefaultPolicy{
+				ID:        "2",
+				Subjects:  []string{"bob"},
+				Resources: []string{"documents", "archive"},
+				Actions:   []string{"<.*>"},
+				Effect:    ladon.AllowAccess,
+			},
+			"3": &ladon.DefaultPolicy{
+				ID:        "3",
+				Subjects:  []string{"bob"},
+				Resources: []string{"archive"},
+				Actions:   []string{"write"},
+				Effect:    ladon.DenyAccess,
+			},
+			"4": &ladon.DefaultPolicy{
+				ID:        "4",
+				Subjects:  []string{"peter"},
+				Resources: []string{"documents"},
+				Actions:   []string{"create", "update"},
+				Effect:    ladon.AllowAccess,
+			},
+		}),
+		Groups:       &group.MemoryManager{Groups: map[string]group.Group{}},
+		L:            logrus.New(),
+		ActionGroups: warden.ActionGroups{"write": {"create", "update", "delete"}},
+	}
+
+	for k, c := range []struct {
+		subject  string
+		resource string
+		action   string
+		allowed  bool
+	}{
+		{subject: "alice", resource: "documents", action: "create", allowed: true},
+		{subject: "alice", resource: "documents", action: "delete", allowed: true},
+		{subject: "alice", resource: "documents", action: "write", allowed: true},
+		{subject: "alice", resource: "documents", action: "get", allowed: false},
+		{subject: "bob", resource: "documents", action: "delete", allowed: true},
+		{subject: "bob", resource: "archive", action: "get", allowed: true},
+		{subject: "bob", resource: "archive", action: "delete", allowed: false},
+		{subject: "bob", resource: "archive", action: "write", allowed: false},
+		{subject: "peter", resource: "documents", action: "create", allowed: true},
+		{subject: "peter", resource: "documents", action: "write", allowed: false},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			err := w.IsAllowed(context.Background(), &firewall.AccessRequest{
+				Subject:  c.subject,
+				Resource: c.resource,
+				Action:   c.action,
+				Context:  ladon.Context{},
+			})
+			if c.allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package warden
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// APIKeys holds the pre-shared keys which authenticate callers of the warden endpoints without an OAuth 2.0 access
+// token. Only the SHA-256 hashes of the keys are kept in memory. Keys can be replaced at runtime using Set.
+type APIKeys struct {
+	sync.RWMutex
+	hashes map[[sha256.Size]byte]bool
+}
+
+func NewAPIKeys(keys []string) *APIKeys {
+	k := new(APIKeys)
+	k.Set(keys)
+	return k
+}
+
+// Set replaces all keys. Empty keys are ignored.
+func (k *APIKeys) Set(keys []string) {
+	hashes := map[[sha256.Size]byte]bool{}
+	for _, key := range keys {
+		if key = strings.TrimSpace(key); key != "" {
+			hashes[sha256.Sum256([]byte(key))] = true
+		}
+	}
+
+	k.Lock()
+	defer k.Unlock()
+	k.hashes = hashes
+}
+
+// Valid returns true if key is one of the keys.
+func (k *APIKeys) Valid(key string) bool {
+	if key == "" {
+		return false
+	}
+
+	k.RLock()
+	defer k.RUnlock()
+	return k.hashes[sha256.Sum256([]byte(key))]
+}
+
+// Len returns the number of keys.
+func (k *APIKeys) Len() int {
+	k.RLock()
+	defer k.RUnlock()
+	return len(k.hashes)
+}
+
+// ReadAPIKeysFile reads one key per line from the file at path. Empty lines and lines starting with # are ignored.
+func ReadAPIKeysFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+
+	var keys []string
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		if line := strings.TrimSpace(s.Text()); line != "" && !strings.HasPrefix(line, "#") {
+			keys = append(keys, line)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return keys, nil
+}
@@ -0,0 +1,104 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package warden_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/herodot"
+	hydra "github.com/ory/hydra/sdk/go/hydra/swagger"
+	"github.com/ory/hydra/warden"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	coauth2 "golang.org/x/oauth2"
+)
+
+func TestAPIKeys(t *testing.T) {
+	k := warden.NewAPIKeys([]string{"foo", " bar ", ""})
+	assert.Equal(t, 2, k.Len())
+	assert.True(t, k.Valid("foo"))
+	assert.True(t, k.Valid("bar"))
+	assert.False(t, k.Valid("baz"))
+	assert.False(t, k.Valid(""))
+
+	k.Set([]string{"baz"})
+	assert.False(t, k.Valid("foo"))
+	assert.True(t, k.Valid("baz"))
+}
+
+func TestReadAPIKeysFile(t *testing.T) {
+	f, err := ioutil.TempFile("", "hydra-warden-api-keys")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+
+	_, err = f.WriteString("# rotated on 2017-10-01\nfoo\n\n  bar  \n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	keys, err := warden.ReadAPIKeysFile(f.Name())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"foo", "bar"}, keys)
+
+	_, err = warden.ReadAPIKeysFile(f.Name() + "-does-not-exist")
+	assert.Error(t, err)
+}
+
+func TestWardenHandlerAPIKeys(t *testing.T) {
+	router := httprouter.New()
+	handler := &warden.WardenHandler{
+		H:       herodot.NewJSONWriter(nil),
+		Warden:  wardens["local"],
+		APIKeys: warden.NewAPIKeys([]string{"pre-shared-key"}),
+	}
+	handler.SetRoutes(router)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	for k, tc := range []struct {
+		key     string
+		granted bool
+	}{
+		{key: "pre-shared-key", granted: true},
+		{key: "not-a-key", granted: false},
+	} {
+		client := hydra.NewWardenApiWithBasePath(server.URL)
+		client.Configuration.Transport = (&coauth2.Config{}).Client(coauth2.NoContext, &coauth2.Token{
+			AccessToken: tc.key,
+			Expiry:      time.Now().UTC().Add(time.Hour),
+			TokenType:   "bearer",
+		}).Transport
+
+		c := accessRequestTestCases[0]
+		result, response, err := client.DoesWardenAllowAccessRequest(hydra.WardenAccessRequest{
+			Action:   c.req.Action,
+			Resource: c.req.Resource,
+			Subject:  c.req.Subject,
+			Context:  c.req.Context,
+		})
+		require.NoError(t, err, "%d", k)
+		if tc.granted {
+			require.Equal(t, http.StatusOK, response.StatusCode, "%d", k)
+			assert.Equal(t, !c.expectErr, result.Allowed, "%d", k)
+		} else {
+			assert.NotEqual(t, http.StatusOK, response.StatusCode, "%d", k)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package warden
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/ory/hydra/firewall"
+	"github.com/ory/hydra/warden/decision"
+	"github.com/ory/ladon"
+	"github.com/pkg/errors"
+)
+
+// AuthorizerResponse is the response an external authorizer returns for an access request.
+type AuthorizerResponse struct {
+	// Allowed is true if the access request is granted.
+	Allowed bool `json:"allowed"`
+}
+
+// HTTPAuthorizer replaces ladon's policy evaluation with an external authorizer, for example ORY Keto or Open Policy
+// Agent behind a small adapter. It implements ladon.Warden and can therefore be used as the Warden of LocalWarden,
+// which still introspects tokens and resolves groups.
+//
+// Every access request is sent as a wardenAccessRequest JSON object in a POST request to URL:
+//
+//  {"subject": "peter", "resource": "rn:hydra:clients", "action": "get", "context": {}}
+//
+// The authorizer must respond with a 2xx status code and an AuthorizerResponse, for example {"allowed": true}. If
+// the subject is a member of groups, one request is sent per group with the group id as subject, access is granted
+// if any of them is allowed. Any other response denies the request.
+type HTTPAuthorizer struct {
+	URL    string
+	Client *http.Client
+}
+
+func (a *HTTPAuthorizer) IsAllowed(r *ladon.Request) error {
+	// The id used to record decisions is internal and not sent to the authorizer.
+	context := ladon.Context{}
+	for k, v := range r.Context {
+		if k != decision.RequestIDKey {
+			context[k] = v
+		}
+	}
+
+	out, err := json.Marshal(&firewall.AccessRequest{
+		Subject:  r.Subject,
+		Resource: r.Resource,
+		Action:   r.Action,
+		Context:  context,
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	c := a.Client
+	if c == nil {
+		c = http.DefaultClient
+	}
+
+	res, err := c.Post(a.URL, "application/json", bytes.NewReader(out))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return errors.Errorf("Expected 2xx status code from authorizer %s but got %d", a.URL, res.StatusCode)
+	}
+
+	var decision AuthorizerResponse
+	if err := json.NewDecoder(res.Body).Decode(&decision); err != nil {
+		return errors.Wrapf(err, "Could not decode response from authorizer %s", a.URL)
+	}
+
+	if !decision.Allowed {
+		return errors.WithStack(ladon.ErrRequestDenied)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package warden
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ory/hydra/firewall"
+	"github.com/ory/ladon"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPAuthorizer(t *testing.T) {
+	var received firewall.AccessRequest
+	var status = http.StatusOK
+	var body = `{"allowed": true}`
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	defer ts.Close()
+
+	a := &HTTPAuthorizer{URL: ts.URL}
+	r := &ladon.Request{Subject: "peter", Resource: "rn:hydra:clients", Action: "get", Context: ladon.Context{"foo": "bar"}}
+	require.NoError(t, a.IsAllowed(r))
+	assert.Equal(t, "peter", received.Subject)
+	assert.Equal(t, "rn:hydra:clients", received.Resource)
+	assert.Equal(t, "get", received.Action)
+	assert.Equal(t, "bar", received.Context["foo"])
+
+	body = `{"allowed": false}`
+	err := a.IsAllowed(r)
+	require.Error(t, err)
+	assert.Equal(t, ladon.ErrRequestDenied, errors.Cause(err))
+
+	body = `not json`
+	assert.Error(t, a.IsAllowed(r))
+
+	status, body = http.StatusInternalServerError, `{"allowed": true}`
+	assert.Error(t, a.IsAllowed(r))
+}
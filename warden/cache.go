@@ -0,0 +1,246 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package warden
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ory/hydra/firewall"
+	"github.com/ory/hydra/pkg"
+	"github.com/pkg/errors"
+)
+
+// DefaultDecisionCacheMaxEntries is the number of decisions a DecisionCache holds if MaxEntries is not set.
+const DefaultDecisionCacheMaxEntries = 10000
+
+// DecisionCache caches granted token access requests for a given time to live, so that repeated requests with the
+// same token do not require token introspection and policy evaluation. Entries are keyed by the hash of the token,
+// the resource, action, context and scopes of the request. Denied requests are never cached. When MaxEntries is
+// reached, the least recently used entry is evicted.
+//
+// Entries never outlive the token they were created for and are invalidated when the token is revoked through
+// CacheInvalidatingStorage. Changes to policies and groups become visible once the entry expires.
+type DecisionCache struct {
+	TTL        time.Duration
+	MaxEntries int
+
+	sync.Mutex
+	entries  map[string]*list.Element
+	requests map[string]map[string]bool
+	lru      *list.List
+}
+
+type cachedDecision struct {
+	key       string
+	requestID string
+	context   firewall.Context
+	expiresAt time.Time
+}
+
+func NewDecisionCache(ttl time.Duration, maxEntries int) *DecisionCache {
+	return &DecisionCache{
+		TTL:        ttl,
+		MaxEntries: maxEntries,
+		entries:    map[string]*list.Element{},
+		requests:   map[string]map[string]bool{},
+		lru:        list.New(),
+	}
+}
+
+func decisionCacheKey(token string, a *firewall.TokenAccessRequest, scopes []string) (string, error) {
+	ctx, err := json.Marshal(a.Context)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	hash := sha256.Sum256([]byte(token))
+	return strings.Join([]string{
+		hex.EncodeToString(hash[:]),
+		a.Resource,
+		a.Action,
+		string(ctx),
+		strings.Join(scopes, " "),
+	}, "\x00"), nil
+}
+
+// Get returns the cached context of a granted request, if any.
+func (c *DecisionCache) Get(key string) (*firewall.Context, bool) {
+	c.Lock()
+	defer c.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	d := el.Value.(*cachedDecision)
+	if !time.Now().Before(d.expiresAt) {
+		c.remove(el)
+		return nil, false
+	}
+
+	c.lru.MoveToFront(el)
+	result := d.context
+	return &result, true
+}
+
+// Add caches the context of a granted request. requestID is the id of the token's request and is used to invalidate
+// the entry when the token is revoked.
+func (c *DecisionCache) Add(key, requestID string, ctx *firewall.Context) {
+	expiresAt := time.Now().Add(c.TTL)
+	if !ctx.ExpiresAt.IsZero() && ctx.ExpiresAt.Before(expiresAt) {
+		expiresAt = ctx.ExpiresAt
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	c.init()
+	if el, ok := c.entries[key]; ok {
+		c.remove(el)
+	}
+
+	c.entries[key] = c.lru.PushFront(&cachedDecision{key: key, requestID: requestID, context: *ctx, expiresAt: expiresAt})
+	if c.requests[requestID] == nil {
+		c.requests[requestID] = map[string]bool{}
+	}
+	c.requests[requestID][key] = true
+
+	max := c.MaxEntries
+	if max <= 0 {
+		max = DefaultDecisionCacheMaxEntries
+	}
+	for c.lru.Len() > max {
+		c.remove(c.lru.Back())
+	}
+}
+
+// InvalidateRequest removes all entries of tokens which were issued by the request with the given id.
+func (c *DecisionCache) InvalidateRequest(requestID string) {
+	c.Lock()
+	defer c.Unlock()
+
+	for key := range c.requests[requestID] {
+		if el, ok := c.entries[key]; ok {
+			c.remove(el)
+		}
+	}
+}
+
+// Purge removes all entries.
+func (c *DecisionCache) Purge() {
+	c.Lock()
+	defer c.Unlock()
+
+	c.entries = map[string]*list.Element{}
+	c.requests = map[string]map[string]bool{}
+	c.lru = list.New()
+}
+
+// Len returns the number of cached entries, including expired ones which were not evicted yet.
+func (c *DecisionCache) Len() int {
+	c.Lock()
+	defer c.Unlock()
+
+	c.init()
+	return c.lru.Len()
+}
+
+func (c *DecisionCache) init() {
+	if c.entries == nil {
+		c.entries = map[string]*list.Element{}
+	}
+	if c.requests == nil {
+		c.requests = map[string]map[string]bool{}
+	}
+	if c.lru == nil {
+		c.lru = list.New()
+	}
+}
+
+func (c *DecisionCache) remove(el *list.Element) {
+	d := c.lru.Remove(el).(*cachedDecision)
+	delete(c.entries, d.key)
+	if keys := c.requests[d.requestID]; keys != nil {
+		delete(keys, d.key)
+		if len(keys) == 0 {
+			delete(c.requests, d.requestID)
+		}
+	}
+}
+
+// TokenCache is a cache whose entries belong to the request the token they were created for was issued by, such as
+// DecisionCache and oauth2.IntrospectionCache.
+type TokenCache interface {
+	// InvalidateRequest removes all entries of tokens which were issued by the request with the given id.
+	InvalidateRequest(requestID string)
+
+	// Purge removes all entries.
+	Purge()
+}
+
+// CacheInvalidatingStorage invalidates entries of a TokenCache when tokens are revoked or deleted.
+type CacheInvalidatingStorage struct {
+	pkg.FositeStorer
+	Cache TokenCache
+}
+
+func (s *CacheInvalidatingStorage) RevokeAccessToken(ctx context.Context, requestID string) error {
+	defer s.Cache.InvalidateRequest(requestID)
+	return s.FositeStorer.RevokeAccessToken(ctx, requestID)
+}
+
+func (s *CacheInvalidatingStorage) RevokeClientTokens(ctx context.Context, clientID string) ([]string, error) {
+	requestIDs, err := s.FositeStorer.RevokeClientTokens(ctx, clientID)
+	for _, id := range requestIDs {
+		s.Cache.InvalidateRequest(id)
+	}
+	return requestIDs, err
+}
+
+func (s *CacheInvalidatingStorage) RevokeSubjectTokens(ctx context.Context, subject string) ([]string, error) {
+	requestIDs, err := s.FositeStorer.RevokeSubjectTokens(ctx, subject)
+	for _, id := range requestIDs {
+		s.Cache.InvalidateRequest(id)
+	}
+	return requestIDs, err
+}
+
+func (s *CacheInvalidatingStorage) RevokeSubjectClientTokens(ctx context.Context, subject, clientID string) ([]string, error) {
+	requestIDs, err := s.FositeStorer.RevokeSubjectClientTokens(ctx, subject, clientID)
+	for _, id := range requestIDs {
+		s.Cache.InvalidateRequest(id)
+	}
+	return requestIDs, err
+}
+
+func (s *CacheInvalidatingStorage) RevokeRefreshToken(ctx context.Context, requestID string) error {
+	defer s.Cache.InvalidateRequest(requestID)
+	return s.FositeStorer.RevokeRefreshToken(ctx, requestID)
+}
+
+// DeleteAccessTokenSession purges the cache, because the request an access token belongs to is not known from its
+// signature alone.
+func (s *CacheInvalidatingStorage) DeleteAccessTokenSession(ctx context.Context, signature string) error {
+	defer s.Cache.Purge()
+	return s.FositeStorer.DeleteAccessTokenSession(ctx, signature)
+}
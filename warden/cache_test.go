@@ -0,0 +1,114 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package warden
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ory/hydra/firewall"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecisionCacheKey(t *testing.T) {
+	a := &firewall.TokenAccessRequest{Resource: "foo", Action: "get", Context: map[string]interface{}{"a": "b"}}
+	k1, err := decisionCacheKey("token", a, []string{"core"})
+	require.NoError(t, err)
+	assert.NotContains(t, k1, "token")
+
+	for _, tc := range []struct {
+		token  string
+		a      *firewall.TokenAccessRequest
+		scopes []string
+	}{
+		{token: "other-token", a: a, scopes: []string{"core"}},
+		{token: "token", a: &firewall.TokenAccessRequest{Resource: "bar", Action: "get", Context: a.Context}, scopes: []string{"core"}},
+		{token: "token", a: &firewall.TokenAccessRequest{Resource: "foo", Action: "delete", Context: a.Context}, scopes: []string{"core"}},
+		{token: "token", a: &firewall.TokenAccessRequest{Resource: "foo", Action: "get"}, scopes: []string{"core"}},
+		{token: "token", a: a, scopes: []string{"core", "hydra"}},
+	} {
+		k2, err := decisionCacheKey(tc.token, tc.a, tc.scopes)
+		require.NoError(t, err)
+		assert.NotEqual(t, k1, k2)
+	}
+}
+
+func TestDecisionCache(t *testing.T) {
+	ctx := &firewall.Context{Subject: "peter", ExpiresAt: time.Now().Add(time.Hour)}
+
+	t.Run("case=get", func(t *testing.T) {
+		c := NewDecisionCache(time.Minute, 0)
+		_, ok := c.Get("foo")
+		assert.False(t, ok)
+
+		c.Add("foo", "request-1", ctx)
+		got, ok := c.Get("foo")
+		require.True(t, ok)
+		assert.Equal(t, "peter", got.Subject)
+	})
+
+	t.Run("case=expires with ttl", func(t *testing.T) {
+		c := NewDecisionCache(time.Millisecond*10, 0)
+		c.Add("foo", "request-1", ctx)
+		time.Sleep(time.Millisecond * 20)
+		_, ok := c.Get("foo")
+		assert.False(t, ok)
+		assert.Equal(t, 0, c.Len())
+	})
+
+	t.Run("case=expires with token", func(t *testing.T) {
+		c := NewDecisionCache(time.Hour, 0)
+		c.Add("foo", "request-1", &firewall.Context{Subject: "peter", ExpiresAt: time.Now().Add(-time.Second)})
+		_, ok := c.Get("foo")
+		assert.False(t, ok)
+	})
+
+	t.Run("case=evicts least recently used", func(t *testing.T) {
+		c := NewDecisionCache(time.Minute, 2)
+		c.Add("foo", "request-1", ctx)
+		c.Add("bar", "request-2", ctx)
+		_, ok := c.Get("foo")
+		require.True(t, ok)
+
+		c.Add("baz", "request-3", ctx)
+		assert.Equal(t, 2, c.Len())
+		_, ok = c.Get("bar")
+		assert.False(t, ok)
+		_, ok = c.Get("foo")
+		assert.True(t, ok)
+		_, ok = c.Get("baz")
+		assert.True(t, ok)
+	})
+
+	t.Run("case=invalidate request", func(t *testing.T) {
+		c := NewDecisionCache(time.Minute, 0)
+		c.Add("foo", "request-1", ctx)
+		c.Add("bar", "request-1", ctx)
+		c.Add("baz", "request-2", ctx)
+
+		c.InvalidateRequest("request-1")
+		assert.Equal(t, 1, c.Len())
+		_, ok := c.Get("baz")
+		assert.True(t, ok)
+	})
+
+	t.Run("case=purge", func(t *testing.T) {
+		c := NewDecisionCache(time.Minute, 0)
+		c.Add("foo", "request-1", ctx)
+		c.Purge()
+		assert.Equal(t, 0, c.Len())
+	})
+}
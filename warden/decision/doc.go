@@ -0,0 +1,42 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package decision
+
+// A list of recorded warden decisions
+// swagger:response listWardenDecisionsResponse
+type swaggerListDecisionsResponse struct {
+	// in: body
+	// type: array
+	Body []Decision
+}
+
+// swagger:parameters listWardenDecisions
+type swaggerListDecisionsParameters struct {
+	// Only return decisions made at or after this time (RFC3339).
+	// in: query
+	From string `json:"from"`
+
+	// Only return decisions made before this time (RFC3339).
+	// in: query
+	Until string `json:"until"`
+
+	// The maximum amount of decisions returned.
+	// in: query
+	Limit int `json:"limit"`
+
+	// The offset from where to start looking.
+	// in: query
+	Offset int `json:"offset"`
+}
@@ -0,0 +1,138 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package decision
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/herodot"
+	"github.com/ory/hydra/firewall"
+	"github.com/ory/pagination"
+	"github.com/pkg/errors"
+)
+
+const (
+	DecisionsHandlerPath = "/warden/decisions"
+)
+
+const (
+	DecisionsResource = "warden:decisions"
+	Scope             = "hydra.warden.decisions"
+)
+
+type Handler struct {
+	Manager Manager
+	H       herodot.Writer
+	W       firewall.Firewall
+
+	ResourcePrefix string
+}
+
+func (h *Handler) PrefixResource(resource string) string {
+	if h.ResourcePrefix == "" {
+		h.ResourcePrefix = "rn:hydra"
+	}
+
+	if h.ResourcePrefix[len(h.ResourcePrefix)-1] == ':' {
+		h.ResourcePrefix = h.ResourcePrefix[:len(h.ResourcePrefix)-1]
+	}
+
+	return h.ResourcePrefix + ":" + resource
+}
+
+func (h *Handler) SetRoutes(r *httprouter.Router) {
+	r.GET(DecisionsHandlerPath, h.ListDecisions)
+}
+
+// swagger:route GET /warden/decisions warden listWardenDecisions
+//
+// List recorded warden decisions
+//
+// Returns decisions recorded by the warden, newest first. Denied access requests are always recorded, granted
+// access requests are sampled. Use the from and until query parameters (RFC3339) to select a time range.
+//
+// The subject making the request needs to be assigned to a policy containing:
+//
+//  ```
+//  {
+//    "resources": ["rn:hydra:warden:decisions"],
+//    "actions": ["list"],
+//    "effect": "allow"
+//  }
+//  ```
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Security:
+//       oauth2: hydra.warden.decisions
+//
+//     Responses:
+//       200: listWardenDecisionsResponse
+//       400: genericError
+//       401: genericError
+//       403: genericError
+//       500: genericError
+func (h *Handler) ListDecisions(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var ctx = r.Context()
+
+	if _, err := h.W.TokenAllowed(ctx, h.W.TokenFromRequest(r), &firewall.TokenAccessRequest{
+		Resource: h.PrefixResource(DecisionsResource),
+		Action:   "list",
+	}, Scope); err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	from, err := parseTime(r.URL.Query().Get("from"), time.Time{})
+	if err != nil {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	until, err := parseTime(r.URL.Query().Get("until"), time.Now().UTC().Add(time.Second))
+	if err != nil {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	limit, offset := pagination.Parse(r, 100, 0, 500)
+	decisions, err := h.Manager.GetDecisions(from, until, limit, offset)
+	if err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	h.H.Write(w, r, decisions)
+}
+
+func parseTime(value string, fallback time.Time) (time.Time, error) {
+	if value == "" {
+		return fallback, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, errors.Errorf("Could not parse time %s, expected RFC3339 format", value)
+	}
+	return t, nil
+}
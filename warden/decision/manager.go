@@ -0,0 +1,59 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package decision
+
+import "time"
+
+// Decision is a recorded outcome of an access request evaluated by the warden.
+//
+// swagger:model wardenDecision
+type Decision struct {
+	// ID is the unique identifier of this decision.
+	ID string `json:"id"`
+
+	// Subject is the subject that requested access.
+	Subject string `json:"subject"`
+
+	// Resource is the resource that access was requested to.
+	Resource string `json:"resource"`
+
+	// Action is the action that was requested on the resource.
+	Action string `json:"action"`
+
+	// Allowed is true if access was granted.
+	Allowed bool `json:"allowed"`
+
+	// Policies are the IDs of the policies that led to this decision.
+	Policies []string `json:"policies"`
+
+	// Elevation is the ID of the elevation which allowed the request the policies denied, if any.
+	Elevation string `json:"elevation,omitempty"`
+
+	// CreatedAt is the time the decision was made.
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Manager interface {
+	AddDecision(d *Decision) error
+
+	// GetDecisions returns decisions made in [from, until), newest first.
+	GetDecisions(from, until time.Time, limit, offset int) ([]Decision, error)
+
+	// DeleteSubjectDecisions removes all decisions made for the subject and returns how many were removed.
+	DeleteSubjectDecisions(subject string) (int, error)
+
+	// DeleteDecisionsBefore removes all decisions made before notAfter and returns how many were removed.
+	DeleteDecisionsBefore(notAfter time.Time) (int, error)
+}
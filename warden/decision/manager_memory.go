@@ -0,0 +1,102 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package decision
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pborman/uuid"
+)
+
+type MemoryManager struct {
+	Decisions []Decision
+	sync.RWMutex
+}
+
+func NewMemoryManager() *MemoryManager {
+	return &MemoryManager{Decisions: []Decision{}}
+}
+
+func (m *MemoryManager) AddDecision(d *Decision) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if d.ID == "" {
+		d.ID = uuid.New()
+	}
+
+	m.Decisions = append(m.Decisions, *d)
+	return nil
+}
+
+func (m *MemoryManager) GetDecisions(from, until time.Time, limit, offset int) ([]Decision, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	result := []Decision{}
+	for _, d := range m.Decisions {
+		if !d.CreatedAt.Before(from) && d.CreatedAt.Before(until) {
+			result = append(result, d)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].CreatedAt.After(result[j].CreatedAt)
+	})
+
+	if offset >= len(result) {
+		return []Decision{}, nil
+	}
+
+	result = result[offset:]
+	if limit < len(result) {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+func (m *MemoryManager) DeleteSubjectDecisions(subject string) (int, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	kept := []Decision{}
+	for _, d := range m.Decisions {
+		if d.Subject != subject {
+			kept = append(kept, d)
+		}
+	}
+
+	n := len(m.Decisions) - len(kept)
+	m.Decisions = kept
+	return n, nil
+}
+
+func (m *MemoryManager) DeleteDecisionsBefore(notAfter time.Time) (int, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	kept := []Decision{}
+	for _, d := range m.Decisions {
+		if !d.CreatedAt.Before(notAfter) {
+			kept = append(kept, d)
+		}
+	}
+
+	n := len(m.Decisions) - len(kept)
+	m.Decisions = kept
+	return n, nil
+}
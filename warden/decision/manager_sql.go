@@ -0,0 +1,156 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package decision
+
+import (
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+	"github.com/rubenv/sql-migrate"
+)
+
+var migrations = &migrate.MemoryMigrationSource{
+	Migrations: []*migrate.Migration{
+		{
+			Id: "1",
+			Up: []string{`CREATE TABLE IF NOT EXISTS hydra_warden_decision (
+	id      	varchar(36) NOT NULL PRIMARY KEY,
+	subject		varchar(255) NOT NULL,
+	resource	text NOT NULL,
+	action		text NOT NULL,
+	allowed		bool NOT NULL,
+	policies	text NOT NULL,
+	created_at	timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP
+)`, `CREATE INDEX hydra_warden_decision_created_at_idx ON hydra_warden_decision (created_at)`},
+			Down: []string{
+				"DROP TABLE hydra_warden_decision",
+			},
+		},
+		{
+			Id: "2",
+			Up: []string{
+				`ALTER TABLE hydra_warden_decision ADD elevation varchar(36) NOT NULL DEFAULT ''`,
+			},
+			Down: []string{
+				`ALTER TABLE hydra_warden_decision DROP COLUMN elevation`,
+			},
+		},
+	},
+}
+
+type sqlData struct {
+	ID        string    `db:"id"`
+	Subject   string    `db:"subject"`
+	Resource  string    `db:"resource"`
+	Action    string    `db:"action"`
+	Allowed   bool      `db:"allowed"`
+	Policies  string    `db:"policies"`
+	Elevation string    `db:"elevation"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+type SQLManager struct {
+	DB *sqlx.DB
+}
+
+// Migrations returns the SQL migrations embedded in the binary.
+func (m *SQLManager) Migrations() *migrate.MemoryMigrationSource {
+	return migrations
+}
+
+func (m *SQLManager) CreateSchemas() (int, error) {
+	migrate.SetTable("hydra_warden_decision_migration")
+	n, err := migrate.Exec(m.DB.DB, m.DB.DriverName(), migrations, migrate.Up)
+	if err != nil {
+		return 0, errors.Wrapf(err, "Could not migrate sql schema, applied %d migrations", n)
+	}
+	return n, nil
+}
+
+func (m *SQLManager) AddDecision(d *Decision) error {
+	if d.ID == "" {
+		d.ID = uuid.New()
+	}
+
+	if _, err := m.DB.NamedExec(`INSERT INTO hydra_warden_decision (id, subject, resource, action, allowed, policies, elevation, created_at) VALUES (:id, :subject, :resource, :action, :allowed, :policies, :elevation, :created_at)`, &sqlData{
+		ID:        d.ID,
+		Subject:   d.Subject,
+		Resource:  d.Resource,
+		Action:    d.Action,
+		Allowed:   d.Allowed,
+		Policies:  strings.Join(d.Policies, "|"),
+		Elevation: d.Elevation,
+		CreatedAt: d.CreatedAt.UTC(),
+	}); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func (m *SQLManager) GetDecisions(from, until time.Time, limit, offset int) ([]Decision, error) {
+	var ds []sqlData
+	if err := m.DB.Select(&ds, m.DB.Rebind("SELECT * FROM hydra_warden_decision WHERE created_at >= ? AND created_at < ? ORDER BY created_at DESC LIMIT ? OFFSET ?"), from.UTC(), until.UTC(), limit, offset); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	decisions := make([]Decision, len(ds))
+	for k, d := range ds {
+		policies := []string{}
+		if d.Policies != "" {
+			policies = strings.Split(d.Policies, "|")
+		}
+
+		decisions[k] = Decision{
+			ID:        d.ID,
+			Subject:   d.Subject,
+			Resource:  d.Resource,
+			Action:    d.Action,
+			Allowed:   d.Allowed,
+			Policies:  policies,
+			Elevation: d.Elevation,
+			CreatedAt: d.CreatedAt,
+		}
+	}
+	return decisions, nil
+}
+
+func (m *SQLManager) DeleteSubjectDecisions(subject string) (int, error) {
+	res, err := m.DB.Exec(m.DB.Rebind("DELETE FROM hydra_warden_decision WHERE subject=?"), subject)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	return int(n), nil
+}
+
+func (m *SQLManager) DeleteDecisionsBefore(notAfter time.Time) (int, error) {
+	res, err := m.DB.Exec(m.DB.Rebind("DELETE FROM hydra_warden_decision WHERE created_at < ?"), notAfter.UTC())
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	return int(n), nil
+}
@@ -0,0 +1,99 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package decision_test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/ory/hydra/warden/decision"
+	"github.com/ory/ladon"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryManager(t *testing.T) {
+	m := NewMemoryManager()
+	now := time.Now().UTC().Round(time.Second)
+
+	require.NoError(t, m.AddDecision(&Decision{Subject: "alice", CreatedAt: now.Add(-time.Hour)}))
+	require.NoError(t, m.AddDecision(&Decision{Subject: "bob", CreatedAt: now.Add(-time.Minute)}))
+	require.NoError(t, m.AddDecision(&Decision{Subject: "eve", CreatedAt: now.Add(-time.Hour * 48)}))
+
+	ds, err := m.GetDecisions(now.Add(-time.Hour*24), now, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, ds, 2)
+	assert.Equal(t, "bob", ds[0].Subject)
+	assert.Equal(t, "alice", ds[1].Subject)
+	assert.NotEmpty(t, ds[0].ID)
+
+	ds, err = m.GetDecisions(now.Add(-time.Hour*24), now, 1, 1)
+	require.NoError(t, err)
+	require.Len(t, ds, 1)
+	assert.Equal(t, "alice", ds[0].Subject)
+
+	ds, err = m.GetDecisions(now.Add(-time.Hour*24), now, 10, 5)
+	require.NoError(t, err)
+	assert.Empty(t, ds)
+
+	n, err := m.DeleteSubjectDecisions("alice")
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	ds, err = m.GetDecisions(now.Add(-time.Hour*24), now, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, ds, 1)
+	assert.Equal(t, "bob", ds[0].Subject)
+
+	n, err = m.DeleteDecisionsBefore(now.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	ds, err = m.GetDecisions(now.Add(-time.Hour*72), now, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, ds, 1)
+	assert.Equal(t, "bob", ds[0].Subject)
+}
+
+func TestRecorder(t *testing.T) {
+	m := NewMemoryManager()
+	r := &Recorder{Manager: m, AllowSampleRate: 0, L: logrus.New()}
+
+	req := &ladon.Request{Subject: "alice", Resource: "foo", Action: "bar", Context: WithRequestID(nil, "request-1")}
+	r.LogRejectedAccessRequest(req, ladon.Policies{}, ladon.Policies{&ladon.DefaultPolicy{ID: "deny-all"}})
+	r.LogRejectedAccessRequest(&ladon.Request{Subject: "admins", Resource: "foo", Action: "bar", Context: req.Context}, ladon.Policies{}, ladon.Policies{&ladon.DefaultPolicy{ID: "deny-all"}})
+	r.Record("alice", "foo", "bar", false, "request-1")
+
+	req = &ladon.Request{Subject: "alice", Resource: "foo", Action: "baz", Context: WithRequestID(nil, "request-2")}
+	r.LogGrantedAccessRequest(req, ladon.Policies{}, ladon.Policies{&ladon.DefaultPolicy{ID: "allow-baz"}})
+	r.Record("alice", "foo", "baz", true, "request-2")
+
+	require.Len(t, m.Decisions, 1)
+	assert.False(t, m.Decisions[0].Allowed)
+	assert.Equal(t, []string{"deny-all"}, m.Decisions[0].Policies)
+
+	r.AllowSampleRate = 1
+	req = &ladon.Request{Subject: "alice", Resource: "foo", Action: "baz", Context: WithRequestID(ladon.Context{"ip": "127.0.0.1"}, "request-3")}
+	r.LogGrantedAccessRequest(req, ladon.Policies{}, ladon.Policies{&ladon.DefaultPolicy{ID: "allow-baz"}})
+	r.LogGrantedAccessRequest(&ladon.Request{Subject: "alice", Context: WithRequestID(nil, "request-4")}, ladon.Policies{}, ladon.Policies{&ladon.DefaultPolicy{ID: "allow-other"}})
+	r.Forget("request-4")
+	r.Record("alice", "foo", "baz", true, "request-3")
+
+	require.Len(t, m.Decisions, 2)
+	assert.True(t, m.Decisions[1].Allowed)
+	assert.Equal(t, []string{"allow-baz"}, m.Decisions[1].Policies)
+	assert.Equal(t, "127.0.0.1", req.Context["ip"])
+}
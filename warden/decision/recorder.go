@@ -0,0 +1,161 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package decision
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/ory/ladon"
+	"github.com/sirupsen/logrus"
+)
+
+// Recorder persists warden decisions. All denied requests are recorded, allowed requests are sampled using
+// AllowSampleRate, a value between 0 (none) and 1 (all).
+//
+// Recorder implements ladon.AuditLogger in order to find out which policies led to a decision. The policies are
+// collected per request id, see WithRequestID, until Record is called. Policies which are never collected, for
+// example because evaluating the request failed, are dropped after pendingTTL.
+type Recorder struct {
+	Manager         Manager
+	AllowSampleRate float64
+	L               logrus.FieldLogger
+
+	deciders map[string]*pending
+	pruned   time.Time
+	sync.Mutex
+}
+
+// RequestIDKey is the key of the request id in the context of ladon requests evaluated for a decision.
+const RequestIDKey = "hydra_decision_request_id"
+
+const pendingTTL = time.Minute
+
+type pending struct {
+	policies  ladon.Policies
+	createdAt time.Time
+}
+
+// WithRequestID returns a copy of the ladon context which carries the request id. All ladon requests evaluated to
+// reach one decision share the same id, which is later passed to Record.
+func WithRequestID(ctx ladon.Context, id string) ladon.Context {
+	c := ladon.Context{}
+	for k, v := range ctx {
+		c[k] = v
+	}
+	c[RequestIDKey] = id
+	return c
+}
+
+func (r *Recorder) LogRejectedAccessRequest(request *ladon.Request, pool ladon.Policies, deciders ladon.Policies) {
+	r.remember(request, deciders)
+}
+
+func (r *Recorder) LogGrantedAccessRequest(request *ladon.Request, pool ladon.Policies, deciders ladon.Policies) {
+	r.remember(request, deciders)
+}
+
+func (r *Recorder) remember(request *ladon.Request, deciders ladon.Policies) {
+	id, _ := request.Context[RequestIDKey].(string)
+	if id == "" {
+		return
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	now := time.Now()
+	if r.deciders == nil {
+		r.deciders = map[string]*pending{}
+	}
+	if now.Sub(r.pruned) > pendingTTL {
+		for k, p := range r.deciders {
+			if now.Sub(p.createdAt) > pendingTTL {
+				delete(r.deciders, k)
+			}
+		}
+		r.pruned = now
+	}
+
+	p, ok := r.deciders[id]
+	if !ok {
+		p = &pending{createdAt: now}
+		r.deciders[id] = p
+	}
+	p.policies = append(p.policies, deciders...)
+}
+
+// Record stores the decision for an access request. The request id is the one carried by the ladon requests that
+// were evaluated to reach the decision, for example one for the subject and one for each group the subject belongs to.
+func (r *Recorder) Record(subject, resource, action string, allowed bool, requestID string) {
+	policies := r.collect(requestID)
+	if allowed && rand.Float64() >= r.AllowSampleRate {
+		return
+	}
+
+	if err := r.Manager.AddDecision(&Decision{
+		Subject:   subject,
+		Resource:  resource,
+		Action:    action,
+		Allowed:   allowed,
+		Policies:  policies,
+		CreatedAt: time.Now().UTC().Round(time.Second),
+	}); err != nil {
+		r.L.WithError(err).Errorln("Could not record warden decision")
+	}
+}
+
+// RecordElevation stores the decision for an access request which the policies denied and the elevation allowed.
+// Unlike other allowed requests, these decisions are always recorded.
+func (r *Recorder) RecordElevation(subject, resource, action, elevation, requestID string) {
+	if err := r.Manager.AddDecision(&Decision{
+		Subject:   subject,
+		Resource:  resource,
+		Action:    action,
+		Allowed:   true,
+		Policies:  r.collect(requestID),
+		Elevation: elevation,
+		CreatedAt: time.Now().UTC().Round(time.Second),
+	}); err != nil {
+		r.L.WithError(err).Errorln("Could not record warden decision")
+	}
+}
+
+func (r *Recorder) collect(requestID string) []string {
+	r.Lock()
+	defer r.Unlock()
+
+	seen := map[string]bool{}
+	policies := []string{}
+	if p, ok := r.deciders[requestID]; ok {
+		for _, policy := range p.policies {
+			if !seen[policy.GetID()] {
+				seen[policy.GetID()] = true
+				policies = append(policies, policy.GetID())
+			}
+		}
+		delete(r.deciders, requestID)
+	}
+	return policies
+}
+
+// Forget drops the policies collected for the request id without recording a decision.
+func (r *Recorder) Forget(requestID string) {
+	r.Lock()
+	defer r.Unlock()
+
+	delete(r.deciders, requestID)
+}
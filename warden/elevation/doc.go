@@ -0,0 +1,67 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elevation
+
+// A list of elevations
+// swagger:response listWardenElevationsResponse
+type swaggerListElevationsResponse struct {
+	// in: body
+	// type: array
+	Body []Elevation
+}
+
+// swagger:parameters listWardenElevations
+type swaggerListElevationsParameters struct {
+	// Only return elevations of this subject.
+	// in: query
+	Subject string `json:"subject"`
+
+	// Only return elevations with this status, one of pending, approved or rejected.
+	// in: query
+	Status string `json:"status"`
+
+	// The maximum amount of elevations returned.
+	// in: query
+	Limit int `json:"limit"`
+
+	// The offset from where to start looking.
+	// in: query
+	Offset int `json:"offset"`
+}
+
+// swagger:parameters requestWardenElevation
+type swaggerRequestElevationParameters struct {
+	// in: body
+	Body Elevation
+}
+
+// swagger:parameters getWardenElevation
+type swaggerGetElevationParameters struct {
+	// The id of the elevation.
+	// in: path
+	// required: true
+	ID string `json:"id"`
+}
+
+// swagger:parameters approveWardenElevation rejectWardenElevation
+type swaggerDecideElevationParameters struct {
+	// The id of the elevation.
+	// in: path
+	// required: true
+	ID string `json:"id"`
+
+	// in: body
+	Body Decision
+}
@@ -0,0 +1,148 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elevation
+
+import (
+	"time"
+
+	"github.com/ory/fosite"
+	"github.com/ory/ladon/compiler"
+	"github.com/pkg/errors"
+)
+
+const (
+	// StatusPending is the status of elevations which were requested and are waiting for an approver.
+	StatusPending = "pending"
+
+	// StatusApproved is the status of elevations which were approved. They are honored by the warden until they
+	// expire.
+	StatusApproved = "approved"
+
+	// StatusRejected is the status of elevations which were rejected by an approver.
+	StatusRejected = "rejected"
+)
+
+// ErrNotPending is returned when an elevation which was already approved or rejected is decided on.
+var ErrNotPending = errors.New("The elevation was already approved or rejected")
+
+// Elevation is a request of a subject for temporary access in addition to its policies, for example to fix an
+// incident in production. Once approved by another subject, the warden allows the subject to perform the actions on
+// the resources and accepts the access tokens the subject obtained through the client for the scopes until the
+// elevation expires.
+// swagger:model wardenElevation
+type Elevation struct {
+	// ID is the unique identifier of this elevation.
+	ID string `json:"id"`
+
+	// Subject is the subject which requested the elevation.
+	Subject string `json:"subject"`
+
+	// Justification explains why the elevation is needed, for example by referring to an incident.
+	Justification string `json:"justification"`
+
+	// Resources are the resources the actions may be performed on. They are matched like the resources of policies,
+	// for example "rn:hydra:clients:<.*>".
+	Resources []string `json:"resources"`
+
+	// Actions are the actions which may be performed on the resources. They are matched like the actions of policies.
+	Actions []string `json:"actions"`
+
+	// Scopes are accepted by the warden as if they were granted to the access tokens of the subject issued to
+	// ClientID.
+	Scopes []string `json:"scopes"`
+
+	// ClientID is the client whose access tokens are elevated by Scopes. Defaults to the client of the access token
+	// requesting the elevation.
+	ClientID string `json:"client_id,omitempty"`
+
+	// Duration is how long the elevation lasts once it is approved, for example "1h". Valid time units are "s", "m"
+	// and "h".
+	Duration string `json:"duration"`
+
+	// Status is one of pending, approved or rejected.
+	Status string `json:"status"`
+
+	// Approver is the subject which approved or rejected the elevation.
+	Approver string `json:"approver,omitempty"`
+
+	// Comment is an optional note of the approver.
+	Comment string `json:"comment,omitempty"`
+
+	// RequestedAt is the time the elevation was requested.
+	RequestedAt time.Time `json:"requested_at"`
+
+	// DecidedAt is the time the elevation was approved or rejected.
+	DecidedAt *time.Time `json:"decided_at,omitempty"`
+
+	// ExpiresAt is the time an approved elevation ends.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// Validate checks that the elevation grants something and that its duration is positive and not longer than max.
+func (e *Elevation) Validate(max time.Duration) error {
+	if e.Justification == "" {
+		return errors.New("A justification is required")
+	} else if len(e.Scopes) == 0 && (len(e.Resources) == 0 || len(e.Actions) == 0) {
+		return errors.New("Resources and actions or scopes are required")
+	} else if len(e.Scopes) > 0 && e.ClientID == "" {
+		return errors.New("A client id is required to elevate scopes")
+	}
+
+	for _, pattern := range append(append([]string{}, e.Resources...), e.Actions...) {
+		if _, err := compiler.CompileRegex(pattern, '<', '>'); err != nil {
+			return errors.Errorf("Could not compile %s: %s", pattern, err)
+		}
+	}
+
+	d, err := time.ParseDuration(e.Duration)
+	if err != nil {
+		return errors.Errorf("Could not parse duration %s: %s", e.Duration, err)
+	} else if d <= 0 {
+		return errors.Errorf("Duration %s must be positive", e.Duration)
+	} else if d > max {
+		return errors.Errorf("Duration %s must not be longer than %s", e.Duration, max)
+	}
+	return nil
+}
+
+// IsActive returns true if the elevation was approved and did not expire at now.
+func (e *Elevation) IsActive(now time.Time) bool {
+	return e.Status == StatusApproved && e.ExpiresAt != nil && now.Before(*e.ExpiresAt)
+}
+
+// Allows returns true if the elevation allows the action on the resource.
+func (e *Elevation) Allows(resource, action string) bool {
+	return matches(e.Resources, resource) && matches(e.Actions, action)
+}
+
+// GrantsScope returns true if the elevation grants the scope to access tokens issued to the client using the scope
+// strategy.
+func (e *Elevation) GrantsScope(strategy fosite.ScopeStrategy, clientID, scope string) bool {
+	return e.ClientID != "" && e.ClientID == clientID && strategy(e.Scopes, scope)
+}
+
+func matches(patterns []string, needle string) bool {
+	for _, pattern := range patterns {
+		reg, err := compiler.CompileRegex(pattern, '<', '>')
+		if err != nil {
+			continue
+		}
+
+		if reg.MatchString(needle) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,395 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elevation
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/herodot"
+	"github.com/ory/hydra/firewall"
+	"github.com/ory/pagination"
+	"github.com/pkg/errors"
+)
+
+const (
+	ElevationsHandlerPath = "/warden/elevations"
+)
+
+const (
+	ElevationsResource = "warden:elevations"
+	ElevationResource  = "warden:elevations:%s"
+	Scope              = "hydra.warden.elevations"
+)
+
+type Handler struct {
+	Manager Manager
+	H       herodot.Writer
+	W       firewall.Firewall
+
+	ResourcePrefix string
+
+	// MaxDuration is the longest duration an elevation can be requested for.
+	MaxDuration time.Duration
+}
+
+// swagger:model wardenElevationDecision
+type Decision struct {
+	// Comment is an optional note of the approver, for example why the elevation was rejected.
+	Comment string `json:"comment"`
+}
+
+func (h *Handler) PrefixResource(resource string) string {
+	if h.ResourcePrefix == "" {
+		h.ResourcePrefix = "rn:hydra"
+	}
+
+	if h.ResourcePrefix[len(h.ResourcePrefix)-1] == ':' {
+		h.ResourcePrefix = h.ResourcePrefix[:len(h.ResourcePrefix)-1]
+	}
+
+	return h.ResourcePrefix + ":" + resource
+}
+
+func (h *Handler) SetRoutes(r *httprouter.Router) {
+	r.POST(ElevationsHandlerPath, h.RequestElevation)
+	r.GET(ElevationsHandlerPath, h.ListElevations)
+	r.GET(ElevationsHandlerPath+"/:id", h.GetElevation)
+	r.POST(ElevationsHandlerPath+"/:id/approve", h.ApproveElevation)
+	r.POST(ElevationsHandlerPath+"/:id/reject", h.RejectElevation)
+}
+
+// swagger:route POST /warden/elevations warden requestWardenElevation
+//
+// Request temporary elevated access
+//
+// Requests access in addition to the policies of the subject of the access token, for example to fix an incident in
+// production. The request must contain a justification, the resources and actions or the scopes needed and how long
+// they are needed for. The elevation is pending until another subject approves it using
+// `/warden/elevations/{id}/approve`.
+//
+// The subject making the request needs to be assigned to a policy containing:
+//
+//  ```
+//  {
+//    "resources": ["rn:hydra:warden:elevations"],
+//    "actions": ["request"],
+//    "effect": "allow"
+//  }
+//  ```
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Security:
+//       oauth2: hydra.warden.elevations
+//
+//     Responses:
+//       201: wardenElevation
+//       400: genericError
+//       401: genericError
+//       403: genericError
+//       500: genericError
+func (h *Handler) RequestElevation(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var e Elevation
+	var ctx = r.Context()
+
+	if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+		h.H.WriteError(w, r, errors.WithStack(err))
+		return
+	}
+
+	c, err := h.W.TokenAllowed(ctx, h.W.TokenFromRequest(r), &firewall.TokenAccessRequest{
+		Resource: h.PrefixResource(ElevationsResource),
+		Action:   "request",
+	}, Scope)
+	if err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	if e.ClientID == "" {
+		e.ClientID = c.ClientID
+	}
+
+	if err := e.Validate(h.MaxDuration); err != nil {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	e.ID = ""
+	e.Subject = c.Subject
+	e.Status = StatusPending
+	e.Approver = ""
+	e.Comment = ""
+	e.RequestedAt = time.Now().UTC().Round(time.Second)
+	e.DecidedAt = nil
+	e.ExpiresAt = nil
+	if err := h.Manager.CreateElevation(&e); err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	h.H.WriteCreated(w, r, ElevationsHandlerPath+"/"+e.ID, &e)
+}
+
+// swagger:route GET /warden/elevations warden listWardenElevations
+//
+// List elevations
+//
+// Returns elevations newest first. Use the subject and status query parameters to find, for example, the pending
+// elevations waiting for approval.
+//
+// The subject making the request needs to be assigned to a policy containing:
+//
+//  ```
+//  {
+//    "resources": ["rn:hydra:warden:elevations"],
+//    "actions": ["list"],
+//    "effect": "allow"
+//  }
+//  ```
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Security:
+//       oauth2: hydra.warden.elevations
+//
+//     Responses:
+//       200: listWardenElevationsResponse
+//       401: genericError
+//       403: genericError
+//       500: genericError
+func (h *Handler) ListElevations(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var ctx = r.Context()
+
+	if _, err := h.W.TokenAllowed(ctx, h.W.TokenFromRequest(r), &firewall.TokenAccessRequest{
+		Resource: h.PrefixResource(ElevationsResource),
+		Action:   "list",
+	}, Scope); err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	limit, offset := pagination.Parse(r, 100, 0, 500)
+	elevations, err := h.Manager.GetElevations(r.URL.Query().Get("subject"), r.URL.Query().Get("status"), limit, offset)
+	if err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	h.H.Write(w, r, elevations)
+}
+
+// swagger:route GET /warden/elevations/{id} warden getWardenElevation
+//
+// Get an elevation
+//
+// The subject making the request needs to be assigned to a policy containing:
+//
+//  ```
+//  {
+//    "resources": ["rn:hydra:warden:elevations:<id>"],
+//    "actions": ["get"],
+//    "effect": "allow"
+//  }
+//  ```
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Security:
+//       oauth2: hydra.warden.elevations
+//
+//     Responses:
+//       200: wardenElevation
+//       401: genericError
+//       403: genericError
+//       404: genericError
+//       500: genericError
+func (h *Handler) GetElevation(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var ctx = r.Context()
+	var id = ps.ByName("id")
+
+	if _, err := h.W.TokenAllowed(ctx, h.W.TokenFromRequest(r), &firewall.TokenAccessRequest{
+		Resource: fmt.Sprintf(h.PrefixResource(ElevationResource), id),
+		Action:   "get",
+	}, Scope); err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	e, err := h.Manager.GetElevation(id)
+	if err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	h.H.Write(w, r, e)
+}
+
+// swagger:route POST /warden/elevations/{id}/approve warden approveWardenElevation
+//
+// Approve an elevation
+//
+// Approves a pending elevation. The warden honors the elevation from now on until its duration has passed. Subjects
+// can not approve their own elevations.
+//
+// The subject making the request needs to be assigned to a policy containing:
+//
+//  ```
+//  {
+//    "resources": ["rn:hydra:warden:elevations:<id>"],
+//    "actions": ["approve"],
+//    "effect": "allow"
+//  }
+//  ```
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Security:
+//       oauth2: hydra.warden.elevations
+//
+//     Responses:
+//       200: wardenElevation
+//       401: genericError
+//       403: genericError
+//       404: genericError
+//       409: genericError
+//       500: genericError
+func (h *Handler) ApproveElevation(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	h.decide(w, r, ps.ByName("id"), "approve", StatusApproved)
+}
+
+// swagger:route POST /warden/elevations/{id}/reject warden rejectWardenElevation
+//
+// Reject an elevation
+//
+// Rejects a pending elevation. Subjects can not reject their own elevations.
+//
+// The subject making the request needs to be assigned to a policy containing:
+//
+//  ```
+//  {
+//    "resources": ["rn:hydra:warden:elevations:<id>"],
+//    "actions": ["reject"],
+//    "effect": "allow"
+//  }
+//  ```
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Security:
+//       oauth2: hydra.warden.elevations
+//
+//     Responses:
+//       200: wardenElevation
+//       401: genericError
+//       403: genericError
+//       404: genericError
+//       409: genericError
+//       500: genericError
+func (h *Handler) RejectElevation(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	h.decide(w, r, ps.ByName("id"), "reject", StatusRejected)
+}
+
+func (h *Handler) decide(w http.ResponseWriter, r *http.Request, id, action, status string) {
+	var ctx = r.Context()
+	var d Decision
+
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&d); err != nil {
+			h.H.WriteError(w, r, errors.WithStack(err))
+			return
+		}
+	}
+
+	c, err := h.W.TokenAllowed(ctx, h.W.TokenFromRequest(r), &firewall.TokenAccessRequest{
+		Resource: fmt.Sprintf(h.PrefixResource(ElevationResource), id),
+		Action:   action,
+	}, Scope)
+	if err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	e, err := h.Manager.GetElevation(id)
+	if err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	if e.Subject == c.Subject {
+		h.H.WriteErrorCode(w, r, http.StatusForbidden, errors.Errorf("Subject %s can not %s its own elevation", c.Subject, action))
+		return
+	}
+
+	now := time.Now().UTC().Round(time.Second)
+	e.Status = status
+	e.Approver = c.Subject
+	e.Comment = d.Comment
+	e.DecidedAt = &now
+	if status == StatusApproved {
+		duration, err := time.ParseDuration(e.Duration)
+		if err != nil {
+			h.H.WriteError(w, r, errors.WithStack(err))
+			return
+		}
+
+		expiresAt := now.Add(duration)
+		e.ExpiresAt = &expiresAt
+	}
+
+	if err := h.Manager.DecideElevation(e); errors.Cause(err) == ErrNotPending {
+		h.H.WriteErrorCode(w, r, http.StatusConflict, err)
+		return
+	} else if err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	h.H.Write(w, r, e)
+}
@@ -0,0 +1,38 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elevation
+
+import "time"
+
+type Manager interface {
+	CreateElevation(e *Elevation) error
+
+	// GetElevation returns the elevation or pkg.ErrNotFound if it does not exist.
+	GetElevation(id string) (*Elevation, error)
+
+	// GetElevations returns the elevations of subject with status, newest first. All subjects or statuses are returned
+	// if subject or status are empty.
+	GetElevations(subject, status string, limit, offset int) ([]Elevation, error)
+
+	// DecideElevation stores the status, approver, comment, decision time and expiry of a pending elevation. It returns
+	// ErrNotPending if the elevation was already decided on.
+	DecideElevation(e *Elevation) error
+
+	// FindActiveElevations returns the approved elevations of subject which did not expire at now.
+	FindActiveElevations(subject string, now time.Time) ([]Elevation, error)
+
+	// DeleteSubjectElevations removes all elevations requested by the subject and returns how many were removed.
+	DeleteSubjectElevations(subject string) (int, error)
+}
@@ -0,0 +1,133 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elevation
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ory/hydra/pkg"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+)
+
+type MemoryManager struct {
+	Elevations map[string]Elevation
+	sync.RWMutex
+}
+
+func NewMemoryManager() *MemoryManager {
+	return &MemoryManager{Elevations: map[string]Elevation{}}
+}
+
+func (m *MemoryManager) CreateElevation(e *Elevation) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if e.ID == "" {
+		e.ID = uuid.New()
+	}
+
+	m.Elevations[e.ID] = *e
+	return nil
+}
+
+func (m *MemoryManager) GetElevation(id string) (*Elevation, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	e, ok := m.Elevations[id]
+	if !ok {
+		return nil, errors.WithStack(pkg.ErrNotFound)
+	}
+	return &e, nil
+}
+
+func (m *MemoryManager) GetElevations(subject, status string, limit, offset int) ([]Elevation, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	result := []Elevation{}
+	for _, e := range m.Elevations {
+		if (subject == "" || e.Subject == subject) && (status == "" || e.Status == status) {
+			result = append(result, e)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].RequestedAt.Equal(result[j].RequestedAt) {
+			return result[i].ID < result[j].ID
+		}
+		return result[i].RequestedAt.After(result[j].RequestedAt)
+	})
+
+	if offset >= len(result) {
+		return []Elevation{}, nil
+	}
+
+	result = result[offset:]
+	if limit < len(result) {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+func (m *MemoryManager) DecideElevation(e *Elevation) error {
+	m.Lock()
+	defer m.Unlock()
+
+	stored, ok := m.Elevations[e.ID]
+	if !ok {
+		return errors.WithStack(pkg.ErrNotFound)
+	} else if stored.Status != StatusPending {
+		return errors.WithStack(ErrNotPending)
+	}
+
+	stored.Status = e.Status
+	stored.Approver = e.Approver
+	stored.Comment = e.Comment
+	stored.DecidedAt = e.DecidedAt
+	stored.ExpiresAt = e.ExpiresAt
+	m.Elevations[e.ID] = stored
+	return nil
+}
+
+func (m *MemoryManager) FindActiveElevations(subject string, now time.Time) ([]Elevation, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	var result []Elevation
+	for _, e := range m.Elevations {
+		if e.Subject == subject && e.IsActive(now) {
+			result = append(result, e)
+		}
+	}
+	return result, nil
+}
+
+func (m *MemoryManager) DeleteSubjectElevations(subject string) (int, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	var n int
+	for id, e := range m.Elevations {
+		if e.Subject == subject {
+			delete(m.Elevations, id)
+			n++
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,264 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elevation
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/ory/hydra/pkg"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+	"github.com/rubenv/sql-migrate"
+)
+
+var migrations = &migrate.MemoryMigrationSource{
+	Migrations: []*migrate.Migration{
+		{
+			Id: "1",
+			Up: []string{`CREATE TABLE IF NOT EXISTS hydra_warden_elevation (
+	id      		varchar(36) NOT NULL PRIMARY KEY,
+	subject			varchar(255) NOT NULL,
+	justification	text NOT NULL,
+	resources		text NOT NULL,
+	actions			text NOT NULL,
+	scopes			text NOT NULL,
+	duration		varchar(32) NOT NULL,
+	status			varchar(16) NOT NULL,
+	approver		varchar(255) NOT NULL,
+	comment			text NOT NULL,
+	requested_at	timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	decided_at		timestamp NULL,
+	expires_at		timestamp NULL
+)`, `CREATE INDEX hydra_warden_elevation_subject_status_idx ON hydra_warden_elevation (subject, status)`},
+			Down: []string{
+				"DROP TABLE hydra_warden_elevation",
+			},
+		},
+		{
+			Id: "2",
+			Up: []string{
+				"ALTER TABLE hydra_warden_elevation ADD client_id varchar(255) NOT NULL DEFAULT ''",
+			},
+			Down: []string{
+				"ALTER TABLE hydra_warden_elevation DROP COLUMN client_id",
+			},
+		},
+	},
+}
+
+type sqlData struct {
+	ID            string     `db:"id"`
+	Subject       string     `db:"subject"`
+	Justification string     `db:"justification"`
+	Resources     string     `db:"resources"`
+	Actions       string     `db:"actions"`
+	Scopes        string     `db:"scopes"`
+	ClientID      string     `db:"client_id"`
+	Duration      string     `db:"duration"`
+	Status        string     `db:"status"`
+	Approver      string     `db:"approver"`
+	Comment       string     `db:"comment"`
+	RequestedAt   time.Time  `db:"requested_at"`
+	DecidedAt     *time.Time `db:"decided_at"`
+	ExpiresAt     *time.Time `db:"expires_at"`
+}
+
+func sqlDataFromElevation(e *Elevation) (*sqlData, error) {
+	d := &sqlData{
+		ID:            e.ID,
+		Subject:       e.Subject,
+		Justification: e.Justification,
+		ClientID:      e.ClientID,
+		Duration:      e.Duration,
+		Status:        e.Status,
+		Approver:      e.Approver,
+		Comment:       e.Comment,
+		RequestedAt:   e.RequestedAt.UTC(),
+		DecidedAt:     utc(e.DecidedAt),
+		ExpiresAt:     utc(e.ExpiresAt),
+	}
+
+	// Resources and actions may contain regular expressions, they are stored as JSON to avoid clashing separators.
+	for _, list := range []struct {
+		value []string
+		field *string
+	}{
+		{value: e.Resources, field: &d.Resources},
+		{value: e.Actions, field: &d.Actions},
+		{value: e.Scopes, field: &d.Scopes},
+	} {
+		if list.value == nil {
+			list.value = []string{}
+		}
+
+		out, err := json.Marshal(list.value)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		*list.field = string(out)
+	}
+
+	return d, nil
+}
+
+func (d *sqlData) toElevation() (*Elevation, error) {
+	e := &Elevation{
+		ID:            d.ID,
+		Subject:       d.Subject,
+		Justification: d.Justification,
+		ClientID:      d.ClientID,
+		Duration:      d.Duration,
+		Status:        d.Status,
+		Approver:      d.Approver,
+		Comment:       d.Comment,
+		RequestedAt:   d.RequestedAt.UTC(),
+		DecidedAt:     utc(d.DecidedAt),
+		ExpiresAt:     utc(d.ExpiresAt),
+	}
+
+	for _, list := range []struct {
+		value string
+		field *[]string
+	}{
+		{value: d.Resources, field: &e.Resources},
+		{value: d.Actions, field: &e.Actions},
+		{value: d.Scopes, field: &e.Scopes},
+	} {
+		if err := json.Unmarshal([]byte(list.value), list.field); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	return e, nil
+}
+
+func utc(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	u := t.UTC()
+	return &u
+}
+
+type SQLManager struct {
+	DB *sqlx.DB
+}
+
+// Migrations returns the SQL migrations embedded in the binary.
+func (m *SQLManager) Migrations() *migrate.MemoryMigrationSource {
+	return migrations
+}
+
+func (m *SQLManager) CreateSchemas() (int, error) {
+	migrate.SetTable("hydra_warden_elevation_migration")
+	n, err := migrate.Exec(m.DB.DB, m.DB.DriverName(), migrations, migrate.Up)
+	if err != nil {
+		return 0, errors.Wrapf(err, "Could not migrate sql schema, applied %d migrations", n)
+	}
+	return n, nil
+}
+
+func (m *SQLManager) CreateElevation(e *Elevation) error {
+	if e.ID == "" {
+		e.ID = uuid.New()
+	}
+
+	d, err := sqlDataFromElevation(e)
+	if err != nil {
+		return err
+	}
+
+	if _, err := m.DB.NamedExec(`INSERT INTO hydra_warden_elevation
+	(id, subject, justification, resources, actions, scopes, client_id, duration, status, approver, comment, requested_at, decided_at, expires_at)
+	VALUES (:id, :subject, :justification, :resources, :actions, :scopes, :client_id, :duration, :status, :approver, :comment, :requested_at, :decided_at, :expires_at)`, d); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func (m *SQLManager) GetElevation(id string) (*Elevation, error) {
+	var d sqlData
+	if err := m.DB.Get(&d, m.DB.Rebind("SELECT * FROM hydra_warden_elevation WHERE id=?"), id); err == sql.ErrNoRows {
+		return nil, errors.WithStack(pkg.ErrNotFound)
+	} else if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return d.toElevation()
+}
+
+func (m *SQLManager) GetElevations(subject, status string, limit, offset int) ([]Elevation, error) {
+	var ds []sqlData
+	if err := m.DB.Select(&ds, m.DB.Rebind(`SELECT * FROM hydra_warden_elevation WHERE (?='' OR subject=?) AND (?='' OR status=?)
+	ORDER BY requested_at DESC, id LIMIT ? OFFSET ?`), subject, subject, status, status, limit, offset); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return toElevations(ds)
+}
+
+func (m *SQLManager) DecideElevation(e *Elevation) error {
+	result, err := m.DB.Exec(m.DB.Rebind(`UPDATE hydra_warden_elevation SET status=?, approver=?, comment=?, decided_at=?, expires_at=?
+	WHERE id=? AND status=?`), e.Status, e.Approver, e.Comment, utc(e.DecidedAt), utc(e.ExpiresAt), e.ID, StatusPending)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if rows, err := result.RowsAffected(); err != nil {
+		return errors.WithStack(err)
+	} else if rows > 0 {
+		return nil
+	}
+
+	// Nothing was updated, find out whether the elevation does not exist or was already decided on.
+	if _, err := m.GetElevation(e.ID); err != nil {
+		return err
+	}
+	return errors.WithStack(ErrNotPending)
+}
+
+func (m *SQLManager) FindActiveElevations(subject string, now time.Time) ([]Elevation, error) {
+	var ds []sqlData
+	if err := m.DB.Select(&ds, m.DB.Rebind("SELECT * FROM hydra_warden_elevation WHERE subject=? AND status=? AND expires_at > ?"), subject, StatusApproved, now.UTC()); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return toElevations(ds)
+}
+
+func (m *SQLManager) DeleteSubjectElevations(subject string) (int, error) {
+	res, err := m.DB.Exec(m.DB.Rebind("DELETE FROM hydra_warden_elevation WHERE subject=?"), subject)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	return int(n), nil
+}
+
+func toElevations(ds []sqlData) ([]Elevation, error) {
+	elevations := make([]Elevation, len(ds))
+	for k, d := range ds {
+		e, err := d.toElevation()
+		if err != nil {
+			return nil, err
+		}
+		elevations[k] = *e
+	}
+	return elevations, nil
+}
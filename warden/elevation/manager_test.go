@@ -0,0 +1,117 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elevation_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ory/fosite"
+	"github.com/ory/hydra/pkg"
+	. "github.com/ory/hydra/warden/elevation"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryManager(t *testing.T) {
+	m := NewMemoryManager()
+	now := time.Now().UTC().Round(time.Second)
+
+	require.NoError(t, m.CreateElevation(&Elevation{ID: "1", Subject: "alice", Status: StatusPending, RequestedAt: now.Add(-time.Hour)}))
+	require.NoError(t, m.CreateElevation(&Elevation{ID: "2", Subject: "bob", Status: StatusPending, RequestedAt: now.Add(-time.Minute)}))
+	require.NoError(t, m.CreateElevation(&Elevation{ID: "3", Subject: "alice", Status: StatusRejected, RequestedAt: now.Add(-time.Hour * 48)}))
+
+	es, err := m.GetElevations("", "", 10, 0)
+	require.NoError(t, err)
+	require.Len(t, es, 3)
+	assert.Equal(t, "2", es[0].ID)
+	assert.Equal(t, "1", es[1].ID)
+	assert.Equal(t, "3", es[2].ID)
+
+	es, err = m.GetElevations("alice", StatusPending, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, es, 1)
+	assert.Equal(t, "1", es[0].ID)
+
+	es, err = m.GetElevations("", "", 1, 1)
+	require.NoError(t, err)
+	require.Len(t, es, 1)
+	assert.Equal(t, "1", es[0].ID)
+
+	_, err = m.GetElevation("4")
+	assert.Equal(t, pkg.ErrNotFound, errors.Cause(err))
+
+	expiresAt := now.Add(time.Hour)
+	require.NoError(t, m.DecideElevation(&Elevation{ID: "1", Status: StatusApproved, Approver: "bob", DecidedAt: &now, ExpiresAt: &expiresAt}))
+	assert.Equal(t, ErrNotPending, errors.Cause(m.DecideElevation(&Elevation{ID: "1", Status: StatusRejected, Approver: "bob"})))
+	assert.Equal(t, ErrNotPending, errors.Cause(m.DecideElevation(&Elevation{ID: "3", Status: StatusApproved, Approver: "bob"})))
+
+	e, err := m.GetElevation("1")
+	require.NoError(t, err)
+	assert.Equal(t, StatusApproved, e.Status)
+	assert.Equal(t, "bob", e.Approver)
+
+	es, err = m.FindActiveElevations("alice", now)
+	require.NoError(t, err)
+	require.Len(t, es, 1)
+	assert.Equal(t, "1", es[0].ID)
+
+	es, err = m.FindActiveElevations("alice", now.Add(time.Hour*2))
+	require.NoError(t, err)
+	assert.Empty(t, es)
+
+	n, err := m.DeleteSubjectElevations("alice")
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	es, err = m.GetElevations("", "", 10, 0)
+	require.NoError(t, err)
+	require.Len(t, es, 1)
+	assert.Equal(t, "bob", es[0].Subject)
+}
+
+func TestElevation(t *testing.T) {
+	e := &Elevation{
+		Justification: "INC-1234",
+		Resources:     []string{"rn:hydra:clients:<.*>"},
+		Actions:       []string{"get", "update"},
+		Scopes:        []string{"hydra.clients"},
+		ClientID:      "admin-cli",
+		Duration:      "1h",
+	}
+
+	require.NoError(t, e.Validate(time.Hour*8))
+	assert.Error(t, e.Validate(time.Minute))
+
+	assert.True(t, e.Allows("rn:hydra:clients:foo", "update"))
+	assert.False(t, e.Allows("rn:hydra:clients:foo", "delete"))
+	assert.False(t, e.Allows("rn:hydra:policies:foo", "get"))
+
+	assert.True(t, e.GrantsScope(fosite.HierarchicScopeStrategy, "admin-cli", "hydra.clients"))
+	assert.False(t, e.GrantsScope(fosite.HierarchicScopeStrategy, "admin-cli", "hydra.policies"))
+	assert.False(t, e.GrantsScope(fosite.HierarchicScopeStrategy, "some-app", "hydra.clients"), "Tokens of other clients must not be elevated")
+
+	for k, c := range []*Elevation{
+		{Resources: []string{"foo"}, Actions: []string{"get"}, Duration: "1h"},
+		{Justification: "INC-1234", Duration: "1h"},
+		{Justification: "INC-1234", Scopes: []string{"foo"}, Duration: "1h"},
+		{Justification: "INC-1234", Scopes: []string{"foo"}, ClientID: "admin-cli", Duration: "-1h"},
+		{Justification: "INC-1234", Scopes: []string{"foo"}, ClientID: "admin-cli", Duration: "forever"},
+		{Justification: "INC-1234", Resources: []string{"<[>"}, Actions: []string{"get"}, Duration: "1h"},
+	} {
+		assert.Error(t, c.Validate(time.Hour*8), "case %d", k)
+	}
+}
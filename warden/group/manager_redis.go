@@ -0,0 +1,168 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package group
+
+import (
+	"github.com/go-redis/redis"
+	"github.com/ory/hydra/pkg"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+)
+
+// redisGroupIDs is a sorted set containing all group ids. All sorted sets used by the RedisManager have members
+// with the same score, so they are ordered by value.
+const redisGroupIDs = "hydra:group:ids"
+
+// redisGroupMembersKey is a sorted set containing the members of a group.
+func redisGroupMembersKey(id string) string {
+	return "hydra:group:members:" + id
+}
+
+// redisMemberGroupsKey is a sorted set containing the ids of the groups a subject is a member of.
+func redisMemberGroupsKey(subject string) string {
+	return "hydra:group:member:" + subject
+}
+
+type RedisManager struct {
+	DB *redis.Client
+}
+
+func (m *RedisManager) CreateGroup(g *Group) error {
+	if g.ID == "" {
+		g.ID = uuid.New()
+	}
+
+	previous, err := m.DB.ZRange(redisGroupMembersKey(g.ID), 0, -1).Result()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if _, err := m.DB.TxPipelined(func(pipe redis.Pipeliner) error {
+		for _, member := range previous {
+			pipe.ZRem(redisMemberGroupsKey(member), g.ID)
+		}
+		pipe.Del(redisGroupMembersKey(g.ID))
+		pipe.ZAdd(redisGroupIDs, redis.Z{Member: g.ID})
+		m.addMembers(pipe, g.ID, g.Members)
+		return nil
+	}); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func (m *RedisManager) GetGroup(id string) (*Group, error) {
+	if _, err := m.DB.ZScore(redisGroupIDs, id).Result(); err == redis.Nil {
+		return nil, errors.WithStack(pkg.ErrNotFound)
+	} else if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	members, err := m.DB.ZRange(redisGroupMembersKey(id), 0, -1).Result()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &Group{ID: id, Members: members}, nil
+}
+
+func (m *RedisManager) DeleteGroup(id string) error {
+	members, err := m.DB.ZRange(redisGroupMembersKey(id), 0, -1).Result()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if _, err := m.DB.TxPipelined(func(pipe redis.Pipeliner) error {
+		for _, member := range members {
+			pipe.ZRem(redisMemberGroupsKey(member), id)
+		}
+		pipe.Del(redisGroupMembersKey(id))
+		pipe.ZRem(redisGroupIDs, id)
+		return nil
+	}); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func (m *RedisManager) AddGroupMembers(group string, subjects []string) error {
+	if _, err := m.GetGroup(group); err != nil {
+		return err
+	}
+
+	if _, err := m.DB.TxPipelined(func(pipe redis.Pipeliner) error {
+		m.addMembers(pipe, group, subjects)
+		return nil
+	}); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func (m *RedisManager) RemoveGroupMembers(group string, subjects []string) error {
+	if _, err := m.GetGroup(group); err != nil {
+		return err
+	}
+
+	if _, err := m.DB.TxPipelined(func(pipe redis.Pipeliner) error {
+		for _, subject := range subjects {
+			pipe.ZRem(redisGroupMembersKey(group), subject)
+			pipe.ZRem(redisMemberGroupsKey(subject), group)
+		}
+		return nil
+	}); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func (m *RedisManager) FindGroupsByMember(subject string, limit, offset int) ([]Group, error) {
+	return m.listGroups(redisMemberGroupsKey(subject), limit, offset)
+}
+
+func (m *RedisManager) ListGroups(limit, offset int) ([]Group, error) {
+	return m.listGroups(redisGroupIDs, limit, offset)
+}
+
+func (m *RedisManager) listGroups(key string, limit, offset int) ([]Group, error) {
+	groups := make([]Group, 0)
+	if limit <= 0 {
+		return groups, nil
+	}
+
+	ids, err := m.DB.ZRange(key, int64(offset), int64(offset+limit-1)).Result()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	for _, id := range ids {
+		g, err := m.GetGroup(id)
+		if errors.Cause(err) == pkg.ErrNotFound {
+			// The group was deleted in the meantime.
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		groups = append(groups, *g)
+	}
+	return groups, nil
+}
+
+func (m *RedisManager) addMembers(pipe redis.Pipeliner, group string, subjects []string) {
+	for _, subject := range subjects {
+		pipe.ZAdd(redisGroupMembersKey(group), redis.Z{Member: subject})
+		pipe.ZAdd(redisMemberGroupsKey(subject), redis.Z{Member: group})
+	}
+}
@@ -48,6 +48,11 @@ type SQLManager struct {
 	DB *sqlx.DB
 }
 
+// Migrations returns the SQL migrations embedded in the binary.
+func (m *SQLManager) Migrations() *migrate.MemoryMigrationSource {
+	return migrations
+}
+
 func (m *SQLManager) CreateSchemas() (int, error) {
 	migrate.SetTable("hydra_groups_migration")
 	n, err := migrate.Exec(m.DB.DB, m.DB.DriverName(), migrations, migrate.Up)
@@ -96,50 +101,25 @@ func (m *SQLManager) DeleteGroup(id string) error {
 }
 
 func (m *SQLManager) AddGroupMembers(group string, subjects []string) error {
-	tx, err := m.DB.Beginx()
-	if err != nil {
-		return errors.Wrap(err, "Could not begin transaction")
-	}
-
-	for _, subject := range subjects {
-		if _, err := tx.Exec(m.DB.Rebind("INSERT INTO hydra_warden_group_member (group_id, member) VALUES (?, ?)"), group, subject); err != nil {
-			if err := tx.Rollback(); err != nil {
+	return pkg.SQLTransaction(m.DB, func(tx *sqlx.Tx) error {
+		for _, subject := range subjects {
+			if _, err := tx.Exec(m.DB.Rebind("INSERT INTO hydra_warden_group_member (group_id, member) VALUES (?, ?)"), group, subject); err != nil {
 				return errors.WithStack(err)
 			}
-			return errors.WithStack(err)
 		}
-	}
-
-	if err := tx.Commit(); err != nil {
-		if err := tx.Rollback(); err != nil {
-			return errors.WithStack(err)
-		}
-		return errors.Wrap(err, "Could not commit transaction")
-	}
-	return nil
+		return nil
+	})
 }
 
 func (m *SQLManager) RemoveGroupMembers(group string, subjects []string) error {
-	tx, err := m.DB.Beginx()
-	if err != nil {
-		return errors.Wrap(err, "Could not begin transaction")
-	}
-	for _, subject := range subjects {
-		if _, err := m.DB.Exec(m.DB.Rebind("DELETE FROM hydra_warden_group_member WHERE member=? AND group_id=?"), subject, group); err != nil {
-			if err := tx.Rollback(); err != nil {
+	return pkg.SQLTransaction(m.DB, func(tx *sqlx.Tx) error {
+		for _, subject := range subjects {
+			if _, err := tx.Exec(m.DB.Rebind("DELETE FROM hydra_warden_group_member WHERE member=? AND group_id=?"), subject, group); err != nil {
 				return errors.WithStack(err)
 			}
-			return errors.WithStack(err)
-		}
-	}
-
-	if err := tx.Commit(); err != nil {
-		if err := tx.Rollback(); err != nil {
-			return errors.WithStack(err)
 		}
-		return errors.Wrap(err, "Could not commit transaction")
-	}
-	return nil
+		return nil
+	})
 }
 
 func (m *SQLManager) FindGroupsByMember(subject string, limit, offset int) ([]Group, error) {
@@ -37,6 +37,7 @@ func TestMain(m *testing.M) {
 	if !testing.Short() {
 		connectToPG()
 		connectToMySQL()
+		connectToRedis()
 	}
 
 	s := m.Run()
@@ -65,6 +66,10 @@ func connectToPG() {
 	clientManagers["postgres"] = s
 }
 
+func connectToRedis() {
+	clientManagers["redis"] = &RedisManager{DB: integration.ConnectToRedis()}
+}
+
 func TestManagers(t *testing.T) {
 	for k, m := range clientManagers {
 		t.Run(fmt.Sprintf("case=%s", k), TestHelperManagers(m))
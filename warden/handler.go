@@ -15,6 +15,7 @@
 package warden
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"strings"
@@ -56,6 +57,10 @@ type WardenHandler struct {
 	H      herodot.Writer
 	Warden firewall.Firewall
 
+	// APIKeys, if set, authenticates callers presenting one of the keys as bearer token without token introspection
+	// and policy evaluation.
+	APIKeys *APIKeys
+
 	ResourcePrefix string
 }
 
@@ -89,6 +94,21 @@ func (h *WardenHandler) SetRoutes(r *httprouter.Router) {
 	r.POST(AllowedHandlerPath, h.Allowed)
 }
 
+// authorizeCaller returns nil if the caller presented one of APIKeys or an access token which is allowed to decide
+// access requests using resource.
+func (h *WardenHandler) authorizeCaller(ctx context.Context, r *http.Request, resource string) error {
+	token := h.Warden.TokenFromRequest(r)
+	if h.APIKeys != nil && h.APIKeys.Valid(token) {
+		return nil
+	}
+
+	_, err := h.Warden.TokenAllowed(ctx, token, &firewall.TokenAccessRequest{
+		Resource: h.PrefixResource(resource),
+		Action:   "decide",
+	}, "hydra.warden")
+	return err
+}
+
 // swagger:route POST /warden/allowed warden doesWardenAllowAccessRequest
 //
 // Check if an access request is valid (without providing an access token)
@@ -98,7 +118,8 @@ func (h *WardenHandler) SetRoutes(r *httprouter.Router) {
 // this endpoint returns a 200 response with `{ "allowed": false}`, otherwise `{ "allowed": true }` is returned.
 //
 //
-// The subject making the request needs to be assigned to a policy containing:
+// The subject making the request needs to be assigned to a policy containing the following, unless the request is
+// authenticated with a pre-shared key configured in WARDEN_API_KEYS or WARDEN_API_KEYS_FILE:
 //
 //  ```
 //  {
@@ -118,6 +139,7 @@ func (h *WardenHandler) SetRoutes(r *httprouter.Router) {
 //
 //     Security:
 //       oauth2: hydra.warden
+//       wardenApiKey:
 //
 //     Responses:
 //       200: wardenAccessRequestResponse
@@ -126,10 +148,7 @@ func (h *WardenHandler) SetRoutes(r *httprouter.Router) {
 //       500: genericError
 func (h *WardenHandler) Allowed(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
 	var ctx = r.Context()
-	if _, err := h.Warden.TokenAllowed(ctx, h.Warden.TokenFromRequest(r), &firewall.TokenAccessRequest{
-		Resource: h.PrefixResource("warden:allowed"),
-		Action:   "decide",
-	}, "hydra.warden"); err != nil {
+	if err := h.authorizeCaller(ctx, r, "warden:allowed"); err != nil {
 		h.H.WriteError(w, r, err)
 		return
 	}
@@ -166,7 +185,8 @@ func (h *WardenHandler) Allowed(w http.ResponseWriter, r *http.Request, _ httpro
 // Extra data set through the `accessTokenExtra` field in the consent flow will be included in the response.
 //
 //
-// The subject making the request needs to be assigned to a policy containing:
+// The subject making the request needs to be assigned to a policy containing the following, unless the request is
+// authenticated with a pre-shared key configured in WARDEN_API_KEYS or WARDEN_API_KEYS_FILE:
 //
 //  ```
 //  {
@@ -186,6 +206,7 @@ func (h *WardenHandler) Allowed(w http.ResponseWriter, r *http.Request, _ httpro
 //
 //     Security:
 //       oauth2: hydra.warden
+//       wardenApiKey:
 //
 //     Responses:
 //       200: wardenTokenAccessRequestResponse
@@ -194,11 +215,7 @@ func (h *WardenHandler) Allowed(w http.ResponseWriter, r *http.Request, _ httpro
 //       500: genericError
 func (h *WardenHandler) TokenAllowed(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
 	ctx := r.Context()
-	_, err := h.Warden.TokenAllowed(ctx, h.Warden.TokenFromRequest(r), &firewall.TokenAccessRequest{
-		Resource: h.PrefixResource("warden:token:allowed"),
-		Action:   "decide",
-	}, "hydra.warden")
-	if err != nil {
+	if err := h.authorizeCaller(ctx, r, "warden:token:allowed"); err != nil {
 		h.H.WriteError(w, r, err)
 		return
 	}
@@ -0,0 +1,339 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package warden
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ory/fosite"
+	"github.com/ory/hydra/firewall"
+	"github.com/ory/hydra/oauth2"
+	"github.com/ory/hydra/pkg"
+	"github.com/pkg/errors"
+	"github.com/square/go-jose"
+)
+
+// peerKeysMinRefreshInterval limits how often the keys of a peer are fetched because a token was signed with an
+// unknown key.
+const peerKeysMinRefreshInterval = time.Second * 10
+
+// PeerScopes maps a scope granted by a peer to the scopes it grants on this instance.
+type PeerScopes map[string][]string
+
+// ParsePeerScopes parses a comma separated list of peer scopes with the scopes they grant separated by "|", for
+// example "ops.admin=hydra.clients|hydra.policies,ops.read=hydra.clients.get".
+func ParsePeerScopes(s string) (PeerScopes, error) {
+	scopes := PeerScopes{}
+	for _, definition := range pkg.SplitNonEmpty(s, ",") {
+		parts := strings.SplitN(definition, "=", 2)
+		name := strings.TrimSpace(parts[0])
+		if len(parts) != 2 || name == "" {
+			return nil, errors.Errorf("Peer scope %s must have the format <peer-scope>=<scope>|<scope>", definition)
+		} else if _, ok := scopes[name]; ok {
+			return nil, errors.Errorf("Peer scope %s is mapped twice", name)
+		}
+
+		var granted []string
+		for _, scope := range strings.Split(parts[1], "|") {
+			if scope = strings.TrimSpace(scope); scope != "" {
+				granted = append(granted, scope)
+			}
+		}
+		if len(granted) == 0 {
+			return nil, errors.Errorf("Peer scope %s grants no scopes", name)
+		}
+		scopes[name] = granted
+	}
+	return scopes, nil
+}
+
+// Map returns the scopes granted on this instance for the scopes granted by a peer. Scopes which are not mapped are
+// dropped.
+func (s PeerScopes) Map(peerScopes []string) []string {
+	seen := map[string]bool{}
+	var granted []string
+	for _, peerScope := range peerScopes {
+		for _, scope := range s[peerScope] {
+			if !seen[scope] {
+				seen[scope] = true
+				granted = append(granted, scope)
+			}
+		}
+	}
+	return granted
+}
+
+// PeerIssuers accepts JSON Web Token access tokens issued by other ORY Hydra instances, so that one instance can
+// manage others without sharing secrets. A token is trusted if its issuer is one of the peers, it is signed by
+// one of the keys the peer publishes at /.well-known/jwks.json and its audience contains Audience. Only the scopes
+// mapped by Scopes are granted, and the subject of the token, namespaced by its issuer (see PeerSubject), is still
+// subject to the policies of this instance.
+type PeerIssuers struct {
+	Audience      string
+	Scopes        PeerScopes
+	ScopeStrategy fosite.ScopeStrategy
+
+	peers map[string]*peerKeys
+}
+
+// NewPeerIssuers returns PeerIssuers trusting the tokens of the given issuers issued for audience. The keys of a peer
+// are fetched using client and kept for ttl.
+func NewPeerIssuers(issuers []string, audience string, scopes PeerScopes, scopeStrategy fosite.ScopeStrategy, client *http.Client, ttl time.Duration) *PeerIssuers {
+	p := &PeerIssuers{
+		Audience:      audience,
+		Scopes:        scopes,
+		ScopeStrategy: scopeStrategy,
+		peers:         map[string]*peerKeys{},
+	}
+	for _, issuer := range issuers {
+		p.peers[issuer] = &peerKeys{
+			url:    pkg.JoinURLStrings(issuer, oauth2.JWKPath),
+			client: client,
+			ttl:    ttl,
+		}
+	}
+	return p
+}
+
+// PeerSubject returns the subject of a token issued by a peer as seen by the policies of this instance, which is the
+// subject prefixed with the issuer, for example "https://ops.hydra.myapp.com/|alice". Subjects of different peers and
+// subjects of this instance can thus never be confused.
+func PeerSubject(issuer, subject string) string {
+	return issuer + "|" + subject
+}
+
+// Issued returns true if token is a JSON Web Token claiming to be issued by one of the peers. The signature is not
+// verified.
+func (p *PeerIssuers) Issued(token string) bool {
+	if !oauth2.IsJWT(token) {
+		return false
+	}
+
+	claims, err := peerTokenClaimsWithoutVerification(token)
+	if err != nil {
+		return false
+	}
+
+	_, ok := p.peers[claims.Issuer]
+	return ok
+}
+
+// Authenticate verifies a token issued by one of the peers and returns its context with the mapped scopes, and the
+// token's id. Every scope in scopes must be granted by the mapped scopes.
+func (p *PeerIssuers) Authenticate(token string, scopes []string) (*firewall.Context, string, error) {
+	if p.Audience == "" {
+		return nil, "", errors.Wrap(fosite.ErrRequestUnauthorized, "No audience is configured for tokens of trusted peers")
+	}
+
+	unverified, err := peerTokenClaimsWithoutVerification(token)
+	if err != nil {
+		return nil, "", err
+	}
+
+	peer, ok := p.peers[unverified.Issuer]
+	if !ok {
+		return nil, "", errors.Wrapf(fosite.ErrRequestUnauthorized, "Issuer %s is not a trusted peer", unverified.Issuer)
+	}
+
+	signed, err := jose.ParseSigned(token)
+	if err != nil {
+		return nil, "", errors.Wrap(fosite.ErrTokenSignatureMismatch, err.Error())
+	} else if len(signed.Signatures) != 1 {
+		return nil, "", errors.Wrap(fosite.ErrTokenSignatureMismatch, "Expected exactly one signature")
+	}
+
+	key, err := peer.key(signed.Signatures[0].Header.KeyID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	payload, err := signed.Verify(key.Key)
+	if err != nil {
+		return nil, "", errors.Wrap(fosite.ErrTokenSignatureMismatch, err.Error())
+	}
+
+	var claims peerTokenClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, "", errors.Wrap(fosite.ErrTokenSignatureMismatch, err.Error())
+	}
+
+	now := time.Now().UTC()
+	if claims.Issuer != unverified.Issuer {
+		return nil, "", errors.Wrap(fosite.ErrTokenSignatureMismatch, "Issuer of the verified token differs")
+	} else if claims.ExpiresAt == 0 || now.After(time.Unix(claims.ExpiresAt, 0)) {
+		return nil, "", errors.Wrap(fosite.ErrTokenExpired, "Access token expired")
+	} else if claims.NotBefore > 0 && now.Before(time.Unix(claims.NotBefore, 0)) {
+		return nil, "", errors.Wrap(fosite.ErrRequestUnauthorized, "Access token is not valid yet")
+	} else if claims.Subject == "" {
+		return nil, "", errors.Wrap(fosite.ErrRequestUnauthorized, "Access token has no subject")
+	} else if !claims.Audience.contains(p.Audience) {
+		return nil, "", errors.Wrapf(fosite.ErrRequestUnauthorized, "Access token is not intended for audience %s", p.Audience)
+	}
+
+	granted := p.Scopes.Map(claims.Scopes)
+	if err := matchScopes(p.ScopeStrategy, granted, scopes); err != nil {
+		return nil, "", err
+	}
+
+	return &firewall.Context{
+		Subject:       PeerSubject(claims.Issuer, claims.Subject),
+		GrantedScopes: granted,
+		Issuer:        claims.Issuer,
+		ClientID:      claims.clientID(),
+		IssuedAt:      time.Unix(claims.IssuedAt, 0).UTC(),
+		ExpiresAt:     time.Unix(claims.ExpiresAt, 0).UTC(),
+		Extra:         claims.Extra,
+	}, claims.ID, nil
+}
+
+type peerTokenClaims struct {
+	ID        string                 `json:"jti"`
+	Issuer    string                 `json:"iss"`
+	Subject   string                 `json:"sub"`
+	Audience  peerAudience           `json:"aud"`
+	ClientID  string                 `json:"client_id"`
+	IssuedAt  int64                  `json:"iat"`
+	NotBefore int64                  `json:"nbf"`
+	ExpiresAt int64                  `json:"exp"`
+	Scopes    []string               `json:"scp"`
+	Extra     map[string]interface{} `json:"ext"`
+}
+
+// clientID returns the client the token was issued to. Tokens of peers which do not set the client_id claim carry the
+// client in the audience, unless their audience was restricted.
+func (c *peerTokenClaims) clientID() string {
+	if c.ClientID != "" {
+		return c.ClientID
+	} else if len(c.Audience) == 1 {
+		return c.Audience[0]
+	}
+	return ""
+}
+
+// peerAudience is the audience of a peer token, which is either a single string or an array of strings.
+type peerAudience []string
+
+func (a *peerAudience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = peerAudience{single}
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return errors.WithStack(err)
+	}
+	*a = peerAudience(multiple)
+	return nil
+}
+
+func (a peerAudience) contains(audience string) bool {
+	for _, aud := range a {
+		if aud == audience {
+			return true
+		}
+	}
+	return false
+}
+
+// peerTokenClaimsWithoutVerification decodes the claims of a compact serialized JSON Web Token without verifying
+// its signature, which is needed to find the keys verifying it.
+func peerTokenClaimsWithoutVerification(token string) (*peerTokenClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.Wrap(fosite.ErrRequestUnauthorized, "Access token is not a JSON Web Token")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, errors.Wrap(fosite.ErrRequestUnauthorized, err.Error())
+	}
+
+	var claims peerTokenClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, errors.Wrap(fosite.ErrRequestUnauthorized, err.Error())
+	}
+	return &claims, nil
+}
+
+// peerKeys caches the JSON Web Key Set of a peer.
+type peerKeys struct {
+	sync.Mutex
+	url    string
+	client *http.Client
+	ttl    time.Duration
+
+	keys      *jose.JSONWebKeySet
+	fetchedAt time.Time
+}
+
+// key returns the key with id kid. The keys are fetched again if they are older than ttl, or if kid is unknown and
+// they were not fetched within peerKeysMinRefreshInterval.
+func (k *peerKeys) key(kid string) (*jose.JSONWebKey, error) {
+	k.Lock()
+	defer k.Unlock()
+
+	if k.keys == nil || time.Since(k.fetchedAt) > k.ttl {
+		if err := k.fetch(); err != nil {
+			return nil, err
+		}
+	}
+
+	if found := k.keys.Key(kid); len(found) > 0 {
+		return &found[0], nil
+	} else if time.Since(k.fetchedAt) < peerKeysMinRefreshInterval {
+		return nil, errors.Wrapf(fosite.ErrTokenSignatureMismatch, "Key %s is not published by the peer", kid)
+	}
+
+	if err := k.fetch(); err != nil {
+		return nil, err
+	}
+	if found := k.keys.Key(kid); len(found) > 0 {
+		return &found[0], nil
+	}
+	return nil, errors.Wrapf(fosite.ErrTokenSignatureMismatch, "Key %s is not published by the peer", kid)
+}
+
+func (k *peerKeys) fetch() error {
+	c := k.client
+	if c == nil {
+		c = http.DefaultClient
+	}
+
+	res, err := c.Get(k.url)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return errors.Errorf("Expected status code %d from %s but got %d", http.StatusOK, k.url, res.StatusCode)
+	}
+
+	var keys jose.JSONWebKeySet
+	if err := json.NewDecoder(res.Body).Decode(&keys); err != nil {
+		return errors.Wrapf(err, "Could not decode keys from %s", k.url)
+	}
+
+	k.keys = &keys
+	k.fetchedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,237 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package warden_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ory/fosite"
+	"github.com/ory/hydra/firewall"
+	"github.com/ory/hydra/pkg"
+	"github.com/ory/hydra/warden"
+	"github.com/ory/hydra/warden/group"
+	"github.com/ory/ladon"
+	"github.com/sirupsen/logrus"
+	"github.com/square/go-jose"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePeerScopes(t *testing.T) {
+	scopes, err := warden.ParsePeerScopes("ops.admin=hydra.clients|hydra.policies, ops.read = hydra.clients.get")
+	require.NoError(t, err)
+	assert.Equal(t, warden.PeerScopes{
+		"ops.admin": {"hydra.clients", "hydra.policies"},
+		"ops.read":  {"hydra.clients.get"},
+	}, scopes)
+
+	assert.Equal(t, []string{"hydra.clients", "hydra.policies", "hydra.clients.get"}, scopes.Map([]string{"ops.admin", "ops.read", "ops.unknown"}))
+	assert.Empty(t, scopes.Map([]string{"hydra"}))
+
+	for _, invalid := range []string{
+		"ops.admin",
+		"=hydra",
+		"ops.admin=",
+		"ops.admin=|",
+		"ops.admin=hydra,ops.admin=hydra.clients",
+	} {
+		_, err := warden.ParsePeerScopes(invalid)
+		assert.Error(t, err, "%s", invalid)
+	}
+}
+
+type testPeer struct {
+	*httptest.Server
+	key     *rsa.PrivateKey
+	keyID   string
+	fetches int
+}
+
+func newTestPeer(t *testing.T) *testPeer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	p := &testPeer{key: key, keyID: "public:ops"}
+	p.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p.fetches++
+		require.Equal(t, "/.well-known/jwks.json", r.URL.Path)
+		require.NoError(t, json.NewEncoder(w).Encode(&jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+			{Key: &p.key.PublicKey, KeyID: p.keyID, Algorithm: "RS256", Use: "sig"},
+		}}))
+	}))
+	return p
+}
+
+func (p *testPeer) sign(t *testing.T, claims map[string]interface{}) string {
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: p.key}, new(jose.SignerOptions).WithType("JWT").WithHeader("kid", p.keyID))
+	require.NoError(t, err)
+
+	signed, err := signer.Sign(payload)
+	require.NoError(t, err)
+
+	token, err := signed.CompactSerialize()
+	require.NoError(t, err)
+	return token
+}
+
+const testPeerAudience = "https://hydra.example.com"
+
+func (p *testPeer) claims(subject string, scopes ...string) map[string]interface{} {
+	now := time.Now().UTC()
+	return map[string]interface{}{
+		"jti":       "token-" + subject,
+		"iss":       p.URL,
+		"sub":       subject,
+		"aud":       testPeerAudience,
+		"client_id": "ops-client",
+		"iat":       now.Unix(),
+		"nbf":       now.Unix(),
+		"exp":       now.Add(time.Hour).Unix(),
+		"scp":       scopes,
+	}
+}
+
+func TestPeerIssuersAuthenticate(t *testing.T) {
+	peer := newTestPeer(t)
+	defer peer.Close()
+
+	peers := warden.NewPeerIssuers([]string{peer.URL}, testPeerAudience, warden.PeerScopes{
+		"ops.admin": {"hydra.clients", "hydra.policies"},
+	}, fosite.WildcardScopeStrategy, nil, time.Hour)
+
+	token := peer.sign(t, peer.claims("ops-admin", "ops.admin", "openid"))
+	require.True(t, peers.Issued(token))
+
+	c, id, err := peers.Authenticate(token, []string{"hydra.clients"})
+	require.NoError(t, err)
+	assert.Equal(t, "token-ops-admin", id)
+	assert.Equal(t, warden.PeerSubject(peer.URL, "ops-admin"), c.Subject)
+	assert.Equal(t, peer.URL, c.Issuer)
+	assert.Equal(t, "ops-client", c.ClientID)
+	assert.Equal(t, []string{"hydra.clients", "hydra.policies"}, c.GrantedScopes)
+
+	_, _, err = peers.Authenticate(token, []string{"hydra.keys"})
+	assert.Error(t, err)
+
+	_, _, err = peers.Authenticate(token, []string{"openid"})
+	assert.Error(t, err, "Scopes which are not mapped must not be granted")
+
+	expired := peer.claims("ops-admin", "ops.admin")
+	expired["exp"] = time.Now().Add(-time.Minute).Unix()
+	_, _, err = peers.Authenticate(peer.sign(t, expired), nil)
+	assert.Error(t, err)
+
+	anonymous := peer.claims("", "ops.admin")
+	_, _, err = peers.Authenticate(peer.sign(t, anonymous), nil)
+	assert.Error(t, err)
+
+	untrusted := peer.claims("ops-admin", "ops.admin")
+	untrusted["iss"] = "https://untrusted.example.com/"
+	assert.False(t, peers.Issued(peer.sign(t, untrusted)))
+	_, _, err = peers.Authenticate(peer.sign(t, untrusted), nil)
+	assert.Error(t, err)
+
+	restricted := peer.claims("ops-admin", "ops.admin")
+	restricted["aud"] = []string{testPeerAudience, "https://billing.example.com"}
+	c, _, err = peers.Authenticate(peer.sign(t, restricted), []string{"hydra.clients"})
+	require.NoError(t, err, "Tokens with an audience restricted to several audiences must be accepted")
+	assert.Equal(t, "ops-client", c.ClientID)
+
+	foreign := peer.claims("ops-admin", "ops.admin")
+	foreign["aud"] = []string{"https://billing.example.com"}
+	_, _, err = peers.Authenticate(peer.sign(t, foreign), nil)
+	assert.Error(t, err, "Tokens issued for other audiences must be rejected")
+
+	unrestricted := peer.claims("ops-admin", "ops.admin")
+	delete(unrestricted, "aud")
+	_, _, err = peers.Authenticate(peer.sign(t, unrestricted), nil)
+	assert.Error(t, err, "Tokens without an audience must be rejected")
+
+	_, _, err = warden.NewPeerIssuers([]string{peer.URL}, "", peers.Scopes, fosite.WildcardScopeStrategy, nil, time.Hour).Authenticate(token, nil)
+	assert.Error(t, err, "Tokens must be rejected if no audience is configured")
+
+	assert.False(t, peers.Issued("opaque-token.signature"))
+	assert.Equal(t, 1, peer.fetches, "Keys must be cached")
+
+	impostor := newTestPeer(t)
+	defer impostor.Close()
+	_, _, err = peers.Authenticate(impostor.sign(t, peer.claims("ops-admin", "ops.admin")), nil)
+	assert.Error(t, err, "Tokens signed by keys the peer does not publish must be rejected")
+}
+
+func TestPeerIssuersRefreshKeysOnUnknownKeyID(t *testing.T) {
+	peer := newTestPeer(t)
+	defer peer.Close()
+
+	peers := warden.NewPeerIssuers([]string{peer.URL}, testPeerAudience, warden.PeerScopes{"ops.admin": {"hydra"}}, fosite.WildcardScopeStrategy, nil, time.Nanosecond)
+
+	_, _, err := peers.Authenticate(peer.sign(t, peer.claims("ops-admin", "ops.admin")), nil)
+	require.NoError(t, err)
+
+	peer.keyID = "public:ops-rotated"
+	_, _, err = peers.Authenticate(peer.sign(t, peer.claims("ops-admin", "ops.admin")), nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, peer.fetches)
+}
+
+func TestLocalWardenPeerTokens(t *testing.T) {
+	peer := newTestPeer(t)
+	defer peer.Close()
+
+	w := &warden.LocalWarden{
+		Warden: pkg.LadonWarden(map[string]ladon.Policy{
+			"1": &ladon.DefaultPolicy{
+				ID:        "1",
+				Subjects:  []string{warden.PeerSubject(peer.URL, "ops-admin")},
+				Resources: []string{"rn:hydra:clients"},
+				Actions:   []string{"get"},
+				Effect:    ladon.AllowAccess,
+			},
+		}),
+		Groups: &group.MemoryManager{Groups: map[string]group.Group{}},
+		L:      logrus.New(),
+		Peers:  warden.NewPeerIssuers([]string{peer.URL}, testPeerAudience, warden.PeerScopes{"ops.admin": {"hydra.clients"}}, fosite.WildcardScopeStrategy, nil, time.Hour),
+	}
+
+	c, err := w.TokenAllowed(context.Background(), peer.sign(t, peer.claims("ops-admin", "ops.admin")), &firewall.TokenAccessRequest{
+		Resource: "rn:hydra:clients",
+		Action:   "get",
+	}, "hydra.clients")
+	require.NoError(t, err)
+	assert.Equal(t, warden.PeerSubject(peer.URL, "ops-admin"), c.Subject)
+	assert.Equal(t, peer.URL, c.Issuer)
+
+	_, err = w.TokenAllowed(context.Background(), peer.sign(t, peer.claims("ops-admin", "ops.admin")), &firewall.TokenAccessRequest{
+		Resource: "rn:hydra:clients",
+		Action:   "delete",
+	}, "hydra.clients")
+	assert.Error(t, err, "Policies must still be evaluated for peer tokens")
+
+	_, err = w.TokenAllowed(context.Background(), peer.sign(t, peer.claims("peter", "ops.admin")), &firewall.TokenAccessRequest{
+		Resource: "rn:hydra:clients",
+		Action:   "get",
+	}, "hydra.clients")
+	assert.Error(t, err)
+}
@@ -0,0 +1,54 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package usage
+
+// swagger:parameters getPolicyUsageReport
+type swaggerGetReportParameters struct {
+	// The number of days a policy must not have matched any access request to be reported as unused. Defaults to 30.
+	// in: query
+	UnusedDays int `json:"unused_days"`
+}
+
+// swagger:parameters disablePolicies
+type swaggerDisablePoliciesParameters struct {
+	// in: body
+	Body DisableRequest
+}
+
+// A list of disabled policies
+// swagger:response listDisabledPoliciesResponse
+type swaggerListDisabledPoliciesResponse struct {
+	// in: body
+	// type: array
+	Body []DisabledPolicy
+}
+
+// swagger:parameters listDisabledPolicies
+type swaggerListDisabledPoliciesParameters struct {
+	// The maximum amount of disabled policies returned.
+	// in: query
+	Limit int `json:"limit"`
+
+	// The offset from where to start looking.
+	// in: query
+	Offset int `json:"offset"`
+}
+
+// swagger:parameters enablePolicy
+type swaggerEnablePolicyParameters struct {
+	// The id of the disabled policy.
+	// in: path
+	ID string `json:"id"`
+}
@@ -0,0 +1,482 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package usage
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/herodot"
+	"github.com/ory/hydra/firewall"
+	"github.com/ory/ladon"
+	"github.com/ory/pagination"
+	"github.com/pkg/errors"
+)
+
+const (
+	UsageHandlerPath          = "/warden/policies/usage"
+	DisableHandlerPath        = "/warden/policies/disable"
+	DisabledPolicyHandlerPath = "/warden/policies/disabled"
+)
+
+const (
+	UsageResource            = "warden:policies:usage"
+	DisabledPoliciesResource = "warden:policies:disabled"
+	DisabledPolicyResource   = "warden:policies:disabled:%s"
+	Scope                    = "hydra.policies"
+
+	// DefaultUnusedDays is the number of days a policy must not have matched to be considered unused if the
+	// request does not say otherwise.
+	DefaultUnusedDays = 30
+)
+
+type Handler struct {
+	Manager  Manager
+	Policies ladon.Manager
+	H        herodot.Writer
+	W        firewall.Firewall
+
+	ResourcePrefix string
+}
+
+// Report lists the usage of all policies and the policies which did not match any access request for a number of
+// days.
+//
+// swagger:model policyUsageReport
+type Report struct {
+	// Since is the start of the period the report covers.
+	Since time.Time `json:"since"`
+
+	// Unused is the usage of the policies which were tracked before Since and did not match any access request since
+	// then. Policies tracked for a shorter period are never reported as unused.
+	Unused []Usage `json:"unused"`
+
+	// Policies is the usage of all policies.
+	Policies []Usage `json:"policies"`
+}
+
+// DisableRequest selects the policies to disable.
+//
+// swagger:model disablePoliciesRequest
+type DisableRequest struct {
+	// Policies are the IDs of the policies to disable.
+	Policies []string `json:"policies"`
+
+	// UnusedDays is the number of days a policy must not have matched any access request to be disabled. Policies
+	// which matched or were tracked for a shorter period are skipped. Defaults to 30.
+	UnusedDays int `json:"unused_days"`
+
+	// DryRun reports which policies would be disabled without disabling them.
+	DryRun bool `json:"dry_run"`
+}
+
+// DisableResult lists the policies which were disabled and the ones which were skipped.
+//
+// swagger:model disablePoliciesResult
+type DisableResult struct {
+	// Disabled are the IDs of the policies which were disabled, or would be if the request is a dry run.
+	Disabled []string `json:"disabled"`
+
+	// Skipped are the IDs of the policies which were not disabled because they do not exist or are not unused.
+	Skipped []string `json:"skipped"`
+}
+
+func (h *Handler) PrefixResource(resource string) string {
+	if h.ResourcePrefix == "" {
+		h.ResourcePrefix = "rn:hydra"
+	}
+
+	if h.ResourcePrefix[len(h.ResourcePrefix)-1] == ':' {
+		h.ResourcePrefix = h.ResourcePrefix[:len(h.ResourcePrefix)-1]
+	}
+
+	return h.ResourcePrefix + ":" + resource
+}
+
+func (h *Handler) SetRoutes(r *httprouter.Router) {
+	r.GET(UsageHandlerPath, h.GetReport)
+	r.POST(DisableHandlerPath, h.DisablePolicies)
+	r.GET(DisabledPolicyHandlerPath, h.ListDisabledPolicies)
+	r.POST(DisabledPolicyHandlerPath+"/:id/enable", h.EnablePolicy)
+}
+
+// swagger:route GET /warden/policies/usage warden getPolicyUsageReport
+//
+// Get a report of unused policies
+//
+// Returns how often and when each policy last decided an access request, and which policies did not decide any
+// access request for unused_days days (defaults to 30). Policies are tracked from the first time they match or are
+// included in a report, so a policy is only reported as unused once it has been tracked for unused_days days.
+//
+// The subject making the request needs to be assigned to a policy containing:
+//
+//  ```
+//  {
+//    "resources": ["rn:hydra:warden:policies:usage"],
+//    "actions": ["get"],
+//    "effect": "allow"
+//  }
+//  ```
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Security:
+//       oauth2: hydra.policies
+//
+//     Responses:
+//       200: policyUsageReport
+//       400: genericError
+//       401: genericError
+//       403: genericError
+//       500: genericError
+func (h *Handler) GetReport(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var ctx = r.Context()
+
+	if _, err := h.W.TokenAllowed(ctx, h.W.TokenFromRequest(r), &firewall.TokenAccessRequest{
+		Resource: h.PrefixResource(UsageResource),
+		Action:   "get",
+	}, Scope); err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	days := DefaultUnusedDays
+	if v := r.URL.Query().Get("unused_days"); v != "" {
+		var err error
+		if days, err = strconv.Atoi(v); err != nil || days < 1 {
+			h.H.WriteErrorCode(w, r, http.StatusBadRequest, errors.Errorf("unused_days must be a positive number but got %s", v))
+			return
+		}
+	}
+
+	now := time.Now().UTC()
+	_, usage, err := h.usage(now)
+	if err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	since := now.Add(-time.Hour * 24 * time.Duration(days))
+	report := &Report{Since: since, Unused: []Usage{}, Policies: []Usage{}}
+	for _, u := range usage {
+		report.Policies = append(report.Policies, u)
+		if u.UnusedSince(since) {
+			report.Unused = append(report.Unused, u)
+		}
+	}
+
+	sort.Slice(report.Policies, func(i, j int) bool { return report.Policies[i].PolicyID < report.Policies[j].PolicyID })
+	sort.Slice(report.Unused, func(i, j int) bool { return report.Unused[i].PolicyID < report.Unused[j].PolicyID })
+	h.H.Write(w, r, report)
+}
+
+// swagger:route POST /warden/policies/disable warden disablePolicies
+//
+// Disable unused policies
+//
+// Removes the given policies from the policy storage if they did not decide any access request for unused_days days
+// and keeps them as disabled policies, which can be enabled again. Policies which are not unused are skipped, so it
+// is safe to pass the unused policies of an earlier report. Set dry_run to find out which policies would be disabled.
+//
+// The subject making the request needs to be assigned to a policy containing:
+//
+//  ```
+//  {
+//    "resources": ["rn:hydra:warden:policies:disabled"],
+//    "actions": ["create"],
+//    "effect": "allow"
+//  }
+//  ```
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Security:
+//       oauth2: hydra.policies
+//
+//     Responses:
+//       200: disablePoliciesResult
+//       400: genericError
+//       401: genericError
+//       403: genericError
+//       500: genericError
+func (h *Handler) DisablePolicies(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var ctx = r.Context()
+	var req DisableRequest
+
+	if _, err := h.W.TokenAllowed(ctx, h.W.TokenFromRequest(r), &firewall.TokenAccessRequest{
+		Resource: h.PrefixResource(DisabledPoliciesResource),
+		Action:   "create",
+	}, Scope); err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, errors.WithStack(err))
+		return
+	}
+
+	if req.UnusedDays == 0 {
+		req.UnusedDays = DefaultUnusedDays
+	} else if req.UnusedDays < 0 {
+		h.H.WriteErrorCode(w, r, http.StatusBadRequest, errors.Errorf("unused_days must be a positive number but got %d", req.UnusedDays))
+		return
+	}
+
+	now := time.Now().UTC()
+	policies, usage, err := h.usage(now)
+	if err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	byID := make(map[string]ladon.Policy, len(policies))
+	for _, p := range policies {
+		byID[p.GetID()] = p
+	}
+
+	since := now.Add(-time.Hour * 24 * time.Duration(req.UnusedDays))
+	result := &DisableResult{Disabled: []string{}, Skipped: []string{}}
+	for _, id := range req.Policies {
+		p, ok := byID[id]
+		u, tracked := usage[id]
+		if !ok || !tracked || !u.UnusedSince(since) {
+			result.Skipped = append(result.Skipped, id)
+			continue
+		}
+
+		if !req.DryRun {
+			if err := h.disable(p, &u, now); err != nil {
+				h.H.WriteError(w, r, err)
+				return
+			}
+		}
+
+		delete(byID, id)
+		result.Disabled = append(result.Disabled, id)
+	}
+
+	h.H.Write(w, r, result)
+}
+
+// swagger:route GET /warden/policies/disabled warden listDisabledPolicies
+//
+// List disabled policies
+//
+// Returns the policies disabled by /warden/policies/disable, most recently disabled first.
+//
+// The subject making the request needs to be assigned to a policy containing:
+//
+//  ```
+//  {
+//    "resources": ["rn:hydra:warden:policies:disabled"],
+//    "actions": ["list"],
+//    "effect": "allow"
+//  }
+//  ```
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Security:
+//       oauth2: hydra.policies
+//
+//     Responses:
+//       200: listDisabledPoliciesResponse
+//       401: genericError
+//       403: genericError
+//       500: genericError
+func (h *Handler) ListDisabledPolicies(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var ctx = r.Context()
+
+	if _, err := h.W.TokenAllowed(ctx, h.W.TokenFromRequest(r), &firewall.TokenAccessRequest{
+		Resource: h.PrefixResource(DisabledPoliciesResource),
+		Action:   "list",
+	}, Scope); err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	limit, offset := pagination.Parse(r, 100, 0, 500)
+	policies, err := h.Manager.GetDisabledPolicies(limit, offset)
+	if err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	h.H.Write(w, r, policies)
+}
+
+// swagger:route POST /warden/policies/disabled/{id}/enable warden enablePolicy
+//
+// Enable a disabled policy
+//
+// Restores a policy disabled by /warden/policies/disable to the policy storage, unchanged.
+//
+// The subject making the request needs to be assigned to a policy containing:
+//
+//  ```
+//  {
+//    "resources": ["rn:hydra:warden:policies:disabled:<id>"],
+//    "actions": ["enable"],
+//    "effect": "allow"
+//  }
+//  ```
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Security:
+//       oauth2: hydra.policies
+//
+//     Responses:
+//       200: policy
+//       401: genericError
+//       403: genericError
+//       404: genericError
+//       500: genericError
+func (h *Handler) EnablePolicy(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var ctx = r.Context()
+	var id = ps.ByName("id")
+
+	if _, err := h.W.TokenAllowed(ctx, h.W.TokenFromRequest(r), &firewall.TokenAccessRequest{
+		Resource: fmt.Sprintf(h.PrefixResource(DisabledPolicyResource), id),
+		Action:   "enable",
+	}, Scope); err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	disabled, err := h.Manager.GetDisabledPolicy(id)
+	if err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	if err := h.Policies.Create(disabled.Policy); err != nil {
+		h.H.WriteError(w, r, errors.WithStack(err))
+		return
+	}
+
+	if err := h.Manager.DeleteDisabledPolicy(id); err != nil {
+		h.H.WriteError(w, r, err)
+		return
+	}
+
+	h.H.Write(w, r, disabled.Policy)
+}
+
+// usage returns all policies and their usage. Policies which are not tracked yet are tracked from now on, the usage
+// of policies which no longer exist is forgotten.
+func (h *Handler) usage(now time.Time) ([]ladon.Policy, map[string]Usage, error) {
+	var policies []ladon.Policy
+	for offset := int64(0); ; offset += 500 {
+		page, err := h.Policies.GetAll(500, offset)
+		if err != nil {
+			return nil, nil, errors.WithStack(err)
+		}
+		policies = append(policies, page...)
+		if len(page) < 500 {
+			break
+		}
+	}
+
+	ids := make([]string, len(policies))
+	exists := make(map[string]bool, len(policies))
+	for k, p := range policies {
+		ids[k] = p.GetID()
+		exists[p.GetID()] = true
+	}
+
+	if err := h.Manager.TrackPolicies(ids, now); err != nil {
+		return nil, nil, err
+	}
+
+	usage, err := h.Manager.GetPolicyUsage()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var stale []string
+	for id := range usage {
+		if !exists[id] {
+			stale = append(stale, id)
+			delete(usage, id)
+		}
+	}
+
+	if len(stale) > 0 {
+		if err := h.Manager.ForgetPolicies(stale); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return policies, usage, nil
+}
+
+// disable stores the policy as a disabled policy before deleting it, so that it can not get lost.
+func (h *Handler) disable(p ladon.Policy, u *Usage, now time.Time) error {
+	encoded, err := json.Marshal(p)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	policy := &ladon.DefaultPolicy{Conditions: ladon.Conditions{}}
+	if err := json.Unmarshal(encoded, policy); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := h.Manager.AddDisabledPolicy(&DisabledPolicy{
+		ID:            p.GetID(),
+		Policy:        policy,
+		LastMatchedAt: u.LastMatchedAt,
+		DisabledAt:    now,
+	}); err != nil {
+		return err
+	}
+
+	if err := h.Policies.Delete(p.GetID()); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return h.Manager.ForgetPolicies([]string{p.GetID()})
+}
@@ -0,0 +1,150 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package usage
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ory/hydra/pkg"
+	"github.com/pkg/errors"
+)
+
+type MemoryManager struct {
+	Usage    map[string]Usage
+	Disabled map[string]DisabledPolicy
+	sync.RWMutex
+}
+
+func NewMemoryManager() *MemoryManager {
+	return &MemoryManager{
+		Usage:    map[string]Usage{},
+		Disabled: map[string]DisabledPolicy{},
+	}
+}
+
+func (m *MemoryManager) AddPolicyMatches(matches map[string]int64, at time.Time) error {
+	m.Lock()
+	defer m.Unlock()
+
+	at = at.UTC()
+	for id, n := range matches {
+		u, ok := m.Usage[id]
+		if !ok {
+			u = Usage{PolicyID: id, TrackedSince: at}
+		}
+
+		u.Matches += n
+		u.LastMatchedAt = &at
+		m.Usage[id] = u
+	}
+	return nil
+}
+
+func (m *MemoryManager) TrackPolicies(ids []string, at time.Time) error {
+	m.Lock()
+	defer m.Unlock()
+
+	for _, id := range ids {
+		if _, ok := m.Usage[id]; !ok {
+			m.Usage[id] = Usage{PolicyID: id, TrackedSince: at.UTC()}
+		}
+	}
+	return nil
+}
+
+func (m *MemoryManager) ForgetPolicies(ids []string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	for _, id := range ids {
+		delete(m.Usage, id)
+	}
+	return nil
+}
+
+func (m *MemoryManager) GetPolicyUsage() (map[string]Usage, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	result := make(map[string]Usage, len(m.Usage))
+	for id, u := range m.Usage {
+		result[id] = u
+	}
+	return result, nil
+}
+
+func (m *MemoryManager) AddDisabledPolicy(p *DisabledPolicy) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if _, ok := m.Disabled[p.ID]; ok {
+		return errors.Errorf("Policy %s is already disabled", p.ID)
+	}
+
+	m.Disabled[p.ID] = *p
+	return nil
+}
+
+func (m *MemoryManager) GetDisabledPolicy(id string) (*DisabledPolicy, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	p, ok := m.Disabled[id]
+	if !ok {
+		return nil, errors.WithStack(pkg.ErrNotFound)
+	}
+	return &p, nil
+}
+
+func (m *MemoryManager) GetDisabledPolicies(limit, offset int) ([]DisabledPolicy, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	result := make([]DisabledPolicy, 0, len(m.Disabled))
+	for _, p := range m.Disabled {
+		result = append(result, p)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].DisabledAt.Equal(result[j].DisabledAt) {
+			return result[i].ID < result[j].ID
+		}
+		return result[i].DisabledAt.After(result[j].DisabledAt)
+	})
+
+	if offset >= len(result) {
+		return []DisabledPolicy{}, nil
+	}
+
+	result = result[offset:]
+	if limit < len(result) {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+func (m *MemoryManager) DeleteDisabledPolicy(id string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if _, ok := m.Disabled[id]; !ok {
+		return errors.WithStack(pkg.ErrNotFound)
+	}
+
+	delete(m.Disabled, id)
+	return nil
+}
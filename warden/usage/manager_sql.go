@@ -0,0 +1,239 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package usage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/ory/hydra/pkg"
+	"github.com/ory/ladon"
+	"github.com/pkg/errors"
+	"github.com/rubenv/sql-migrate"
+)
+
+var migrations = &migrate.MemoryMigrationSource{
+	Migrations: []*migrate.Migration{
+		{
+			Id: "1",
+			Up: []string{`CREATE TABLE IF NOT EXISTS hydra_warden_policy_usage (
+	policy_id		varchar(255) NOT NULL PRIMARY KEY,
+	matches			bigint NOT NULL DEFAULT 0,
+	last_matched_at	timestamp NULL,
+	tracked_since	timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP
+)`, `CREATE TABLE IF NOT EXISTS hydra_warden_disabled_policy (
+	id				varchar(255) NOT NULL PRIMARY KEY,
+	policy			text NOT NULL,
+	last_matched_at	timestamp NULL,
+	disabled_at		timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP
+)`, `CREATE INDEX hydra_warden_disabled_policy_disabled_at_idx ON hydra_warden_disabled_policy (disabled_at)`},
+			Down: []string{
+				"DROP TABLE hydra_warden_policy_usage",
+				"DROP TABLE hydra_warden_disabled_policy",
+			},
+		},
+	},
+}
+
+type sqlUsage struct {
+	PolicyID      string     `db:"policy_id"`
+	Matches       int64      `db:"matches"`
+	LastMatchedAt *time.Time `db:"last_matched_at"`
+	TrackedSince  time.Time  `db:"tracked_since"`
+}
+
+type sqlDisabledPolicy struct {
+	ID            string     `db:"id"`
+	Policy        string     `db:"policy"`
+	LastMatchedAt *time.Time `db:"last_matched_at"`
+	DisabledAt    time.Time  `db:"disabled_at"`
+}
+
+func (d *sqlDisabledPolicy) toDisabledPolicy() (*DisabledPolicy, error) {
+	p := &ladon.DefaultPolicy{Conditions: ladon.Conditions{}}
+	if err := json.Unmarshal([]byte(d.Policy), p); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &DisabledPolicy{
+		ID:            d.ID,
+		Policy:        p,
+		LastMatchedAt: utc(d.LastMatchedAt),
+		DisabledAt:    d.DisabledAt.UTC(),
+	}, nil
+}
+
+func utc(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	u := t.UTC()
+	return &u
+}
+
+type SQLManager struct {
+	DB *sqlx.DB
+}
+
+// Migrations returns the SQL migrations embedded in the binary.
+func (m *SQLManager) Migrations() *migrate.MemoryMigrationSource {
+	return migrations
+}
+
+func (m *SQLManager) CreateSchemas() (int, error) {
+	migrate.SetTable("hydra_warden_policy_usage_migration")
+	n, err := migrate.Exec(m.DB.DB, m.DB.DriverName(), migrations, migrate.Up)
+	if err != nil {
+		return 0, errors.Wrapf(err, "Could not migrate sql schema, applied %d migrations", n)
+	}
+	return n, nil
+}
+
+func (m *SQLManager) AddPolicyMatches(matches map[string]int64, at time.Time) error {
+	at = at.UTC()
+	return pkg.SQLTransaction(m.DB, func(tx *sqlx.Tx) error {
+		for id, n := range matches {
+			result, err := tx.Exec(m.DB.Rebind("UPDATE hydra_warden_policy_usage SET matches=matches+?, last_matched_at=? WHERE policy_id=?"), n, at, id)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+
+			if rows, err := result.RowsAffected(); err != nil {
+				return errors.WithStack(err)
+			} else if rows > 0 {
+				continue
+			}
+
+			if _, err := tx.Exec(m.DB.Rebind("INSERT INTO hydra_warden_policy_usage (policy_id, matches, last_matched_at, tracked_since) VALUES (?, ?, ?, ?)"), id, n, at, at); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+		return nil
+	})
+}
+
+func (m *SQLManager) TrackPolicies(ids []string, at time.Time) error {
+	return pkg.SQLTransaction(m.DB, func(tx *sqlx.Tx) error {
+		var tracked []string
+		if err := tx.Select(&tracked, "SELECT policy_id FROM hydra_warden_policy_usage"); err != nil {
+			return errors.WithStack(err)
+		}
+
+		known := make(map[string]bool, len(tracked))
+		for _, id := range tracked {
+			known[id] = true
+		}
+
+		for _, id := range ids {
+			if known[id] {
+				continue
+			}
+
+			if _, err := tx.Exec(m.DB.Rebind("INSERT INTO hydra_warden_policy_usage (policy_id, matches, tracked_since) VALUES (?, 0, ?)"), id, at.UTC()); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+		return nil
+	})
+}
+
+func (m *SQLManager) ForgetPolicies(ids []string) error {
+	return pkg.SQLTransaction(m.DB, func(tx *sqlx.Tx) error {
+		for _, id := range ids {
+			if _, err := tx.Exec(m.DB.Rebind("DELETE FROM hydra_warden_policy_usage WHERE policy_id=?"), id); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+		return nil
+	})
+}
+
+func (m *SQLManager) GetPolicyUsage() (map[string]Usage, error) {
+	var ds []sqlUsage
+	if err := m.DB.Select(&ds, "SELECT policy_id, matches, last_matched_at, tracked_since FROM hydra_warden_policy_usage"); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	result := make(map[string]Usage, len(ds))
+	for _, d := range ds {
+		result[d.PolicyID] = Usage{
+			PolicyID:      d.PolicyID,
+			Matches:       d.Matches,
+			LastMatchedAt: utc(d.LastMatchedAt),
+			TrackedSince:  d.TrackedSince.UTC(),
+		}
+	}
+	return result, nil
+}
+
+func (m *SQLManager) AddDisabledPolicy(p *DisabledPolicy) error {
+	policy, err := json.Marshal(p.Policy)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if _, err := m.DB.NamedExec(`INSERT INTO hydra_warden_disabled_policy (id, policy, last_matched_at, disabled_at) VALUES (:id, :policy, :last_matched_at, :disabled_at)`, &sqlDisabledPolicy{
+		ID:            p.ID,
+		Policy:        string(policy),
+		LastMatchedAt: utc(p.LastMatchedAt),
+		DisabledAt:    p.DisabledAt.UTC(),
+	}); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func (m *SQLManager) GetDisabledPolicy(id string) (*DisabledPolicy, error) {
+	var d sqlDisabledPolicy
+	if err := m.DB.Get(&d, m.DB.Rebind("SELECT * FROM hydra_warden_disabled_policy WHERE id=?"), id); err == sql.ErrNoRows {
+		return nil, errors.WithStack(pkg.ErrNotFound)
+	} else if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return d.toDisabledPolicy()
+}
+
+func (m *SQLManager) GetDisabledPolicies(limit, offset int) ([]DisabledPolicy, error) {
+	var ds []sqlDisabledPolicy
+	if err := m.DB.Select(&ds, m.DB.Rebind("SELECT * FROM hydra_warden_disabled_policy ORDER BY disabled_at DESC, id LIMIT ? OFFSET ?"), limit, offset); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	result := make([]DisabledPolicy, len(ds))
+	for k, d := range ds {
+		p, err := d.toDisabledPolicy()
+		if err != nil {
+			return nil, err
+		}
+		result[k] = *p
+	}
+	return result, nil
+}
+
+func (m *SQLManager) DeleteDisabledPolicy(id string) error {
+	result, err := m.DB.Exec(m.DB.Rebind("DELETE FROM hydra_warden_disabled_policy WHERE id=?"), id)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if rows, err := result.RowsAffected(); err != nil {
+		return errors.WithStack(err)
+	} else if rows == 0 {
+		return errors.WithStack(pkg.ErrNotFound)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package usage_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ory/hydra/pkg"
+	. "github.com/ory/hydra/warden/usage"
+	"github.com/ory/ladon"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryManager(t *testing.T) {
+	m := NewMemoryManager()
+	now := time.Now().UTC().Round(time.Second)
+
+	require.NoError(t, m.TrackPolicies([]string{"foo", "bar"}, now.Add(-time.Hour*48)))
+	require.NoError(t, m.AddPolicyMatches(map[string]int64{"foo": 2, "baz": 1}, now))
+	require.NoError(t, m.AddPolicyMatches(map[string]int64{"foo": 1}, now))
+	require.NoError(t, m.TrackPolicies([]string{"foo", "bar", "qux"}, now))
+
+	usage, err := m.GetPolicyUsage()
+	require.NoError(t, err)
+	require.Len(t, usage, 4)
+	assert.EqualValues(t, 3, usage["foo"].Matches)
+	assert.Equal(t, now.Add(-time.Hour*48), usage["foo"].TrackedSince)
+	assert.Equal(t, now, *usage["foo"].LastMatchedAt)
+	assert.Equal(t, now, usage["baz"].TrackedSince)
+	assert.Nil(t, usage["bar"].LastMatchedAt)
+
+	since := now.Add(-time.Hour * 24)
+	assert.False(t, usage["foo"].UnusedSince(since))
+	assert.True(t, usage["bar"].UnusedSince(since))
+	assert.False(t, usage["qux"].UnusedSince(since), "policies tracked for a shorter period are not unused")
+
+	require.NoError(t, m.ForgetPolicies([]string{"baz", "qux"}))
+	usage, err = m.GetPolicyUsage()
+	require.NoError(t, err)
+	assert.Len(t, usage, 2)
+
+	require.NoError(t, m.AddDisabledPolicy(&DisabledPolicy{ID: "foo", Policy: &ladon.DefaultPolicy{ID: "foo"}, DisabledAt: now.Add(-time.Hour)}))
+	require.NoError(t, m.AddDisabledPolicy(&DisabledPolicy{ID: "bar", Policy: &ladon.DefaultPolicy{ID: "bar"}, DisabledAt: now}))
+	assert.Error(t, m.AddDisabledPolicy(&DisabledPolicy{ID: "bar", Policy: &ladon.DefaultPolicy{ID: "bar"}, DisabledAt: now}))
+
+	disabled, err := m.GetDisabledPolicies(10, 0)
+	require.NoError(t, err)
+	require.Len(t, disabled, 2)
+	assert.Equal(t, "bar", disabled[0].ID)
+	assert.Equal(t, "foo", disabled[1].ID)
+
+	p, err := m.GetDisabledPolicy("foo")
+	require.NoError(t, err)
+	assert.Equal(t, "foo", p.Policy.ID)
+
+	require.NoError(t, m.DeleteDisabledPolicy("foo"))
+	_, err = m.GetDisabledPolicy("foo")
+	assert.Equal(t, pkg.ErrNotFound, errors.Cause(err))
+	assert.Equal(t, pkg.ErrNotFound, errors.Cause(m.DeleteDisabledPolicy("foo")))
+}
+
+type failingManager struct {
+	*MemoryManager
+	fail bool
+}
+
+func (m *failingManager) AddPolicyMatches(matches map[string]int64, at time.Time) error {
+	if m.fail {
+		return errors.New("database is down")
+	}
+	return m.MemoryManager.AddPolicyMatches(matches, at)
+}
+
+func TestTracker(t *testing.T) {
+	m := &failingManager{MemoryManager: NewMemoryManager()}
+	tr := &Tracker{Manager: m, L: logrus.New()}
+
+	req := &ladon.Request{Subject: "alice", Resource: "foo", Action: "bar"}
+	tr.LogGrantedAccessRequest(req, ladon.Policies{}, ladon.Policies{&ladon.DefaultPolicy{ID: "allow-foo"}})
+	tr.LogRejectedAccessRequest(req, ladon.Policies{}, ladon.Policies{&ladon.DefaultPolicy{ID: "deny-foo"}})
+	tr.LogRejectedAccessRequest(req, ladon.Policies{}, ladon.Policies{})
+
+	m.fail = true
+	assert.Error(t, tr.Flush())
+
+	m.fail = false
+	tr.LogGrantedAccessRequest(req, ladon.Policies{}, ladon.Policies{&ladon.DefaultPolicy{ID: "allow-foo"}})
+	require.NoError(t, tr.Flush())
+
+	usage, err := m.GetPolicyUsage()
+	require.NoError(t, err)
+	require.Len(t, usage, 2)
+	assert.EqualValues(t, 2, usage["allow-foo"].Matches)
+	assert.EqualValues(t, 1, usage["deny-foo"].Matches)
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		tr.Run(stop)
+		close(done)
+	}()
+
+	tr.LogGrantedAccessRequest(req, ladon.Policies{}, ladon.Policies{&ladon.DefaultPolicy{ID: "allow-foo"}})
+	close(stop)
+	<-done
+
+	usage, err = m.GetPolicyUsage()
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, usage["allow-foo"].Matches)
+}
@@ -0,0 +1,111 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package usage
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ory/ladon"
+	"github.com/sirupsen/logrus"
+)
+
+// Tracker implements ladon.AuditLogger and counts how often policies decide access requests. The counts are kept in
+// memory and written to the Manager by Run, so that evaluating an access request does not wait for the database.
+//
+// Access requests answered from the warden cache are not evaluated by ladon and therefore not counted.
+type Tracker struct {
+	Manager Manager
+	L       logrus.FieldLogger
+
+	// Interval is how often counts are written to the Manager. Defaults to one minute.
+	Interval time.Duration
+
+	matches map[string]int64
+	sync.Mutex
+}
+
+func (t *Tracker) LogRejectedAccessRequest(request *ladon.Request, pool ladon.Policies, deciders ladon.Policies) {
+	t.count(deciders)
+}
+
+func (t *Tracker) LogGrantedAccessRequest(request *ladon.Request, pool ladon.Policies, deciders ladon.Policies) {
+	t.count(deciders)
+}
+
+func (t *Tracker) count(deciders ladon.Policies) {
+	if len(deciders) == 0 {
+		return
+	}
+
+	t.Lock()
+	defer t.Unlock()
+
+	if t.matches == nil {
+		t.matches = map[string]int64{}
+	}
+	for _, p := range deciders {
+		t.matches[p.GetID()]++
+	}
+}
+
+// Flush writes the counts collected since the last flush to the Manager. If that fails, the counts are kept and
+// written with the next flush.
+func (t *Tracker) Flush() error {
+	t.Lock()
+	matches := t.matches
+	t.matches = nil
+	t.Unlock()
+
+	if len(matches) == 0 {
+		return nil
+	}
+
+	if err := t.Manager.AddPolicyMatches(matches, time.Now().UTC()); err != nil {
+		t.Lock()
+		if t.matches == nil {
+			t.matches = map[string]int64{}
+		}
+		for id, n := range matches {
+			t.matches[id] += n
+		}
+		t.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Run flushes the counts every Interval until stop is closed, then flushes them one last time.
+func (t *Tracker) Run(stop <-chan struct{}) {
+	interval := t.Interval
+	if interval == 0 {
+		interval = time.Minute
+	}
+
+	for {
+		select {
+		case <-stop:
+			if err := t.Flush(); err != nil {
+				t.L.WithError(err).Errorln("Could not store policy usage")
+			}
+			return
+		case <-time.After(interval):
+		}
+
+		if err := t.Flush(); err != nil {
+			t.L.WithError(err).Errorln("Could not store policy usage")
+		}
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright © 2017 Aeneas Rekkas <aeneas+oss@aeneas.io>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package usage
+
+import (
+	"time"
+
+	"github.com/ory/ladon"
+)
+
+// Usage describes how often and when a policy matched an access request.
+//
+// swagger:model policyUsage
+type Usage struct {
+	// PolicyID is the ID of the policy.
+	PolicyID string `json:"policy_id"`
+
+	// Matches is how often the policy decided an access request since its usage is tracked.
+	Matches int64 `json:"matches"`
+
+	// LastMatchedAt is the time the policy last decided an access request, if ever.
+	LastMatchedAt *time.Time `json:"last_matched_at,omitempty"`
+
+	// TrackedSince is the time the usage of the policy is tracked since, either the first time it matched or the
+	// first time it was included in a report.
+	TrackedSince time.Time `json:"tracked_since"`
+}
+
+// UnusedSince returns true if the policy was tracked before since and did not match an access request since then.
+func (u *Usage) UnusedSince(since time.Time) bool {
+	if u.TrackedSince.After(since) {
+		return false
+	}
+	return u.LastMatchedAt == nil || u.LastMatchedAt.Before(since)
+}
+
+// DisabledPolicy is a policy which was removed from the policy storage because it was unused. It can be enabled
+// again, which restores it unchanged.
+//
+// swagger:model disabledPolicy
+type DisabledPolicy struct {
+	// ID is the ID of the policy.
+	ID string `json:"id"`
+
+	// Policy is the policy as it was stored when it was disabled.
+	Policy *ladon.DefaultPolicy `json:"policy"`
+
+	// LastMatchedAt is the time the policy last decided an access request before it was disabled, if ever.
+	LastMatchedAt *time.Time `json:"last_matched_at,omitempty"`
+
+	// DisabledAt is the time the policy was disabled.
+	DisabledAt time.Time `json:"disabled_at"`
+}
+
+type Manager interface {
+	// AddPolicyMatches adds how often each policy matched and sets their last match to at. Policies which are not
+	// tracked yet are tracked since at.
+	AddPolicyMatches(matches map[string]int64, at time.Time) error
+
+	// TrackPolicies starts tracking the usage of the policies which are not tracked yet at the given time.
+	TrackPolicies(ids []string, at time.Time) error
+
+	// ForgetPolicies stops tracking the usage of the policies, for example because they were deleted.
+	ForgetPolicies(ids []string) error
+
+	// GetPolicyUsage returns the usage of all tracked policies by policy ID.
+	GetPolicyUsage() (map[string]Usage, error)
+
+	AddDisabledPolicy(p *DisabledPolicy) error
+	GetDisabledPolicy(id string) (*DisabledPolicy, error)
+
+	// GetDisabledPolicies returns disabled policies, most recently disabled first.
+	GetDisabledPolicies(limit, offset int) ([]DisabledPolicy, error)
+	DeleteDisabledPolicy(id string) error
+}
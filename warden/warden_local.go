@@ -22,8 +22,12 @@ import (
 	"github.com/ory/fosite"
 	"github.com/ory/hydra/firewall"
 	"github.com/ory/hydra/oauth2"
+	"github.com/ory/hydra/pkg"
+	"github.com/ory/hydra/warden/decision"
+	"github.com/ory/hydra/warden/elevation"
 	"github.com/ory/hydra/warden/group"
 	"github.com/ory/ladon"
+	"github.com/pborman/uuid"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
@@ -36,6 +40,33 @@ type LocalWarden struct {
 	AccessTokenLifespan time.Duration
 	Issuer              string
 	L                   logrus.FieldLogger
+
+	// Decisions, if set, records access request decisions. It must also be set as the AuditLogger of Warden.
+	Decisions *decision.Recorder
+
+	// Cache, if set, caches granted token access requests. Cache hits are not recorded by Decisions.
+	Cache *DecisionCache
+
+	// Subjects, if set, pseudonymizes subjects before they are logged.
+	Subjects *pkg.Pseudonymizer
+
+	// DecisionObserved, if set, is called with the result and duration of every policy decision, including the
+	// lookup of the subject's groups. Cache hits are not observed.
+	DecisionObserved func(allowed bool, duration time.Duration)
+
+	// ActionGroups, if set, defines action groups which can be used in policies and access requests.
+	ActionGroups ActionGroups
+
+	// Peers, if set, accepts access tokens issued by other ORY Hydra instances.
+	Peers *PeerIssuers
+
+	// Elevations, if set, allows access requests denied by the policies if an approved elevation of the subject
+	// allows them, and accepts access tokens lacking scopes if an approved elevation grants them. Requests forcefully
+	// denied by a policy are never allowed. Access granted by elevations is not cached.
+	Elevations elevation.Manager
+
+	// ScopeStrategy matches the scopes of elevations. Defaults to fosite.WildcardScopeStrategy.
+	ScopeStrategy fosite.ScopeStrategy
 }
 
 func (w *LocalWarden) TokenFromRequest(r *http.Request) string {
@@ -43,30 +74,57 @@ func (w *LocalWarden) TokenFromRequest(r *http.Request) string {
 }
 
 func (w *LocalWarden) IsAllowed(ctx context.Context, a *firewall.AccessRequest) error {
-	if err := w.isAllowed(ctx, &ladon.Request{
+	elevated, err := w.isAllowed(ctx, &ladon.Request{
 		Resource: a.Resource,
 		Action:   a.Action,
 		Subject:  a.Subject,
 		Context:  a.Context,
-	}); err != nil {
+	})
+	if err != nil {
 		w.L.WithFields(logrus.Fields{
-			"subject": a.Subject,
-			"request": a,
+			"subject": w.Subjects.Pseudonymize(a.Subject),
+			"request": w.loggableAccessRequest(a),
 			"reason":  "The policy decision point denied the request",
 		}).WithError(err).Infof("Access denied")
 		return err
 	}
 
-	w.L.WithFields(logrus.Fields{
-		"subject": a.Subject,
-		"request": a,
+	w.L.WithFields(withElevations(logrus.Fields{
+		"subject": w.Subjects.Pseudonymize(a.Subject),
+		"request": w.loggableAccessRequest(a),
 		"reason":  "The policy decision point allowed the request",
-	}).Infof("Access allowed")
+	}, elevated)).Infof("Access allowed")
 	return nil
 }
 
 func (w *LocalWarden) TokenAllowed(ctx context.Context, token string, a *firewall.TokenAccessRequest, scopes ...string) (*firewall.Context, error) {
+	var key string
+	if w.Cache != nil {
+		var err error
+		if key, err = decisionCacheKey(token, a, scopes); err != nil {
+			return nil, err
+		}
+
+		if c, ok := w.Cache.Get(key); ok {
+			w.L.WithFields(logrus.Fields{
+				"subject":   w.Subjects.Pseudonymize(c.Subject),
+				"client_id": c.ClientID,
+				"request":   a,
+				"result":    w.loggableContext(c),
+			}).Debugf("Access granted from cache")
+			return c, nil
+		}
+	}
+
+	if w.Peers != nil && w.Peers.Issued(token) {
+		return w.peerTokenAllowed(ctx, token, key, a, scopes)
+	}
+
+	var elevated []elevation.Elevation
 	var auth, err = w.OAuth2.IntrospectToken(ctx, token, fosite.AccessToken, oauth2.NewSession(""), scopes...)
+	if errors.Cause(err) == fosite.ErrInvalidScope && w.Elevations != nil {
+		auth, elevated, err = w.introspectElevated(ctx, token, scopes)
+	}
 	if err != nil {
 		w.L.WithFields(logrus.Fields{
 			"request": a,
@@ -77,15 +135,16 @@ func (w *LocalWarden) TokenAllowed(ctx context.Context, token string, a *firewal
 	}
 
 	session := auth.GetSession()
-	if err := w.isAllowed(ctx, &ladon.Request{
+	policyElevated, err := w.isAllowed(ctx, &ladon.Request{
 		Resource: a.Resource,
 		Action:   a.Action,
 		Subject:  session.GetSubject(),
 		Context:  a.Context,
-	}); err != nil {
+	})
+	if err != nil {
 		w.L.WithFields(logrus.Fields{
 			"scopes":    scopes,
-			"subject":   session.GetSubject(),
+			"subject":   w.Subjects.Pseudonymize(session.GetSubject()),
 			"client_id": auth.GetClient().GetID(),
 			"request":   a,
 			"reason":    "The policy decision point denied the request",
@@ -93,40 +152,233 @@ func (w *LocalWarden) TokenAllowed(ctx context.Context, token string, a *firewal
 		return nil, err
 	}
 
+	elevated = append(elevated, policyElevated...)
 	c := w.newContext(auth)
-	w.L.WithFields(logrus.Fields{
-		"subject":   c.Subject,
+	w.L.WithFields(withElevations(logrus.Fields{
+		"subject":   w.Subjects.Pseudonymize(c.Subject),
 		"client_id": auth.GetClient().GetID(),
-		"request":   auth,
-		"result":    c,
-	}).Infof("Access granted")
+		"request":   a,
+		"result":    w.loggableContext(c),
+	}, elevated)).Infof("Access granted")
+
+	if w.Cache != nil && len(elevated) == 0 {
+		w.Cache.Add(key, auth.GetID(), c)
+	}
 
 	return c, nil
 }
 
-func (w *LocalWarden) isAllowed(ctx context.Context, a *ladon.Request) error {
-	groups, err := w.Groups.FindGroupsByMember(a.Subject, 10000, 0)
+// peerTokenAllowed is TokenAllowed for tokens issued by one of Peers.
+func (w *LocalWarden) peerTokenAllowed(ctx context.Context, token string, key string, a *firewall.TokenAccessRequest, scopes []string) (*firewall.Context, error) {
+	c, id, err := w.Peers.Authenticate(token, scopes)
 	if err != nil {
-		return err
+		w.L.WithFields(logrus.Fields{
+			"request": a,
+			"scopes":  scopes,
+			"reason":  "Peer token is expired, malformed, not signed by the peer or missing scopes",
+		}).WithError(err).Infof("Access denied")
+		return nil, err
 	}
 
-	errs := make([]error, len(groups)+1)
-	errs[0] = w.Warden.IsAllowed(&ladon.Request{
+	elevated, err := w.isAllowed(ctx, &ladon.Request{
 		Resource: a.Resource,
 		Action:   a.Action,
-		Subject:  a.Subject,
+		Subject:  c.Subject,
 		Context:  a.Context,
 	})
+	if err != nil {
+		w.L.WithFields(logrus.Fields{
+			"scopes":    scopes,
+			"subject":   w.Subjects.Pseudonymize(c.Subject),
+			"client_id": c.ClientID,
+			"issuer":    c.Issuer,
+			"request":   a,
+			"reason":    "The policy decision point denied the request",
+		}).WithError(err).Infof("Access denied")
+		return nil, err
+	}
+
+	w.L.WithFields(withElevations(logrus.Fields{
+		"subject":   w.Subjects.Pseudonymize(c.Subject),
+		"client_id": c.ClientID,
+		"issuer":    c.Issuer,
+		"request":   a,
+		"result":    w.loggableContext(c),
+	}, elevated)).Infof("Access granted to peer token")
 
+	if w.Cache != nil && len(elevated) == 0 {
+		w.Cache.Add(key, id, c)
+	}
+
+	return c, nil
+}
+
+// isAllowed returns nil if the policies or an elevation of the subject allow the request. If an elevation allowed
+// the request, it is returned.
+func (w *LocalWarden) isAllowed(ctx context.Context, a *ladon.Request) ([]elevation.Elevation, error) {
+	start := time.Now()
+	groups, err := w.Groups.FindGroupsByMember(a.Subject, 10000, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	subjects := make([]string, len(groups)+1)
+	subjects[0] = a.Subject
 	for k, g := range groups {
-		errs[k+1] = w.Warden.IsAllowed(&ladon.Request{
-			Resource: a.Resource,
-			Action:   a.Action,
-			Subject:  g.ID,
-			Context:  a.Context,
-		})
+		subjects[k+1] = g.ID
+	}
+
+	var requestID string
+	requestContext := a.Context
+	if w.Decisions != nil {
+		requestID = uuid.New()
+		requestContext = decision.WithRequestID(a.Context, requestID)
+	}
+
+	var forced bool
+	for _, action := range w.ActionGroups.Expand(a.Action) {
+		var errs []error
+		for _, alias := range w.ActionGroups.Aliases(action) {
+			for _, subject := range subjects {
+				r := &ladon.Request{
+					Resource: a.Resource,
+					Action:   alias,
+					Subject:  subject,
+					Context:  requestContext,
+				}
+				errs = append(errs, w.Warden.IsAllowed(r))
+			}
+		}
+
+		if err = decide(errs); err != nil {
+			forced = forcefullyDenied(errs)
+			break
+		}
+	}
+
+	var elevated *elevation.Elevation
+	if err != nil && !forced && w.Elevations != nil {
+		e, findErr := w.findElevation(a)
+		if findErr != nil {
+			if w.Decisions != nil {
+				w.Decisions.Forget(requestID)
+			}
+			return nil, findErr
+		} else if e != nil {
+			elevated, err = e, nil
+		}
+	}
+
+	if w.Decisions != nil && elevated != nil {
+		w.Decisions.RecordElevation(a.Subject, a.Resource, a.Action, elevated.ID, requestID)
+	} else if w.Decisions != nil {
+		w.Decisions.Record(a.Subject, a.Resource, a.Action, err == nil, requestID)
+	}
+	if w.DecisionObserved != nil {
+		w.DecisionObserved(err == nil, time.Since(start))
+	}
+
+	if elevated != nil {
+		return []elevation.Elevation{*elevated}, nil
+	}
+	return nil, err
+}
+
+// findElevation returns an active elevation of the subject allowing the request, or nil if there is none. Action
+// groups are matched like they are matched by policies.
+func (w *LocalWarden) findElevation(a *ladon.Request) (*elevation.Elevation, error) {
+	elevations, err := w.Elevations.FindActiveElevations(a.Subject, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	for k, e := range elevations {
+		allowed := true
+		for _, action := range w.ActionGroups.Expand(a.Action) {
+			var matched bool
+			for _, alias := range w.ActionGroups.Aliases(action) {
+				matched = matched || e.Allows(a.Resource, alias)
+			}
+			allowed = allowed && matched
+		}
+
+		if allowed {
+			return &elevations[k], nil
+		}
+	}
+	return nil, nil
+}
+
+// introspectElevated introspects a token which lacks some of the scopes and checks that active elevations of its
+// subject grant the missing scopes to the client the token was issued to. It returns the elevations which granted
+// them.
+func (w *LocalWarden) introspectElevated(ctx context.Context, token string, scopes []string) (fosite.AccessRequester, []elevation.Elevation, error) {
+	auth, err := w.OAuth2.IntrospectToken(ctx, token, fosite.AccessToken, oauth2.NewSession(""))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	strategy := w.ScopeStrategy
+	if strategy == nil {
+		strategy = fosite.WildcardScopeStrategy
 	}
 
+	elevations, err := w.Elevations.FindActiveElevations(auth.GetSession().GetSubject(), time.Now().UTC())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var granted []elevation.Elevation
+	seen := map[string]bool{}
+	for _, scope := range scopes {
+		if strategy(auth.GetGrantedScopes(), scope) {
+			continue
+		}
+
+		var found bool
+		for _, e := range elevations {
+			if e.GrantsScope(strategy, auth.GetClient().GetID(), scope) {
+				found = true
+				if !seen[e.ID] {
+					seen[e.ID] = true
+					granted = append(granted, e)
+				}
+				break
+			}
+		}
+
+		if !found {
+			return nil, nil, errors.WithStack(fosite.ErrInvalidScope)
+		}
+	}
+
+	return auth, granted, nil
+}
+
+// withElevations adds the ids of the elevations, if any, to the log fields.
+func withElevations(fields logrus.Fields, elevations []elevation.Elevation) logrus.Fields {
+	if len(elevations) == 0 {
+		return fields
+	}
+
+	ids := make([]string, len(elevations))
+	for k, e := range elevations {
+		ids[k] = e.ID
+	}
+	fields["elevations"] = ids
+	return fields
+}
+
+func forcefullyDenied(errs []error) bool {
+	for _, err := range errs {
+		if errors.Cause(err) == ladon.ErrRequestForcefullyDenied {
+			return true
+		}
+	}
+	return false
+}
+
+func decide(errs []error) error {
 	for _, err := range errs {
 		if errors.Cause(err) == ladon.ErrRequestForcefullyDenied {
 			return errors.Wrap(fosite.ErrRequestForbidden, err.Error())
@@ -162,3 +414,17 @@ func (w *LocalWarden) newContext(auth fosite.AccessRequester) *firewall.Context
 
 	return c
 }
+
+// loggableAccessRequest returns a copy of a with the subject pseudonymized.
+func (w *LocalWarden) loggableAccessRequest(a *firewall.AccessRequest) *firewall.AccessRequest {
+	logged := *a
+	logged.Subject = w.Subjects.Pseudonymize(a.Subject)
+	return &logged
+}
+
+// loggableContext returns a copy of c with the subject pseudonymized.
+func (w *LocalWarden) loggableContext(c *firewall.Context) *firewall.Context {
+	logged := *c
+	logged.Subject = w.Subjects.Pseudonymize(c.Subject)
+	return &logged
+}